@@ -0,0 +1,62 @@
+package v1alpha1
+
+import "strings"
+
+// DefaultAccessCommand is the access command rendered for requesters whose
+// platform hint matches none of the accessCommands of the template.
+const DefaultAccessCommand = "kubectl exec -ti -n {{.Namespace}} {{.PodName}} -- /bin/sh"
+
+// DefaultAccessCommandPlatform is the platform of the entry in the
+// accessCommands of a template that is used when the platform hint of the
+// request matches no other entry.
+const DefaultAccessCommandPlatform = "default"
+
+// AccessCommand is the command that requesters on a particular platform (or
+// shell) run to use the access granted to them, which is reported in the
+// status of their Access Request once it is ready.
+type AccessCommand struct {
+	// Platform is the hint (eg, "linux", "windows" or "powershell") that Access Requests
+	// supply in their `spec.platform` field to select this command. It is matched
+	// case-insensitively. The "default" entry is used for requests whose hint matches no
+	// other entry.
+	//
+	// +kubebuilder:validation:Required
+	Platform string `json:"platform"`
+
+	// Command is a Go text/template of the command. It is rendered with the `.Namespace`
+	// and `.PodName` of the target Pod, and the `.Platform` hint of the request (eg,
+	// "kubectl exec -ti -n {{.Namespace}} {{.PodName}} -- powershell.exe").
+	//
+	// +kubebuilder:validation:Required
+	Command string `json:"command"`
+}
+
+// GetAccessCommand returns the command template selected by the supplied
+// platform hint: the entry for that platform, or the "default" entry, or the
+// DefaultAccessCommand.
+func (a *AccessConfig) GetAccessCommand(platform string) string {
+	var fallback string
+	for _, cmd := range a.AccessCommands {
+		if platform != "" && strings.EqualFold(cmd.Platform, platform) {
+			return cmd.Command
+		}
+		if strings.EqualFold(cmd.Platform, DefaultAccessCommandPlatform) && fallback == "" {
+			fallback = cmd.Command
+		}
+	}
+	if fallback != "" {
+		return fallback
+	}
+	return DefaultAccessCommand
+}
+
+// AllowsAccessCommandOverride returns whether the supplied command is one of
+// the accessCommandOverrides of the template.
+func (a *AccessConfig) AllowsAccessCommandOverride(command string) bool {
+	for _, allowed := range a.AccessCommandOverrides {
+		if allowed == command {
+			return true
+		}
+	}
+	return false
+}
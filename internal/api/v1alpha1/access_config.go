@@ -1,7 +1,14 @@
 package v1alpha1
 
 import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
 	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // AccessConfig provides a common interface for our Template structs (which implement
@@ -14,6 +21,38 @@ type AccessConfig struct {
 	// +kubebuilder:validation:Required
 	AllowedGroups []string `json:"allowedGroups"`
 
+	// AllowedDelegators lists out the groups (in string name form) whose members may request
+	// access on behalf of another user, by setting the `spec.requestFor` field of an Access
+	// Request. When empty, nobody can delegate access through this template.
+	//
+	// +kubebuilder:validation:Optional
+	AllowedDelegators []string `json:"allowedDelegators,omitempty"`
+
+	// AllowedRequesters limits which users and groups may create Access Requests against this
+	// template at all - requests from anybody else are rejected, regardless of the RBAC
+	// permissions that the request would grant. When not set, anybody who can create an Access
+	// Request may use this template.
+	//
+	// +kubebuilder:validation:Optional
+	AllowedRequesters *AllowedRequesters `json:"allowedRequesters,omitempty"`
+
+	// NodeSelector restricts access to Nodes carrying all of these labels (for example
+	// `topology.kubernetes.io/zone`, or a node pool label), for region-specific debugging.
+	// ExecAccessTemplates only pick target pods running on matching Nodes, and
+	// PodAccessTemplates schedule their Pods onto them.
+	//
+	// +kubebuilder:validation:Optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// EnvFrom exposes Secrets and ConfigMaps as environment variables in the default container of
+	// the Pods launched by PodAccessTemplates (eg, the credentials of a read-only database user
+	// for debugging). Only the Secrets that the controller allows (with its
+	// `--allowed-env-from-secret` flag) may be referenced - templates referencing any other
+	// Secret are rejected. ConfigMaps are not restricted. Ignored by ExecAccessTemplates.
+	//
+	// +kubebuilder:validation:Optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
 	// DefaultDuration sets the default time that an access request resource will live. Must
 	// be set below MaxDuration.
 	//
@@ -29,6 +68,225 @@ type AccessConfig struct {
 	//
 	// +kubebuilder:default:="24h"
 	MaxDuration string `json:"maxDuration"`
+
+	// Paused prevents any new access requests from being created against this template. Existing
+	// access requests are not affected.
+	//
+	// +kubebuilder:default:=false
+	Paused bool `json:"paused,omitempty"`
+
+	// Parameters declares the variables that Access Requests may supply
+	// through their `spec.parameterValues` field. Requests that do not supply
+	// a value for a required parameter are rejected.
+	//
+	// For PodAccessTemplates, the values are substituted into the
+	// `controllerTargetMutationConfig` (command, args, env, podLabels and
+	// podAnnotations) wherever `${name}` is referenced. For
+	// ExecAccessTemplates, they are substituted into the name of the
+	// `controllerTargetRef` and into the `allowedCommands`.
+	Parameters []TemplateParameter `json:"parameters,omitempty"`
+
+	// RequiredApprovals is the number of distinct users that must approve an Access Request
+	// (with `ozctl approve`) before any access is granted. When zero, requests are granted as
+	// soon as their access resources are ready.
+	//
+	// +kubebuilder:validation:Minimum=0
+	RequiredApprovals int `json:"requiredApprovals,omitempty"`
+
+	// ApprovalRequiredSelector limits the `requiredApprovals` to Access Requests whose target Pod
+	// matches this label selector (eg, Pods labeled for production). Requests for any other Pod
+	// are granted without approval. For PodAccessTemplates the selector is evaluated against the
+	// labels of the Pod that would be created. When not set, every request requires approval.
+	//
+	// +kubebuilder:validation:Optional
+	ApprovalRequiredSelector *metav1.LabelSelector `json:"approvalRequiredSelector,omitempty"`
+
+	// ExternalApproval defers the approval of Access Requests against this template to an
+	// external approval system, which the controller polls until it approves or rejects each
+	// request. When set, it replaces the `requiredApprovals` and `approvalRequiredSelector`.
+	//
+	// +kubebuilder:validation:Optional
+	ExternalApproval *ExternalApprovalConfig `json:"externalApproval,omitempty"`
+
+	// RenewalApprovalThreshold requires a fresh approval before an Access Request that has
+	// already been granted is extended (by raising its `spec.duration`) by more than this long.
+	// Until enough users approve the request again (with `ozctl approve`), the request stays
+	// unapproved and its access still ends when it was originally granted until. Only the users
+	// that approve after the access was granted count - the `requiredApprovals` of them, or one
+	// if the template does not otherwise require approvals. Smaller extensions are granted right
+	// away. When unset, extensions never require a fresh approval.
+	//
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	//
+	// +kubebuilder:validation:Optional
+	RenewalApprovalThreshold string `json:"renewalApprovalThreshold,omitempty"`
+
+	// ClusterRoleRef is the name of an existing ClusterRole that defines the permissions granted
+	// by Access Requests against this template. When set, no Role is created for the request -
+	// only a RoleBinding to this ClusterRole, scoped to the namespace of the request. This allows
+	// permission sets to be curated centrally. The template is marked invalid if the ClusterRole
+	// does not exist. Only the ClusterRoles that the controller allows (with its
+	// `--bindable-cluster-role` flag) may be referenced - templates referencing any other
+	// ClusterRole are rejected.
+	//
+	// +kubebuilder:validation:Optional
+	ClusterRoleRef string `json:"clusterRoleRef,omitempty"`
+
+	// VerbsByGroup tunes the verbs that Access Requests against this template grant on the
+	// `pods/exec` subresource of their target Pod, by the groups of the user that created the
+	// request. A requester in several of the groups is granted the verbs of all of them, and a
+	// requester in none of them is granted the default verbs (create, update, delete, get and
+	// list). An empty list of verbs grants read-only access to the Pod, without exec. Eg:
+	//
+	//   verbsByGroup:
+	//     senior-engineers: [create, update, delete, get, list]
+	//     junior-engineers: []
+	//
+	// Requests made on behalf of another user (`requestFor`), or transferred to one (`transferTo`),
+	// are bound to that user, whose groups are not known. They are only granted the verbs that
+	// every group in the list (and the default) allows.
+	//
+	// Any other request is bound to the user that created it alone, rather than to the
+	// allowedGroups, so that the verbs granted to one group never reach the members of another.
+	//
+	// Ignored when the `clusterRoleRef` is set.
+	//
+	// +kubebuilder:validation:Optional
+	VerbsByGroup map[string][]string `json:"verbsByGroup,omitempty"`
+
+	// ExpireAtEndOfDay, when set, ends the access granted by an Access Request at the next
+	// midnight (in the configured time zone) after the request was created, if that comes
+	// before the end of its requested duration.
+	//
+	// +kubebuilder:validation:Optional
+	ExpireAtEndOfDay *EndOfDayConfig `json:"expireAtEndOfDay,omitempty"`
+
+	// IncidentDurations, when set, replaces the `defaultDuration` and `maxDuration` of this
+	// template while an incident is declared in its namespace (with the `incidentMode` of the
+	// OzConfig), so that responders can be granted longer access than usual.
+	//
+	// +kubebuilder:validation:Optional
+	IncidentDurations *DurationProfile `json:"incidentDurations,omitempty"`
+
+	// RequireRevokeReason, when true, rejects any revocation of an Access Request (with `ozctl
+	// revoke`) that does not state a reason. The reason is recorded in the audit log.
+	//
+	// +kubebuilder:default:=false
+	RequireRevokeReason bool `json:"requireRevokeReason,omitempty"`
+
+	// ExpirationGracePeriod keeps an expired (or revoked) Access Request around for this long
+	// after its access ends, for visibility and auditing. The access resources are removed as
+	// soon as the request expires - only the request object itself lingers. When unset, expired
+	// requests are deleted right away.
+	//
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	//
+	// +kubebuilder:validation:Optional
+	ExpirationGracePeriod string `json:"expirationGracePeriod,omitempty"`
+
+	// AllowedCommands limits the commands that the users granted access through this template may
+	// run with `kubectl exec`. Each entry is matched against the program being executed (the
+	// first element of the command), either as a full path (`/bin/ls`) or as a bare program name
+	// (`ls`) that matches it in any directory. When empty, any command may be run.
+	//
+	// This is a best-effort guardrail enforced by the admission webhook on `pods/exec`, not a
+	// security boundary: RBAC itself cannot restrict commands, allowing any shell or interpreter
+	// allows everything, and users who have exec access through other means are not restricted.
+	//
+	// +kubebuilder:validation:Optional
+	AllowedCommands []string `json:"allowedCommands,omitempty"`
+
+	// MaxGrantsPerPod caps how many Access Requests may hold access to the same target Pod at
+	// once, to protect a fragile Pod from being overwhelmed by exec sessions. Requests beyond
+	// this cap are queued until a grant on the Pod expires. Grants through other templates
+	// count towards the cap too. It only applies to ExecAccessTemplates. When unset, there is
+	// no limit.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	MaxGrantsPerPod int `json:"maxGrantsPerPod,omitempty"`
+
+	// FallbackTemplate names another ExecAccessTemplate in the same namespace to fall back
+	// to when the target of this template has no live Pods to pick from, so that responders
+	// are not stuck waiting on it. Access is then granted through the fallback template (its
+	// target, permissions and access command), and the name of it is recorded in the
+	// status.fallbackTemplate field of the request. The fallbackTemplate of the fallback
+	// template is not followed. It only applies to ExecAccessTemplates, and to requests that
+	// did not name their own targetPod.
+	//
+	// +kubebuilder:validation:Optional
+	FallbackTemplate string `json:"fallbackTemplate,omitempty"`
+
+	// SyncTemplateMetadata, when true, copies the labels and annotations of this template onto
+	// the Roles and RoleBindings created for its Access Requests. They are re-applied on every
+	// reconcile of a request, so changes to the metadata of the template reach the RBAC of the
+	// access that is already granted - keeping audit tooling that keys off of them consistent.
+	//
+	// +kubebuilder:default:=false
+	SyncTemplateMetadata bool `json:"syncTemplateMetadata,omitempty"`
+
+	// NamespacePattern shares this template with the Access Requests in every namespace
+	// matching the glob pattern (eg, "team-*"), so that one template can serve many namespaces
+	// without being copied into each of them. Those requests name the template with their
+	// Spec.templateNamespace field. The targetRef is looked up, and the RBAC resources are
+	// created, in the namespace of each request. When unset, the template only serves its own
+	// namespace.
+	//
+	// A namespace only accepts the template if the `allowedTemplateNamespaces` of its OzConfig
+	// lists the namespace of the template. Patterns matching the reserved `kube-*` namespaces
+	// are rejected.
+	//
+	// +kubebuilder:validation:Optional
+	NamespacePattern string `json:"namespacePattern,omitempty"`
+
+	// AllowedNamespaces limits the namespaces whose Access Requests may use this template to
+	// those matching any of the glob patterns (eg, "team-a-*"). It narrows which of the
+	// namespaces a shared template (see namespacePattern) serves may request access through it,
+	// and applies to requests in the namespace of the template itself as well. Invalid patterns
+	// match no namespace. When empty, requests from every namespace the template serves are
+	// allowed.
+	//
+	// +kubebuilder:validation:Optional
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+
+	// IdleTimeout keeps the access of a request that is still in use from expiring. The
+	// access is in use while it has been active (see the `crds.wizardofoz.co/last-active`
+	// annotation, which is stamped on each exec into the target Pod, or may be stamped by a
+	// heartbeat running as one of the `--last-active-writer` users of the controller) within
+	// this long. Its expiration is then deferred until it has been idle for this long, but
+	// never past the maxDuration of the template. When unset, access expires on time
+	// regardless of whether it is in use.
+	//
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	//
+	// +kubebuilder:validation:Optional
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+
+	// AccessCommands customizes the command reported to requesters for using their access,
+	// per platform (or shell) - eg, a Windows user and a Linux user may need different
+	// commands. Access Requests select one with their `spec.platform` hint. When no entry
+	// matches, the "default" entry (or the built-in `kubectl exec` command) is used.
+	//
+	// +kubebuilder:validation:Optional
+	AccessCommands []AccessCommand `json:"accessCommands,omitempty"`
+
+	// AccessCommandOverrides lists the commands that Access Requests may pick with their
+	// `spec.accessCommandOverride` to replace the command reported to them (eg, the same
+	// `kubectl exec` with a different shell), without a template of their own. Each entry is a
+	// Go text/template, rendered like the accessCommands. Requests asking for any other
+	// override are rejected. When empty, no overrides are allowed.
+	//
+	// +kubebuilder:validation:Optional
+	AccessCommandOverrides []string `json:"accessCommandOverrides,omitempty"`
+
+	// JustificationPattern requires the Access Requests against this template to state a
+	// `spec.justification` matching this regular expression (eg `JIRA-\d+`, or a URL of the
+	// ticket tracker), so that the audit trail links every grant to a ticket in a consistent
+	// format. The pattern has to match the whole justification. Requests without a conforming
+	// justification are rejected. When unset, the justification is optional and free-form.
+	//
+	// +kubebuilder:validation:Optional
+	JustificationPattern string `json:"justificationPattern,omitempty"`
 }
 
 // GetAllowedGroups returns the Spec.AllowedGroups for this particular template
@@ -36,6 +294,68 @@ func (a *AccessConfig) GetAllowedGroups() []string {
 	return a.AllowedGroups
 }
 
+// GetAllowedCommands returns the Spec.AllowedCommands for this particular template
+func (a *AccessConfig) GetAllowedCommands() []string {
+	return a.AllowedCommands
+}
+
+// IsCommandAllowed returns true if the supplied exec command may be run under
+// this template's AllowedCommands. An empty AllowedCommands list allows any
+// command.
+func (a *AccessConfig) IsCommandAllowed(command []string) bool {
+	if len(a.AllowedCommands) == 0 {
+		return true
+	}
+	if len(command) == 0 {
+		return false
+	}
+	program := command[0]
+	for _, allowed := range a.AllowedCommands {
+		if allowed == program || (!strings.Contains(allowed, "/") && allowed == path.Base(program)) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAllowedDelegators returns the Spec.AllowedDelegators for this particular template
+func (a *AccessConfig) GetAllowedDelegators() []string {
+	return a.AllowedDelegators
+}
+
+// GetAllowedRequesters returns the Spec.allowedRequesters field for this particular template
+func (a *AccessConfig) GetAllowedRequesters() *AllowedRequesters {
+	return a.AllowedRequesters
+}
+
+// GetNodeSelector returns the Spec.NodeSelector for this particular template
+func (a *AccessConfig) GetNodeSelector() map[string]string {
+	return a.NodeSelector
+}
+
+// GetEnvFrom returns the Spec.envFrom field for this particular template
+func (a *AccessConfig) GetEnvFrom() []corev1.EnvFromSource {
+	return a.EnvFrom
+}
+
+// GetDisallowedEnvFromSecrets returns the names of the Secrets referenced by
+// Spec.envFrom that are not in the supplied list of allowed Secret names, in
+// order. ConfigMaps are never disallowed.
+func (a *AccessConfig) GetDisallowedEnvFromSecrets(allowed []string) []string {
+	allowedSet := map[string]bool{}
+	for _, name := range allowed {
+		allowedSet[name] = true
+	}
+	disallowed := []string{}
+	for _, src := range a.EnvFrom {
+		if src.SecretRef == nil || allowedSet[src.SecretRef.Name] {
+			continue
+		}
+		disallowed = append(disallowed, src.SecretRef.Name)
+	}
+	return disallowed
+}
+
 // GetDefaultDuration parses the Spec.defaultDuration field into a time.Duration struct.
 //
 // Returns:
@@ -55,3 +375,252 @@ func (a *AccessConfig) GetDefaultDuration() (time.Duration, error) {
 func (a *AccessConfig) GetMaxDuration() (time.Duration, error) {
 	return time.ParseDuration(a.MaxDuration)
 }
+
+// GetExternalApproval returns the Spec.externalApproval field for this particular template
+func (a *AccessConfig) GetExternalApproval() *ExternalApprovalConfig {
+	return a.ExternalApproval
+}
+
+// GetIncidentDurations returns the Spec.incidentDurations field for this particular template
+func (a *AccessConfig) GetIncidentDurations() *DurationProfile {
+	return a.IncidentDurations
+}
+
+// IsPaused returns the Spec.paused field for this particular template
+func (a *AccessConfig) IsPaused() bool {
+	return a.Paused
+}
+
+// GetParameters returns the Spec.parameters field for this particular template
+func (a *AccessConfig) GetParameters() []TemplateParameter {
+	return a.Parameters
+}
+
+// GetRequiredApprovals returns the Spec.requiredApprovals field for this particular template
+func (a *AccessConfig) GetRequiredApprovals() int {
+	return a.RequiredApprovals
+}
+
+// GetApprovalRequiredSelector returns the Spec.approvalRequiredSelector field for this particular template
+func (a *AccessConfig) GetApprovalRequiredSelector() *metav1.LabelSelector {
+	return a.ApprovalRequiredSelector
+}
+
+// GetRenewalApprovalThreshold parses the Spec.renewalApprovalThreshold field into a
+// time.Duration struct. An unset threshold is returned as zero.
+//
+// Returns:
+//
+//	time.Duration: Populated struct (or zero, if unset or error)
+//	error: If any error occurs in the parsing, the error is returned
+func (a *AccessConfig) GetRenewalApprovalThreshold() (time.Duration, error) {
+	if a.RenewalApprovalThreshold == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(a.RenewalApprovalThreshold)
+}
+
+// GetMaxGrantsPerPod returns the Spec.maxGrantsPerPod field for this particular template
+func (a *AccessConfig) GetMaxGrantsPerPod() int {
+	return a.MaxGrantsPerPod
+}
+
+// GetFallbackTemplate returns the Spec.fallbackTemplate field for this particular template
+func (a *AccessConfig) GetFallbackTemplate() string {
+	return a.FallbackTemplate
+}
+
+// IsTemplateMetadataSynced returns the Spec.syncTemplateMetadata field for this particular template
+func (a *AccessConfig) IsTemplateMetadataSynced() bool {
+	return a.SyncTemplateMetadata
+}
+
+// GetNamespacePattern returns the Spec.namespacePattern field for this particular template
+func (a *AccessConfig) GetNamespacePattern() string {
+	return a.NamespacePattern
+}
+
+// ReservedNamespaces are the system namespaces that a template can never be
+// shared with through its Spec.namespacePattern.
+var ReservedNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// isReservedNamespace returns true for the ReservedNamespaces, and for any
+// other namespace with the "kube-" prefix that Kubernetes reserves.
+func isReservedNamespace(namespace string) bool {
+	return strings.HasPrefix(namespace, "kube-")
+}
+
+// ValidateNamespacePattern returns an error if the Spec.namespacePattern field
+// is not a valid glob pattern, or if it matches any of the ReservedNamespaces.
+func (a *AccessConfig) ValidateNamespacePattern() error {
+	if _, err := path.Match(a.NamespacePattern, ""); err != nil {
+		return fmt.Errorf("namespacePattern %q is invalid: %w", a.NamespacePattern, err)
+	}
+	if a.NamespacePattern == "" {
+		return nil
+	}
+	for _, namespace := range ReservedNamespaces {
+		if matched, _ := path.Match(a.NamespacePattern, namespace); matched {
+			return fmt.Errorf("namespacePattern %q matches the reserved namespace %s",
+				a.NamespacePattern, namespace)
+		}
+	}
+	return nil
+}
+
+// SharesWithNamespace returns true if the Access Requests in the supplied
+// namespace may use this template from another namespace, as far as the
+// template is concerned - only if Spec.namespacePattern is set, valid and
+// matches the namespace, and the namespace is not reserved. The namespace must
+// still accept the template (see OzConfig.AcceptsTemplatesFrom).
+func (a *AccessConfig) SharesWithNamespace(namespace string) bool {
+	if a.NamespacePattern == "" || isReservedNamespace(namespace) || a.ValidateNamespacePattern() != nil {
+		return false
+	}
+	matched, err := path.Match(a.NamespacePattern, namespace)
+	return err == nil && matched
+}
+
+// GetAllowedNamespaces returns the Spec.allowedNamespaces field for this particular template
+func (a *AccessConfig) GetAllowedNamespaces() []string {
+	return a.AllowedNamespaces
+}
+
+// AllowsNamespace returns true if the Access Requests in the supplied
+// namespace may use this template - if Spec.allowedNamespaces is empty, or the
+// namespace matches any of its (valid) patterns.
+func (a *AccessConfig) AllowsNamespace(namespace string) bool {
+	if len(a.AllowedNamespaces) == 0 {
+		return true
+	}
+	for _, pattern := range a.AllowedNamespaces {
+		if matched, err := path.Match(pattern, namespace); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// GetClusterRoleRef returns the Spec.clusterRoleRef field for this particular template
+func (a *AccessConfig) GetClusterRoleRef() string {
+	return a.ClusterRoleRef
+}
+
+// IsClusterRoleRefBindable returns true if Spec.clusterRoleRef is unset, or is
+// in the supplied list of bindable ClusterRole names.
+func (a *AccessConfig) IsClusterRoleRefBindable(bindable []string) bool {
+	if a.ClusterRoleRef == "" {
+		return true
+	}
+	for _, name := range bindable {
+		if name == a.ClusterRoleRef {
+			return true
+		}
+	}
+	return false
+}
+
+// GetVerbsByGroup returns the Spec.verbsByGroup field for this particular template
+func (a *AccessConfig) GetVerbsByGroup() map[string][]string {
+	return a.VerbsByGroup
+}
+
+// GetExecVerbs resolves the Spec.verbsByGroup field for a requester in the
+// supplied groups. The verbs of every matching group are merged, in sorted
+// order.
+//
+// Returns:
+//   - The verbs to grant on the `pods/exec` subresource
+//   - false if the requester is in none of the groups, and the default verbs
+//     apply
+func (a *AccessConfig) GetExecVerbs(groups []string) ([]string, bool) {
+	matched := false
+	verbs := map[string]bool{}
+	for _, group := range groups {
+		groupVerbs, ok := a.VerbsByGroup[group]
+		if !ok {
+			continue
+		}
+		matched = true
+		for _, verb := range groupVerbs {
+			verbs[verb] = true
+		}
+	}
+	if !matched {
+		return nil, false
+	}
+
+	ret := make([]string, 0, len(verbs))
+	for verb := range verbs {
+		ret = append(ret, verb)
+	}
+	sort.Strings(ret)
+	return ret, true
+}
+
+// DefaultExecVerbs are the verbs granted on the `pods/exec` subresource of the
+// target Pods, unless the verbsByGroup of the template says otherwise.
+var DefaultExecVerbs = []string{"create", "update", "delete", "get", "list"}
+
+// GetAllowedExecVerbs returns the verbs on the `pods/exec` subresource that a
+// requester in the supplied groups may be granted through this template - the
+// verbs of their groups in Spec.verbsByGroup, or the DefaultExecVerbs.
+func (a *AccessConfig) GetAllowedExecVerbs(groups []string) []string {
+	if verbs, ok := a.GetExecVerbs(groups); ok {
+		return verbs
+	}
+	return DefaultExecVerbs
+}
+
+// GetCommonExecVerbs returns the verbs on the `pods/exec` subresource that any
+// user is granted through this template, whatever their groups - the
+// DefaultExecVerbs that every group in Spec.verbsByGroup also allows. It is
+// used for users whose groups are not known.
+func (a *AccessConfig) GetCommonExecVerbs() []string {
+	verbs := DefaultExecVerbs
+	for _, groupVerbs := range a.VerbsByGroup {
+		if len(groupVerbs) == 0 {
+			return []string{}
+		}
+		verbs = NarrowExecVerbs(verbs, groupVerbs)
+	}
+	return verbs
+}
+
+// GetExpireAtEndOfDay returns the Spec.expireAtEndOfDay field for this particular template
+func (a *AccessConfig) GetExpireAtEndOfDay() *EndOfDayConfig {
+	return a.ExpireAtEndOfDay
+}
+
+// IsRevokeReasonRequired returns the Spec.requireRevokeReason field for this particular template
+func (a *AccessConfig) IsRevokeReasonRequired() bool {
+	return a.RequireRevokeReason
+}
+
+// GetIdleTimeout parses the Spec.idleTimeout field into a time.Duration
+// struct. An unset idle timeout is returned as zero.
+//
+// Returns:
+//
+//	time.Duration: Populated struct (or zero, if unset or error)
+//	error: If any error occurs in the parsing, the error is returned
+func (a *AccessConfig) GetIdleTimeout() (time.Duration, error) {
+	if a.IdleTimeout == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(a.IdleTimeout)
+}
+
+// GetExpirationGracePeriod parses the Spec.expirationGracePeriod field into a time.Duration
+// struct. An unset grace period is returned as zero.
+//
+// Returns:
+//
+//	time.Duration: Populated struct (or zero, if unset or error)
+//	error: If any error occurs in the parsing, the error is returned
+func (a *AccessConfig) GetExpirationGracePeriod() (time.Duration, error) {
+	if a.ExpirationGracePeriod == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(a.ExpirationGracePeriod)
+}
@@ -0,0 +1,187 @@
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("AccessConfig", func() {
+	Context("IsCommandAllowed()", func() {
+		It("Should allow anything without an allowlist", func() {
+			cfg := &AccessConfig{}
+			Expect(cfg.IsCommandAllowed([]string{"/bin/sh"})).To(BeTrue())
+		})
+
+		cfg := &AccessConfig{AllowedCommands: []string{"ls", "/bin/cat"}}
+
+		It("Should match bare program names in any directory", func() {
+			Expect(cfg.IsCommandAllowed([]string{"ls", "-l"})).To(BeTrue())
+			Expect(cfg.IsCommandAllowed([]string{"/usr/bin/ls"})).To(BeTrue())
+		})
+
+		It("Should match full paths exactly", func() {
+			Expect(cfg.IsCommandAllowed([]string{"/bin/cat"})).To(BeTrue())
+			Expect(cfg.IsCommandAllowed([]string{"cat"})).To(BeFalse())
+			Expect(cfg.IsCommandAllowed([]string{"/tmp/cat"})).To(BeFalse())
+		})
+
+		It("Should deny anything else", func() {
+			Expect(cfg.IsCommandAllowed([]string{"/bin/sh"})).To(BeFalse())
+			Expect(cfg.IsCommandAllowed([]string{})).To(BeFalse())
+		})
+	})
+
+	Context("SharesWithNamespace()", func() {
+		It("Should not share a template without a namespacePattern", func() {
+			cfg := &AccessConfig{}
+			Expect(cfg.SharesWithNamespace("team-a")).To(BeFalse())
+		})
+
+		cfg := &AccessConfig{NamespacePattern: "team-*"}
+
+		It("Should share with the namespaces matching the pattern", func() {
+			Expect(cfg.SharesWithNamespace("team-a")).To(BeTrue())
+			Expect(cfg.SharesWithNamespace("team-b")).To(BeTrue())
+		})
+
+		It("Should not share with any other namespace", func() {
+			Expect(cfg.SharesWithNamespace("kube-system")).To(BeFalse())
+			Expect(cfg.SharesWithNamespace("my-team-a")).To(BeFalse())
+		})
+
+		It("Should never share with a reserved namespace", func() {
+			broad := &AccessConfig{NamespacePattern: "*"}
+			Expect(broad.ValidateNamespacePattern()).To(MatchError(
+				`namespacePattern "*" matches the reserved namespace kube-system`,
+			))
+			Expect(broad.SharesWithNamespace("kube-system")).To(BeFalse())
+			Expect(broad.SharesWithNamespace("team-a")).To(BeFalse())
+
+			kube := &AccessConfig{NamespacePattern: "kube-sys*"}
+			Expect(kube.ValidateNamespacePattern()).ToNot(Succeed())
+			Expect(kube.SharesWithNamespace("kube-system")).To(BeFalse())
+		})
+
+		It("Should never share through an invalid pattern", func() {
+			bad := &AccessConfig{NamespacePattern: "team-["}
+			Expect(bad.ValidateNamespacePattern()).ToNot(Succeed())
+			Expect(bad.SharesWithNamespace("team-[")).To(BeFalse())
+			Expect(cfg.ValidateNamespacePattern()).To(Succeed())
+		})
+	})
+
+	Context("AllowsNamespace()", func() {
+		It("Should allow every namespace without allowedNamespaces", func() {
+			cfg := &AccessConfig{}
+			Expect(cfg.AllowsNamespace("kube-system")).To(BeTrue())
+		})
+
+		cfg := &AccessConfig{AllowedNamespaces: []string{"team-a-*", "shared", "team-["}}
+
+		It("Should allow the namespaces matching any of the patterns", func() {
+			Expect(cfg.AllowsNamespace("team-a-web")).To(BeTrue())
+			Expect(cfg.AllowsNamespace("shared")).To(BeTrue())
+		})
+
+		It("Should not allow any other namespace", func() {
+			Expect(cfg.AllowsNamespace("team-b-web")).To(BeFalse())
+			Expect(cfg.AllowsNamespace("shared-2")).To(BeFalse())
+			Expect(cfg.AllowsNamespace("team-[")).To(BeFalse())
+		})
+	})
+
+	Context("GetDisallowedEnvFromSecrets()", func() {
+		cfg := &AccessConfig{EnvFrom: []corev1.EnvFromSource{
+			{SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-readonly"},
+			}},
+			{ConfigMapRef: &corev1.ConfigMapEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-settings"},
+			}},
+			{SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "db-admin"},
+			}},
+		}}
+
+		It("Should return the Secrets that are not allowed", func() {
+			Expect(cfg.GetDisallowedEnvFromSecrets([]string{"db-readonly"})).
+				To(Equal([]string{"db-admin"}))
+			Expect(cfg.GetDisallowedEnvFromSecrets(nil)).
+				To(Equal([]string{"db-readonly", "db-admin"}))
+		})
+
+		It("Should return nothing once all of the Secrets are allowed", func() {
+			Expect(cfg.GetDisallowedEnvFromSecrets([]string{"db-admin", "db-readonly"})).To(BeEmpty())
+			Expect((&AccessConfig{}).GetDisallowedEnvFromSecrets(nil)).To(BeEmpty())
+		})
+	})
+
+	Context("validateRequestingNamespace()", func() {
+		tmpl := &ExecAccessTemplate{}
+		tmpl.Name = "shared"
+		tmpl.Spec.AccessConfig.AllowedNamespaces = []string{"team-a-*"}
+
+		It("Should accept a request from an allowed namespace", func() {
+			req := &ExecAccessRequest{}
+			req.Namespace = "team-a-web"
+			Expect(validateRequestingNamespace(req, tmpl)).To(Succeed())
+		})
+
+		It("Should reject a request from any other namespace", func() {
+			req := &ExecAccessRequest{}
+			req.Namespace = "team-b-web"
+			err := validateRequestingNamespace(req, tmpl)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"error - template shared does not allow requests from namespace team-b-web (allowed: team-a-*)",
+			))
+		})
+	})
+
+	Context("ValidateJustification() / validateJustification()", func() {
+		tmpl := &ExecAccessTemplate{}
+		tmpl.Name = "ticketed"
+		tmpl.Spec.AccessConfig.JustificationPattern = `JIRA-\d+`
+
+		withJustification := func(justification string) *ExecAccessRequest {
+			req := &ExecAccessRequest{}
+			req.Spec.Justification = justification
+			return req
+		}
+
+		It("Should accept any justification without a pattern", func() {
+			Expect((&AccessConfig{}).ValidateJustification("")).To(Succeed())
+			Expect((&AccessConfig{}).ValidateJustification("because")).To(Succeed())
+		})
+
+		It("Should accept a conforming justification", func() {
+			Expect(validateJustification(withJustification("JIRA-1234"), tmpl)).To(Succeed())
+		})
+
+		It("Should reject a non-conforming justification", func() {
+			err := validateJustification(withJustification("JIRA-1234 and more"), tmpl)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				`error - template ticketed: justification "JIRA-1234 and more" does not match "JIRA-\\d+"`,
+			))
+
+			err = validateJustification(withJustification("jira-1234"), tmpl)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should reject a missing justification", func() {
+			err := validateJustification(withJustification(""), tmpl)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				`error - template ticketed: a justification matching "JIRA-\\d+" is required`,
+			))
+		})
+
+		It("Should reject every justification against an invalid pattern", func() {
+			cfg := &AccessConfig{JustificationPattern: `JIRA-(\d+`}
+			Expect(cfg.ValidateJustificationPattern()).To(HaveOccurred())
+			Expect(cfg.ValidateJustification("JIRA-1234")).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,900 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/diranged/oz/internal/audit"
+)
+
+// webhookReader is a non-cached client.Reader populated by the
+// SetupWebhookWithManager() functions. It is used by the validating webhooks
+// to look up the Access Templates that the Access Requests are referencing.
+var webhookReader client.Reader
+
+// LastActiveWriters lists the users (eg, the service account of the
+// controller, or of a heartbeat) that may write the LastActiveAnnotation of
+// Access Requests. It is populated from the `--last-active-writer` flags of
+// the controller, before the webhooks are set up.
+var LastActiveWriters []string
+
+// LastActiveClockSkew is how far ahead of the local clock a
+// LastActiveAnnotation may be before GetLastActive ignores it, to absorb the
+// skew between the clocks of its writers and the controller.
+const LastActiveClockSkew = time.Minute
+
+// ValidateAccessRequest runs the checks that the validating webhooks apply to
+// a new Access Request against the supplied (already fetched) template. It is
+// exported so that the same decisions can be predicted without creating the
+// request.
+//
+// Returns:
+//   - An "error" describing why the request would be rejected
+func ValidateAccessRequest(
+	log logr.Logger,
+	req admission.Request,
+	obj IRequestResource,
+	tmpl ITemplateResource,
+) error {
+	if err := validateTemplateAcceptsRequests(obj, tmpl, nil); err != nil {
+		return err
+	}
+	if err := validateRequester(req, tmpl); err != nil {
+		return err
+	}
+	if err := validateRequestingNamespace(obj, tmpl); err != nil {
+		return err
+	}
+	if err := validateRequestedVerbs(req, obj, tmpl); err != nil {
+		return err
+	}
+	if err := validateAccessCommandOverride(obj, tmpl); err != nil {
+		return err
+	}
+	if err := validateJustification(obj, tmpl); err != nil {
+		return err
+	}
+	return validateDelegation(log, req, obj, tmpl)
+}
+
+// validateRequester verifies that the creator of a new Access Request is one
+// of the template's allowedRequesters, if the template limits them.
+//
+// Returns:
+//   - An "error" if the requester may not use the template
+func validateRequester(req admission.Request, tmpl ITemplateResource) error {
+	allowed := tmpl.GetAccessConfig().GetAllowedRequesters()
+	if allowed == nil || allowed.Allows(req.UserInfo.Username, req.UserInfo.Groups) {
+		return nil
+	}
+	return fmt.Errorf(
+		"error - %s is not an allowed requester of template %s",
+		req.UserInfo.Username, tmpl.GetName(),
+	)
+}
+
+// validateRequestingNamespace verifies that the namespace of a new Access
+// Request is one of the allowedNamespaces of the template, if the template
+// limits them.
+//
+// Returns:
+//   - An "error" if requests from the namespace may not use the template
+func validateRequestingNamespace(obj IRequestResource, tmpl ITemplateResource) error {
+	cfg := tmpl.GetAccessConfig()
+	if cfg.AllowsNamespace(obj.GetNamespace()) {
+		return nil
+	}
+	return fmt.Errorf(
+		"error - template %s does not allow requests from namespace %s (allowed: %s)",
+		tmpl.GetName(), obj.GetNamespace(), strings.Join(cfg.GetAllowedNamespaces(), ", "),
+	)
+}
+
+// validateRequestedVerbs verifies that the `spec.requestedVerbs` of a new
+// Access Request only narrows the verbs that the template allows its creator
+// on the `pods/exec` subresource. Templates granting a ClusterRole cannot be
+// narrowed this way.
+//
+// Returns:
+//   - An "error" if any of the requested verbs is not allowed
+func validateRequestedVerbs(req admission.Request, obj IRequestResource, tmpl ITemplateResource) error {
+	requested := obj.GetRequestedVerbs()
+	if len(requested) == 0 {
+		return nil
+	}
+	cfg := tmpl.GetAccessConfig()
+	if name := cfg.GetClusterRoleRef(); name != "" {
+		return fmt.Errorf(
+			"error - template %s grants the permissions of ClusterRole %s, which requestedVerbs cannot narrow",
+			tmpl.GetName(), name,
+		)
+	}
+	allowed := cfg.GetAllowedExecVerbs(req.UserInfo.Groups)
+	if disallowed := disallowedVerbs(allowed, requested); len(disallowed) > 0 {
+		return fmt.Errorf(
+			"error - template %s does not allow %s on pods/exec (allowed: %s)",
+			tmpl.GetName(), strings.Join(disallowed, ", "), strings.Join(allowed, ", "),
+		)
+	}
+	return nil
+}
+
+// validateAccessCommandOverride verifies that the `spec.accessCommandOverride`
+// of a new Access Request, if any, is one of the accessCommandOverrides that
+// the template allows.
+//
+// Returns:
+//   - An "error" if the override is not allowed by the template
+func validateAccessCommandOverride(obj IRequestResource, tmpl ITemplateResource) error {
+	override := obj.GetAccessCommandOverride()
+	if override == "" || tmpl.GetAccessConfig().AllowsAccessCommandOverride(override) {
+		return nil
+	}
+	return fmt.Errorf(
+		"error - template %s does not allow the access command override %q",
+		tmpl.GetName(), override,
+	)
+}
+
+// validateJustification verifies that the `spec.justification` of a new
+// Access Request conforms to the justificationPattern of the template, if it
+// sets one.
+//
+// Returns:
+//   - An "error" if the justification is missing or does not conform
+func validateJustification(obj IRequestResource, tmpl ITemplateResource) error {
+	if err := tmpl.GetAccessConfig().ValidateJustification(obj.GetJustification()); err != nil {
+		return fmt.Errorf("error - template %s: %w", tmpl.GetName(), err)
+	}
+	return nil
+}
+
+// immutableField is a single Spec field of an Access Request that can not be
+// changed once access has been granted, with its old and new values.
+type immutableField struct {
+	name     string
+	old, new interface{}
+}
+
+// validateImmutableAfterGrant verifies that an update to an Access Request
+// does not change any of the supplied fields once its access resources have
+// been created. Until then the request is still being evaluated, and may be
+// edited freely. The Spec.duration field is deliberately never passed in, so
+// that granted access can still be extended.
+//
+// Returns:
+//   - An "error" naming the first field that was changed after the grant
+func validateImmutableAfterGrant(
+	kind string,
+	oldReq IRequestResource,
+	fields ...immutableField,
+) error {
+	if !meta.IsStatusConditionTrue(
+		*oldReq.GetStatus().GetConditions(),
+		ConditionAccessResourcesCreated.String(),
+	) {
+		return nil
+	}
+	for _, f := range fields {
+		if !equality.Semantic.DeepEqual(f.old, f.new) {
+			return fmt.Errorf(
+				"error - Spec.%s can not be changed once access has been granted, create a new %s instead",
+				f.name, kind,
+			)
+		}
+	}
+	return nil
+}
+
+// validateTemplateRef verifies that an update to an Access Request does not
+// change the template that it was created against. Unlike the fields passed
+// to validateImmutableAfterGrant(), this holds from creation: pointing a
+// pending request at another template would sidestep the checks that it was
+// admitted with.
+//
+// Returns:
+//   - An "error" if the Spec.templateName or Spec.templateNamespace changed
+func validateTemplateRef(kind string, oldReq IRequestResource, newReq IRequestResource) error {
+	if oldReq.GetTemplateName() == newReq.GetTemplateName() &&
+		oldReq.GetTemplateNamespace() == newReq.GetTemplateNamespace() {
+		return nil
+	}
+	return fmt.Errorf(
+		"error - Spec.TemplateName and Spec.TemplateNamespace are immutable fields, create a new %s instead",
+		kind,
+	)
+}
+
+// revalidateAccessRequest re-runs ValidateAccessRequest() for an update that
+// changes the Spec of an Access Request, against its template as it is now -
+// so that a request edited before its access is granted (eg, its
+// parameterValues or requestedVerbs) is held to the same checks as a new one.
+// The request is validated as the user that created it, recorded in the
+// RequestedByAnnotation of the old object, rather than as the user making the
+// update. Only if none was recorded is the updating user validated instead.
+//
+// Returns:
+//   - An "error" describing why the updated request would be rejected
+func revalidateAccessRequest(
+	req admission.Request,
+	oldReq IRequestResource,
+	newReq IRequestResource,
+	tmpl ITemplateResource,
+) error {
+	if requester := GetRequester(oldReq); requester != "" {
+		req.UserInfo = authenticationv1.UserInfo{
+			Username: requester,
+			Groups:   GetRequesterGroups(oldReq),
+		}
+	}
+
+	// Any delegation was already written to the audit log on creation.
+	return ValidateAccessRequest(logr.Discard(), req, newReq, tmpl)
+}
+
+// validateTemplateAcceptsRequests verifies that the supplied ITemplateResource
+// is in a state where new Access Requests can be created against it. The
+// supplied error is the result of fetching the template - if it is set (for
+// example, a NotFound error) it is returned as-is so that the API server can
+// pass the status back to the user.
+//
+// Returns:
+//   - An "error" if the template is missing, paused or not valid, or if the
+//     request does not satisfy the parameters declared by the template
+func validateTemplateAcceptsRequests(
+	req IRequestResource,
+	tmpl ITemplateResource,
+	err error,
+) error {
+	if err != nil {
+		return err
+	}
+
+	if tmpl.GetAccessConfig().IsPaused() {
+		return fmt.Errorf(
+			"error - template %s is paused, new access requests are not accepted",
+			tmpl.GetName(),
+		)
+	}
+
+	// We only reject the request if the template has explicitly been marked
+	// invalid. A template that has not been reconciled yet gets the benefit of
+	// the doubt, and the request reconciler will catch any problems.
+	cond := meta.FindStatusCondition(
+		*tmpl.GetStatus().GetConditions(),
+		ConditionTemplateValid.String(),
+	)
+	if cond != nil && cond.Status == metav1.ConditionFalse {
+		return fmt.Errorf(
+			"error - template %s is not valid: %s",
+			tmpl.GetName(),
+			cond.Message,
+		)
+	}
+
+	if _, err := ResolveParameters(
+		tmpl.GetAccessConfig().GetParameters(),
+		req.GetParameterValues(),
+	); err != nil {
+		return fmt.Errorf("error - template %s: %w", tmpl.GetName(), err)
+	}
+
+	return nil
+}
+
+// validateDelegation verifies that a new Access Request naming a different
+// user in its Spec.requestFor field was created by a member of one of the
+// template's allowedDelegators groups. Allowed delegations are written to the
+// audit log with both the requester and the beneficiary of the access.
+//
+// Returns:
+//   - An "error" if the requester is not allowed to delegate access through
+//     the template, or if the delegation cannot be tied to a user identity
+func validateDelegation(
+	log logr.Logger,
+	req admission.Request,
+	obj IRequestResource,
+	tmpl ITemplateResource,
+) error {
+	user := req.UserInfo.Username
+	requestFor := obj.GetRequestFor()
+	if requestFor == "" || requestFor == user {
+		return nil
+	}
+	if user == "" {
+		return fmt.Errorf("error - delegated access requests require a user identity")
+	}
+
+	if !isDelegator(req.UserInfo.Groups, tmpl) {
+		return fmt.Errorf(
+			"error - %s is not allowed to request access on behalf of other users with template %s",
+			user, tmpl.GetName(),
+		)
+	}
+
+	log.Info("AUDIT - Access requested on behalf of another user", audit.KeysAndValues(
+		"name", obj.GetName(),
+		"namespace", obj.GetNamespace(),
+		"user", user,
+		"requestFor", requestFor,
+	)...)
+	return nil
+}
+
+// isDelegator returns true if any of the supplied groups is one of the
+// allowedDelegators of the template.
+func isDelegator(groups []string, tmpl ITemplateResource) bool {
+	for _, delegator := range tmpl.GetAccessConfig().GetAllowedDelegators() {
+		for _, group := range groups {
+			if group == delegator {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getOwner returns the user that currently holds the access of an Access
+// Request - the user it was transferred to, the user it was requested on
+// behalf of, or otherwise the user that requested it.
+func getOwner(obj IRequestResource) string {
+	if user := obj.GetTransferTo(); user != "" {
+		return user
+	}
+	if user := obj.GetRequestFor(); user != "" {
+		return user
+	}
+	return GetRequester(obj)
+}
+
+// validateTransfer verifies an update that changes the Spec.transferTo field
+// of an Access Request. Only the user that requested the access, or one of
+// the users that approved it, may hand it over. Handing it to another user is
+// held to the same rules as creating a request on their behalf: the new owner
+// must be one of the allowedRequesters of the template (if it limits them),
+// and the user making the transfer must be a member of one of its
+// allowedDelegators groups. Clearing the field hands the access back, and
+// needs neither.
+//
+// The getTemplate function is only called when the field changes.
+//
+// Returns:
+//   - An "error" if the user may not transfer the request, or the new owner
+//     may not hold the access
+func validateTransfer(
+	req admission.Request,
+	oldReq IRequestResource,
+	newReq IRequestResource,
+	getTemplate func() (ITemplateResource, error),
+) error {
+	transferTo := newReq.GetTransferTo()
+	if oldReq.GetTransferTo() == transferTo {
+		return nil
+	}
+
+	user := req.UserInfo.Username
+	if user == "" {
+		return fmt.Errorf("error - transfers require a user identity")
+	}
+	allowed := user == GetRequester(oldReq)
+	for _, approver := range GetApprovers(oldReq) {
+		allowed = allowed || user == approver
+	}
+	if !allowed {
+		return fmt.Errorf(
+			"error - only the requester or an approver of %s may transfer it", newReq.GetName(),
+		)
+	}
+	if transferTo == "" {
+		return nil
+	}
+
+	tmpl, err := getTemplate()
+	if err != nil {
+		return err
+	}
+	if requesters := tmpl.GetAccessConfig().GetAllowedRequesters(); requesters != nil &&
+		!requesters.Allows(transferTo, nil) {
+		return fmt.Errorf(
+			"error - %s is not an allowed requester of template %s",
+			transferTo, tmpl.GetName(),
+		)
+	}
+	if !isDelegator(req.UserInfo.Groups, tmpl) {
+		return fmt.Errorf(
+			"error - %s is not allowed to transfer access to other users with template %s",
+			user, tmpl.GetName(),
+		)
+	}
+	return nil
+}
+
+// auditTransfer writes an audit log record whenever the Spec.transferTo field
+// of an Access Request changes, recording who handed the access over and to
+// whom.
+func auditTransfer(
+	log logr.Logger,
+	req admission.Request,
+	oldReq IRequestResource,
+	newReq IRequestResource,
+) {
+	if oldReq.GetTransferTo() == newReq.GetTransferTo() {
+		return
+	}
+	log.Info("AUDIT - Access request transferred", audit.KeysAndValues(
+		"name", newReq.GetName(),
+		"namespace", newReq.GetNamespace(),
+		"user", req.UserInfo.Username,
+		"from", getOwner(oldReq),
+		"to", getOwner(newReq),
+	)...)
+}
+
+// GetApprovers returns the distinct users recorded in the ApprovedByAnnotation
+// of the supplied object, in the order that they approved it.
+func GetApprovers(obj metav1.Object) []string {
+	value := obj.GetAnnotations()[ApprovedByAnnotation]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// GetApprovedUntil returns the end of the window covered by the approvals of
+// the supplied object, from its ApprovedUntilAnnotation. Returns false if no
+// approval was given for a window, or the annotation cannot be parsed.
+func GetApprovedUntil(obj metav1.Object) (time.Time, bool) {
+	value, ok := obj.GetAnnotations()[ApprovedUntilAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	approvedUntil, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return approvedUntil, true
+}
+
+// GetRequester returns the user recorded in the RequestedByAnnotation of the
+// supplied object, or an empty string if it was never recorded.
+func GetRequester(obj metav1.Object) string {
+	return obj.GetAnnotations()[RequestedByAnnotation]
+}
+
+// GetRequesterGroups returns the groups recorded in the
+// RequestedByGroupsAnnotation of the supplied object.
+func GetRequesterGroups(obj metav1.Object) []string {
+	value := obj.GetAnnotations()[RequestedByGroupsAnnotation]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// GetAdoptedRoleBinding returns the name of the pre-existing RoleBinding
+// recorded in the AdoptRoleBindingAnnotation of the supplied object, or an
+// empty string if the object does not adopt one.
+func GetAdoptedRoleBinding(obj metav1.Object) string {
+	return obj.GetAnnotations()[AdoptRoleBindingAnnotation]
+}
+
+// getOldObjectMeta returns the metadata of the previous revision of the object
+// in an UPDATE admission request. For any other operation, empty metadata is
+// returned.
+func getOldObjectMeta(req admission.Request) (metav1.Object, error) {
+	old := &metav1.PartialObjectMetadata{}
+	if req.Operation != admissionv1.Update {
+		return old, nil
+	}
+	if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// recordRequester is called by the mutating webhooks to record the identity of
+// the user that created an Access Request in the RequestedByAnnotation, and
+// their groups in the RequestedByGroupsAnnotation. On updates the values are
+// always carried over from the previous revision of the object, so that they
+// cannot be changed after the fact.
+func recordRequester(req admission.Request, obj IRequestResource) error {
+	old, err := getOldObjectMeta(req)
+	if err != nil {
+		return err
+	}
+
+	requester := GetRequester(old)
+	groups := old.GetAnnotations()[RequestedByGroupsAnnotation]
+	if req.Operation == admissionv1.Create {
+		requester = req.UserInfo.Username
+		groups = strings.Join(req.UserInfo.Groups, ",")
+	}
+
+	annotations := obj.GetAnnotations()
+	delete(annotations, RequestedByAnnotation)
+	delete(annotations, RequestedByGroupsAnnotation)
+	if requester != "" || groups != "" {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+	}
+	if requester != "" {
+		annotations[RequestedByAnnotation] = requester
+	}
+	if groups != "" {
+		annotations[RequestedByGroupsAnnotation] = groups
+	}
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// recordIncidentID is called by the mutating webhooks to mirror the
+// `spec.incidentID` of an Access Request into its IncidentIDLabel, or to
+// remove the label if the request is not tagged with an incident.
+func recordIncidentID(obj IRequestResource) {
+	mirrorLabel(obj, IncidentIDLabel, obj.GetIncidentID())
+}
+
+// recordSessionID is called by the mutating webhooks to mirror the
+// `spec.sessionID` of an Access Request into its SessionIDLabel, or to remove
+// the label if the request is not part of a session.
+func recordSessionID(obj IRequestResource) {
+	mirrorLabel(obj, SessionIDLabel, obj.GetSessionID())
+}
+
+// mirrorLabel sets the supplied label on the object to the supplied value,
+// discarding whatever it was set to before. An empty value removes the label.
+func mirrorLabel(obj IRequestResource, label string, value string) {
+	labels := obj.GetLabels()
+	delete(labels, label)
+	if value != "" {
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[label] = value
+	}
+	obj.SetLabels(labels)
+}
+
+// recordApproval is called by the mutating webhooks to turn an
+// ApproveAnnotation set by a user into an entry in the ApprovedByAnnotation.
+// The list of approvers is always rebuilt from the previous revision of the
+// object, so that it can only ever be appended to by this function. The same
+// goes for the ApprovedUntilAnnotation, which is only set by approvals given
+// for a window.
+//
+// Returns:
+//   - An "error" if the user has already approved the request, if the user is
+//     the one that requested the access, if the approval cannot be tied to a
+//     user identity, or if its window is not a positive duration
+func recordApproval(
+	log logr.Logger,
+	req admission.Request,
+	obj IRequestResource,
+) error {
+	old, err := getOldObjectMeta(req)
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+	approval, approving := annotations[ApproveAnnotation]
+	delete(annotations, ApproveAnnotation)
+	delete(annotations, ApprovedByAnnotation)
+	delete(annotations, ApprovedUntilAnnotation)
+
+	var approvers []string
+	var approvedUntil string
+	if req.Operation == admissionv1.Update {
+		approvers = GetApprovers(old)
+		approvedUntil = old.GetAnnotations()[ApprovedUntilAnnotation]
+
+		if approving {
+			user := req.UserInfo.Username
+			if user == "" {
+				return fmt.Errorf("error - approvals require a user identity")
+			}
+			if user == GetRequester(old) {
+				return fmt.Errorf(
+					"error - %s requested %s and cannot approve their own request",
+					user, obj.GetName(),
+				)
+			}
+			for _, approver := range approvers {
+				if approver == user {
+					return fmt.Errorf(
+						"error - %s has already approved %s", user, obj.GetName(),
+					)
+				}
+			}
+			approvers = append(approvers, user)
+
+			// An approval given for a window replaces the window of any
+			// earlier one - the access may not outlast it.
+			window := ""
+			if approval != "" && approval != "true" {
+				duration, err := time.ParseDuration(approval)
+				if err != nil || duration <= 0 {
+					return fmt.Errorf(
+						"error - %s must be \"true\" or a positive duration (eg, \"4h\"), not %q",
+						ApproveAnnotation, approval,
+					)
+				}
+				window = duration.String()
+				approvedUntil = time.Now().Add(duration).UTC().Format(time.RFC3339)
+			}
+
+			log.Info("AUDIT - Access request approved", audit.KeysAndValues(
+				"name", obj.GetName(),
+				"namespace", obj.GetNamespace(),
+				"user", user,
+				"approvals", len(approvers),
+				"window", window,
+			)...)
+		}
+	}
+
+	if len(approvers) > 0 {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[ApprovedByAnnotation] = strings.Join(approvers, ",")
+		if approvedUntil != "" {
+			annotations[ApprovedUntilAnnotation] = approvedUntil
+		}
+	}
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// recordRevocation is called by the mutating webhooks when a user sets the
+// RevokeAnnotation on an Access Request. The identity of the user is recorded
+// in the RevokedByAnnotation, and the revocation (with its reason) is written
+// to the audit log. Once an Access Request has been revoked, both annotations
+// are always carried over from the previous revision of the object, so that
+// the revocation cannot be undone or re-attributed.
+//
+// The getTemplate function is only called for new revocations, to find out
+// whether the template requires a reason. If the template cannot be found,
+// the revocation is allowed - it only ever takes access away.
+//
+// Returns:
+//   - An "error" if the template requires a reason and none was given, or if
+//     the revocation cannot be tied to a user identity
+func recordRevocation(
+	log logr.Logger,
+	req admission.Request,
+	obj IRequestResource,
+	getTemplate func() (ITemplateResource, error),
+) error {
+	old, err := getOldObjectMeta(req)
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+	oldAnnotations := old.GetAnnotations()
+
+	// Already revoked - carry the original revocation over as-is.
+	if reason, revoked := oldAnnotations[RevokeAnnotation]; revoked {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[RevokeAnnotation] = reason
+		delete(annotations, RevokedByAnnotation)
+		if revokedBy := oldAnnotations[RevokedByAnnotation]; revokedBy != "" {
+			annotations[RevokedByAnnotation] = revokedBy
+		}
+		obj.SetAnnotations(annotations)
+		return nil
+	}
+
+	delete(annotations, RevokedByAnnotation)
+	reason, revoking := annotations[RevokeAnnotation]
+	if !revoking || req.Operation != admissionv1.Update {
+		// Requests cannot be created in a revoked state.
+		delete(annotations, RevokeAnnotation)
+		obj.SetAnnotations(annotations)
+		return nil
+	}
+
+	user := req.UserInfo.Username
+	if user == "" {
+		return fmt.Errorf("error - revocations require a user identity")
+	}
+	if tmpl, err := getTemplate(); err == nil &&
+		tmpl.GetAccessConfig().IsRevokeReasonRequired() &&
+		strings.TrimSpace(reason) == "" {
+		return fmt.Errorf(
+			"error - template %s requires a reason to revoke %s",
+			tmpl.GetName(), obj.GetName(),
+		)
+	}
+
+	annotations[RevokedByAnnotation] = user
+	obj.SetAnnotations(annotations)
+
+	log.Info("AUDIT - Access request revoked", audit.KeysAndValues(
+		"name", obj.GetName(),
+		"namespace", obj.GetNamespace(),
+		"user", user,
+		"reason", reason,
+	)...)
+	return nil
+}
+
+// GetForcedExpiration returns the user recorded in the ExpireAnnotation of the
+// supplied object, and whether or not the expiration was forced at all.
+func GetForcedExpiration(obj metav1.Object) (string, bool) {
+	user, forced := obj.GetAnnotations()[ExpireAnnotation]
+	return user, forced
+}
+
+// recordForcedExpiration is called by the mutating webhooks when a user sets
+// the ExpireAnnotation on an Access Request. The value is replaced with the
+// identity of the user and the forced expiration is written to the audit log.
+// Once set, the annotation is always carried over from the previous revision
+// of the object.
+//
+// Returns:
+//   - An "error" if the expiration cannot be tied to a user identity
+func recordForcedExpiration(
+	log logr.Logger,
+	req admission.Request,
+	obj IRequestResource,
+) error {
+	old, err := getOldObjectMeta(req)
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+	if user, forced := GetForcedExpiration(old); forced {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[ExpireAnnotation] = user
+		obj.SetAnnotations(annotations)
+		return nil
+	}
+
+	_, expiring := annotations[ExpireAnnotation]
+	if !expiring || req.Operation != admissionv1.Update {
+		delete(annotations, ExpireAnnotation)
+		obj.SetAnnotations(annotations)
+		return nil
+	}
+
+	user := req.UserInfo.Username
+	if user == "" {
+		return fmt.Errorf("error - forced expirations require a user identity")
+	}
+	annotations[ExpireAnnotation] = user
+	obj.SetAnnotations(annotations)
+
+	log.Info("AUDIT - Access request expiration forced", audit.KeysAndValues(
+		"name", obj.GetName(),
+		"namespace", obj.GetNamespace(),
+		"user", user,
+	)...)
+	return nil
+}
+
+// GetSessionEnded returns the name of the Access Request whose end ended the
+// session of the supplied Access Request, and whether it has ended.
+func GetSessionEnded(obj metav1.Object) (string, bool) {
+	name, ended := obj.GetAnnotations()[SessionEndedAnnotation]
+	return name, ended
+}
+
+// GetLastActive returns when the access of the supplied Access Request was
+// last used, from its LastActiveAnnotation. Returns false if the annotation is
+// missing, cannot be parsed, or is more than LastActiveClockSkew ahead of the
+// supplied time - a timestamp in the future would keep the access in use
+// long after its last use.
+func GetLastActive(obj metav1.Object, now time.Time) (time.Time, bool) {
+	value, ok := obj.GetAnnotations()[LastActiveAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	lastActive, err := time.Parse(time.RFC3339, value)
+	if err != nil || lastActive.After(now.Add(LastActiveClockSkew)) {
+		return time.Time{}, false
+	}
+	return lastActive, true
+}
+
+// GetExpiresAt returns when the access of the supplied object (such as a Role
+// or RoleBinding created for an Access Request) ends, from its
+// ExpiresAtAnnotation. Returns false if the annotation is missing or cannot be
+// parsed.
+func GetExpiresAt(obj metav1.Object) (time.Time, bool) {
+	value, ok := obj.GetAnnotations()[ExpiresAtAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
+
+// IsTraced returns true if the supplied Access Request asks for the decision
+// tree of its reconciles to be logged, with the TraceAnnotation.
+func IsTraced(obj metav1.Object) bool {
+	return obj.GetAnnotations()[TraceAnnotation] == "true"
+}
+
+// IsCleanupExempt returns whether the supplied Access Request carries the
+// CleanupExemptAnnotation.
+func IsCleanupExempt(obj metav1.Object) bool {
+	return obj.GetAnnotations()[CleanupExemptAnnotation] == "true"
+}
+
+// recordSessionEnded is called by the mutating webhooks to make the
+// SessionEndedAnnotation final. It is dropped from new Access Requests, and
+// once set it is always carried over from the previous revision of the
+// object, so that the end of a session cannot be undone.
+//
+// Returns:
+//   - An "error" if the previous revision of the object cannot be decoded
+func recordSessionEnded(req admission.Request, obj IRequestResource) error {
+	old, err := getOldObjectMeta(req)
+	if err != nil {
+		return err
+	}
+
+	annotations := obj.GetAnnotations()
+	if name, ended := GetSessionEnded(old); ended {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[SessionEndedAnnotation] = name
+	} else if req.Operation != admissionv1.Update {
+		delete(annotations, SessionEndedAnnotation)
+	}
+	obj.SetAnnotations(annotations)
+	return nil
+}
+
+// recordLastActive is called by the mutating webhooks to keep the
+// LastActiveAnnotation from being written by anybody but the
+// LastActiveWriters. Otherwise the holder of the access could keep it from
+// expiring by stamping the annotation themselves.
+//
+// Returns:
+//   - An "error" if the annotation was set, changed or removed by any other
+//     user, or if the previous revision of the object cannot be decoded
+func recordLastActive(req admission.Request, obj IRequestResource) error {
+	old, err := getOldObjectMeta(req)
+	if err != nil {
+		return err
+	}
+
+	oldValue, wasSet := old.GetAnnotations()[LastActiveAnnotation]
+	value, set := obj.GetAnnotations()[LastActiveAnnotation]
+	if set == wasSet && value == oldValue {
+		return nil
+	}
+	for _, writer := range LastActiveWriters {
+		if req.UserInfo.Username == writer {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"error - %s is written by the controller, and cannot be changed by %s",
+		LastActiveAnnotation, req.UserInfo.Username,
+	)
+}
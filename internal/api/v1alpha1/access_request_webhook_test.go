@@ -0,0 +1,198 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var _ = Describe("validateTransfer()", func() {
+	var template *ExecAccessTemplate
+
+	// owned returns a request made by alice and approved by carol, that has
+	// been transferred to the supplied user (if any).
+	owned := func(transferTo string) *ExecAccessRequest {
+		req := &ExecAccessRequest{}
+		req.Name = "test"
+		req.Annotations = map[string]string{
+			RequestedByAnnotation: "alice",
+			ApprovedByAnnotation:  "carol",
+		}
+		req.Spec.TransferTo = transferTo
+		return req
+	}
+
+	// by returns an UPDATE admission request made by the supplied user.
+	by := func(user string, groups ...string) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Update,
+			UserInfo:  authenticationv1.UserInfo{Username: user, Groups: groups},
+		}}
+	}
+
+	getTemplate := func() (ITemplateResource, error) { return template, nil }
+
+	BeforeEach(func() {
+		template = &ExecAccessTemplate{}
+		template.Name = "web"
+		template.Spec.AccessConfig.AllowedDelegators = []string{"leads"}
+	})
+
+	It("Should allow the requester to transfer the access", func() {
+		Expect(validateTransfer(by("alice", "leads"), owned(""), owned("bob"), getTemplate)).To(Succeed())
+	})
+
+	It("Should allow an approver to transfer the access", func() {
+		Expect(validateTransfer(by("carol", "leads"), owned(""), owned("bob"), getTemplate)).To(Succeed())
+	})
+
+	It("Should reject a transfer by anybody else", func() {
+		err := validateTransfer(by("mallory", "leads"), owned(""), owned("mallory"), getTemplate)
+		Expect(err).To(MatchError("error - only the requester or an approver of test may transfer it"))
+	})
+
+	It("Should reject a transfer without a user identity", func() {
+		err := validateTransfer(by(""), owned(""), owned("bob"), getTemplate)
+		Expect(err).To(MatchError(ContainSubstring("require a user identity")))
+	})
+
+	It("Should reject a transfer by a user outside of the delegator groups", func() {
+		err := validateTransfer(by("alice", "devs"), owned(""), owned("bob"), getTemplate)
+		Expect(err).To(MatchError(
+			"error - alice is not allowed to transfer access to other users with template web",
+		))
+	})
+
+	It("Should reject a transfer to a user that is not an allowed requester", func() {
+		template.Spec.AccessConfig.AllowedRequesters = &AllowedRequesters{Users: []string{"alice"}}
+		err := validateTransfer(by("alice", "leads"), owned(""), owned("bob"), getTemplate)
+		Expect(err).To(MatchError("error - bob is not an allowed requester of template web"))
+	})
+
+	It("Should allow the access to be handed back without consulting the template", func() {
+		failing := func() (ITemplateResource, error) { return nil, errors.New("not called") }
+		Expect(validateTransfer(by("carol"), owned("bob"), owned(""), failing)).To(Succeed())
+	})
+
+	It("Should ignore updates that do not change the owner", func() {
+		failing := func() (ITemplateResource, error) { return nil, errors.New("not called") }
+		Expect(validateTransfer(by("mallory"), owned("bob"), owned("bob"), failing)).To(Succeed())
+	})
+})
+
+var _ = Describe("getOwner()", func() {
+	It("Should return the user holding the access of the request", func() {
+		req := &ExecAccessRequest{}
+		req.Annotations = map[string]string{RequestedByAnnotation: "alice"}
+		Expect(getOwner(req)).To(Equal("alice"))
+
+		req.Spec.RequestFor = "bob"
+		Expect(getOwner(req)).To(Equal("bob"))
+
+		req.Spec.TransferTo = "carol"
+		Expect(getOwner(req)).To(Equal("carol"))
+	})
+})
+
+var _ = Describe("recordLastActive()", func() {
+	// stamped returns a request whose LastActiveAnnotation is set to the
+	// supplied value (if any).
+	stamped := func(value string) *ExecAccessRequest {
+		req := &ExecAccessRequest{}
+		req.Name = "test"
+		if value != "" {
+			req.Annotations = map[string]string{LastActiveAnnotation: value}
+		}
+		return req
+	}
+
+	// update returns an UPDATE admission request made by the supplied user,
+	// from the supplied previous revision of the object.
+	update := func(user string, old *ExecAccessRequest) admission.Request {
+		raw, err := json.Marshal(old)
+		Expect(err).ToNot(HaveOccurred())
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Update,
+			UserInfo:  authenticationv1.UserInfo{Username: user},
+			OldObject: runtime.RawExtension{Raw: raw},
+		}}
+	}
+
+	BeforeEach(func() {
+		LastActiveWriters = []string{"system:serviceaccount:oz-system:oz-controller-manager"}
+		DeferCleanup(func() { LastActiveWriters = nil })
+	})
+
+	It("Should let the LastActiveWriters stamp the annotation", func() {
+		req := update("system:serviceaccount:oz-system:oz-controller-manager", stamped("2022-01-01T00:00:00Z"))
+		Expect(recordLastActive(req, stamped("2022-01-01T00:05:00Z"))).To(Succeed())
+	})
+
+	It("Should reject any other user stamping the annotation", func() {
+		err := recordLastActive(update("alice", stamped("")), stamped("2099-01-01T00:00:00Z"))
+		Expect(err).To(MatchError(
+			"error - crds.wizardofoz.co/last-active is written by the controller, and cannot be changed by alice",
+		))
+		Expect(recordLastActive(update("alice", stamped("2022-01-01T00:00:00Z")), stamped(""))).ToNot(Succeed())
+	})
+
+	It("Should reject new requests that are created with the annotation", func() {
+		req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			UserInfo:  authenticationv1.UserInfo{Username: "alice"},
+		}}
+		Expect(recordLastActive(req, stamped("2099-01-01T00:00:00Z"))).ToNot(Succeed())
+		Expect(recordLastActive(req, stamped(""))).To(Succeed())
+	})
+
+	It("Should let any user carry the annotation over unchanged", func() {
+		req := update("alice", stamped("2022-01-01T00:00:00Z"))
+		Expect(recordLastActive(req, stamped("2022-01-01T00:00:00Z"))).To(Succeed())
+	})
+})
+
+var _ = Describe("GetLastActive()", func() {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// at returns a request whose LastActiveAnnotation is set to the supplied
+	// time.
+	at := func(lastActive time.Time) *ExecAccessRequest {
+		req := &ExecAccessRequest{}
+		req.Annotations = map[string]string{LastActiveAnnotation: lastActive.Format(time.RFC3339)}
+		return req
+	}
+
+	It("Should return when the access was last active", func() {
+		lastActive, ok := GetLastActive(at(now.Add(-time.Minute)), now)
+		Expect(ok).To(BeTrue())
+		Expect(lastActive).To(Equal(now.Add(-time.Minute)))
+	})
+
+	It("Should tolerate a timestamp within the clock skew", func() {
+		_, ok := GetLastActive(at(now.Add(LastActiveClockSkew)), now)
+		Expect(ok).To(BeTrue())
+	})
+
+	It("Should ignore a timestamp in the future", func() {
+		_, ok := GetLastActive(at(now.Add(LastActiveClockSkew+time.Second)), now)
+		Expect(ok).To(BeFalse())
+		_, ok = GetLastActive(at(now.Add(24*time.Hour)), now)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Should ignore a missing or invalid annotation", func() {
+		_, ok := GetLastActive(&ExecAccessRequest{}, now)
+		Expect(ok).To(BeFalse())
+		req := &ExecAccessRequest{}
+		req.Annotations = map[string]string{LastActiveAnnotation: "yesterday"}
+		_, ok = GetLastActive(req, now)
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,34 @@
+package v1alpha1
+
+// AllowedRequesters limits which identities may create Access Requests against an Access
+// Template, independent of the RBAC permissions that the requests end up granting.
+type AllowedRequesters struct {
+	// Users lists out the usernames that may create Access Requests.
+	//
+	// +kubebuilder:validation:Optional
+	Users []string `json:"users,omitempty"`
+
+	// Groups lists out the groups (in string name form) whose members may create Access
+	// Requests.
+	//
+	// +kubebuilder:validation:Optional
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Allows returns true if the supplied user, or any of the supplied groups, is
+// listed.
+func (a *AllowedRequesters) Allows(user string, groups []string) bool {
+	for _, u := range a.Users {
+		if u == user {
+			return true
+		}
+	}
+	for _, allowed := range a.Groups {
+		for _, group := range groups {
+			if allowed == group {
+				return true
+			}
+		}
+	}
+	return false
+}
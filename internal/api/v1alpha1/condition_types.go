@@ -36,6 +36,60 @@ const (
 	// resources" (eg, a Pod) are up and in the ready state.
 	ConditionAccessResourcesReady RequestConditionTypes = "AccessResourcesReady"
 
+	// ConditionAccessApproved indicates whether or not the Access Request
+	// has been approved by the number of distinct users required by the
+	// Access Template. It is only set when the template requires approvals.
+	ConditionAccessApproved RequestConditionTypes = "AccessApproved"
+
+	// ConditionControllerPermissions indicates whether or not the controller
+	// itself was permitted to create the access resources (eg, Roles and
+	// RoleBindings) in the namespace of the Access Request. It is only set
+	// once the controller has been denied.
+	ConditionControllerPermissions RequestConditionTypes = "ControllerPermissions"
+
+	// ConditionRequestUnique indicates whether or not the Access Request is
+	// the only active request from its user asking for the same access. It
+	// is only set once a duplicate has been detected.
+	ConditionRequestUnique RequestConditionTypes = "RequestUnique"
+
+	// ConditionNotificationSent indicates whether or not the last
+	// notification about the Access Request was delivered to its requester.
+	// It is only set when the controller has a Notifier configured, and it is
+	// advisory - a failed delivery does not hold the request back from being
+	// ready.
+	ConditionNotificationSent RequestConditionTypes = "NotificationSent"
+
+	// ConditionAccessEffective indicates whether or not the requester is
+	// actually authorized to use the access that was granted, as answered by
+	// a SubjectAccessReview. It is only set when the controller is
+	// configured to verify the access, and it is advisory - it surfaces
+	// conflicts with other policies in the cluster, but does not hold the
+	// request back from being ready.
+	ConditionAccessEffective RequestConditionTypes = "AccessEffective"
+
+	// ConditionInUse indicates whether or not the access granted by the
+	// Access Request is still in use - ie, it has been active within the
+	// idleTimeout of its template. It is only set on requests against
+	// templates with an idleTimeout, and it is advisory - it explains why
+	// the expiration of the access was deferred.
+	ConditionInUse RequestConditionTypes = "InUse"
+
+	// ConditionTargetPodExclusive indicates whether or not the Access Request
+	// is the only active request that was assigned its target Pod. It is only
+	// set when the controller is configured to detect shared Pods, and it is
+	// advisory - templates with a maxGrantsPerPod deliberately share Pods, so
+	// a shared Pod does not hold the request back from being ready.
+	ConditionTargetPodExclusive RequestConditionTypes = "TargetPodExclusive"
+
+	// ConditionApprovalWithinSLO indicates whether or not the Access Request
+	// was approved within the approval SLO of the controller - counted from
+	// its creation until the ConditionAccessApproved condition flipped to
+	// True. It is only set when the controller has an approval SLO, on
+	// requests that had to wait on approval, and it is advisory - it lets
+	// managers follow up on slow approvals, but does not hold the request
+	// back from being ready.
+	ConditionApprovalWithinSLO RequestConditionTypes = "ApprovalWithinSLO"
+
 	// ConditionAccessMessage is used to record
 	ConditionAccessMessage RequestConditionTypes = "AccessMessage"
 )
@@ -56,7 +110,56 @@ const (
 	// ConditionTargetRefExists indicates whether or not an AccessTemplate is
 	// pointing to a valid Controller.
 	ConditionTargetRefExists TemplateConditionTypes = "TargetRefExists"
+
+	// ConditionClusterRoleRefExists indicates whether or not the ClusterRole
+	// referenced by an AccessTemplate exists. It is only set on templates that
+	// reference a ClusterRole.
+	ConditionClusterRoleRefExists TemplateConditionTypes = "ClusterRoleRefExists"
+
+	// ConditionNamespacePatternValid indicates whether or not the
+	// namespacePattern of an AccessTemplate is a valid glob pattern. It is
+	// only set on templates that are shared with other namespaces.
+	ConditionNamespacePatternValid TemplateConditionTypes = "NamespacePatternValid"
+
+	// ConditionTemplateValid indicates whether or not all of the validation
+	// checks on an AccessTemplate have passed. Access Requests are not
+	// accepted against templates where this condition is False.
+	ConditionTemplateValid TemplateConditionTypes = "TemplateValid"
+
+	// ConditionMaxDurationWithinCeiling indicates whether or not the
+	// maxDuration of an AccessTemplate is within the absolute maximum duration
+	// enforced by the controller. It is only set when the controller has a
+	// ceiling configured, and it is advisory - requests are clamped to the
+	// ceiling regardless, so a False value does not make the template invalid.
+	ConditionMaxDurationWithinCeiling TemplateConditionTypes = "MaxDurationWithinCeiling"
 )
 
 // String implements the fmt.Stringer interface.
 func (x TemplateConditionTypes) String() string { return string(x) }
+
+// IsAdvisoryCondition returns true for the condition types that only warn
+// about a resource, and are therefore left out when the conditions are rolled
+// up into the ConditionTemplateValid and ConditionReady conditions.
+func IsAdvisoryCondition(condType string) bool {
+	return condType == ConditionMaxDurationWithinCeiling.String() ||
+		condType == ConditionNotificationSent.String() ||
+		condType == ConditionAccessEffective.String() ||
+		condType == ConditionInUse.String() ||
+		condType == ConditionTargetPodExclusive.String() ||
+		condType == ConditionApprovalWithinSLO.String()
+}
+
+// CoreConditionTypes defines a set of known Status.Condition[].ConditionType fields that are
+// written to every ICoreResource resource, regardless of whether it is a request or a template.
+type CoreConditionTypes string
+
+const (
+	// ConditionReady is an aggregate of all of the other conditions on a
+	// resource. It is True only when every other condition is True, and its
+	// message summarizes why the resource is (or is not) ready. It mirrors
+	// the Status.Ready boolean.
+	ConditionReady CoreConditionTypes = "Ready"
+)
+
+// String implements the fmt.Stringer interface.
+func (x CoreConditionTypes) String() string { return string(x) }
@@ -15,6 +15,27 @@ const (
 	StatefulSetController ControllerKind = "StatefulSet"
 )
 
+// PodSelectionStrategy is a string that represents how an ExecAccessTemplate
+// picks the target Pod for an Access Request that did not ask for a specific
+// one.
+type PodSelectionStrategy string
+
+const (
+	// RandomPodSelection picks any one of the candidate Pods at random.
+	RandomPodSelection PodSelectionStrategy = "random"
+
+	// LeastRecentlyUsedPodSelection picks the candidate Pod that was assigned
+	// to an Access Request the longest time ago, preferring Pods that have
+	// never been assigned at all. This spreads sequential requests across the
+	// Pods rather than repeatedly landing on the same one.
+	LeastRecentlyUsedPodSelection PodSelectionStrategy = "leastRecentlyUsed"
+
+	// ManualPodSelection only picks the candidate Pod if there is exactly one.
+	// If there are several, the requester has to pick one of them as the
+	// targetPod of the request.
+	ManualPodSelection PodSelectionStrategy = "manual"
+)
+
 const (
 	// FieldSelectorMetadataName refers to the metadata.name field on an
 	// object, and is used during the creation of the K8S API Client as one of
@@ -25,4 +46,149 @@ const (
 	// object, and is used during the creation of the K8S API Client as one of
 	// the fields we want to index.
 	FieldSelectorStatusPhase string = "status.phase"
+
+	// FieldSelectorSpecNodeName refers to the spec.nodeName field on a Pod,
+	// and is used during the creation of the K8S API Client as one of the
+	// fields we want to index.
+	FieldSelectorSpecNodeName string = "spec.nodeName"
+
+	// FieldSelectorStatusPodName refers to the status.podName field on an
+	// Access Request, and is used during the creation of the K8S API Client
+	// as one of the fields we want to index. The API server itself does not
+	// support selecting Access Requests by this field, so it can only be used
+	// against the cached client.
+	FieldSelectorStatusPodName string = "status.podName"
+)
+
+// IncidentIDLabel mirrors the `spec.incidentID` of an Access Request, so that
+// the requests for an incident can be looked up with a label selector. It is
+// only ever written by the mutating webhook - changes made to it by anyone
+// else are discarded.
+const IncidentIDLabel string = "crds.wizardofoz.co/incident-id"
+
+// SessionIDLabel mirrors the `spec.sessionID` of an Access Request, so that
+// the requests sharing a session can be looked up with a label selector. Like
+// the IncidentIDLabel, it is only ever written by the mutating webhook.
+const SessionIDLabel string = "crds.wizardofoz.co/session-id"
+
+// RequestFinalizer is placed on every Access Request by the RequestReconciler.
+// It holds the request in place on deletion until the access resources have
+// been torn down in a deterministic order.
+const RequestFinalizer string = "crds.wizardofoz.co/access-resources"
+
+const (
+	// ApproveAnnotation is set on an Access Request by an approver (for
+	// example, with `ozctl approve`). The mutating webhook removes it again
+	// and records the identity of the approver in ApprovedByAnnotation. Its
+	// value is either "true", or the window that the approval covers (eg,
+	// "4h"), which is recorded in ApprovedUntilAnnotation.
+	ApproveAnnotation string = "crds.wizardofoz.co/approve"
+
+	// ApprovedUntilAnnotation holds the end (RFC3339) of the window covered
+	// by the latest approval of an Access Request that was given for a
+	// window. The access (including any extension of it) never outlasts
+	// it. It is only ever written by the mutating webhook.
+	ApprovedUntilAnnotation string = "crds.wizardofoz.co/approved-until"
+
+	// ApprovedByAnnotation holds the comma-separated list of distinct users
+	// that have approved an Access Request. It is only ever written by the
+	// mutating webhook - changes made to it by anyone else are discarded.
+	ApprovedByAnnotation string = "crds.wizardofoz.co/approved-by"
+
+	// RequestedByAnnotation holds the identity of the user that created an
+	// Access Request. It is written by the mutating webhook on creation, and
+	// cannot be changed afterwards. Requesters may not approve their own
+	// requests.
+	RequestedByAnnotation string = "crds.wizardofoz.co/requested-by"
+
+	// RequestedByGroupsAnnotation holds the comma-separated groups of the
+	// user that created an Access Request, so that the access can be tuned
+	// to them (see the verbsByGroup of the Access Templates). Like the
+	// RequestedByAnnotation, it is written by the mutating webhook on
+	// creation, and cannot be changed afterwards.
+	RequestedByGroupsAnnotation string = "crds.wizardofoz.co/requested-by-groups"
+
+	// RevokeAnnotation is set on an Access Request to revoke the access it
+	// grants before it expires (for example, with `ozctl revoke`). Its value
+	// is the reason for the revocation, which may be required by the
+	// template. Once set, it cannot be changed or removed.
+	RevokeAnnotation string = "crds.wizardofoz.co/revoke"
+
+	// RevokedByAnnotation holds the identity of the user that revoked an
+	// Access Request. It is only ever written by the mutating webhook.
+	RevokedByAnnotation string = "crds.wizardofoz.co/revoked-by"
+
+	// ExpireAnnotation is set on an Access Request to force it to expire on
+	// the next reconcile, regardless of its duration (for example, with
+	// `ozctl expire --force`). It exists so that teams can exercise their
+	// cleanup and alerting in staging. The mutating webhook replaces the
+	// value with the identity of the user that forced the expiration, and it
+	// cannot be changed or removed afterwards.
+	ExpireAnnotation string = "crds.wizardofoz.co/expire"
+
+	// SessionEndedAnnotation is set on the Access Requests of a session by
+	// the RequestReconciler when the access of another request in the
+	// session ends, and holds the name of that request. The access of every
+	// request carrying it ends on its next reconcile. Once set, it cannot be
+	// changed or removed.
+	SessionEndedAnnotation string = "crds.wizardofoz.co/session-ended"
+
+	// LastActiveAnnotation holds when the access granted by an Access
+	// Request was last used (RFC3339). It is stamped by the Pod exec webhook
+	// on each exec into the target Pod by a subject of the access, and may
+	// also be stamped by a heartbeat running as one of the LastActiveWriters.
+	// Writes by any other user are rejected. Templates with an idleTimeout
+	// defer the expiration of access that is still in use.
+	LastActiveAnnotation string = "crds.wizardofoz.co/last-active"
+
+	// TraceAnnotation may be set (to "true") on an Access Request to have the
+	// controller log the full decision tree of each of its reconciles - each
+	// check, its inputs and its outcome - without raising the verbosity of
+	// the logs for every other request.
+	TraceAnnotation string = "crds.wizardofoz.co/trace"
+
+	// CleanupExemptAnnotation may be set (to "true") on an Access Request to
+	// keep it around after its access has expired, for example while
+	// debugging the controller. The access resources are still removed on
+	// expiry, and the request is still deleted once it reaches the hard
+	// absolute maximum lifetime, so it is never an indefinite bypass.
+	CleanupExemptAnnotation string = "crds.wizardofoz.co/exempt-from-cleanup"
+
+	// NotifyDestinationAnnotation may be set on an Access Request by the
+	// requester to say where notifications about the request should be sent
+	// to them (eg an email address or a Slack member ID). A destination
+	// configured for the requester by the operator of the controller always
+	// takes precedence.
+	NotifyDestinationAnnotation string = "crds.wizardofoz.co/notify-destination"
+
+	// AdoptRoleBindingAnnotation is set on an Access Request (for example,
+	// with `ozctl adopt`) to name a pre-existing RoleBinding in the same
+	// namespace that the request takes over, instead of creating its own
+	// access resources. The RoleBinding is deleted when the request expires.
+	AdoptRoleBindingAnnotation string = "crds.wizardofoz.co/adopt-role-binding"
+
+	// AdoptedByAnnotation is set on a pre-existing RoleBinding to name the
+	// Access Request that may adopt it. Requiring it on both sides means that
+	// only users who can already modify the RoleBinding can hand it over to
+	// oz.
+	AdoptedByAnnotation string = "crds.wizardofoz.co/adopted-by"
+
+	// RemainingTimeAnnotation is set on the Kubernetes Events emitted at the
+	// milestones of a grant, and holds how much of the access was left at
+	// the time (eg "30m0s").
+	RemainingTimeAnnotation string = "crds.wizardofoz.co/remaining-time"
+
+	// ExpiresAtAnnotation is set on the Kubernetes Events emitted at the
+	// milestones of a grant, and holds when the access ends (RFC3339). When
+	// the controller runs with --stamp-rbac-expiry, it is also set on the
+	// Roles and RoleBindings that it creates, so that they can be cleaned up
+	// with `ozctl prune-expired-rbac` even while the controller is down.
+	ExpiresAtAnnotation string = "crds.wizardofoz.co/expires-at"
+
+	// TraceParentAnnotation may be set on an Access Request by the client
+	// that created it, to the W3C `traceparent` of the trace the request is
+	// part of. The trace ID is attached as an exemplar to the metrics that
+	// are observed for the request, so that they can be correlated with the
+	// trace.
+	TraceParentAnnotation string = "crds.wizardofoz.co/traceparent"
 )
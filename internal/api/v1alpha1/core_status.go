@@ -13,12 +13,46 @@ type CoreStatus struct {
 	// Simple boolean to let us know if the resource is ready for use or not
 	Ready bool `json:"ready,omitempty"`
 
+	// ObservedGeneration is the metadata.generation of the resource that the Ready field was
+	// computed for. If it is behind the current generation, the status is stale.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
 	// AccessMessage is used to describe to the user how they can make use of their temporary access
 	// request. Eg, for a PodAccessTemplate the value set here would be something like:
 	//
 	//   "Access Graned, connect to your pod with: kubectl exec -ti -n namespace pod-xyz -- /bin/bash"
 	//
 	AccessMessage string `json:"accessMessage,omitempty"`
+
+	// Approvers lists the distinct users that have approved an Access Request, when the Access
+	// Template requires approvals.
+	Approvers []string `json:"approvers,omitempty"`
+
+	// DuplicateOf is the name of an earlier Access Request from the same user that already
+	// grants the same access. No access resources are created for a duplicate request.
+	DuplicateOf string `json:"duplicateOf,omitempty"`
+
+	// RequesterNotified lists the events (eg "granted") that the requester of an Access Request
+	// has already been notified about, so that each notification is only delivered once.
+	RequesterNotified []string `json:"requesterNotified,omitempty"`
+
+	// GrantToken is a signed JSON Web Token describing the access granted by an Access Request
+	// (who, to what, and until when), so that downstream systems can verify the grant. It is only
+	// issued when the controller is configured with a signing key.
+	GrantToken string `json:"grantToken,omitempty"`
+
+	// GrantMilestones lists the milestones in the life of a grant (eg "Granted", "HalfLife") that
+	// a Kubernetes Event has already been emitted for, so that each Event is only emitted once.
+	GrantMilestones []string `json:"grantMilestones,omitempty"`
+
+	// AccessExpiresAt is when the access granted by an Access Request ends. It is only set once
+	// the access has been granted, and counts towards the daily grant budget of the requester.
+	AccessExpiresAt *metav1.Time `json:"accessExpiresAt,omitempty"`
+
+	// ApproversAtGrant is the number of approvers that an Access Request had when its current
+	// access was granted. Only the users that approve it after that count towards approving an
+	// extension that requires a fresh approval.
+	ApproversAtGrant int `json:"approversAtGrant,omitempty"`
 }
 
 // https://stackoverflow.com/questions/33089523/how-to-mark-golang-struct-as-implementing-interface
@@ -42,6 +76,22 @@ func (in *CoreStatus) SetReady(ready bool) {
 	in.Ready = ready
 }
 
+// GetObservedGeneration conforms to the interfaces.OzResource interface
+func (in *CoreStatus) GetObservedGeneration() int64 {
+	return in.ObservedGeneration
+}
+
+// SetObservedGeneration conforms to the interfaces.OzResource interface
+func (in *CoreStatus) SetObservedGeneration(generation int64) {
+	in.ObservedGeneration = generation
+}
+
+// isReadyAt returns true if the status is Ready, and was computed for the
+// supplied metadata.generation.
+func (in *CoreStatus) isReadyAt(generation int64) bool {
+	return in.Ready && in.ObservedGeneration == generation
+}
+
 // SetAccessMessage sets (or updates) the Status.AccessMessage field.
 func (in *CoreStatus) SetAccessMessage(msg string) {
 	in.AccessMessage = msg
@@ -52,6 +102,94 @@ func (in *CoreStatus) GetAccessMessage() string {
 	return in.AccessMessage
 }
 
+// SetApprovers sets (or updates) the Status.Approvers field.
+func (in *CoreStatus) SetApprovers(approvers []string) {
+	in.Approvers = approvers
+}
+
+// GetApprovers returns the Status.Approvers field.
+func (in *CoreStatus) GetApprovers() []string {
+	return in.Approvers
+}
+
+// SetDuplicateOf sets (or clears) the Status.DuplicateOf field.
+func (in *CoreStatus) SetDuplicateOf(name string) {
+	in.DuplicateOf = name
+}
+
+// GetDuplicateOf returns the Status.DuplicateOf field.
+func (in *CoreStatus) GetDuplicateOf() string {
+	return in.DuplicateOf
+}
+
+// HasNotifiedRequester returns true if the requester has already been
+// notified about the supplied event.
+func (in *CoreStatus) HasNotifiedRequester(event string) bool {
+	for _, e := range in.RequesterNotified {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// AddRequesterNotified records that the requester has been notified about the
+// supplied event.
+func (in *CoreStatus) AddRequesterNotified(event string) {
+	if !in.HasNotifiedRequester(event) {
+		in.RequesterNotified = append(in.RequesterNotified, event)
+	}
+}
+
+// HasGrantMilestone returns true if an Event has already been emitted for the
+// supplied milestone.
+func (in *CoreStatus) HasGrantMilestone(milestone string) bool {
+	for _, m := range in.GrantMilestones {
+		if m == milestone {
+			return true
+		}
+	}
+	return false
+}
+
+// AddGrantMilestone records that an Event has been emitted for the supplied
+// milestone.
+func (in *CoreStatus) AddGrantMilestone(milestone string) {
+	if !in.HasGrantMilestone(milestone) {
+		in.GrantMilestones = append(in.GrantMilestones, milestone)
+	}
+}
+
+// SetGrantToken sets (or clears) the Status.GrantToken field.
+func (in *CoreStatus) SetGrantToken(token string) {
+	in.GrantToken = token
+}
+
+// GetGrantToken returns the Status.GrantToken field.
+func (in *CoreStatus) GetGrantToken() string {
+	return in.GrantToken
+}
+
+// SetAccessExpiresAt sets (or clears) the Status.AccessExpiresAt field.
+func (in *CoreStatus) SetAccessExpiresAt(expiresAt *metav1.Time) {
+	in.AccessExpiresAt = expiresAt
+}
+
+// GetAccessExpiresAt returns the Status.AccessExpiresAt field.
+func (in *CoreStatus) GetAccessExpiresAt() *metav1.Time {
+	return in.AccessExpiresAt
+}
+
+// SetApproversAtGrant sets (or updates) the Status.ApproversAtGrant field.
+func (in *CoreStatus) SetApproversAtGrant(count int) {
+	in.ApproversAtGrant = count
+}
+
+// GetApproversAtGrant returns the Status.ApproversAtGrant field.
+func (in *CoreStatus) GetApproversAtGrant() int {
+	return in.ApproversAtGrant
+}
+
 // DeepCopyInto is typically auto-generated by controller-gen. However, it seems that controller-gen
 // fails when we include the ozResourceCoreStatus.Conditions field. Implementing our own DeepCopyInto function
 // resolves this, but does put the responsibility on us to keep this updated.
@@ -64,4 +202,23 @@ func (in *CoreStatus) DeepCopyInto(out *CoreStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Approvers != nil {
+		in, out := &in.Approvers, &out.Approvers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.RequesterNotified != nil {
+		in, out := &in.RequesterNotified, &out.RequesterNotified
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GrantMilestones != nil {
+		in, out := &in.GrantMilestones, &out.GrantMilestones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AccessExpiresAt != nil {
+		in, out := &in.AccessExpiresAt, &out.AccessExpiresAt
+		*out = (*in).DeepCopy()
+	}
 }
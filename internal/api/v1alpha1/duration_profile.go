@@ -0,0 +1,35 @@
+package v1alpha1
+
+// DurationProfile is an alternate set of durations for the access granted
+// through an Access Template. It is used in place of the defaultDuration and
+// maxDuration of the template while an incident is declared in its namespace
+// (see the `incidentMode` of the OzConfig).
+type DurationProfile struct {
+	// DefaultDuration replaces the template `defaultDuration` during an incident. When unset,
+	// the template `defaultDuration` is used.
+	//
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	//
+	// +kubebuilder:validation:Optional
+	DefaultDuration string `json:"defaultDuration,omitempty"`
+
+	// MaxDuration replaces the template `maxDuration` during an incident. When unset, the
+	// template `maxDuration` is used.
+	//
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	//
+	// +kubebuilder:validation:Optional
+	MaxDuration string `json:"maxDuration,omitempty"`
+}
+
+// ApplyTo replaces the defaultDuration and maxDuration of the supplied
+// AccessConfig with the durations set in this profile. Durations left unset
+// in the profile are left alone.
+func (p *DurationProfile) ApplyTo(cfg *AccessConfig) {
+	if p.DefaultDuration != "" {
+		cfg.DefaultDuration = p.DefaultDuration
+	}
+	if p.MaxDuration != "" {
+		cfg.MaxDuration = p.MaxDuration
+	}
+}
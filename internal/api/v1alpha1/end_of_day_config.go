@@ -0,0 +1,35 @@
+package v1alpha1
+
+import (
+	"time"
+)
+
+// EndOfDayConfig configures an Access Template to end all of the access it
+// grants at midnight, regardless of the duration that was requested. This is
+// required by some compliance regimes.
+type EndOfDayConfig struct {
+	// TimeZone is the IANA time zone name (eg, "America/New_York") that
+	// "midnight" is calculated in.
+	//
+	// +kubebuilder:default:="UTC"
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// GetLocation returns the time.Location for the Spec.timeZone field, defaulting to UTC.
+func (c *EndOfDayConfig) GetLocation() (*time.Location, error) {
+	if c.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(c.TimeZone)
+}
+
+// NextMidnight returns the first midnight (in the configured time zone) after
+// the supplied time.
+func (c *EndOfDayConfig) NextMidnight(t time.Time) (time.Time, error) {
+	loc, err := c.GetLocation()
+	if err != nil {
+		return time.Time{}, err
+	}
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day+1, 0, 0, 0, 0, loc), nil
+}
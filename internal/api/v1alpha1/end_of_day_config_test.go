@@ -0,0 +1,34 @@
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EndOfDayConfig", func() {
+	Context("NextMidnight()", func() {
+		It("Should default to midnight UTC", func() {
+			cfg := &EndOfDayConfig{}
+			ret, err := cfg.NextMidnight(time.Date(2023, 3, 1, 22, 30, 0, 0, time.UTC))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(ret).To(Equal(time.Date(2023, 3, 2, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("Should calculate midnight in the configured time zone", func() {
+			cfg := &EndOfDayConfig{TimeZone: "America/New_York"}
+
+			// 03:00 UTC is still the previous evening in New York
+			ret, err := cfg.NextMidnight(time.Date(2023, 3, 2, 3, 0, 0, 0, time.UTC))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(ret.UTC()).To(Equal(time.Date(2023, 3, 2, 5, 0, 0, 0, time.UTC)))
+		})
+
+		It("Should reject an unknown time zone", func() {
+			cfg := &EndOfDayConfig{TimeZone: "Mars/Olympus_Mons"}
+			_, err := cfg.NextMidnight(time.Now())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -14,6 +14,8 @@ import (
 	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -130,6 +132,14 @@ var _ = Describe("ExecAccessRequest", Ordered, func() {
 			}
 		)
 
+		// ValidateCreate() looks up the template that the request points to,
+		// so the request must live alongside the template created in the
+		// outer BeforeEach().
+		BeforeEach(func() {
+			request.ObjectMeta.Namespace = namespace.Name
+			request.Spec.TemplateName = template.Name
+		})
+
 		// TODO: The "Userinfo" checks should move into an authentication
 		// package so that we can write one set of tests for all of the
 		// Validate* functions.
@@ -226,6 +236,653 @@ var _ = Describe("ExecAccessRequest", Ordered, func() {
 			err = request.ValidateUpdate(*admissionRequest, request)
 			Expect(err).To(Not(HaveOccurred()))
 		})
+
+		It("Update of the template of a request is always rejected...", func() {
+			update := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: "UPDATE",
+					UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+				},
+			}
+			changed := request.DeepCopy()
+			changed.Spec.TemplateName = "other"
+			err = changed.ValidateUpdate(update, request)
+			Expect(err).To(MatchError(
+				"error - Spec.TemplateName and Spec.TemplateNamespace are immutable fields, create a new ExecAccessRequest instead",
+			))
+
+			changed = request.DeepCopy()
+			changed.Spec.TemplateNamespace = "shared"
+			err = changed.ValidateUpdate(update, request)
+			Expect(err).To(MatchError(ContainSubstring("are immutable fields")))
+		})
+
+		It("Update of a pending request is validated against the template again...", func() {
+			update := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: "UPDATE",
+					UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+				},
+			}
+
+			// The template declares no parameters
+			changed := request.DeepCopy()
+			changed.Spec.ParameterValues = map[string]string{"database": "orders"}
+			err = changed.ValidateUpdate(update, request)
+			Expect(err).To(MatchError(ContainSubstring("unknown parameters: database")))
+
+			// The request is validated as its requester, not the updating user
+			template.Spec.AccessConfig.AllowedRequesters = &AllowedRequesters{Users: []string{"alice"}}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			pending := request.DeepCopy()
+			pending.SetAnnotations(map[string]string{RequestedByAnnotation: "bob"})
+			changed = pending.DeepCopy()
+			changed.Spec.Duration = "2h"
+			err = changed.ValidateUpdate(update, pending)
+			Expect(err).To(MatchError(ContainSubstring("bob is not an allowed requester")))
+
+			pending.SetAnnotations(map[string]string{RequestedByAnnotation: "alice"})
+			changed = pending.DeepCopy()
+			changed.Spec.Duration = "2h"
+			err = changed.ValidateUpdate(update, pending)
+			Expect(err).To(Not(HaveOccurred()))
+
+			// Changes outside of the Spec are not validated again
+			changed = request.DeepCopy()
+			changed.SetLabels(map[string]string{"team": "payments"})
+			err = changed.ValidateUpdate(update, request)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Update of a granted request only allows the duration to change...", func() {
+			update := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: "UPDATE",
+					UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+				},
+			}
+			granted := request.DeepCopy()
+			granted.Spec.Duration = "1h"
+			granted.Status.Conditions = []metav1.Condition{{
+				Type:   ConditionAccessResourcesCreated.String(),
+				Status: metav1.ConditionTrue,
+				Reason: "Success",
+			}}
+
+			changed := granted.DeepCopy()
+			changed.Spec.ParameterValues = map[string]string{"database": "payments"}
+			err = changed.ValidateUpdate(update, granted)
+			Expect(err).To(MatchError(ContainSubstring("Spec.ParameterValues can not be changed")))
+
+			changed = granted.DeepCopy()
+			changed.Spec.TargetNode = "node-b"
+			err = changed.ValidateUpdate(update, granted)
+			Expect(err).To(MatchError(ContainSubstring("Spec.TargetNode can not be changed")))
+
+			// Extending the access is still allowed
+			changed = granted.DeepCopy()
+			changed.Spec.Duration = "2h"
+			err = changed.ValidateUpdate(update, granted)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		createRequest := func(r *ExecAccessRequest) *admission.Request {
+			requestBytes, _ := json.Marshal(r)
+			return &admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Resource:        gvr,
+					RequestKind:     &gvk,
+					RequestResource: &gvr,
+					Name:            requestName,
+					Namespace:       namespace.Name,
+					Operation:       "CREATE",
+					UserInfo: authenticationv1.UserInfo{
+						Username: "admin",
+					},
+					Object: runtime.RawExtension{
+						Raw: requestBytes,
+					},
+				},
+			}
+		}
+
+		It("Create against a missing template is rejected with NotFound...", func() {
+			missing := request.DeepCopy()
+			missing.Spec.TemplateName = "missing"
+			err = missing.ValidateCreate(*createRequest(missing))
+			Expect(err).To(HaveOccurred())
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Create against a paused template is rejected...", func() {
+			template.Spec.AccessConfig.Paused = true
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			err = request.ValidateCreate(*createRequest(request))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("is paused"))
+		})
+
+		It("Create against an invalid template is rejected...", func() {
+			meta.SetStatusCondition(template.GetStatus().GetConditions(), metav1.Condition{
+				Type:    ConditionTemplateValid.String(),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(metav1.StatusReasonInvalid),
+				Message: "broken",
+			})
+			err = k8sClient.Status().Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			err = request.ValidateCreate(*createRequest(request))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("is not valid: broken"))
+		})
+
+		delegate := func(r *ExecAccessRequest, requestFor string, groups ...string) (*ExecAccessRequest, *admission.Request) {
+			delegated := r.DeepCopy()
+			delegated.Spec.RequestFor = requestFor
+			admissionReq := createRequest(delegated)
+			admissionReq.UserInfo.Groups = groups
+			return delegated, admissionReq
+		}
+
+		It("Create on behalf of another user is allowed for members of the delegator groups...", func() {
+			template.Spec.AccessConfig.AllowedDelegators = []string{"leads"}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			delegated, admissionReq := delegate(request, "bob", "devs", "leads")
+			err = delegated.ValidateCreate(*admissionReq)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create on behalf of another user is rejected for everyone else...", func() {
+			template.Spec.AccessConfig.AllowedDelegators = []string{"leads"}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			delegated, admissionReq := delegate(request, "bob", "devs")
+			err = delegated.ValidateCreate(*admissionReq)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("admin is not allowed to request access on behalf of other users"))
+		})
+
+		It("Create on behalf of another user is rejected when the template has no delegators...", func() {
+			delegated, admissionReq := delegate(request, "bob", "leads")
+			err = delegated.ValidateCreate(*admissionReq)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("is not allowed to request access on behalf of other users"))
+		})
+
+		It("Create on behalf of yourself needs no delegation...", func() {
+			delegated, admissionReq := delegate(request, "admin")
+			err = delegated.ValidateCreate(*admissionReq)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create by an allowed requester user is allowed...", func() {
+			template.Spec.AccessConfig.AllowedRequesters = &AllowedRequesters{Users: []string{"admin"}}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			err = request.ValidateCreate(*createRequest(request))
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create by a member of an allowed requester group is allowed...", func() {
+			template.Spec.AccessConfig.AllowedRequesters = &AllowedRequesters{Groups: []string{"oncall"}}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			_, admissionReq := delegate(request, "", "devs", "oncall")
+			err = request.ValidateCreate(*admissionReq)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create by anybody else is rejected when allowedRequesters is set...", func() {
+			template.Spec.AccessConfig.AllowedRequesters = &AllowedRequesters{
+				Users:  []string{"bob"},
+				Groups: []string{"oncall"},
+			}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			_, admissionReq := delegate(request, "", "devs")
+			err = request.ValidateCreate(*admissionReq)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("admin is not an allowed requester of template " + template.Name))
+		})
+
+		It("Create from a namespace the template does not allow is rejected...", func() {
+			template.Spec.AccessConfig.AllowedNamespaces = []string{"team-a-*"}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			err = request.ValidateCreate(*createRequest(request))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("does not allow requests from namespace " + request.Namespace))
+
+			template.Spec.AccessConfig.AllowedNamespaces = []string{request.Namespace}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			err = request.ValidateCreate(*createRequest(request))
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create asking for a subset of the allowed verbs is allowed...", func() {
+			template.Spec.AccessConfig.VerbsByGroup = map[string][]string{"devs": {"create", "get"}}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			narrowed := request.DeepCopy()
+			narrowed.Spec.RequestedVerbs = []string{"get"}
+			_, admissionReq := delegate(narrowed, "", "devs")
+			err = narrowed.ValidateCreate(*admissionReq)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create asking for more verbs than allowed is rejected...", func() {
+			template.Spec.AccessConfig.VerbsByGroup = map[string][]string{"devs": {"create", "get"}}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			broad := request.DeepCopy()
+			broad.Spec.RequestedVerbs = []string{"get", "delete"}
+			_, admissionReq := delegate(broad, "", "devs")
+			err = broad.ValidateCreate(*admissionReq)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"error - template " + template.Name + " does not allow delete on pods/exec (allowed: create, get)",
+			))
+		})
+
+		It("Create asking for verbs against a ClusterRole template is rejected...", func() {
+			template.Spec.AccessConfig.ClusterRoleRef = "view"
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			narrowed := request.DeepCopy()
+			narrowed.Spec.RequestedVerbs = []string{"get"}
+			err = narrowed.ValidateCreate(*createRequest(narrowed))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("which requestedVerbs cannot narrow"))
+		})
+
+		It("Create asking for an access command override the template allows is allowed...", func() {
+			template.Spec.AccessConfig.AccessCommandOverrides = []string{
+				"kubectl exec -ti -n {{.Namespace}} {{.PodName}} -- /bin/bash",
+			}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			overridden := request.DeepCopy()
+			overridden.Spec.AccessCommandOverride = "kubectl exec -ti -n {{.Namespace}} {{.PodName}} -- /bin/bash"
+			err = overridden.ValidateCreate(*createRequest(overridden))
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create asking for an access command override the template does not allow is rejected...", func() {
+			overridden := request.DeepCopy()
+			overridden.Spec.AccessCommandOverride = "kubectl debug -ti -n {{.Namespace}} {{.PodName}}"
+			err = overridden.ValidateCreate(*createRequest(overridden))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("does not allow the access command override"))
+		})
+
+		It("Create with a justification conforming to the template is allowed...", func() {
+			template.Spec.AccessConfig.JustificationPattern = `JIRA-\d+`
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			justified := request.DeepCopy()
+			justified.Spec.Justification = "JIRA-1234"
+			err = justified.ValidateCreate(*createRequest(justified))
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create with a justification not conforming to the template is rejected...", func() {
+			template.Spec.AccessConfig.JustificationPattern = `JIRA-\d+`
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			justified := request.DeepCopy()
+			justified.Spec.Justification = "because I need it"
+			err = justified.ValidateCreate(*createRequest(justified))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("does not match"))
+		})
+
+		It("Update of the access command override is rejected...", func() {
+			overridden := request.DeepCopy()
+			overridden.Spec.AccessCommandOverride = "kubectl exec -ti -n {{.Namespace}} {{.PodName}} -- /bin/bash"
+			err = overridden.ValidateUpdate(*createRequest(overridden), request)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("Spec.AccessCommandOverride is an immutable field"))
+		})
+
+		It("Update of the delegated user is rejected...", func() {
+			delegated, _ := delegate(request, "bob")
+			err = delegated.ValidateUpdate(*createRequest(delegated), request)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("Spec.RequestFor is an immutable field"))
+		})
+
+		updateRequest := func(oldReq, newReq *ExecAccessRequest, user string) *admission.Request {
+			oldBytes, _ := json.Marshal(oldReq)
+			newBytes, _ := json.Marshal(newReq)
+			return &admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Resource:        gvr,
+					RequestKind:     &gvk,
+					RequestResource: &gvr,
+					Name:            requestName,
+					Namespace:       namespace.Name,
+					Operation:       "UPDATE",
+					UserInfo: authenticationv1.UserInfo{
+						Username: user,
+					},
+					Object:    runtime.RawExtension{Raw: newBytes},
+					OldObject: runtime.RawExtension{Raw: oldBytes},
+				},
+			}
+		}
+		approve := func(r *ExecAccessRequest) *ExecAccessRequest {
+			approved := r.DeepCopy()
+			if approved.Annotations == nil {
+				approved.Annotations = map[string]string{}
+			}
+			approved.Annotations[ApproveAnnotation] = "true"
+			return approved
+		}
+
+		It("Default() records each distinct approver for the two-person rule...", func() {
+			first := approve(request)
+			err = first.Default(*updateRequest(request, first, "alice"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(first.Annotations).To(Not(HaveKey(ApproveAnnotation)))
+			Expect(GetApprovers(first)).To(Equal([]string{"alice"}))
+
+			second := approve(first)
+			err = second.Default(*updateRequest(first, second, "bob"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(second.Annotations).To(Not(HaveKey(ApproveAnnotation)))
+			Expect(GetApprovers(second)).To(Equal([]string{"alice", "bob"}))
+		})
+
+		It("Default() records the window that an approval covers...", func() {
+			first := approve(request)
+			first.Annotations[ApproveAnnotation] = "2h"
+			err = first.Default(*updateRequest(request, first, "alice"))
+			Expect(err).To(Not(HaveOccurred()))
+			approvedUntil, ok := GetApprovedUntil(first)
+			Expect(ok).To(BeTrue())
+			Expect(approvedUntil).To(BeTemporally("~", time.Now().Add(2*time.Hour), 2*time.Second))
+
+			// An approval without a window keeps the window, and it can not be forged
+			second := approve(first)
+			second.Annotations[ApprovedUntilAnnotation] = "2099-01-01T00:00:00Z"
+			err = second.Default(*updateRequest(first, second, "bob"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(second.Annotations[ApprovedUntilAnnotation]).To(Equal(first.Annotations[ApprovedUntilAnnotation]))
+
+			invalid := approve(request)
+			invalid.Annotations[ApproveAnnotation] = "forever"
+			err = invalid.Default(*updateRequest(request, invalid, "alice"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("must be \"true\" or a positive duration"))
+		})
+
+		It("Default() rejects an approver approving twice...", func() {
+			first := approve(request)
+			err = first.Default(*updateRequest(request, first, "alice"))
+			Expect(err).To(Not(HaveOccurred()))
+
+			again := approve(first)
+			err = again.Default(*updateRequest(first, again, "alice"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("alice has already approved"))
+		})
+
+		It("Default() rejects an approval without a user identity...", func() {
+			approved := approve(request)
+			err = approved.Default(*updateRequest(request, approved, ""))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Default() discards direct changes to the approved-by annotation...", func() {
+			first := approve(request)
+			err = first.Default(*updateRequest(request, first, "alice"))
+			Expect(err).To(Not(HaveOccurred()))
+
+			forged := first.DeepCopy()
+			forged.Annotations[ApprovedByAnnotation] = "alice,mallory"
+			err = forged.Default(*updateRequest(first, forged, "mallory"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(GetApprovers(forged)).To(Equal([]string{"alice"}))
+		})
+
+		It("Default() records the requester on create, and keeps it on update...", func() {
+			created := request.DeepCopy()
+			err = created.Default(*createRequest(created))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(GetRequester(created)).To(Equal("admin"))
+
+			forged := created.DeepCopy()
+			forged.Annotations[RequestedByAnnotation] = "mallory"
+			err = forged.Default(*updateRequest(created, forged, "mallory"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(GetRequester(forged)).To(Equal("admin"))
+		})
+
+		It("Default() records the groups of the requester on create, and keeps them on update...", func() {
+			created := request.DeepCopy()
+			admissionRequest := createRequest(created)
+			admissionRequest.UserInfo.Groups = []string{"system:authenticated", "seniors"}
+			err = created.Default(*admissionRequest)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(GetRequesterGroups(created)).To(Equal([]string{"system:authenticated", "seniors"}))
+
+			forged := created.DeepCopy()
+			forged.Annotations[RequestedByGroupsAnnotation] = "admins"
+			err = forged.Default(*updateRequest(created, forged, "mallory"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(GetRequesterGroups(forged)).To(Equal([]string{"system:authenticated", "seniors"}))
+		})
+
+		It("Default() mirrors the incident ID into a label, and discards direct changes to it...", func() {
+			tagged := request.DeepCopy()
+			tagged.Spec.IncidentID = "INC-1234"
+			err = tagged.Default(*createRequest(tagged))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(tagged.Labels).To(HaveKeyWithValue(IncidentIDLabel, "INC-1234"))
+
+			forged := tagged.DeepCopy()
+			forged.Labels[IncidentIDLabel] = "INC-9999"
+			err = forged.Default(*updateRequest(tagged, forged, "mallory"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(forged.Labels).To(HaveKeyWithValue(IncidentIDLabel, "INC-1234"))
+
+			untagged := forged.DeepCopy()
+			untagged.Spec.IncidentID = ""
+			err = untagged.Default(*updateRequest(forged, untagged, "admin"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(untagged.Labels).To(Not(HaveKey(IncidentIDLabel)))
+		})
+
+		It("Default() mirrors the session ID into a label...", func() {
+			session := request.DeepCopy()
+			session.Spec.SessionID = "debug-1"
+			err = session.Default(*createRequest(session))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(session.Labels).To(HaveKeyWithValue(SessionIDLabel, "debug-1"))
+
+			forged := session.DeepCopy()
+			forged.Labels[SessionIDLabel] = "debug-2"
+			err = forged.Default(*updateRequest(session, forged, "mallory"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(forged.Labels).To(HaveKeyWithValue(SessionIDLabel, "debug-1"))
+		})
+
+		It("Default() does not allow the end of a session to be undone...", func() {
+			created := request.DeepCopy()
+			created.Annotations = map[string]string{SessionEndedAnnotation: "other"}
+			err = created.Default(*createRequest(created))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(created.Annotations).To(Not(HaveKey(SessionEndedAnnotation)))
+
+			ended := request.DeepCopy()
+			ended.Annotations = map[string]string{SessionEndedAnnotation: "other"}
+			err = ended.Default(*updateRequest(request, ended, "controller"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(ended.Annotations).To(HaveKeyWithValue(SessionEndedAnnotation, "other"))
+
+			forged := ended.DeepCopy()
+			delete(forged.Annotations, SessionEndedAnnotation)
+			err = forged.Default(*updateRequest(ended, forged, "mallory"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(forged.Annotations).To(HaveKeyWithValue(SessionEndedAnnotation, "other"))
+		})
+
+		It("Default() rejects the requester approving their own request...", func() {
+			requested := request.DeepCopy()
+			requested.Annotations = map[string]string{RequestedByAnnotation: "alice"}
+
+			self := approve(requested)
+			err = self.Default(*updateRequest(requested, self, "alice"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("alice requested .*cannot approve their own request"))
+		})
+
+		It("Default() accepts an approval from a third party...", func() {
+			requested := request.DeepCopy()
+			requested.Annotations = map[string]string{RequestedByAnnotation: "alice"}
+
+			other := approve(requested)
+			err = other.Default(*updateRequest(requested, other, "bob"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(GetApprovers(other)).To(Equal([]string{"bob"}))
+			Expect(GetRequester(other)).To(Equal("alice"))
+		})
+
+		It("Default() does not record approvals on create...", func() {
+			created := approve(request)
+			created.Annotations[ApprovedByAnnotation] = "alice,bob"
+			err = created.Default(*createRequest(created))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(created.Annotations).To(Not(HaveKey(ApproveAnnotation)))
+			Expect(GetApprovers(created)).To(BeEmpty())
+		})
+
+		revoke := func(r *ExecAccessRequest, reason string) *ExecAccessRequest {
+			revoked := r.DeepCopy()
+			if revoked.Annotations == nil {
+				revoked.Annotations = map[string]string{}
+			}
+			revoked.Annotations[RevokeAnnotation] = reason
+			return revoked
+		}
+		requireRevokeReason := func() {
+			template.Spec.AccessConfig.RequireRevokeReason = true
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+		}
+
+		It("Default() records a revocation with a reason...", func() {
+			requireRevokeReason()
+
+			revoked := revoke(request, "incident resolved")
+			err = revoked.Default(*updateRequest(request, revoked, "alice"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(revoked.Annotations).To(HaveKeyWithValue(RevokeAnnotation, "incident resolved"))
+			Expect(revoked.Annotations).To(HaveKeyWithValue(RevokedByAnnotation, "alice"))
+		})
+
+		It("Default() rejects a revocation without a reason when the template requires one...", func() {
+			requireRevokeReason()
+
+			revoked := revoke(request, " ")
+			err = revoked.Default(*updateRequest(request, revoked, "alice"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("requires a reason to revoke"))
+		})
+
+		It("Default() accepts a revocation without a reason otherwise...", func() {
+			revoked := revoke(request, "")
+			err = revoked.Default(*updateRequest(request, revoked, "alice"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(revoked.Annotations).To(HaveKeyWithValue(RevokedByAnnotation, "alice"))
+		})
+
+		It("Default() does not allow a revocation to be undone or re-attributed...", func() {
+			revoked := revoke(request, "incident resolved")
+			err = revoked.Default(*updateRequest(request, revoked, "alice"))
+			Expect(err).To(Not(HaveOccurred()))
+
+			forged := revoked.DeepCopy()
+			delete(forged.Annotations, RevokeAnnotation)
+			forged.Annotations[RevokedByAnnotation] = "mallory"
+			err = forged.Default(*updateRequest(revoked, forged, "mallory"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(forged.Annotations).To(HaveKeyWithValue(RevokeAnnotation, "incident resolved"))
+			Expect(forged.Annotations).To(HaveKeyWithValue(RevokedByAnnotation, "alice"))
+		})
+
+		It("Default() does not accept revocations on create...", func() {
+			created := revoke(request, "nope")
+			created.Annotations[RevokedByAnnotation] = "alice"
+			err = created.Default(*createRequest(created))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(created.Annotations).To(Not(HaveKey(RevokeAnnotation)))
+			Expect(created.Annotations).To(Not(HaveKey(RevokedByAnnotation)))
+		})
+
+		expire := func(r *ExecAccessRequest, value string) *ExecAccessRequest {
+			expired := r.DeepCopy()
+			if expired.Annotations == nil {
+				expired.Annotations = map[string]string{}
+			}
+			expired.Annotations[ExpireAnnotation] = value
+			return expired
+		}
+
+		It("Default() records the user that forced an expiration...", func() {
+			expired := expire(request, "mallory")
+			err = expired.Default(*updateRequest(request, expired, "alice"))
+			Expect(err).To(Not(HaveOccurred()))
+			user, forced := GetForcedExpiration(expired)
+			Expect(forced).To(BeTrue())
+			Expect(user).To(Equal("alice"))
+		})
+
+		It("Default() rejects a forced expiration without a user identity...", func() {
+			expired := expire(request, "")
+			err = expired.Default(*updateRequest(request, expired, ""))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("require a user identity"))
+		})
+
+		It("Default() does not allow a forced expiration to be undone or re-attributed...", func() {
+			expired := expire(request, "")
+			err = expired.Default(*updateRequest(request, expired, "alice"))
+			Expect(err).To(Not(HaveOccurred()))
+
+			forged := expired.DeepCopy()
+			delete(forged.Annotations, ExpireAnnotation)
+			err = forged.Default(*updateRequest(expired, forged, "mallory"))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(forged.Annotations).To(HaveKeyWithValue(ExpireAnnotation, "alice"))
+		})
+
+		It("Default() does not accept forced expirations on create...", func() {
+			created := expire(request, "alice")
+			err = created.Default(*createRequest(created))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(created.Annotations).To(Not(HaveKey(ExpireAnnotation)))
+		})
 	})
 
 	// Setup code below here - this code rarely changes, the tests above are
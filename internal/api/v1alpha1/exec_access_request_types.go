@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -34,10 +35,23 @@ type ExecAccessRequestSpec struct {
 	// +kubebuilder:validation:Required
 	TemplateName string `json:"templateName"`
 
+	// TemplateNamespace is the namespace of the template named by TemplateName. It is only set
+	// to use a template that is shared with the namespace of this request (see the
+	// namespacePattern field of the template). Defaults to the namespace of this request.
+	//
+	// +kubebuilder:validation:Optional
+	TemplateNamespace string `json:"templateNamespace,omitempty"`
+
 	// TargetPod is used to explicitly define the target pod that the Exec privilges should be
 	// granted to. If not supplied, then a random pod is chosen.
 	TargetPod string `json:"targetPod,omitempty"`
 
+	// TargetNode is used to restrict the randomly selected target pod to one
+	// that is running on the named Node. This is useful for node-level
+	// troubleshooting. If TargetPod is also set, that Pod must be running on
+	// this Node.
+	TargetNode string `json:"targetNode,omitempty"`
+
 	// Duration sets the length of time from the `spec.creationTimestamp` that this object will live. After the
 	// time has expired, the resouce will be automatically deleted on the next reconcilliation loop.
 	//
@@ -45,6 +59,85 @@ type ExecAccessRequestSpec struct {
 	//
 	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
 	Duration string `json:"duration,omitempty"`
+
+	// TransferTo hands the access granted by this request over to another user, for example
+	// when the on-call engineer rotates mid-incident. When set, the RoleBinding subjects are
+	// replaced with this single user. The original expiration time of the request is preserved.
+	//
+	// Only the requester, or one of the approvers, of the request may transfer it. The new owner
+	// is held to the same rules as a request made on their behalf (see requestFor).
+	//
+	// +kubebuilder:validation:Optional
+	TransferTo string `json:"transferTo,omitempty"`
+
+	// RequestFor names the user that the access is being requested on behalf of, for example
+	// when a lead provisions access for a teammate who cannot request it themselves. When set,
+	// the RoleBinding subject is this single user rather than the template's allowed groups. Only
+	// members of the ExecAccessTemplate's `spec.accessConfig.allowedDelegators` groups may set it, and it can not
+	// be changed after the request has been created.
+	//
+	// +kubebuilder:validation:Optional
+	RequestFor string `json:"requestFor,omitempty"`
+
+	// ParameterValues supplies values for the parameters declared in the
+	// `spec.accessConfig.parameters` field of the ExecAccessTemplate. Requests that omit a
+	// required parameter are rejected.
+	//
+	// +kubebuilder:validation:Optional
+	ParameterValues map[string]string `json:"parameterValues,omitempty"`
+
+	// IncidentID tags this request with the ID of the incident (eg "INC-1234") that the access
+	// is needed for. It is mirrored into the `crds.wizardofoz.co/incident-id` label, so that all
+	// of the requests for an incident can be listed (and revoked) together once it is closed.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`
+	IncidentID string `json:"incidentID,omitempty"`
+
+	// SessionID groups this request with the other Access Requests in its namespace that share
+	// the same ID, for coordinated debugging. It is mirrored into the `crds.wizardofoz.co/session-id`
+	// label. When the access of any request in the session ends (it expires, or is revoked or
+	// denied), the access of the other requests in the session made by the same requester ends
+	// with it. The requests of other users in the session are left alone.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`
+	SessionID string `json:"sessionID,omitempty"`
+
+	// RequestedVerbs narrows the access granted by this request to a subset of the verbs that the
+	// template allows the requester on the `pods/exec` subresource of the target Pod (the verbs
+	// of their groups in its `verbsByGroup`, or the default verbs), for least-privilege access.
+	// Requests for verbs beyond that are rejected. When empty, all of the allowed verbs are
+	// granted.
+	//
+	// +kubebuilder:validation:Optional
+	RequestedVerbs []string `json:"requestedVerbs,omitempty"`
+
+	// Platform hints at the platform (or shell) of the requester, eg "linux", "windows" or
+	// "powershell". It selects the matching entry of the `spec.accessConfig.accessCommands` of
+	// the template, which renders the command reported in the status once access is granted.
+	//
+	// +kubebuilder:validation:Optional
+	Platform string `json:"platform,omitempty"`
+
+	// AccessCommandOverride replaces the command reported in the status once access is granted
+	// (eg, to use a different shell) with one of the `spec.accessConfig.accessCommandOverrides`
+	// that the template allows. It is a Go text/template, rendered like the accessCommands of
+	// the template, and must match one of the allowed overrides exactly. It can not be changed
+	// after the request has been created.
+	//
+	// +kubebuilder:validation:Optional
+	AccessCommandOverride string `json:"accessCommandOverride,omitempty"`
+
+	// Justification states why the access is needed - typically the ID or the URL of the
+	// ticket that it is needed for. It is recorded with the request for auditing. If the
+	// template sets a `spec.accessConfig.justificationPattern`, the justification is required
+	// and must match it. It can not be changed after access has been granted.
+	//
+	// +kubebuilder:validation:Optional
+	Justification string `json:"justification,omitempty"`
 }
 
 // ExecAccessRequestStatus defines the observed state of ExecAccessRequest
@@ -53,6 +146,26 @@ type ExecAccessRequestStatus struct {
 
 	// The Target Pod Name where access has been granted
 	PodName string `json:"podName,omitempty"`
+
+	// PodUID is the UID of the Target Pod at the time access was granted. If the Pod is
+	// replaced by a new Pod with the same name, the access is revoked rather than silently
+	// carried over to the new Pod.
+	PodUID types.UID `json:"podUID,omitempty"`
+
+	// FallbackTemplate is the name of the fallbackTemplate that access was granted through,
+	// when the template of the request had no live Pods to pick from. Empty when access was
+	// granted through the template of the request itself.
+	FallbackTemplate string `json:"fallbackTemplate,omitempty"`
+
+	// TargetPodNode is the name of the Node that the Target Pod runs on, as of the last
+	// reconcile.
+	TargetPodNode string `json:"targetPodNode,omitempty"`
+
+	// TargetPodIP is the IP address of the Target Pod, as of the last reconcile.
+	TargetPodIP string `json:"targetPodIP,omitempty"`
+
+	// TargetPodPhase is the phase of the Target Pod, as of the last reconcile.
+	TargetPodPhase corev1.PodPhase `json:"targetPodPhase,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -62,6 +175,7 @@ type ExecAccessRequestStatus struct {
 //
 // +kubebuilder:printcolumn:name="Template",type="string",JSONPath=".spec.templateName",description="Access Template"
 // +kubebuilder:printcolumn:name="Pod",type="string",JSONPath=".status.podName",description="Target Pod Name"
+// +kubebuilder:printcolumn:name="Expires",type="string",JSONPath=".status.accessExpiresAt",description="When the access ends"
 // +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready",description="Is request ready?"
 type ExecAccessRequest struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -82,12 +196,45 @@ func (r *ExecAccessRequest) GetStatus() ICoreStatus {
 	return &r.Status
 }
 
-// GetTemplate returns a populated ExecAccessTemplate that this ExecAccessRequest is referencing.
+// IsReady implements the ICoreResource interface
+func (r *ExecAccessRequest) IsReady() bool {
+	return r.Status.isReadyAt(r.Generation)
+}
+
+// GetTemplate returns a populated ExecAccessTemplate that this ExecAccessRequest is referencing,
+// with the Spec.parameterValues of the request substituted into it (see
+// ExecAccessTemplate.WithParameters).
+//
+// Returns:
+//   - An "error" if the template can not be fetched, or if the parameter
+//     values no longer satisfy the parameters declared by the template
 func (r *ExecAccessRequest) GetTemplate(
 	ctx context.Context,
 	cl client.Client,
 ) (ITemplateResource, error) {
-	return GetExecAccessTemplate(ctx, cl, r.Spec.TemplateName, r.Namespace)
+	tmpl, err := r.resolveTemplate(ctx, cl)
+	if err != nil {
+		return tmpl, err
+	}
+
+	// The webhook rejects bad values up front, but we verify again here in
+	// case the template has changed since.
+	params, err := ResolveParameters(tmpl.Spec.AccessConfig.GetParameters(), r.GetParameterValues())
+	if err != nil {
+		return tmpl, fmt.Errorf("template %s: %w", tmpl.GetName(), err)
+	}
+	return tmpl.WithParameters(params), nil
+}
+
+// resolveTemplate fetches the template named by the request through the
+// supplied reader, and shares it with the namespace of the request if it lives
+// in another namespace (see shareTemplate).
+func (r *ExecAccessRequest) resolveTemplate(ctx context.Context, cl client.Reader) (*ExecAccessTemplate, error) {
+	tmpl, err := GetExecAccessTemplate(ctx, cl, r.Spec.TemplateName, r.GetTemplateNamespace())
+	if err != nil {
+		return tmpl, err
+	}
+	return tmpl, shareTemplate(ctx, cl, tmpl, r.Namespace)
 }
 
 // GetTemplateName returns the user supplied Spec.templateName field
@@ -95,6 +242,15 @@ func (r *ExecAccessRequest) GetTemplateName() string {
 	return r.Spec.TemplateName
 }
 
+// GetTemplateNamespace returns the Spec.templateNamespace field, or the
+// namespace of the request if it is not set.
+func (r *ExecAccessRequest) GetTemplateNamespace() string {
+	if r.Spec.TemplateNamespace != "" {
+		return r.Spec.TemplateNamespace
+	}
+	return r.Namespace
+}
+
 // GetDuration conforms to the interfaces.OzRequestResource interface
 func (r *ExecAccessRequest) GetDuration() (time.Duration, error) {
 	if r.Spec.Duration != "" {
@@ -103,6 +259,65 @@ func (r *ExecAccessRequest) GetDuration() (time.Duration, error) {
 	return time.Duration(0), nil
 }
 
+// GetTransferTo conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) GetTransferTo() string {
+	return r.Spec.TransferTo
+}
+
+// GetRequestFor conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) GetRequestFor() string {
+	return r.Spec.RequestFor
+}
+
+// GetParameterValues conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) GetParameterValues() map[string]string {
+	return r.Spec.ParameterValues
+}
+
+// GetIncidentID conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) GetIncidentID() string {
+	return r.Spec.IncidentID
+}
+
+// GetSessionID conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) GetSessionID() string {
+	return r.Spec.SessionID
+}
+
+// GetRequestedVerbs conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) GetRequestedVerbs() []string {
+	return r.Spec.RequestedVerbs
+}
+
+// GetPlatform conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) GetPlatform() string {
+	return r.Spec.Platform
+}
+
+// GetAccessCommandOverride conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) GetAccessCommandOverride() string {
+	return r.Spec.AccessCommandOverride
+}
+
+// GetJustification conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) GetJustification() string {
+	return r.Spec.Justification
+}
+
+// IsEquivalentTo conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) IsEquivalentTo(other IRequestResource) bool {
+	o, ok := other.(*ExecAccessRequest)
+	return ok &&
+		r.Spec.TemplateName == o.Spec.TemplateName &&
+		r.GetTemplateNamespace() == o.GetTemplateNamespace() &&
+		r.Spec.TargetPod == o.Spec.TargetPod &&
+		r.Spec.TargetNode == o.Spec.TargetNode &&
+		r.Spec.TransferTo == o.Spec.TransferTo &&
+		r.Spec.RequestFor == o.Spec.RequestFor &&
+		equalParameterValues(r.Spec.ParameterValues, o.Spec.ParameterValues) &&
+		equalVerbs(r.Spec.RequestedVerbs, o.Spec.RequestedVerbs)
+}
+
 // GetUptime conforms to the interfaces.OzRequestResource interface
 func (r *ExecAccessRequest) GetUptime() time.Duration {
 	now := time.Now()
@@ -124,6 +339,16 @@ func (r *ExecAccessRequest) GetPodName() string {
 	return r.Status.PodName
 }
 
+// SetPodUID conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) SetPodUID(uid types.UID) {
+	r.Status.PodUID = uid
+}
+
+// GetPodUID conforms to the interfaces.OzRequestResource interface
+func (r *ExecAccessRequest) GetPodUID() types.UID {
+	return r.Status.PodUID
+}
+
 // GetExecAccessRequest returns back an ExecAccessRequest resource matching the request supplied to
 // the reconciler loop, or returns back an error.
 func GetExecAccessRequest(
@@ -17,8 +17,10 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -34,6 +36,8 @@ var execaccessrequestlog = logf.Log.WithName("execaccessrequest-resource")
 // accept MutatingWebhookConfiguration and ValidatingWebhookConfiguration calls
 // from the Kubernetes API server.
 func (r *ExecAccessRequest) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookReader = mgr.GetAPIReader()
+
 	if err := webhook.RegisterContextualDefaulter(r, mgr); err != nil {
 		panic(err)
 	}
@@ -51,9 +55,32 @@ func (r *ExecAccessRequest) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 var _ webhook.IContextuallyDefaultableObject = &ExecAccessRequest{}
 
-// Default implements webhook.Defaulter so a webhook will be registered for the type
-func (r *ExecAccessRequest) Default(_ admission.Request) error {
-	return nil
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+// It records the identity of the requester, the incident the request is tagged with,
+// and any approval, revocation or forced expiration of the request.
+// Writes of the LastActiveAnnotation by anybody but the LastActiveWriters are
+// rejected.
+func (r *ExecAccessRequest) Default(req admission.Request) error {
+	if err := recordRequester(req, r); err != nil {
+		return err
+	}
+	recordIncidentID(r)
+	recordSessionID(r)
+	if err := recordApproval(execaccessrequestlog, req, r); err != nil {
+		return err
+	}
+	if err := recordRevocation(execaccessrequestlog, req, r, func() (ITemplateResource, error) {
+		return r.resolveTemplate(context.Background(), webhookReader)
+	}); err != nil {
+		return err
+	}
+	if err := recordForcedExpiration(execaccessrequestlog, req, r); err != nil {
+		return err
+	}
+	if err := recordLastActive(req, r); err != nil {
+		return err
+	}
+	return recordSessionEnded(req, r)
 }
 
 //+kubebuilder:webhook:path=/validate-crds-wizardofoz-co-v1alpha1-execaccessrequest,mutating=false,failurePolicy=fail,sideEffects=None,groups=crds.wizardofoz.co,resources=execaccessrequests,verbs=create;update;delete,versions=v1alpha1,name=vexecaccessrequest.kb.io,admissionReviewVersions=v1
@@ -70,11 +97,18 @@ func (r *ExecAccessRequest) ValidateCreate(req admission.Request) error {
 		// TODO: Make this fail, after we have confidence in the code in a live environment.
 		execaccessrequestlog.Info("WARNING - Create ExecAccessRequest with missing user identity")
 	}
-	return nil
+
+	// Reject requests against templates that are missing, paused or invalid
+	// now, rather than letting the request get stuck in the reconciler.
+	tmpl, err := r.resolveTemplate(context.Background(), webhookReader)
+	if err != nil {
+		return err
+	}
+	return ValidateAccessRequest(execaccessrequestlog, req, r, tmpl)
 }
 
 // ValidateUpdate prevents immutable updates to the ExecAccessRequest.
-func (r *ExecAccessRequest) ValidateUpdate(_ admission.Request, old runtime.Object) error {
+func (r *ExecAccessRequest) ValidateUpdate(req admission.Request, old runtime.Object) error {
 	execaccessrequestlog.Info("validate update", "name", r.Name)
 
 	// https://stackoverflow.com/questions/70650677/manage-immutable-fields-in-kubebuilder-validating-webhook
@@ -84,6 +118,53 @@ func (r *ExecAccessRequest) ValidateUpdate(_ admission.Request, old runtime.Obje
 			"error - Spec.TargetPod is an immutable field, create a new PodAccessRequest instead",
 		)
 	}
+	if r.Spec.RequestFor != oldRequest.Spec.RequestFor {
+		return fmt.Errorf(
+			"error - Spec.RequestFor is an immutable field, create a new ExecAccessRequest instead",
+		)
+	}
+	if r.Spec.AccessCommandOverride != oldRequest.Spec.AccessCommandOverride {
+		return fmt.Errorf(
+			"error - Spec.AccessCommandOverride is an immutable field, create a new ExecAccessRequest instead",
+		)
+	}
+
+	if err := validateTemplateRef("ExecAccessRequest", oldRequest, r); err != nil {
+		return err
+	}
+
+	// Once granted, only the duration (to extend the access) and the owner
+	// (through Spec.transferTo) of the request may change.
+	if err := validateImmutableAfterGrant("ExecAccessRequest", oldRequest,
+		immutableField{"TargetNode", oldRequest.Spec.TargetNode, r.Spec.TargetNode},
+		immutableField{"ParameterValues", oldRequest.Spec.ParameterValues, r.Spec.ParameterValues},
+		immutableField{"IncidentID", oldRequest.Spec.IncidentID, r.Spec.IncidentID},
+		immutableField{"SessionID", oldRequest.Spec.SessionID, r.Spec.SessionID},
+		immutableField{"Justification", oldRequest.Spec.Justification, r.Spec.Justification},
+		immutableField{"RequestedVerbs", oldRequest.Spec.RequestedVerbs, r.Spec.RequestedVerbs},
+	); err != nil {
+		return err
+	}
+
+	// Any other change to the Spec is held to the template, as it would be
+	// on creation.
+	if !equality.Semantic.DeepEqual(oldRequest.Spec, r.Spec) {
+		tmpl, err := r.resolveTemplate(context.Background(), webhookReader)
+		if err != nil {
+			return err
+		}
+		if err := revalidateAccessRequest(req, oldRequest, r, tmpl); err != nil {
+			return err
+		}
+	}
+
+	if err := validateTransfer(req, oldRequest, r, func() (ITemplateResource, error) {
+		return r.resolveTemplate(context.Background(), webhookReader)
+	}); err != nil {
+		return err
+	}
+
+	auditTransfer(execaccessrequestlog, req, oldRequest, r)
 	return nil
 }
 
@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"context"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -34,6 +35,58 @@ type ExecAccessTemplateSpec struct {
 	//
 	// +kubebuilder:validation:Required
 	ControllerTargetRef *CrossVersionObjectReference `json:"controllerTargetRef"`
+
+	// OnlyCurrentGeneration restricts access to the Pods that belong to the
+	// current revision of the target controller (eg the newest ReplicaSet of
+	// a Deployment), rejecting Pods from older or newer revisions. This is
+	// useful when debugging canary rollouts. Not supported for DaemonSets.
+	//
+	// +kubebuilder:default:=false
+	OnlyCurrentGeneration bool `json:"onlyCurrentGeneration,omitempty"`
+
+	// ResolvePodsByOwnerReference restricts access to the Pods that are
+	// actually owned by the target controller, by walking the ownerReferences
+	// of each Pod (eg Deployment -> ReplicaSet -> Pod) rather than relying on
+	// the label selector alone. This is useful when the selector of the
+	// target controller is broad enough to match Pods of other workloads.
+	//
+	// +kubebuilder:default:=false
+	ResolvePodsByOwnerReference bool `json:"resolvePodsByOwnerReference,omitempty"`
+
+	// PodSelectionStrategy controls how the target Pod is picked for an
+	// Access Request that does not set a targetPod. "random" (the default)
+	// picks any candidate Pod. "leastRecentlyUsed" prefers the Pod that was
+	// assigned to a request the longest time ago, to spread debugging load
+	// across the Pods. Pod usage is tracked by the controller in a ConfigMap in
+	// its `--state-namespace`, so it survives restarts of the controller.
+	// "manual" only picks the candidate Pod if there is exactly one - requests
+	// matching several Pods are left waiting, and have to be created again
+	// with one of them as their targetPod.
+	//
+	// +kubebuilder:validation:Enum=random;leastRecentlyUsed;manual
+	// +kubebuilder:default:=random
+	PodSelectionStrategy PodSelectionStrategy `json:"podSelectionStrategy,omitempty"`
+
+	// PreferUngrantedPods steers the selection of the target Pod away from
+	// Pods that already have an active Access Request assigned to them, to
+	// avoid everyone piling onto the same Pod. Pods with active grants are
+	// only picked when every candidate Pod has one. It applies to the
+	// random and leastRecentlyUsed podSelectionStrategies, but never to an
+	// explicit targetPod.
+	//
+	// +kubebuilder:default:=false
+	PreferUngrantedPods bool `json:"preferUngrantedPods,omitempty"`
+
+	// PodSelectionTimeout is how long an Access Request that does not set a
+	// targetPod waits for a candidate Pod to show up (eg during a rollout of
+	// the target controller), counted from when the request was created. Until
+	// then, the controller keeps checking for a Pod instead of failing the
+	// request. When unset, the controller-wide default is used.
+	//
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	//
+	// +kubebuilder:validation:Optional
+	PodSelectionTimeout string `json:"podSelectionTimeout,omitempty"`
 }
 
 // ExecAccessTemplateStatus is the core set of status fields that we expect to be in each and every one of
@@ -47,6 +100,8 @@ type ExecAccessTemplateStatus struct {
 
 // ExecAccessTemplate is the Schema for the execaccesstemplates API
 //
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.controllerTargetRef.name",description="Target Controller"
+// +kubebuilder:printcolumn:name="MaxDuration",type="string",JSONPath=".spec.accessConfig.maxDuration",description="Maximum Access Duration"
 // +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready",description="Is template ready?"
 type ExecAccessTemplate struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -71,6 +126,11 @@ func (t *ExecAccessTemplate) GetStatus() ICoreStatus {
 	return &t.Status
 }
 
+// IsReady returns true if the template is ready for the current metadata.generation.
+func (t *ExecAccessTemplate) IsReady() bool {
+	return t.Status.isReadyAt(t.Generation)
+}
+
 // GetAccessConfig returns the Spec.accessConfig field for this resource in an AccessConfig object form.
 func (t *ExecAccessTemplate) GetAccessConfig() *AccessConfig {
 	return &t.Spec.AccessConfig
@@ -81,6 +141,34 @@ func (t *ExecAccessTemplate) GetTargetRef() *CrossVersionObjectReference {
 	return t.Spec.ControllerTargetRef
 }
 
+// GetPodSelectionTimeout parses the Spec.podSelectionTimeout field into a
+// time.Duration. The second return value is false if it is unset.
+func (t *ExecAccessTemplate) GetPodSelectionTimeout() (time.Duration, bool, error) {
+	if t.Spec.PodSelectionTimeout == "" {
+		return 0, false, nil
+	}
+	timeout, err := time.ParseDuration(t.Spec.PodSelectionTimeout)
+	return timeout, true, err
+}
+
+// WithParameters returns a copy of the ExecAccessTemplate with any `${name}`
+// references in the name of its controllerTargetRef and in its
+// allowedCommands replaced with the supplied (already resolved) parameter
+// values.
+func (t *ExecAccessTemplate) WithParameters(values map[string]string) *ExecAccessTemplate {
+	n := t.DeepCopy()
+	if len(values) == 0 {
+		return n
+	}
+	if n.Spec.ControllerTargetRef != nil {
+		n.Spec.ControllerTargetRef.Name = SubstituteParameters(n.Spec.ControllerTargetRef.Name, values)
+	}
+	for i, cmd := range n.Spec.AccessConfig.AllowedCommands {
+		n.Spec.AccessConfig.AllowedCommands[i] = SubstituteParameters(cmd, values)
+	}
+	return n
+}
+
 // GetExecAccessTemplate returns back an ExecAccessTemplate resource matching the request supplied to the reconciler loop, or returns back an error.
 func GetExecAccessTemplate(
 	ctx context.Context,
@@ -0,0 +1,13 @@
+package v1alpha1
+
+// ExternalApprovalConfig configures an Access Template to defer the approval of
+// its Access Requests to an external approval system (eg, ServiceNow or Jira).
+type ExternalApprovalConfig struct {
+	// URL of the approval-status endpoint of the external system. The controller polls it with
+	// a GET request, identifying the Access Request with the `kind`, `namespace`, `name`, `uid`
+	// and `requester` query parameters, and expects a JSON response like `{"state": "approved",
+	// "message": "..."}`, where the state is one of `pending`, `approved` or `rejected`.
+	//
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+}
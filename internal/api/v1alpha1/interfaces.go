@@ -6,6 +6,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -15,8 +16,12 @@ import (
 //
 // +kubebuilder:object:generate=false
 type ICoreStatus interface {
+	// Returns the raw Status.Ready field. Use ICoreResource.IsReady() to
+	// also take the generation it was computed for into account.
 	IsReady() bool
 	SetReady(bool)
+	GetObservedGeneration() int64
+	SetObservedGeneration(int64)
 	GetConditions() *[]metav1.Condition
 }
 
@@ -28,6 +33,20 @@ type IRequestStatus interface {
 	ICoreStatus
 	SetAccessMessage(string)
 	GetAccessMessage() string
+	SetApprovers([]string)
+	GetApprovers() []string
+	SetDuplicateOf(string)
+	GetDuplicateOf() string
+	HasNotifiedRequester(string) bool
+	AddRequesterNotified(string)
+	SetGrantToken(string)
+	GetGrantToken() string
+	HasGrantMilestone(string) bool
+	AddGrantMilestone(string)
+	SetAccessExpiresAt(*metav1.Time)
+	GetAccessExpiresAt() *metav1.Time
+	SetApproversAtGrant(int)
+	GetApproversAtGrant() int
 }
 
 // ITemplateStatus provides a more specific Status interface for Access
@@ -49,6 +68,11 @@ type ICoreResource interface {
 
 	// Returns a Status object that matches our ICoreStatus interface.
 	GetStatus() ICoreStatus
+
+	// Returns true only if Status.Ready is set, and was computed for the
+	// current metadata.generation of the resource. A spec change makes the
+	// resource not-ready until it has been reconciled again.
+	IsReady() bool
 }
 
 // ITemplateResource represents a common "AccessTemplate" resource for the Oz Controller. These
@@ -80,11 +104,46 @@ type IRequestResource interface {
 	// Returns the user-supplied Spec.templateName field
 	GetTemplateName() string
 
+	// Returns the Spec.templateNamespace field, defaulting to the namespace
+	// of the request
+	GetTemplateNamespace() string
+
 	// Returns the Spec.duration in time.Duration() format, or nil.
 	GetDuration() (time.Duration, error)
 
 	// Returns the uptime in time.Duration() format
 	GetUptime() time.Duration
+
+	// Returns the user-supplied Spec.transferTo field
+	GetTransferTo() string
+
+	// Returns the user-supplied Spec.requestFor field
+	GetRequestFor() string
+
+	// Returns the user-supplied Spec.parameterValues field
+	GetParameterValues() map[string]string
+
+	// Returns the user-supplied Spec.incidentID field
+	GetIncidentID() string
+
+	// Returns the user-supplied Spec.sessionID field
+	GetSessionID() string
+
+	// Returns the user-supplied Spec.requestedVerbs field
+	GetRequestedVerbs() []string
+
+	// Returns the user-supplied Spec.platform field
+	GetPlatform() string
+
+	// Returns the user-supplied Spec.accessCommandOverride field
+	GetAccessCommandOverride() string
+
+	// Returns the user-supplied Spec.justification field
+	GetJustification() string
+
+	// Returns true if the supplied IRequestResource is of the same kind, and
+	// asks for the same access (template, target and parameters) as this one.
+	IsEquivalentTo(IRequestResource) bool
 }
 
 // IPodRequestResource is a Pod-access specific request interface that exposes a few more functions
@@ -99,4 +158,11 @@ type IPodRequestResource interface {
 
 	// Gets the Status.PodName field, or returns an empty string.
 	GetPodName() string
+
+	// Sets the Status.PodUID field, recording which incarnation of the Pod
+	// the access was granted to.
+	SetPodUID(types.UID)
+
+	// Gets the Status.PodUID field, or returns an empty string.
+	GetPodUID() types.UID
 }
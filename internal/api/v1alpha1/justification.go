@@ -0,0 +1,52 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// GetJustificationPattern returns the Spec.justificationPattern field for this particular template
+func (a *AccessConfig) GetJustificationPattern() string {
+	return a.JustificationPattern
+}
+
+// compileJustificationPattern compiles the Spec.justificationPattern field,
+// anchored so that it has to match the whole justification.
+func (a *AccessConfig) compileJustificationPattern() (*regexp.Regexp, error) {
+	re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", a.JustificationPattern))
+	if err != nil {
+		return nil, fmt.Errorf("justificationPattern %q is invalid: %w", a.JustificationPattern, err)
+	}
+	return re, nil
+}
+
+// ValidateJustificationPattern returns an error if the Spec.justificationPattern
+// field is not a valid regular expression.
+func (a *AccessConfig) ValidateJustificationPattern() error {
+	_, err := a.compileJustificationPattern()
+	return err
+}
+
+// ValidateJustification verifies that the supplied justification of an Access
+// Request conforms to the Spec.justificationPattern field. Any justification
+// (even none at all) conforms if the template does not set a pattern.
+//
+// Returns:
+//   - An "error" if the justification is missing or does not match the
+//     pattern, or if the pattern itself is invalid
+func (a *AccessConfig) ValidateJustification(justification string) error {
+	if a.JustificationPattern == "" {
+		return nil
+	}
+	re, err := a.compileJustificationPattern()
+	if err != nil {
+		return err
+	}
+	if justification == "" {
+		return fmt.Errorf("a justification matching %q is required", a.JustificationPattern)
+	}
+	if !re.MatchString(justification) {
+		return fmt.Errorf("justification %q does not match %q", justification, a.JustificationPattern)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package v1alpha1
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OzConfig", func() {
+	Context("GetAbsoluteMaxDuration()", func() {
+		It("Should return zero when unset", func() {
+			cfg := &OzConfig{}
+			ret, err := cfg.GetAbsoluteMaxDuration()
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(ret).To(BeZero())
+		})
+
+		It("Should parse the configured ceiling", func() {
+			cfg := &OzConfig{Spec: OzConfigSpec{AbsoluteMaxDuration: "4h"}}
+			ret, err := cfg.GetAbsoluteMaxDuration()
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(ret).To(Equal(4 * time.Hour))
+		})
+
+		It("Should reject an invalid ceiling", func() {
+			cfg := &OzConfig{Spec: OzConfigSpec{AbsoluteMaxDuration: "forever"}}
+			_, err := cfg.GetAbsoluteMaxDuration()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("GetDeniedUser()", func() {
+		cfg := &OzConfig{Spec: OzConfigSpec{DeniedUsers: []string{"mallory"}}}
+
+		It("Should find a denied user amongst the supplied users", func() {
+			user, denied := cfg.GetDeniedUser("alice", "", "mallory")
+			Expect(denied).To(BeTrue())
+			Expect(user).To(Equal("mallory"))
+		})
+
+		It("Should not deny anybody else", func() {
+			_, denied := cfg.GetDeniedUser("alice", "", "bob")
+			Expect(denied).To(BeFalse())
+		})
+	})
+
+	Context("AcceptsTemplatesFrom()", func() {
+		It("Should only accept templates from the listed namespaces", func() {
+			cfg := &OzConfig{Spec: OzConfigSpec{AllowedTemplateNamespaces: []string{"oz-templates"}}}
+			Expect(cfg.AcceptsTemplatesFrom("oz-templates")).To(BeTrue())
+			Expect(cfg.AcceptsTemplatesFrom("team-b")).To(BeFalse())
+			Expect((&OzConfig{}).AcceptsTemplatesFrom("oz-templates")).To(BeFalse())
+		})
+	})
+
+	Context("DurationProfile.ApplyTo()", func() {
+		It("Should replace the durations set in the profile", func() {
+			accessConfig := &AccessConfig{DefaultDuration: "1h", MaxDuration: "2h"}
+			(&DurationProfile{DefaultDuration: "4h", MaxDuration: "8h"}).ApplyTo(accessConfig)
+			Expect(accessConfig.DefaultDuration).To(Equal("4h"))
+			Expect(accessConfig.MaxDuration).To(Equal("8h"))
+		})
+
+		It("Should keep the normal durations that the profile leaves unset", func() {
+			accessConfig := &AccessConfig{DefaultDuration: "1h", MaxDuration: "2h"}
+			(&DurationProfile{MaxDuration: "8h"}).ApplyTo(accessConfig)
+			Expect(accessConfig.DefaultDuration).To(Equal("1h"))
+			Expect(accessConfig.MaxDuration).To(Equal("8h"))
+		})
+	})
+
+	Context("ApplyTo()", func() {
+		cfg := &OzConfig{Spec: OzConfigSpec{RequiredApprovals: 2}}
+
+		It("Should default the requiredApprovals of a template", func() {
+			accessConfig := &AccessConfig{}
+			cfg.ApplyTo(accessConfig)
+			Expect(accessConfig.RequiredApprovals).To(Equal(2))
+		})
+
+		It("Should leave the requiredApprovals set by a template alone", func() {
+			accessConfig := &AccessConfig{RequiredApprovals: 1}
+			cfg.ApplyTo(accessConfig)
+			Expect(accessConfig.RequiredApprovals).To(Equal(1))
+		})
+	})
+})
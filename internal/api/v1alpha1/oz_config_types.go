@@ -0,0 +1,158 @@
+/*
+Copyright 2022 Matt Wise.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// OzConfigName is the name of the OzConfig that applies to the namespace it
+// lives in. OzConfigs with any other name are ignored.
+const OzConfigName = "default"
+
+// OzConfigSpec defines the policy that applies to every Access Request in the
+// namespace of the OzConfig, on top of (or instead of) the controller-wide
+// defaults.
+type OzConfigSpec struct {
+	// AbsoluteMaxDuration is a ceiling on the duration of every Access Request in this namespace,
+	// regardless of what the templates allow. When set, it replaces the controller-wide
+	// `--absolute-max-duration` for this namespace.
+	//
+	// Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+	//
+	// +kubebuilder:validation:Optional
+	AbsoluteMaxDuration string `json:"absoluteMaxDuration,omitempty"`
+
+	// RequiredApprovals is the number of distinct users that must approve an Access Request
+	// against a template in this namespace that does not set its own `requiredApprovals`.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Optional
+	RequiredApprovals int `json:"requiredApprovals,omitempty"`
+
+	// DeniedUsers lists out the usernames that may not be granted access in this namespace. Access
+	// Requests created by, on behalf of, or transferred to any of them are denied, and any access
+	// they were already granted is revoked.
+	//
+	// +kubebuilder:validation:Optional
+	DeniedUsers []string `json:"deniedUsers,omitempty"`
+
+	// IncidentMode declares an incident in this namespace. While it is true, Access Requests
+	// against templates that set `incidentDurations` are granted with those durations instead of
+	// the normal `defaultDuration` and `maxDuration`.
+	//
+	// +kubebuilder:default:=false
+	IncidentMode bool `json:"incidentMode,omitempty"`
+
+	// AllowedTemplateNamespaces lists the namespaces whose Access Templates may serve the Access
+	// Requests in this namespace, through their `namespacePattern`. Templates shared from any
+	// other namespace are not honoured here, whatever their pattern. When empty, only the
+	// templates in this namespace may be used.
+	//
+	// +kubebuilder:validation:Optional
+	AllowedTemplateNamespaces []string `json:"allowedTemplateNamespaces,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// OzConfig is the Schema for the ozconfigs API. The OzConfig named "default"
+// sets the policy for the Access Requests in its namespace, allowing platform
+// teams to delegate namespace policy without changing the controller flags.
+type OzConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec OzConfigSpec `json:"spec,omitempty"`
+}
+
+// GetAbsoluteMaxDuration parses the Spec.absoluteMaxDuration field into a time.Duration struct.
+// An unset ceiling is returned as zero.
+//
+// Returns:
+//
+//	time.Duration: Populated struct (or zero, if unset or error)
+//	error: If any error occurs in the parsing, the error is returned
+func (c *OzConfig) GetAbsoluteMaxDuration() (time.Duration, error) {
+	if c.Spec.AbsoluteMaxDuration == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(c.Spec.AbsoluteMaxDuration)
+}
+
+// GetDeniedUser returns the first of the supplied users that is listed in the
+// Spec.deniedUsers field, and whether any of them was listed at all. Empty
+// usernames are ignored.
+func (c *OzConfig) GetDeniedUser(users ...string) (string, bool) {
+	for _, denied := range c.Spec.DeniedUsers {
+		for _, user := range users {
+			if user != "" && user == denied {
+				return user, true
+			}
+		}
+	}
+	return "", false
+}
+
+// AcceptsTemplatesFrom returns true if the Spec.allowedTemplateNamespaces
+// field lists the supplied namespace, so that templates shared from it may
+// serve the Access Requests in the namespace of the OzConfig.
+func (c *OzConfig) AcceptsTemplatesFrom(namespace string) bool {
+	for _, allowed := range c.Spec.AllowedTemplateNamespaces {
+		if allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyTo merges the namespace defaults into the supplied AccessConfig of a
+// template in the namespace. Settings made on the template itself win.
+func (c *OzConfig) ApplyTo(cfg *AccessConfig) {
+	if cfg.RequiredApprovals <= 0 {
+		cfg.RequiredApprovals = c.Spec.RequiredApprovals
+	}
+}
+
+// GetOzConfig returns back the OzConfig that applies to the supplied namespace, or returns back
+// an error. A NotFound error means that the namespace only uses the controller-wide defaults.
+func GetOzConfig(
+	ctx context.Context,
+	cl client.Reader,
+	namespace string,
+) (*OzConfig, error) {
+	cfg := &OzConfig{}
+	err := cl.Get(ctx, types.NamespacedName{Name: OzConfigName, Namespace: namespace}, cfg)
+	return cfg, err
+}
+
+//+kubebuilder:object:root=true
+
+// OzConfigList contains a list of OzConfig
+type OzConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OzConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OzConfig{}, &OzConfigList{})
+}
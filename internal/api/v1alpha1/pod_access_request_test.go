@@ -14,6 +14,7 @@ import (
 	authenticationv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -130,6 +131,14 @@ var _ = Describe("PodAccessRequest", Ordered, func() {
 			}
 		)
 
+		// ValidateCreate() looks up the template that the request points to,
+		// so the request must live alongside the template created in the
+		// outer BeforeEach().
+		BeforeEach(func() {
+			request.ObjectMeta.Namespace = namespace.Name
+			request.Spec.TemplateName = template.Name
+		})
+
 		// TODO: The "Userinfo" checks should move into an authentication
 		// package so that we can write one set of tests for all of the
 		// Validate* functions.
@@ -226,6 +235,212 @@ var _ = Describe("PodAccessRequest", Ordered, func() {
 			err = request.ValidateUpdate(*admissionRequest, request)
 			Expect(err).To(Not(HaveOccurred()))
 		})
+
+		It("Update of the template of a request is always rejected...", func() {
+			update := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: "UPDATE",
+					UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+				},
+			}
+			changed := request.DeepCopy()
+			changed.Spec.TemplateName = "other"
+			err = changed.ValidateUpdate(update, request)
+			Expect(err).To(MatchError(
+				"error - Spec.TemplateName and Spec.TemplateNamespace are immutable fields, create a new PodAccessRequest instead",
+			))
+
+			changed = request.DeepCopy()
+			changed.Spec.TemplateNamespace = "shared"
+			err = changed.ValidateUpdate(update, request)
+			Expect(err).To(MatchError(ContainSubstring("are immutable fields")))
+		})
+
+		It("Update of a pending request is validated against the template again...", func() {
+			update := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: "UPDATE",
+					UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+				},
+			}
+
+			// The template declares no parameters
+			changed := request.DeepCopy()
+			changed.Spec.ParameterValues = map[string]string{"database": "orders"}
+			err = changed.ValidateUpdate(update, request)
+			Expect(err).To(MatchError(ContainSubstring("unknown parameters: database")))
+
+			// The request is validated as its requester, not the updating user
+			template.Spec.AccessConfig.AllowedRequesters = &AllowedRequesters{Users: []string{"alice"}}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			pending := request.DeepCopy()
+			pending.SetAnnotations(map[string]string{RequestedByAnnotation: "bob"})
+			changed = pending.DeepCopy()
+			changed.Spec.Duration = "2h"
+			err = changed.ValidateUpdate(update, pending)
+			Expect(err).To(MatchError(ContainSubstring("bob is not an allowed requester")))
+
+			pending.SetAnnotations(map[string]string{RequestedByAnnotation: "alice"})
+			changed = pending.DeepCopy()
+			changed.Spec.Duration = "2h"
+			err = changed.ValidateUpdate(update, pending)
+			Expect(err).To(Not(HaveOccurred()))
+
+			// Changes outside of the Spec are not validated again
+			changed = request.DeepCopy()
+			changed.SetLabels(map[string]string{"team": "payments"})
+			err = changed.ValidateUpdate(update, request)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Update of a granted request only allows the duration to change...", func() {
+			update := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: "UPDATE",
+					UserInfo:  authenticationv1.UserInfo{Username: "admin"},
+				},
+			}
+			granted := request.DeepCopy()
+			granted.Spec.Duration = "1h"
+			granted.Status.Conditions = []metav1.Condition{{
+				Type:   ConditionAccessResourcesCreated.String(),
+				Status: metav1.ConditionTrue,
+				Reason: "Success",
+			}}
+
+			changed := granted.DeepCopy()
+			changed.Spec.ParameterValues = map[string]string{"database": "payments"}
+			err = changed.ValidateUpdate(update, granted)
+			Expect(err).To(MatchError(ContainSubstring("Spec.ParameterValues can not be changed")))
+
+			// Extending the access is still allowed
+			changed = granted.DeepCopy()
+			changed.Spec.Duration = "2h"
+			err = changed.ValidateUpdate(update, granted)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		createRequest := func(r *PodAccessRequest) *admission.Request {
+			requestBytes, _ := json.Marshal(r)
+			return &admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Resource:        gvr,
+					RequestKind:     &gvk,
+					RequestResource: &gvr,
+					Name:            requestName,
+					Namespace:       namespace.Name,
+					Operation:       "CREATE",
+					UserInfo: authenticationv1.UserInfo{
+						Username: "admin",
+					},
+					Object: runtime.RawExtension{
+						Raw: requestBytes,
+					},
+				},
+			}
+		}
+
+		It("Create against a missing template is rejected with NotFound...", func() {
+			missing := request.DeepCopy()
+			missing.Spec.TemplateName = "missing"
+			err = missing.ValidateCreate(*createRequest(missing))
+			Expect(err).To(HaveOccurred())
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Create must satisfy the template parameters...", func() {
+			template.Spec.AccessConfig.Parameters = []TemplateParameter{
+				{Name: "app", Required: true, Pattern: "[a-z]+"},
+			}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Rejecting a request without the parameter")
+			err = request.ValidateCreate(*createRequest(request))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp(`missing required parameter "app"`))
+
+			By("Rejecting a request with a value that does not match the pattern")
+			bad := request.DeepCopy()
+			bad.Spec.ParameterValues = map[string]string{"app": "Not-Valid"}
+			err = bad.ValidateCreate(*createRequest(bad))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("does not match pattern"))
+
+			By("Accepting a request with a valid value")
+			good := request.DeepCopy()
+			good.Spec.ParameterValues = map[string]string{"app": "web"}
+			err = good.ValidateCreate(*createRequest(good))
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create with an SSH public key requires a template that allows SSH...", func() {
+			withKey := request.DeepCopy()
+			withKey.Spec.SSHPublicKey = testSSHPublicKey
+
+			By("Rejecting the key against a template without an sshConfig")
+			err = withKey.ValidateCreate(*createRequest(withKey))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("does not allow SSH access"))
+
+			template.Spec.SSHConfig = &PodSSHConfig{}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Rejecting a malformed key")
+			bad := request.DeepCopy()
+			bad.Spec.SSHPublicKey = "ssh-ed25519 not-a-key"
+			err = bad.ValidateCreate(*createRequest(bad))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("invalid spec.sshPublicKey"))
+
+			By("Accepting a valid key")
+			err = withKey.ValidateCreate(*createRequest(withKey))
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create with a priority requires a template that allows it...", func() {
+			high := request.DeepCopy()
+			high.Spec.Priority = PriorityHigh
+
+			By("Accepting the default priority against any template")
+			normal := request.DeepCopy()
+			normal.Spec.Priority = PriorityNormal
+			err = normal.ValidateCreate(*createRequest(normal))
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Rejecting a priority that the template does not allow")
+			err = high.ValidateCreate(*createRequest(high))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp(`does not allow spec.priority "high"`))
+
+			template.Spec.AllowedPriorities = []string{PriorityHigh}
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Rejecting an unknown priority")
+			bad := request.DeepCopy()
+			bad.Spec.Priority = "urgent"
+			err = bad.ValidateCreate(*createRequest(bad))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("invalid spec.priority"))
+
+			By("Accepting a priority that the template allows")
+			err = high.ValidateCreate(*createRequest(high))
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		It("Create against a paused template is rejected...", func() {
+			template.Spec.AccessConfig.Paused = true
+			err = k8sClient.Update(ctx, template)
+			Expect(err).To(Not(HaveOccurred()))
+
+			err = request.ValidateCreate(*createRequest(request))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("is paused"))
+		})
 	})
 
 	// Setup code below here - this code rarely changes, the tests above are
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -37,6 +38,13 @@ type PodAccessRequestSpec struct {
 	// +kubebuilder:validation:Required
 	TemplateName string `json:"templateName"`
 
+	// TemplateNamespace is the namespace of the template named by TemplateName. It is only set
+	// to use a template that is shared with the namespace of this request (see the
+	// namespacePattern field of the template). Defaults to the namespace of this request.
+	//
+	// +kubebuilder:validation:Optional
+	TemplateNamespace string `json:"templateNamespace,omitempty"`
+
 	// Duration sets the length of time from the `spec.creationTimestamp` that this object will live. After the
 	// time has expired, the resouce will be automatically deleted on the next reconcilliation loop.
 	//
@@ -47,6 +55,105 @@ type PodAccessRequestSpec struct {
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Pattern="^[0-9]+(s|m|h)$"
 	Duration string `json:"duration,omitempty"`
+
+	// TransferTo hands the access granted by this request over to another user, for example
+	// when the on-call engineer rotates mid-incident. When set, the RoleBinding subjects are
+	// replaced with this single user. The original expiration time of the request is preserved.
+	//
+	// Only the requester, or one of the approvers, of the request may transfer it. The new owner
+	// is held to the same rules as a request made on their behalf (see requestFor).
+	//
+	// +kubebuilder:validation:Optional
+	TransferTo string `json:"transferTo,omitempty"`
+
+	// RequestFor names the user that the access is being requested on behalf of, for example
+	// when a lead provisions access for a teammate who cannot request it themselves. When set,
+	// the RoleBinding subject is this single user rather than the template's allowed groups. Only
+	// members of the PodAccessTemplate's `spec.accessConfig.allowedDelegators` groups may set it, and it can not
+	// be changed after the request has been created.
+	//
+	// +kubebuilder:validation:Optional
+	RequestFor string `json:"requestFor,omitempty"`
+
+	// ParameterValues supplies values for the parameters declared in the
+	// `spec.accessConfig.parameters` field of the PodAccessTemplate. Requests that omit a
+	// required parameter are rejected.
+	//
+	// +kubebuilder:validation:Optional
+	ParameterValues map[string]string `json:"parameterValues,omitempty"`
+
+	// IncidentID tags this request with the ID of the incident (eg "INC-1234") that the access
+	// is needed for. It is mirrored into the `crds.wizardofoz.co/incident-id` label, so that all
+	// of the requests for an incident can be listed (and revoked) together once it is closed.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`
+	IncidentID string `json:"incidentID,omitempty"`
+
+	// SessionID groups this request with the other Access Requests in its namespace that share
+	// the same ID, for coordinated debugging. It is mirrored into the `crds.wizardofoz.co/session-id`
+	// label. When the access of any request in the session ends (it expires, or is revoked or
+	// denied), the access of the other requests in the session made by the same requester ends
+	// with it. The requests of other users in the session are left alone.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]$`
+	SessionID string `json:"sessionID,omitempty"`
+
+	// RequestedVerbs narrows the access granted by this request to a subset of the verbs that the
+	// template allows the requester on the `pods/exec` subresource of the target Pod (the verbs
+	// of their groups in its `verbsByGroup`, or the default verbs), for least-privilege access.
+	// Requests for verbs beyond that are rejected. When empty, all of the allowed verbs are
+	// granted.
+	//
+	// +kubebuilder:validation:Optional
+	RequestedVerbs []string `json:"requestedVerbs,omitempty"`
+
+	// Platform hints at the platform (or shell) of the requester, eg "linux", "windows" or
+	// "powershell". It selects the matching entry of the `spec.accessConfig.accessCommands` of
+	// the template, which renders the command reported in the status once access is granted.
+	//
+	// +kubebuilder:validation:Optional
+	Platform string `json:"platform,omitempty"`
+
+	// AccessCommandOverride replaces the command reported in the status once access is granted
+	// (eg, to use a different shell) with one of the `spec.accessConfig.accessCommandOverrides`
+	// that the template allows. It is a Go text/template, rendered like the accessCommands of
+	// the template, and must match one of the allowed overrides exactly. It can not be changed
+	// after the request has been created.
+	//
+	// +kubebuilder:validation:Optional
+	AccessCommandOverride string `json:"accessCommandOverride,omitempty"`
+
+	// Justification states why the access is needed - typically the ID or the URL of the
+	// ticket that it is needed for. It is recorded with the request for auditing. If the
+	// template sets a `spec.accessConfig.justificationPattern`, the justification is required
+	// and must match it. It can not be changed after access has been granted.
+	//
+	// +kubebuilder:validation:Optional
+	Justification string `json:"justification,omitempty"`
+
+	// SSHPublicKey is an SSH public key (eg, "ssh-ed25519 AAAA... me@host") that is authorized
+	// to SSH into the Pod, as an alternative to `kubectl exec`. SSH is reached through a
+	// `kubectl port-forward` to the Pod, which the access then also allows. Only valid against a
+	// PodAccessTemplate with `spec.sshConfig` set, and it can not be changed after the request
+	// has been created.
+	//
+	// +kubebuilder:validation:Optional
+	SSHPublicKey string `json:"sshPublicKey,omitempty"`
+
+	// Priority is the priority class of the request ("low", "normal", "high" or "critical"),
+	// which orders the queue of requests waiting on a Pod from a template with `spec.maxPods`
+	// set. Higher priority requests are handed a freed Pod first, and requests of the same
+	// priority take turns across their requesters. Classes other than "normal" (the default)
+	// must be listed in the `spec.allowedPriorities` of the template. It can not be changed
+	// after the request has been created.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=low;normal;high;critical
+	Priority string `json:"priority,omitempty"`
 }
 
 // PodAccessRequestStatus defines the observed state of AccessRequest
@@ -55,6 +162,14 @@ type PodAccessRequestStatus struct {
 
 	// The Target Pod Name where access has been granted
 	PodName string `json:"podName,omitempty"`
+
+	// PodUID is the UID of the Pod that was created for this request. If the Pod is replaced
+	// by a Pod with the same name that was not created for this request, the access is revoked.
+	PodUID types.UID `json:"podUID,omitempty"`
+
+	// PodPhase is the phase of the Pod that was created for this request, as of the last time
+	// its readiness was checked.
+	PodPhase corev1.PodPhase `json:"podPhase,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -64,6 +179,7 @@ type PodAccessRequestStatus struct {
 //
 // +kubebuilder:printcolumn:name="Template",type="string",JSONPath=".spec.templateName",description="Access Template"
 // +kubebuilder:printcolumn:name="Pod",type="string",JSONPath=".status.podName",description="Target Pod Name"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.podPhase",description="Phase of the Target Pod"
 // +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready",description="Is request ready?"
 type PodAccessRequest struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -88,12 +204,28 @@ func (r *PodAccessRequest) GetStatus() ICoreStatus {
 	return &r.Status
 }
 
+// IsReady implements the ICoreResource interface
+func (r *PodAccessRequest) IsReady() bool {
+	return r.Status.isReadyAt(r.Generation)
+}
+
 // GetTemplate returns a populated PodAccessTemplate that this PodAccessRequest is referencing.
 func (r *PodAccessRequest) GetTemplate(
 	ctx context.Context,
 	cl client.Client,
 ) (ITemplateResource, error) {
-	return GetPodAccessTemplate(ctx, cl, r.Spec.TemplateName, r.Namespace)
+	return r.resolveTemplate(ctx, cl)
+}
+
+// resolveTemplate fetches the template named by the request through the
+// supplied reader, and shares it with the namespace of the request if it lives
+// in another namespace (see shareTemplate).
+func (r *PodAccessRequest) resolveTemplate(ctx context.Context, cl client.Reader) (*PodAccessTemplate, error) {
+	tmpl, err := GetPodAccessTemplate(ctx, cl, r.Spec.TemplateName, r.GetTemplateNamespace())
+	if err != nil {
+		return tmpl, err
+	}
+	return tmpl, shareTemplate(ctx, cl, tmpl, r.Namespace)
 }
 
 // GetTemplateName returns the user supplied Spec.templateName field
@@ -101,6 +233,15 @@ func (r *PodAccessRequest) GetTemplateName() string {
 	return r.Spec.TemplateName
 }
 
+// GetTemplateNamespace returns the Spec.templateNamespace field, or the
+// namespace of the request if it is not set.
+func (r *PodAccessRequest) GetTemplateNamespace() string {
+	if r.Spec.TemplateNamespace != "" {
+		return r.Spec.TemplateNamespace
+	}
+	return r.Namespace
+}
+
 // GetDuration conform to the interfaces.OzRequestResource interface
 func (r *PodAccessRequest) GetDuration() (time.Duration, error) {
 	if r.Spec.Duration != "" {
@@ -109,6 +250,72 @@ func (r *PodAccessRequest) GetDuration() (time.Duration, error) {
 	return time.Duration(0), nil
 }
 
+// GetTransferTo conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) GetTransferTo() string {
+	return r.Spec.TransferTo
+}
+
+// GetRequestFor conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) GetRequestFor() string {
+	return r.Spec.RequestFor
+}
+
+// GetParameterValues conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) GetParameterValues() map[string]string {
+	return r.Spec.ParameterValues
+}
+
+// GetIncidentID conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) GetIncidentID() string {
+	return r.Spec.IncidentID
+}
+
+// GetSessionID conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) GetSessionID() string {
+	return r.Spec.SessionID
+}
+
+// GetRequestedVerbs conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) GetRequestedVerbs() []string {
+	return r.Spec.RequestedVerbs
+}
+
+// GetPlatform conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) GetPlatform() string {
+	return r.Spec.Platform
+}
+
+// GetAccessCommandOverride conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) GetAccessCommandOverride() string {
+	return r.Spec.AccessCommandOverride
+}
+
+// GetJustification conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) GetJustification() string {
+	return r.Spec.Justification
+}
+
+// GetPriority returns the priority class of the request, defaulting to
+// PriorityNormal.
+func (r *PodAccessRequest) GetPriority() string {
+	if r.Spec.Priority == "" {
+		return PriorityNormal
+	}
+	return r.Spec.Priority
+}
+
+// IsEquivalentTo conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) IsEquivalentTo(other IRequestResource) bool {
+	o, ok := other.(*PodAccessRequest)
+	return ok &&
+		r.Spec.TemplateName == o.Spec.TemplateName &&
+		r.GetTemplateNamespace() == o.GetTemplateNamespace() &&
+		r.Spec.TransferTo == o.Spec.TransferTo &&
+		r.Spec.RequestFor == o.Spec.RequestFor &&
+		equalParameterValues(r.Spec.ParameterValues, o.Spec.ParameterValues) &&
+		equalVerbs(r.Spec.RequestedVerbs, o.Spec.RequestedVerbs)
+}
+
 // GetUptime conform to the interfaces.OzRequestResource interface
 func (r *PodAccessRequest) GetUptime() time.Duration {
 	now := time.Now()
@@ -134,6 +341,16 @@ func (r *PodAccessRequest) GetPodName() string {
 	return r.Status.PodName
 }
 
+// SetPodUID conforms to the interfaces.OzRequestResource interface
+func (r *PodAccessRequest) SetPodUID(uid types.UID) {
+	r.Status.PodUID = uid
+}
+
+// GetPodUID returns the PodUID that has been assigned to the Status field within this AccessRequest.
+func (r *PodAccessRequest) GetPodUID() types.UID {
+	return r.Status.PodUID
+}
+
 // GetPodAccessRequest returns back an ExecAccessRequest resource matching the request supplied to the
 // reconciler loop, or returns back an error.
 func GetPodAccessRequest(
@@ -17,8 +17,11 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
@@ -36,6 +39,8 @@ var podaccessrequestlog = logf.Log.WithName("podaccessrequest-resource")
 // accept MutatingWebhookConfiguration and ValidatingWebhookConfiguration calls
 // from the Kubernetes API server.
 func (r *PodAccessRequest) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookReader = mgr.GetAPIReader()
+
 	if err := webhook.RegisterContextualDefaulter(r, mgr); err != nil {
 		panic(err)
 	}
@@ -51,9 +56,32 @@ func (r *PodAccessRequest) SetupWebhookWithManager(mgr ctrl.Manager) error {
 
 var _ webhook.IContextuallyDefaultableObject = &PodAccessRequest{}
 
-// Default implements webhook.Defaulter so a webhook will be registered for the type
-func (r *PodAccessRequest) Default(_ admission.Request) error {
-	return nil
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+// It records the identity of the requester, the incident the request is tagged with,
+// and any approval, revocation or forced expiration of the request.
+// Writes of the LastActiveAnnotation by anybody but the LastActiveWriters are
+// rejected.
+func (r *PodAccessRequest) Default(req admission.Request) error {
+	if err := recordRequester(req, r); err != nil {
+		return err
+	}
+	recordIncidentID(r)
+	recordSessionID(r)
+	if err := recordApproval(podaccessrequestlog, req, r); err != nil {
+		return err
+	}
+	if err := recordRevocation(podaccessrequestlog, req, r, func() (ITemplateResource, error) {
+		return r.resolveTemplate(context.Background(), webhookReader)
+	}); err != nil {
+		return err
+	}
+	if err := recordForcedExpiration(podaccessrequestlog, req, r); err != nil {
+		return err
+	}
+	if err := recordLastActive(req, r); err != nil {
+		return err
+	}
+	return recordSessionEnded(req, r)
 }
 
 //+kubebuilder:webhook:path=/validate-crds-wizardofoz-co-v1alpha1-podaccessrequest,mutating=false,failurePolicy=fail,sideEffects=None,groups=crds.wizardofoz.co,resources=podaccessrequests,verbs=create;update;delete,versions=v1alpha1,name=vpodaccessrequest.kb.io,admissionReviewVersions=v1
@@ -70,11 +98,66 @@ func (r *PodAccessRequest) ValidateCreate(req admission.Request) error {
 		// TODO: Make this fail, after we have confidence in the code in a live environment.
 		podaccessrequestlog.Info("WARNING - Create ExecAccessRequest with missing user identity")
 	}
+
+	// Reject requests against templates that are missing, paused or invalid
+	// now, rather than letting the request get stuck in the reconciler.
+	tmpl, err := r.resolveTemplate(context.Background(), webhookReader)
+	if err != nil {
+		return err
+	}
+	if err := ValidateAccessRequest(podaccessrequestlog, req, r, tmpl); err != nil {
+		return err
+	}
+	if err := r.validatePriority(tmpl); err != nil {
+		return err
+	}
+	return r.validateSSHPublicKey(tmpl)
+}
+
+// validatePriority verifies that the Spec.Priority (if any) is a known
+// priority class, and that the template allows it.
+func (r *PodAccessRequest) validatePriority(tmpl *PodAccessTemplate) error {
+	priority := r.GetPriority()
+	if PriorityRank(priority) < 0 {
+		return fmt.Errorf(
+			"error - invalid spec.priority %q, must be one of: %s",
+			priority, strings.Join(PriorityClasses, ", "),
+		)
+	}
+	if priority == PriorityNormal {
+		return nil
+	}
+	for _, allowed := range tmpl.Spec.AllowedPriorities {
+		if allowed == priority {
+			return nil
+		}
+	}
+	return fmt.Errorf(
+		"error - template %s does not allow spec.priority %q",
+		tmpl.GetName(), priority,
+	)
+}
+
+// validateSSHPublicKey verifies that the Spec.SSHPublicKey (if any) is a valid
+// SSH public key, and that the template allows SSH access at all.
+func (r *PodAccessRequest) validateSSHPublicKey(tmpl *PodAccessTemplate) error {
+	if r.Spec.SSHPublicKey == "" {
+		return nil
+	}
+	if tmpl.Spec.SSHConfig == nil {
+		return fmt.Errorf(
+			"error - template %s does not allow SSH access, remove spec.sshPublicKey",
+			tmpl.GetName(),
+		)
+	}
+	if err := ValidateSSHPublicKey(r.Spec.SSHPublicKey); err != nil {
+		return fmt.Errorf("error - invalid spec.sshPublicKey: %w", err)
+	}
 	return nil
 }
 
 // ValidateUpdate implements webhook.IContextuallyValidatableObject so a webhook will be registered for the type
-func (r *PodAccessRequest) ValidateUpdate(req admission.Request, _ runtime.Object) error {
+func (r *PodAccessRequest) ValidateUpdate(req admission.Request, old runtime.Object) error {
 	if req.UserInfo.Username != "" {
 		podaccessrequestlog.Info(
 			fmt.Sprintf("Update PodAccessRequest from %s", req.UserInfo.Username),
@@ -83,6 +166,63 @@ func (r *PodAccessRequest) ValidateUpdate(req admission.Request, _ runtime.Objec
 		// TODO: Make this fail, after we have confidence in the code in a live environment.
 		podaccessrequestlog.Info("WARNING - Update ExecAccessRequest with missing user identity")
 	}
+
+	oldRequest, _ := old.(*PodAccessRequest)
+	if r.Spec.RequestFor != oldRequest.Spec.RequestFor {
+		return fmt.Errorf(
+			"error - Spec.RequestFor is an immutable field, create a new PodAccessRequest instead",
+		)
+	}
+	if r.Spec.AccessCommandOverride != oldRequest.Spec.AccessCommandOverride {
+		return fmt.Errorf(
+			"error - Spec.AccessCommandOverride is an immutable field, create a new PodAccessRequest instead",
+		)
+	}
+	if r.Spec.SSHPublicKey != oldRequest.Spec.SSHPublicKey {
+		return fmt.Errorf(
+			"error - Spec.SSHPublicKey is an immutable field, create a new PodAccessRequest instead",
+		)
+	}
+	if r.Spec.Priority != oldRequest.Spec.Priority {
+		return fmt.Errorf(
+			"error - Spec.Priority is an immutable field, create a new PodAccessRequest instead",
+		)
+	}
+
+	if err := validateTemplateRef("PodAccessRequest", oldRequest, r); err != nil {
+		return err
+	}
+
+	// Once granted, only the duration (to extend the access) and the owner
+	// (through Spec.transferTo) of the request may change.
+	if err := validateImmutableAfterGrant("PodAccessRequest", oldRequest,
+		immutableField{"ParameterValues", oldRequest.Spec.ParameterValues, r.Spec.ParameterValues},
+		immutableField{"IncidentID", oldRequest.Spec.IncidentID, r.Spec.IncidentID},
+		immutableField{"SessionID", oldRequest.Spec.SessionID, r.Spec.SessionID},
+		immutableField{"Justification", oldRequest.Spec.Justification, r.Spec.Justification},
+		immutableField{"RequestedVerbs", oldRequest.Spec.RequestedVerbs, r.Spec.RequestedVerbs},
+	); err != nil {
+		return err
+	}
+
+	// Any other change to the Spec is held to the template, as it would be
+	// on creation.
+	if !equality.Semantic.DeepEqual(oldRequest.Spec, r.Spec) {
+		tmpl, err := r.resolveTemplate(context.Background(), webhookReader)
+		if err != nil {
+			return err
+		}
+		if err := revalidateAccessRequest(req, oldRequest, r, tmpl); err != nil {
+			return err
+		}
+	}
+	if err := validateTransfer(req, oldRequest, r, func() (ITemplateResource, error) {
+		return r.resolveTemplate(context.Background(), webhookReader)
+	}); err != nil {
+		return err
+	}
+
+	auditTransfer(podaccessrequestlog, req, oldRequest, r)
 	return nil
 }
 
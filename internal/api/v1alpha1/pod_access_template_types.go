@@ -65,6 +65,39 @@ type PodAccessTemplateSpec struct {
 	//
 	// +kubebuilder:validation:Optional
 	MaxMemory resource.Quantity `json:"maxMemory,omitempty"`
+
+	// MaxPods limits the number of concurrent Pods that PodAccessRequests against this template
+	// may have alive at once. Requests beyond this cap are queued until one of the existing Pods
+	// is freed up by its request expiring. Freed Pods are handed out round-robin across the
+	// requesters in the queue, so that no single requester can starve the others. A value of 0
+	// means there is no limit.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	MaxPods int32 `json:"maxPods,omitempty"`
+
+	// AllowedPriorities lists the priority classes ("low", "high" or "critical") that
+	// PodAccessRequests against this template may set in their `spec.priority`, to move ahead
+	// of (or behind) other requests in the `spec.maxPods` queue. The "normal" class is always
+	// allowed. Requests for any other class are rejected.
+	//
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:items:Enum=low;normal;high;critical
+	AllowedPriorities []string `json:"allowedPriorities,omitempty"`
+
+	// PreCreatePod creates the Pod for a PodAccessRequest while it is still waiting on its
+	// requiredApprovals, so that it is already warm once the request is approved. Access to
+	// the Pod is only granted on approval. If the request is denied or expires unapproved,
+	// the Pod is deleted along with it.
+	//
+	// +kubebuilder:validation:Optional
+	PreCreatePod bool `json:"preCreatePod,omitempty"`
+
+	// SSHConfig allows requesters to SSH into their Pod with the `spec.sshPublicKey` of their
+	// PodAccessRequest. Requests that supply an SSH public key are rejected unless it is set.
+	//
+	// +kubebuilder:validation:Optional
+	SSHConfig *PodSSHConfig `json:"sshConfig,omitempty"`
 }
 
 // PodAccessTemplateStatus defines the observed state of PodAccessTemplate
@@ -78,6 +111,8 @@ type PodAccessTemplateStatus struct {
 // PodAccessTemplate is the Schema for the accesstemplates API
 //
 // +kubebuilder:object:root=true
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.controllerTargetRef.name",description="Target Controller"
+// +kubebuilder:printcolumn:name="MaxDuration",type="string",JSONPath=".spec.accessConfig.maxDuration",description="Maximum Access Duration"
 // +kubebuilder:printcolumn:name="Ready",type="boolean",JSONPath=".status.ready",description="Is template ready?"
 type PodAccessTemplate struct {
 	metav1.TypeMeta   `json:",inline"`
@@ -102,6 +137,11 @@ func (t *PodAccessTemplate) GetStatus() ICoreStatus {
 	return &t.Status
 }
 
+// IsReady returns true if the template is ready for the current metadata.generation.
+func (t *PodAccessTemplate) IsReady() bool {
+	return t.Status.isReadyAt(t.Generation)
+}
+
 // GetTargetRef conforms to the controllers.OzTemplateResource interface.
 func (t *PodAccessTemplate) GetTargetRef() *CrossVersionObjectReference {
 	return t.Spec.ControllerTargetRef
@@ -148,7 +188,7 @@ func init() {
 // reconciler loop, or returns back an error.
 func GetPodAccessTemplate(
 	ctx context.Context,
-	cl client.Client,
+	cl client.Reader,
 	name string,
 	namespace string,
 ) (*PodAccessTemplate, error) {
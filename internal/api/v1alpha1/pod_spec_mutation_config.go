@@ -259,3 +259,73 @@ func (c *PodTemplateSpecMutationConfig) PatchPodTemplateSpec(
 
 	return n, nil
 }
+
+// AddEnvFrom returns a new PodTemplateSpec object based on the supplied spec,
+// with the supplied EnvFromSources appended to the envFrom of the default
+// container (see DefaultContainerName).
+//
+// Returns:
+//
+//	corev1.PodTemplateSpec: A new PodTemplateSpec object with the added sources.
+func (c *PodTemplateSpecMutationConfig) AddEnvFrom(
+	ctx context.Context,
+	orig corev1.PodTemplateSpec,
+	envFrom []corev1.EnvFromSource,
+) (corev1.PodTemplateSpec, error) {
+	logger := log.FromContext(ctx)
+	n := *orig.DeepCopy()
+
+	defContainerID, err := c.getDefaultContainerID(ctx, orig)
+	if err != nil {
+		return orig, err
+	}
+
+	logger.V(1).Info(fmt.Sprintf("Adding spec.containers[%d].envFrom...", defContainerID))
+	n.Spec.Containers[defContainerID].EnvFrom = append(
+		n.Spec.Containers[defContainerID].EnvFrom,
+		envFrom...)
+	return n, nil
+}
+
+// WithParameters returns a copy of the PodTemplateSpecMutationConfig with any
+// `${name}` references in the command, args, env values, pod labels and pod
+// annotations replaced with the supplied (already resolved) parameter values.
+//
+// Returns:
+//
+//	*PodTemplateSpecMutationConfig: A new copy of the config with the values substituted.
+func (c *PodTemplateSpecMutationConfig) WithParameters(
+	values map[string]string,
+) *PodTemplateSpecMutationConfig {
+	n := c.DeepCopy()
+	if len(values) == 0 {
+		return n
+	}
+
+	sub := func(s string) string { return SubstituteParameters(s, values) }
+	subList := func(l *[]string) {
+		if l == nil {
+			return
+		}
+		for i := range *l {
+			(*l)[i] = sub((*l)[i])
+		}
+	}
+	subMap := func(m *map[string]string) {
+		if m == nil {
+			return
+		}
+		for k, v := range *m {
+			(*m)[k] = sub(v)
+		}
+	}
+
+	subList(n.Command)
+	subList(n.Args)
+	for i := range n.Env {
+		n.Env[i].Value = sub(n.Env[i].Value)
+	}
+	subMap(n.PodLabels)
+	subMap(n.PodAnnotations)
+	return n
+}
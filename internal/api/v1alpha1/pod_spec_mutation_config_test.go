@@ -229,6 +229,38 @@ var _ = Describe("PodSpecMutationConfig", Ordered, func() {
 			Expect(len(ret.Spec.Containers[0].Env)).To(Equal(1))
 		})
 
+		It("WithParameters should substitute values before patching", func() {
+			config := &PodTemplateSpecMutationConfig{
+				Command: &[]string{"/bin/debug", "--app=${app}"},
+				Args:    &[]string{"${region}"},
+				PodLabels: &map[string]string{
+					"app": "${app}",
+				},
+				PodAnnotations: &map[string]string{
+					"note": "debugging ${app} in ${region}",
+				},
+				Env: []corev1.EnvVar{
+					{Name: "APP", Value: "${app}"},
+				},
+			}
+
+			ret, err := config.WithParameters(map[string]string{
+				"app":    "web",
+				"region": "us-west-2",
+			}).PatchPodTemplateSpec(ctx, podTemplateSpec)
+			Expect(err).To(Not(HaveOccurred()))
+
+			// VERIFY: Values are substituted everywhere
+			Expect(ret.Spec.Containers[0].Command).To(Equal([]string{"/bin/debug", "--app=web"}))
+			Expect(ret.Spec.Containers[0].Args).To(Equal([]string{"us-west-2"}))
+			Expect(ret.ObjectMeta.Labels["app"]).To(Equal("web"))
+			Expect(ret.ObjectMeta.Annotations["note"]).To(Equal("debugging web in us-west-2"))
+			Expect(ret.Spec.Containers[0].Env[0].Value).To(Equal("web"))
+
+			// VERIFY: The original config is untouched
+			Expect((*config.Command)[1]).To(Equal("--app=${app}"))
+		})
+
 		It("PatchPodTemplateSpec should fail if invalid container name supplied", func() {
 			// Basic resource with no mutation config
 			config := &PodTemplateSpecMutationConfig{DefaultContainerName: "bogus"}
@@ -240,5 +272,36 @@ var _ = Describe("PodSpecMutationConfig", Ordered, func() {
 			// VERIFY: Unmutated
 			Expect(ret).To(Equal(podTemplateSpec))
 		})
+
+		It("AddEnvFrom should add the sources to the default container", func() {
+			config := &PodTemplateSpecMutationConfig{DefaultContainerName: "contB"}
+			envFrom := []corev1.EnvFromSource{
+				{SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "db-readonly"},
+				}},
+				{ConfigMapRef: &corev1.ConfigMapEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "db-settings"},
+				}},
+			}
+
+			// Run it
+			ret, err := config.AddEnvFrom(ctx, podTemplateSpec, envFrom)
+			Expect(err).To(Not(HaveOccurred()))
+
+			// VERIFY: Only the default container gets the sources
+			Expect(ret.Spec.Containers[1].EnvFrom).To(Equal(envFrom))
+			Expect(ret.Spec.Containers[0].EnvFrom).To(BeEmpty())
+
+			// VERIFY: The original spec is untouched
+			Expect(podTemplateSpec.Spec.Containers[1].EnvFrom).To(BeEmpty())
+		})
+
+		It("AddEnvFrom should fail if invalid container name supplied", func() {
+			config := &PodTemplateSpecMutationConfig{DefaultContainerName: "bogus"}
+
+			// Run it
+			_, err := config.AddEnvFrom(ctx, podTemplateSpec, []corev1.EnvFromSource{})
+			Expect(err).To(HaveOccurred())
+		})
 	})
 })
@@ -0,0 +1,111 @@
+package v1alpha1
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultSSHPort is the port that the SSH server in a Pod is expected to
+	// listen on, unless the PodSSHConfig says otherwise.
+	DefaultSSHPort int32 = 22
+
+	// DefaultSSHMountPath is the directory that the authorized keys file is
+	// mounted into, unless the PodSSHConfig says otherwise.
+	DefaultSSHMountPath = "/etc/ssh/oz"
+
+	// SSHAuthorizedKeysFile is the name of the file (and Secret key) holding
+	// the SSH public key of the requester.
+	SSHAuthorizedKeysFile = "authorized_keys"
+)
+
+// sshPublicKeyTypes are the SSH public key types accepted in the
+// sshPublicKey of a PodAccessRequest.
+var sshPublicKeyTypes = map[string]bool{
+	"ssh-rsa":                            true,
+	"ssh-ed25519":                        true,
+	"ecdsa-sha2-nistp256":                true,
+	"ecdsa-sha2-nistp384":                true,
+	"ecdsa-sha2-nistp521":                true,
+	"sk-ssh-ed25519@openssh.com":         true,
+	"sk-ecdsa-sha2-nistp256@openssh.com": true,
+}
+
+// PodSSHConfig allows the requesters of PodAccessRequests to SSH into their Pod rather than
+// using `kubectl exec`. The SSH public key supplied by the requester is mounted into the
+// container from a Secret, and the SSH port is exposed on it. The image of the container must
+// run an SSH server that reads the authorized keys from `mountPath/authorized_keys`.
+type PodSSHConfig struct {
+	// ContainerName is the container that the authorized keys are mounted into, and that
+	// exposes the SSH port. Defaults to the first container of the Pod.
+	//
+	// +kubebuilder:validation:Optional
+	ContainerName string `json:"containerName,omitempty"`
+
+	// Port is the port that the SSH server in the container listens on.
+	//
+	// +kubebuilder:default:=22
+	// +kubebuilder:validation:Optional
+	Port int32 `json:"port,omitempty"`
+
+	// MountPath is the directory that the `authorized_keys` file is mounted into.
+	//
+	// +kubebuilder:default:="/etc/ssh/oz"
+	// +kubebuilder:validation:Optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// GetPort returns the Port field, defaulting to DefaultSSHPort.
+func (c *PodSSHConfig) GetPort() int32 {
+	if c.Port == 0 {
+		return DefaultSSHPort
+	}
+	return c.Port
+}
+
+// GetMountPath returns the MountPath field, defaulting to DefaultSSHMountPath.
+func (c *PodSSHConfig) GetMountPath() string {
+	if c.MountPath == "" {
+		return DefaultSSHMountPath
+	}
+	return c.MountPath
+}
+
+// ValidateSSHPublicKey verifies that the supplied key is a single SSH public
+// key in the OpenSSH authorized_keys format (eg, "ssh-ed25519 AAAA... me@host"),
+// without any options. The key blob must decode, and must be of the type it
+// claims to be.
+func ValidateSSHPublicKey(key string) error {
+	if strings.ContainsAny(key, "\r\n") {
+		return fmt.Errorf("must be a single line")
+	}
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return fmt.Errorf("must be in the format \"<type> <base64 key> [comment]\"")
+	}
+	keyType := fields[0]
+	if !sshPublicKeyTypes[keyType] {
+		return fmt.Errorf("unsupported key type %q", keyType)
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return fmt.Errorf("key is not valid base64: %w", err)
+	}
+
+	// The blob starts with the length-prefixed key type, which must match
+	// the type the key claims to be.
+	if len(blob) < 4 {
+		return fmt.Errorf("key is truncated")
+	}
+	n := binary.BigEndian.Uint32(blob[:4])
+	if uint64(len(blob)-4) < uint64(n) || !bytes.Equal(blob[4:4+n], []byte(keyType)) {
+		return fmt.Errorf("key does not match its type %q", keyType)
+	}
+	if len(blob) == int(4+n) {
+		return fmt.Errorf("key is truncated")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package v1alpha1
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+var _ = Describe("Printcolumns", func() {
+	// renderColumns renders the additionalPrinterColumns of the named CRD
+	// (as `kubectl get` would) for the supplied object, by column name.
+	renderColumns := func(crd string, obj runtime.Object) map[string]string {
+		raw, err := os.ReadFile(filepath.Join(
+			"..", "..", "..", "config", "crd", "bases", "crds.wizardofoz.co_"+crd+".yaml",
+		))
+		Expect(err).ToNot(HaveOccurred())
+		def := struct {
+			Spec struct {
+				Versions []struct {
+					AdditionalPrinterColumns []struct {
+						Name     string `json:"name"`
+						JSONPath string `json:"jsonPath"`
+					} `json:"additionalPrinterColumns"`
+				} `json:"versions"`
+			} `json:"spec"`
+		}{}
+		Expect(yaml.Unmarshal(raw, &def)).To(Succeed())
+		Expect(def.Spec.Versions).To(HaveLen(1))
+
+		data, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		Expect(err).ToNot(HaveOccurred())
+		columns := map[string]string{}
+		for _, col := range def.Spec.Versions[0].AdditionalPrinterColumns {
+			jp := jsonpath.New(col.Name).AllowMissingKeys(true)
+			Expect(jp.Parse(fmt.Sprintf("{%s}", col.JSONPath))).To(Succeed())
+			buf := &bytes.Buffer{}
+			Expect(jp.Execute(buf, data)).To(Succeed())
+			columns[col.Name] = buf.String()
+		}
+		return columns
+	}
+
+	expiresAt := metav1.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+
+	It("Should show the Pod and the end of the access of an ExecAccessRequest", func() {
+		req := &ExecAccessRequest{
+			Spec: ExecAccessRequestSpec{TemplateName: "readonly"},
+			Status: ExecAccessRequestStatus{
+				CoreStatus: CoreStatus{Ready: true, AccessExpiresAt: &expiresAt},
+				PodName:    "app-abc12",
+			},
+		}
+		Expect(renderColumns("execaccessrequests", req)).To(Equal(map[string]string{
+			"Template": "readonly",
+			"Pod":      "app-abc12",
+			"Expires":  "2023-03-14T15:00:00Z",
+			"Ready":    "true",
+		}))
+	})
+
+	It("Should show the dedicated Pod and its phase of a PodAccessRequest", func() {
+		req := &PodAccessRequest{
+			Spec: PodAccessRequestSpec{TemplateName: "debug"},
+			Status: PodAccessRequestStatus{
+				PodName:  "debug-xyz89",
+				PodPhase: corev1.PodPending,
+			},
+		}
+		Expect(renderColumns("podaccessrequests", req)).To(Equal(map[string]string{
+			"Template": "debug",
+			"Pod":      "debug-xyz89",
+			"Phase":    "Pending",
+			"Ready":    "",
+		}))
+	})
+
+	It("Should show the target and the maxDuration of the templates", func() {
+		target := &CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "app"}
+		accessConfig := AccessConfig{DefaultDuration: "1h", MaxDuration: "4h"}
+
+		Expect(renderColumns("execaccesstemplates", &ExecAccessTemplate{
+			Spec: ExecAccessTemplateSpec{AccessConfig: accessConfig, ControllerTargetRef: target},
+		})).To(Equal(map[string]string{
+			"Target":      "app",
+			"MaxDuration": "4h",
+			"Ready":       "",
+		}))
+		Expect(renderColumns("podaccesstemplates", &PodAccessTemplate{
+			Spec: PodAccessTemplateSpec{AccessConfig: accessConfig, ControllerTargetRef: target},
+		})).To(Equal(map[string]string{
+			"Target":      "app",
+			"MaxDuration": "4h",
+			"Ready":       "",
+		}))
+	})
+})
@@ -0,0 +1,28 @@
+package v1alpha1
+
+// The priority classes of a PodAccessRequest (see Spec.Priority), which order
+// the queue of requests waiting on a Pod from their template.
+const (
+	PriorityLow      = "low"
+	PriorityNormal   = "normal"
+	PriorityHigh     = "high"
+	PriorityCritical = "critical"
+)
+
+// PriorityClasses lists the known priority classes, lowest first.
+var PriorityClasses = []string{PriorityLow, PriorityNormal, PriorityHigh, PriorityCritical}
+
+// PriorityRank returns the rank of the supplied priority class, where higher
+// ranks go first. An empty class ranks as PriorityNormal, and an unknown class
+// ranks as -1.
+func PriorityRank(priority string) int {
+	if priority == "" {
+		priority = PriorityNormal
+	}
+	for rank, class := range PriorityClasses {
+		if class == priority {
+			return rank
+		}
+	}
+	return -1
+}
@@ -0,0 +1,43 @@
+package v1alpha1
+
+// NarrowExecVerbs returns the verbs of the allowed list that are also in the
+// requested list, in the order of the allowed list. An empty requested list
+// narrows nothing, and all of the allowed verbs are returned.
+func NarrowExecVerbs(allowed []string, requested []string) []string {
+	if len(requested) == 0 {
+		return allowed
+	}
+	wanted := map[string]bool{}
+	for _, verb := range requested {
+		wanted[verb] = true
+	}
+	narrowed := []string{}
+	for _, verb := range allowed {
+		if wanted[verb] {
+			narrowed = append(narrowed, verb)
+		}
+	}
+	return narrowed
+}
+
+// disallowedVerbs returns the verbs of the requested list that are not in the
+// allowed list, in the order they were requested.
+func disallowedVerbs(allowed []string, requested []string) []string {
+	ok := map[string]bool{}
+	for _, verb := range allowed {
+		ok[verb] = true
+	}
+	var disallowed []string
+	for _, verb := range requested {
+		if !ok[verb] {
+			disallowed = append(disallowed, verb)
+		}
+	}
+	return disallowed
+}
+
+// equalVerbs returns true if both lists hold the same verbs, in any order. A
+// nil list is equal to an empty one.
+func equalVerbs(a, b []string) bool {
+	return len(disallowedVerbs(a, b)) == 0 && len(disallowedVerbs(b, a)) == 0
+}
@@ -0,0 +1,68 @@
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ErrTemplateNotShared indicates that an Access Request names a template in
+// another namespace, and that template is not shared with the namespace of the
+// request through its namespacePattern.
+var ErrTemplateNotShared = errors.New("template is not shared with this namespace")
+
+// shareTemplate prepares the supplied template, fetched from the
+// Spec.templateNamespace of an Access Request, for use by that request in the
+// supplied namespace. Templates in the same namespace are left alone. A
+// template in another namespace must be shared with the namespace through its
+// namespacePattern, and the namespace must accept templates from the namespace
+// of the template (see OzConfig.AcceptsTemplatesFrom) - so that a template
+// author can not grant access in namespaces that never agreed to it. The
+// template is then moved (in memory only) into the namespace, so that the
+// targetRef is looked up and the access resources are created alongside the
+// request.
+//
+// Returns:
+//   - An "error" wrapping ErrTemplateNotShared if the template is not shared
+//     with (or not accepted by) the namespace, or if its namespacePattern is
+//     invalid
+//   - An "error" if the OzConfig of the namespace could not be fetched
+func shareTemplate(
+	ctx context.Context,
+	cl client.Reader,
+	tmpl ITemplateResource,
+	namespace string,
+) error {
+	if tmpl.GetNamespace() == namespace {
+		return nil
+	}
+
+	cfg := tmpl.GetAccessConfig()
+	switch {
+	case cfg.GetNamespacePattern() == "":
+		return fmt.Errorf("%w: %s/%s has no namespacePattern",
+			ErrTemplateNotShared, tmpl.GetNamespace(), tmpl.GetName())
+	case cfg.ValidateNamespacePattern() != nil:
+		return fmt.Errorf("%w: %s/%s: %s",
+			ErrTemplateNotShared, tmpl.GetNamespace(), tmpl.GetName(), cfg.ValidateNamespacePattern())
+	case !cfg.SharesWithNamespace(namespace):
+		return fmt.Errorf("%w: %s/%s only shares with namespaces matching %q",
+			ErrTemplateNotShared, tmpl.GetNamespace(), tmpl.GetName(), cfg.GetNamespacePattern())
+	}
+
+	ozConfig, err := GetOzConfig(ctx, cl, namespace)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err != nil || !ozConfig.AcceptsTemplatesFrom(tmpl.GetNamespace()) {
+		return fmt.Errorf("%w: namespace %s does not accept templates from namespace %s "+
+			"(see the allowedTemplateNamespaces of its OzConfig)",
+			ErrTemplateNotShared, namespace, tmpl.GetNamespace())
+	}
+
+	tmpl.SetNamespace(namespace)
+	return nil
+}
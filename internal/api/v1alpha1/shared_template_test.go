@@ -0,0 +1,189 @@
+package v1alpha1
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("Shared templates", Ordered, func() {
+	var (
+		ctx      = context.Background()
+		home     *corev1.Namespace
+		teamA    *corev1.Namespace
+		teamB    *corev1.Namespace
+		teamC    *corev1.Namespace
+		other    *corev1.Namespace
+		template *ExecAccessTemplate
+	)
+
+	// request returns an ExecAccessRequest in the supplied namespace, naming
+	// the shared template.
+	request := func(ns *corev1.Namespace) *ExecAccessRequest {
+		return &ExecAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "req", Namespace: ns.GetName()},
+			Spec: ExecAccessRequestSpec{
+				TemplateName:      template.GetName(),
+				TemplateNamespace: home.GetName(),
+			},
+		}
+	}
+
+	BeforeAll(func() {
+		By("Should have a home namespace for the template, and namespaces to request from")
+		prefix := utils.RandomString(8)
+		home = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: prefix + "-oz"}}
+		teamA = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: prefix + "-team-a"}}
+		teamB = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: prefix + "-team-b"}}
+		teamC = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: prefix + "-team-c"}}
+		other = &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: prefix + "-other"}}
+		for _, ns := range []*corev1.Namespace{home, teamA, teamB, teamC, other} {
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+		}
+
+		By("Should have the team-a and team-b namespaces accept templates from the home namespace")
+		for _, ns := range []*corev1.Namespace{teamA, teamB} {
+			Expect(k8sClient.Create(ctx, &OzConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: OzConfigName, Namespace: ns.GetName()},
+				Spec:       OzConfigSpec{AllowedTemplateNamespaces: []string{home.GetName()}},
+			})).To(Succeed())
+		}
+
+		By("Should have an ExecAccessTemplate shared with the team namespaces")
+		template = &ExecAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared", Namespace: home.GetName()},
+			Spec: ExecAccessTemplateSpec{
+				AccessConfig: AccessConfig{
+					AllowedGroups:    []string{"devs"},
+					DefaultDuration:  "1h",
+					MaxDuration:      "2h",
+					NamespacePattern: prefix + "-team-*",
+				},
+				ControllerTargetRef: &CrossVersionObjectReference{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       "app",
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, template)).To(Succeed())
+	})
+
+	AfterAll(func() {
+		for _, ns := range []*corev1.Namespace{home, teamA, teamB, teamC, other} {
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		}
+	})
+
+	It("Should resolve the template into the namespace of each matching request", func() {
+		for _, ns := range []*corev1.Namespace{teamA, teamB} {
+			req := request(ns)
+			tmpl, err := req.GetTemplate(ctx, k8sClient)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tmpl.GetName()).To(Equal(template.GetName()))
+			Expect(tmpl.GetUID()).To(Equal(template.GetUID()))
+
+			// VERIFY: The targetRef is looked up alongside the request
+			Expect(tmpl.GetNamespace()).To(Equal(ns.GetName()))
+		}
+	})
+
+	It("Should reject requests from namespaces the template is not shared with", func() {
+		_, err := request(other).GetTemplate(ctx, k8sClient)
+		Expect(errors.Is(err, ErrTemplateNotShared)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("only shares with namespaces matching"))
+	})
+
+	It("Should reject requests from matching namespaces that do not accept the template", func() {
+		_, err := request(teamC).GetTemplate(ctx, k8sClient)
+		Expect(errors.Is(err, ErrTemplateNotShared)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(
+			"namespace " + teamC.GetName() + " does not accept templates from namespace " + home.GetName(),
+		))
+	})
+
+	It("Should leave templates in the namespace of the request alone", func() {
+		req := request(home)
+		req.Spec.TemplateNamespace = ""
+		Expect(req.GetTemplateNamespace()).To(Equal(home.GetName()))
+
+		tmpl, err := req.GetTemplate(ctx, k8sClient)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(tmpl.GetNamespace()).To(Equal(home.GetName()))
+	})
+
+	It("Should reject requests naming an unshared template in another namespace", func() {
+		unshared := &ExecAccessTemplate{}
+		unshared.Name = "unshared"
+		unshared.Namespace = home.GetName()
+		err := shareTemplate(ctx, k8sClient, unshared, teamA.GetName())
+		Expect(errors.Is(err, ErrTemplateNotShared)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("has no namespacePattern"))
+	})
+})
+
+var _ = Describe("shareTemplate()", func() {
+	var ctx = context.Background()
+
+	template := func() *ExecAccessTemplate {
+		tmpl := &ExecAccessTemplate{}
+		tmpl.Name = "shared"
+		tmpl.Namespace = "oz-templates"
+		tmpl.Spec.AccessConfig.NamespacePattern = "team-*"
+		return tmpl
+	}
+
+	// reader returns a client.Reader holding the supplied OzConfigs.
+	reader := func(configs ...client.Object) client.Reader {
+		s := runtime.NewScheme()
+		Expect(AddToScheme(s)).To(Succeed())
+		return fake.NewClientBuilder().WithScheme(s).WithObjects(configs...).Build()
+	}
+
+	It("Should share the template with a namespace that accepts it", func() {
+		tmpl := template()
+		cl := reader(&OzConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: OzConfigName, Namespace: "team-a"},
+			Spec:       OzConfigSpec{AllowedTemplateNamespaces: []string{"oz-templates"}},
+		})
+		Expect(shareTemplate(ctx, cl, tmpl, "team-a")).To(Succeed())
+		Expect(tmpl.GetNamespace()).To(Equal("team-a"))
+	})
+
+	It("Should not share the template with a namespace without an OzConfig", func() {
+		err := shareTemplate(ctx, reader(), template(), "team-a")
+		Expect(errors.Is(err, ErrTemplateNotShared)).To(BeTrue())
+	})
+
+	It("Should not share the template with a namespace accepting other namespaces", func() {
+		cl := reader(&OzConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: OzConfigName, Namespace: "team-a"},
+			Spec:       OzConfigSpec{AllowedTemplateNamespaces: []string{"team-b"}},
+		})
+		err := shareTemplate(ctx, cl, template(), "team-a")
+		Expect(errors.Is(err, ErrTemplateNotShared)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(
+			"namespace team-a does not accept templates from namespace oz-templates",
+		))
+	})
+
+	It("Should never share a template with the kube-system namespace", func() {
+		tmpl := template()
+		tmpl.Spec.AccessConfig.NamespacePattern = "*"
+		cl := reader(&OzConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: OzConfigName, Namespace: "kube-system"},
+			Spec:       OzConfigSpec{AllowedTemplateNamespaces: []string{"oz-templates"}},
+		})
+		err := shareTemplate(ctx, cl, tmpl, "kube-system")
+		Expect(errors.Is(err, ErrTemplateNotShared)).To(BeTrue())
+	})
+})
@@ -0,0 +1,161 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TemplateParameter declares a variable that an Access Request may (or must)
+// supply a value for when it is created. This allows a single generic
+// template to be reused across many slightly different targets.
+//
+// Values are referenced in the template as `${name}`.
+type TemplateParameter struct {
+	// Name of the parameter, as referenced by `${name}` in the template and in
+	// the `spec.parameterValues` map of the Access Request.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z_][a-zA-Z0-9_]*$`
+	Name string `json:"name"`
+
+	// Description is a human readable description of the parameter.
+	Description string `json:"description,omitempty"`
+
+	// Required indicates that Access Requests must supply a value for this
+	// parameter, otherwise they are rejected.
+	//
+	// +kubebuilder:default:=false
+	Required bool `json:"required,omitempty"`
+
+	// Default is used as the value of the parameter when the Access Request
+	// does not supply one.
+	Default string `json:"default,omitempty"`
+
+	// Pattern is an optional regular expression that any supplied value, and
+	// the Default (if set), must fully match.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// ResolveParameters validates the supplied values against the parameters
+// declared by a template, and returns the full set of values (including any
+// defaults) that should be substituted into the template.
+//
+// Returns:
+//   - A map of parameter names to their resolved values
+//   - An "error" if a required value is missing, a value (or default) does not
+//     match its pattern, or a value is supplied for an undeclared parameter
+func ResolveParameters(
+	params []TemplateParameter,
+	values map[string]string,
+) (map[string]string, error) {
+	resolved := map[string]string{}
+	declared := map[string]bool{}
+
+	for _, param := range params {
+		declared[param.Name] = true
+
+		val, ok := values[param.Name]
+		if !ok {
+			if param.Required {
+				return nil, fmt.Errorf("missing required parameter %q", param.Name)
+			}
+			val = param.Default
+		}
+
+		// An optional parameter without a default is simply left empty.
+		if ok || val != "" {
+			if err := param.validateValue(val); err != nil {
+				return nil, err
+			}
+		}
+		resolved[param.Name] = val
+	}
+
+	// Sort the unknown keys so the error message is stable.
+	var unknown []string
+	for name := range values {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown parameters: %s", strings.Join(unknown, ", "))
+	}
+
+	return resolved, nil
+}
+
+// compilePattern compiles the Pattern of the parameter, anchored so that it
+// has to match whole values. A parameter without a Pattern returns nil.
+func (p TemplateParameter) compilePattern() (*regexp.Regexp, error) {
+	if p.Pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", p.Pattern))
+	if err != nil {
+		return nil, fmt.Errorf("parameter %q has an invalid pattern: %w", p.Name, err)
+	}
+	return re, nil
+}
+
+// validateValue returns an error if the supplied value does not fully match
+// the Pattern of the parameter, or if the Pattern itself is invalid. Any value
+// is valid for a parameter without a Pattern.
+func (p TemplateParameter) validateValue(val string) error {
+	re, err := p.compilePattern()
+	if err != nil || re == nil {
+		return err
+	}
+	if !re.MatchString(val) {
+		return fmt.Errorf("parameter %q value %q does not match pattern %q", p.Name, val, p.Pattern)
+	}
+	return nil
+}
+
+// ValidateParameters returns an error if any of the Spec.parameters has an
+// invalid pattern, or a default that does not match its own pattern -
+// otherwise every request relying on that default would be rejected.
+func (a *AccessConfig) ValidateParameters() error {
+	for _, param := range a.Parameters {
+		re, err := param.compilePattern()
+		if err != nil {
+			return err
+		}
+		if re != nil && param.Default != "" && !re.MatchString(param.Default) {
+			return fmt.Errorf("parameter %q default %q does not match pattern %q",
+				param.Name, param.Default, param.Pattern)
+		}
+	}
+	return nil
+}
+
+// SubstituteParameters replaces every `${name}` reference in the supplied
+// string with the matching value. References to unknown parameters are left
+// untouched.
+func SubstituteParameters(s string, values map[string]string) string {
+	if len(values) == 0 {
+		return s
+	}
+	pairs := make([]string, 0, len(values)*2)
+	for name, val := range values {
+		pairs = append(pairs, fmt.Sprintf("${%s}", name), val)
+	}
+	return strings.NewReplacer(pairs...).Replace(s)
+}
+
+// equalParameterValues returns true if both sets of parameter values are the
+// same. A nil map is equal to an empty one.
+func equalParameterValues(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
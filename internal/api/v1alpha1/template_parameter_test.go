@@ -0,0 +1,94 @@
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TemplateParameter", func() {
+	params := []TemplateParameter{
+		{Name: "app", Required: true, Pattern: "[a-z]+"},
+		{Name: "region", Default: "us-west-2"},
+	}
+
+	Context("ResolveParameters()", func() {
+		It("Should return supplied values and fill in defaults", func() {
+			ret, err := ResolveParameters(params, map[string]string{"app": "web"})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(ret).To(Equal(map[string]string{"app": "web", "region": "us-west-2"}))
+		})
+
+		It("Should reject a missing required parameter", func() {
+			_, err := ResolveParameters(params, map[string]string{"region": "eu-west-1"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(`missing required parameter "app"`))
+		})
+
+		It("Should reject values that only partially match the pattern", func() {
+			_, err := ResolveParameters(params, map[string]string{"app": "web-1"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("does not match pattern"))
+		})
+
+		It("Should reject defaults that do not match the pattern", func() {
+			_, err := ResolveParameters(
+				[]TemplateParameter{{Name: "app", Pattern: "[a-z]+", Default: "web-1"}},
+				map[string]string{},
+			)
+			Expect(err).To(MatchError(`parameter "app" value "web-1" does not match pattern "[a-z]+"`))
+
+			// An optional parameter without a default is left empty
+			ret, err := ResolveParameters(
+				[]TemplateParameter{{Name: "app", Pattern: "[a-z]+"}},
+				map[string]string{},
+			)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret).To(Equal(map[string]string{"app": ""}))
+		})
+
+		It("Should reject undeclared parameters", func() {
+			_, err := ResolveParameters(params, map[string]string{"app": "web", "zz": "1", "aa": "2"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("unknown parameters: aa, zz"))
+		})
+
+		It("Should report invalid patterns", func() {
+			_, err := ResolveParameters(
+				[]TemplateParameter{{Name: "app", Pattern: "("}},
+				map[string]string{"app": "web"},
+			)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("invalid pattern"))
+		})
+	})
+
+	Context("ValidateParameters()", func() {
+		It("Should accept defaults that match their pattern", func() {
+			cfg := &AccessConfig{Parameters: params}
+			Expect(cfg.ValidateParameters()).To(Succeed())
+		})
+
+		It("Should reject defaults that do not match their pattern", func() {
+			cfg := &AccessConfig{Parameters: []TemplateParameter{
+				{Name: "region", Pattern: "[a-z]+-[a-z]+-[0-9]", Default: "us-west"},
+			}}
+			Expect(cfg.ValidateParameters()).To(MatchError(
+				`parameter "region" default "us-west" does not match pattern "[a-z]+-[a-z]+-[0-9]"`,
+			))
+		})
+
+		It("Should reject invalid patterns", func() {
+			cfg := &AccessConfig{Parameters: []TemplateParameter{{Name: "app", Pattern: "("}}}
+			Expect(cfg.ValidateParameters()).To(MatchError(ContainSubstring("invalid pattern")))
+		})
+	})
+
+	Context("SubstituteParameters()", func() {
+		It("Should replace known references and leave unknown ones alone", func() {
+			Expect(SubstituteParameters(
+				"${app}-${region}-${other}",
+				map[string]string{"app": "web", "region": "us-west-2"},
+			)).To(Equal("web-us-west-2-${other}"))
+		})
+	})
+})
@@ -22,10 +22,26 @@ limitations under the License.
 package v1alpha1
 
 import (
-	"k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AccessCommand) DeepCopyInto(out *AccessCommand) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessCommand.
+func (in *AccessCommand) DeepCopy() *AccessCommand {
+	if in == nil {
+		return nil
+	}
+	out := new(AccessCommand)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AccessConfig) DeepCopyInto(out *AccessConfig) {
 	*out = *in
@@ -34,6 +50,90 @@ func (in *AccessConfig) DeepCopyInto(out *AccessConfig) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AllowedDelegators != nil {
+		in, out := &in.AllowedDelegators, &out.AllowedDelegators
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedRequesters != nil {
+		in, out := &in.AllowedRequesters, &out.AllowedRequesters
+		*out = new(AllowedRequesters)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]corev1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]TemplateParameter, len(*in))
+		copy(*out, *in)
+	}
+	if in.ApprovalRequiredSelector != nil {
+		in, out := &in.ApprovalRequiredSelector, &out.ApprovalRequiredSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalApproval != nil {
+		in, out := &in.ExternalApproval, &out.ExternalApproval
+		*out = new(ExternalApprovalConfig)
+		**out = **in
+	}
+	if in.VerbsByGroup != nil {
+		in, out := &in.VerbsByGroup, &out.VerbsByGroup
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.ExpireAtEndOfDay != nil {
+		in, out := &in.ExpireAtEndOfDay, &out.ExpireAtEndOfDay
+		*out = new(EndOfDayConfig)
+		**out = **in
+	}
+	if in.IncidentDurations != nil {
+		in, out := &in.IncidentDurations, &out.IncidentDurations
+		*out = new(DurationProfile)
+		**out = **in
+	}
+	if in.AllowedCommands != nil {
+		in, out := &in.AllowedCommands, &out.AllowedCommands
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedNamespaces != nil {
+		in, out := &in.AllowedNamespaces, &out.AllowedNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AccessCommands != nil {
+		in, out := &in.AccessCommands, &out.AccessCommands
+		*out = make([]AccessCommand, len(*in))
+		copy(*out, *in)
+	}
+	if in.AccessCommandOverrides != nil {
+		in, out := &in.AccessCommandOverrides, &out.AccessCommandOverrides
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AccessConfig.
@@ -46,6 +146,31 @@ func (in *AccessConfig) DeepCopy() *AccessConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllowedRequesters) DeepCopyInto(out *AllowedRequesters) {
+	*out = *in
+	if in.Users != nil {
+		in, out := &in.Users, &out.Users
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedRequesters.
+func (in *AllowedRequesters) DeepCopy() *AllowedRequesters {
+	if in == nil {
+		return nil
+	}
+	out := new(AllowedRequesters)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CoreStatus.
 func (in *CoreStatus) DeepCopy() *CoreStatus {
 	if in == nil {
@@ -71,12 +196,42 @@ func (in *CrossVersionObjectReference) DeepCopy() *CrossVersionObjectReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DurationProfile) DeepCopyInto(out *DurationProfile) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DurationProfile.
+func (in *DurationProfile) DeepCopy() *DurationProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(DurationProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EndOfDayConfig) DeepCopyInto(out *EndOfDayConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndOfDayConfig.
+func (in *EndOfDayConfig) DeepCopy() *EndOfDayConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EndOfDayConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExecAccessRequest) DeepCopyInto(out *ExecAccessRequest) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -133,6 +288,18 @@ func (in *ExecAccessRequestList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExecAccessRequestSpec) DeepCopyInto(out *ExecAccessRequestSpec) {
 	*out = *in
+	if in.ParameterValues != nil {
+		in, out := &in.ParameterValues, &out.ParameterValues
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RequestedVerbs != nil {
+		in, out := &in.RequestedVerbs, &out.RequestedVerbs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExecAccessRequestSpec.
@@ -257,12 +424,110 @@ func (in *ExecAccessTemplateStatus) DeepCopy() *ExecAccessTemplateStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalApprovalConfig) DeepCopyInto(out *ExternalApprovalConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalApprovalConfig.
+func (in *ExternalApprovalConfig) DeepCopy() *ExternalApprovalConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalApprovalConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OzConfig) DeepCopyInto(out *OzConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OzConfig.
+func (in *OzConfig) DeepCopy() *OzConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OzConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OzConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OzConfigList) DeepCopyInto(out *OzConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OzConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OzConfigList.
+func (in *OzConfigList) DeepCopy() *OzConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(OzConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OzConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OzConfigSpec) DeepCopyInto(out *OzConfigSpec) {
+	*out = *in
+	if in.DeniedUsers != nil {
+		in, out := &in.DeniedUsers, &out.DeniedUsers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedTemplateNamespaces != nil {
+		in, out := &in.AllowedTemplateNamespaces, &out.AllowedTemplateNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OzConfigSpec.
+func (in *OzConfigSpec) DeepCopy() *OzConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OzConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodAccessRequest) DeepCopyInto(out *PodAccessRequest) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -319,6 +584,18 @@ func (in *PodAccessRequestList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodAccessRequestSpec) DeepCopyInto(out *PodAccessRequestSpec) {
 	*out = *in
+	if in.ParameterValues != nil {
+		in, out := &in.ParameterValues, &out.ParameterValues
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.RequestedVerbs != nil {
+		in, out := &in.RequestedVerbs, &out.RequestedVerbs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodAccessRequestSpec.
@@ -422,12 +699,22 @@ func (in *PodAccessTemplateSpec) DeepCopyInto(out *PodAccessTemplateSpec) {
 	}
 	if in.PodSpec != nil {
 		in, out := &in.PodSpec, &out.PodSpec
-		*out = new(v1.PodSpec)
+		*out = new(corev1.PodSpec)
 		(*in).DeepCopyInto(*out)
 	}
 	out.MaxStorage = in.MaxStorage.DeepCopy()
 	out.MaxCPU = in.MaxCPU.DeepCopy()
 	out.MaxMemory = in.MaxMemory.DeepCopy()
+	if in.AllowedPriorities != nil {
+		in, out := &in.AllowedPriorities, &out.AllowedPriorities
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SSHConfig != nil {
+		in, out := &in.SSHConfig, &out.SSHConfig
+		*out = new(PodSSHConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodAccessTemplateSpec.
@@ -456,6 +743,21 @@ func (in *PodAccessTemplateStatus) DeepCopy() *PodAccessTemplateStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodSSHConfig) DeepCopyInto(out *PodSSHConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSSHConfig.
+func (in *PodSSHConfig) DeepCopy() *PodSSHConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PodSSHConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodTemplateSpecMutationConfig) DeepCopyInto(out *PodTemplateSpecMutationConfig) {
 	*out = *in
@@ -479,7 +781,7 @@ func (in *PodTemplateSpecMutationConfig) DeepCopyInto(out *PodTemplateSpecMutati
 	}
 	if in.Env != nil {
 		in, out := &in.Env, &out.Env
-		*out = make([]v1.EnvVar, len(*in))
+		*out = make([]corev1.EnvVar, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -518,3 +820,18 @@ func (in *PodTemplateSpecMutationConfig) DeepCopy() *PodTemplateSpecMutationConf
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemplateParameter) DeepCopyInto(out *TemplateParameter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemplateParameter.
+func (in *TemplateParameter) DeepCopy() *TemplateParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(TemplateParameter)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MessagePrefix is the prefix on the message of every audit log record
+// written by the operator. Only log records with this prefix are shipped to
+// a Backend.
+const MessagePrefix = "AUDIT - "
+
+// Record is a single audit log record, as shipped to a Backend.
+type Record struct {
+	// Time is when the record was written.
+	Time time.Time `json:"time"`
+
+	// Logger is the name of the logger that wrote the record.
+	Logger string `json:"logger,omitempty"`
+
+	// Message is the log message, eg "AUDIT - Access request approved".
+	Message string `json:"message"`
+
+	// Fields holds the (already redacted) key/value pairs of the record.
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// Backend is implemented by anything that can store batches of audit records
+// outside of the cluster for long-term retention.
+type Backend interface {
+	// Name returns a short description of the backend for logging.
+	Name() string
+
+	// Write stores the supplied batch of records. If an error is returned,
+	// the whole batch is considered unwritten and will be retried.
+	Write(ctx context.Context, records []Record) error
+}
+
+// newRecord builds a Record from a logr-style message and list of
+// alternating key/value pairs.
+func newRecord(logger string, msg string, keysAndValues []any) Record {
+	rec := Record{
+		Time:    time.Now().UTC(),
+		Logger:  logger,
+		Message: msg,
+		Fields:  map[string]any{},
+	}
+	for i := 1; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i-1].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i-1])
+		}
+		val := keysAndValues[i]
+		if err, ok := val.(error); ok {
+			val = err.Error()
+		}
+		rec.Fields[key] = val
+	}
+	return rec
+}
+
+// encodeRecords encodes the records as newline delimited JSON. Any field
+// value that cannot be encoded is written in its fmt.Sprint() form instead,
+// so that a single bad value never loses a whole record.
+func encodeRecords(records []Record) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, rec := range records {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			fields := make(map[string]any, len(rec.Fields))
+			for k, v := range rec.Fields {
+				fields[k] = fmt.Sprint(v)
+			}
+			rec.Fields = fields
+			if line, err = json.Marshal(rec); err != nil {
+				return nil, err
+			}
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
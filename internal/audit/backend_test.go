@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Backends", func() {
+	var (
+		ctx      = context.Background()
+		server   *httptest.Server
+		requests []*http.Request
+		bodies   []string
+		status   int
+		response string
+	)
+
+	records := []Record{
+		{Message: MessagePrefix + "one", Fields: map[string]any{"user": "alice"}},
+		{Message: MessagePrefix + "two", Fields: map[string]any{"ch": make(chan int)}},
+	}
+
+	BeforeEach(func() {
+		requests, bodies = nil, nil
+		status, response = http.StatusOK, ""
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r)
+			bodies = append(bodies, string(body))
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(response))
+		}))
+		DeferCleanup(server.Close)
+	})
+
+	Context("S3Backend", func() {
+		var backend *S3Backend
+
+		BeforeEach(func() {
+			backend = &S3Backend{
+				Endpoint:        server.URL,
+				Bucket:          "audit",
+				Prefix:          "oz",
+				Region:          "us-west-2",
+				AccessKeyID:     "AKID",
+				SecretAccessKey: "SECRET",
+				id:              "abcd",
+				now:             func() time.Time { return time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC) },
+			}
+		})
+
+		It("Should write the batch as a signed, newline delimited JSON object", func() {
+			Expect(backend.Write(ctx, records)).To(Succeed())
+			Expect(requests).To(HaveLen(1))
+
+			req := requests[0]
+			Expect(req.Method).To(Equal(http.MethodPut))
+			Expect(req.URL.Path).To(Equal("/audit/oz/2023/05/01/120000.000000000-abcd.jsonl"))
+			Expect(req.Header.Get("X-Amz-Date")).To(Equal("20230501T120000Z"))
+			Expect(req.Header.Get("Authorization")).To(HavePrefix(
+				"AWS4-HMAC-SHA256 Credential=AKID/20230501/us-west-2/s3/aws4_request, " +
+					"SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=",
+			))
+
+			// VERIFY: One record per line, with unencodable values stringified
+			lines := strings.Split(strings.TrimSpace(bodies[0]), "\n")
+			Expect(lines).To(HaveLen(2))
+			rec := Record{}
+			Expect(json.Unmarshal([]byte(lines[0]), &rec)).To(Succeed())
+			Expect(rec.Fields["user"]).To(Equal("alice"))
+			Expect(lines[1]).To(ContainSubstring(`"ch":"0x`))
+		})
+
+		It("Should sign requests the same way as the AWS SDK", func() {
+			req := httptest.NewRequest(http.MethodPut,
+				"http://minio:9000/audit/"+s3URIEncode("oz/2023/05/01/120000.000000000-ab+c d.jsonl"), nil)
+			backend.sign(req, []byte("{\"a\":1}\n"), time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC))
+
+			// Generated with the aws-sdk-go v4.Signer for the same request
+			Expect(req.Header.Get("Authorization")).To(HaveSuffix(
+				"Signature=559a113e2ca6fd4e6cc03b6d2ff8a349796656ead120c1d6289cb5941f92c485",
+			))
+		})
+
+		It("Should return an error on a failed upload", func() {
+			status, response = http.StatusForbidden, "AccessDenied"
+			err := backend.Write(ctx, records)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("403 Forbidden: AccessDenied"))
+		})
+	})
+
+	Context("KafkaRESTBackend", func() {
+		var backend *KafkaRESTBackend
+
+		BeforeEach(func() {
+			backend = &KafkaRESTBackend{URL: server.URL + "/", Topic: "oz-audit"}
+		})
+
+		It("Should produce one message per record", func() {
+			response = `{"offsets":[{"partition":0,"offset":1},{"partition":0,"offset":2}]}`
+			Expect(backend.Write(ctx, records[:1])).To(Succeed())
+			Expect(requests).To(HaveLen(1))
+
+			req := requests[0]
+			Expect(req.Method).To(Equal(http.MethodPost))
+			Expect(req.URL.Path).To(Equal("/topics/oz-audit"))
+			Expect(req.Header.Get("Content-Type")).To(Equal(kafkaRESTContentType))
+
+			payload := kafkaProduceRequest{}
+			Expect(json.Unmarshal([]byte(bodies[0]), &payload)).To(Succeed())
+			Expect(payload.Records).To(HaveLen(1))
+			Expect(payload.Records[0].Value.Message).To(Equal(MessagePrefix + "one"))
+		})
+
+		It("Should return an error if any record was not produced", func() {
+			response = `{"offsets":[{"partition":0,"offset":1},{"error_code":50003,"error":"timed out"}]}`
+			err := backend.Write(ctx, records[:1])
+			Expect(err).To(MatchError("kafka produce to oz-audit failed: timed out"))
+		})
+
+		It("Should return an error on a failed request", func() {
+			status, response = http.StatusNotFound, `{"error_code":40401,"message":"Topic not found"}`
+			err := backend.Write(ctx, records[:1])
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("404 Not Found"))
+		})
+	})
+})
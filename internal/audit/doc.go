@@ -0,0 +1,9 @@
+// Package audit provides helpers for scrubbing sensitive data (for example,
+// secrets passed on the command line of a `kubectl exec` call) out of the
+// "AUDIT" log records that the operator writes before they reach the log
+// sink.
+//
+// It also provides an optional Shipper, which batches those same records and
+// writes them to a Backend (S3-compatible object storage, or a Kafka topic)
+// for long-term retention outside of the cluster.
+package audit
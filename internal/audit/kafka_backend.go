@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	kafkaRESTContentType = "application/vnd.kafka.json.v2+json"
+	kafkaRESTAccept      = "application/vnd.kafka.v2+json"
+)
+
+// KafkaRESTBackend writes each batch of audit records to a Kafka topic through
+// a Kafka REST Proxy (v2 API), with one Kafka message per record.
+//
+// See https://docs.confluent.io/platform/current/kafka-rest/api.html#post--topics-(string-topic_name)
+type KafkaRESTBackend struct {
+	// URL is the base URL of the Kafka REST Proxy.
+	URL string
+
+	// Topic that the records are produced to.
+	Topic string
+
+	// Client is used to make the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+var _ Backend = &KafkaRESTBackend{}
+
+type kafkaProduceRequest struct {
+	Records []kafkaProduceRecord `json:"records"`
+}
+
+type kafkaProduceRecord struct {
+	Value Record `json:"value"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// Name conforms to the Backend interface.
+func (b *KafkaRESTBackend) Name() string {
+	return fmt.Sprintf("kafka://%s", b.Topic)
+}
+
+// Write conforms to the Backend interface. The batch fails if any single
+// record in it was not produced.
+func (b *KafkaRESTBackend) Write(ctx context.Context, records []Record) error {
+	payload := kafkaProduceRequest{Records: make([]kafkaProduceRecord, len(records))}
+	for i, rec := range records {
+		payload.Records[i] = kafkaProduceRecord{Value: rec}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost,
+		strings.TrimSuffix(b.URL, "/")+"/topics/"+url.PathEscape(b.Topic),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaRESTContentType)
+	req.Header.Set("Accept", kafkaRESTAccept)
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("kafka produce to %s failed with %s: %s", b.Topic, resp.Status, respBody)
+	}
+
+	produced := kafkaProduceResponse{}
+	if err := json.Unmarshal(respBody, &produced); err != nil {
+		return fmt.Errorf("kafka produce to %s returned an invalid response: %w", b.Topic, err)
+	}
+	for _, offset := range produced.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka produce to %s failed: %s", b.Topic, offset.Error)
+		}
+	}
+	return nil
+}
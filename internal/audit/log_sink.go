@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// auditLogSink wraps another logr.LogSink, passing every call through to it -
+// except for the Info() records whose message starts with MessagePrefix,
+// which are written to a separate records sink instead, and shipped to a
+// Shipper (if any).
+type auditLogSink struct {
+	logr.LogSink
+
+	records logr.LogSink
+	shipper *Shipper
+	name    string
+	values  []any
+}
+
+var _ logr.CallDepthLogSink = &auditLogSink{}
+
+// NewAuditLogger returns a copy of the supplied logger that writes all of the
+// audit records through the records logger instead, and also ships them to
+// the Shipper (which may be nil). The records logger is not subject to the
+// level of the supplied logger, so that turning the log level down at runtime
+// never silences the audit trail. This lets the existing "AUDIT - ..." log
+// calls throughout the operator feed the Backend without any changes.
+func NewAuditLogger(log, records logr.Logger, shipper *Shipper) logr.Logger {
+	// Account for our extra stack frame, so that the wrapped sinks still
+	// report the right caller.
+	return logr.New(&auditLogSink{
+		LogSink: withCallDepth(log.GetSink(), 1),
+		records: withCallDepth(records.GetSink(), 1),
+		shipper: shipper,
+	})
+}
+
+// Enabled reports whether the wrapped sink is enabled at the supplied level.
+// Level 0 - the level that the audit records are written at - is always
+// enabled, and Info() filters out the other records itself.
+func (s *auditLogSink) Enabled(level int) bool {
+	return level == 0 || s.LogSink.Enabled(level)
+}
+
+// Info passes the record through, unless it is an audit record - which is
+// written to the records sink and shipped instead.
+func (s *auditLogSink) Info(level int, msg string, keysAndValues ...any) {
+	if !strings.HasPrefix(msg, MessagePrefix) {
+		if s.LogSink.Enabled(level) {
+			s.LogSink.Info(level, msg, keysAndValues...)
+		}
+		return
+	}
+
+	s.records.Info(level, msg, keysAndValues...)
+	if s.shipper == nil {
+		return
+	}
+	kvs := make([]any, 0, len(s.values)+len(keysAndValues))
+	kvs = append(kvs, s.values...)
+	kvs = append(kvs, keysAndValues...)
+	s.shipper.Ship(newRecord(s.name, msg, kvs))
+}
+
+// WithValues conforms to the logr.LogSink interface.
+func (s *auditLogSink) WithValues(keysAndValues ...any) logr.LogSink {
+	ret := *s
+	ret.LogSink = s.LogSink.WithValues(keysAndValues...)
+	ret.records = s.records.WithValues(keysAndValues...)
+	ret.values = append(append([]any{}, s.values...), keysAndValues...)
+	return &ret
+}
+
+// WithName conforms to the logr.LogSink interface.
+func (s *auditLogSink) WithName(name string) logr.LogSink {
+	ret := *s
+	ret.LogSink = s.LogSink.WithName(name)
+	ret.records = s.records.WithName(name)
+	ret.name = name
+	if s.name != "" {
+		ret.name = s.name + "." + name
+	}
+	return &ret
+}
+
+// WithCallDepth conforms to the logr.CallDepthLogSink interface.
+func (s *auditLogSink) WithCallDepth(depth int) logr.LogSink {
+	ret := *s
+	ret.LogSink = withCallDepth(s.LogSink, depth)
+	ret.records = withCallDepth(s.records, depth)
+	return &ret
+}
+
+// withCallDepth returns the supplied sink with its call depth raised, if it
+// supports that.
+func withCallDepth(sink logr.LogSink, depth int) logr.LogSink {
+	if cd, ok := sink.(logr.CallDepthLogSink); ok {
+		return cd.WithCallDepth(depth)
+	}
+	return sink
+}
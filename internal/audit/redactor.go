@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// RedactedValue is written in place of any data that has been redacted.
+const RedactedValue = "[REDACTED]"
+
+// Redactor scrubs sensitive data out of audit log records. Any string value
+// matching one of the configured patterns has the matching portion replaced
+// with RedactedValue, and the values of any of the designated fields are
+// replaced entirely.
+//
+// A nil or empty Redactor passes all values through untouched.
+type Redactor struct {
+	patterns []*regexp.Regexp
+	fields   map[string]bool
+}
+
+// NewRedactor compiles the supplied regular expressions and returns a
+// Redactor that applies them, along with the list of field names whose values
+// are always fully redacted.
+//
+// Returns:
+//   - A pointer to the Redactor
+//   - An "error" if any of the patterns fail to compile
+func NewRedactor(patterns []string, fields []string) (*Redactor, error) {
+	r := &Redactor{fields: map[string]bool{}}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid audit redaction pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	for _, f := range fields {
+		r.fields[f] = true
+	}
+	return r, nil
+}
+
+// Redact replaces any portion of the string that matches one of the
+// configured patterns with RedactedValue.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, RedactedValue)
+	}
+	return s
+}
+
+// KeysAndValues takes a list of logr-style alternating key/value pairs and
+// returns a copy of that list that is safe to log. Values of designated fields
+// are replaced with RedactedValue, other string values are passed through
+// Redact(), and all non-string values are passed through untouched.
+func (r *Redactor) KeysAndValues(keysAndValues ...any) []any {
+	ret := make([]any, len(keysAndValues))
+	copy(ret, keysAndValues)
+	if r == nil {
+		return ret
+	}
+	for i := 1; i < len(ret); i += 2 {
+		if key, ok := ret[i-1].(string); ok && r.fields[key] {
+			ret[i] = RedactedValue
+			continue
+		}
+		if val, ok := ret[i].(string); ok {
+			ret[i] = r.Redact(val)
+		}
+	}
+	return ret
+}
+
+// defaultRedactor is used by the package level Redact() and KeysAndValues()
+// functions. It is configured once at startup by the manager.
+var defaultRedactor *Redactor
+
+// SetDefaultRedactor configures the Redactor used by the package level
+// Redact() and KeysAndValues() functions.
+func SetDefaultRedactor(r *Redactor) {
+	defaultRedactor = r
+}
+
+// Redact applies the default Redactor to the supplied string.
+func Redact(s string) string {
+	return defaultRedactor.Redact(s)
+}
+
+// KeysAndValues applies the default Redactor to the supplied logr-style
+// key/value pairs.
+func KeysAndValues(keysAndValues ...any) []any {
+	return defaultRedactor.KeysAndValues(keysAndValues...)
+}
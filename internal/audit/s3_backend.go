@@ -0,0 +1,217 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service       = "s3"
+	s3Algorithm     = "AWS4-HMAC-SHA256"
+	amzDateFormat   = "20060102T150405Z"
+	amzDayFormat    = "20060102"
+	s3ObjectKeyTime = "2006/01/02/150405.000000000"
+)
+
+// S3Backend writes each batch of audit records as a newline delimited JSON
+// object to a bucket in S3, or in any S3-compatible object store (eg, MinIO).
+// Requests are signed with AWS Signature Version 4 and use path-style URLs, so
+// that no SDK is required.
+type S3Backend struct {
+	// Endpoint is the base URL of the object store. Defaults to the AWS S3
+	// endpoint for the Region.
+	Endpoint string
+
+	// Bucket that the objects are written to.
+	Bucket string
+
+	// Prefix is prepended to the key of every object that is written.
+	Prefix string
+
+	// Region used to sign the requests. Defaults to "us-east-1".
+	Region string
+
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials used
+	// to sign the requests.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Client is used to make the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+
+	id  string
+	now func() time.Time
+}
+
+var _ Backend = &S3Backend{}
+
+// Name conforms to the Backend interface.
+func (b *S3Backend) Name() string {
+	return fmt.Sprintf("s3://%s/%s", b.Bucket, b.Prefix)
+}
+
+// Write conforms to the Backend interface. Each batch is written as a single
+// new object, keyed by the time it was written.
+func (b *S3Backend) Write(ctx context.Context, records []Record) error {
+	body, err := encodeRecords(records)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if b.now != nil {
+		now = b.now().UTC()
+	}
+	if b.id == "" {
+		b.id = randomID()
+	}
+	key := path.Join(b.Prefix, fmt.Sprintf("%s-%s.jsonl", now.Format(s3ObjectKeyTime), b.id))
+
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPut, b.getEndpoint()+"/"+b.Bucket+"/"+s3URIEncode(key),
+		bytes.NewReader(body),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	b.sign(req, body, now)
+
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 put of %s failed with %s: %s", key, resp.Status, msg)
+	}
+	return nil
+}
+
+func (b *S3Backend) getRegion() string {
+	if b.Region == "" {
+		return "us-east-1"
+	}
+	return b.Region
+}
+
+func (b *S3Backend) getEndpoint() string {
+	if b.Endpoint == "" {
+		return fmt.Sprintf("https://s3.%s.amazonaws.com", b.getRegion())
+	}
+	return strings.TrimSuffix(b.Endpoint, "/")
+}
+
+// sign adds the AWS Signature Version 4 headers to the request.
+//
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (b *S3Backend) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if b.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", b.SessionToken)
+	}
+
+	// Every header that starts with X-Amz, plus the Host, is signed.
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(amzDayFormat), b.getRegion(), s3Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(amzDateFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+b.SecretAccessKey), now.Format(amzDayFormat))
+	key = hmacSHA256(key, b.getRegion())
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, b.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+// s3URIEncode encodes everything except the unreserved characters, as
+// required by Signature Version 4. Slashes are left alone, since they separate
+// the "directories" of an object key.
+func s3URIEncode(s string) string {
+	var buf strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		case c == '/':
+			buf.WriteByte(c)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", c)
+		}
+	}
+	return buf.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// randomID returns a short random string used to keep the object keys written
+// by different replicas of the operator from colliding.
+func randomID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+const (
+	// DefaultBatchSize is the number of records written to the Backend at once.
+	DefaultBatchSize = 100
+
+	// DefaultFlushInterval is the longest a record waits before being written.
+	DefaultFlushInterval = 10 * time.Second
+
+	// DefaultQueueSize is the number of records buffered in memory before new
+	// records are dropped.
+	DefaultQueueSize = 10000
+
+	// DefaultShutdownTimeout is how long the final flush on shutdown may take.
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// ShipperOptions configures the batching behavior of a Shipper. Zero values
+// are replaced with the package defaults.
+type ShipperOptions struct {
+	BatchSize       int
+	FlushInterval   time.Duration
+	QueueSize       int
+	ShutdownTimeout time.Duration
+}
+
+// Shipper batches audit records in memory and asynchronously writes them to a
+// Backend. It implements the controller-runtime manager.Runnable interface, so
+// that it is started and stopped alongside the rest of the operator, and gets
+// a final flush of any buffered records on shutdown.
+//
+// Shipping never blocks the caller. If the Backend falls behind (or is down)
+// long enough for the in-memory queue to fill up, new records are dropped and
+// counted rather than slowing down the reconcilers and webhooks. They are
+// always still written to the regular log.
+type Shipper struct {
+	backend Backend
+	opts    ShipperOptions
+	queue   chan Record
+	dropped atomic.Int64
+	log     logr.Logger
+}
+
+// NewShipper returns a Shipper that writes to the supplied Backend.
+func NewShipper(backend Backend, opts ShipperOptions, log logr.Logger) *Shipper {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultFlushInterval
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	return &Shipper{
+		backend: backend,
+		opts:    opts,
+		queue:   make(chan Record, opts.QueueSize),
+		log:     log.WithName("audit-shipper").WithValues("backend", backend.Name()),
+	}
+}
+
+// Ship queues the record to be written to the Backend. It returns false if the
+// queue is full and the record was dropped.
+func (s *Shipper) Ship(rec Record) bool {
+	select {
+	case s.queue <- rec:
+		return true
+	default:
+		s.dropped.Add(1)
+		return false
+	}
+}
+
+// Dropped returns the number of records that have been dropped, either
+// because the queue was full or because the Backend could not keep up.
+func (s *Shipper) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// NeedLeaderElection conforms to the manager.LeaderElectionRunnable interface.
+// Every replica of the operator writes its own audit records, so every
+// replica must ship them.
+func (s *Shipper) NeedLeaderElection() bool {
+	return false
+}
+
+// Start conforms to the manager.Runnable interface. It writes batches to the
+// Backend until the context is cancelled, and then drains the queue and
+// makes a final attempt to write any remaining records.
+func (s *Shipper) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var pending []Record
+	for {
+		select {
+		case rec := <-s.queue:
+			pending = append(pending, rec)
+			if len(pending) >= s.opts.BatchSize {
+				pending = s.flush(ctx, pending)
+			}
+		case <-ticker.C:
+			pending = s.flush(ctx, pending)
+		case <-ctx.Done():
+			return s.shutdown(pending)
+		}
+	}
+}
+
+// flush writes the pending records to the Backend in batches. On failure the
+// unwritten records are returned so that they can be retried on the next
+// flush. To bound memory use, the oldest records are dropped if too many
+// build up.
+func (s *Shipper) flush(ctx context.Context, pending []Record) []Record {
+	for len(pending) > 0 {
+		n := len(pending)
+		if n > s.opts.BatchSize {
+			n = s.opts.BatchSize
+		}
+		if err := s.backend.Write(ctx, pending[:n]); err != nil {
+			s.log.Error(err, "Failed to write audit records, will retry", "records", len(pending))
+			if over := len(pending) - s.opts.QueueSize; over > 0 {
+				s.dropped.Add(int64(over))
+				s.log.Info("Dropping the oldest audit records", "dropped", over)
+				pending = pending[over:]
+			}
+			return pending
+		}
+		pending = pending[n:]
+	}
+	return nil
+}
+
+// shutdown drains anything still queued and writes it out with a fresh
+// context, since the one passed to Start() has already been cancelled.
+func (s *Shipper) shutdown(pending []Record) error {
+	for drained := false; !drained; {
+		select {
+		case rec := <-s.queue:
+			pending = append(pending, rec)
+		default:
+			drained = true
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.opts.ShutdownTimeout)
+	defer cancel()
+	for len(pending) > 0 {
+		n := len(pending)
+		if n > s.opts.BatchSize {
+			n = s.opts.BatchSize
+		}
+		if err := s.backend.Write(ctx, pending[:n]); err != nil {
+			s.dropped.Add(int64(len(pending)))
+			s.log.Error(err, "Failed to write audit records on shutdown", "dropped", len(pending))
+			return err
+		}
+		pending = pending[n:]
+	}
+	s.log.Info("Flushed audit records on shutdown", "dropped", s.Dropped())
+	return nil
+}
@@ -0,0 +1,243 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeBackend records every batch written to it, and can be told to fail.
+type fakeBackend struct {
+	mu      sync.Mutex
+	batches [][]Record
+	err     error
+}
+
+func (b *fakeBackend) Name() string { return "fake" }
+
+func (b *fakeBackend) Write(_ context.Context, records []Record) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		return b.err
+	}
+	b.batches = append(b.batches, append([]Record{}, records...))
+	return nil
+}
+
+func (b *fakeBackend) setErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.err = err
+}
+
+func (b *fakeBackend) messages() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var ret []string
+	for _, batch := range b.batches {
+		for _, rec := range batch {
+			ret = append(ret, rec.Message)
+		}
+	}
+	return ret
+}
+
+func (b *fakeBackend) batchSizes() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var ret []int
+	for _, batch := range b.batches {
+		ret = append(ret, len(batch))
+	}
+	return ret
+}
+
+var _ = Describe("Shipper", func() {
+	var (
+		backend *fakeBackend
+		shipper *Shipper
+		cancel  context.CancelFunc
+		done    chan error
+	)
+
+	start := func(opts ShipperOptions) {
+		shipper = NewShipper(backend, opts, logr.Discard())
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		done = make(chan error, 1)
+		go func() { done <- shipper.Start(ctx) }()
+	}
+
+	stop := func() error {
+		cancel()
+		var err error
+		Eventually(done).Should(Receive(&err))
+		return err
+	}
+
+	BeforeEach(func() {
+		backend = &fakeBackend{}
+	})
+
+	It("Should write full batches without waiting for the flush interval", func() {
+		start(ShipperOptions{BatchSize: 2, FlushInterval: time.Hour})
+		DeferCleanup(stop)
+
+		for _, msg := range []string{"a", "b", "c", "d"} {
+			Expect(shipper.Ship(Record{Message: msg})).To(BeTrue())
+		}
+
+		// VERIFY: Two full batches were written
+		Eventually(backend.batchSizes).Should(Equal([]int{2, 2}))
+		Expect(backend.messages()).To(Equal([]string{"a", "b", "c", "d"}))
+	})
+
+	It("Should write partial batches on the flush interval", func() {
+		start(ShipperOptions{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+		DeferCleanup(stop)
+
+		shipper.Ship(Record{Message: "a"})
+
+		Eventually(backend.messages).Should(Equal([]string{"a"}))
+	})
+
+	It("Should flush everything that is buffered on shutdown", func() {
+		start(ShipperOptions{BatchSize: 2, FlushInterval: time.Hour})
+
+		// A failing backend leaves records pending in memory...
+		backend.setErr(errors.New("unavailable"))
+		for _, msg := range []string{"a", "b", "c"} {
+			shipper.Ship(Record{Message: msg})
+		}
+		Eventually(func() int { return len(shipper.queue) }).Should(BeZero())
+
+		// ... which are all written once it recovers and we shut down
+		backend.setErr(nil)
+		Expect(stop()).To(Succeed())
+		Expect(backend.messages()).To(Equal([]string{"a", "b", "c"}))
+		Expect(shipper.Dropped()).To(BeZero())
+	})
+
+	It("Should return an error if the final flush fails", func() {
+		start(ShipperOptions{BatchSize: 10, FlushInterval: time.Hour})
+		backend.setErr(errors.New("unavailable"))
+		shipper.Ship(Record{Message: "a"})
+
+		Expect(stop()).To(MatchError("unavailable"))
+		Expect(shipper.Dropped()).To(Equal(int64(1)))
+	})
+
+	It("Should drop, rather than block, when the queue is full", func() {
+		// Not started, so nothing drains the queue
+		shipper = NewShipper(backend, ShipperOptions{QueueSize: 2}, logr.Discard())
+
+		Expect(shipper.Ship(Record{Message: "a"})).To(BeTrue())
+		Expect(shipper.Ship(Record{Message: "b"})).To(BeTrue())
+		Expect(shipper.Ship(Record{Message: "c"})).To(BeFalse())
+		Expect(shipper.Dropped()).To(Equal(int64(1)))
+	})
+
+	It("Should drop the oldest pending records when the backend falls too far behind", func() {
+		start(ShipperOptions{BatchSize: 1, QueueSize: 2, FlushInterval: time.Hour})
+		backend.setErr(errors.New("unavailable"))
+		for _, msg := range []string{"a", "b", "c", "d"} {
+			shipper.Ship(Record{Message: msg})
+			Eventually(func() int { return len(shipper.queue) }).Should(BeZero())
+		}
+		Eventually(shipper.Dropped).Should(Equal(int64(2)))
+
+		backend.setErr(nil)
+		Expect(stop()).To(Succeed())
+		Expect(backend.messages()).To(Equal([]string{"c", "d"}))
+		Expect(shipper.Dropped()).To(Equal(int64(2)))
+	})
+
+	Context("NewAuditLogger()", func() {
+		var logged, recorded []string
+
+		BeforeEach(func() {
+			logged, recorded = nil, nil
+		})
+
+		// newLogger returns an audit logger that writes to logged, at the
+		// supplied verbosity, and its audit records to recorded.
+		newLogger := func(verbosity int, shipper *Shipper) logr.Logger {
+			inner := funcr.New(func(prefix, args string) {
+				logged = append(logged, args)
+			}, funcr.Options{Verbosity: verbosity})
+			records := funcr.New(func(prefix, args string) {
+				recorded = append(recorded, args)
+			}, funcr.Options{})
+			return NewAuditLogger(inner, records, shipper)
+		}
+
+		It("Should ship only the audit records, with their names and values", func() {
+			start(ShipperOptions{BatchSize: 1, FlushInterval: time.Hour})
+			log := newLogger(0, shipper).WithName("webhook").WithValues("namespace", "ns")
+			log.Info("Not an audit record", "user", "bob")
+			log.Info(MessagePrefix+"Access request approved", "user", "alice", "err", errors.New("boom"))
+			Expect(stop()).To(Succeed())
+
+			// VERIFY: The audit record went to the records logger instead
+			Expect(logged).To(HaveLen(1))
+			Expect(recorded).To(HaveLen(1))
+			Expect(recorded[0]).To(ContainSubstring(`"namespace"="ns"`))
+
+			// VERIFY: Only the audit record was shipped
+			Expect(backend.messages()).To(Equal([]string{MessagePrefix + "Access request approved"}))
+			rec := backend.batches[0][0]
+			Expect(rec.Logger).To(Equal("webhook"))
+			Expect(rec.Fields).To(Equal(map[string]any{
+				"namespace": "ns",
+				"user":      "alice",
+				"err":       "boom",
+			}))
+		})
+
+		It("Should write and ship the audit records whatever the level of the wrapped logger", func() {
+			disabled := funcr.New(func(prefix, args string) {
+				logged = append(logged, args)
+			}, funcr.Options{})
+			disabled = logr.New(&disabledSink{LogSink: disabled.GetSink()})
+			records := funcr.New(func(prefix, args string) {
+				recorded = append(recorded, args)
+			}, funcr.Options{})
+
+			start(ShipperOptions{BatchSize: 1, FlushInterval: time.Hour})
+			log := NewAuditLogger(disabled, records, shipper)
+			log.Info("Not an audit record")
+			log.Info(MessagePrefix + "Access request approved")
+			Expect(stop()).To(Succeed())
+
+			Expect(logged).To(BeEmpty())
+			Expect(recorded).To(HaveLen(1))
+			Expect(backend.messages()).To(Equal([]string{MessagePrefix + "Access request approved"}))
+		})
+
+		It("Should pass the debug records through at the level of the wrapped logger", func() {
+			log := newLogger(1, nil)
+			log.V(1).Info("Debug record")
+			log.V(2).Info("Trace record")
+			log.V(2).Info(MessagePrefix + "Access request approved")
+
+			Expect(log.V(2).Enabled()).To(BeFalse())
+			Expect(logged).To(HaveLen(1))
+			Expect(recorded).To(BeEmpty())
+		})
+	})
+})
+
+// disabledSink is a logr.LogSink that is disabled at every level, like one
+// whose level has been turned all the way down.
+type disabledSink struct {
+	logr.LogSink
+}
+
+func (s *disabledSink) Enabled(int) bool { return false }
@@ -0,0 +1,19 @@
+package builders
+
+import "context"
+
+// dryRunKey is the context key set by WithDryRun().
+type dryRunKey struct{}
+
+// WithDryRun returns a copy of the supplied context under which the builders
+// predict their decisions without recording them anywhere - for example, the
+// Pod that would be picked for a request is not marked as used.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun returns true if the supplied context was returned by WithDryRun().
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
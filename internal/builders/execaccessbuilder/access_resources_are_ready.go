@@ -3,18 +3,56 @@ package execaccessbuilder
 import (
 	"context"
 
-	"github.com/diranged/oz/internal/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
 )
 
 // AccessResourcesAreReady implements the IBuilder interface
 func (b *ExecAccessBuilder) AccessResourcesAreReady(
-	_ context.Context,
-	_ client.Client,
-	_ v1alpha1.IRequestResource,
+	ctx context.Context,
+	client client.Client,
+	req v1alpha1.IRequestResource,
 	_ v1alpha1.ITemplateResource,
 ) (bool, error) {
 	// There is no waiting for resources to come up here. Everything we create
 	// is automatically available.
+	if execReq, ok := req.(*v1alpha1.ExecAccessRequest); ok {
+		recordTargetPodDetails(ctx, client, execReq)
+	}
 	return true, nil
 }
+
+// recordTargetPodDetails copies the Node, IP and phase of the target Pod into
+// the (local) request status, so that users do not have to describe the Pod to
+// find them. It is saved along with the conditions, and refreshed on every
+// reconcile.
+//
+// The details are only informational - if the Pod cannot be fetched, the last
+// known details are kept, and replaced Pods are dealt with by the reconciler.
+func recordTargetPodDetails(
+	ctx context.Context,
+	cl client.Client,
+	req *v1alpha1.ExecAccessRequest,
+) {
+	if req.GetPodName() == "" {
+		return
+	}
+	pod := &corev1.Pod{}
+	if err := cl.Get(ctx, types.NamespacedName{
+		Name:      req.GetPodName(),
+		Namespace: req.GetNamespace(),
+	}, pod); err != nil {
+		if !apierrors.IsNotFound(err) {
+			logf.FromContext(ctx).Error(err, "Unable to get the target Pod details")
+		}
+		return
+	}
+	req.Status.TargetPodNode = pod.Spec.NodeName
+	req.Status.TargetPodIP = pod.Status.PodIP
+	req.Status.TargetPodPhase = pod.Status.Phase
+}
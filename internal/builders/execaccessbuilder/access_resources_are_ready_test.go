@@ -0,0 +1,84 @@
+package execaccessbuilder
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("ExecAccessBuilder", func() {
+	Context("AccessResourcesAreReady()", func() {
+		var (
+			ctx     = context.Background()
+			cl      client.Client
+			pod     *corev1.Pod
+			request *v1alpha1.ExecAccessRequest
+			builder = ExecAccessBuilder{}
+		)
+
+		BeforeEach(func() {
+			pod = &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "target", Namespace: "ns"},
+				Spec:       corev1.PodSpec{NodeName: "node-a"},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.1"},
+			}
+			cl = fake.NewClientBuilder().WithObjects(pod).Build()
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "request", Namespace: "ns"},
+				Status:     v1alpha1.ExecAccessRequestStatus{PodName: "target"},
+			}
+		})
+
+		It("Should record the details of the target Pod", func() {
+			ready, err := builder.AccessResourcesAreReady(ctx, cl, request, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeTrue())
+			Expect(request.Status.TargetPodNode).To(Equal("node-a"))
+			Expect(request.Status.TargetPodIP).To(Equal("10.0.0.1"))
+			Expect(request.Status.TargetPodPhase).To(Equal(corev1.PodRunning))
+		})
+
+		It("Should refresh the details as the target Pod changes", func() {
+			_, err := builder.AccessResourcesAreReady(ctx, cl, request, nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			pod.Status.Phase = corev1.PodFailed
+			pod.Status.PodIP = ""
+			Expect(cl.Status().Update(ctx, pod)).To(Succeed())
+
+			_, err = builder.AccessResourcesAreReady(ctx, cl, request, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.Status.TargetPodNode).To(Equal("node-a"))
+			Expect(request.Status.TargetPodIP).To(BeEmpty())
+			Expect(request.Status.TargetPodPhase).To(Equal(corev1.PodFailed))
+		})
+
+		It("Should keep the last known details once the target Pod is gone", func() {
+			_, err := builder.AccessResourcesAreReady(ctx, cl, request, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cl.Delete(ctx, pod)).To(Succeed())
+
+			ready, err := builder.AccessResourcesAreReady(ctx, cl, request, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeTrue())
+			Expect(request.Status.TargetPodNode).To(Equal("node-a"))
+			Expect(request.Status.TargetPodPhase).To(Equal(corev1.PodRunning))
+		})
+
+		It("Should skip requests that have not been assigned a Pod", func() {
+			request.Status.PodName = ""
+			ready, err := builder.AccessResourcesAreReady(ctx, cl, request, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ready).To(BeTrue())
+			Expect(request.Status.TargetPodNode).To(BeEmpty())
+		})
+	})
+})
@@ -4,16 +4,25 @@ import (
 	"context"
 	"fmt"
 
-	corev1 "k8s.io/api/core/v1"
-	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
 	"github.com/diranged/oz/internal/builders/execaccessbuilder/internal"
 	"github.com/diranged/oz/internal/builders/utils"
 )
 
-// CreateAccessResources implements the IBuilder interface
+// CreateAccessResources implements the IBuilder interface.
+//
+// A request whose template has no candidate Pods is granted access through
+// the fallbackTemplate of the template, if it names one.
+//
+// A target Pod that cannot be found is returned as a
+// builders.ErrTargetPodNotFound, no candidate Pod within the pod selection
+// timeout as a builders.ErrPodSelectionPending, a target Pod without room for
+// another grant as a builders.ErrMaxGrantsPerPodReached, and a conflict
+// writing back the status of the request as a builders.ErrStatusConflict.
 func (b *ExecAccessBuilder) CreateAccessResources(
 	ctx context.Context,
 	client client.Client,
@@ -25,59 +34,65 @@ func (b *ExecAccessBuilder) CreateAccessResources(
 	// Cast the Template into an ExecAccessTemplate.
 	execTmpl := tmpl.(*v1alpha1.ExecAccessTemplate)
 
-	// Get the target Pod Name that the user is going to have access to
-	targetPodName, err := internal.GetPodName(ctx, client, execReq, execTmpl)
+	// Get the target Pod Name that the user is going to have access to, and
+	// the template (possibly the fallbackTemplate) that it was picked from.
+	// The rest of the access resources are built from that template.
+	targetPodName, grantTmpl, err := b.selectPod(ctx, client, execReq, execTmpl)
 	if err != nil {
+		return statusString, b.checkPodSelectionTimeout(execReq, execTmpl, err)
+	}
+	tmpl = grantTmpl
+
+	// Make sure the target Pod has room for another grant, or queue up. The
+	// grant reserved for the request is handed back if it is not made.
+	if err := checkMaxGrantsPerPod(ctx, client, execReq, grantTmpl, targetPodName); err != nil {
 		return statusString, err
 	}
+	defer func() {
+		if err != nil {
+			internal.ReleaseGrant(execReq, targetPodName)
+		}
+	}()
 
 	// Define the permissions the access request will grant.
-	//
-	// TODO: Implement the ability to tune this in the ExecAccessTemplate settings.
-	rules := []rbacv1.PolicyRule{
-		{
-			APIGroups:     []string{corev1.GroupName},
-			Resources:     []string{"pods"},
-			ResourceNames: []string{targetPodName},
-			Verbs:         []string{"get", "list", "watch"},
-		},
-		{
-			APIGroups:     []string{corev1.GroupName},
-			Resources:     []string{"pods/exec"},
-			ResourceNames: []string{targetPodName},
-			Verbs:         []string{"create", "update", "delete", "get", "list"},
-		},
-	}
+	rules := utils.RequesterPodAccessRules(execReq, tmpl, targetPodName)
 
-	// Get the Role, or error out
-	role, err := utils.CreateRole(ctx, client, execReq, rules)
+	// Get the Role (or the template's ClusterRole), or error out
+	roleRef, err := utils.CreateAccessRole(ctx, client, execReq, tmpl, rules)
 	if err != nil {
 		return statusString, err
 	}
 
 	// Get the Binding, or error out
-	rb, err := utils.CreateRoleBinding(ctx, client, execReq, tmpl, role)
+	rb, err := utils.CreateRoleBinding(ctx, client, execReq, tmpl, roleRef)
 	if err != nil {
 		return statusString, err
 	}
 
-	// Generate the user-friendly information for how to access the pod
-	//
-	// TODO: Templatize this into the ExecAccessTemplate in some way
-	//
-	accessString := fmt.Sprintf(
-		"kubectl exec -ti -n %s %s -- /bin/sh",
-		req.GetNamespace(),
-		targetPodName,
-	)
+	// Generate the user-friendly information for how to access the pod, in
+	// the form that suits the platform of the requester.
+	accessString, err := utils.CreateAccessCommand(req, tmpl, targetPodName)
+	if err != nil {
+		return statusString, err
+	}
 	execReq.Status.SetAccessMessage(accessString)
 
 	// We've been mutating the execReq Status throughout this build. Need to
 	// push the update back to the cluster here.
 	if err := client.Status().Update(ctx, execReq); err != nil {
+		if apierrors.IsConflict(err) {
+			return "", builders.WithKind(builders.ErrStatusConflict, err)
+		}
 		return "", err
 	}
 
-	statusString = fmt.Sprintf("Success. Role %s, RoleBinding %s created", role.Name, rb.Name)
+	statusString = fmt.Sprintf("Success. %s %s, RoleBinding %s created",
+		roleRef.Kind,
+		roleRef.Name,
+		rb.Name,
+	)
+	if name := execReq.Status.FallbackTemplate; name != "" {
+		statusString += fmt.Sprintf(" through fallback template %s", name)
+	}
 	return statusString, nil
 }
@@ -2,7 +2,10 @@ package execaccessbuilder
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -11,10 +14,12 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
 	"github.com/diranged/oz/internal/builders/execaccessbuilder/internal"
 	bldutil "github.com/diranged/oz/internal/builders/utils"
 	"github.com/diranged/oz/internal/testing/utils"
@@ -176,6 +181,7 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(MatchRegexp("not found"))
+			Expect(errors.Is(err, builders.ErrTargetPodNotFound)).To(BeTrue())
 		})
 
 		It("CreateAccessResources() should succeed with random pod selection", func() {
@@ -216,5 +222,1427 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 			Expect(foundRoleBinding.RoleRef.Name).To(Equal(foundRole.GetName()))
 			Expect(foundRoleBinding.Subjects[0].Name).To(Equal("foo"))
 		})
+
+		It("CreateAccessResources() should bind the transferred user and keep the expiry", func() {
+			By("Stamping the expiry of the access that was already granted")
+			expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+			err := bldutil.StampAccessExpiry(ctx, k8sClient, request, expiresAt)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Transferring the request to another user")
+			request.Spec.TransferTo = "bob"
+			err = k8sClient.Update(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: RoleBinding subject is now the single transferred user
+			foundRoleBinding := &rbacv1.RoleBinding{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      bldutil.GenerateResourceName(request),
+				Namespace: ns.GetName(),
+			}, foundRoleBinding)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundRoleBinding.Subjects).To(HaveLen(1))
+			Expect(foundRoleBinding.Subjects[0].Kind).To(Equal(rbacv1.UserKind))
+			Expect(foundRoleBinding.Subjects[0].Name).To(Equal("bob"))
+
+			// VERIFY: The expiry stamped before the transfer is kept
+			Expect(foundRoleBinding.GetAnnotations()).To(HaveKeyWithValue(
+				v1alpha1.ExpiresAtAnnotation, expiresAt.Format(time.RFC3339),
+			))
+		})
+
+		It("CreateAccessResources() should bind the user that the request was made on behalf of", func() {
+			request.Spec.TransferTo = ""
+			request.Spec.RequestFor = "carol"
+			DeferCleanup(func() { request.Spec.RequestFor = "" })
+
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: RoleBinding subject is the beneficiary, not the template groups
+			foundRoleBinding := &rbacv1.RoleBinding{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      bldutil.GenerateResourceName(request),
+				Namespace: ns.GetName(),
+			}, foundRoleBinding)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundRoleBinding.Subjects).To(HaveLen(1))
+			Expect(foundRoleBinding.Subjects[0].Kind).To(Equal(rbacv1.UserKind))
+			Expect(foundRoleBinding.Subjects[0].Name).To(Equal("carol"))
+		})
+
+		It("CreateAccessResources() should select a pod on the requested node", func() {
+			By("Creating a second Pod scheduled onto a specific node")
+			nodePod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+					Labels:    deployment.Spec.Selector.MatchLabels,
+				},
+				Spec: *deployment.Spec.Template.Spec.DeepCopy(),
+			}
+			nodePod.Spec.NodeName = "node-a"
+			err := k8sClient.Create(ctx, nodePod)
+			Expect(err).ToNot(HaveOccurred())
+
+			request.Status.PodName = ""
+			request.Spec.TargetPod = ""
+			request.Spec.TargetNode = "node-a"
+			_, err = builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The pod on node-a was picked, not the unscheduled pod
+			Expect(request.GetPodName()).To(Equal(nodePod.GetName()))
+			Expect(request.GetPodUID()).To(Equal(nodePod.GetUID()))
+		})
+
+		It("CreateAccessResources() should fail if no pods run on the requested node", func() {
+			request.Status.PodName = ""
+			request.Spec.TargetPod = ""
+			request.Spec.TargetNode = "node-b"
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("no pods found maching selector on node node-b"))
+		})
+
+		It("CreateAccessResources() should fail if the target pod is not on the requested node", func() {
+			request.Status.PodName = ""
+			request.Spec.TargetPod = pod.GetName()
+			request.Spec.TargetNode = "node-a"
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("not found on node node-a"))
+		})
+
+		It("CreateAccessResources() should bind the template ClusterRole without creating a Role", func() {
+			By("Pointing a copy of the template at a ClusterRole")
+			crTemplate := template.DeepCopy()
+			crTemplate.Spec.AccessConfig.ClusterRoleRef = "curated-exec"
+
+			By("Creating a new request against it")
+			crRequest := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "clusterroleref-test",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+					TargetPod:    pod.GetName(),
+				},
+			}
+			err := k8sClient.Create(ctx, crRequest)
+			Expect(err).ToNot(HaveOccurred())
+
+			ret, err := builder.CreateAccessResources(ctx, k8sClient, crRequest, crTemplate)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: Proper status string returned
+			Expect(ret).To(MatchRegexp(fmt.Sprintf(
+				"Success. ClusterRole curated-exec, RoleBinding %s.* created",
+				crRequest.GetName(),
+			)))
+
+			// VERIFY: No Role was created
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      bldutil.GenerateResourceName(crRequest),
+				Namespace: ns.GetName(),
+			}, &rbacv1.Role{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("not found"))
+
+			// VERIFY: RoleBinding points at the ClusterRole, for the template groups
+			foundRoleBinding := &rbacv1.RoleBinding{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      bldutil.GenerateResourceName(crRequest),
+				Namespace: ns.GetName(),
+			}, foundRoleBinding)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundRoleBinding.RoleRef.Kind).To(Equal("ClusterRole"))
+			Expect(foundRoleBinding.RoleRef.Name).To(Equal("curated-exec"))
+			Expect(foundRoleBinding.Subjects[0].Name).To(Equal("foo"))
+		})
+
+		It("CreateAccessResources() should return ErrStatusConflict if the request was modified", func() {
+			By("Creating a new request")
+			staleRequest := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "statusconflict-test",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+					TargetPod:    pod.GetName(),
+				},
+			}
+			err := k8sClient.Create(ctx, staleRequest)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Modifying the request behind the back of the builder")
+			latest := staleRequest.DeepCopy()
+			latest.SetLabels(map[string]string{"modified": "true"})
+			err = k8sClient.Update(ctx, latest)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = builder.CreateAccessResources(ctx, k8sClient, staleRequest, template)
+
+			// VERIFY: The conflict is returned as a retryable ErrStatusConflict
+			Expect(err).To(HaveOccurred())
+			Expect(errors.Is(err, builders.ErrStatusConflict)).To(BeTrue())
+			Expect(errors.Is(err, builders.ErrTargetPodNotFound)).To(BeFalse())
+		})
+	})
+
+	Context("CreateAccessResources() with OnlyCurrentGeneration", func() {
+		var (
+			ctx        = context.Background()
+			ns         *corev1.Namespace
+			deployment *appsv1.Deployment
+			oldPod     *corev1.Pod
+			newPod     *corev1.Pod
+			template   *v1alpha1.ExecAccessTemplate
+			builder    = ExecAccessBuilder{}
+		)
+
+		// createRevision creates a ReplicaSet owned by the Deployment for the
+		// given revision, and a single Pod belonging to that ReplicaSet.
+		createRevision := func(revision string, hash string) *corev1.Pod {
+			labels := map[string]string{
+				"testLabel":                            "testValue",
+				appsv1.DefaultDeploymentUniqueLabelKey: hash,
+			}
+			isController := true
+			rs := &appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        fmt.Sprintf("%s-%s", deployment.GetName(), hash),
+					Namespace:   ns.GetName(),
+					Labels:      labels,
+					Annotations: map[string]string{"deployment.kubernetes.io/revision": revision},
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deployment.GetName(),
+						UID:        deployment.GetUID(),
+						Controller: &isController,
+					}},
+				},
+				Spec: appsv1.ReplicaSetSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec:       deployment.Spec.Template.Spec,
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, rs)).To(Succeed())
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-%s", rs.GetName(), utils.RandomString(4)),
+					Namespace: ns.GetName(),
+					Labels:    labels,
+				},
+				Spec: deployment.Spec.Template.Spec,
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			return pod
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+			By("Creating a Deployment that is on its second revision")
+			deployment = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "canary",
+					Namespace:   ns.Name,
+					Annotations: map[string]string{"deployment.kubernetes.io/revision": "2"},
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"testLabel": "testValue"},
+					},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"testLabel": "testValue"},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Name: "test", Image: "nginx:latest"},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			By("Creating Pods for both the old and the current revision")
+			oldPod = createRevision("1", "old111")
+			newPod = createRevision("2", "new222")
+
+			By("Should have an ExecAccessTemplate restricted to the current generation")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deployment.GetName(),
+					},
+					OnlyCurrentGeneration: true,
+				},
+			}
+			Expect(k8sClient.Create(ctx, template)).To(Succeed())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		newRequest := func(targetPod string) *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+					TargetPod:    targetPod,
+				},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+			return request
+		}
+
+		It("Random selection should only pick Pods from the current revision", func() {
+			for i := 0; i < 5; i++ {
+				request := newRequest("")
+				_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(request.GetPodName()).To(Equal(newPod.GetName()))
+			}
+		})
+
+		It("Should reject a Pod from an older revision", func() {
+			request := newRequest(oldPod.GetName())
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(MatchRegexp("not found"))
+		})
+
+		It("Should allow a Pod from the current revision", func() {
+			request := newRequest(newPod.GetName())
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.GetPodName()).To(Equal(newPod.GetName()))
+		})
+
+		It("Should fail clearly if the current revision cannot be found", func() {
+			By("Rolling the Deployment forward to a revision with no ReplicaSet")
+			deployment.Annotations["deployment.kubernetes.io/revision"] = "3"
+			Expect(k8sClient.Update(ctx, deployment)).To(Succeed())
+
+			request := newRequest("")
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"could not find the replicaset for revision 3 of deployment canary",
+			))
+		})
+	})
+
+	Context("CreateAccessResources() with ResolvePodsByOwnerReference", func() {
+		var (
+			ctx        = context.Background()
+			ns         *corev1.Namespace
+			deployment *appsv1.Deployment
+			replicaSet *appsv1.ReplicaSet
+			ownedPod   *corev1.Pod
+			orphanPod  *corev1.Pod
+			template   *v1alpha1.ExecAccessTemplate
+			builder    = ExecAccessBuilder{}
+			labels     = map[string]string{"testLabel": "testValue"}
+		)
+
+		// controllerRef returns an ownerReference marking the supplied object
+		// as the managing controller.
+		controllerRef := func(kind string, obj metav1.Object) []metav1.OwnerReference {
+			isController := true
+			return []metav1.OwnerReference{{
+				APIVersion: "apps/v1",
+				Kind:       kind,
+				Name:       obj.GetName(),
+				UID:        obj.GetUID(),
+				Controller: &isController,
+			}}
+		}
+
+		// createPod creates a Pod matching the Deployment selector, with the
+		// supplied ownerReferences.
+		createPod := func(name string, owners []metav1.OwnerReference) *corev1.Pod {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            name,
+					Namespace:       ns.GetName(),
+					Labels:          labels,
+					OwnerReferences: owners,
+				},
+				Spec: deployment.Spec.Template.Spec,
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			return pod
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+			By("Creating a Deployment")
+			deployment = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "owned",
+					Namespace: ns.Name,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{Name: "test", Image: "nginx:latest"},
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			By("Creating a ReplicaSet owned by the Deployment")
+			replicaSet = &appsv1.ReplicaSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "owned-abc123",
+					Namespace:       ns.GetName(),
+					Labels:          labels,
+					OwnerReferences: controllerRef("Deployment", deployment),
+				},
+				Spec: appsv1.ReplicaSetSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: deployment.Spec.Template,
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicaSet)).To(Succeed())
+
+			By("Creating a Pod owned by the ReplicaSet, and an orphan Pod with the same labels")
+			ownedPod = createPod("owned-abc123-xyz", controllerRef("ReplicaSet", replicaSet))
+			orphanPod = createPod("orphan", nil)
+
+			By("Should have an ExecAccessTemplate that resolves Pods by ownerReference")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deployment.GetName(),
+					},
+					ResolvePodsByOwnerReference: true,
+				},
+			}
+			Expect(k8sClient.Create(ctx, template)).To(Succeed())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		newRequest := func(targetPod string) *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+					TargetPod:    targetPod,
+				},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+			return request
+		}
+
+		It("Random selection should only pick Pods owned through the full chain", func() {
+			for i := 0; i < 5; i++ {
+				request := newRequest("")
+				_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(request.GetPodName()).To(Equal(ownedPod.GetName()))
+			}
+		})
+
+		It("Should reject a Pod that only matches the label selector", func() {
+			request := newRequest(orphanPod.GetName())
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(fmt.Sprintf("pod named %s not found", orphanPod.GetName())))
+		})
+
+		It("Should reject a Pod whose ReplicaSet no longer exists", func() {
+			By("Creating a Pod owned by a ReplicaSet that was never created")
+			stale := createPod("owned-gone-xyz", controllerRef("ReplicaSet", &metav1.ObjectMeta{
+				Name: "owned-gone",
+				UID:  "00000000-0000-0000-0000-000000000000",
+			}))
+
+			request := newRequest(stale.GetName())
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(fmt.Sprintf("pod named %s not found", stale.GetName())))
+		})
+
+		It("Should fail clearly if the Deployment owns no ReplicaSets", func() {
+			By("Deleting the intermediate ReplicaSet")
+			Expect(k8sClient.Delete(ctx, replicaSet)).To(Succeed())
+
+			request := newRequest("")
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"could not find any replicasets owned by deployment owned",
+			))
+		})
+	})
+
+	Context("CreateAccessResources() with the leastRecentlyUsed podSelectionStrategy", func() {
+		var (
+			ctx        = context.Background()
+			ns         *corev1.Namespace
+			deployment *appsv1.Deployment
+			template   *v1alpha1.ExecAccessTemplate
+			builder    = ExecAccessBuilder{}
+		)
+
+		createPod := func(name string) *corev1.Pod {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns.GetName(),
+					Labels:    deployment.Spec.Selector.MatchLabels,
+				},
+				Spec: deployment.Spec.Template.Spec,
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			return pod
+		}
+
+		// assignPods creates count Access Requests one after the other, and
+		// returns the Pods they were assigned to in order.
+		assignPods := func(count int) []string {
+			assigned := []string{}
+			for i := 0; i < count; i++ {
+				request := &v1alpha1.ExecAccessRequest{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      utils.RandomString(8),
+						Namespace: ns.GetName(),
+					},
+					Spec: v1alpha1.ExecAccessRequestSpec{
+						TemplateName: template.GetName(),
+					},
+				}
+				Expect(k8sClient.Create(ctx, request)).To(Succeed())
+				_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+				Expect(err).ToNot(HaveOccurred())
+				assigned = append(assigned, request.GetPodName())
+			}
+			return assigned
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+			By("Creating a Deployment to reference for the test")
+			deployment = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "lru",
+					Namespace: ns.Name,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"testLabel": "lru"},
+					},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"testLabel": "lru"},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "test", Image: "nginx:latest"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			By("Creating three Pods that match the Deployment")
+			for _, name := range []string{"lru-c", "lru-a", "lru-b"} {
+				createPod(name)
+			}
+
+			By("Should have an ExecAccessTemplate that picks the least recently used Pod")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deployment.GetName(),
+					},
+					PodSelectionStrategy: v1alpha1.LeastRecentlyUsedPodSelection,
+				},
+			}
+			Expect(k8sClient.Create(ctx, template)).To(Succeed())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("Should spread sequential requests across the Pods in turn", func() {
+			Expect(assignPods(6)).To(Equal([]string{
+				"lru-a", "lru-b", "lru-c",
+				"lru-a", "lru-b", "lru-c",
+			}))
+		})
+
+		It("Should prefer a Pod that has never been assigned", func() {
+			createPod("lru-d")
+			Expect(assignPods(2)).To(Equal([]string{"lru-d", "lru-a"}))
+		})
+
+		It("Should skip Pods that have gone away", func() {
+			Expect(k8sClient.Delete(ctx, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "lru-b", Namespace: ns.GetName()},
+			})).To(Succeed())
+			Expect(assignPods(3)).To(Equal([]string{"lru-c", "lru-d", "lru-a"}))
+		})
+	})
+
+	Context("CreateAccessResources() with preferUngrantedPods", func() {
+		var (
+			ctx        = context.Background()
+			ns         *corev1.Namespace
+			deployment *appsv1.Deployment
+			template   *v1alpha1.ExecAccessTemplate
+			builder    = ExecAccessBuilder{}
+		)
+
+		// grantPod creates an Access Request that has already been assigned
+		// to the named Pod.
+		grantPod := func(podName string) *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+			Expect(request.SetPodName(podName)).To(Succeed())
+			Expect(k8sClient.Status().Update(ctx, request)).To(Succeed())
+			return request
+		}
+
+		// assignPod creates an Access Request, and returns the Pod that it
+		// was assigned to.
+		assignPod := func() string {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).ToNot(HaveOccurred())
+			return request.GetPodName()
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+			By("Creating a Deployment to reference for the test")
+			deployment = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "spread",
+					Namespace: ns.Name,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"testLabel": "spread"},
+					},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"testLabel": "spread"},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "test", Image: "nginx:latest"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			By("Creating three Pods that match the Deployment")
+			for _, name := range []string{"spread-a", "spread-b", "spread-c"} {
+				pod := &corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: ns.GetName(),
+						Labels:    deployment.Spec.Selector.MatchLabels,
+					},
+					Spec: deployment.Spec.Template.Spec,
+				}
+				Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+			}
+
+			By("Should have an ExecAccessTemplate that prefers ungranted Pods")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deployment.GetName(),
+					},
+					PreferUngrantedPods: true,
+				},
+			}
+			Expect(k8sClient.Create(ctx, template)).To(Succeed())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("Should pick the only Pod without an active grant", func() {
+			grantPod("spread-a")
+			expiring := grantPod("spread-b")
+			Expect(assignPod()).To(Equal("spread-c"))
+
+			// Once its access is no longer valid, a grant no longer counts
+			expiring.Status.Conditions = []metav1.Condition{{
+				Type:               v1alpha1.ConditionAccessStillValid.String(),
+				Status:             metav1.ConditionFalse,
+				Reason:             "Expired",
+				LastTransitionTime: metav1.Now(),
+			}}
+			Expect(k8sClient.Status().Update(ctx, expiring)).To(Succeed())
+			Expect(assignPod()).To(Equal("spread-b"))
+		})
+
+		It("Should still pick a Pod once every Pod has an active grant", func() {
+			Expect([]string{"spread-a", "spread-b", "spread-c"}).To(ContainElement(assignPod()))
+		})
+	})
+
+	Context("CreateAccessResources() with a nodeSelector", func() {
+		var (
+			ctx        = context.Background()
+			ns         *corev1.Namespace
+			deployment *appsv1.Deployment
+			template   *v1alpha1.ExecAccessTemplate
+			nodes      []*corev1.Node
+			builder    = ExecAccessBuilder{}
+			zoneLabel  = "topology.kubernetes.io/zone"
+		)
+
+		createNode := func(name string, zone string) {
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   name,
+					Labels: map[string]string{zoneLabel: zone},
+				},
+			}
+			Expect(k8sClient.Create(ctx, node)).To(Succeed())
+			nodes = append(nodes, node)
+		}
+
+		createPod := func(name string, nodeName string) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns.GetName(),
+					Labels:    deployment.Spec.Selector.MatchLabels,
+				},
+				Spec: *deployment.Spec.Template.Spec.DeepCopy(),
+			}
+			pod.Spec.NodeName = nodeName
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		}
+
+		newRequest := func(targetPod string) *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+					TargetPod:    targetPod,
+				},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+			return request
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+			By("Creating Nodes in two zones")
+			createNode("zone-a-node", "us-west-2a")
+			createNode("zone-b-node", "us-west-2b")
+
+			By("Creating a Deployment to reference for the test")
+			deployment = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "zoned",
+					Namespace: ns.Name,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"testLabel": "zoned"},
+					},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"testLabel": "zoned"},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "test", Image: "nginx:latest"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			By("Creating a Pod in each zone")
+			createPod("zoned-a", "zone-a-node")
+			createPod("zoned-b", "zone-b-node")
+
+			By("Should have an ExecAccessTemplate restricted to one zone")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+						NodeSelector:    map[string]string{zoneLabel: "us-west-2b"},
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deployment.GetName(),
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, template)).To(Succeed())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace and Nodes")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+			for _, node := range nodes {
+				Expect(k8sClient.Delete(ctx, node)).To(Succeed())
+			}
+		})
+
+		It("Random selection should only pick Pods on matching Nodes", func() {
+			for i := 0; i < 5; i++ {
+				request := newRequest("")
+				_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(request.GetPodName()).To(Equal("zoned-b"))
+			}
+		})
+
+		It("Should reject a target Pod on a Node that does not match", func() {
+			request := newRequest("zoned-a")
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"pod named zoned-a not found on nodes matching topology.kubernetes.io/zone=us-west-2b",
+			))
+		})
+
+		It("Should fail if no Pods run on matching Nodes", func() {
+			noMatch := template.DeepCopy()
+			noMatch.Spec.AccessConfig.NodeSelector = map[string]string{zoneLabel: "us-west-2c"}
+
+			request := newRequest("")
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, noMatch)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(
+				"no pods found maching selector on nodes matching topology.kubernetes.io/zone=us-west-2c",
+			))
+		})
+	})
+
+	Context("CreateAccessResources() with a podSelectionTimeout", func() {
+		var (
+			ctx        = context.Background()
+			ns         *corev1.Namespace
+			deployment *appsv1.Deployment
+			template   *v1alpha1.ExecAccessTemplate
+		)
+
+		newRequest := func() *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+			return request
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+			By("Creating a Deployment without any Pods yet, as if mid-rollout")
+			deployment = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rollout",
+					Namespace: ns.Name,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"testLabel": "rollout"},
+					},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"testLabel": "rollout"},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "test", Image: "nginx:latest"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			By("Should have an ExecAccessTemplate with a podSelectionTimeout")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deployment.GetName(),
+					},
+					PodSelectionTimeout: "5m",
+				},
+			}
+			Expect(k8sClient.Create(ctx, template)).To(Succeed())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("Should fail right away without a podSelectionTimeout", func() {
+			noTimeout := template.DeepCopy()
+			noTimeout.Spec.PodSelectionTimeout = ""
+
+			request := newRequest()
+			builder := ExecAccessBuilder{}
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, noTimeout)
+			Expect(err).To(MatchError("no pods found maching selector"))
+			Expect(errors.Is(err, builders.ErrNoCandidatePods)).To(BeTrue())
+			Expect(errors.Is(err, builders.ErrPodSelectionPending)).To(BeFalse())
+		})
+
+		It("Should fall back to the timeout of the builder", func() {
+			noTimeout := template.DeepCopy()
+			noTimeout.Spec.PodSelectionTimeout = ""
+			builder := ExecAccessBuilder{PodSelectionTimeout: time.Minute}
+
+			request := newRequest()
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, noTimeout)
+			Expect(errors.Is(err, builders.ErrPodSelectionPending)).To(BeTrue())
+		})
+
+		It("Should pick a Pod that shows up within the podSelectionTimeout", func() {
+			builder := ExecAccessBuilder{}
+			request := newRequest()
+
+			By("Finding no Pods at first")
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).To(MatchError("no pods found maching selector"))
+			Expect(errors.Is(err, builders.ErrPodSelectionPending)).To(BeTrue())
+			Expect(request.GetPodName()).To(BeEmpty())
+
+			By("Creating a Pod as the rollout progresses")
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rollout-a",
+					Namespace: ns.GetName(),
+					Labels:    deployment.Spec.Selector.MatchLabels,
+				},
+				Spec: *deployment.Spec.Template.Spec.DeepCopy(),
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+			By("Checking again")
+			_, err = builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.GetPodName()).To(Equal("rollout-a"))
+		})
+	})
+
+	Context("CreateAccessResources() with maxGrantsPerPod", func() {
+		var (
+			ctx        = context.Background()
+			ns         *corev1.Namespace
+			deployment *appsv1.Deployment
+			template   *v1alpha1.ExecAccessTemplate
+			builder    = ExecAccessBuilder{}
+		)
+
+		createPod := func(name string) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns.GetName(),
+					Labels:    deployment.Spec.Selector.MatchLabels,
+				},
+				Spec: *deployment.Spec.Template.Spec.DeepCopy(),
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		}
+
+		newRequest := func(targetPod string) *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+					TargetPod:    targetPod,
+				},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+			return request
+		}
+
+		// grant creates the access resources for the supplied request, and
+		// marks them as created - as the reconciler would.
+		grant := func(request *v1alpha1.ExecAccessRequest) {
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).ToNot(HaveOccurred())
+			meta.SetStatusCondition(&request.Status.Conditions, metav1.Condition{
+				Type:   v1alpha1.ConditionAccessResourcesCreated.String(),
+				Status: metav1.ConditionTrue,
+				Reason: "Success",
+			})
+			Expect(k8sClient.Status().Update(ctx, request)).To(Succeed())
+		}
+
+		// expire marks the access of the supplied request as expired, freeing
+		// up its grant - as the reconciler would.
+		expire := func(request *v1alpha1.ExecAccessRequest) {
+			meta.SetStatusCondition(&request.Status.Conditions, metav1.Condition{
+				Type:   v1alpha1.ConditionAccessStillValid.String(),
+				Status: metav1.ConditionFalse,
+				Reason: "Expired",
+			})
+			Expect(k8sClient.Status().Update(ctx, request)).To(Succeed())
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+			By("Creating a Deployment to reference for the test")
+			deployment = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "fragile",
+					Namespace: ns.Name,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"testLabel": "fragile"},
+					},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"testLabel": "fragile"},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "test", Image: "nginx:latest"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			By("Creating two Pods")
+			createPod("fragile-a")
+			createPod("fragile-b")
+
+			By("Should have an ExecAccessTemplate allowing a single grant per Pod")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+						MaxGrantsPerPod: 1,
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deployment.GetName(),
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, template)).To(Succeed())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		var first *v1alpha1.ExecAccessRequest
+
+		It("Should queue a request for a Pod that is at its cap", func() {
+			first = newRequest("fragile-a")
+			grant(first)
+
+			second := newRequest("fragile-a")
+			_, err := builder.CreateAccessResources(ctx, k8sClient, second, template)
+			Expect(err).To(MatchError("target pod maximum grant count reached: 1 of 1 grants on pod fragile-a in use"))
+			Expect(errors.Is(err, builders.ErrMaxGrantsPerPodReached)).To(BeTrue())
+
+			// VERIFY: The request that named its own Pod keeps it
+			Expect(second.GetPodName()).To(Equal("fragile-a"))
+		})
+
+		It("Should assign a Pod with room to a request that did not name one", func() {
+			for i := 0; i < 5; i++ {
+				request := newRequest("")
+				_, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(request.GetPodName()).To(Equal("fragile-b"))
+
+				// The grant counts until it expires, even before it is
+				// marked as created.
+				expire(request)
+			}
+		})
+
+		It("Should hand the slot to a queued request once a grant expires", func() {
+			second := newRequest("fragile-a")
+			_, err := builder.CreateAccessResources(ctx, k8sClient, second, template)
+			Expect(errors.Is(err, builders.ErrMaxGrantsPerPodReached)).To(BeTrue())
+
+			By("Expiring the first grant")
+			expire(first)
+
+			_, err = builder.CreateAccessResources(ctx, k8sClient, second, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(second.GetPodName()).To(Equal("fragile-a"))
+		})
+
+		It("Should not exceed the cap with requests assigned concurrently", func() {
+			// fragile-a is taken by the previous test, and only fragile-b
+			// has room for a single grant.
+			requests := make([]*v1alpha1.ExecAccessRequest, 4)
+			for i := range requests {
+				requests[i] = newRequest("")
+			}
+
+			var wg sync.WaitGroup
+			errs := make([]error, len(requests))
+			for i, request := range requests {
+				wg.Add(1)
+				go func(i int, request *v1alpha1.ExecAccessRequest) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					_, errs[i] = builder.CreateAccessResources(ctx, k8sClient, request, template)
+				}(i, request)
+			}
+			wg.Wait()
+
+			// VERIFY: Exactly one request was granted, and the rest queued up
+			granted := 0
+			for i, err := range errs {
+				if err == nil {
+					granted++
+					Expect(requests[i].GetPodName()).To(Equal("fragile-b"))
+					continue
+				}
+				Expect(errors.Is(err, builders.ErrMaxGrantsPerPodReached)).To(BeTrue())
+			}
+			Expect(granted).To(Equal(1))
+		})
+	})
+
+	Context("CreateAccessResources() with a fallbackTemplate", func() {
+		var (
+			ctx      = context.Background()
+			ns       *corev1.Namespace
+			primary  *appsv1.Deployment
+			template *v1alpha1.ExecAccessTemplate
+			fallback *v1alpha1.ExecAccessTemplate
+			builder  = ExecAccessBuilder{}
+		)
+
+		newDeployment := func(name string) *appsv1.Deployment {
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns.Name,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"testLabel": name},
+					},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"testLabel": name},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "test", Image: "nginx:latest"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+			return deployment
+		}
+
+		createPod := func(name string, deployment *appsv1.Deployment) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns.GetName(),
+					Labels:    deployment.Spec.Selector.MatchLabels,
+				},
+				Spec: *deployment.Spec.Template.Spec.DeepCopy(),
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		}
+
+		newTemplate := func(deployment *appsv1.Deployment, fallbackTemplate string) *v1alpha1.ExecAccessTemplate {
+			tmpl := &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:    []string{"foo"},
+						DefaultDuration:  "1h",
+						MaxDuration:      "2h",
+						FallbackTemplate: fallbackTemplate,
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deployment.GetName(),
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, tmpl)).To(Succeed())
+			return tmpl
+		}
+
+		newRequest := func() *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+			return request
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+			By("Creating a primary Deployment without any Pods, and a secondary one with a Pod")
+			primary = newDeployment("primary")
+			secondary := newDeployment("secondary")
+			createPod("secondary-a", secondary)
+
+			By("Should have an ExecAccessTemplate falling back to the secondary Deployment")
+			fallback = newTemplate(secondary, "")
+			template = newTemplate(primary, fallback.GetName())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("Should fail if the fallback template does not exist", func() {
+			missing := template.DeepCopy()
+			missing.Spec.AccessConfig.FallbackTemplate = "missing"
+
+			request := newRequest()
+			_, err := builder.CreateAccessResources(ctx, k8sClient, request, missing)
+			Expect(err).To(MatchError(HavePrefix("no pods found maching selector (failed to get fallback template missing: ")))
+			Expect(errors.Is(err, builders.ErrNoCandidatePods)).To(BeTrue())
+			Expect(request.Status.FallbackTemplate).To(BeEmpty())
+		})
+
+		var fellBack *v1alpha1.ExecAccessRequest
+
+		It("Should fall back to the fallback template when the primary has no Pods", func() {
+			fellBack = newRequest()
+			ret, err := builder.CreateAccessResources(ctx, k8sClient, fellBack, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret).To(HaveSuffix(" through fallback template " + fallback.GetName()))
+
+			// VERIFY: The Pod and the template it was picked from are recorded
+			Expect(fellBack.GetPodName()).To(Equal("secondary-a"))
+			Expect(fellBack.Status.FallbackTemplate).To(Equal(fallback.GetName()))
+			found := &v1alpha1.ExecAccessRequest{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      fellBack.GetName(),
+				Namespace: fellBack.GetNamespace(),
+			}, found)).To(Succeed())
+			Expect(found.Status.FallbackTemplate).To(Equal(fallback.GetName()))
+		})
+
+		It("Should use the primary template once it has Pods", func() {
+			createPod("primary-a", primary)
+
+			request := newRequest()
+			ret, err := builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret).ToNot(ContainSubstring("fallback"))
+			Expect(request.GetPodName()).To(Equal("primary-a"))
+			Expect(request.Status.FallbackTemplate).To(BeEmpty())
+		})
+
+		It("Should keep granting access through the fallback template it fell back to", func() {
+			_, err := builder.CreateAccessResources(ctx, k8sClient, fellBack, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fellBack.GetPodName()).To(Equal("secondary-a"))
+			Expect(fellBack.Status.FallbackTemplate).To(Equal(fallback.GetName()))
+		})
 	})
 })
@@ -0,0 +1,29 @@
+package execaccessbuilder
+
+import (
+	"context"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// DeleteAccessResources implements the IBuilder interface. The RoleBinding is
+// removed before the Role so that access is cut off first.
+func (b *ExecAccessBuilder) DeleteAccessResources(
+	ctx context.Context,
+	client client.Client,
+	req v1alpha1.IRequestResource,
+) error {
+	meta := metav1.ObjectMeta{
+		Name:      utils.GenerateResourceName(req),
+		Namespace: req.GetNamespace(),
+	}
+	if err := utils.DeleteResource(ctx, client, &rbacv1.RoleBinding{ObjectMeta: meta}); err != nil {
+		return err
+	}
+	return utils.DeleteResource(ctx, client, &rbacv1.Role{ObjectMeta: meta})
+}
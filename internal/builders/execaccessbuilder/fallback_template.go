@@ -0,0 +1,63 @@
+package execaccessbuilder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder/internal"
+)
+
+// selectPod picks the target Pod of the request (see internal.GetPodName), and
+// returns it along with the template that access is granted through.
+//
+// That is the template of the request, unless it has no candidate Pods and
+// names a fallbackTemplate - then a Pod is picked from the fallback template
+// instead. The fallback template is recorded in the (local) request status,
+// so that later reconciles keep granting access through it.
+//
+// Returns:
+//   - The name of the target Pod
+//   - The template that access is granted through
+//   - An "error" if no Pod could be picked from either template (a
+//     builders.ErrNoCandidatePods if neither had any candidate Pods)
+func (b *ExecAccessBuilder) selectPod(
+	ctx context.Context,
+	cl client.Client,
+	req *v1alpha1.ExecAccessRequest,
+	tmpl *v1alpha1.ExecAccessTemplate,
+) (string, *v1alpha1.ExecAccessTemplate, error) {
+	log := logf.FromContext(ctx)
+
+	// Stick with the fallback template once access went through it.
+	if name := req.Status.FallbackTemplate; name != "" {
+		fallback, err := v1alpha1.GetExecAccessTemplate(ctx, cl, name, tmpl.GetNamespace())
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get fallback template %s: %w", name, err)
+		}
+		podName, err := internal.GetPodName(ctx, cl, req, fallback, b.StateStore)
+		return podName, fallback, err
+	}
+
+	podName, err := internal.GetPodName(ctx, cl, req, tmpl, b.StateStore)
+	name := tmpl.GetAccessConfig().GetFallbackTemplate()
+	if err == nil || name == "" || !errors.Is(err, builders.ErrNoCandidatePods) {
+		return podName, tmpl, err
+	}
+
+	log.Info(fmt.Sprintf("No candidate Pods, falling back to template %s", name), "reason", err.Error())
+	fallback, fbErr := v1alpha1.GetExecAccessTemplate(ctx, cl, name, tmpl.GetNamespace())
+	if fbErr != nil {
+		return "", nil, fmt.Errorf("%w (failed to get fallback template %s: %s)", err, name, fbErr)
+	}
+	if podName, err = internal.GetPodName(ctx, cl, req, fallback, b.StateStore); err != nil {
+		return "", nil, err
+	}
+	req.Status.FallbackTemplate = name
+	return podName, fallback, nil
+}
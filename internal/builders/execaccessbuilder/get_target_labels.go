@@ -0,0 +1,41 @@
+package execaccessbuilder
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// GetTargetLabels implements the IBuilder interface
+//
+// The target Pod is picked the same way that CreateAccessResources() picks it
+// (falling back to the fallbackTemplate if need be), and is recorded in the (local) request status so that both agree on it.
+func (b *ExecAccessBuilder) GetTargetLabels(
+	ctx context.Context,
+	client client.Client,
+	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
+) (map[string]string, error) {
+	// Cast the Request into an ExecAccessRequest.
+	execReq := req.(*v1alpha1.ExecAccessRequest)
+	// Cast the Template into an ExecAccessTemplate.
+	execTmpl := tmpl.(*v1alpha1.ExecAccessTemplate)
+
+	targetPodName, _, err := b.selectPod(ctx, client, execReq, execTmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	pod := &corev1.Pod{}
+	if err := client.Get(ctx, types.NamespacedName{
+		Name:      targetPodName,
+		Namespace: execReq.GetNamespace(),
+	}, pod); err != nil {
+		return nil, err
+	}
+	return pod.GetLabels(), nil
+}
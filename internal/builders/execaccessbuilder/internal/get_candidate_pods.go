@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// getCandidatePods returns the running Pods of the target controller of the
+// template that an Access Request could be assigned to, optionally restricted
+// to a single node. Only Pods on Nodes matching the template nodeSelector are
+// returned. Pods without room for another grant (see maxGrantsPerPod), and if
+// the template prefers ungranted Pods, the Pods with active grants, are
+// dropped whenever there are others.
+//
+// Returns:
+//   - A list of one or more Pods
+//   - An "error" if no Pods are found (a builders.ErrNoCandidatePods), or the
+//     Pods could not be listed
+func getCandidatePods(
+	ctx context.Context,
+	cl client.Client,
+	nodeName string,
+	tmpl *v1alpha1.ExecAccessTemplate,
+) ([]corev1.Pod, error) {
+	log := logf.FromContext(ctx)
+	log.Info("Finding Pods...")
+
+	// https://medium.com/coding-kubernetes/using-k8s-label-selectors-in-go-the-right-way-733cde7e8630
+	selector, err := getSelector(ctx, cl, tmpl)
+	if err != nil {
+		log.Error(err, "Failed to find label selector, cannot automatically discover pods")
+		return nil, err
+	}
+
+	// List all of the pods in the Deployment by searching for matching pods with the current Label
+	// Selector.
+	podList := &corev1.PodList{}
+	fields := client.MatchingFields{
+		v1alpha1.FieldSelectorStatusPhase: string(PodPhaseRunning),
+	}
+	if nodeName != "" {
+		fields[v1alpha1.FieldSelectorSpecNodeName] = nodeName
+	}
+	opts := []client.ListOption{
+		client.InNamespace(tmpl.Namespace),
+		client.MatchingLabelsSelector{
+			Selector: selector,
+		},
+		fields,
+	}
+	if err := cl.List(ctx, podList, opts...); err != nil {
+		log.Error(err, "Failed to retrieve Pod list")
+		return nil, err
+	}
+
+	// Optionally drop any Pods that the label selector matched, but which are
+	// not actually owned by the target controller.
+	if tmpl.Spec.ResolvePodsByOwnerReference {
+		if podList.Items, err = utils.FilterOwnedPods(ctx, cl, tmpl, podList.Items); err != nil {
+			log.Error(err, "Failed to resolve Pods by ownerReference")
+			return nil, err
+		}
+	}
+
+	// Drop any Pods that are not running on a Node the template allows.
+	nodeSelector := tmpl.Spec.AccessConfig.GetNodeSelector()
+	if podList.Items, err = utils.FilterPodsByNodeSelector(
+		ctx, cl, nodeSelector, podList.Items,
+	); err != nil {
+		return nil, err
+	}
+
+	if len(podList.Items) < 1 {
+		if len(nodeSelector) > 0 {
+			return nil, builders.WithKind(builders.ErrNoCandidatePods, fmt.Errorf(
+				"no pods found maching selector on nodes matching %s", labels.Set(nodeSelector),
+			))
+		}
+		if nodeName != "" {
+			return nil, builders.WithKind(builders.ErrNoCandidatePods,
+				fmt.Errorf("no pods found maching selector on node %s", nodeName))
+		}
+		return nil, builders.WithKind(builders.ErrNoCandidatePods,
+			fmt.Errorf("no pods found maching selector"))
+	}
+
+	// Steer away from the Pods that have no room for another grant.
+	if podList.Items, err = dropFullPods(ctx, cl, tmpl, podList.Items); err != nil {
+		return nil, err
+	}
+
+	// Optionally steer away from the Pods that other requests are already using.
+	if tmpl.Spec.PreferUngrantedPods {
+		return preferUngrantedPods(ctx, cl, tmpl, podList.Items)
+	}
+	return podList.Items, nil
+}
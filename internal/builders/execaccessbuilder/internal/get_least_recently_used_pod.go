@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/opstate"
+)
+
+// podUsageState names the operational state (see opstate.Store) that the
+// podUsage is persisted in.
+const podUsageState = "pod-usage"
+
+// podUsage records the order in which Pods were last assigned to Access
+// Requests, per template. It is kept in memory, and unless the controller is
+// given an opstate.Store to persist it in (see load() and save()), a restart
+// of the controller starts every template over from scratch.
+type podUsage struct {
+	mu sync.Mutex
+
+	// seq is bumped on every assignment, and is used instead of a clock so
+	// that two assignments are never considered to have happened at once.
+	seq uint64
+
+	// lastUsed maps a "namespace.template" key to the sequence number at
+	// which each of its Pods was last assigned.
+	lastUsed map[string]map[string]uint64
+}
+
+// recentPodUsage is shared by all of the ExecAccessTemplates that use the
+// LeastRecentlyUsedPodSelection strategy.
+var recentPodUsage = &podUsage{lastUsed: map[string]map[string]uint64{}}
+
+// pick returns the least recently used of the supplied Pods, and records it as
+// the most recently used one. Pods that were never assigned come first, and
+// ties are broken by name so that the Pods are visited in a stable order.
+//
+// Only the supplied Pods are remembered afterwards, so Pods that have gone
+// away do not accumulate in memory.
+func (u *podUsage) pick(key string, pods []corev1.Pod) *corev1.Pod {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	previous := u.lastUsed[key]
+	pod := leastRecentlyUsed(previous, pods)
+
+	u.seq++
+	current := map[string]uint64{}
+	for _, p := range pods {
+		if seq, ok := previous[p.GetName()]; ok {
+			current[p.GetName()] = seq
+		}
+	}
+	current[pod.GetName()] = u.seq
+	u.lastUsed[key] = current
+	return pod
+}
+
+// peek returns the Pod that pick() would return, without recording it.
+func (u *podUsage) peek(key string, pods []corev1.Pod) *corev1.Pod {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return leastRecentlyUsed(u.lastUsed[key], pods)
+}
+
+// leastRecentlyUsed returns the Pod with the lowest sequence number in the
+// supplied usage, breaking ties by name.
+func leastRecentlyUsed(usage map[string]uint64, pods []corev1.Pod) *corev1.Pod {
+	sorted := append([]corev1.Pod{}, pods...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := usage[sorted[i].GetName()], usage[sorted[j].GetName()]
+		if a != b {
+			return a < b
+		}
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+	return &sorted[0]
+}
+
+// load seeds the usage of the Pods of the key from the supplied store, unless
+// it is already known in memory (which is always at least as recent, as only
+// the leading controller replica assigns Pods).
+func (u *podUsage) load(ctx context.Context, state *opstate.Store, key string) error {
+	u.mu.Lock()
+	_, known := u.lastUsed[key]
+	u.mu.Unlock()
+	if known {
+		return nil
+	}
+
+	value, ok, err := state.Get(ctx, podUsageState, key)
+	if err != nil || !ok {
+		return err
+	}
+	stored := map[string]uint64{}
+	if err := json.Unmarshal([]byte(value), &stored); err != nil {
+		return fmt.Errorf("invalid pod usage of %s: %w", key, err)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, known := u.lastUsed[key]; known {
+		return nil
+	}
+	for _, seq := range stored {
+		if seq > u.seq {
+			u.seq = seq
+		}
+	}
+	u.lastUsed[key] = stored
+	return nil
+}
+
+// save writes the usage of the Pods of the key into the supplied store.
+func (u *podUsage) save(ctx context.Context, state *opstate.Store, key string) error {
+	u.mu.Lock()
+	value, err := json.Marshal(u.lastUsed[key])
+	u.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return state.Set(ctx, podUsageState, key, string(value))
+}
+
+// getLeastRecentlyUsedPod picks the candidate Pod that this controller has
+// assigned to an Access Request for the template the longest time ago. If a
+// store is supplied, the usage of the Pods is persisted in it, so that it
+// survives restarts and leader changes of the controller. Failing to read or
+// write the store is logged, and the Pod is picked from memory alone. Under a
+// builders.WithDryRun() context, the Pod is returned without being recorded.
+func getLeastRecentlyUsedPod(
+	ctx context.Context,
+	cl client.Client,
+	nodeName string,
+	tmpl *v1alpha1.ExecAccessTemplate,
+	state *opstate.Store,
+) (*corev1.Pod, error) {
+	log := logf.FromContext(ctx)
+
+	pods, err := getCandidatePods(ctx, cl, nodeName, tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s.%s", tmpl.GetNamespace(), tmpl.GetName())
+	if state != nil {
+		if err := recentPodUsage.load(ctx, state, key); err != nil {
+			log.Error(err, "Failed to load the Pod usage, picking from memory")
+		}
+	}
+	if builders.IsDryRun(ctx) {
+		return recentPodUsage.peek(key, pods), nil
+	}
+	pod := recentPodUsage.pick(key, pods)
+	log.Info(fmt.Sprintf("Returning least recently used Pod %s", pod.Name))
+	if state != nil {
+		if err := recentPodUsage.save(ctx, state, key); err != nil {
+			log.Error(err, "Failed to persist the Pod usage")
+		}
+	}
+
+	return pod, nil
+}
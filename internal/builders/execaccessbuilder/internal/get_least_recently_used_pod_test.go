@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/diranged/oz/internal/opstate"
+)
+
+var _ = Describe("podUsage", func() {
+	var (
+		ctx   = context.Background()
+		key   = "test-ns.test-tmpl"
+		state *opstate.Store
+		pods  []corev1.Pod
+	)
+
+	BeforeEach(func() {
+		state = &opstate.Store{
+			Client:    fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+			Namespace: "oz-state",
+		}
+		pods = []corev1.Pod{}
+		for _, name := range []string{"pod-a", "pod-b", "pod-c"} {
+			pods = append(pods, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		}
+	})
+
+	It("Should persist the usage in the state namespace", func() {
+		usage := &podUsage{lastUsed: map[string]map[string]uint64{}}
+		Expect(usage.load(ctx, state, key)).To(Succeed())
+		Expect(usage.pick(key, pods).GetName()).To(Equal("pod-a"))
+		Expect(usage.save(ctx, state, key)).To(Succeed())
+
+		cm := &corev1.ConfigMap{}
+		Expect(state.Client.Get(ctx, types.NamespacedName{
+			Name:      opstate.ConfigMapPrefix + podUsageState,
+			Namespace: "oz-state",
+		}, cm)).To(Succeed())
+		Expect(cm.Data).To(HaveKeyWithValue(key, `{"pod-a":1}`))
+	})
+
+	It("Should carry on from the persisted usage after a restart", func() {
+		before := &podUsage{lastUsed: map[string]map[string]uint64{}}
+		for _, want := range []string{"pod-a", "pod-b"} {
+			Expect(before.load(ctx, state, key)).To(Succeed())
+			Expect(before.pick(key, pods).GetName()).To(Equal(want))
+			Expect(before.save(ctx, state, key)).To(Succeed())
+		}
+
+		after := &podUsage{lastUsed: map[string]map[string]uint64{}}
+		Expect(after.load(ctx, state, key)).To(Succeed())
+		Expect(after.pick(key, pods).GetName()).To(Equal("pod-c"))
+		Expect(after.pick(key, pods).GetName()).To(Equal("pod-a"))
+	})
+
+	It("Should prefer the usage known in memory over the persisted usage", func() {
+		Expect(state.Set(ctx, podUsageState, key, `{"pod-a":1}`)).To(Succeed())
+
+		usage := &podUsage{lastUsed: map[string]map[string]uint64{}}
+		Expect(usage.pick(key, pods).GetName()).To(Equal("pod-a"))
+		Expect(usage.load(ctx, state, key)).To(Succeed())
+		Expect(usage.pick(key, pods).GetName()).To(Equal("pod-b"))
+	})
+
+	It("Should reject persisted usage that can not be parsed", func() {
+		Expect(state.Set(ctx, podUsageState, key, "not-json")).To(Succeed())
+
+		usage := &podUsage{lastUsed: map[string]map[string]uint64{}}
+		Expect(usage.load(ctx, state, key)).To(HaveOccurred())
+	})
+
+	It("Should peek at the next Pod without recording it", func() {
+		usage := &podUsage{lastUsed: map[string]map[string]uint64{}}
+		Expect(usage.pick(key, pods).GetName()).To(Equal("pod-a"))
+		Expect(usage.peek(key, pods).GetName()).To(Equal("pod-b"))
+		Expect(usage.peek(key, pods).GetName()).To(Equal("pod-b"))
+		Expect(usage.pick(key, pods).GetName()).To(Equal("pod-b"))
+	})
+})
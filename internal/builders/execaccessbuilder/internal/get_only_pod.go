@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+)
+
+// getOnlyPod returns the candidate Pod of the template, if there is exactly
+// one. If there are several, a builders.ErrMultipleCandidatePods naming them is
+// returned - the requester has to pick one of them.
+func getOnlyPod(
+	ctx context.Context,
+	cl client.Client,
+	nodeName string,
+	tmpl *v1alpha1.ExecAccessTemplate,
+) (*corev1.Pod, error) {
+	log := logf.FromContext(ctx)
+
+	pods, err := getCandidatePods(ctx, cl, nodeName, tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pods) > 1 {
+		names := make([]string, 0, len(pods))
+		for _, pod := range pods {
+			names = append(names, pod.GetName())
+		}
+		return nil, builders.WithKind(builders.ErrMultipleCandidatePods, fmt.Errorf(
+			"%d pods match template %s (%s), set one of them as the targetPod of a new request",
+			len(pods), tmpl.GetName(), strings.Join(names, ", "),
+		))
+	}
+
+	pod := &pods[0]
+	log.Info(fmt.Sprintf("Returning the only candidate Pod %s", pod.Name))
+	return pod, nil
+}
@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+)
+
+var _ = Describe("getOnlyPod()", func() {
+	var (
+		ctx    = context.Background()
+		labels = map[string]string{"app": "web"}
+		tmpl   *v1alpha1.ExecAccessTemplate
+	)
+
+	// newClient returns a client populated with the target Deployment of the
+	// template, and a running Pod of it for each of the supplied names.
+	newClient := func(names ...string) client.Client {
+		objs := []client.Object{&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+			},
+		}}
+		for _, name := range names {
+			objs = append(objs, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Labels: labels},
+				Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+			})
+		}
+		return fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithObjects(objs...).
+			WithIndex(&corev1.Pod{}, v1alpha1.FieldSelectorStatusPhase, func(o client.Object) []string {
+				return []string{string(o.(*corev1.Pod).Status.Phase)}
+			}).
+			Build()
+	}
+
+	BeforeEach(func() {
+		tmpl = &v1alpha1.ExecAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: v1alpha1.ExecAccessTemplateSpec{
+				ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       "web",
+				},
+				PodSelectionStrategy: v1alpha1.ManualPodSelection,
+			},
+		}
+	})
+
+	It("Should auto-select the only candidate pod", func() {
+		pod, err := getOnlyPod(ctx, newClient("web-a"), "", tmpl)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pod.GetName()).To(Equal("web-a"))
+	})
+
+	It("Should require a selection when several pods are candidates", func() {
+		_, err := getOnlyPod(ctx, newClient("web-a", "web-b"), "", tmpl)
+		Expect(err).To(MatchError(builders.ErrMultipleCandidatePods))
+		Expect(err).To(MatchError(
+			"2 pods match template web (web-a, web-b), set one of them as the targetPod of a new request",
+		))
+	})
+
+	It("Should fail without any candidates", func() {
+		_, err := getOnlyPod(ctx, newClient(), "", tmpl)
+		Expect(err).To(MatchError(builders.ErrNoCandidatePods))
+	})
+
+	It("Should be used by GetPodName() for the manual podSelectionStrategy", func() {
+		req := &v1alpha1.ExecAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "test"},
+		}
+		_, err := GetPodName(ctx, newClient("web-a", "web-b"), req, tmpl, nil)
+		Expect(err).To(MatchError(builders.ErrMultipleCandidatePods))
+		Expect(req.GetPodName()).To(BeEmpty())
+	})
+})
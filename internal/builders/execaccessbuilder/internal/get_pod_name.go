@@ -9,6 +9,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/opstate"
 )
 
 // GetPodName is used to discover the target pod that the user is going to have access to. This
@@ -18,18 +19,26 @@ import (
 //   - If status.podName is set? Return that value Else? Continue.
 //   - If request.targetPod...
 //     ... is set, call getSpecificPod() to verify that the pod exists and is valid for the request
-//     ... is not set, call getRandomPod() to pick a random pod from the target controller
-//   - Save the picked podName into the request status and update the request object
+//     ... is not set, pick a pod from the target controller using the template's
+//     podSelectionStrategy - getRandomPod(), getLeastRecentlyUsedPod() or getOnlyPod()
+//   - The leastRecentlyUsed podSelectionStrategy persists the Pod usage in the state store, if
+//     one is supplied
+//   - If request.targetNode is set, only pods running on that node are considered
+//   - If the template sets a nodeSelector, only pods running on matching nodes are considered
+//   - Save the picked podName (and the UID of the Pod) into the request status and update the
+//     request object
 //
 // Returns:
 //
 //	podname: A string with the pod name (or an empty string in a failure)
-//	error: Any errors generating the podName.
+//	error: Any errors generating the podName (a builders.ErrMultipleCandidatePods if the template
+//	leaves the choice between several Pods to the requester).
 func GetPodName(
 	ctx context.Context,
 	client client.Client,
 	req *v1alpha1.ExecAccessRequest,
 	tmpl *v1alpha1.ExecAccessTemplate,
+	state *opstate.Store,
 ) (podName string, err error) {
 	log := logf.FromContext(ctx)
 	var pod *corev1.Pod
@@ -44,16 +53,23 @@ func GetPodName(
 	}
 
 	// If the user supplied their own Pod, then get that Pod back to make sure
-	// it exists. Otherwise, randomly select a pod.
+	// it exists. Otherwise, select a pod the way the template asks us to.
 	switch req.Spec.TargetPod {
 	case "":
-		pod, err = getRandomPod(ctx, client, tmpl)
+		switch tmpl.Spec.PodSelectionStrategy {
+		case v1alpha1.LeastRecentlyUsedPodSelection:
+			pod, err = getLeastRecentlyUsedPod(ctx, client, req.Spec.TargetNode, tmpl, state)
+		case v1alpha1.ManualPodSelection:
+			pod, err = getOnlyPod(ctx, client, req.Spec.TargetNode, tmpl)
+		default:
+			pod, err = getRandomPod(ctx, client, req.Spec.TargetNode, tmpl)
+		}
 		if err != nil {
 			log.Error(err, "Failed to retrieve Pod from ExecAccessTemplate")
 			return "", err
 		}
 	default:
-		pod, err = getSpecificPod(ctx, client, req.Spec.TargetPod, tmpl)
+		pod, err = getSpecificPod(ctx, client, req.Spec.TargetPod, req.Spec.TargetNode, tmpl)
 
 		// Informative for the operator for now. The verification step below
 		// truly let the user know about the problem.
@@ -73,6 +89,7 @@ func GetPodName(
 	if err := req.SetPodName(pod.GetName()); err != nil {
 		return "", err
 	}
+	req.SetPodUID(pod.GetUID())
 
 	// Return the podName string.
 	return pod.Name, nil
@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// getSelector returns the labels.Selector used to find candidate Pods for the
+// supplied template. If the template sets OnlyCurrentGeneration, the selector
+// is narrowed down to the Pods of the current controller revision.
+func getSelector(
+	ctx context.Context,
+	cl client.Client,
+	tmpl *v1alpha1.ExecAccessTemplate,
+) (labels.Selector, error) {
+	if tmpl.Spec.OnlyCurrentGeneration {
+		return utils.GetCurrentRevisionSelector(ctx, cl, tmpl)
+	}
+	return utils.GetSelectorLabels(ctx, cl, tmpl)
+}
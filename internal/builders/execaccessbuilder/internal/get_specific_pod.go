@@ -5,10 +5,12 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
 	"github.com/diranged/oz/internal/builders/utils"
 )
 
@@ -16,13 +18,14 @@ func getSpecificPod(
 	ctx context.Context,
 	cl client.Client,
 	podName string,
+	nodeName string,
 	tmpl *v1alpha1.ExecAccessTemplate,
 ) (*corev1.Pod, error) {
 	log := logf.FromContext(ctx)
 	log.Info(fmt.Sprintf("Looking for Pod %s", podName))
 
 	// https://medium.com/coding-kubernetes/using-k8s-label-selectors-in-go-the-right-way-733cde7e8630
-	selector, err := utils.GetSelectorLabels(ctx, cl, tmpl)
+	selector, err := getSelector(ctx, cl, tmpl)
 	if err != nil {
 		log.Error(err, "Failed to find label selector, cannot automatically discover pods")
 		return nil, err
@@ -31,22 +34,53 @@ func getSpecificPod(
 	// List all of the pods in the Deployment by searching for matching pods with the current Label
 	// Selector.
 	podList := &corev1.PodList{}
+	fields := client.MatchingFields{
+		v1alpha1.FieldSelectorMetadataName: podName,
+		v1alpha1.FieldSelectorStatusPhase:  string(PodPhaseRunning),
+	}
+	if nodeName != "" {
+		fields[v1alpha1.FieldSelectorSpecNodeName] = nodeName
+	}
 	opts := []client.ListOption{
 		client.InNamespace(tmpl.GetNamespace()),
 		client.MatchingLabelsSelector{
 			Selector: selector,
 		},
-		client.MatchingFields{
-			v1alpha1.FieldSelectorMetadataName: podName,
-			v1alpha1.FieldSelectorStatusPhase:  string(PodPhaseRunning),
-		},
+		fields,
 	}
 	if err := cl.List(ctx, podList, opts...); err != nil {
 		log.Error(err, "Failed to retrieve Pod list")
 		return nil, err
 	}
+
+	// Optionally drop any Pods that the label selector matched, but which are
+	// not actually owned by the target controller.
+	if tmpl.Spec.ResolvePodsByOwnerReference {
+		if podList.Items, err = utils.FilterOwnedPods(ctx, cl, tmpl, podList.Items); err != nil {
+			log.Error(err, "Failed to resolve Pods by ownerReference")
+			return nil, err
+		}
+	}
+	// The Pod must also be running on a Node the template allows.
+	nodeSelector := tmpl.Spec.AccessConfig.GetNodeSelector()
+	if podList.Items, err = utils.FilterPodsByNodeSelector(
+		ctx, cl, nodeSelector, podList.Items,
+	); err != nil {
+		return nil, err
+	}
+
 	if len(podList.Items) < 1 {
-		return nil, fmt.Errorf("pod named %s not found", podName)
+		if len(nodeSelector) > 0 {
+			return nil, builders.WithKind(builders.ErrTargetPodNotFound, fmt.Errorf(
+				"pod named %s not found on nodes matching %s", podName, labels.Set(nodeSelector),
+			))
+		}
+		if nodeName != "" {
+			return nil, builders.WithKind(builders.ErrTargetPodNotFound,
+				fmt.Errorf("pod named %s not found on node %s", podName, nodeName))
+		}
+		return nil, builders.WithKind(builders.ErrTargetPodNotFound,
+			fmt.Errorf("pod named %s not found", podName))
 	}
 	if len(podList.Items) > 1 {
 		return nil, fmt.Errorf("multiple pods matching %s returned - critical failure", podName)
@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// preferUngrantedPods drops the Pods that already have an active
+// ExecAccessRequest assigned to them from the supplied candidates - unless
+// that would drop every one of them, in which case all of the candidates are
+// returned. A request stops counting once it is being deleted, or its access
+// is no longer valid.
+//
+// Returns:
+//   - A list of one or more Pods
+//   - An "error" if the ExecAccessRequests could not be listed
+func preferUngrantedPods(
+	ctx context.Context,
+	cl client.Client,
+	tmpl *v1alpha1.ExecAccessTemplate,
+	pods []corev1.Pod,
+) ([]corev1.Pod, error) {
+	log := logf.FromContext(ctx)
+
+	reqList := &v1alpha1.ExecAccessRequestList{}
+	if err := cl.List(ctx, reqList, client.InNamespace(tmpl.GetNamespace())); err != nil {
+		log.Error(err, "Failed to retrieve ExecAccessRequest list")
+		return nil, err
+	}
+
+	granted := map[string]bool{}
+	for _, req := range reqList.Items {
+		if req.GetPodName() == "" ||
+			req.GetDeletionTimestamp() != nil ||
+			meta.IsStatusConditionFalse(
+				req.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+			) {
+			continue
+		}
+		granted[req.GetPodName()] = true
+	}
+
+	ungranted := make([]corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if !granted[pod.GetName()] {
+			ungranted = append(ungranted, pod)
+		}
+	}
+	if len(ungranted) == 0 {
+		log.Info("Every candidate Pod already has an active grant, considering all of them")
+		return pods, nil
+	}
+	return ungranted, nil
+}
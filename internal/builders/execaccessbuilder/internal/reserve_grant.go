@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// grantReservationTTL is how long a reservation is kept for a request whose
+// grant has not shown up (see IsGranted) in the client yet. It only has to
+// outlast the delay of the client's cache - a request that has not been
+// granted by then has failed or gone away.
+const grantReservationTTL = time.Minute
+
+// grantReservations records the ExecAccessRequests that were let through
+// the maxGrantsPerPod check of a Pod, until their grant can be seen in the
+// client. Without them, requests checked in the meantime (concurrently, or
+// from a cache that has not caught up yet) would not count the grant, and
+// exceed the maxGrantsPerPod of the Pod.
+type grantReservations struct {
+	mu sync.Mutex
+
+	// byPod maps each Pod to when each request (by name) reserved a grant on
+	// it.
+	byPod map[types.NamespacedName]map[string]time.Time
+}
+
+// pendingGrants is shared by all of the ExecAccessTemplates that set a
+// maxGrantsPerPod - grants through any template count towards it.
+var pendingGrants = &grantReservations{byPod: map[types.NamespacedName]map[string]time.Time{}}
+
+// ReserveGrant reserves one of the maxGrants grants on the named Pod for the
+// supplied request, if the ExecAccessRequests (other than the supplied
+// request) that hold or have reserved a grant on the Pod leave room for it.
+// The reservation is released by ReleaseGrant if the grant fails, and
+// otherwise once the grant can be seen through the client.
+//
+// Returns:
+//   - The number of grants held or reserved by other requests
+//   - True if the grant was reserved
+//   - An "error" if the ExecAccessRequests could not be listed
+func ReserveGrant(
+	ctx context.Context,
+	cl client.Client,
+	req *v1alpha1.ExecAccessRequest,
+	podName string,
+	maxGrants int,
+) (int, bool, error) {
+	pendingGrants.mu.Lock()
+	defer pendingGrants.mu.Unlock()
+
+	grants, err := pendingGrants.countLocked(ctx, cl, req.GetNamespace(), req.GetName())
+	if err != nil {
+		return 0, false, err
+	}
+	count := grants[podName]
+	if count >= maxGrants {
+		return count, false, nil
+	}
+
+	key := types.NamespacedName{Namespace: req.GetNamespace(), Name: podName}
+	if pendingGrants.byPod[key] == nil {
+		pendingGrants.byPod[key] = map[string]time.Time{}
+	}
+	pendingGrants.byPod[key][req.GetName()] = time.Now()
+	return count, true, nil
+}
+
+// ReleaseGrant drops the reservation of the supplied request on the named
+// Pod (see ReserveGrant), if it has one.
+func ReleaseGrant(req *v1alpha1.ExecAccessRequest, podName string) {
+	pendingGrants.mu.Lock()
+	defer pendingGrants.mu.Unlock()
+
+	key := types.NamespacedName{Namespace: req.GetNamespace(), Name: podName}
+	delete(pendingGrants.byPod[key], req.GetName())
+	if len(pendingGrants.byPod[key]) == 0 {
+		delete(pendingGrants.byPod, key)
+	}
+}
+
+// IsGranted returns true if the supplied request holds access to its Pod: its
+// access resources have been created, and its access is still valid. A
+// request stops holding access once it is being deleted, or its access
+// resources have been removed.
+func IsGranted(req *v1alpha1.ExecAccessRequest) bool {
+	return req.GetPodName() != "" &&
+		req.GetDeletionTimestamp() == nil &&
+		meta.IsStatusConditionTrue(
+			req.Status.Conditions, v1alpha1.ConditionAccessResourcesCreated.String(),
+		) &&
+		!meta.IsStatusConditionFalse(
+			req.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+		)
+}
+
+// hasEnded returns true if the access of the supplied request is over: it is
+// being deleted, or its access is no longer valid.
+func hasEnded(req *v1alpha1.ExecAccessRequest) bool {
+	return req.GetDeletionTimestamp() != nil ||
+		meta.IsStatusConditionFalse(
+			req.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+		)
+}
+
+// countLocked returns the number of ExecAccessRequests in the namespace that
+// hold or have reserved a grant on each Pod, leaving out the named request.
+// Reservations that have turned into a grant, whose access has ended, or that
+// have outlived the grantReservationTTL are dropped along the way. The caller must hold the
+// lock.
+func (g *grantReservations) countLocked(
+	ctx context.Context,
+	cl client.Client,
+	namespace string,
+	exclude string,
+) (map[string]int, error) {
+	reqList := &v1alpha1.ExecAccessRequestList{}
+	if err := cl.List(ctx, reqList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	grants := map[string]int{}
+	byName := map[string]*v1alpha1.ExecAccessRequest{}
+	for i := range reqList.Items {
+		r := &reqList.Items[i]
+		byName[r.GetName()] = r
+		if r.GetName() == exclude || !IsGranted(r) {
+			continue
+		}
+		grants[r.GetPodName()]++
+	}
+
+	now := time.Now()
+	for key, reserved := range g.byPod {
+		if key.Namespace != namespace {
+			continue
+		}
+		for name, at := range reserved {
+			r, found := byName[name]
+			if now.Sub(at) > grantReservationTTL ||
+				(found && (IsGranted(r) || hasEnded(r))) {
+				delete(reserved, name)
+				continue
+			}
+			if name != exclude {
+				grants[key.Name]++
+			}
+		}
+		if len(reserved) == 0 {
+			delete(g.byPod, key)
+		}
+	}
+	return grants, nil
+}
+
+// dropFullPods drops the Pods that already have the maximum number of
+// concurrent grants that the template allows (see maxGrantsPerPod), counting
+// the reserved ones, from the supplied candidates - unless that would drop
+// every one of them, in which case all of the candidates are returned, and the
+// request queues for a grant on whichever one it is assigned.
+func dropFullPods(
+	ctx context.Context,
+	cl client.Client,
+	tmpl *v1alpha1.ExecAccessTemplate,
+	pods []corev1.Pod,
+) ([]corev1.Pod, error) {
+	maxGrants := tmpl.GetAccessConfig().GetMaxGrantsPerPod()
+	if maxGrants <= 0 {
+		return pods, nil
+	}
+	log := logf.FromContext(ctx)
+
+	pendingGrants.mu.Lock()
+	grants, err := pendingGrants.countLocked(ctx, cl, tmpl.GetNamespace(), "")
+	pendingGrants.mu.Unlock()
+	if err != nil {
+		log.Error(err, "Failed to retrieve ExecAccessRequest list")
+		return nil, err
+	}
+
+	open := make([]corev1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if grants[pod.GetName()] < maxGrants {
+			open = append(open, pod)
+		}
+	}
+	if len(open) == 0 {
+		log.Info("Every candidate Pod already has the maximum number of grants, considering all of them")
+		return pods, nil
+	}
+	return open, nil
+}
@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("ReserveGrant()", func() {
+	var (
+		ctx = context.Background()
+		cl  client.Client
+		ns  string
+	)
+
+	// newRequest creates an ExecAccessRequest assigned to the named Pod,
+	// that does not hold a grant yet.
+	newRequest := func(name string, podName string) *v1alpha1.ExecAccessRequest {
+		req := &v1alpha1.ExecAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		}
+		Expect(cl.Create(ctx, req)).To(Succeed())
+		req.Status.PodName = podName
+		return req
+	}
+
+	// grant marks the access resources of the supplied request as created,
+	// as the reconciler would once the grant is made.
+	grant := func(req *v1alpha1.ExecAccessRequest) {
+		meta.SetStatusCondition(&req.Status.Conditions, metav1.Condition{
+			Type:   v1alpha1.ConditionAccessResourcesCreated.String(),
+			Status: metav1.ConditionTrue,
+			Reason: "Success",
+		})
+		Expect(cl.Status().Update(ctx, req)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		// The reservations are shared, so every spec gets its own namespace.
+		ns = utils.RandomString(8)
+		s := runtime.NewScheme()
+		Expect(v1alpha1.AddToScheme(s)).To(Succeed())
+		cl = fake.NewClientBuilder().WithScheme(s).Build()
+	})
+
+	It("Should not exceed the maximum with requests assigned concurrently", func() {
+		requests := make([]*v1alpha1.ExecAccessRequest, 10)
+		for i := range requests {
+			requests[i] = newRequest(fmt.Sprintf("req-%d", i), "web-1")
+		}
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			reserved int
+		)
+		for _, req := range requests {
+			wg.Add(1)
+			go func(req *v1alpha1.ExecAccessRequest) {
+				defer GinkgoRecover()
+				defer wg.Done()
+				_, ok, err := ReserveGrant(ctx, cl, req, "web-1", 2)
+				Expect(err).ToNot(HaveOccurred())
+				if ok {
+					mu.Lock()
+					reserved++
+					mu.Unlock()
+				}
+			}(req)
+		}
+		wg.Wait()
+
+		// VERIFY: None of the grants were visible to the others yet, but only
+		// two of them were let through
+		Expect(reserved).To(Equal(2))
+	})
+
+	It("Should count a grant once, whether it is reserved or visible", func() {
+		first := newRequest("first", "web-1")
+		count, ok, err := ReserveGrant(ctx, cl, first, "web-1", 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(count).To(Equal(0))
+
+		By("Counting the reservation before the grant shows up")
+		second := newRequest("second", "web-1")
+		count, _, err = ReserveGrant(ctx, cl, second, "web-1", 3)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(1))
+		ReleaseGrant(second, "web-1")
+
+		By("Counting the grant instead of the reservation once it shows up")
+		grant(first)
+		count, ok, err = ReserveGrant(ctx, cl, second, "web-1", 2)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(count).To(Equal(1))
+	})
+
+	It("Should hand a reservation back on ReleaseGrant()", func() {
+		first := newRequest("first", "web-1")
+		_, ok, err := ReserveGrant(ctx, cl, first, "web-1", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+
+		second := newRequest("second", "web-1")
+		count, ok, err := ReserveGrant(ctx, cl, second, "web-1", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeFalse())
+		Expect(count).To(Equal(1))
+
+		ReleaseGrant(first, "web-1")
+		_, ok, err = ReserveGrant(ctx, cl, second, "web-1", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+	})
+
+	It("Should drop the reservation of a request whose access has ended", func() {
+		first := newRequest("first", "web-1")
+		_, ok, err := ReserveGrant(ctx, cl, first, "web-1", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+
+		meta.SetStatusCondition(&first.Status.Conditions, metav1.Condition{
+			Type:   v1alpha1.ConditionAccessStillValid.String(),
+			Status: metav1.ConditionFalse,
+			Reason: "Expired",
+		})
+		Expect(cl.Status().Update(ctx, first)).To(Succeed())
+
+		second := newRequest("second", "web-1")
+		_, ok, err = ReserveGrant(ctx, cl, second, "web-1", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+	})
+
+	It("Should drop reservations that have outlived the grantReservationTTL", func() {
+		first := newRequest("first", "web-1")
+		_, ok, err := ReserveGrant(ctx, cl, first, "web-1", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+
+		By("Backdating the reservation")
+		pendingGrants.mu.Lock()
+		for key, reserved := range pendingGrants.byPod {
+			if key.Namespace == ns {
+				reserved["first"] = reserved["first"].Add(-2 * grantReservationTTL)
+			}
+		}
+		pendingGrants.mu.Unlock()
+
+		second := newRequest("second", "web-1")
+		_, ok, err = ReserveGrant(ctx, cl, second, "web-1", 1)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ok).To(BeTrue())
+	})
+})
@@ -0,0 +1,49 @@
+package execaccessbuilder
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder/internal"
+)
+
+// checkMaxGrantsPerPod returns a builders.ErrMaxGrantsPerPodReached error if
+// the template caps the concurrent grants per Pod, and the target Pod of the
+// request (which does not hold a grant yet) has no room for another one.
+// Otherwise a grant on the Pod is reserved for the request (see
+// internal.ReserveGrant), which the caller must release with
+// internal.ReleaseGrant if it fails to grant the access.
+//
+// A request that did not name its own targetPod is released from the Pod it
+// was assigned, so that it may be assigned any Pod with room on its next try -
+// unless the template has an approvalRequiredSelector, which was evaluated
+// against the labels of the assigned Pod.
+func checkMaxGrantsPerPod(
+	ctx context.Context,
+	cl client.Client,
+	req *v1alpha1.ExecAccessRequest,
+	tmpl *v1alpha1.ExecAccessTemplate,
+	podName string,
+) error {
+	maxGrants := tmpl.GetAccessConfig().GetMaxGrantsPerPod()
+	if maxGrants <= 0 || internal.IsGranted(req) {
+		return nil
+	}
+	count, reserved, err := internal.ReserveGrant(ctx, cl, req, podName, maxGrants)
+	if err != nil {
+		return err
+	}
+	if reserved {
+		return nil
+	}
+	if req.Spec.TargetPod == "" && tmpl.GetAccessConfig().GetApprovalRequiredSelector() == nil {
+		req.Status.PodName = ""
+		req.Status.PodUID = ""
+	}
+	return fmt.Errorf("%w: %d of %d grants on pod %s in use",
+		builders.ErrMaxGrantsPerPodReached, count, maxGrants, podName)
+}
@@ -0,0 +1,33 @@
+package execaccessbuilder
+
+import (
+	"errors"
+	"time"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+)
+
+// checkPodSelectionTimeout turns a builders.ErrNoCandidatePods error into a
+// retryable builders.ErrPodSelectionPending one while the request is still
+// within the pod selection timeout - the podSelectionTimeout of the template,
+// or the PodSelectionTimeout of the builder if the template does not set one.
+// An invalid podSelectionTimeout is treated as unset. Any other error is
+// returned unchanged.
+func (b *ExecAccessBuilder) checkPodSelectionTimeout(
+	req *v1alpha1.ExecAccessRequest,
+	tmpl *v1alpha1.ExecAccessTemplate,
+	err error,
+) error {
+	if !errors.Is(err, builders.ErrNoCandidatePods) {
+		return err
+	}
+	timeout, ok, parseErr := tmpl.GetPodSelectionTimeout()
+	if !ok || parseErr != nil {
+		timeout = b.PodSelectionTimeout
+	}
+	if time.Since(req.GetCreationTimestamp().Time) < timeout {
+		return builders.WithKind(builders.ErrPodSelectionPending, err)
+	}
+	return err
+}
@@ -0,0 +1,22 @@
+package execaccessbuilder
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// PrepareAccessResources implements the IBuilder interface
+//
+// The target Pod of an ExecAccessRequest already exists, so there is nothing
+// to prepare ahead of approval.
+func (b *ExecAccessBuilder) PrepareAccessResources(
+	_ context.Context,
+	_ client.Client,
+	_ v1alpha1.IRequestResource,
+	_ v1alpha1.ITemplateResource,
+) (string, error) {
+	return "", nil
+}
@@ -2,7 +2,10 @@
 package execaccessbuilder
 
 import (
+	"time"
+
 	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/opstate"
 )
 
 //+kubebuilder:rbac:groups=crds.wizardofoz.co,resources=execaccessrequests,verbs=get;list;watch;create;update;patch;delete
@@ -11,9 +14,19 @@ import (
 
 //+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=roles,verbs=get;list;watch;create;update;patch;delete;bind;escalate
 //+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch
 
 // ExecAccessBuilder implements the IBuilder interface for ExecAccessRequest resources
-type ExecAccessBuilder struct{}
+type ExecAccessBuilder struct {
+	// PodSelectionTimeout is how long an Access Request waits for a
+	// candidate Pod to show up before it fails, for templates that do not
+	// set their own podSelectionTimeout. Zero means it fails right away.
+	PodSelectionTimeout time.Duration
+
+	// StateStore is optional. If set, the Pod usage of the leastRecentlyUsed
+	// podSelectionStrategy is persisted in it, rather than only in memory.
+	StateStore *opstate.Store
+}
 
 // https://stackoverflow.com/questions/33089523/how-to-mark-golang-struct-as-implementing-interface
 var (
@@ -37,10 +37,36 @@ type IBuilder interface {
 		tmpl v1alpha1.ITemplateResource,
 	) error
 
+	// GetTargetLabels returns the labels of the Pod that the access request
+	// targets, so that label-driven policy (eg, the template's
+	// approvalRequiredSelector) can be evaluated before any access resources
+	// are created. Builders that pick a target Pod record their choice on the
+	// request, so that the same Pod is used by CreateAccessResources().
+	GetTargetLabels(
+		ctx context.Context,
+		client client.Client,
+		req v1alpha1.IRequestResource,
+		tmpl v1alpha1.ITemplateResource,
+	) (map[string]string, error)
+
+	// PrepareAccessResources creates any resources that can be prepared while
+	// the access request is still waiting on approvals, without granting any
+	// access (eg, pre-creating a Pod so that it is warm once approved). It is
+	// called on every reconcile until the request is approved, so it must be
+	// idempotent. Builders with nothing to prepare return an empty string.
+	PrepareAccessResources(
+		ctx context.Context,
+		client client.Client,
+		req v1alpha1.IRequestResource,
+		tmpl v1alpha1.ITemplateResource,
+	) (string, error)
+
 	// CreateAccessResources is the heavy lifter in an Access Builder - it is
 	// responsible for creating any access resources required to satisfy the
 	// access request. All resources created by this function must have an
 	// OwnerReference set to the Access Request to ensure proper cleanup.
+	// Errors that the reconciler should handle specially are tagged with one
+	// of the builders.Err* kinds (see WithKind()).
 	CreateAccessResources(
 		ctx context.Context,
 		client client.Client,
@@ -59,4 +85,15 @@ type IBuilder interface {
 		req v1alpha1.IRequestResource,
 		tmpl v1alpha1.ITemplateResource,
 	) (bool, error)
+
+	// DeleteAccessResources tears down the resources created by
+	// CreateAccessResources(). It is called by the Access Request finalizer,
+	// and must always remove the RoleBinding first so that access is cut off
+	// before any other resource (eg, a Pod) is removed. Resources that are
+	// already gone are not considered an error.
+	DeleteAccessResources(
+		ctx context.Context,
+		client client.Client,
+		req v1alpha1.IRequestResource,
+	) error
 }
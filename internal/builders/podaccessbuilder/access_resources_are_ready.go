@@ -47,6 +47,10 @@ func (b *PodAccessBuilder) AccessResourcesAreReady(
 		},
 	}
 
+	// Record the last known phase of the Pod in the (local) request status,
+	// for the Phase printcolumn. It is saved along with the conditions.
+	defer func() { podReq.Status.PodPhase = pod.Status.Phase }()
+
 	log.Info(
 		fmt.Sprintf(
 			"Checking if pod %s is ready yet (timeout: %s)",
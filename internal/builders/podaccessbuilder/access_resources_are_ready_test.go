@@ -94,6 +94,9 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 
 			// VERIFY: The returned ready state is True
 			Expect(ret).To(BeFalse())
+
+			// VERIFY: The phase of the Pod is recorded
+			Expect(request.Status.PodPhase).To(Equal(corev1.PodPending))
 		})
 
 		It("AccessResoucesAreReady() should succeed", func() {
@@ -121,6 +124,7 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 
 			// VERIFY: The returned ready state is True
 			Expect(ret).To(BeTrue())
+			Expect(request.Status.PodPhase).To(Equal(corev1.PodRunning))
 		})
 
 		It("AccessResoucesAreReady() should fail if the pod has no conditions", func() {
@@ -2,6 +2,7 @@ package podaccessbuilder
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -10,11 +11,13 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
 	bldutil "github.com/diranged/oz/internal/builders/utils"
 	"github.com/diranged/oz/internal/testing/utils"
 )
@@ -128,6 +131,17 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("GetTargetLabels() should return the labels of the Pod that would be created", func() {
+			tmpl := template.DeepCopy()
+			tmpl.Spec.ControllerTargetMutationConfig.PodLabels = &map[string]string{"env": "production"}
+
+			ret, err := builder.GetTargetLabels(ctx, k8sClient, request, tmpl)
+
+			// VERIFY: The mutated labels, which replace the controller labels
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret).To(Equal(map[string]string{"env": "production"}))
+		})
+
 		It("CreateAccessResources() should succeed", func() {
 			request.Status.PodName = ""
 
@@ -166,6 +180,7 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 			Expect(foundRole.GetOwnerReferences()).ToNot(BeNil())
 			Expect(foundRole.Rules[0].ResourceNames[0]).To(Equal(foundPod.GetName()))
 			Expect(foundRole.Rules[1].ResourceNames[0]).To(Equal(foundPod.GetName()))
+			Expect(foundRole.Rules).To(HaveLen(2))
 
 			// VERIFY: RoleBinding Created as expected
 			foundRoleBinding := &rbacv1.RoleBinding{}
@@ -178,5 +193,244 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 			Expect(foundRoleBinding.RoleRef.Name).To(Equal(foundRole.GetName()))
 			Expect(foundRoleBinding.Subjects[0].Name).To(Equal("testGroupA"))
 		})
+
+		It("CreateAccessResources() should queue requests beyond spec.maxPods", func() {
+			template.Spec.MaxPods = 1
+
+			By("Creating a second PodAccessRequest against the same template")
+			queued := &v1alpha1.PodAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "createaccessresource-queued",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.PodAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err := k8sClient.Create(ctx, queued)
+			Expect(err).ToNot(HaveOccurred())
+
+			// Execute
+			_, err = builder.CreateAccessResources(ctx, k8sClient, queued, template)
+
+			// VERIFY: The pod cap error is returned, and no Pod was created
+			Expect(errors.Is(err, builders.ErrMaxPodsReached)).To(BeTrue())
+			Expect(err.Error()).To(MatchRegexp("1 of 1 pods in use"))
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      bldutil.GenerateResourceName(queued),
+				Namespace: ns.GetName(),
+			}, &corev1.Pod{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			By("Freeing up the Pod held by the first request")
+			err = k8sClient.Delete(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			// Execute
+			ret, err := builder.CreateAccessResources(ctx, k8sClient, queued, template)
+
+			// VERIFY: The queued request now gets its Pod
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret).To(MatchRegexp(fmt.Sprintf("Success. Pod %s-.*", queued.GetName())))
+		})
+
+		It("CreateAccessResources() should schedule the Pod onto the template nodeSelector", func() {
+			zoned := template.DeepCopy()
+			zoned.Spec.MaxPods = 0
+			zoned.Spec.AccessConfig.NodeSelector = map[string]string{
+				"topology.kubernetes.io/zone": "us-west-2b",
+			}
+			zonedRequest := &v1alpha1.PodAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "createaccessresource-zoned",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.PodAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err := k8sClient.Create(ctx, zonedRequest)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = builder.CreateAccessResources(ctx, k8sClient, zonedRequest, zoned)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The Pod is pinned to the matching Nodes
+			foundPod := &corev1.Pod{}
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      bldutil.GenerateResourceName(zonedRequest),
+				Namespace: ns.GetName(),
+			}, foundPod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundPod.Spec.NodeSelector).To(HaveKeyWithValue("topology.kubernetes.io/zone", "us-west-2b"))
+		})
+
+		It("CreateAccessResources() should mount the requester's SSH public key into the Pod", func() {
+			key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f me@host"
+			sshTmpl := template.DeepCopy()
+			sshTmpl.Spec.MaxPods = 0
+			sshTmpl.Spec.SSHConfig = &v1alpha1.PodSSHConfig{ContainerName: "test", Port: 2022}
+			sshRequest := &v1alpha1.PodAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "createaccessresource-ssh",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.PodAccessRequestSpec{
+					TemplateName: template.GetName(),
+					SSHPublicKey: key,
+				},
+			}
+			err := k8sClient.Create(ctx, sshRequest)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = builder.CreateAccessResources(ctx, k8sClient, sshRequest, sshTmpl)
+			Expect(err).ToNot(HaveOccurred())
+			name := types.NamespacedName{
+				Name:      bldutil.GenerateResourceName(sshRequest),
+				Namespace: ns.GetName(),
+			}
+
+			// VERIFY: The key ends up in a Secret owned by the request
+			secret := &corev1.Secret{}
+			err = k8sClient.Get(ctx, name, secret)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(secret.GetOwnerReferences()).To(HaveLen(1))
+			Expect(string(secret.Data[v1alpha1.SSHAuthorizedKeysFile])).To(Equal(key + "\n"))
+
+			// VERIFY: The Secret is mounted into the container, which exposes SSH
+			foundPod := &corev1.Pod{}
+			err = k8sClient.Get(ctx, name, foundPod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundPod.Spec.Volumes).To(ContainElement(And(
+				HaveField("Name", "oz-ssh-authorized-keys"),
+				HaveField("VolumeSource.Secret.SecretName", secret.GetName()),
+			)))
+			Expect(foundPod.Spec.Containers[0].VolumeMounts).To(ContainElement(And(
+				HaveField("Name", "oz-ssh-authorized-keys"),
+				HaveField("MountPath", v1alpha1.DefaultSSHMountPath),
+				HaveField("ReadOnly", true),
+			)))
+			Expect(foundPod.Spec.Containers[0].Ports).To(ContainElement(And(
+				HaveField("Name", "ssh"),
+				HaveField("ContainerPort", int32(2022)),
+			)))
+			Expect(sshRequest.Status.AccessMessage).To(ContainSubstring("2222:2022"))
+
+			// VERIFY: The Role allows the port-forward to the Pod
+			role := &rbacv1.Role{}
+			err = k8sClient.Get(ctx, name, role)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(role.Rules).To(ContainElement(rbacv1.PolicyRule{
+				APIGroups:     []string{corev1.GroupName},
+				Resources:     []string{"pods/portforward"},
+				ResourceNames: []string{foundPod.GetName()},
+				Verbs:         []string{"create"},
+			}))
+
+			By("Cleaning the Secret up along with the Pod")
+			err = builder.DeleteAccessResources(ctx, k8sClient, sshRequest)
+			Expect(err).ToNot(HaveOccurred())
+			err = k8sClient.Get(ctx, name, &corev1.Secret{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("CreateAccessResources() should refuse an SSH public key the template does not allow", func() {
+			sshRequest := request.DeepCopy()
+			sshRequest.Spec.SSHPublicKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIAABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f"
+			sshRequest.Status.PodName = ""
+
+			_, err := builder.CreateAccessResources(ctx, k8sClient, sshRequest, template)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not allow SSH access"))
+		})
+
+		// newWarmRequest creates a PodAccessRequest against a copy of the
+		// template with preCreatePod set.
+		newWarmRequest := func(name string) (*v1alpha1.PodAccessRequest, *v1alpha1.PodAccessTemplate) {
+			warm := template.DeepCopy()
+			warm.Spec.MaxPods = 0
+			warm.Spec.PreCreatePod = true
+			warmRequest := &v1alpha1.PodAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.PodAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err := k8sClient.Create(ctx, warmRequest)
+			Expect(err).ToNot(HaveOccurred())
+			return warmRequest, warm
+		}
+
+		It("PrepareAccessResources() should do nothing unless spec.preCreatePod is set", func() {
+			cold := template.DeepCopy()
+			cold.Spec.MaxPods = 0
+			ret, err := builder.PrepareAccessResources(ctx, k8sClient, request, cold)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret).To(BeEmpty())
+		})
+
+		It("PrepareAccessResources() should pre-create the Pod, which is granted on approval", func() {
+			warmRequest, warm := newWarmRequest("createaccessresource-warm")
+			key := types.NamespacedName{
+				Name:      bldutil.GenerateResourceName(warmRequest),
+				Namespace: ns.GetName(),
+			}
+
+			By("Preparing the request while it waits on approvals")
+			ret, err := builder.PrepareAccessResources(ctx, k8sClient, warmRequest, warm)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret).To(Equal(fmt.Sprintf("Pod %s pre-created, waiting on approval", key.Name)))
+
+			// VERIFY: The Pod is warm and recorded, but nobody has access to it
+			warmPod := &corev1.Pod{}
+			err = k8sClient.Get(ctx, key, warmPod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(warmRequest.GetPodName()).To(Equal(warmPod.GetName()))
+			Expect(warmRequest.GetPodUID()).To(Equal(warmPod.GetUID()))
+			err = k8sClient.Get(ctx, key, &rbacv1.RoleBinding{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			By("Preparing it again on the next reconcile")
+			_, err = builder.PrepareAccessResources(ctx, k8sClient, warmRequest, warm)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Approving the request")
+			_, err = builder.CreateAccessResources(ctx, k8sClient, warmRequest, warm)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: Access is granted to the very same Pod
+			foundPod := &corev1.Pod{}
+			err = k8sClient.Get(ctx, key, foundPod)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundPod.GetUID()).To(Equal(warmPod.GetUID()))
+			foundRoleBinding := &rbacv1.RoleBinding{}
+			err = k8sClient.Get(ctx, key, foundRoleBinding)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(foundRoleBinding.Subjects[0].Name).To(Equal("testGroupA"))
+		})
+
+		It("DeleteAccessResources() should clean up a pre-created Pod when the request is denied", func() {
+			warmRequest, warm := newWarmRequest("createaccessresource-denied")
+			key := types.NamespacedName{
+				Name:      bldutil.GenerateResourceName(warmRequest),
+				Namespace: ns.GetName(),
+			}
+			_, err := builder.PrepareAccessResources(ctx, k8sClient, warmRequest, warm)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Tearing the request down, as the finalizer does once it is denied or expires")
+			err = builder.DeleteAccessResources(ctx, k8sClient, warmRequest)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The Pod is gone (or going), and no access was ever granted
+			pod := &corev1.Pod{}
+			err = k8sClient.Get(ctx, key, pod)
+			Expect(apierrors.IsNotFound(err) || !pod.GetDeletionTimestamp().IsZero()).To(BeTrue())
+			err = k8sClient.Get(ctx, key, &rbacv1.RoleBinding{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
 	})
 })
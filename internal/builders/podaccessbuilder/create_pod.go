@@ -0,0 +1,85 @@
+package podaccessbuilder
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/builders/podaccessbuilder/internal"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// createPod creates the Pod for the request (or returns it, if it already
+// exists), and records it in the local request status. Writing the status back
+// into the cluster must be handled by the caller.
+func createPod(
+	ctx context.Context,
+	client client.Client,
+	podReq *v1alpha1.PodAccessRequest,
+	podTmpl *v1alpha1.PodAccessTemplate,
+) (*corev1.Pod, error) {
+	log := logf.FromContext(ctx).WithName("createPod")
+
+	// First, get the desired PodSpec. If there's a failure at this point, return it.
+	podTemplateSpec, err := getPodTemplateSpec(ctx, client, podReq, podTmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	// If the template limits the number of concurrent Pods, and this request
+	// does not already have one, make sure there is room for another - and
+	// that it is this request's turn to take it.
+	if maxPods := podTmpl.Spec.MaxPods; maxPods > 0 && podReq.GetPodName() == "" {
+		count, err := internal.CountActivePods(ctx, client, podReq, podTmpl)
+		if err != nil {
+			return nil, err
+		}
+		if count >= int(maxPods) {
+			return nil, fmt.Errorf(
+				"%w: %d of %d pods in use", builders.ErrMaxPodsReached, count, maxPods,
+			)
+		}
+		pos, err := internal.QueuePosition(ctx, client, podReq, podTmpl)
+		if err != nil {
+			return nil, err
+		}
+		if free := int(maxPods) - count; pos >= free {
+			return nil, fmt.Errorf(
+				"%w: %d of %d pods in use, %d requests ahead in the queue",
+				builders.ErrMaxPodsReached, count, maxPods, pos,
+			)
+		}
+	}
+
+	// The Secret with the requester's SSH public key must exist before the
+	// Pod that mounts it.
+	if _, err := createSSHSecret(ctx, client, podReq); err != nil {
+		return nil, err
+	}
+
+	// Generate a Pod for the user to access
+	pod, err := utils.CreatePod(ctx, client, podReq, podTemplateSpec)
+	if err != nil {
+		log.Error(err, "Failed to create Pod for AccessRequest")
+		return nil, err
+	}
+
+	// Set the podName (note, just in the local object). If this fails (for
+	// example, its already set on the object), then we also bail out. This
+	// only fails if the Status.PodName field has already been set, which would
+	// indicate some kind of a reconcile loop conflict.
+	if err := podReq.SetPodName(pod.GetName()); err != nil {
+		return nil, err
+	}
+
+	// Record which Pod the access was granted to. If the Pod has been
+	// recreated by this function, this is the UID of the new Pod.
+	podReq.SetPodUID(pod.GetUID())
+
+	return pod, nil
+}
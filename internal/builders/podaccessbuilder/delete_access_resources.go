@@ -0,0 +1,57 @@
+package podaccessbuilder
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// DeleteAccessResources implements the IBuilder interface.
+//
+// The order of operations here is deliberate. The RoleBinding is removed
+// first, cutting off the user's access. The Role goes next, and only then is
+// the Pod deleted, followed by the SSH Secret. This guarantees there is never a window where the Pod is
+// being torn down while the user can still reach it, or where a new Pod with
+// the same name could be picked up by a stale binding.
+func (b *PodAccessBuilder) DeleteAccessResources(
+	ctx context.Context,
+	client client.Client,
+	req v1alpha1.IRequestResource,
+) error {
+	// Cast the Request into an PodAccessRequest.
+	podReq := req.(*v1alpha1.PodAccessRequest)
+
+	meta := metav1.ObjectMeta{
+		Name:      utils.GenerateResourceName(req),
+		Namespace: req.GetNamespace(),
+	}
+	if err := utils.DeleteResource(ctx, client, &rbacv1.RoleBinding{ObjectMeta: meta}); err != nil {
+		return err
+	}
+	if err := utils.DeleteResource(ctx, client, &rbacv1.Role{ObjectMeta: meta}); err != nil {
+		return err
+	}
+
+	// The Pod name is recorded in the status once it has been created. Fall
+	// back to the generated name in case the status update never made it.
+	podMeta := meta
+	if name := podReq.GetPodName(); name != "" {
+		podMeta.Name = name
+	}
+	if err := utils.DeleteResource(ctx, client, &corev1.Pod{ObjectMeta: podMeta}); err != nil {
+		return err
+	}
+
+	// The Secret holding the requester's SSH public key goes last, once
+	// nothing mounts it anymore.
+	if podReq.Spec.SSHPublicKey == "" {
+		return nil
+	}
+	return utils.DeleteResource(ctx, client, &corev1.Secret{ObjectMeta: meta})
+}
@@ -0,0 +1,159 @@
+package podaccessbuilder
+
+import (
+	"context"
+	"reflect"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	bldutil "github.com/diranged/oz/internal/builders/utils"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+// deleteRecorder wraps a client.Client and records the type of every object
+// passed into Delete(), so that we can verify the order of operations.
+type deleteRecorder struct {
+	client.Client
+	deleted []string
+}
+
+func (c *deleteRecorder) Delete(
+	ctx context.Context,
+	obj client.Object,
+	opts ...client.DeleteOption,
+) error {
+	c.deleted = append(c.deleted, reflect.TypeOf(obj).Elem().Name())
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	Context("DeleteAccessResources()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *corev1.Namespace
+			deployment *appsv1.Deployment
+			request    *v1alpha1.PodAccessRequest
+			template   *v1alpha1.PodAccessTemplate
+			builder    = PodAccessBuilder{}
+		)
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating a Deployment to reference for the test")
+			deployment = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deployment-test",
+					Namespace: ns.Name,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"testLabel": "testValue",
+						},
+					},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{
+								"testLabel": "testValue",
+							},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{
+								{
+									Name:  "test",
+									Image: "nginx:latest",
+								},
+							},
+						},
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, deployment)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Should have an PodAccessTemplate to test against")
+			template = &v1alpha1.PodAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.PodAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"testGroupA"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       deployment.Name,
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an PodAccessRequest with access resources to test against")
+			request = &v1alpha1.PodAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deleteaccessresource-test",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.PodAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = builder.CreateAccessResources(ctx, k8sClient, request, template)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("DeleteAccessResources() should remove the RoleBinding before the Pod", func() {
+			recorder := &deleteRecorder{Client: k8sClient}
+
+			// Execute
+			err := builder.DeleteAccessResources(ctx, recorder, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: Access is cut off first, the Pod goes last
+			Expect(recorder.deleted).To(Equal([]string{"RoleBinding", "Role", "Pod"}))
+
+			// VERIFY: RoleBinding is gone
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      bldutil.GenerateResourceName(request),
+				Namespace: ns.GetName(),
+			}, &rbacv1.RoleBinding{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("DeleteAccessResources() should succeed if resources are already gone", func() {
+			err := builder.DeleteAccessResources(ctx, k8sClient, request)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,84 @@
+package podaccessbuilder
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// getPodTemplateSpec generates the PodTemplateSpec that the access Pod for
+// the request is created from - the target controller's PodTemplateSpec, run
+// through the template's optional mutation config, envFrom, nodeSelector and
+// SSH config.
+func getPodTemplateSpec(
+	ctx context.Context,
+	client client.Client,
+	podReq *v1alpha1.PodAccessRequest,
+	podTmpl *v1alpha1.PodAccessTemplate,
+) (corev1.PodTemplateSpec, error) {
+	log := logf.FromContext(ctx).WithName("getPodTemplateSpec")
+
+	podTemplateSpec, err := utils.GetPodTemplateFromController(ctx, client, podTmpl)
+	if err != nil {
+		log.Error(err, "Failed to generate PodSpec for PodAccessRequest")
+		return podTemplateSpec, err
+	}
+
+	// Resolve the request-time parameter values against the parameters
+	// declared by the template. The webhook rejects bad requests up front, but
+	// we verify again here in case the template has changed since.
+	params, err := v1alpha1.ResolveParameters(
+		podTmpl.Spec.AccessConfig.GetParameters(),
+		podReq.GetParameterValues(),
+	)
+	if err != nil {
+		return podTemplateSpec, err
+	}
+
+	// Run the PodSpec through the optional mutation config
+	mutator := podTmpl.Spec.ControllerTargetMutationConfig
+	if mutator != nil {
+		podTemplateSpec, err = mutator.WithParameters(params).
+			PatchPodTemplateSpec(ctx, podTemplateSpec)
+		if err != nil {
+			log.Error(err, "Failed to mutate PodSpec for PodAccessRequest")
+			return podTemplateSpec, err
+		}
+	}
+
+	// Expose the Secrets and ConfigMaps of the template as environment
+	// variables. The TemplateAuthorWatcher has already checked that only
+	// allowed Secrets are referenced.
+	if envFrom := podTmpl.Spec.AccessConfig.GetEnvFrom(); len(envFrom) > 0 {
+		if mutator == nil {
+			mutator = &v1alpha1.PodTemplateSpecMutationConfig{}
+		}
+		podTemplateSpec, err = mutator.AddEnvFrom(ctx, podTemplateSpec, envFrom)
+		if err != nil {
+			log.Error(err, "Failed to add envFrom to PodSpec for PodAccessRequest")
+			return podTemplateSpec, err
+		}
+	}
+
+	// Schedule the Pod onto the Nodes that the template allows.
+	if nodeSelector := podTmpl.Spec.AccessConfig.GetNodeSelector(); len(nodeSelector) > 0 {
+		if podTemplateSpec.Spec.NodeSelector == nil {
+			podTemplateSpec.Spec.NodeSelector = map[string]string{}
+		}
+		for k, v := range nodeSelector {
+			podTemplateSpec.Spec.NodeSelector[k] = v
+		}
+	}
+
+	// Mount the requester's SSH public key, if they supplied one.
+	if err := applySSHConfig(&podTemplateSpec, podReq, podTmpl); err != nil {
+		return podTemplateSpec, err
+	}
+
+	return podTemplateSpec, nil
+}
@@ -0,0 +1,30 @@
+package podaccessbuilder
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// GetTargetLabels implements the IBuilder interface
+//
+// The Pod does not exist yet, so the labels are those of the PodTemplateSpec
+// that CreateAccessResources() would create the Pod from.
+func (b *PodAccessBuilder) GetTargetLabels(
+	ctx context.Context,
+	client client.Client,
+	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
+) (map[string]string, error) {
+	podTemplateSpec, err := getPodTemplateSpec(
+		ctx, client,
+		req.(*v1alpha1.PodAccessRequest),
+		tmpl.(*v1alpha1.PodAccessTemplate),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return podTemplateSpec.GetLabels(), nil
+}
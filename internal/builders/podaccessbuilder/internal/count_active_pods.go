@@ -0,0 +1,37 @@
+package internal
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// CountActivePods returns the number of PodAccessRequests (other than the
+// supplied request) pointing at the supplied template that currently have a
+// Pod assigned to them. Requests that are being deleted are not counted, as
+// their Pods are on their way out.
+func CountActivePods(
+	ctx context.Context,
+	cl client.Client,
+	req *v1alpha1.PodAccessRequest,
+	tmpl *v1alpha1.PodAccessTemplate,
+) (int, error) {
+	reqList := &v1alpha1.PodAccessRequestList{}
+	if err := cl.List(ctx, reqList, client.InNamespace(tmpl.GetNamespace())); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, r := range reqList.Items {
+		if r.GetName() == req.GetName() ||
+			r.Spec.TemplateName != tmpl.GetName() ||
+			r.GetDeletionTimestamp() != nil ||
+			r.GetPodName() == "" {
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"context"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+)
+
+// QueuePosition returns the position of the supplied request in the queue of
+// PodAccessRequests waiting on a Pod from the supplied template, where 0 means
+// the request is next in line. The queue is fair (see FairOrder), so a single
+// requester cannot hold everyone else back by queueing many requests.
+func QueuePosition(
+	ctx context.Context,
+	cl client.Client,
+	req *v1alpha1.PodAccessRequest,
+	tmpl *v1alpha1.PodAccessTemplate,
+) (int, error) {
+	reqList := &v1alpha1.PodAccessRequestList{}
+	if err := cl.List(ctx, reqList, client.InNamespace(tmpl.GetNamespace())); err != nil {
+		return 0, err
+	}
+	return queuePosition(req, tmpl, reqList.Items), nil
+}
+
+// queuePosition implements QueuePosition against the supplied list of
+// PodAccessRequests. The supplied request is always part of the queue, even
+// if it has not been marked as queued yet.
+func queuePosition(
+	req *v1alpha1.PodAccessRequest,
+	tmpl *v1alpha1.PodAccessTemplate,
+	reqs []v1alpha1.PodAccessRequest,
+) int {
+	held := map[string]int{}
+	waiting := []*v1alpha1.PodAccessRequest{req}
+	for i := range reqs {
+		r := &reqs[i]
+		if r.GetName() == req.GetName() ||
+			r.Spec.TemplateName != tmpl.GetName() ||
+			r.GetDeletionTimestamp() != nil {
+			continue
+		}
+		if r.GetPodName() != "" {
+			held[v1alpha1.GetRequester(r)]++
+			continue
+		}
+		if isQueued(r) {
+			waiting = append(waiting, r)
+		}
+	}
+
+	for pos, r := range FairOrder(waiting, held) {
+		if r.GetName() == req.GetName() {
+			return pos
+		}
+	}
+	return 0
+}
+
+// FairOrder returns the supplied waiting requests in the order that they
+// should be handed Pods: highest priority (see Spec.Priority) first, and
+// within a priority round-robin across their requesters, rather than strictly
+// first-come-first-served. Each requester gets one turn per round, with their
+// oldest request first, and the requesters who already hold Pods (as counted
+// in held) sit out a round per Pod. Within a round the oldest request goes
+// first.
+func FairOrder(
+	waiting []*v1alpha1.PodAccessRequest,
+	held map[string]int,
+) []*v1alpha1.PodAccessRequest {
+	ordered := make([]*v1alpha1.PodAccessRequest, len(waiting))
+	copy(ordered, waiting)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return olderThan(ordered[i], ordered[j])
+	})
+
+	turns := map[string]int{}
+	for requester, count := range held {
+		turns[requester] = count
+	}
+	rounds := map[*v1alpha1.PodAccessRequest]int{}
+	for _, r := range ordered {
+		requester := v1alpha1.GetRequester(r)
+		rounds[r] = turns[requester]
+		turns[requester]++
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		aRank := v1alpha1.PriorityRank(a.GetPriority())
+		bRank := v1alpha1.PriorityRank(b.GetPriority())
+		if aRank != bRank {
+			return aRank > bRank
+		}
+		return rounds[a] < rounds[b]
+	})
+	return ordered
+}
+
+// olderThan returns true if request a was created before request b. Requests
+// created within the same second are ordered by name, so that the order is
+// the same on every reconcile.
+func olderThan(a, b *v1alpha1.PodAccessRequest) bool {
+	aCreated, bCreated := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	if !aCreated.Equal(&bCreated) {
+		return aCreated.Before(&bCreated)
+	}
+	return a.GetName() < b.GetName()
+}
+
+// isQueued returns true if the supplied request is still valid, and is
+// waiting on a Pod from its template.
+func isQueued(req *v1alpha1.PodAccessRequest) bool {
+	if meta.IsStatusConditionFalse(req.Status.Conditions, v1alpha1.ConditionAccessStillValid.String()) {
+		return false
+	}
+	cond := meta.FindStatusCondition(req.Status.Conditions, v1alpha1.ConditionAccessResourcesCreated.String())
+	return cond != nil && cond.Reason == builders.ReasonAccessResourcesQueued
+}
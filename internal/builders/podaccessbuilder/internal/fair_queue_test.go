@@ -0,0 +1,182 @@
+package internal
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+)
+
+var _ = Describe("FairOrder()", func() {
+	start := time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+	tmpl := &v1alpha1.PodAccessTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "tmpl", Namespace: "test"},
+		Spec:       v1alpha1.PodAccessTemplateSpec{MaxPods: 2},
+	}
+
+	// request returns a PodAccessRequest against tmpl from the supplied
+	// user, created the supplied number of minutes after start.
+	request := func(name string, user string, minute int) *v1alpha1.PodAccessRequest {
+		return &v1alpha1.PodAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "test",
+				CreationTimestamp: metav1.NewTime(start.Add(time.Duration(minute) * time.Minute)),
+				Annotations:       map[string]string{v1alpha1.RequestedByAnnotation: user},
+			},
+			Spec: v1alpha1.PodAccessRequestSpec{TemplateName: tmpl.GetName()},
+		}
+	}
+
+	// queued marks the supplied request as waiting on a Pod.
+	queued := func(req *v1alpha1.PodAccessRequest) *v1alpha1.PodAccessRequest {
+		req.Status.Conditions = []metav1.Condition{{
+			Type:   v1alpha1.ConditionAccessResourcesCreated.String(),
+			Status: metav1.ConditionFalse,
+			Reason: builders.ReasonAccessResourcesQueued,
+		}}
+		return req
+	}
+
+	// holding gives the supplied request a Pod.
+	holding := func(req *v1alpha1.PodAccessRequest) *v1alpha1.PodAccessRequest {
+		req.Status.PodName = req.GetName() + "-pod"
+		return req
+	}
+
+	names := func(reqs []*v1alpha1.PodAccessRequest) []string {
+		var out []string
+		for _, r := range reqs {
+			out = append(out, r.GetName())
+		}
+		return out
+	}
+
+	It("Should hand out slots round-robin across requesters, rather than FIFO", func() {
+		waiting := []*v1alpha1.PodAccessRequest{
+			request("alice-1", "alice", 0),
+			request("alice-2", "alice", 1),
+			request("alice-3", "alice", 2),
+			request("bob-1", "bob", 3),
+			request("carol-1", "carol", 4),
+			request("bob-2", "bob", 5),
+		}
+		Expect(names(FairOrder(waiting, nil))).To(Equal([]string{
+			"alice-1", "bob-1", "carol-1", "alice-2", "bob-2", "alice-3",
+		}))
+	})
+
+	It("Should put requesters who already hold Pods behind those who do not", func() {
+		waiting := []*v1alpha1.PodAccessRequest{
+			request("alice-2", "alice", 0),
+			request("bob-1", "bob", 1),
+		}
+		Expect(names(FairOrder(waiting, map[string]int{"alice": 1}))).To(Equal([]string{
+			"bob-1", "alice-2",
+		}))
+	})
+
+	It("Should order requests created at the same time by name", func() {
+		waiting := []*v1alpha1.PodAccessRequest{
+			request("b", "bob", 0),
+			request("a", "alice", 0),
+		}
+		Expect(names(FairOrder(waiting, nil))).To(Equal([]string{"a", "b"}))
+	})
+
+	It("Should hand out slots to higher priority requests first", func() {
+		high := request("bob-1", "bob", 3)
+		high.Spec.Priority = v1alpha1.PriorityHigh
+		critical := request("carol-1", "carol", 4)
+		critical.Spec.Priority = v1alpha1.PriorityCritical
+		low := request("dave-1", "dave", 0)
+		low.Spec.Priority = v1alpha1.PriorityLow
+		waiting := []*v1alpha1.PodAccessRequest{
+			low,
+			request("alice-1", "alice", 1),
+			request("alice-2", "alice", 2),
+			high,
+			critical,
+		}
+		Expect(names(FairOrder(waiting, nil))).To(Equal([]string{
+			"carol-1", "bob-1", "alice-1", "alice-2", "dave-1",
+		}))
+	})
+
+	It("Should take turns across requesters of the same priority", func() {
+		waiting := []*v1alpha1.PodAccessRequest{
+			request("alice-1", "alice", 0),
+			request("alice-2", "alice", 1),
+			request("bob-1", "bob", 2),
+			request("bob-2", "bob", 3),
+		}
+		for _, r := range waiting {
+			r.Spec.Priority = v1alpha1.PriorityHigh
+		}
+		Expect(names(FairOrder(waiting, map[string]int{"alice": 1}))).To(Equal([]string{
+			"bob-1", "alice-1", "bob-2", "alice-2",
+		}))
+	})
+
+	It("queuePosition() should grant the freed slot to a higher priority request", func() {
+		// Both Pods are held, and Alice queued up before Bob raised a
+		// critical request.
+		reqs := []v1alpha1.PodAccessRequest{
+			*holding(request("carol-1", "carol", 0)),
+			*holding(request("dave-1", "dave", 0)),
+			*queued(request("alice-1", "alice", 1)),
+			*queued(request("bob-1", "bob", 2)),
+		}
+		reqs[3].Spec.Priority = v1alpha1.PriorityCritical
+		Expect(queuePosition(&reqs[3], tmpl, reqs)).To(Equal(0))
+		Expect(queuePosition(&reqs[2], tmpl, reqs)).To(Equal(1))
+
+		// Once Carol's Pod is freed up, the one open slot goes to Bob.
+		reqs = reqs[1:]
+		Expect(queuePosition(&reqs[2], tmpl, reqs)).To(BeNumerically("<", 1))
+		Expect(queuePosition(&reqs[1], tmpl, reqs)).To(BeNumerically(">=", 1))
+	})
+
+	It("queuePosition() should not let one requester starve the others", func() {
+		// Alice holds one of the two Pods, and has queued up two more
+		// requests before Bob and Carol asked for one each.
+		reqs := []v1alpha1.PodAccessRequest{
+			*holding(request("alice-1", "alice", 0)),
+			*holding(request("dave-1", "dave", 0)),
+			*queued(request("alice-2", "alice", 1)),
+			*queued(request("alice-3", "alice", 2)),
+			*queued(request("bob-1", "bob", 3)),
+			*queued(request("carol-1", "carol", 4)),
+		}
+
+		Expect(queuePosition(&reqs[4], tmpl, reqs)).To(Equal(0))
+		Expect(queuePosition(&reqs[5], tmpl, reqs)).To(Equal(1))
+		Expect(queuePosition(&reqs[2], tmpl, reqs)).To(Equal(2))
+		Expect(queuePosition(&reqs[3], tmpl, reqs)).To(Equal(3))
+
+		// A brand new request is part of the queue before it is marked
+		// as queued.
+		Expect(queuePosition(request("erin-1", "erin", 5), tmpl, reqs)).To(Equal(2))
+	})
+
+	It("queuePosition() should skip requests that are not waiting on this template", func() {
+		other := queued(request("other-1", "bob", 0))
+		other.Spec.TemplateName = "other"
+		expired := queued(request("expired-1", "carol", 1))
+		expired.Status.Conditions = append(expired.Status.Conditions, metav1.Condition{
+			Type:   v1alpha1.ConditionAccessStillValid.String(),
+			Status: metav1.ConditionFalse,
+		})
+		deleting := queued(request("deleting-1", "dave", 2))
+		now := metav1.NewTime(start)
+		deleting.SetDeletionTimestamp(&now)
+		pending := request("pending-1", "erin", 3)
+
+		reqs := []v1alpha1.PodAccessRequest{*other, *expired, *deleting, *pending}
+		Expect(queuePosition(request("alice-1", "alice", 4), tmpl, reqs)).To(Equal(0))
+	})
+})
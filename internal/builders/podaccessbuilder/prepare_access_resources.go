@@ -0,0 +1,46 @@
+package podaccessbuilder
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// PrepareAccessResources implements the IBuilder interface
+//
+// Templates with preCreatePod set get their Pod created while the request is
+// waiting on approvals, so that it is warm by the time it is approved. No Role
+// or RoleBinding is created here - nobody can reach the Pod until
+// CreateAccessResources() picks it up.
+func (b *PodAccessBuilder) PrepareAccessResources(
+	ctx context.Context,
+	client client.Client,
+	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
+) (string, error) {
+	// Cast the Request into an PodAccessRequest.
+	podReq := req.(*v1alpha1.PodAccessRequest)
+	// Cast the Template into an PodAccessTemplate.
+	podTmpl := tmpl.(*v1alpha1.PodAccessTemplate)
+
+	if !podTmpl.Spec.PreCreatePod {
+		return "", nil
+	}
+
+	prevName, prevUID := podReq.GetPodName(), podReq.GetPodUID()
+	pod, err := createPod(ctx, client, podReq, podTmpl)
+	if err != nil {
+		return "", err
+	}
+
+	// Only write the status back when the Pod is new.
+	if podReq.GetPodName() != prevName || podReq.GetPodUID() != prevUID {
+		if err := client.Status().Update(ctx, podReq); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("Pod %s pre-created, waiting on approval", pod.GetName()), nil
+}
@@ -0,0 +1,128 @@
+package podaccessbuilder
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// sshVolumeName is the name of the Pod volume that the authorized keys Secret
+// is mounted through.
+const sshVolumeName = "oz-ssh-authorized-keys"
+
+// applySSHConfig mounts the authorized keys Secret of the request into the
+// PodTemplateSpec, and exposes the SSH port on the container - if the
+// requester supplied an SSH public key. The Secret itself is created by
+// createSSHSecret().
+func applySSHConfig(
+	podTemplateSpec *corev1.PodTemplateSpec,
+	podReq *v1alpha1.PodAccessRequest,
+	podTmpl *v1alpha1.PodAccessTemplate,
+) error {
+	if podReq.Spec.SSHPublicKey == "" {
+		return nil
+	}
+	cfg := podTmpl.Spec.SSHConfig
+	if cfg == nil {
+		return fmt.Errorf("template %s does not allow SSH access", podTmpl.GetName())
+	}
+
+	containers := podTemplateSpec.Spec.Containers
+	idx := 0
+	if cfg.ContainerName != "" {
+		idx = -1
+		for i := range containers {
+			if containers[i].Name == cfg.ContainerName {
+				idx = i
+			}
+		}
+	}
+	if idx < 0 || idx >= len(containers) {
+		return fmt.Errorf("SSH container %q not found in the Pod", cfg.ContainerName)
+	}
+
+	// The keys are mounted read-only, with the permissions that sshd
+	// insists on for an authorized_keys file.
+	mode := int32(0o600)
+	podTemplateSpec.Spec.Volumes = append(podTemplateSpec.Spec.Volumes, corev1.Volume{
+		Name: sshVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  utils.GenerateResourceName(podReq),
+				DefaultMode: &mode,
+			},
+		},
+	})
+	container := &containers[idx]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      sshVolumeName,
+		MountPath: cfg.GetMountPath(),
+		ReadOnly:  true,
+	})
+	container.Ports = append(container.Ports, corev1.ContainerPort{
+		Name:          "ssh",
+		ContainerPort: cfg.GetPort(),
+		Protocol:      corev1.ProtocolTCP,
+	})
+	return nil
+}
+
+// createSSHSecret creates (or updates) the Secret holding the authorized keys
+// that applySSHConfig() mounts into the Pod. Requests without an SSH public
+// key get no Secret.
+func createSSHSecret(
+	ctx context.Context,
+	client client.Client,
+	podReq *v1alpha1.PodAccessRequest,
+) (*corev1.Secret, error) {
+	if podReq.Spec.SSHPublicKey == "" {
+		return nil, nil
+	}
+	if err := v1alpha1.ValidateSSHPublicKey(podReq.Spec.SSHPublicKey); err != nil {
+		return nil, fmt.Errorf("invalid SSH public key: %w", err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.GenerateResourceName(podReq),
+			Namespace: podReq.GetNamespace(),
+		},
+	}
+	if _, err := ctrlutil.CreateOrUpdate(ctx, client, secret, func() error {
+		secret.Type = corev1.SecretTypeOpaque
+		secret.Data = map[string][]byte{
+			v1alpha1.SSHAuthorizedKeysFile: []byte(podReq.Spec.SSHPublicKey + "\n"),
+		}
+		// Set the ownerRef for the Secret
+		// More info: https://kubernetes.io/docs/concepts/overview/working-with-objects/owners-dependents/
+		return ctrlutil.SetControllerReference(podReq, secret, client.Scheme())
+	}); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// sshAccessRules returns the rules that the requester needs on top of the
+// PodAccessRules to reach SSH on the Pod - which is done through a
+// `kubectl port-forward` - if they supplied an SSH public key.
+func sshAccessRules(podReq *v1alpha1.PodAccessRequest, podName string) []rbacv1.PolicyRule {
+	if podReq.Spec.SSHPublicKey == "" {
+		return nil
+	}
+	return []rbacv1.PolicyRule{
+		{
+			APIGroups:     []string{corev1.GroupName},
+			Resources:     []string{"pods/portforward"},
+			ResourceNames: []string{podName},
+			Verbs:         []string{"create"},
+		},
+	}
+}
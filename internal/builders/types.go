@@ -18,3 +18,67 @@ var ErrRequestDurationTooLong = errors.New(
 
 // ErrRequestExpired indicates that the Access Request has expired
 var ErrRequestExpired = errors.New("access expired")
+
+// ErrMaxPodsReached indicates that the target template already has its maximum
+// number of concurrent Pods running, and the Access Request must wait for one
+// of them to be freed up.
+var ErrMaxPodsReached = errors.New("template maximum pod count reached")
+
+// ErrTargetPodNotFound indicates that the Pod named by the Access Request
+// does not exist (or is not valid for the template). It is terminal - the
+// Access Request cannot be satisfied until the request itself is changed.
+var ErrTargetPodNotFound = errors.New("target pod not found")
+
+// ErrMaxGrantsPerPodReached indicates that the target Pod of the Access
+// Request already has the maximum number of concurrent grants that the
+// template allows, and the Access Request must wait for one of them to expire.
+var ErrMaxGrantsPerPodReached = errors.New("target pod maximum grant count reached")
+
+// ErrNoCandidatePods indicates that no Pod could be found for an Access
+// Request that does not name its own target Pod.
+var ErrNoCandidatePods = errors.New("no candidate pods found")
+
+// ErrPodSelectionPending indicates that no candidate Pod has been found for
+// an Access Request yet, but that it is still within the pod selection
+// timeout of its template. It is retryable - a Pod may show up shortly (eg
+// during a rollout of the target controller).
+var ErrPodSelectionPending = errors.New("waiting for a candidate pod")
+
+// ErrMultipleCandidatePods indicates that several Pods are candidates for an
+// Access Request that does not name its own target Pod, and that the template
+// leaves it to the requester to pick one of them (see
+// v1alpha1.ManualPodSelection).
+var ErrMultipleCandidatePods = errors.New("multiple candidate pods match the template")
+
+// ErrStatusConflict indicates that the status of the Access Request could not
+// be written back because the object was modified in the meantime. It is
+// retryable - the next reconcile starts over from the latest version.
+var ErrStatusConflict = errors.New("access request status conflict")
+
+// kindError tags a detailed error with one of the Err* values above, so that
+// callers can branch on it with errors.Is() without changing its message.
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string { return e.err.Error() }
+
+func (e *kindError) Unwrap() error { return e.err }
+
+func (e *kindError) Is(target error) bool { return target == e.kind }
+
+// WithKind tags err with the supplied kind (one of the Err* values above).
+// The message of err is returned unchanged.
+func WithKind(kind error, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+// ReasonAccessResourcesQueued is the reason set on the
+// ConditionAccessResourcesCreated condition of an Access Request that is
+// waiting on ErrMaxPodsReached or ErrMaxGrantsPerPodReached - ie, the request
+// is in the queue for a Pod.
+const ReasonAccessResourcesQueued = "Queued"
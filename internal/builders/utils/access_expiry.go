@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// StampAccessExpiry sets the ExpiresAtAnnotation on the Role and RoleBinding
+// created for the supplied Access Request to when its access ends. RBAC has no
+// notion of a TTL, so this is what lets `ozctl prune-expired-rbac` remove
+// expired access even while the controller is down. Resources that do not
+// exist (eg, the Role of a template granting a ClusterRole) are skipped, and
+// resources that already carry the right expiry are left alone.
+func StampAccessExpiry(
+	ctx context.Context,
+	cl client.Client,
+	req v1alpha1.IRequestResource,
+	expiresAt time.Time,
+) error {
+	value := expiresAt.UTC().Format(time.RFC3339)
+	key := types.NamespacedName{Name: GenerateResourceName(req), Namespace: req.GetNamespace()}
+	for _, obj := range []client.Object{&rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+		if err := cl.Get(ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if obj.GetAnnotations()[v1alpha1.ExpiresAtAnnotation] == value {
+			continue
+		}
+		patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[v1alpha1.ExpiresAtAnnotation] = value
+		obj.SetAnnotations(annotations)
+		if err := cl.Patch(ctx, obj, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keepAccessExpiry returns the supplied annotations of a Role or RoleBinding
+// that is about to be updated, with the ExpiresAtAnnotation carried over from
+// the existing annotations - so that the update does not strip the expiry set
+// by StampAccessExpiry().
+func keepAccessExpiry(existing, desired map[string]string) map[string]string {
+	value, ok := existing[v1alpha1.ExpiresAtAnnotation]
+	if !ok {
+		return desired
+	}
+	annotations := map[string]string{v1alpha1.ExpiresAtAnnotation: value}
+	for k, v := range desired {
+		annotations[k] = v
+	}
+	return annotations
+}
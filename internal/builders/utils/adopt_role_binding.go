@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// ValidateAdoptableRoleBinding verifies that the supplied RoleBinding is
+// shaped like one that an Access Request can take over: it must bind a Role
+// or ClusterRole to at least one subject, and must not already be managed by
+// another Access Request.
+func ValidateAdoptableRoleBinding(rb *rbacv1.RoleBinding, req v1alpha1.IRequestResource) error {
+	if !rb.GetDeletionTimestamp().IsZero() {
+		return fmt.Errorf("rolebinding %s is being deleted", rb.GetName())
+	}
+	if rb.RoleRef.APIGroup != rbacv1.GroupName ||
+		(rb.RoleRef.Kind != "Role" && rb.RoleRef.Kind != "ClusterRole") {
+		return fmt.Errorf("rolebinding %s has an unsupported roleRef %s %s",
+			rb.GetName(), rb.RoleRef.Kind, rb.RoleRef.Name)
+	}
+	if len(rb.Subjects) == 0 {
+		return fmt.Errorf("rolebinding %s has no subjects", rb.GetName())
+	}
+	for _, ref := range rb.GetOwnerReferences() {
+		gv, _ := schema.ParseGroupVersion(ref.APIVersion)
+		if gv.Group == v1alpha1.GroupVersion.Group && (req == nil || ref.UID != req.GetUID()) {
+			return fmt.Errorf("rolebinding %s is already managed by %s %s",
+				rb.GetName(), ref.Kind, ref.Name)
+		}
+	}
+	return nil
+}
+
+// AdoptRoleBinding takes over the pre-existing RoleBinding named in the
+// AdoptRoleBindingAnnotation of the supplied request. The RoleBinding must
+// name the request in its own AdoptedByAnnotation, and pass
+// ValidateAdoptableRoleBinding(). Once adopted, the request is set as an owner
+// of the RoleBinding. It is safe to call on every reconcile.
+//
+// Returns:
+//   - The adopted RoleBinding
+//   - An "error" if the RoleBinding is missing, or cannot be adopted
+func AdoptRoleBinding(
+	ctx context.Context,
+	cl client.Client,
+	req v1alpha1.IRequestResource,
+) (*rbacv1.RoleBinding, error) {
+	rb := &rbacv1.RoleBinding{}
+	if err := cl.Get(ctx, types.NamespacedName{
+		Name:      v1alpha1.GetAdoptedRoleBinding(req),
+		Namespace: req.GetNamespace(),
+	}, rb); err != nil {
+		return nil, err
+	}
+	if rb.GetAnnotations()[v1alpha1.AdoptedByAnnotation] != req.GetName() {
+		return nil, fmt.Errorf("rolebinding %s has not been marked for adoption by %s (%s annotation)",
+			rb.GetName(), req.GetName(), v1alpha1.AdoptedByAnnotation)
+	}
+	if err := ValidateAdoptableRoleBinding(rb, req); err != nil {
+		return nil, err
+	}
+	if isOwnedBy(rb, req) {
+		return rb, nil
+	}
+
+	logf.FromContext(ctx).Info("Adopting RoleBinding", "name", rb.GetName())
+	if err := ctrlutil.SetOwnerReference(req, rb, cl.Scheme()); err != nil {
+		return nil, err
+	}
+	return rb, cl.Update(ctx, rb)
+}
+
+// DeleteAdoptedRoleBinding deletes the RoleBinding adopted by the supplied
+// request. RoleBindings that are already gone, or that were never actually
+// adopted by the request, are left alone.
+func DeleteAdoptedRoleBinding(
+	ctx context.Context,
+	cl client.Client,
+	req v1alpha1.IRequestResource,
+) error {
+	name := v1alpha1.GetAdoptedRoleBinding(req)
+	if name == "" {
+		return nil
+	}
+	rb := &rbacv1.RoleBinding{}
+	if err := cl.Get(ctx, types.NamespacedName{Name: name, Namespace: req.GetNamespace()}, rb); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !isOwnedBy(rb, req) {
+		return nil
+	}
+	return DeleteResource(ctx, cl, rb)
+}
+
+// isOwnedBy returns true if the owner is listed in the OwnerReferences of obj.
+func isOwnedBy(obj metav1.Object, owner metav1.Object) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID == owner.GetUID() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("IBuilder / Utils", func() {
+	Context("AdoptRoleBinding()", func() {
+		var (
+			ctx     = context.Background()
+			ns      *corev1.Namespace
+			request *api.ExecAccessRequest
+			rb      *rbacv1.RoleBinding
+		)
+
+		newRoleBinding := func(adoptedBy string) *rbacv1.RoleBinding {
+			rb := &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        utils.RandomString(8),
+					Namespace:   ns.GetName(),
+					Annotations: map[string]string{api.AdoptedByAnnotation: adoptedBy},
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: rbacv1.GroupName,
+					Kind:     "ClusterRole",
+					Name:     "view",
+				},
+				Subjects: []rbacv1.Subject{
+					{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "alice"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, rb)).To(Succeed())
+			return rb
+		}
+
+		newRequest := func(roleBinding string) *api.ExecAccessRequest {
+			request := &api.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        utils.RandomString(8),
+					Namespace:   ns.GetName(),
+					Annotations: map[string]string{api.AdoptRoleBindingAnnotation: roleBinding},
+				},
+				Spec: api.ExecAccessRequestSpec{TemplateName: "foo"},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+			return request
+		}
+
+		BeforeEach(func() {
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+			DeferCleanup(k8sClient.Delete, ctx, ns)
+		})
+
+		It("AdoptRoleBinding() should take over a RoleBinding marked for the request", func() {
+			request = newRequest("")
+			rb = newRoleBinding(request.GetName())
+			request.SetAnnotations(map[string]string{api.AdoptRoleBindingAnnotation: rb.GetName()})
+
+			ret, err := AdoptRoleBinding(ctx, k8sClient, request)
+
+			// VERIFY: The request now owns the RoleBinding
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret.GetOwnerReferences()).To(HaveLen(1))
+			Expect(ret.GetOwnerReferences()[0].UID).To(Equal(request.GetUID()))
+
+			By("Adopting it again")
+			_, err = AdoptRoleBinding(ctx, k8sClient, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Refusing to let another request adopt it")
+			other := newRequest(rb.GetName())
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: rb.GetName(), Namespace: rb.GetNamespace()}, rb)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ValidateAdoptableRoleBinding(rb, other)).
+				To(MatchError(ContainSubstring("is already managed by ExecAccessRequest " + request.GetName())))
+
+			By("Deleting it along with the request")
+			Expect(DeleteAdoptedRoleBinding(ctx, k8sClient, request)).To(Succeed())
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: rb.GetName(), Namespace: rb.GetNamespace()}, rb)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("AdoptRoleBinding() should refuse a RoleBinding that is not marked for the request", func() {
+			rb = newRoleBinding("someone-else")
+			request = newRequest(rb.GetName())
+
+			_, err := AdoptRoleBinding(ctx, k8sClient, request)
+
+			// VERIFY: Not adopted
+			Expect(err).To(MatchError(ContainSubstring("has not been marked for adoption")))
+
+			By("Leaving it alone when the request goes away")
+			Expect(DeleteAdoptedRoleBinding(ctx, k8sClient, request)).To(Succeed())
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: rb.GetName(), Namespace: rb.GetNamespace()}, rb)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("ValidateAdoptableRoleBinding() should validate the shape of the RoleBinding", func() {
+			rb := &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "foo"},
+			}
+			Expect(ValidateAdoptableRoleBinding(rb, nil)).
+				To(MatchError("rolebinding test has no subjects"))
+
+			rb.Subjects = []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "devs"}}
+			Expect(ValidateAdoptableRoleBinding(rb, nil)).To(Succeed())
+
+			rb.RoleRef.APIGroup = "example.com"
+			Expect(ValidateAdoptableRoleBinding(rb, nil)).
+				To(MatchError("rolebinding test has an unsupported roleRef Role foo"))
+		})
+	})
+})
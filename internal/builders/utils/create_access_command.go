@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// accessCommandData is the data that the access command templates are
+// rendered with.
+type accessCommandData struct {
+	Namespace string
+	PodName   string
+	Platform  string
+}
+
+// CreateAccessCommand renders the command that the requester runs to use the
+// access granted to them on the named Pod. The command template is selected
+// from the accessCommands of the template by the platform hint of the request
+// (see v1alpha1.AccessConfig.GetAccessCommand()), unless the request asks for
+// one of the accessCommandOverrides of the template instead. An override that
+// the template no longer allows is ignored.
+//
+// Returns:
+//   - The rendered command
+//   - An "error" if the command template is invalid, or fails to render
+func CreateAccessCommand(
+	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
+	podName string,
+) (string, error) {
+	platform := req.GetPlatform()
+	command := tmpl.GetAccessConfig().GetAccessCommand(platform)
+	if override := req.GetAccessCommandOverride(); override != "" &&
+		tmpl.GetAccessConfig().AllowsAccessCommandOverride(override) {
+		command = override
+	}
+
+	t, err := template.New("accessCommand").Option("missingkey=error").Parse(command)
+	if err != nil {
+		return "", fmt.Errorf("invalid access command for platform %q: %w", platform, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, accessCommandData{
+		Namespace: req.GetNamespace(),
+		PodName:   podName,
+		Platform:  platform,
+	}); err != nil {
+		return "", fmt.Errorf("unable to render the access command for platform %q: %w", platform, err)
+	}
+	return buf.String(), nil
+}
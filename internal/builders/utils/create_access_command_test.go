@@ -0,0 +1,100 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("CreateAccessCommand", func() {
+	tmpl := &v1alpha1.ExecAccessTemplate{
+		Spec: v1alpha1.ExecAccessTemplateSpec{
+			AccessConfig: v1alpha1.AccessConfig{
+				AccessCommands: []v1alpha1.AccessCommand{
+					{
+						Platform: "windows",
+						Command:  "kubectl exec -ti -n {{.Namespace}} {{.PodName}} -- powershell.exe",
+					},
+					{
+						Platform: "linux",
+						Command:  "kubectl exec -ti -n {{.Namespace}} {{.PodName}} -- /bin/bash",
+					},
+				},
+			},
+		},
+	}
+
+	// requestOn returns an ExecAccessRequest with the supplied platform hint.
+	requestOn := func(platform string) *v1alpha1.ExecAccessRequest {
+		return &v1alpha1.ExecAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+			Spec:       v1alpha1.ExecAccessRequestSpec{Platform: platform},
+		}
+	}
+
+	It("Should render the command for the platform of the requester", func() {
+		ret, err := CreateAccessCommand(requestOn("windows"), tmpl, "pod-a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ret).To(Equal("kubectl exec -ti -n ns pod-a -- powershell.exe"))
+
+		ret, err = CreateAccessCommand(requestOn("Linux"), tmpl, "pod-a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ret).To(Equal("kubectl exec -ti -n ns pod-a -- /bin/bash"))
+	})
+
+	It("Should fall back to the built-in command for unknown platforms", func() {
+		ret, err := CreateAccessCommand(requestOn("plan9"), tmpl, "pod-a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ret).To(Equal("kubectl exec -ti -n ns pod-a -- /bin/sh"))
+
+		ret, err = CreateAccessCommand(requestOn(""), &v1alpha1.ExecAccessTemplate{}, "pod-a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ret).To(Equal("kubectl exec -ti -n ns pod-a -- /bin/sh"))
+	})
+
+	It("Should fall back to the default entry of the template", func() {
+		withDefault := tmpl.DeepCopy()
+		withDefault.Spec.AccessConfig.AccessCommands = append(withDefault.Spec.AccessConfig.AccessCommands,
+			v1alpha1.AccessCommand{Platform: "default", Command: "oz-connect {{.Namespace}}/{{.PodName}} ({{.Platform}})"})
+
+		ret, err := CreateAccessCommand(requestOn("darwin"), withDefault, "pod-a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ret).To(Equal("oz-connect ns/pod-a (darwin)"))
+	})
+
+	It("Should render an access command override that the template allows", func() {
+		withOverrides := tmpl.DeepCopy()
+		withOverrides.Spec.AccessConfig.AccessCommandOverrides = []string{
+			"kubectl exec -ti -n {{.Namespace}} {{.PodName}} -- /bin/zsh",
+		}
+		req := requestOn("linux")
+		req.Spec.AccessCommandOverride = "kubectl exec -ti -n {{.Namespace}} {{.PodName}} -- /bin/zsh"
+
+		ret, err := CreateAccessCommand(req, withOverrides, "pod-a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ret).To(Equal("kubectl exec -ti -n ns pod-a -- /bin/zsh"))
+	})
+
+	It("Should ignore an access command override that the template does not allow", func() {
+		req := requestOn("linux")
+		req.Spec.AccessCommandOverride = "kubectl exec -ti -n {{.Namespace}} {{.PodName}} -- /bin/zsh"
+
+		ret, err := CreateAccessCommand(req, tmpl, "pod-a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ret).To(Equal("kubectl exec -ti -n ns pod-a -- /bin/bash"))
+	})
+
+	It("Should return an error for an invalid command template", func() {
+		broken := tmpl.DeepCopy()
+		broken.Spec.AccessConfig.AccessCommands[0].Command = "kubectl exec {{.Namespace"
+		_, err := CreateAccessCommand(requestOn("windows"), broken, "pod-a")
+		Expect(err).To(MatchError(ContainSubstring(`invalid access command for platform "windows"`)))
+
+		broken.Spec.AccessConfig.AccessCommands[0].Command = "kubectl exec {{.Container}}"
+		_, err = CreateAccessCommand(requestOn("windows"), broken, "pod-a")
+		Expect(err).To(MatchError(ContainSubstring(`unable to render the access command`)))
+	})
+})
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"context"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// CreateAccessRole returns the RoleRef that the RoleBinding for an Access
+// Request should point to. If the template references an existing ClusterRole
+// through its Spec.accessConfig.clusterRoleRef field, that ClusterRole is used
+// as-is and no Role is created. Otherwise a Role granting the supplied rules
+// is created for the request.
+func CreateAccessRole(
+	ctx context.Context,
+	client client.Client,
+	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
+	rules []rbacv1.PolicyRule,
+) (rbacv1.RoleRef, error) {
+	if name := tmpl.GetAccessConfig().GetClusterRoleRef(); name != "" {
+		return rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     name,
+		}, nil
+	}
+
+	role, err := CreateRole(ctx, client, req, tmpl, rules)
+	if err != nil {
+		return rbacv1.RoleRef{}, err
+	}
+	return rbacv1.RoleRef{
+		APIGroup: rbacv1.GroupName,
+		Kind:     "Role",
+		Name:     role.Name,
+	}, nil
+}
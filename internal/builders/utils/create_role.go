@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"time"
 
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -13,13 +14,19 @@ import (
 
 // CreateRole will create a Kubernetes Role for a specific Access Request with
 // the supplied permissions. The OwnerReference is set to ensure proper
-// cleanup.
+// cleanup. If the template sets syncTemplateMetadata, the Role carries its
+// labels and annotations. The time taken is recorded in the
+// oz_rbac_create_seconds metric.
 func CreateRole(
 	ctx context.Context,
 	client client.Client,
 	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
 	rules []rbacv1.PolicyRule,
-) (*rbacv1.Role, error) {
+) (_ *rbacv1.Role, err error) {
+	start := time.Now()
+	defer func() { observeRBACCreate(start, err) }()
+
 	role := &rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GenerateResourceName(req),
@@ -27,6 +34,7 @@ func CreateRole(
 		},
 		Rules: rules,
 	}
+	role.Labels, role.Annotations = templateMetadata(tmpl)
 
 	// Set the OwnerRef before we try to create the object
 	// More info: https://kubernetes.io/docs/concepts/overview/working-with-objects/owners-dependents/
@@ -43,7 +51,13 @@ func CreateRole(
 
 	// https://pkg.go.dev/sigs.k8s.io/controller-runtime/pkg/controller/controllerutil#CreateOrUpdate
 	if _, err := ctrlutil.CreateOrUpdate(ctx, client, emptyRole, func() error {
-		emptyRole.ObjectMeta = role.ObjectMeta
+		// Only the fields we own are set, so that the metadata of the existing
+		// role (its resourceVersion in particular) is kept, and an unchanged
+		// role is not written again on every reconcile.
+		//
+		// Keep the expiry stamped by StampAccessExpiry(), if any.
+		emptyRole.Labels = role.Labels
+		emptyRole.Annotations = keepAccessExpiry(emptyRole.Annotations, role.Annotations)
 		emptyRole.Rules = role.Rules
 		emptyRole.OwnerReferences = role.OwnerReferences
 		return nil
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"time"
 
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -11,35 +12,29 @@ import (
 	"github.com/diranged/oz/internal/api/v1alpha1"
 )
 
-// CreateRoleBinding will create a RoleBinding to a Role for a set of Groups
-// defined in an Access Template.
+// CreateRoleBinding will create a RoleBinding to a Role (or ClusterRole) for
+// the subjects returned by RoleBindingSubjects(). If the template sets
+// syncTemplateMetadata, the RoleBinding carries its labels and annotations.
+// The time taken is recorded in the oz_rbac_create_seconds metric.
 func CreateRoleBinding(
 	ctx context.Context,
 	client client.Client,
 	req v1alpha1.IRequestResource,
 	tmpl v1alpha1.ITemplateResource,
-	role *rbacv1.Role,
-) (*rbacv1.RoleBinding, error) {
+	roleRef rbacv1.RoleRef,
+) (_ *rbacv1.RoleBinding, err error) {
+	start := time.Now()
+	defer func() { observeRBACCreate(start, err) }()
+
 	rb := &rbacv1.RoleBinding{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      GenerateResourceName(req),
 			Namespace: req.GetNamespace(),
 		},
-		RoleRef: rbacv1.RoleRef{
-			APIGroup: rbacv1.GroupName,
-			Kind:     "Role",
-			Name:     role.Name,
-		},
-		Subjects: []rbacv1.Subject{},
-	}
-
-	for _, group := range tmpl.GetAccessConfig().GetAllowedGroups() {
-		rb.Subjects = append(rb.Subjects, rbacv1.Subject{
-			APIGroup: rbacv1.SchemeGroupVersion.Group,
-			Kind:     rbacv1.GroupKind,
-			Name:     group,
-		})
+		RoleRef:  roleRef,
+		Subjects: RoleBindingSubjects(req, tmpl),
 	}
+	rb.Labels, rb.Annotations = templateMetadata(tmpl)
 
 	// Set the ownerRef for the Deployment
 	// More info: https://kubernetes.io/docs/concepts/overview/working-with-objects/owners-dependents/
@@ -56,7 +51,13 @@ func CreateRoleBinding(
 
 	// https://pkg.go.dev/sigs.k8s.io/controller-runtime/pkg/controller/controllerutil#CreateOrUpdate
 	if _, err := ctrlutil.CreateOrUpdate(ctx, client, emptyRb, func() error {
-		emptyRb.ObjectMeta = rb.ObjectMeta
+		// Only the fields we own are set, so that the metadata of the existing
+		// binding (its resourceVersion in particular) is kept, and an unchanged
+		// binding is not written again on every reconcile.
+		//
+		// Keep the expiry stamped by StampAccessExpiry(), if any.
+		emptyRb.Labels = rb.Labels
+		emptyRb.Annotations = keepAccessExpiry(emptyRb.Annotations, rb.Annotations)
 		emptyRb.RoleRef = rb.RoleRef
 		emptyRb.Subjects = rb.Subjects
 		emptyRb.OwnerReferences = rb.OwnerReferences
@@ -67,3 +68,63 @@ func CreateRoleBinding(
 
 	return rb, nil
 }
+
+// RoleBindingSubjects returns the subjects of the RoleBinding of the supplied
+// request. Only subjects that are allowed every `pods/exec` verb in the Role
+// of the request are bound:
+//
+//   - A request that has been transferred to another user (or made on behalf
+//     of one) is bound to that user alone.
+//   - If the template sets verbsByGroup, the Role grants the verbs of the
+//     groups of the requester - so it is bound to the requester alone.
+//   - Otherwise the groups of the template are bound, leaving out any group
+//     that is allowed fewer verbs than the Role grants.
+func RoleBindingSubjects(req v1alpha1.IRequestResource, tmpl v1alpha1.ITemplateResource) []rbacv1.Subject {
+	if user := grantedUser(req); user != "" {
+		return []rbacv1.Subject{userSubject(user)}
+	}
+
+	cfg := tmpl.GetAccessConfig()
+	if cfg.GetClusterRoleRef() == "" && len(cfg.GetVerbsByGroup()) > 0 {
+		if user := v1alpha1.GetRequester(req); user != "" {
+			return []rbacv1.Subject{userSubject(user)}
+		}
+	}
+
+	verbs := v1alpha1.NarrowExecVerbs(ExecVerbs(req, tmpl), req.GetRequestedVerbs())
+	subjects := []rbacv1.Subject{}
+	for _, group := range cfg.GetAllowedGroups() {
+		if cfg.GetClusterRoleRef() == "" && !containsAll(cfg.GetAllowedExecVerbs([]string{group}), verbs) {
+			continue
+		}
+		subjects = append(subjects, rbacv1.Subject{
+			APIGroup: rbacv1.SchemeGroupVersion.Group,
+			Kind:     rbacv1.GroupKind,
+			Name:     group,
+		})
+	}
+	return subjects
+}
+
+// userSubject returns a RoleBinding subject for the supplied user.
+func userSubject(user string) rbacv1.Subject {
+	return rbacv1.Subject{
+		APIGroup: rbacv1.SchemeGroupVersion.Group,
+		Kind:     rbacv1.UserKind,
+		Name:     user,
+	}
+}
+
+// containsAll returns true if every one of the wanted strings is in the list.
+func containsAll(list []string, wanted []string) bool {
+	have := map[string]bool{}
+	for _, s := range list {
+		have[s] = true
+	}
+	for _, s := range wanted {
+		if !have[s] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("CreateRoleBinding", func() {
+	Context("RoleBindingSubjects()", func() {
+		// requestFrom returns an ExecAccessRequest created by alice, as a
+		// member of the supplied groups.
+		requestFrom := func(groups string) *v1alpha1.ExecAccessRequest {
+			return &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						v1alpha1.RequestedByAnnotation:       "alice",
+						v1alpha1.RequestedByGroupsAnnotation: groups,
+					},
+				},
+			}
+		}
+
+		// templateFor returns an ExecAccessTemplate allowing the supplied
+		// groups, and granting them the supplied verbs.
+		templateFor := func(groups []string, verbsByGroup map[string][]string) *v1alpha1.ExecAccessTemplate {
+			return &v1alpha1.ExecAccessTemplate{
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups: groups,
+						VerbsByGroup:  verbsByGroup,
+					},
+				},
+			}
+		}
+
+		user := func(name string) rbacv1.Subject {
+			return rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.UserKind, Name: name}
+		}
+		group := func(name string) rbacv1.Subject {
+			return rbacv1.Subject{APIGroup: rbacv1.GroupName, Kind: rbacv1.GroupKind, Name: name}
+		}
+
+		It("Should bind every group of a template without verbsByGroup", func() {
+			tmpl := templateFor([]string{"seniors", "juniors"}, nil)
+			Expect(RoleBindingSubjects(requestFrom("seniors"), tmpl)).
+				To(Equal([]rbacv1.Subject{group("seniors"), group("juniors")}))
+		})
+
+		It("Should only bind the requester when groups are granted different verbs", func() {
+			tmpl := templateFor([]string{"seniors", "juniors"}, map[string][]string{
+				"seniors": {"create", "get", "list"},
+				"juniors": {},
+			})
+
+			// The Role grants the verbs of the seniors, which must not
+			// reach the juniors through the binding.
+			req := requestFrom("seniors")
+			Expect(RequesterPodAccessRules(req, tmpl, "pod-a")[1].Verbs).
+				To(Equal([]string{"create", "get", "list"}))
+			Expect(RoleBindingSubjects(req, tmpl)).To(Equal([]rbacv1.Subject{user("alice")}))
+		})
+
+		It("Should leave out groups that are allowed fewer verbs than the Role grants", func() {
+			tmpl := templateFor([]string{"seniors", "juniors", "oncall"}, map[string][]string{
+				"seniors": {"create", "get", "list"},
+				"juniors": {"get"},
+			})
+
+			// Without a known requester, the Role grants the default verbs
+			req := &v1alpha1.ExecAccessRequest{}
+			Expect(RoleBindingSubjects(req, tmpl)).To(Equal([]rbacv1.Subject{group("oncall")}))
+
+			// Narrowing the request with requestedVerbs lets the seniors in
+			req.Spec.RequestedVerbs = []string{"create", "get"}
+			Expect(RoleBindingSubjects(req, tmpl)).
+				To(Equal([]rbacv1.Subject{group("seniors"), group("oncall")}))
+		})
+
+		It("Should bind every group to a ClusterRole, whose verbs are not tuned", func() {
+			tmpl := templateFor([]string{"seniors", "juniors"}, map[string][]string{
+				"juniors": {},
+			})
+			tmpl.Spec.AccessConfig.ClusterRoleRef = "view"
+			Expect(RoleBindingSubjects(&v1alpha1.ExecAccessRequest{}, tmpl)).
+				To(Equal([]rbacv1.Subject{group("seniors"), group("juniors")}))
+		})
+
+		It("Should bind the user that the access was transferred to", func() {
+			tmpl := templateFor([]string{"seniors"}, map[string][]string{"seniors": {"get"}})
+			req := requestFrom("seniors")
+			req.Spec.TransferTo = "bob"
+			Expect(RoleBindingSubjects(req, tmpl)).To(Equal([]rbacv1.Subject{user("bob")}))
+		})
+	})
+})
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DeleteResource deletes the supplied object from the cluster, treating an
+// object that is already gone as a success.
+func DeleteResource(ctx context.Context, client client.Client, obj client.Object) error {
+	logger := logf.FromContext(ctx)
+	logger.V(1).Info("Deleting resource", "name", obj.GetName(), "namespace", obj.GetNamespace())
+	if err := client.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// FilterOwnedPods narrows the supplied list of Pods down to the ones that are
+// actually owned by the target controller of the template, by walking the
+// ownerReferences of each Pod rather than trusting the label selector alone.
+// This protects against an overly broad selector matching Pods that belong to
+// some other workload.
+//
+//   - Deployment: Pods controlled by a ReplicaSet that is controlled by the Deployment
+//   - StatefulSet: Pods controlled by the StatefulSet
+//   - DaemonSet: Pods controlled by the DaemonSet
+//
+// Returns:
+//
+//   - []corev1.Pod: The Pods from the supplied list that are owned by the target controller
+//   - error: If the target controller or its ReplicaSets cannot be found
+func FilterOwnedPods(
+	ctx context.Context,
+	cl client.Client,
+	tmpl v1alpha1.ITemplateResource,
+	pods []corev1.Pod,
+) ([]corev1.Pod, error) {
+	log := logf.FromContext(ctx)
+
+	targetController, err := GetTargetRefResource(ctx, cl, tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build up the set of owners that a Pod is allowed to be controlled by.
+	owners := map[types.UID]bool{}
+	switch kind := targetController.GetObjectKind().GroupVersionKind().Kind; kind {
+	case "Deployment":
+		rsList := &appsv1.ReplicaSetList{}
+		if err := cl.List(ctx, rsList, client.InNamespace(targetController.GetNamespace())); err != nil {
+			return nil, err
+		}
+		for i := range rsList.Items {
+			if metav1.IsControlledBy(&rsList.Items[i], targetController) {
+				owners[rsList.Items[i].GetUID()] = true
+			}
+		}
+		if len(owners) == 0 {
+			return nil, fmt.Errorf(
+				"could not find any replicasets owned by deployment %s",
+				targetController.GetName(),
+			)
+		}
+	case "StatefulSet", "DaemonSet":
+		owners[targetController.GetUID()] = true
+	default:
+		return nil, fmt.Errorf("resolving pods by ownerReference is not supported for %s", kind)
+	}
+
+	owned := []corev1.Pod{}
+	for i := range pods {
+		pod := &pods[i]
+		ref := metav1.GetControllerOf(pod)
+		if ref == nil || !owners[ref.UID] {
+			log.V(1).Info(fmt.Sprintf("Skipping Pod %s, not owned by %s %s",
+				pod.GetName(), tmpl.GetTargetRef().GetKind(), targetController.GetName()))
+			continue
+		}
+		owned = append(owned, *pod)
+	}
+	return owned, nil
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// FilterPodsByNodeSelector narrows the supplied list of Pods down to the ones
+// that are running on a Node carrying all of the supplied labels (for example
+// a topology zone, or a node pool). An empty nodeSelector returns the Pods
+// untouched.
+//
+// Returns:
+//
+//   - []corev1.Pod: The Pods from the supplied list that run on a matching Node
+//   - error: If the Nodes could not be listed
+func FilterPodsByNodeSelector(
+	ctx context.Context,
+	cl client.Client,
+	nodeSelector map[string]string,
+	pods []corev1.Pod,
+) ([]corev1.Pod, error) {
+	if len(nodeSelector) == 0 {
+		return pods, nil
+	}
+	log := logf.FromContext(ctx)
+
+	nodeList := &corev1.NodeList{}
+	if err := cl.List(ctx, nodeList, client.MatchingLabels(nodeSelector)); err != nil {
+		log.Error(err, "Failed to retrieve Node list")
+		return nil, err
+	}
+	nodes := map[string]bool{}
+	for _, node := range nodeList.Items {
+		nodes[node.GetName()] = true
+	}
+
+	matching := []corev1.Pod{}
+	for _, pod := range pods {
+		if nodes[pod.Spec.NodeName] {
+			matching = append(matching, pod)
+		}
+	}
+	return matching, nil
+}
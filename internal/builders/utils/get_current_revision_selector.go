@@ -0,0 +1,136 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+const (
+	// deploymentRevisionAnnotation is written by the Deployment controller
+	// onto both the Deployment and its ReplicaSets.
+	deploymentRevisionAnnotation = "deployment.kubernetes.io/revision"
+
+	// podTemplateHashLabel is written by the Deployment controller onto
+	// each ReplicaSet and the Pods that it manages.
+	podTemplateHashLabel = appsv1.DefaultDeploymentUniqueLabelKey
+
+	// controllerRevisionHashLabel is written by the StatefulSet controller
+	// onto each of the Pods that it manages.
+	controllerRevisionHashLabel = appsv1.ControllerRevisionHashLabelKey
+)
+
+// GetCurrentRevisionSelector behaves like GetSelectorLabels(), but narrows the
+// returned labels.Selector down so that it only matches the Pods that belong
+// to the current revision of the target controller. This is used to make
+// sure that a user debugging a canary rollout lands on a Pod running the new
+// code, rather than one left over from the previous rollout.
+//
+//   - Deployment: Pods whose "pod-template-hash" matches the ReplicaSet of the current revision
+//   - StatefulSet: Pods whose "controller-revision-hash" matches status.updateRevision
+//   - DaemonSet: Not supported
+//
+// Returns:
+//
+//   - labels.Selector: A populated labels.Selector which can be used when searching for Pods
+//   - error
+func GetCurrentRevisionSelector(
+	ctx context.Context,
+	cl client.Client,
+	tmpl v1alpha1.ITemplateResource,
+) (labels.Selector, error) {
+	log := logf.FromContext(ctx)
+
+	selector, err := GetSelectorLabels(ctx, cl, tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	targetController, err := GetTargetRefResource(ctx, cl, tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	var key, hash string
+	switch kind := targetController.GetObjectKind().GroupVersionKind().Kind; kind {
+	case "Deployment":
+		controller, err := getDeployment(ctx, cl, targetController)
+		if err != nil {
+			log.Error(err, "Failed to find target Deployment")
+			return nil, err
+		}
+		key = podTemplateHashLabel
+		hash, err = getCurrentReplicaSetHash(ctx, cl, controller, selector)
+		if err != nil {
+			return nil, err
+		}
+	case "StatefulSet":
+		controller, err := getStatefulSet(ctx, cl, targetController)
+		if err != nil {
+			log.Error(err, "Failed to find target StatefulSet")
+			return nil, err
+		}
+		key = controllerRevisionHashLabel
+		hash = controller.Status.UpdateRevision
+		if hash == "" {
+			return nil, fmt.Errorf("statefulset %s has no current revision yet", controller.GetName())
+		}
+	default:
+		return nil, fmt.Errorf("restricting access to the current revision is not supported for %s", kind)
+	}
+
+	req, err := labels.NewRequirement(key, selection.Equals, []string{hash})
+	if err != nil {
+		return nil, err
+	}
+	log.V(1).Info(fmt.Sprintf("Restricting Pod selection to %s=%s", key, hash))
+	return selector.Add(*req), nil
+}
+
+// getCurrentReplicaSetHash finds the ReplicaSet that belongs to the current
+// revision of the supplied Deployment, and returns its pod-template-hash.
+func getCurrentReplicaSetHash(
+	ctx context.Context,
+	cl client.Client,
+	deployment *appsv1.Deployment,
+	selector labels.Selector,
+) (string, error) {
+	revision := deployment.GetAnnotations()[deploymentRevisionAnnotation]
+	if revision == "" {
+		return "", fmt.Errorf("deployment %s has no current revision yet", deployment.GetName())
+	}
+
+	rsList := &appsv1.ReplicaSetList{}
+	if err := cl.List(ctx, rsList,
+		client.InNamespace(deployment.GetNamespace()),
+		client.MatchingLabelsSelector{Selector: selector},
+	); err != nil {
+		return "", err
+	}
+
+	for i := range rsList.Items {
+		rs := &rsList.Items[i]
+		if !metav1.IsControlledBy(rs, deployment) {
+			continue
+		}
+		if rs.GetAnnotations()[deploymentRevisionAnnotation] != revision {
+			continue
+		}
+		if hash := rs.GetLabels()[podTemplateHashLabel]; hash != "" {
+			return hash, nil
+		}
+	}
+
+	return "", fmt.Errorf(
+		"could not find the replicaset for revision %s of deployment %s",
+		revision, deployment.GetName(),
+	)
+}
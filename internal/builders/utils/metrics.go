@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// rbacCreateSeconds measures how long CreateRole() and CreateRoleBinding()
+// take to create (or update) the RBAC resources for an Access Request. Slow
+// observations here usually point at a slow API server.
+var rbacCreateSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "oz_rbac_create_seconds",
+		Help:    "Time taken to create or update the Roles and RoleBindings for Access Requests",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"result"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(rbacCreateSeconds)
+}
+
+// observeRBACCreate records the time since start in the rbacCreateSeconds
+// histogram, labeled with a "success" or "error" result.
+func observeRBACCreate(start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	rbacCreateSeconds.WithLabelValues(result).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// DefaultExecVerbs are the verbs granted on the `pods/exec` subresource of the
+// target Pod, unless the verbsByGroup of the template says otherwise.
+var DefaultExecVerbs = v1alpha1.DefaultExecVerbs
+
+// PodAccessRules returns the permissions that an access request grants on its
+// target Pod. These rules are shared by the ExecAccessBuilder and the
+// PodAccessBuilder, and are also used by `ozctl explain-template` to describe
+// what a template grants.
+//
+// Returns:
+//
+//	[]rbacv1.PolicyRule: The rules to put into the Role for the access request
+func PodAccessRules(podName string) []rbacv1.PolicyRule {
+	return PodAccessRulesWithVerbs(DefaultExecVerbs, podName)
+}
+
+// RequesterPodAccessRules returns the PodAccessRules for the supplied request,
+// with the `pods/exec` verbs resolved by ExecVerbs(), and narrowed to the
+// requestedVerbs of the request (if any). If the resolved verbs are empty,
+// the `pods/exec` rule is left out entirely - granting read-only access.
+//
+// Returns:
+//
+//	[]rbacv1.PolicyRule: The rules to put into the Role for the access request
+func RequesterPodAccessRules(
+	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
+	podName string,
+) []rbacv1.PolicyRule {
+	verbs := v1alpha1.NarrowExecVerbs(ExecVerbs(req, tmpl), req.GetRequestedVerbs())
+	return PodAccessRulesWithVerbs(verbs, podName)
+}
+
+// ExecVerbs returns the verbs on the `pods/exec` subresource that the
+// template allows the subject of the RoleBinding of the supplied request. The
+// verbs are resolved from the verbsByGroup of the template for the groups
+// recorded for the requester. A request made on behalf of (or transferred
+// to) another user is bound to that user, whose groups are not known - so
+// they are only granted the verbs that the template allows any user.
+func ExecVerbs(req v1alpha1.IRequestResource, tmpl v1alpha1.ITemplateResource) []string {
+	cfg := tmpl.GetAccessConfig()
+	if user := grantedUser(req); user != "" && user != v1alpha1.GetRequester(req) {
+		return cfg.GetCommonExecVerbs()
+	}
+	return cfg.GetAllowedExecVerbs(v1alpha1.GetRequesterGroups(req))
+}
+
+// grantedUser returns the user that the RoleBinding of the supplied request
+// is bound to - the user it was transferred to, or made on behalf of. An
+// empty string means that the access is bound to the groups of the template.
+func grantedUser(req v1alpha1.IRequestResource) string {
+	if user := req.GetTransferTo(); user != "" {
+		return user
+	}
+	return req.GetRequestFor()
+}
+
+// PodAccessRulesWithVerbs returns the PodAccessRules, granting the supplied
+// verbs on the `pods/exec` subresource. If there are none, the `pods/exec`
+// rule is left out entirely.
+func PodAccessRulesWithVerbs(execVerbs []string, podName string) []rbacv1.PolicyRule {
+	rules := []rbacv1.PolicyRule{
+		{
+			APIGroups:     []string{corev1.GroupName},
+			Resources:     []string{"pods"},
+			ResourceNames: []string{podName},
+			Verbs:         []string{"get", "list", "watch"},
+		},
+	}
+	if len(execVerbs) == 0 {
+		return rules
+	}
+	return append(rules, rbacv1.PolicyRule{
+		APIGroups:     []string{corev1.GroupName},
+		Resources:     []string{"pods/exec"},
+		ResourceNames: []string{podName},
+		Verbs:         execVerbs,
+	})
+}
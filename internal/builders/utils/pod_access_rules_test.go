@@ -0,0 +1,118 @@
+package utils
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("PodAccessRules", func() {
+	Context("RequesterPodAccessRules()", func() {
+		tmpl := &v1alpha1.ExecAccessTemplate{
+			Spec: v1alpha1.ExecAccessTemplateSpec{
+				AccessConfig: v1alpha1.AccessConfig{
+					VerbsByGroup: map[string][]string{
+						"seniors":  {"create", "get"},
+						"oncall":   {"create", "update"},
+						"juniors":  {},
+						"unlisted": nil,
+					},
+				},
+			},
+		}
+
+		// requestFrom returns an ExecAccessRequest created by a member of
+		// the supplied groups.
+		requestFrom := func(groups string) *v1alpha1.ExecAccessRequest {
+			return &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						v1alpha1.RequestedByAnnotation:       "alice",
+						v1alpha1.RequestedByGroupsAnnotation: groups,
+					},
+				},
+			}
+		}
+
+		It("Should grant the verbs of the group of the requester", func() {
+			ret := RequesterPodAccessRules(requestFrom("system:authenticated,seniors"), tmpl, "pod-a")
+			Expect(ret).To(HaveLen(2))
+			Expect(ret[0]).To(Equal(PodAccessRules("pod-a")[0]))
+			Expect(ret[1].Resources).To(Equal([]string{"pods/exec"}))
+			Expect(ret[1].ResourceNames).To(Equal([]string{"pod-a"}))
+			Expect(ret[1].Verbs).To(Equal([]string{"create", "get"}))
+		})
+
+		It("Should merge the verbs of every group of the requester", func() {
+			ret := RequesterPodAccessRules(requestFrom("seniors,oncall"), tmpl, "pod-a")
+			Expect(ret[1].Verbs).To(Equal([]string{"create", "get", "update"}))
+		})
+
+		It("Should grant read-only access to a group with no verbs", func() {
+			ret := RequesterPodAccessRules(requestFrom("juniors"), tmpl, "pod-a")
+			Expect(ret).To(Equal(PodAccessRules("pod-a")[:1]))
+			Expect(ret[0].Verbs).To(Equal([]string{"get", "list", "watch"}))
+		})
+
+		It("Should narrow the verbs to the requestedVerbs of the request", func() {
+			req := requestFrom("seniors,oncall")
+			req.Spec.RequestedVerbs = []string{"get", "create"}
+			ret := RequesterPodAccessRules(req, tmpl, "pod-a")
+			Expect(ret[1].Verbs).To(Equal([]string{"create", "get"}))
+
+			// Verbs that the template does not allow are never granted
+			req.Spec.RequestedVerbs = []string{"get", "delete"}
+			ret = RequesterPodAccessRules(req, tmpl, "pod-a")
+			Expect(ret[1].Verbs).To(Equal([]string{"get"}))
+
+			// Narrowing away every verb leaves read-only access
+			req.Spec.RequestedVerbs = []string{"delete"}
+			Expect(RequesterPodAccessRules(req, tmpl, "pod-a")).To(Equal(PodAccessRules("pod-a")[:1]))
+		})
+
+		It("Should fall back to the default verbs for anybody else", func() {
+			Expect(RequesterPodAccessRules(requestFrom("devs"), tmpl, "pod-a")).
+				To(Equal(PodAccessRules("pod-a")))
+			Expect(RequesterPodAccessRules(requestFrom(""), tmpl, "pod-a")).
+				To(Equal(PodAccessRules("pod-a")))
+			Expect(RequesterPodAccessRules(requestFrom("seniors"), &v1alpha1.ExecAccessTemplate{}, "pod-a")).
+				To(Equal(PodAccessRules("pod-a")))
+		})
+
+		It("Should only grant the verbs common to every group to another user", func() {
+			common := &v1alpha1.ExecAccessTemplate{
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						VerbsByGroup: map[string][]string{
+							"seniors": {"create", "get", "list"},
+							"oncall":  {"create", "get", "update"},
+						},
+					},
+				},
+			}
+
+			// A request made on behalf of bob
+			req := requestFrom("seniors")
+			req.Spec.RequestFor = "bob"
+			ret := RequesterPodAccessRules(req, common, "pod-a")
+			Expect(ret[1].Verbs).To(Equal([]string{"create", "get"}))
+
+			// The same request, transferred to carol
+			req.Spec.TransferTo = "carol"
+			ret = RequesterPodAccessRules(req, common, "pod-a")
+			Expect(ret[1].Verbs).To(Equal([]string{"create", "get"}))
+
+			// A group without any verbs leaves other users read-only access
+			Expect(RequesterPodAccessRules(req, tmpl, "pod-a")).To(Equal(PodAccessRules("pod-a")[:1]))
+
+			// Handing the access back to the requester restores their verbs
+			req.Spec.RequestFor = ""
+			req.Spec.TransferTo = "alice"
+			ret = RequesterPodAccessRules(req, common, "pod-a")
+			Expect(ret[1].Verbs).To(Equal([]string{"create", "get", "list"}))
+		})
+	})
+})
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// lastAppliedConfigAnnotation is written by `kubectl apply` onto the
+// templates, and describes the template rather than anything derived from it.
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// templateMetadata returns copies of the labels and annotations of the
+// supplied template that the access resources of its requests should carry -
+// or nil for both, unless the template sets syncTemplateMetadata.
+func templateMetadata(tmpl v1alpha1.ITemplateResource) (map[string]string, map[string]string) {
+	if !tmpl.GetAccessConfig().IsTemplateMetadataSynced() {
+		return nil, nil
+	}
+
+	var labels, annotations map[string]string
+	for k, v := range tmpl.GetLabels() {
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[k] = v
+	}
+	for k, v := range tmpl.GetAnnotations() {
+		if k == lastAppliedConfigAnnotation {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[k] = v
+	}
+	return labels, annotations
+}
@@ -5,10 +5,14 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	api "github.com/diranged/oz/internal/api/v1alpha1"
 	"github.com/diranged/oz/internal/testing/utils"
@@ -135,5 +139,79 @@ var _ = Describe("IBuilder / Utils", Ordered, func() {
 			ret := GenerateResourceName(request)
 			Expect(len(ret)).To(Equal(17))
 		})
+
+		It("CreateRole() and CreateRoleBinding() should record their latency", func() {
+			observations := func(result string) uint64 {
+				m := &dto.Metric{}
+				Expect(rbacCreateSeconds.WithLabelValues(result).(prometheus.Metric).Write(m)).To(Succeed())
+				return m.GetHistogram().GetSampleCount()
+			}
+			successes, failures := observations("success"), observations("error")
+
+			role, err := CreateRole(ctx, k8sClient, request, template, PodAccessRules("test-pod"))
+			Expect(err).To(Not(HaveOccurred()))
+			_, err = CreateRoleBinding(ctx, k8sClient, request, template, rbacv1.RoleRef{
+				APIGroup: rbacv1.SchemeGroupVersion.Group,
+				Kind:     "Role",
+				Name:     role.GetName(),
+			})
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Creating a Role in a Namespace that does not exist")
+			missing := request.DeepCopy()
+			missing.Namespace = "missing"
+			_, err = CreateRole(ctx, k8sClient, missing, template, PodAccessRules("test-pod"))
+			Expect(err).To(HaveOccurred())
+
+			// VERIFY: Every call was observed, labeled by its result
+			Expect(observations("success")).To(Equal(successes + 2))
+			Expect(observations("error")).To(Equal(failures + 1))
+		})
+
+		It("CreateRole() and CreateRoleBinding() should relabel the RBAC when the template metadata changes", func() {
+			// createRBAC creates (or updates) the RBAC of the request, and
+			// returns it as it is stored in the cluster.
+			createRBAC := func() (*rbacv1.Role, *rbacv1.RoleBinding) {
+				roleRef, err := CreateAccessRole(ctx, k8sClient, request, template, PodAccessRules("test-pod"))
+				Expect(err).To(Not(HaveOccurred()))
+				rb, err := CreateRoleBinding(ctx, k8sClient, request, template, roleRef)
+				Expect(err).To(Not(HaveOccurred()))
+
+				role := &rbacv1.Role{}
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(rb), role)).To(Succeed())
+				Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(rb), rb)).To(Succeed())
+				return role, rb
+			}
+
+			By("Leaving the RBAC unlabeled by default")
+			template.SetLabels(map[string]string{"team": "payments"})
+			Expect(k8sClient.Update(ctx, template)).To(Succeed())
+			role, rb := createRBAC()
+			Expect(role.GetLabels()).To(BeEmpty())
+			Expect(rb.GetLabels()).To(BeEmpty())
+
+			By("Copying the template metadata once syncTemplateMetadata is set")
+			template.Spec.AccessConfig.SyncTemplateMetadata = true
+			template.SetAnnotations(map[string]string{
+				"audit.example.com/owner":   "alice",
+				lastAppliedConfigAnnotation: "{}",
+			})
+			Expect(k8sClient.Update(ctx, template)).To(Succeed())
+			role, rb = createRBAC()
+			for _, obj := range []client.Object{role, rb} {
+				Expect(obj.GetLabels()).To(Equal(map[string]string{"team": "payments"}))
+				Expect(obj.GetAnnotations()).To(Equal(map[string]string{"audit.example.com/owner": "alice"}))
+			}
+
+			By("Relabeling the active grant when the template metadata changes")
+			template.SetLabels(map[string]string{"team": "checkout"})
+			template.SetAnnotations(nil)
+			Expect(k8sClient.Update(ctx, template)).To(Succeed())
+			role, rb = createRBAC()
+			for _, obj := range []client.Object{role, rb} {
+				Expect(obj.GetLabels()).To(Equal(map[string]string{"team": "checkout"}))
+				Expect(obj.GetAnnotations()).To(BeEmpty())
+			}
+		})
 	})
 })
@@ -19,35 +19,58 @@ package manager
 
 import (
 	"context"
+	"crypto/sha256"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	uzap "go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
 	crdsv1alpha1 "github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/audit"
 	"github.com/diranged/oz/internal/builders/execaccessbuilder"
 	"github.com/diranged/oz/internal/builders/podaccessbuilder"
 	"github.com/diranged/oz/internal/controllers/podwatcher"
 	"github.com/diranged/oz/internal/controllers/requestcontroller"
 	"github.com/diranged/oz/internal/controllers/templatecontroller"
+	"github.com/diranged/oz/internal/controllers/templatewatcher"
+	"github.com/diranged/oz/internal/externalapproval"
+	"github.com/diranged/oz/internal/granttoken"
+	"github.com/diranged/oz/internal/httpauth"
+	"github.com/diranged/oz/internal/logswitch"
+	"github.com/diranged/oz/internal/notify"
+	"github.com/diranged/oz/internal/opstate"
+	"github.com/diranged/oz/internal/version"
 	//+kubebuilder:scaffold:imports
 )
 
 const (
 	defaultReconciliationInterval = 5
+	defaultSyncPeriod             = 10 * time.Hour
+	defaultPodSelectionTimeout    = 30 * time.Second
+	defaultClientQPS              = 20
+	defaultClientBurst            = 30
 	metricsPort                   = 9443
 	controllerKey                 = "controller"
 	unableToCreateMsg             = "unable to create controller"
+	defaultLeaderElectionID       = "9b20101a.wizardofoz.co"
 )
 
 var (
@@ -71,6 +94,38 @@ func Main() {
 	var enableLeaderElection bool
 	var requestReconciliationInterval int
 	var templateReconciliationInterval int
+	var syncPeriod time.Duration
+	var absoluteMaxDuration time.Duration
+	var dailyGrantBudget time.Duration
+	var podSelectionTimeout time.Duration
+	var stateNamespace string
+	var enableWhatIf bool
+	var enableLogAdmin bool
+	var clientQPS float64
+	var clientBurst int
+	var auditRedactPatterns []string
+	var auditRedactFields []string
+	var auditBackend auditBackendConfig
+	var auditShipperOpts audit.ShipperOptions
+	var notifyConfig notifierConfig
+	var grantTokenConfig grantTokenConfig
+	var labelSelectorStr string
+	var templateAuthors crdsv1alpha1.AllowedRequesters
+	var allowedEnvFromSecrets []string
+	var bindableClusterRoles []string
+	var lastActiveWriters []string
+	var maintenanceMode bool
+	var maintenanceDrainGracePeriod time.Duration
+	var verifyAccessEffective bool
+	var stampRBACExpiry bool
+	var detectSharedPods bool
+	onTemplateDeleted := requestcontroller.RevokeOnTemplateDeleted
+	var approvalSLO time.Duration
+	finalizerName := v1alpha1.RequestFinalizer
+	var previousFinalizerNames []string
+	var disableFinalizer bool
+	var clockSkewTolerance time.Duration
+	var externalApprovalTimeout time.Duration
 
 	// Boilerplate
 	flag.StringVar(
@@ -102,6 +157,351 @@ func Main() {
 		defaultReconciliationInterval,
 		"Access Template reconciliation interval (in minutes)",
 	)
+	flag.DurationVar(
+		&syncPeriod,
+		"sync-period",
+		defaultSyncPeriod,
+		"Minimum frequency at which every watched resource is resynced and reconciled. Shorter "+
+			"periods catch expiration and drift faster, at the cost of more load on the API.",
+	)
+	flag.DurationVar(
+		&absoluteMaxDuration,
+		"absolute-max-duration",
+		0,
+		"Absolute maximum duration of any Access Request. Requests are clamped to it regardless "+
+			"of the template maxDuration. Set to 0 to disable the ceiling. The OzConfig of a "+
+			"namespace may replace it for that namespace.",
+	)
+	flag.DurationVar(
+		&dailyGrantBudget,
+		"daily-grant-budget",
+		0,
+		"Maximum access granted to each user per day (in UTC), summed across all of their Access "+
+			"Requests (including the ones waiting to be granted). Requests are clamped to what is left "+
+			"of the budget, and denied once it is used up. Granted access is only extended while there "+
+			"is budget left. The access granted is recorded in the operational state (see "+
+			"--state-namespace), so that deleting a request does not refund it. Set to 0 to disable "+
+			"the budget.",
+	)
+	flag.DurationVar(
+		&podSelectionTimeout,
+		"pod-selection-timeout",
+		defaultPodSelectionTimeout,
+		"How long an ExecAccessRequest waits for a candidate Pod to show up (eg during a rollout) "+
+			"before it fails, for templates that do not set a podSelectionTimeout. Set to 0 to "+
+			"fail right away.",
+	)
+	flag.StringVar(
+		&stateNamespace,
+		"state-namespace",
+		opstate.DefaultNamespace(),
+		"Namespace that the operational state of the controller (eg, the Pod usage of the "+
+			"leastRecentlyUsed podSelectionStrategy, or the access counted against the "+
+			"--daily-grant-budget) is stored in, in ConfigMaps. The controller "+
+			"must be allowed to get, create and update ConfigMaps there. Defaults to the namespace "+
+			"the controller runs in.",
+	)
+	flag.BoolVar(
+		&enableWhatIf,
+		"enable-what-if-endpoint",
+		false,
+		"Serve the /whatif/execaccessrequest and /whatif/podaccessrequest endpoints on the "+
+			"--metrics-bind-address. They predict the decision for a hypothetical request, "+
+			"template and requester without creating anything. Callers must present a bearer "+
+			"token for a user allowed to \"post\" the path as a nonResourceURL.",
+	)
+	flag.BoolVar(
+		&enableLogAdmin,
+		"enable-log-admin-endpoint",
+		false,
+		"Serve the /admin/log endpoint on the --metrics-bind-address. A GET returns the current "+
+			"log level and format, and a PUT of {\"level\": ..., \"format\": ...} changes them "+
+			"without a restart. The --zap-log-level and --zap-encoder flags set the initial values. "+
+			"Audit records are always logged. Callers must present a bearer token for a user "+
+			"allowed to \"get\" and \"put\" the path as a nonResourceURL.",
+	)
+	flag.Float64Var(
+		&clientQPS,
+		"client-qps",
+		defaultClientQPS,
+		"Sustained queries per second allowed from the controller to the Kubernetes API. "+
+			"Values between 20 and 200 are safe for most clusters - raise it on large clusters "+
+			"where reconciles are throttled, but stay within the API Priority and Fairness limits.",
+	)
+	flag.IntVar(
+		&clientBurst,
+		"client-burst",
+		defaultClientBurst,
+		"Maximum burst of queries allowed from the controller to the Kubernetes API. "+
+			"Should be greater than or equal to --client-qps, typically 1.5x to 2x its value.",
+	)
+	flag.StringVar(
+		&labelSelectorStr,
+		"label-selector",
+		"",
+		"Optional label selector (eg \"shard=a\") limiting the Access Requests and Access Templates "+
+			"that this controller reconciles, so that they can be sharded across several controller "+
+			"deployments. Each shard also gets its own leader election lock.",
+	)
+	flag.BoolVar(
+		&verifyAccessEffective,
+		"verify-access-effective",
+		false,
+		"Once the access of an Access Request is ready, check with a SubjectAccessReview that its "+
+			"subject may actually exec into the target Pod, and record the answer in the "+
+			"AccessEffective condition. This surfaces other authorizers in the cluster that "+
+			"conflict with the access granted.",
+	)
+	flag.BoolVar(
+		&stampRBACExpiry,
+		"stamp-rbac-expiry",
+		false,
+		"Stamp the Roles and RoleBindings created for each Access Request with the "+
+			"crds.wizardofoz.co/expires-at annotation. RBAC has no TTL of its own - run "+
+			"`ozctl prune-expired-rbac` on a schedule (eg a CronJob) to remove expired access "+
+			"even while the controller is down.",
+	)
+	flag.BoolVar(
+		&detectSharedPods,
+		"detect-shared-pods",
+		false,
+		"Report on each Access Request whether other active requests were assigned the same "+
+			"target Pod (the TargetPodExclusive condition), and count them in the "+
+			"oz_access_shared_pod_assignments_total metric.",
+	)
+	flag.Func(
+		"on-template-deleted",
+		"What happens to the access granted by an Access Request when its template is deleted. "+
+			"One of: \"revoke\" (revoke the access right away), \"keep\" (let the access ride out "+
+			"its duration). Defaults to \"revoke\".",
+		func(s string) (err error) {
+			onTemplateDeleted, err = requestcontroller.ParseTemplateDeletedPolicy(s)
+			return err
+		},
+	)
+	flag.DurationVar(
+		&approvalSLO,
+		"approval-slo",
+		0,
+		"How long Access Requests may wait on approval before they are flagged as breaching the "+
+			"approval SLO (the ApprovalWithinSLO condition, an ApprovalSLOBreached Event and the "+
+			"oz_access_approval_slo_breaches_total metric). Set to 0 to disable the check.",
+	)
+	flag.Func(
+		"finalizer-name",
+		fmt.Sprintf("Name of the finalizer placed on each Access Request, which holds it in place on "+
+			"deletion until its access resources have been torn down. Defaults to %q.",
+			v1alpha1.RequestFinalizer),
+		func(s string) error {
+			finalizerName = s
+			return requestcontroller.ValidateFinalizerName(s)
+		},
+	)
+	flag.Func(
+		"previous-finalizer-name",
+		fmt.Sprintf("Name that --finalizer-name was set to before (may be repeated). Requests still "+
+			"carrying it have their access resources torn down and the finalizer released on deletion, "+
+			"like those carrying the current name or %q.", v1alpha1.RequestFinalizer),
+		func(s string) error {
+			previousFinalizerNames = append(previousFinalizerNames, s)
+			return requestcontroller.ValidateFinalizerName(s)
+		},
+	)
+	flag.BoolVar(
+		&disableFinalizer,
+		"disable-finalizer",
+		false,
+		"Do not place a finalizer on the Access Requests. Expired requests still have their access "+
+			"resources torn down before they are deleted, but the resources of requests deleted by "+
+			"hand are left to the OwnerReference garbage collection.",
+	)
+	flag.DurationVar(
+		&clockSkewTolerance,
+		"clock-skew-tolerance",
+		0,
+		"Measure the skew of the controller clock against the API server (from the timestamps that "+
+			"it stamps onto the Access Requests), and correct the expiration of access for any skew "+
+			"beyond this tolerance - so that a replica with a skewed clock, eg after a leader change, "+
+			"neither expires access early nor keeps it for too long. The skew is logged and reported "+
+			"in the oz_controller_clock_skew_seconds metric. Set to 0 to disable the detection.",
+	)
+	flag.DurationVar(
+		&externalApprovalTimeout,
+		"external-approval-timeout",
+		externalapproval.DefaultTimeout,
+		"How long to wait for the approval-status endpoint of a template with an externalApproval "+
+			"to answer, before the check fails and is retried.",
+	)
+	flag.BoolVar(
+		&maintenanceMode,
+		"maintenance-mode",
+		false,
+		"Put the controller into maintenance mode for a controlled shutdown or upgrade: Access "+
+			"Requests that have not been granted yet are denied. See --maintenance-drain-grace-period.",
+	)
+	flag.DurationVar(
+		&maintenanceDrainGracePeriod,
+		"maintenance-drain-grace-period",
+		0,
+		"In --maintenance-mode, end all of the access already granted this long after the "+
+			"controller starts, so that no elevated access lingers during the maintenance. "+
+			"Requesters are warned like they are about any other expiring access. Set to 0 to "+
+			"leave granted access alone.",
+	)
+	flag.Func(
+		"template-author",
+		"User that may create and update Access Templates (may be repeated). If neither this nor "+
+			"--template-author-group is set, writing templates is only limited by RBAC.",
+		func(s string) error {
+			templateAuthors.Users = append(templateAuthors.Users, s)
+			return nil
+		},
+	)
+	flag.Func(
+		"template-author-group",
+		"Group whose members may create and update Access Templates (may be repeated)",
+		func(s string) error {
+			templateAuthors.Groups = append(templateAuthors.Groups, s)
+			return nil
+		},
+	)
+	flag.Func(
+		"allowed-env-from-secret",
+		"Name of a Secret that Access Templates may expose to the Pods they launch through their "+
+			"spec.accessConfig.envFrom (may be repeated). Templates referencing any other Secret "+
+			"are rejected.",
+		func(s string) error {
+			allowedEnvFromSecrets = append(allowedEnvFromSecrets, s)
+			return nil
+		},
+	)
+	flag.Func(
+		"bindable-cluster-role",
+		"Name of a ClusterRole that Access Templates may grant the permissions of through their "+
+			"spec.accessConfig.clusterRoleRef (may be repeated). Templates referencing any other "+
+			"ClusterRole are rejected. The controller must also be allowed to bind it.",
+		func(s string) error {
+			bindableClusterRoles = append(bindableClusterRoles, s)
+			return nil
+		},
+	)
+	flag.Func(
+		"last-active-writer",
+		"User that may write the crds.wizardofoz.co/last-active annotation of Access Requests "+
+			"(may be repeated), such as the service account of the controller itself, or of a "+
+			"heartbeat. Writes by any other user are rejected, and without the controller's own "+
+			"service account the idleTimeout of templates never sees any activity.",
+		func(s string) error {
+			lastActiveWriters = append(lastActiveWriters, s)
+			return nil
+		},
+	)
+	flag.Func(
+		"audit-redact-pattern",
+		"Regular expression matching sensitive data to redact from audit logs (may be repeated)",
+		func(s string) error {
+			auditRedactPatterns = append(auditRedactPatterns, s)
+			return nil
+		},
+	)
+	flag.Func(
+		"audit-redact-field",
+		"Name of an audit log field whose value is always redacted (may be repeated)",
+		func(s string) error {
+			auditRedactFields = append(auditRedactFields, s)
+			return nil
+		},
+	)
+
+	flag.StringVar(
+		&auditBackend.kind,
+		"audit-backend",
+		"",
+		"Optional backend that audit records are also shipped to for long-term retention. "+
+			"One of: \"s3\", \"kafka\". Disabled by default.",
+	)
+	flag.StringVar(
+		&auditBackend.s3Endpoint,
+		"audit-s3-endpoint",
+		"",
+		"Base URL of the S3-compatible object store (defaults to AWS S3 in --audit-s3-region). "+
+			"Credentials are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.",
+	)
+	flag.StringVar(&auditBackend.s3Bucket, "audit-s3-bucket", "", "Bucket that audit records are written to")
+	flag.StringVar(&auditBackend.s3Prefix, "audit-s3-prefix", "oz-audit", "Key prefix for audit record objects")
+	flag.StringVar(
+		&auditBackend.s3Region,
+		"audit-s3-region",
+		"",
+		"Region used to sign S3 requests (defaults to AWS_REGION, then us-east-1)",
+	)
+	flag.StringVar(
+		&auditBackend.kafkaRESTURL,
+		"audit-kafka-rest-url",
+		"",
+		"Base URL of the Kafka REST Proxy that audit records are produced through",
+	)
+	flag.StringVar(&auditBackend.kafkaTopic, "audit-kafka-topic", "", "Kafka topic that audit records are produced to")
+	flag.IntVar(
+		&auditShipperOpts.BatchSize,
+		"audit-batch-size",
+		audit.DefaultBatchSize,
+		"Maximum number of audit records written to the --audit-backend at once",
+	)
+	flag.DurationVar(
+		&auditShipperOpts.FlushInterval,
+		"audit-flush-interval",
+		audit.DefaultFlushInterval,
+		"Maximum time an audit record is buffered before being written to the --audit-backend",
+	)
+	flag.StringVar(
+		&notifyConfig.webhookURL,
+		"notify-webhook-url",
+		"",
+		"Optional URL that notifications to requesters (access granted, denied, about to expire "+
+			"or revoked) are POSTed to as JSON, for delivery to each requester's destination",
+	)
+	flag.StringVar(
+		&notifyConfig.destinationsFile,
+		"notify-destinations-file",
+		"",
+		"YAML file mapping Kubernetes usernames to their notification destinations (eg an email "+
+			"address or Slack member ID). Requesters without one may set the "+
+			v1alpha1.NotifyDestinationAnnotation+" annotation on their requests.",
+	)
+	flag.DurationVar(
+		&notifyConfig.expiringWithin,
+		"notify-expiring-within",
+		notify.DefaultExpiringWithin,
+		"How long before a granted Access Request expires that its requester is warned. "+
+			"Requests are only checked every --request-reconciliation-interval.",
+	)
+
+	flag.Func(
+		"grant-token-key-file",
+		"PEM encoded RSA or ECDSA private key file used to sign grant tokens into the status of "+
+			"granted Access Requests (may be repeated). The first key signs new tokens, the others "+
+			"are only published for verification - to rotate keys, add the new key last, then move "+
+			"it first, then drop the old key once its tokens have expired.",
+		func(s string) error {
+			grantTokenConfig.keyFiles = append(grantTokenConfig.keyFiles, s)
+			return nil
+		},
+	)
+	flag.StringVar(
+		&grantTokenConfig.issuer,
+		"grant-token-issuer",
+		granttoken.DefaultIssuer,
+		"The \"iss\" claim of grant tokens",
+	)
+	flag.Func(
+		"grant-token-audience",
+		"An \"aud\" claim of grant tokens (may be repeated)",
+		func(s string) error {
+			grantTokenConfig.audience = append(grantTokenConfig.audience, s)
+			return nil
+		},
+	)
 
 	// Reconfigure the default logger. Get rid of the JSON log and switch to a LogFmt logger
 	// configLog := uzap.NewProductionEncoderConfig()
@@ -119,33 +519,127 @@ func Main() {
 	// Finish the logger setup - mostly boilerplate below
 	opts.BindFlags(flag.CommandLine)
 	flag.Parse()
+
+	// The audit records are written through a logger of their own, at a fixed level,
+	// so that turning the log level down at runtime never silences them.
+	auditOpts := opts
+	auditOpts.ZapOpts = append([]uzap.Option{}, opts.ZapOpts...)
+	auditOpts.Level = zapcore.InfoLevel
+
+	// Write the logs through a logswitch.Switch, so that their level and format can be
+	// changed at runtime.
+	logSwitch, err := newLogSwitch(&opts, flag.CommandLine.Lookup("zap-encoder").Value.String())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to configure logging: %s\n", err)
+		os.Exit(1)
+	}
 	rootLogger := zap.New(zap.UseFlagOptions(&opts))
+
+	// If an audit backend is configured, the audit records are also shipped to
+	// it by teeing them off of the root logger.
+	var auditShipper *audit.Shipper
+	auditBackendImpl, auditBackendErr := newAuditBackend(auditBackend, os.Getenv)
+	if auditBackendImpl != nil {
+		auditShipper = audit.NewShipper(auditBackendImpl, auditShipperOpts, rootLogger)
+	}
+	rootLogger = audit.NewAuditLogger(rootLogger, zap.New(zap.UseFlagOptions(&auditOpts)), auditShipper)
 	ctrl.SetLogger(rootLogger)
+	if auditBackendErr != nil {
+		setupLog.Error(auditBackendErr, "unable to configure the audit backend")
+		os.Exit(1)
+	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		MetricsBindAddress:     metricsAddr,
-		Port:                   metricsPort,
-		HealthProbeBindAddress: probeAddr,
+	// Configure the redaction rules applied to the audit logs before anything
+	// else gets a chance to write one.
+	redactor, err := audit.NewRedactor(auditRedactPatterns, auditRedactFields)
+	if err != nil {
+		setupLog.Error(err, "unable to configure audit log redaction")
+		os.Exit(1)
+	}
+	audit.SetDefaultRedactor(redactor)
 
-		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
-		// when the Manager ends. This requires the binary to immediately end when the
-		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
-		// speeds up voluntary leader transitions as the new leader don't have to wait
-		// LeaseDuration time first.
-		LeaderElection:                enableLeaderElection,
-		LeaderElectionID:              "9b20101a.wizardofoz.co",
-		LeaderElectionReleaseOnCancel: true,
-	})
+	if syncPeriod <= 0 {
+		setupLog.Error(fmt.Errorf("got %s", syncPeriod), "--sync-period must be greater than zero")
+		os.Exit(1)
+	}
+
+	requesterNotifier, err := newRequesterNotifier(notifyConfig, os.ReadFile)
+	if err != nil {
+		setupLog.Error(err, "unable to configure requester notifications")
+		os.Exit(1)
+	}
+	if requesterNotifier != nil {
+		setupLog.Info("notifying requesters", "notifier", requesterNotifier.Notifier.Name())
+	}
+
+	grantTokenSigner, err := newGrantTokenSigner(grantTokenConfig, os.ReadFile)
+	if err != nil {
+		setupLog.Error(err, "unable to configure grant tokens")
+		os.Exit(1)
+	}
+	if grantTokenSigner != nil {
+		setupLog.Info("issuing grant tokens", "kid", grantTokenSigner.KeyID())
+	}
+
+	labelSelector, err := parseLabelSelector(labelSelectorStr)
+	if err != nil {
+		setupLog.Error(err, "unable to parse --label-selector")
+		os.Exit(1)
+	}
+	if labelSelector != nil {
+		setupLog.Info("only reconciling matching resources", "labelSelector", labelSelector.String())
+	}
+
+	maintenance, err := newMaintenanceMode(maintenanceMode, maintenanceDrainGracePeriod, time.Now())
+	if err != nil {
+		setupLog.Error(err, "unable to configure maintenance mode")
+		os.Exit(1)
+	}
+
+	if clientQPS <= 0 || clientBurst <= 0 {
+		setupLog.Error(
+			fmt.Errorf("got qps=%v burst=%d", clientQPS, clientBurst),
+			"--client-qps and --client-burst must be greater than zero",
+		)
+		os.Exit(1)
+	}
+
+	mgrOpts := managerOptions(metricsAddr, probeAddr, enableLeaderElection, syncPeriod)
+	mgrOpts.LeaderElectionID = leaderElectionID(labelSelector)
+	mgr, err := ctrl.NewManager(
+		restConfig(ctrl.GetConfigOrDie(), clientQPS, clientBurst),
+		mgrOpts,
+	)
 	if err != nil {
 		setupLog.Error(err, unableToCreateMsg)
 		os.Exit(1)
 	}
 
+	// The operational state is read and written around the cache of the
+	// manager, which would otherwise watch every ConfigMap in the cluster.
+	stateClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+	if err != nil {
+		setupLog.Error(err, "unable to create the operational state client")
+		os.Exit(1)
+	}
+	stateStore := &opstate.Store{Client: stateClient, Namespace: stateNamespace}
+	setupLog.Info("storing operational state", "namespace", stateNamespace)
+
+	// The audit shipper is run by the manager so that any buffered records
+	// are flushed when it shuts down.
+	if auditShipper != nil {
+		if err := mgr.Add(auditShipper); err != nil {
+			setupLog.Error(err, "unable to set up the audit shipper")
+			os.Exit(1)
+		}
+		setupLog.Info("shipping audit records", "backend", auditBackendImpl.Name())
+	}
+
 	// Webhooks for our core CRDs are registered through the api/v1alpha1
 	// package. These webhooks are registered so that we can pre-populate (or
 	// validate) our custom resources before they ever get to the Reconcile()
 	// functions.
+	crdsv1alpha1.LastActiveWriters = lastActiveWriters
 	if err = (&crdsv1alpha1.PodAccessRequest{}).SetupWebhookWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create webhook", "webhook", "PodAccessRequest")
 		os.Exit(1)
@@ -162,6 +656,14 @@ func Main() {
 		"/watch-v1-pod",
 		&webhook.Admission{Handler: &podwatcher.PodExecWatcher{Client: mgr.GetClient()}},
 	)
+	hookServer.Register(
+		"/validate-crds-wizardofoz-co-v1alpha1-accesstemplate",
+		&webhook.Admission{Handler: &templatewatcher.TemplateAuthorWatcher{
+			Authors:               newTemplateAuthors(templateAuthors),
+			AllowedEnvFromSecrets: allowedEnvFromSecrets,
+			BindableClusterRoles:  bindableClusterRoles,
+		}},
+	)
 
 	// Provide a searchable index in the cached kubernetes client for "metadata.name" - the pod name.
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, v1alpha1.FieldSelectorMetadataName, func(rawObj client.Object) []string {
@@ -184,6 +686,31 @@ func Main() {
 		panic(err)
 	}
 
+	// Provide a searchable index in the cached kubernetes client for "spec.nodeName", allowing us to
+	// search for Pods running on a specific Node.
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, v1alpha1.FieldSelectorSpecNodeName, func(rawObj client.Object) []string {
+		// grab the job object, extract the node name...
+		pod := rawObj.(*corev1.Pod)
+		return []string{pod.Spec.NodeName}
+	}); err != nil {
+		panic(err)
+	}
+
+	// Provide a searchable index in the cached kubernetes client for "status.podName" on the Access
+	// Requests, allowing us to search for the requests that were assigned a specific Pod.
+	for _, obj := range []client.Object{&v1alpha1.ExecAccessRequest{}, &v1alpha1.PodAccessRequest{}} {
+		if err := mgr.GetFieldIndexer().IndexField(context.Background(), obj, v1alpha1.FieldSelectorStatusPodName, func(rawObj client.Object) []string {
+			// grab the request object, extract the pod name...
+			req := rawObj.(v1alpha1.IPodRequestResource)
+			if req.GetPodName() == "" {
+				return nil
+			}
+			return []string{req.GetPodName()}
+		}); err != nil {
+			panic(err)
+		}
+	}
+
 	// Set Up the Reconcilers
 	//
 	// These are the core components that are "watching" the custom resource
@@ -197,19 +724,48 @@ func Main() {
 		APIReader:              mgr.GetAPIReader(),
 		TemplateType:           &v1alpha1.ExecAccessTemplate{},
 		ReconciliationInterval: time.Duration(templateReconciliationInterval) * time.Minute,
+		AbsoluteMaxDuration:    absoluteMaxDuration,
+		LabelSelector:          labelSelector,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, unableToCreateMsg, controllerKey, "ExecAccessTemplate")
 		os.Exit(1)
 	}
 
-	if err = (&requestcontroller.RequestReconciler{
-		Client:                 mgr.GetClient(),
-		Scheme:                 mgr.GetScheme(),
-		APIReader:              mgr.GetAPIReader(),
-		RequestType:            &v1alpha1.ExecAccessRequest{},
-		Builder:                &execaccessbuilder.ExecAccessBuilder{},
-		ReconciliationInterval: time.Duration(requestReconciliationInterval) * time.Minute,
-	}).SetupWithManager(mgr); err != nil {
+	externalApprovalChecker := &externalapproval.Checker{Timeout: externalApprovalTimeout}
+
+	execBuilder := &execaccessbuilder.ExecAccessBuilder{
+		PodSelectionTimeout: podSelectionTimeout,
+		StateStore:          stateStore,
+	}
+	execRequestReconciler := &requestcontroller.RequestReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		APIReader:               mgr.GetAPIReader(),
+		RequestType:             &v1alpha1.ExecAccessRequest{},
+		Builder:                 execBuilder,
+		ReconciliationInterval:  time.Duration(requestReconciliationInterval) * time.Minute,
+		AbsoluteMaxDuration:     absoluteMaxDuration,
+		DailyGrantBudget:        dailyGrantBudget,
+		StateStore:              stateStore,
+		Notifier:                requesterNotifier,
+		GrantTokenSigner:        grantTokenSigner,
+		LabelSelector:           labelSelector,
+		Recorder:                mgr.GetEventRecorderFor("execaccessrequest-controller"),
+		Maintenance:             maintenance,
+		StampAccessExpiry:       stampRBACExpiry,
+		DetectSharedPods:        detectSharedPods,
+		OnTemplateDeleted:       onTemplateDeleted,
+		ApprovalSLO:             approvalSLO,
+		Finalizer:               finalizerName,
+		PreviousFinalizers:      previousFinalizerNames,
+		DisableFinalizer:        disableFinalizer,
+		ClockSkewTolerance:      clockSkewTolerance,
+		ExternalApprovalChecker: externalApprovalChecker,
+	}
+	if verifyAccessEffective {
+		execRequestReconciler.AccessReviewer = &requestcontroller.SubjectAccessReviewer{Client: mgr.GetClient()}
+	}
+	if err = execRequestReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, unableToCreateMsg, controllerKey, "ExecAccessRequest")
 		os.Exit(1)
 	}
@@ -220,25 +776,101 @@ func Main() {
 		APIReader:              mgr.GetAPIReader(),
 		TemplateType:           &v1alpha1.PodAccessTemplate{},
 		ReconciliationInterval: time.Duration(templateReconciliationInterval) * time.Minute,
+		AbsoluteMaxDuration:    absoluteMaxDuration,
+		LabelSelector:          labelSelector,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, unableToCreateMsg, controllerKey, "PodAccessTemplate")
 		os.Exit(1)
 	}
 
-	if err = (&requestcontroller.RequestReconciler{
-		Client:                 mgr.GetClient(),
-		Scheme:                 mgr.GetScheme(),
-		APIReader:              mgr.GetAPIReader(),
-		RequestType:            &v1alpha1.PodAccessRequest{},
-		Builder:                &podaccessbuilder.PodAccessBuilder{},
-		ReconciliationInterval: time.Duration(requestReconciliationInterval) * time.Minute,
-	}).SetupWithManager(mgr); err != nil {
+	podRequestReconciler := &requestcontroller.RequestReconciler{
+		Client:                  mgr.GetClient(),
+		Scheme:                  mgr.GetScheme(),
+		APIReader:               mgr.GetAPIReader(),
+		RequestType:             &v1alpha1.PodAccessRequest{},
+		Builder:                 &podaccessbuilder.PodAccessBuilder{},
+		ReconciliationInterval:  time.Duration(requestReconciliationInterval) * time.Minute,
+		AbsoluteMaxDuration:     absoluteMaxDuration,
+		DailyGrantBudget:        dailyGrantBudget,
+		StateStore:              stateStore,
+		Notifier:                requesterNotifier,
+		GrantTokenSigner:        grantTokenSigner,
+		LabelSelector:           labelSelector,
+		Recorder:                mgr.GetEventRecorderFor("podaccessrequest-controller"),
+		Maintenance:             maintenance,
+		StampAccessExpiry:       stampRBACExpiry,
+		DetectSharedPods:        detectSharedPods,
+		OnTemplateDeleted:       onTemplateDeleted,
+		ApprovalSLO:             approvalSLO,
+		Finalizer:               finalizerName,
+		PreviousFinalizers:      previousFinalizerNames,
+		DisableFinalizer:        disableFinalizer,
+		ClockSkewTolerance:      clockSkewTolerance,
+		ExternalApprovalChecker: externalApprovalChecker,
+	}
+	if verifyAccessEffective {
+		podRequestReconciler.AccessReviewer = &requestcontroller.SubjectAccessReviewer{Client: mgr.GetClient()}
+	}
+	if err = podRequestReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, unableToCreateMsg, controllerKey, "PodAccessRequest")
 		os.Exit(1)
 	}
 
 	//+kubebuilder:scaffold:builder
 
+	// The what-if endpoints predict the decision for hypothetical Access
+	// Requests, so that operators can try out policy changes before rolling
+	// them out. They are served alongside the metrics, and reveal the policy
+	// of any namespace - so only authorized callers may use them.
+	authorizer := &httpauth.Authorizer{Client: mgr.GetClient()}
+	if enableWhatIf {
+		for path, handler := range map[string]http.Handler{
+			"/whatif/execaccessrequest": execRequestReconciler.WhatIfHandler(&v1alpha1.ExecAccessTemplate{}),
+			"/whatif/podaccessrequest":  podRequestReconciler.WhatIfHandler(&v1alpha1.PodAccessTemplate{}),
+		} {
+			if err := mgr.AddMetricsExtraHandler(path, authorizer.Protect(handler)); err != nil {
+				setupLog.Error(err, "unable to set up the what-if endpoint", "path", path)
+				os.Exit(1)
+			}
+		}
+	}
+
+	// The log admin endpoint changes the level and format of the logs at runtime,
+	// for debugging in production without a restart. Only authorized callers may
+	// use it.
+	if enableLogAdmin {
+		if err := mgr.AddMetricsExtraHandler("/admin/log", authorizer.Protect(logSwitch.Handler())); err != nil {
+			setupLog.Error(err, "unable to set up the log admin endpoint")
+			os.Exit(1)
+		}
+	}
+
+	// The public keys that grant tokens are signed with are published
+	// alongside the metrics, so that downstream systems can verify them.
+	if grantTokenSigner != nil {
+		if err := mgr.AddMetricsExtraHandler("/grant-token/jwks.json", grantTokenSigner.Handler()); err != nil {
+			setupLog.Error(err, "unable to set up the grant token JWKS endpoint")
+			os.Exit(1)
+		}
+	}
+
+	// The build information of the controller is served alongside the
+	// metrics, so that ozctl can warn about a version skew between the two.
+	if err := mgr.AddMetricsExtraHandler("/version", version.Handler()); err != nil {
+		setupLog.Error(err, "unable to set up the version endpoint")
+		os.Exit(1)
+	}
+
+	// Serve the metrics in the OpenMetrics format as well, which is the only
+	// format that carries the exemplars (eg, trace IDs) attached to them.
+	if err := mgr.AddMetricsExtraHandler("/metrics/openmetrics", promhttp.HandlerFor(
+		metrics.Registry,
+		promhttp.HandlerOpts{EnableOpenMetrics: true, ErrorHandling: promhttp.HTTPErrorOnError},
+	)); err != nil {
+		setupLog.Error(err, "unable to set up the OpenMetrics endpoint")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -248,9 +880,264 @@ func Main() {
 		os.Exit(1)
 	}
 
-	setupLog.Info("starting manager")
+	info := version.Get()
+	setupLog.Info("starting manager", "version", info.Version, "commit", info.Commit, "apiVersions", info.APIVersions)
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// managerOptions builds the ctrl.Options used to create the controller
+// manager from the parsed commandline flags.
+func managerOptions(
+	metricsAddr string,
+	probeAddr string,
+	enableLeaderElection bool,
+	syncPeriod time.Duration,
+) ctrl.Options {
+	return ctrl.Options{
+		Scheme:                 scheme,
+		MetricsBindAddress:     metricsAddr,
+		Port:                   metricsPort,
+		HealthProbeBindAddress: probeAddr,
+
+		// SyncPeriod determines how often every watched resource is resynced,
+		// which in turn triggers a reconcile of every Access Request and
+		// Access Template regardless of whether anything has changed.
+		SyncPeriod: &syncPeriod,
+
+		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
+		// when the Manager ends. This requires the binary to immediately end when the
+		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
+		// speeds up voluntary leader transitions as the new leader don't have to wait
+		// LeaseDuration time first.
+		LeaderElection:                enableLeaderElection,
+		LeaderElectionID:              defaultLeaderElectionID,
+		LeaderElectionReleaseOnCancel: true,
+	}
+}
+
+// newLogSwitch returns a logswitch.Switch that starts out at the level of the
+// supplied zap.Options and in the supplied format (the value of the
+// --zap-encoder flag), falling back to the controller-runtime defaults. The
+// options are updated so that the logger they build writes through the Switch.
+func newLogSwitch(opts *zap.Options, format string) (*logswitch.Switch, error) {
+	var level uzap.AtomicLevel
+	switch lvl := opts.Level.(type) {
+	case uzap.AtomicLevel:
+		level = lvl
+	case *uzap.AtomicLevel:
+		level = *lvl
+	case nil:
+		level = uzap.NewAtomicLevelAt(zapcore.InfoLevel)
+		if opts.Development {
+			level = uzap.NewAtomicLevelAt(zapcore.DebugLevel)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported log level %v", opts.Level)
+	}
+	if format == "" {
+		format = logswitch.FormatJSON
+		if opts.Development {
+			format = logswitch.FormatConsole
+		}
+	}
+
+	logSwitch, err := logswitch.New(level, format)
+	if err != nil {
+		return nil, err
+	}
+	sink := opts.DestWriter
+	if sink == nil {
+		sink = os.Stderr
+	}
+	opts.Level = level
+	opts.ZapOpts = append(opts.ZapOpts, uzap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return logSwitch.Core(zapcore.AddSync(sink), opts.TimeEncoder, opts.Development)
+	}))
+	return logSwitch, nil
+}
+
+// newTemplateAuthors returns the authors that may write Access Templates, or
+// nil if the --template-author(-group) flags were not set.
+func newTemplateAuthors(authors crdsv1alpha1.AllowedRequesters) *crdsv1alpha1.AllowedRequesters {
+	if len(authors.Users) == 0 && len(authors.Groups) == 0 {
+		return nil
+	}
+	setupLog.Info("limiting who may write Access Templates",
+		"users", authors.Users, "groups", authors.Groups)
+	return &authors
+}
+
+// newMaintenanceMode returns the requestcontroller.MaintenanceMode configured
+// by the --maintenance-mode flags, or nil if maintenance mode is off. Granted
+// access is drained the grace period after the supplied start time.
+func newMaintenanceMode(
+	enabled bool,
+	drainGracePeriod time.Duration,
+	start time.Time,
+) (*requestcontroller.MaintenanceMode, error) {
+	if drainGracePeriod < 0 {
+		return nil, fmt.Errorf("--maintenance-drain-grace-period can not be negative")
+	}
+	if !enabled {
+		if drainGracePeriod > 0 {
+			return nil, fmt.Errorf("--maintenance-drain-grace-period requires --maintenance-mode")
+		}
+		return nil, nil
+	}
+
+	maintenance := &requestcontroller.MaintenanceMode{}
+	if drainGracePeriod > 0 {
+		maintenance.DrainAt = start.Add(drainGracePeriod)
+	}
+	setupLog.Info("in maintenance mode, not granting new access", "drainAt", maintenance.DrainAt)
+	return maintenance, nil
+}
+
+// parseLabelSelector parses the --label-selector flag. An empty flag returns
+// a nil selector, which matches everything.
+func parseLabelSelector(s string) (labels.Selector, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return labels.Parse(s)
+}
+
+// leaderElectionID returns the ID of the leader election lock. Controllers
+// sharded with a label selector each get their own lock, so that one shard
+// does not block another from running.
+func leaderElectionID(selector labels.Selector) string {
+	if selector == nil {
+		return defaultLeaderElectionID
+	}
+	sum := sha256.Sum256([]byte(selector.String()))
+	return fmt.Sprintf("%x.%s", sum[:4], defaultLeaderElectionID)
+}
+
+// restConfig applies the client rate limits to the supplied rest.Config. The
+// manager client built from it is shared by all of the reconcilers and the
+// builders they call into.
+func restConfig(cfg *rest.Config, qps float64, burst int) *rest.Config {
+	cfg.QPS = float32(qps)
+	cfg.Burst = burst
+	return cfg
+}
+
+// auditBackendConfig holds the commandline flags that select and configure an
+// audit.Backend.
+type auditBackendConfig struct {
+	kind         string
+	s3Endpoint   string
+	s3Bucket     string
+	s3Prefix     string
+	s3Region     string
+	kafkaRESTURL string
+	kafkaTopic   string
+}
+
+// newAuditBackend returns the audit.Backend selected by the commandline flags,
+// or nil if none was selected. Credentials are read through getenv.
+func newAuditBackend(cfg auditBackendConfig, getenv func(string) string) (audit.Backend, error) {
+	switch cfg.kind {
+	case "":
+		return nil, nil
+	case "s3":
+		if cfg.s3Bucket == "" {
+			return nil, fmt.Errorf("--audit-s3-bucket is required with --audit-backend=s3")
+		}
+		region := cfg.s3Region
+		if region == "" {
+			region = getenv("AWS_REGION")
+		}
+		return &audit.S3Backend{
+			Endpoint:        cfg.s3Endpoint,
+			Bucket:          cfg.s3Bucket,
+			Prefix:          cfg.s3Prefix,
+			Region:          region,
+			AccessKeyID:     getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	case "kafka":
+		if cfg.kafkaRESTURL == "" || cfg.kafkaTopic == "" {
+			return nil, fmt.Errorf(
+				"--audit-kafka-rest-url and --audit-kafka-topic are required with --audit-backend=kafka",
+			)
+		}
+		return &audit.KafkaRESTBackend{URL: cfg.kafkaRESTURL, Topic: cfg.kafkaTopic}, nil
+	default:
+		return nil, fmt.Errorf("unknown --audit-backend %q", cfg.kind)
+	}
+}
+
+// notifierConfig holds the commandline flags that configure the
+// notify.RequesterNotifier.
+type notifierConfig struct {
+	webhookURL       string
+	destinationsFile string
+	expiringWithin   time.Duration
+}
+
+// newRequesterNotifier returns the notify.RequesterNotifier configured by the
+// commandline flags, or nil if requester notifications are disabled. The
+// destinations file is read through readFile.
+func newRequesterNotifier(
+	cfg notifierConfig,
+	readFile func(string) ([]byte, error),
+) (*notify.RequesterNotifier, error) {
+	if cfg.webhookURL == "" {
+		if cfg.destinationsFile != "" {
+			return nil, fmt.Errorf("--notify-webhook-url is required with --notify-destinations-file")
+		}
+		return nil, nil
+	}
+
+	destinations := map[string]string{}
+	if cfg.destinationsFile != "" {
+		data, err := readFile(cfg.destinationsFile)
+		if err != nil {
+			return nil, err
+		}
+		if destinations, err = notify.LoadDestinations(data); err != nil {
+			return nil, err
+		}
+	}
+
+	return &notify.RequesterNotifier{
+		Notifier:       &notify.WebhookNotifier{URL: cfg.webhookURL, Client: &http.Client{Timeout: 10 * time.Second}},
+		Destinations:   destinations,
+		ExpiringWithin: cfg.expiringWithin,
+	}, nil
+}
+
+// grantTokenConfig holds the commandline flags used to build the
+// granttoken.Signer.
+type grantTokenConfig struct {
+	keyFiles []string
+	issuer   string
+	audience []string
+}
+
+// newGrantTokenSigner returns the granttoken.Signer configured by the
+// commandline flags, or nil if grant tokens are disabled. The key files are
+// read through readFile.
+func newGrantTokenSigner(
+	cfg grantTokenConfig,
+	readFile func(string) ([]byte, error),
+) (*granttoken.Signer, error) {
+	if len(cfg.keyFiles) == 0 {
+		return nil, nil
+	}
+
+	keys := [][]byte{}
+	for _, name := range cfg.keyFiles {
+		data, err := readFile(name)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, data)
+	}
+	return granttoken.NewSigner(cfg.issuer, cfg.audience, keys...)
+}
@@ -0,0 +1,286 @@
+package manager
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	crdsv1alpha1 "github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/audit"
+	"github.com/diranged/oz/internal/logswitch"
+)
+
+var _ = Describe("managerOptions()", func() {
+	It("Should apply the configured sync period", func() {
+		opts := managerOptions(":8080", ":8081", false, 30*time.Minute)
+		Expect(opts.SyncPeriod).ToNot(BeNil())
+		Expect(*opts.SyncPeriod).To(Equal(30 * time.Minute))
+	})
+
+	It("Should pass the remaining flags through", func() {
+		opts := managerOptions(":1234", ":5678", true, defaultSyncPeriod)
+		Expect(opts.MetricsBindAddress).To(Equal(":1234"))
+		Expect(opts.HealthProbeBindAddress).To(Equal(":5678"))
+		Expect(opts.LeaderElection).To(BeTrue())
+		Expect(*opts.SyncPeriod).To(Equal(defaultSyncPeriod))
+	})
+})
+
+var _ = Describe("restConfig()", func() {
+	It("Should apply the configured QPS and burst to the client config", func() {
+		cfg := restConfig(&rest.Config{Host: "https://example.com"}, 150, 300)
+		Expect(cfg.QPS).To(Equal(float32(150)))
+		Expect(cfg.Burst).To(Equal(300))
+		Expect(cfg.Host).To(Equal("https://example.com"))
+	})
+
+	It("Should override any rate limits already set on the config", func() {
+		cfg := restConfig(&rest.Config{QPS: 5, Burst: 10}, defaultClientQPS, defaultClientBurst)
+		Expect(cfg.QPS).To(Equal(float32(defaultClientQPS)))
+		Expect(cfg.Burst).To(Equal(defaultClientBurst))
+	})
+})
+
+var _ = Describe("newAuditBackend()", func() {
+	env := map[string]string{
+		"AWS_REGION":            "eu-west-1",
+		"AWS_ACCESS_KEY_ID":     "AKID",
+		"AWS_SECRET_ACCESS_KEY": "SECRET",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	It("Should return no backend by default", func() {
+		backend, err := newAuditBackend(auditBackendConfig{}, getenv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backend).To(BeNil())
+	})
+
+	It("Should build an S3 backend with credentials from the environment", func() {
+		backend, err := newAuditBackend(auditBackendConfig{kind: "s3", s3Bucket: "audit"}, getenv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backend).To(Equal(&audit.S3Backend{
+			Bucket:          "audit",
+			Region:          "eu-west-1",
+			AccessKeyID:     "AKID",
+			SecretAccessKey: "SECRET",
+		}))
+	})
+
+	It("Should build a Kafka backend", func() {
+		backend, err := newAuditBackend(auditBackendConfig{
+			kind: "kafka", kafkaRESTURL: "http://rest-proxy:8082", kafkaTopic: "audit",
+		}, getenv)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(backend.Name()).To(Equal("kafka://audit"))
+	})
+
+	It("Should reject incomplete or unknown configurations", func() {
+		_, err := newAuditBackend(auditBackendConfig{kind: "s3"}, getenv)
+		Expect(err).To(MatchError("--audit-s3-bucket is required with --audit-backend=s3"))
+
+		_, err = newAuditBackend(auditBackendConfig{kind: "kafka"}, getenv)
+		Expect(err).To(HaveOccurred())
+
+		_, err = newAuditBackend(auditBackendConfig{kind: "syslog"}, getenv)
+		Expect(err).To(MatchError(`unknown --audit-backend "syslog"`))
+	})
+})
+
+var _ = Describe("newRequesterNotifier()", func() {
+	files := map[string]string{"/etc/oz/destinations.yaml": "alice: U024BE7LH\n"}
+	readFile := func(name string) ([]byte, error) {
+		if data, ok := files[name]; ok {
+			return []byte(data), nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	It("Should return no notifier by default", func() {
+		notifier, err := newRequesterNotifier(notifierConfig{}, readFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(notifier).To(BeNil())
+	})
+
+	It("Should build a webhook notifier with the configured destinations", func() {
+		notifier, err := newRequesterNotifier(notifierConfig{
+			webhookURL:       "https://relay.example.com/hooks/token",
+			destinationsFile: "/etc/oz/destinations.yaml",
+			expiringWithin:   time.Minute,
+		}, readFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(notifier.Notifier.Name()).To(Equal("webhook://relay.example.com"))
+		Expect(notifier.Destinations).To(Equal(map[string]string{"alice": "U024BE7LH"}))
+		Expect(notifier.GetExpiringWithin()).To(Equal(time.Minute))
+	})
+
+	It("Should reject incomplete or unreadable configurations", func() {
+		_, err := newRequesterNotifier(notifierConfig{destinationsFile: "/etc/oz/destinations.yaml"}, readFile)
+		Expect(err).To(MatchError("--notify-webhook-url is required with --notify-destinations-file"))
+
+		_, err = newRequesterNotifier(notifierConfig{
+			webhookURL:       "https://relay.example.com",
+			destinationsFile: "/missing.yaml",
+		}, readFile)
+		Expect(err).To(MatchError(os.ErrNotExist))
+	})
+})
+
+var _ = Describe("newGrantTokenSigner()", func() {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, _ := x509.MarshalECPrivateKey(key)
+	files := map[string][]byte{
+		"/etc/oz/grant-token.pem": pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}),
+		"/etc/oz/bogus.pem":       []byte("bogus"),
+	}
+	readFile := func(name string) ([]byte, error) {
+		if data, ok := files[name]; ok {
+			return data, nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	It("Should return no signer by default", func() {
+		signer, err := newGrantTokenSigner(grantTokenConfig{}, readFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signer).To(BeNil())
+	})
+
+	It("Should build a signer with the configured keys and claims", func() {
+		signer, err := newGrantTokenSigner(grantTokenConfig{
+			keyFiles: []string{"/etc/oz/grant-token.pem"},
+			issuer:   "https://oz.example.com",
+			audience: []string{"downstream"},
+		}, readFile)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(signer.Issuer).To(Equal("https://oz.example.com"))
+		Expect(signer.Audience).To(Equal([]string{"downstream"}))
+		Expect(signer.JWKS().Keys).To(HaveLen(1))
+	})
+
+	It("Should reject unreadable or invalid keys", func() {
+		_, err := newGrantTokenSigner(grantTokenConfig{keyFiles: []string{"/missing.pem"}}, readFile)
+		Expect(err).To(MatchError(os.ErrNotExist))
+
+		_, err = newGrantTokenSigner(grantTokenConfig{keyFiles: []string{"/etc/oz/bogus.pem"}}, readFile)
+		Expect(err).To(MatchError(ContainSubstring("invalid signing key 0")))
+	})
+})
+
+var _ = Describe("parseLabelSelector()", func() {
+	It("Should return no selector by default", func() {
+		selector, err := parseLabelSelector("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(selector).To(BeNil())
+		Expect(leaderElectionID(selector)).To(Equal(defaultLeaderElectionID))
+	})
+
+	It("Should parse the selector, and give the shard its own leader election lock", func() {
+		selector, err := parseLabelSelector("shard=a")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(selector.Matches(labels.Set{"shard": "a"})).To(BeTrue())
+		Expect(selector.Matches(labels.Set{"shard": "b"})).To(BeFalse())
+
+		other, err := parseLabelSelector("shard=b")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(leaderElectionID(selector)).To(HaveSuffix("." + defaultLeaderElectionID))
+		Expect(leaderElectionID(selector)).ToNot(Equal(leaderElectionID(other)))
+	})
+
+	It("Should reject an invalid selector", func() {
+		_, err := parseLabelSelector("shard in (a")
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("newTemplateAuthors()", func() {
+	It("Should return no authors by default", func() {
+		Expect(newTemplateAuthors(crdsv1alpha1.AllowedRequesters{})).To(BeNil())
+	})
+
+	It("Should return the configured users and groups", func() {
+		authors := newTemplateAuthors(crdsv1alpha1.AllowedRequesters{
+			Users:  []string{"alice"},
+			Groups: []string{"platform-admins"},
+		})
+		Expect(authors).ToNot(BeNil())
+		Expect(authors.Allows("alice", nil)).To(BeTrue())
+		Expect(authors.Allows("bob", []string{"platform-admins"})).To(BeTrue())
+		Expect(authors.Allows("mallory", []string{"devs"})).To(BeFalse())
+	})
+})
+
+var _ = Describe("newMaintenanceMode()", func() {
+	start := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	It("Should return no maintenance mode by default", func() {
+		maintenance, err := newMaintenanceMode(false, 0, start)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(maintenance).To(BeNil())
+	})
+
+	It("Should only drain granted access when a grace period is set", func() {
+		maintenance, err := newMaintenanceMode(true, 0, start)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(maintenance).ToNot(BeNil())
+		Expect(maintenance.DrainAt).To(BeZero())
+
+		maintenance, err = newMaintenanceMode(true, 15*time.Minute, start)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(maintenance.DrainAt).To(Equal(start.Add(15 * time.Minute)))
+	})
+
+	It("Should reject a grace period without maintenance mode, or a negative one", func() {
+		_, err := newMaintenanceMode(false, 15*time.Minute, start)
+		Expect(err).To(HaveOccurred())
+		_, err = newMaintenanceMode(true, -time.Minute, start)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("newLogSwitch()", func() {
+	It("Should start out with the development defaults", func() {
+		opts := zap.Options{Development: true}
+		logSwitch, err := newLogSwitch(&opts, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(logSwitch.LevelString()).To(Equal("debug"))
+		Expect(logSwitch.Format()).To(Equal(logswitch.FormatConsole))
+	})
+
+	It("Should start out with the level and format of the flags, and write through the switch", func() {
+		var buf bytes.Buffer
+		opts := zap.Options{Development: true, DestWriter: &buf}
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		opts.BindFlags(fs)
+		Expect(fs.Parse([]string{"--zap-log-level=info", "--zap-encoder=json"})).To(Succeed())
+
+		logSwitch, err := newLogSwitch(&opts, fs.Lookup("zap-encoder").Value.String())
+		Expect(err).ToNot(HaveOccurred())
+		Expect(logSwitch.LevelString()).To(Equal("info"))
+		Expect(logSwitch.Format()).To(Equal(logswitch.FormatJSON))
+
+		logger := zap.New(zap.UseFlagOptions(&opts))
+		logger.V(1).Info("hidden")
+		Expect(buf.String()).To(BeEmpty())
+		Expect(logSwitch.SetLevel("debug")).To(Succeed())
+		logger.V(1).Info("shown")
+		Expect(buf.String()).To(HavePrefix("{"))
+		Expect(buf.String()).To(ContainSubstring("shown"))
+	})
+
+	It("Should reject an unknown format", func() {
+		_, err := newLogSwitch(&zap.Options{}, "xml")
+		Expect(err).To(HaveOccurred())
+	})
+})
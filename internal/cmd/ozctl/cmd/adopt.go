@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// Holder for the value of the --template flag
+var adoptTemplate string
+
+var adoptExample = `
+Hand an existing RoleBinding over to oz, so that it is deleted in four hours:
+$ ozctl adopt ad-hoc-debug-access --template my-template --duration 4h
+...
+
+The duration is capped by the maxDuration of the template, like any other
+access request. Adopting a RoleBinding requires permission to update it.
+`
+
+var adoptCmd = &cobra.Command{
+	Use:     "adopt <RoleBinding Name> --template <ExecAccessTemplate Name>",
+	Short:   "Put an existing RoleBinding under oz management",
+	Long:    `Wraps an existing RoleBinding in an ExecAccessRequest, so that oz tracks it and deletes it once the request expires. No other access resources are created for the request.`,
+	Example: adoptExample,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if adoptTemplate == "" {
+			return fmt.Errorf("the --template flag is required")
+		}
+		re, err := regexp.Compile(`^[a-z][a-z0-9-][a-z0-9]+`)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(requestNamePrefix) {
+			return fmt.Errorf("invalid request name prefix: %s", requestNamePrefix)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get our Kubernetes Client
+		cl, ns := getKubeClient()
+
+		// Make sure the RoleBinding can be adopted before creating anything
+		rb := &rbacv1.RoleBinding{}
+		if err := cl.Get(cmd.Context(), types.NamespacedName{Name: args[0], Namespace: ns}, rb); err != nil {
+			cmd.Printf(logError("Error - Could not find RoleBinding %s: %s\n"), args[0], err)
+			os.Exit(1)
+		}
+		if err := utils.ValidateAdoptableRoleBinding(rb, nil); err != nil {
+			cmd.Printf(logError("Error - Cannot adopt RoleBinding %s: %s\n"), args[0], err)
+			os.Exit(1)
+		}
+
+		req := &api.ExecAccessRequest{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "ExecAccessRequest",
+				APIVersion: api.GroupVersion.String(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				GenerateName: fmt.Sprintf("%s-", requestNamePrefix),
+				Namespace:    ns,
+				Annotations:  map[string]string{api.AdoptRoleBindingAnnotation: rb.GetName()},
+			},
+			Spec: api.ExecAccessRequestSpec{
+				TemplateName: adoptTemplate,
+				Duration:     duration,
+			},
+		}
+
+		// Verify that the target template exists proactively before creating the resource
+		tmpl := verifyTemplate(cmd, req)
+		printEffectiveDuration(cmd, req, tmpl)
+
+		cmd.Printf(logNotice("Creating ExecAccessRequest... "))
+		if err := cl.Create(cmd.Context(), req); err != nil {
+			cmd.Printf(logError("\nError - Creating ExecAccessRequest failed:\n  %s\n"), err)
+			os.Exit(1)
+		}
+		cmd.Printf(logNotice("%s created!\n"), req.GetName())
+
+		// Mark the RoleBinding for adoption by the new request - the
+		// controller will not take it over without this.
+		cmd.Printf(logNotice("Marking RoleBinding %s for adoption... "), rb.GetName())
+		patch := client.MergeFrom(rb.DeepCopy())
+		annotations := rb.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[api.AdoptedByAnnotation] = req.GetName()
+		rb.SetAnnotations(annotations)
+		if err := cl.Patch(cmd.Context(), rb, patch); err != nil {
+			cmd.Printf(logError("\nError - Marking RoleBinding %s failed:\n  %s\n"), rb.GetName(), err)
+			os.Exit(1)
+		}
+		cmd.Printf(logNotice("done!\n"))
+
+		// Wait until the access request is ready
+		waitForAccessRequest(cmd, req)
+	},
+}
+
+func init() {
+	adoptCmd.Flags().
+		StringVarP(&adoptTemplate, "template", "t", "", "Name of the ExecAccessTemplate that limits the duration of the adopted access")
+	adoptCmd.Flags().
+		StringVarP(&duration, "duration", "D", "", "Duration for the RoleBinding to be kept for. Valid time units are: ns, us, ms, s, m, h.")
+	adoptCmd.Flags().
+		StringVarP(&waitTime, "wait", "w", "1m", "Duration to wait for the access request to be fully ready. Valid time units are: ns, us, ms, s, m, h.")
+	adoptCmd.Flags().
+		StringVarP(&requestNamePrefix, "request-name", "N", usernameEnv, "Prefix name to use when creating the `ExecAccessRequest` object.")
+	kubeConfigFlags.AddFlags(adoptCmd.Flags())
+	rootCmd.AddCommand(adoptCmd)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// Holder of the optional --window flag
+var approveWindow time.Duration
+
+var approveExample = `
+Approve an Access Request against a template that requires approvals:
+$ ozctl approve my-request-abc12
+...
+
+Approve an Access Request for the next 4 hours only - it can not be extended
+past that without a fresh approval:
+$ ozctl approve my-request-abc12 --window 4h
+...
+`
+
+var approveCmd = &cobra.Command{
+	Use:     "approve <Access Request Name>",
+	Short:   "Approve an Access Request",
+	Long:    `Records your approval of an Access Request. Access is granted once the number of distinct approvers required by the Access Template is reached. With --window, the approval only covers access until that long from now.`,
+	Example: approveExample,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get our Kubernetes Client
+		cl, ns := getKubeClient()
+
+		req, err := getAccessRequest(cmd, cl, args[0], ns)
+		if err != nil {
+			cmd.Printf(logError("Error - Could not find Access Request %s: %s\n"), args[0], err)
+			os.Exit(1)
+		}
+
+		// Set the approve annotation - the webhook swaps it out for our
+		// identity in the list of approvers.
+		patch := client.MergeFrom(req.DeepCopyObject().(client.Object))
+		annotations := req.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[api.ApproveAnnotation] = "true"
+		if approveWindow > 0 {
+			annotations[api.ApproveAnnotation] = approveWindow.String()
+		}
+		req.SetAnnotations(annotations)
+
+		cmd.Printf(logNotice("Approving %s... "), req.GetName())
+		if err := cl.Patch(cmd.Context(), req, patch); err != nil {
+			cmd.Printf(logError("\nError - Approving %s failed:\n  %s\n"), req.GetName(), err)
+			os.Exit(1)
+		}
+		cmd.Printf(
+			logSuccess("done! %d approval(s) recorded.\n"),
+			len(api.GetApprovers(req)),
+		)
+	},
+}
+
+func init() {
+	approveCmd.Flags().
+		DurationVar(&approveWindow, "window", 0, "Only approve access (and extensions of it) until this long from now")
+	kubeConfigFlags.AddFlags(approveCmd.Flags())
+	rootCmd.AddCommand(approveCmd)
+}
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"regexp"
+	"runtime"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -15,8 +16,38 @@ var (
 	// Holder of the optional --target-pod flag
 	targetPod string
 
-	// Holder for the value of the --duration flag
-	duration = "1h"
+	// Holder of the optional --node flag
+	targetNode string
+
+	// Holder for the value of the --duration flag. When empty, the
+	// defaultDuration of the template is used.
+	duration string
+
+	// Holder for the values of the --param flags
+	parameterValues map[string]string
+
+	// Holder of the optional --for flag
+	requestFor string
+
+	// Holder of the optional --incident flag
+	incidentID string
+
+	// Holder of the optional --session flag
+	sessionID string
+
+	// Holder of the optional --justification flag
+	justification string
+
+	// Holder of the optional --verb flags
+	requestedVerbs []string
+
+	// Holder of the optional --template-namespace flag
+	templateNamespace string
+
+	// Holder of the optional --shell flag, which hints at the platform (or
+	// shell) that the access command should be rendered for. Defaults to the
+	// operating system that ozctl runs on.
+	platform = runtime.GOOS
 
 	// The prefix used in the Metadata.Name field for the ExecAccessRequest object.
 	requestNamePrefix = "unknown"
@@ -33,6 +64,40 @@ $ ozctl create ExecAccessRequest <existing template>
 You can optionally target a specific Pod:
 $ ozctl create ExecAccessRequest <existing template> --targetPod my-existing-pod
 ...
+
+Or restrict the randomly selected Pod to one running on a specific Node:
+$ ozctl create ExecAccessRequest <existing template> --node ip-10-0-0-1.ec2.internal
+...
+
+Or request the access on behalf of a teammate (if the template allows you to):
+$ ozctl create ExecAccessRequest <existing template> --for jane@example.com
+...
+
+Or tag the request with the incident that you need the access for:
+$ ozctl create ExecAccessRequest <existing template> --incident INC-1234
+...
+
+Or share a session with your teammates' requests, so that all of the access ends together:
+$ ozctl create ExecAccessRequest <existing template> --session debug-checkout
+...
+
+Or ask for less than the template allows you, for least-privilege access:
+$ ozctl create ExecAccessRequest <existing template> --verb create --verb get
+...
+
+Or pick the target Pod from a list of the candidates:
+$ ozctl create ExecAccessRequest <existing template> --interactive
+Select a target pod:
+  [1] my-app-7d4b9c8f6-2xkqz (node: ip-10-0-0-1.ec2.internal)
+  [2] my-app-7d4b9c8f6-9hjwn (node: ip-10-0-0-2.ec2.internal)
+Pod number [1-2]: 2
+...
+
+For scripted usage, the template, duration and namespace can be supplied through
+the $OZ_TEMPLATE, $OZ_DURATION and $OZ_NAMESPACE environment variables. Any
+arguments or flags passed in take precedence:
+$ OZ_TEMPLATE=<existing template> OZ_DURATION=30m ozctl create ExecAccessRequest
+...
 `
 
 // createAccessRequestCmd represents the create command
@@ -41,7 +106,7 @@ var createExecAccessRequestCmd = &cobra.Command{
 	Use:     "ExecAccessRequest <ExecAccessTemplate Name>",
 	Short:   "Create ExecAccessRequest resources",
 	Example: createExecAccessRequestExample,
-	Args:    cobra.MinimumNArgs(1),
+	Args:    cobra.MaximumNArgs(1),
 
 	// Static validation of the inputs - cannot be used to set state in the Run function.
 	PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -61,13 +126,23 @@ var createExecAccessRequestCmd = &cobra.Command{
 			return fmt.Errorf("invalid time supplied: %s", waitTime)
 		}
 
+		// Verify that we have a template name from somewhere
+		if _, err := getTemplateName(args); err != nil {
+			return err
+		}
+
+		// Interactive pod selection needs a user at a terminal
+		if err := validateInteractive(); err != nil {
+			return err
+		}
+
 		return nil
 	},
 
 	// Do the thing
 	Run: func(cmd *cobra.Command, args []string) {
-		// The template must be the first argument.
-		template := args[0]
+		// The template is the first argument, or comes from $OZ_TEMPLATE.
+		template, _ := getTemplateName(args)
 
 		// Get our k8s client and namespace
 		_, namespace := getKubeClient()
@@ -83,14 +158,31 @@ var createExecAccessRequestCmd = &cobra.Command{
 				Namespace:    namespace,
 			},
 			Spec: api.ExecAccessRequestSpec{
-				TemplateName: template,
-				Duration:     duration,
-				TargetPod:    targetPod,
+				TemplateName:      template,
+				Duration:          valueOrEnv(duration, envDuration),
+				TargetPod:         targetPod,
+				TargetNode:        targetNode,
+				ParameterValues:   parameterValues,
+				RequestFor:        requestFor,
+				IncidentID:        incidentID,
+				SessionID:         sessionID,
+				Justification:     justification,
+				RequestedVerbs:    requestedVerbs,
+				TemplateNamespace: templateNamespace,
+				Platform:          platform,
 			},
 		}
 
 		// Verify that the target template exists proactively before creating the resource
-		verifyTemplate(cmd, req)
+		tmpl := verifyTemplate(cmd, req)
+
+		// Let the user know how long the access will last, which may be the template default
+		printEffectiveDuration(cmd, req, tmpl)
+
+		// Let the user choose the target pod, rather than the controller
+		if interactive {
+			selectTargetPod(cmd, req)
+		}
 
 		// Create the request resource itself now
 		createAccessRequest(cmd, req)
@@ -104,7 +196,27 @@ func init() {
 	createExecAccessRequestCmd.Flags().
 		StringVarP(&targetPod, "target-pod", "p", "", "Optional name of a specific target pod to request access for")
 	createExecAccessRequestCmd.Flags().
-		StringVarP(&duration, "duration", "D", "", "Duration for the access request to be valid. Valid time units are: ns, us, ms, s, m, h.")
+		BoolVarP(&interactive, "interactive", "i", false, "Choose the target pod from a list of the candidate pods")
+	createExecAccessRequestCmd.Flags().
+		StringVar(&targetNode, "node", "", "Optional name of a Node - the target pod is selected from the pods running on it")
+	createExecAccessRequestCmd.Flags().
+		StringToStringVarP(&parameterValues, "param", "P", nil, "Values for the parameters declared by the template, in key=value form (may be repeated)")
+	createExecAccessRequestCmd.Flags().
+		StringVar(&requestFor, "for", "", "Optional name of the user to request the access on behalf of")
+	createExecAccessRequestCmd.Flags().
+		StringVar(&incidentID, "incident", "", "Optional ID of the incident that the access is needed for")
+	createExecAccessRequestCmd.Flags().
+		StringVar(&justification, "justification", "", "Why the access is needed, eg the ID or URL of a ticket - required by templates with a justificationPattern")
+	createExecAccessRequestCmd.Flags().
+		StringVar(&sessionID, "session", "", "Optional ID of a session to share with other Access Requests, whose access all ends together")
+	createExecAccessRequestCmd.Flags().
+		StringVar(&templateNamespace, "template-namespace", "", "Optional namespace of a template shared with the current namespace, defaults to the current namespace")
+	createExecAccessRequestCmd.Flags().
+		StringVar(&platform, "shell", platform, "Platform or shell (eg, linux, windows or powershell) to show the access command for, out of those the template offers")
+	createExecAccessRequestCmd.Flags().
+		StringSliceVar(&requestedVerbs, "verb", nil, "Optional verb on pods/exec to narrow the access to, out of those the template allows you (may be repeated)")
+	createExecAccessRequestCmd.Flags().
+		StringVarP(&duration, "duration", "D", "", "Duration for the access request to be valid, defaults to the defaultDuration of the template. Valid time units are: ns, us, ms, s, m, h.")
 	createExecAccessRequestCmd.Flags().
 		StringVarP(&waitTime, "wait", "w", "1m", "Duration to wait for the access request to be fully ready. Valid time units are: ns, us, ms, s, m, h.")
 	createExecAccessRequestCmd.Flags().
@@ -19,6 +19,20 @@ $ ozctl create PodAccessRequest <existing template>
 Success, your access request is ready! Here are your access instructions:
 
 kubectl exec -ti -n default user-vd9r9-a217f263 -- /bin/sh
+
+If the template declares parameters, supply their values with --param:
+$ ozctl create PodAccessRequest <existing template> --param app=web --param region=us-west-2
+...
+
+To request the access on behalf of a teammate (if the template allows you to):
+$ ozctl create PodAccessRequest <existing template> --for jane@example.com
+...
+
+For scripted usage, the template, duration and namespace can be supplied through
+the $OZ_TEMPLATE, $OZ_DURATION and $OZ_NAMESPACE environment variables. Any
+arguments or flags passed in take precedence:
+$ OZ_TEMPLATE=<existing template> OZ_DURATION=30m ozctl create PodAccessRequest
+...
 `
 
 // createPodAccessRequestCmd represents the create command
@@ -27,7 +41,7 @@ var createPodAccessRequestCmd = &cobra.Command{
 	Use:     "PodAccessRequest <PodAccessTemplate Name>",
 	Short:   "Create PodAccessRequest resources",
 	Example: createPodAccessRequestExample,
-	Args:    cobra.MinimumNArgs(1),
+	Args:    cobra.MaximumNArgs(1),
 
 	// Static validation of the inputs - cannot be used to set state in the Run function.
 	PreRunE: func(cmd *cobra.Command, args []string) error {
@@ -47,13 +61,18 @@ var createPodAccessRequestCmd = &cobra.Command{
 			return fmt.Errorf("invalid time supplied: %s", waitTime)
 		}
 
+		// Verify that we have a template name from somewhere
+		if _, err := getTemplateName(args); err != nil {
+			return err
+		}
+
 		return nil
 	},
 
 	// Do the thing
 	Run: func(cmd *cobra.Command, args []string) {
-		// The template must be the first argument.
-		templateName := args[0]
+		// The template is the first argument, or comes from $OZ_TEMPLATE.
+		templateName, _ := getTemplateName(args)
 
 		// Get our k8s client and namespace
 		_, namespace := getKubeClient()
@@ -69,13 +88,24 @@ var createPodAccessRequestCmd = &cobra.Command{
 				Namespace:    namespace,
 			},
 			Spec: api.PodAccessRequestSpec{
-				TemplateName: templateName,
-				Duration:     duration,
+				TemplateName:      templateName,
+				Duration:          valueOrEnv(duration, envDuration),
+				ParameterValues:   parameterValues,
+				RequestFor:        requestFor,
+				IncidentID:        incidentID,
+				SessionID:         sessionID,
+				Justification:     justification,
+				RequestedVerbs:    requestedVerbs,
+				TemplateNamespace: templateNamespace,
+				Platform:          platform,
 			},
 		}
 
 		// Verify that the target template exists proactively before creating the resource
-		verifyTemplate(cmd, req)
+		tmpl := verifyTemplate(cmd, req)
+
+		// Let the user know how long the access will last, which may be the template default
+		printEffectiveDuration(cmd, req, tmpl)
 
 		// Create the request resource itself now
 		createAccessRequest(cmd, req)
@@ -87,7 +117,23 @@ var createPodAccessRequestCmd = &cobra.Command{
 
 func init() {
 	createPodAccessRequestCmd.Flags().
-		StringVarP(&duration, "duration", "D", "", "Duration for the access request to be valid. Valid time units are: ns, us, ms, s, m, h.")
+		StringToStringVarP(&parameterValues, "param", "P", nil, "Values for the parameters declared by the template, in key=value form (may be repeated)")
+	createPodAccessRequestCmd.Flags().
+		StringVar(&requestFor, "for", "", "Optional name of the user to request the access on behalf of")
+	createPodAccessRequestCmd.Flags().
+		StringVar(&incidentID, "incident", "", "Optional ID of the incident that the access is needed for")
+	createPodAccessRequestCmd.Flags().
+		StringVar(&justification, "justification", "", "Why the access is needed, eg the ID or URL of a ticket - required by templates with a justificationPattern")
+	createPodAccessRequestCmd.Flags().
+		StringVar(&sessionID, "session", "", "Optional ID of a session to share with other Access Requests, whose access all ends together")
+	createPodAccessRequestCmd.Flags().
+		StringVar(&templateNamespace, "template-namespace", "", "Optional namespace of a template shared with the current namespace, defaults to the current namespace")
+	createPodAccessRequestCmd.Flags().
+		StringVar(&platform, "shell", platform, "Platform or shell (eg, linux, windows or powershell) to show the access command for, out of those the template offers")
+	createPodAccessRequestCmd.Flags().
+		StringSliceVar(&requestedVerbs, "verb", nil, "Optional verb on pods/exec to narrow the access to, out of those the template allows you (may be repeated)")
+	createPodAccessRequestCmd.Flags().
+		StringVarP(&duration, "duration", "D", "", "Duration for the access request to be valid, defaults to the defaultDuration of the template. Valid time units are: ns, us, ms, s, m, h.")
 	createPodAccessRequestCmd.Flags().
 		StringVarP(&waitTime, "wait", "w", "5m", "Duration to wait for the access request to be fully ready. Valid time units are: ns, us, ms, s, m, h.")
 	createPodAccessRequestCmd.Flags().
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/types"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
@@ -60,13 +61,16 @@ func waitForAccessRequest(cmd *cobra.Command, req api.IRequestResource) {
 		}
 
 		// Check the status
-		if status.IsReady() {
+		if req.IsReady() {
 			cmd.Printf(successMsg, status.GetAccessMessage())
 			break
 		}
 
 		if waitCtx.Err() != nil {
 			fmt.Printf(logError("\nError - timed out waiting for %s to be ready\n"), req.GetName())
+			if cond := meta.FindStatusCondition(*status.GetConditions(), api.ConditionReady.String()); cond != nil {
+				cmd.Printf(logError("%s\n"), cond.Message)
+			}
 			for _, cond := range *status.GetConditions() {
 				cmd.Printf(
 					"Condition %s, State: %s, Reason: %s, Message: %s\n",
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// printEffectiveDuration tells the user up front how long the access will
+// last. When no --duration is supplied, the controller falls back to the
+// defaultDuration of the template - which may well differ from what the user
+// expects - so we work it out the same way the controller does. Exits if the
+// request or the template carry an invalid duration.
+func printEffectiveDuration(
+	cmd *cobra.Command,
+	req api.IRequestResource,
+	tmpl api.ITemplateResource,
+) {
+	msg, err := effectiveDurationMsg(req, tmpl)
+	if err != nil {
+		cmd.Printf(logError("Error - Invalid duration: %s\n"), err)
+		os.Exit(1)
+	}
+	cmd.Print(logNotice(msg))
+}
+
+// effectiveDurationMsg describes the duration that the controller will grant
+// the supplied request against the supplied template.
+func effectiveDurationMsg(req api.IRequestResource, tmpl api.ITemplateResource) (string, error) {
+	accessDuration, decision, err := utils.GetAccessDuration(req, tmpl)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("  Duration: %s (%s)\n", accessDuration, decision), nil
+}
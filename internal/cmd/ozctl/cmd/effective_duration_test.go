@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("effectiveDurationMsg()", func() {
+	// The template default deliberately differs from the old CLI default of 1h.
+	tmpl := &api.PodAccessTemplate{
+		Spec: api.PodAccessTemplateSpec{
+			AccessConfig: api.AccessConfig{
+				DefaultDuration: "2h",
+				MaxDuration:     "4h",
+			},
+		},
+	}
+
+	newRequest := func(duration string) *api.PodAccessRequest {
+		return &api.PodAccessRequest{Spec: api.PodAccessRequestSpec{Duration: duration}}
+	}
+
+	It("Should use the template default when no --duration is supplied", func() {
+		msg, err := effectiveDurationMsg(newRequest(""), tmpl)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg).To(HavePrefix("  Duration: 2h0m0s ("))
+		Expect(msg).To(ContainSubstring("defaulting to template duration"))
+	})
+
+	It("Should use the supplied --duration", func() {
+		msg, err := effectiveDurationMsg(newRequest("30m"), tmpl)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg).To(HavePrefix("  Duration: 30m0s ("))
+	})
+
+	It("Should cap the supplied --duration at the template maximum", func() {
+		msg, err := effectiveDurationMsg(newRequest("8h"), tmpl)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(msg).To(HavePrefix("  Duration: 4h0m0s ("))
+		Expect(msg).To(ContainSubstring("larger than template maximum duration"))
+	})
+
+	It("Should reject an invalid --duration", func() {
+		_, err := effectiveDurationMsg(newRequest("forever"), tmpl)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+)
+
+// Environment variables that can be used to supply default values for the
+// `ozctl create` commands. These are primarily intended for scripted and CI
+// usage - any flag or argument passed on the command line always takes
+// precedence.
+const (
+	// envTemplate supplies the Access Template name when no argument is passed.
+	envTemplate = "OZ_TEMPLATE"
+
+	// envDuration supplies the --duration flag value when it is not passed.
+	envDuration = "OZ_DURATION"
+
+	// envNamespace supplies the --namespace flag value when it is not passed.
+	envNamespace = "OZ_NAMESPACE"
+)
+
+// valueOrEnv returns the supplied value if it is set, or falls back to the
+// value of the named environment variable.
+func valueOrEnv(value string, env string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
+
+// getTemplateName returns the template name passed in as the first argument,
+// or falls back to the OZ_TEMPLATE environment variable.
+func getTemplateName(args []string) (string, error) {
+	var arg string
+	if len(args) > 0 {
+		arg = args[0]
+	}
+	if name := valueOrEnv(arg, envTemplate); name != "" {
+		return name, nil
+	}
+	return "", errors.New("a template name must be supplied as an argument or via $" + envTemplate)
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+var _ = Describe("Environment variable defaults", func() {
+	// setEnv sets an environment variable for the duration of a single test.
+	setEnv := func(key, value string) {
+		orig, ok := os.LookupEnv(key)
+		Expect(os.Setenv(key, value)).To(Succeed())
+		DeferCleanup(func() {
+			if ok {
+				_ = os.Setenv(key, orig)
+			} else {
+				_ = os.Unsetenv(key)
+			}
+		})
+	}
+
+	Context("valueOrEnv()", func() {
+		It("Should fall back to the environment variable", func() {
+			setEnv(envDuration, "2h")
+			Expect(valueOrEnv("", envDuration)).To(Equal("2h"))
+		})
+
+		It("Should prefer the flag value over the environment variable", func() {
+			setEnv(envDuration, "2h")
+			Expect(valueOrEnv("30m", envDuration)).To(Equal("30m"))
+		})
+
+		It("Should return an empty string when neither is set", func() {
+			setEnv(envDuration, "")
+			Expect(valueOrEnv("", envDuration)).To(BeEmpty())
+		})
+	})
+
+	Context("getTemplateName()", func() {
+		It("Should fall back to $OZ_TEMPLATE", func() {
+			setEnv(envTemplate, "env-template")
+			Expect(getTemplateName([]string{})).To(Equal("env-template"))
+		})
+
+		It("Should prefer the argument over $OZ_TEMPLATE", func() {
+			setEnv(envTemplate, "env-template")
+			Expect(getTemplateName([]string{"arg-template"})).To(Equal("arg-template"))
+		})
+
+		It("Should return an error when no template is supplied", func() {
+			setEnv(envTemplate, "")
+			_, err := getTemplateName([]string{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(envTemplate))
+		})
+	})
+
+	Context("getDefaultKubeNamespace()", func() {
+		It("Should fall back to $OZ_NAMESPACE", func() {
+			setEnv(envNamespace, "env-namespace")
+			cf := genericclioptions.NewConfigFlags(true)
+			Expect(getDefaultKubeNamespace(cf)).To(Equal("env-namespace"))
+		})
+
+		It("Should prefer the --namespace flag over $OZ_NAMESPACE", func() {
+			setEnv(envNamespace, "env-namespace")
+			cf := genericclioptions.NewConfigFlags(true)
+			*cf.Namespace = "flag-namespace"
+			Expect(getDefaultKubeNamespace(cf)).To(Equal("flag-namespace"))
+		})
+	})
+})
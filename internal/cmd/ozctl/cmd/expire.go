@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var expireForce bool
+
+var expireExample = `
+Force an Access Request to expire right away, to test cleanup in staging:
+$ ozctl expire my-request-abc12 --force
+...
+`
+
+var expireCmd = &cobra.Command{
+	Use:     "expire <Access Request Name>",
+	Short:   "Force an Access Request to expire right away (testing only)",
+	Long:    `Forces an Access Request to expire on its next reconcile, regardless of its duration. This is intended for exercising cleanup in staging environments, and must be confirmed with --force. The forced expiration is recorded in the audit log. Use "ozctl revoke" to end access in production.`,
+	Example: expireExample,
+	Args:    cobra.ExactArgs(1),
+	Hidden:  true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !expireForce {
+			cmd.Printf(logError("Error - Forcing an expiration requires --force\n"))
+			os.Exit(1)
+		}
+
+		// Get our Kubernetes Client
+		cl, ns := getKubeClient()
+
+		req, err := getAccessRequest(cmd, cl, args[0], ns)
+		if err != nil {
+			cmd.Printf(logError("Error - Could not find Access Request %s: %s\n"), args[0], err)
+			os.Exit(1)
+		}
+
+		// Set the expire annotation - the webhook replaces it with our
+		// identity, and the controller tears the access down.
+		patch := client.MergeFrom(req.DeepCopyObject().(client.Object))
+		annotations := req.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		if user, forced := annotations[api.ExpireAnnotation]; forced {
+			cmd.Printf(logWarning("%s has already been force-expired by %s\n"), req.GetName(), user)
+			os.Exit(0)
+		}
+		annotations[api.ExpireAnnotation] = ""
+		req.SetAnnotations(annotations)
+
+		cmd.Printf(logWarning("FORCED - Expiring %s... "), req.GetName())
+		if err := cl.Patch(cmd.Context(), req, patch); err != nil {
+			cmd.Printf(logError("\nError - Expiring %s failed:\n  %s\n"), req.GetName(), err)
+			os.Exit(1)
+		}
+		cmd.Print(logSuccess("done!\n"))
+	},
+}
+
+func init() {
+	expireCmd.Flags().
+		BoolVar(&expireForce, "force", false, "Confirm that the Access Request should be forcibly expired")
+	kubeConfigFlags.AddFlags(expireCmd.Flags())
+	rootCmd.AddCommand(expireCmd)
+}
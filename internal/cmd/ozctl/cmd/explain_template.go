@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// targetPodPlaceholder is used in place of the Pod name in the explained
+// rules, because the real Pod is only known once a request is made.
+const targetPodPlaceholder = "<target pod>"
+
+var explainTemplateExample = `
+Describe what an access request against a template would allow:
+$ ozctl explain-template my-template
+ExecAccessTemplate default/my-template
+  Target:     Deployment my-app (apps/v1)
+  ...
+`
+
+var explainTemplateCmd = &cobra.Command{
+	Use:     "explain-template <Access Template Name>",
+	Short:   "Explain in plain English what an Access Template grants",
+	Long:    `Prints out exactly what an access request against the named Access Template would allow - who can use it, for how long, and which permissions are granted on which resources.`,
+	Example: explainTemplateExample,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get our Kubernetes Client
+		cl, ns := getKubeClient()
+
+		tmpl, err := getAccessTemplate(cmd, cl, args[0], ns)
+		if err != nil {
+			cmd.Printf(logError("Error - Could not find Access Template %s: %s\n"), args[0], err)
+			os.Exit(1)
+		}
+
+		cmd.Print(explainTemplate(tmpl))
+	},
+}
+
+// getAccessTemplate looks up an Access Template by name, trying each of the
+// known Access Template kinds in turn.
+func getAccessTemplate(
+	cmd *cobra.Command,
+	cl client.Client,
+	name string,
+	namespace string,
+) (api.ITemplateResource, error) {
+	execTmpl, err := api.GetExecAccessTemplate(cmd.Context(), cl, name, namespace)
+	if err == nil {
+		return execTmpl, nil
+	}
+	podTmpl, err := api.GetPodAccessTemplate(cmd.Context(), cl, name, namespace)
+	if err == nil {
+		return podTmpl, nil
+	}
+	return nil, err
+}
+
+// explainTemplate generates the human readable explanation of what an access
+// request against the supplied template grants.
+func explainTemplate(tmpl api.ITemplateResource) string {
+	cfg := tmpl.GetAccessConfig()
+	ref := tmpl.GetTargetRef()
+
+	var b strings.Builder
+	kind := "ExecAccessTemplate"
+	if _, ok := tmpl.(*api.PodAccessTemplate); ok {
+		kind = "PodAccessTemplate"
+	}
+	fmt.Fprintf(&b, "%s %s/%s\n", kind, tmpl.GetNamespace(), tmpl.GetName())
+	fmt.Fprintf(&b, "  Target:     %s %s (%s)\n", ref.GetKind(), ref.GetName(), ref.APIVersion)
+	fmt.Fprintf(&b, "  Who:        members of the groups %s\n", joinWords(cfg.GetAllowedGroups()))
+	fmt.Fprintf(&b, "  Duration:   %s by default, and at most %s\n", cfg.DefaultDuration, cfg.MaxDuration)
+	if eod := cfg.GetExpireAtEndOfDay(); eod != nil {
+		tz := eod.TimeZone
+		if tz == "" {
+			tz = "UTC"
+		}
+		fmt.Fprintf(&b, "              always ending by midnight (%s)\n", tz)
+	}
+	if n := cfg.GetRequiredApprovals(); n > 0 {
+		fmt.Fprintf(&b, "  Approval:   required from %d distinct users (with ozctl approve)\n", n)
+		if sel := cfg.GetApprovalRequiredSelector(); sel != nil {
+			fmt.Fprintf(&b, "              only for target pods matching %s\n", metav1.FormatLabelSelector(sel))
+		}
+	} else {
+		fmt.Fprintf(&b, "  Approval:   not required - access is granted as soon as the request is ready\n")
+	}
+	if cfg.IsPaused() {
+		fmt.Fprintf(&b, "  Status:     paused - new access requests are rejected\n")
+	}
+
+	switch t := tmpl.(type) {
+	case *api.ExecAccessTemplate:
+		fmt.Fprintf(&b,
+			"  Pod:        an existing running Pod of %s %s, picked at random unless the request names one\n",
+			ref.GetKind(), ref.GetName(),
+		)
+	case *api.PodAccessTemplate:
+		fmt.Fprintf(&b,
+			"  Pod:        a new Pod launched from a copy of the %s %s Pod template\n",
+			ref.GetKind(), ref.GetName(),
+		)
+		if t.Spec.MaxPods > 0 {
+			fmt.Fprintf(&b, "  Max Pods:   at most %d Pods at once, further requests are queued\n", t.Spec.MaxPods)
+		}
+	}
+
+	if params := cfg.GetParameters(); len(params) > 0 {
+		fmt.Fprintf(&b, "  Parameters:\n")
+		for _, p := range params {
+			fmt.Fprintf(&b, "    - %s%s\n", p.Name, describeParameter(p))
+		}
+	}
+
+	fmt.Fprintf(&b, "  Grants:\n")
+	if name := cfg.GetClusterRoleRef(); name != "" {
+		fmt.Fprintf(&b, "    - the permissions of the ClusterRole %s, within the %s namespace\n",
+			name, tmpl.GetNamespace())
+		return b.String()
+	}
+	if len(cfg.VerbsByGroup) == 0 {
+		for _, rule := range utils.PodAccessRules(targetPodPlaceholder) {
+			fmt.Fprintf(&b, "    - %s\n", describeRule(rule))
+		}
+		return b.String()
+	}
+
+	// The verbs on the pods/exec subresource depend on who the access is
+	// granted to - see utils.ExecVerbs().
+	groups := make([]string, 0, len(cfg.VerbsByGroup))
+	for group := range cfg.VerbsByGroup {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		writeRules(&b, fmt.Sprintf("to members of %s", group), cfg.GetAllowedExecVerbs([]string{group}))
+	}
+	writeRules(&b, "to anybody else", api.DefaultExecVerbs)
+	writeRules(&b, "to users that the access is requested for, or transferred to", cfg.GetCommonExecVerbs())
+	fmt.Fprintf(&b, "    members of several groups are granted the verbs of all of them\n")
+	return b.String()
+}
+
+// writeRules writes out the rules granting the supplied pods/exec verbs, under
+// the supplied heading.
+func writeRules(b *strings.Builder, heading string, execVerbs []string) {
+	fmt.Fprintf(b, "    %s:\n", heading)
+	for _, rule := range utils.PodAccessRulesWithVerbs(execVerbs, targetPodPlaceholder) {
+		fmt.Fprintf(b, "      - %s\n", describeRule(rule))
+	}
+}
+
+// describeParameter returns the qualifiers of a TemplateParameter, eg " (required, must match ^[a-z]+$)"
+func describeParameter(p api.TemplateParameter) string {
+	var quals []string
+	if p.Required {
+		quals = append(quals, "required")
+	}
+	if p.Default != "" {
+		quals = append(quals, fmt.Sprintf("defaults to %q", p.Default))
+	}
+	if p.Pattern != "" {
+		quals = append(quals, fmt.Sprintf("must match %q", p.Pattern))
+	}
+	if len(quals) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%s)", strings.Join(quals, ", "))
+}
+
+// describeRule turns an rbacv1.PolicyRule into a plain English sentence, eg
+// "get, list and watch the pods named <target pod>"
+func describeRule(rule rbacv1.PolicyRule) string {
+	s := fmt.Sprintf("%s the %s", joinWords(rule.Verbs), joinWords(rule.Resources))
+	if len(rule.ResourceNames) > 0 {
+		s += fmt.Sprintf(" named %s", joinWords(rule.ResourceNames))
+	}
+	return s
+}
+
+// joinWords joins a list of words into a readable list, eg "a, b and c"
+func joinWords(words []string) string {
+	switch len(words) {
+	case 0:
+		return "(none)"
+	case 1:
+		return words[0]
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " and " + words[len(words)-1]
+}
+
+func init() {
+	kubeConfigFlags.AddFlags(explainTemplateCmd.Flags())
+	rootCmd.AddCommand(explainTemplateCmd)
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("explainTemplate()", func() {
+	var (
+		targetRef = &api.CrossVersionObjectReference{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Name:       "my-app",
+		}
+		accessConfig = api.AccessConfig{
+			AllowedGroups:   []string{"admins", "devs", "oncall"},
+			DefaultDuration: "1h",
+			MaxDuration:     "4h",
+		}
+	)
+
+	It("Should describe an ExecAccessTemplate from its config", func() {
+		tmpl := &api.ExecAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "exec-tmpl", Namespace: "test"},
+			Spec: api.ExecAccessTemplateSpec{
+				AccessConfig:        accessConfig,
+				ControllerTargetRef: targetRef,
+			},
+		}
+
+		Expect(explainTemplate(tmpl)).To(Equal(`ExecAccessTemplate test/exec-tmpl
+  Target:     Deployment my-app (apps/v1)
+  Who:        members of the groups admins, devs and oncall
+  Duration:   1h by default, and at most 4h
+  Approval:   not required - access is granted as soon as the request is ready
+  Pod:        an existing running Pod of Deployment my-app, picked at random unless the request names one
+  Grants:
+    - get, list and watch the pods named <target pod>
+    - create, update, delete, get and list the pods/exec named <target pod>
+`))
+	})
+
+	It("Should describe the pods/exec verbs granted by the verbsByGroup of a template", func() {
+		cfg := accessConfig
+		cfg.VerbsByGroup = map[string][]string{
+			"seniors": {"create", "get", "list"},
+			"juniors": {},
+		}
+		tmpl := &api.ExecAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "exec-tmpl", Namespace: "test"},
+			Spec: api.ExecAccessTemplateSpec{
+				AccessConfig:        cfg,
+				ControllerTargetRef: targetRef,
+			},
+		}
+
+		Expect(explainTemplate(tmpl)).To(HaveSuffix(`  Grants:
+    to members of juniors:
+      - get, list and watch the pods named <target pod>
+    to members of seniors:
+      - get, list and watch the pods named <target pod>
+      - create, get and list the pods/exec named <target pod>
+    to anybody else:
+      - get, list and watch the pods named <target pod>
+      - create, update, delete, get and list the pods/exec named <target pod>
+    to users that the access is requested for, or transferred to:
+      - get, list and watch the pods named <target pod>
+    members of several groups are granted the verbs of all of them
+`))
+	})
+
+	It("Should describe a paused, parameterized PodAccessTemplate", func() {
+		cfg := accessConfig
+		cfg.Paused = true
+		cfg.RequiredApprovals = 2
+		cfg.ApprovalRequiredSelector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{"env": "production"},
+		}
+		cfg.ClusterRoleRef = "curated-debug"
+		cfg.ExpireAtEndOfDay = &api.EndOfDayConfig{TimeZone: "Europe/London"}
+		cfg.Parameters = []api.TemplateParameter{
+			{Name: "app", Required: true, Pattern: "[a-z]+"},
+			{Name: "region", Default: "us-west-2"},
+		}
+		tmpl := &api.PodAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-tmpl", Namespace: "test"},
+			Spec: api.PodAccessTemplateSpec{
+				AccessConfig:        cfg,
+				ControllerTargetRef: targetRef,
+				MaxPods:             3,
+			},
+		}
+
+		out := explainTemplate(tmpl)
+		Expect(out).To(HavePrefix("PodAccessTemplate test/pod-tmpl\n"))
+		Expect(out).To(ContainSubstring("Approval:   required from 2 distinct users (with ozctl approve)"))
+		Expect(out).To(ContainSubstring("only for target pods matching env=production"))
+		Expect(out).To(ContainSubstring("always ending by midnight (Europe/London)"))
+		Expect(out).To(ContainSubstring("Status:     paused - new access requests are rejected"))
+		Expect(out).To(ContainSubstring("a new Pod launched from a copy of the Deployment my-app Pod template"))
+		Expect(out).To(ContainSubstring("at most 3 Pods at once"))
+		Expect(out).To(ContainSubstring(`- app (required, must match "[a-z]+")`))
+		Expect(out).To(ContainSubstring(`- region (defaults to "us-west-2")`))
+		Expect(out).To(ContainSubstring("- the permissions of the ClusterRole curated-debug, within the test namespace"))
+		Expect(out).ToNot(ContainSubstring("pods/exec"))
+	})
+
+	It("joinWords() should produce readable lists", func() {
+		Expect(joinWords(nil)).To(Equal("(none)"))
+		Expect(joinWords([]string{"a"})).To(Equal("a"))
+		Expect(joinWords([]string{"a", "b"})).To(Equal("a and b"))
+		Expect(joinWords([]string{"a", "b", "c"})).To(Equal("a, b and c"))
+	})
+})
@@ -11,13 +11,22 @@ import (
 	api "github.com/diranged/oz/internal/api/v1alpha1"
 )
 
+// Holder of the optional --incident flag
+var getIncident string
+
+var getExample = `
+List every Access Request tagged with an incident:
+$ ozctl get --incident INC-1234
+...
+`
+
 var getCmd = &cobra.Command{
-	Use:   "get <resource> ...options",
-	Short: "Get an existing Access Request or Template",
-	Long:  ``,
-	Args:  cobra.MinimumNArgs(1),
+	Use:     "get <resource> ...options",
+	Short:   "Get an existing Access Request or Template",
+	Long:    ``,
+	Example: getExample,
 	PreRunE: func(cmd *cobra.Command, args []string) error {
-		if len(args) == 0 {
+		if len(args) == 0 && getIncident == "" {
 			if err := cmd.Help(); err != nil {
 				fmt.Println(err)
 				os.Exit(1)
@@ -31,6 +40,15 @@ var getCmd = &cobra.Command{
 		// Get the client
 		builder := resource.NewBuilder(kubeConfigFlags)
 
+		// Only list the Access Requests tagged with the incident, of any kind
+		// unless some are named.
+		if getIncident != "" {
+			builder = builder.LabelSelectorParam(incidentSelector(getIncident))
+			if len(args) == 0 {
+				args = []string{incidentRequestKinds}
+			}
+		}
+
 		// Get the object or list of objects
 		obj, err := builder.
 			// Scheme teaches the Builder how to instantiate resources by names.
@@ -62,6 +80,8 @@ var getCmd = &cobra.Command{
 }
 
 func init() {
+	getCmd.Flags().
+		StringVar(&getIncident, "incident", "", "Only get the Access Requests tagged with this incident ID")
 	kubeConfigFlags.AddFlags(getCmd.Flags())
 	rootCmd.AddCommand(getCmd)
 }
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// incidentRequestKinds are the resources that `ozctl get --incident` lists
+// when no resource is named.
+const incidentRequestKinds = "execaccessrequests,podaccessrequests"
+
+// incidentSelector returns the label selector that matches the Access
+// Requests tagged with the supplied incident ID.
+func incidentSelector(incidentID string) string {
+	return fmt.Sprintf("%s=%s", api.IncidentIDLabel, incidentID)
+}
+
+// listIncidentRequests returns the Access Requests of every kind that are
+// tagged with the supplied incident ID, looked up by their IncidentIDLabel.
+func listIncidentRequests(
+	ctx context.Context,
+	cl client.Client,
+	incidentID string,
+) ([]api.IRequestResource, error) {
+	var requests []api.IRequestResource
+	selector := client.MatchingLabels{api.IncidentIDLabel: incidentID}
+
+	execReqs := &api.ExecAccessRequestList{}
+	if err := cl.List(ctx, execReqs, selector); err != nil {
+		return nil, err
+	}
+	for i := range execReqs.Items {
+		requests = append(requests, &execReqs.Items[i])
+	}
+
+	podReqs := &api.PodAccessRequestList{}
+	if err := cl.List(ctx, podReqs, selector); err != nil {
+		return nil, err
+	}
+	for i := range podReqs.Items {
+		requests = append(requests, &podReqs.Items[i])
+	}
+	return requests, nil
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("Incidents", func() {
+	var (
+		ctx = context.Background()
+		cl  client.Client
+	)
+
+	// tagged returns the metadata of a request tagged with the supplied
+	// incident, the way the mutating webhook labels it.
+	tagged := func(name string, incidentID string) metav1.ObjectMeta {
+		meta := metav1.ObjectMeta{Name: name, Namespace: "test"}
+		if incidentID != "" {
+			meta.Labels = map[string]string{api.IncidentIDLabel: incidentID}
+		}
+		return meta
+	}
+
+	BeforeEach(func() {
+		scheme := runtime.NewScheme()
+		Expect(api.AddToScheme(scheme)).To(Succeed())
+		cl = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&api.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "strict-tmpl", Namespace: "test"},
+				Spec: api.ExecAccessTemplateSpec{
+					AccessConfig: api.AccessConfig{RequireRevokeReason: true},
+				},
+			},
+			&api.ExecAccessRequest{
+				ObjectMeta: tagged("exec-a", "INC-1"),
+				Spec:       api.ExecAccessRequestSpec{TemplateName: "tmpl", IncidentID: "INC-1"},
+			},
+			&api.ExecAccessRequest{
+				ObjectMeta: tagged("exec-b", "INC-2"),
+				Spec:       api.ExecAccessRequestSpec{TemplateName: "tmpl", IncidentID: "INC-2"},
+			},
+			&api.ExecAccessRequest{
+				ObjectMeta: tagged("exec-c", ""),
+				Spec:       api.ExecAccessRequestSpec{TemplateName: "tmpl"},
+			},
+			&api.ExecAccessRequest{
+				ObjectMeta: tagged("exec-strict", "INC-3"),
+				Spec:       api.ExecAccessRequestSpec{TemplateName: "strict-tmpl", IncidentID: "INC-3"},
+			},
+			&api.PodAccessRequest{
+				ObjectMeta: tagged("pod-a", "INC-1"),
+				Spec:       api.PodAccessRequestSpec{TemplateName: "tmpl", IncidentID: "INC-1"},
+			},
+		).Build()
+	})
+
+	names := func(reqs []api.IRequestResource) []string {
+		var ret []string
+		for _, req := range reqs {
+			ret = append(ret, req.GetName())
+		}
+		return ret
+	}
+
+	It("incidentSelector() should select the incident label", func() {
+		Expect(incidentSelector("INC-1")).To(Equal("crds.wizardofoz.co/incident-id=INC-1"))
+	})
+
+	It("listIncidentRequests() should only return the requests of every kind tagged with the incident", func() {
+		reqs, err := listIncidentRequests(ctx, cl, "INC-1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(names(reqs)).To(ConsistOf("exec-a", "pod-a"))
+
+		reqs, err = listIncidentRequests(ctx, cl, "INC-404")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reqs).To(BeEmpty())
+	})
+
+	It("revokeAccessRequest() should bulk-revoke the requests of an incident", func() {
+		reqs, err := listIncidentRequests(ctx, cl, "INC-1")
+		Expect(err).ToNot(HaveOccurred())
+		for _, req := range reqs {
+			revoked, err := revokeAccessRequest(ctx, cl, req, "incident closed")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revoked).To(BeTrue())
+		}
+
+		// Revoking them again is a no-op
+		reqs, err = listIncidentRequests(ctx, cl, "INC-1")
+		Expect(err).ToNot(HaveOccurred())
+		for _, req := range reqs {
+			Expect(req.GetAnnotations()).To(HaveKeyWithValue(api.RevokeAnnotation, "incident closed"))
+			revoked, err := revokeAccessRequest(ctx, cl, req, "again")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revoked).To(BeFalse())
+		}
+
+		// The other requests are left alone
+		for _, name := range []string{"exec-b", "exec-c"} {
+			req := &api.ExecAccessRequest{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: name, Namespace: "test"}, req)).To(Succeed())
+			Expect(req.GetAnnotations()).ToNot(HaveKey(api.RevokeAnnotation))
+		}
+	})
+
+	It("revokeAccessRequest() should require a reason when the template does", func() {
+		reqs, err := listIncidentRequests(ctx, cl, "INC-3")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reqs).To(HaveLen(1))
+
+		_, err = revokeAccessRequest(ctx, cl, reqs[0], "")
+		Expect(err).To(MatchError(ContainSubstring("requires a --reason")))
+	})
+})
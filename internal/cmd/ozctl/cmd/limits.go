@@ -0,0 +1,293 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	authenticationv1alpha1 "k8s.io/api/authentication/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var (
+	limitsUser             string
+	limitsGroups           []string
+	limitsDailyGrantBudget time.Duration
+)
+
+var limitsExample = `
+Show the limits that apply to your own Access Requests:
+$ ozctl limits --daily-grant-budget 8h
+Limits for alice (groups: devs and oncall)
+  Active:     1 Access Request (ExecAccessRequest default/alice-abc12)
+  Budget:     1h0m0s of 8h0m0s granted today (UTC), 7h0m0s remaining
+  ...
+`
+
+var limitsCmd = &cobra.Command{
+	Use:   "limits",
+	Short: "Show the limits that apply to your own Access Requests",
+	Long: `Shows your active Access Requests, how much of your daily grant budget is left, and which of the Access Templates in the namespace you may use.
+
+Your identity is looked up with a SelfSubjectReview. If your cluster does not serve them, pass --requester and --requester-group instead. The daily grant budget is configured on the controller - pass the same value with --daily-grant-budget to see what is left of it. Only the Access Requests that still exist are counted here, while the controller also counts the access granted through the ones deleted since.`,
+	Example: limitsExample,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get our Kubernetes Client
+		cl, ns := getKubeClient()
+
+		user, groups := limitsUser, limitsGroups
+		if user == "" {
+			var err error
+			user, groups, err = whoAmI(cmd.Context(), cl)
+			if err != nil {
+				cmd.Printf(logError("Error - Could not look up your identity, pass --requester and --requester-group instead: %s\n"), err)
+				os.Exit(1)
+			}
+		}
+
+		templates, err := listAccessTemplates(cmd.Context(), cl)
+		if err != nil {
+			cmd.Printf(logError("Error - Could not list the Access Templates in %s: %s\n"), ns, err)
+			os.Exit(1)
+		}
+
+		// The daily grant budget spans every namespace - but not everybody
+		// may list Access Requests across all of them.
+		kubeRestCfg, _ := kubeConfigFlags.ToRESTConfig()
+		rawCl, _ := client.New(kubeRestCfg, client.Options{})
+		requests, err := listAccessRequests(cmd.Context(), rawCl)
+		if err != nil {
+			cmd.Printf(logWarning("Warning - Could not list Access Requests in all namespaces, only counting %s: %s\n"), ns, err)
+			if requests, err = listAccessRequests(cmd.Context(), cl); err != nil {
+				cmd.Printf(logError("Error - Could not list the Access Requests in %s: %s\n"), ns, err)
+				os.Exit(1)
+			}
+		}
+
+		cmd.Print(describeLimits(user, groups, time.Now(), limitsDailyGrantBudget, templates, requests))
+	},
+}
+
+// whoAmI returns the username and groups that the cluster authenticates the
+// client as.
+func whoAmI(ctx context.Context, cl client.Client) (string, []string, error) {
+	review := &authenticationv1alpha1.SelfSubjectReview{}
+	if err := cl.Create(ctx, review); err != nil {
+		return "", nil, err
+	}
+	return review.Status.UserInfo.Username, review.Status.UserInfo.Groups, nil
+}
+
+// listAccessTemplates returns the Access Templates of every kind that the
+// supplied client can see.
+func listAccessTemplates(ctx context.Context, cl client.Client) ([]api.ITemplateResource, error) {
+	var templates []api.ITemplateResource
+
+	execTmpls := &api.ExecAccessTemplateList{}
+	if err := cl.List(ctx, execTmpls); err != nil {
+		return nil, err
+	}
+	for i := range execTmpls.Items {
+		templates = append(templates, &execTmpls.Items[i])
+	}
+
+	podTmpls := &api.PodAccessTemplateList{}
+	if err := cl.List(ctx, podTmpls); err != nil {
+		return nil, err
+	}
+	for i := range podTmpls.Items {
+		templates = append(templates, &podTmpls.Items[i])
+	}
+	return templates, nil
+}
+
+// listAccessRequests returns the Access Requests of every kind that the
+// supplied client can see.
+func listAccessRequests(ctx context.Context, cl client.Client) ([]api.IRequestResource, error) {
+	var requests []api.IRequestResource
+
+	execReqs := &api.ExecAccessRequestList{}
+	if err := cl.List(ctx, execReqs); err != nil {
+		return nil, err
+	}
+	for i := range execReqs.Items {
+		requests = append(requests, &execReqs.Items[i])
+	}
+
+	podReqs := &api.PodAccessRequestList{}
+	if err := cl.List(ctx, podReqs); err != nil {
+		return nil, err
+	}
+	for i := range podReqs.Items {
+		requests = append(requests, &podReqs.Items[i])
+	}
+	return requests, nil
+}
+
+// describeLimits generates the human readable description of the limits that
+// apply to the Access Requests of the supplied user, given the Access
+// Templates of the namespace and the Access Requests the user can see.
+func describeLimits(
+	user string,
+	groups []string,
+	now time.Time,
+	budget time.Duration,
+	templates []api.ITemplateResource,
+	requests []api.IRequestResource,
+) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Limits for %s (groups: %s)\n", user, joinWords(groups))
+
+	var active []string
+	for _, req := range requests {
+		if api.GetRequester(req) == user && isActiveRequest(req) {
+			active = append(active, fmt.Sprintf("%s %s/%s", kindOf(req), req.GetNamespace(), req.GetName()))
+		}
+	}
+	switch len(active) {
+	case 0:
+		fmt.Fprintf(&b, "  Active:     no Access Requests\n")
+	case 1:
+		fmt.Fprintf(&b, "  Active:     1 Access Request (%s)\n", active[0])
+	default:
+		fmt.Fprintf(&b, "  Active:     %d Access Requests (%s)\n", len(active), joinWords(active))
+	}
+
+	used := dailyGrantsUsed(user, now, requests)
+	if budget > 0 {
+		remaining := budget - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		fmt.Fprintf(&b, "  Budget:     %s of %s granted today (UTC), %s remaining\n", used, budget, remaining)
+	} else {
+		fmt.Fprintf(&b, "  Budget:     %s granted today (UTC), pass --daily-grant-budget to see what is left\n", used)
+	}
+
+	fmt.Fprintf(&b, "  Templates:\n")
+	if len(templates) == 0 {
+		fmt.Fprintf(&b, "    (none)\n")
+	}
+	for _, tmpl := range templates {
+		fmt.Fprintf(&b, "    - %s %s: %s\n",
+			kindOf(tmpl), tmpl.GetName(), describeTemplateLimits(user, groups, tmpl, requests))
+	}
+	return b.String()
+}
+
+// describeTemplateLimits describes whether the supplied user may use the
+// supplied template and, for PodAccessTemplates with maxPods, how many of
+// its Pods are in use.
+func describeTemplateLimits(
+	user string,
+	groups []string,
+	tmpl api.ITemplateResource,
+	requests []api.IRequestResource,
+) string {
+	cfg := tmpl.GetAccessConfig()
+	if cfg.IsPaused() {
+		return "paused - new access requests are rejected"
+	}
+	if allowed := cfg.GetAllowedRequesters(); allowed != nil && !allowed.Allows(user, groups) {
+		return "not allowed - you are not one of its allowed requesters"
+	}
+	if !containsAny(cfg.GetAllowedGroups(), groups) {
+		return fmt.Sprintf("not allowed - you are in none of its groups (%s)", joinWords(cfg.GetAllowedGroups()))
+	}
+
+	podTmpl, ok := tmpl.(*api.PodAccessTemplate)
+	if !ok || podTmpl.Spec.MaxPods <= 0 {
+		return "allowed"
+	}
+	inUse := 0
+	for _, req := range requests {
+		podReq, ok := req.(*api.PodAccessRequest)
+		if !ok || podReq.GetNamespace() != tmpl.GetNamespace() ||
+			podReq.Spec.TemplateName != tmpl.GetName() ||
+			podReq.GetDeletionTimestamp() != nil ||
+			podReq.GetPodName() == "" {
+			continue
+		}
+		inUse++
+	}
+	return fmt.Sprintf("allowed, %d of %d Pods in use", inUse, podTmpl.Spec.MaxPods)
+}
+
+// dailyGrantsUsed returns the sum of the access granted by the Access
+// Requests that the supplied user created since the start of the current day
+// (in UTC) - the same way that the controller counts it against the daily
+// grant budget.
+func dailyGrantsUsed(user string, now time.Time, requests []api.IRequestResource) time.Duration {
+	now = now.UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var used time.Duration
+	for _, req := range requests {
+		if api.GetRequester(req) != user {
+			continue
+		}
+		created := req.GetCreationTimestamp().Time
+		expiresAt := req.GetStatus().(api.IRequestStatus).GetAccessExpiresAt()
+		if expiresAt == nil || created.Before(dayStart) {
+			continue
+		}
+		used += expiresAt.Sub(created)
+	}
+	return used
+}
+
+// isActiveRequest returns true if the request has not expired, been revoked
+// or been denied yet.
+func isActiveRequest(req api.IRequestResource) bool {
+	if !req.GetDeletionTimestamp().IsZero() {
+		return false
+	}
+	return !meta.IsStatusConditionFalse(
+		*req.GetStatus().GetConditions(), api.ConditionAccessStillValid.String(),
+	)
+}
+
+// kindOf returns the Kind of one of our resources, eg "ExecAccessRequest".
+func kindOf(obj client.Object) string {
+	switch obj.(type) {
+	case *api.ExecAccessRequest:
+		return "ExecAccessRequest"
+	case *api.PodAccessRequest:
+		return "PodAccessRequest"
+	case *api.ExecAccessTemplate:
+		return "ExecAccessTemplate"
+	case *api.PodAccessTemplate:
+		return "PodAccessTemplate"
+	}
+	return obj.GetObjectKind().GroupVersionKind().Kind
+}
+
+// containsAny returns true if any of the candidates is in the list.
+func containsAny(list []string, candidates []string) bool {
+	for _, s := range list {
+		for _, c := range candidates {
+			if s == c {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func init() {
+	limitsCmd.Flags().StringVar(&limitsUser, "requester", "",
+		"Show the limits of this user, instead of looking up your identity")
+	limitsCmd.Flags().StringSliceVar(&limitsGroups, "requester-group", nil,
+		"The groups of the --requester (may be repeated)")
+	limitsCmd.Flags().DurationVar(&limitsDailyGrantBudget, "daily-grant-budget", 0,
+		"The --daily-grant-budget that the controller is configured with")
+	kubeConfigFlags.AddFlags(limitsCmd.Flags())
+	rootCmd.AddCommand(limitsCmd)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("describeLimits()", func() {
+	now := time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+
+	// request returns an ExecAccessRequest from the supplied user, created
+	// the supplied time ago and granted for the supplied duration (if any).
+	request := func(name string, user string, age time.Duration, granted time.Duration) *api.ExecAccessRequest {
+		req := &api.ExecAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "test",
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+				Annotations:       map[string]string{api.RequestedByAnnotation: user},
+			},
+		}
+		if granted > 0 {
+			expiresAt := metav1.NewTime(now.Add(-age).Add(granted))
+			req.Status.SetAccessExpiresAt(&expiresAt)
+		}
+		return req
+	}
+
+	// expired marks the supplied request as expired.
+	expired := func(req *api.ExecAccessRequest) *api.ExecAccessRequest {
+		req.Status.Conditions = []metav1.Condition{{
+			Type:   api.ConditionAccessStillValid.String(),
+			Status: metav1.ConditionFalse,
+		}}
+		return req
+	}
+
+	podRequest := func(name string, template string, pod string) *api.PodAccessRequest {
+		return &api.PodAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec:       api.PodAccessRequestSpec{TemplateName: template},
+			Status:     api.PodAccessRequestStatus{PodName: pod},
+		}
+	}
+
+	accessConfig := api.AccessConfig{AllowedGroups: []string{"devs"}}
+	templates := []api.ITemplateResource{
+		&api.ExecAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "exec-tmpl", Namespace: "test"},
+			Spec:       api.ExecAccessTemplateSpec{AccessConfig: accessConfig},
+		},
+		&api.PodAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-tmpl", Namespace: "test"},
+			Spec:       api.PodAccessTemplateSpec{AccessConfig: accessConfig, MaxPods: 3},
+		},
+		&api.ExecAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "admin-tmpl", Namespace: "test"},
+			Spec: api.ExecAccessTemplateSpec{AccessConfig: api.AccessConfig{
+				AllowedGroups: []string{"admins", "sre"},
+			}},
+		},
+		&api.ExecAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "restricted-tmpl", Namespace: "test"},
+			Spec: api.ExecAccessTemplateSpec{AccessConfig: api.AccessConfig{
+				AllowedGroups:     []string{"devs"},
+				AllowedRequesters: &api.AllowedRequesters{Users: []string{"bob"}},
+			}},
+		},
+		&api.ExecAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "paused-tmpl", Namespace: "test"},
+			Spec: api.ExecAccessTemplateSpec{AccessConfig: api.AccessConfig{
+				AllowedGroups: []string{"devs"},
+				Paused:        true,
+			}},
+		},
+	}
+
+	requests := []api.IRequestResource{
+		// Granted today, and still active
+		request("alice-active", "alice", time.Hour, 2*time.Hour),
+		// Granted today, but already over
+		expired(request("alice-expired", "alice", 5*time.Hour, time.Hour+30*time.Minute)),
+		// Granted yesterday - no longer counts towards the budget
+		expired(request("alice-yesterday", "alice", 20*time.Hour, 4*time.Hour)),
+		// Not granted yet
+		request("alice-pending", "alice", time.Minute, 0),
+		// Somebody else entirely
+		request("bob-active", "bob", time.Hour, 8*time.Hour),
+		// Pods of the pod-tmpl in use, and queued
+		podRequest("pod-a", "pod-tmpl", "pod-a-xyz"),
+		podRequest("pod-b", "pod-tmpl", "pod-b-xyz"),
+		podRequest("pod-c", "pod-tmpl", ""),
+	}
+
+	It("Should describe the limits of the requester", func() {
+		Expect(describeLimits("alice", []string{"devs"}, now, 8*time.Hour, templates, requests)).
+			To(Equal(`Limits for alice (groups: devs)
+  Active:     2 Access Requests (ExecAccessRequest test/alice-active and ExecAccessRequest test/alice-pending)
+  Budget:     3h30m0s of 8h0m0s granted today (UTC), 4h30m0s remaining
+  Templates:
+    - ExecAccessTemplate exec-tmpl: allowed
+    - PodAccessTemplate pod-tmpl: allowed, 2 of 3 Pods in use
+    - ExecAccessTemplate admin-tmpl: not allowed - you are in none of its groups (admins and sre)
+    - ExecAccessTemplate restricted-tmpl: not allowed - you are not one of its allowed requesters
+    - ExecAccessTemplate paused-tmpl: paused - new access requests are rejected
+`))
+	})
+
+	It("Should never show a negative budget remaining", func() {
+		Expect(describeLimits("bob", []string{"admins"}, now, 4*time.Hour, nil, requests)).
+			To(Equal(`Limits for bob (groups: admins)
+  Active:     1 Access Request (ExecAccessRequest test/bob-active)
+  Budget:     8h0m0s of 4h0m0s granted today (UTC), 0s remaining
+  Templates:
+    (none)
+`))
+	})
+
+	It("Should only show the budget used without a --daily-grant-budget", func() {
+		Expect(describeLimits("carol", nil, now, 0, nil, nil)).To(ContainSubstring(
+			"  Active:     no Access Requests\n" +
+				"  Budget:     0s granted today (UTC), pass --daily-grant-budget to see what is left\n",
+		))
+	})
+})
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var (
+	pruneAllNamespaces bool
+	pruneDryRun        bool
+)
+
+var pruneExpiredRBACExample = `
+Delete the expired Roles and RoleBindings in every namespace:
+$ ozctl prune-expired-rbac --all-namespaces
+
+See what would be deleted in the current namespace, without deleting it:
+$ ozctl prune-expired-rbac --dry-run
+...
+`
+
+var pruneExpiredRBACCmd = &cobra.Command{
+	Use:   "prune-expired-rbac",
+	Short: "Delete the Roles and RoleBindings of Access Requests that have expired",
+	Long: `Deletes the Roles and RoleBindings whose crds.wizardofoz.co/expires-at annotation has passed.
+
+RBAC resources have no TTL of their own - Oz removes them when their Access Request expires. If the controller is down at that time, the access lingers until it comes back. Running the controller with --stamp-rbac-expiry records the expiry on the resources it creates, and running this command on a schedule (eg from a CronJob, with permission to list and delete Roles and RoleBindings) removes expired access even while the controller is down. The Access Requests themselves are left for the controller to clean up.`,
+	Example: pruneExpiredRBACExample,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get our Kubernetes Client
+		cl, _ := getKubeClient()
+		if pruneAllNamespaces {
+			kubeRestCfg, _ := kubeConfigFlags.ToRESTConfig()
+			cl, _ = client.New(kubeRestCfg, client.Options{})
+		}
+
+		roles := &rbacv1.RoleList{}
+		if err := cl.List(cmd.Context(), roles); err != nil {
+			cmd.Printf(logError("Error - Could not list the Roles: %s\n"), err)
+			os.Exit(1)
+		}
+		bindings := &rbacv1.RoleBindingList{}
+		if err := cl.List(cmd.Context(), bindings); err != nil {
+			cmd.Printf(logError("Error - Could not list the RoleBindings: %s\n"), err)
+			os.Exit(1)
+		}
+
+		// Delete the RoleBindings first, as they are what grants the access.
+		objs := []client.Object{}
+		for i := range bindings.Items {
+			objs = append(objs, &bindings.Items[i])
+		}
+		for i := range roles.Items {
+			objs = append(objs, &roles.Items[i])
+		}
+
+		failed := false
+		for _, obj := range expiredRBAC(objs, time.Now()) {
+			kind := "Role"
+			if _, ok := obj.(*rbacv1.RoleBinding); ok {
+				kind = "RoleBinding"
+			}
+			if pruneDryRun {
+				cmd.Printf(logNotice("Would delete %s %s/%s\n"), kind, obj.GetNamespace(), obj.GetName())
+				continue
+			}
+			if err := cl.Delete(cmd.Context(), obj); client.IgnoreNotFound(err) != nil {
+				cmd.Printf(logError("Error - Deleting %s %s/%s failed: %s\n"), kind, obj.GetNamespace(), obj.GetName(), err)
+				failed = true
+				continue
+			}
+			cmd.Printf(logSuccess("Deleted %s %s/%s\n"), kind, obj.GetNamespace(), obj.GetName())
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// expiredRBAC returns the supplied objects whose ExpiresAtAnnotation is at or
+// before now, keeping them in order. Objects without the annotation (or with
+// one that cannot be parsed) are never expired.
+func expiredRBAC(objs []client.Object, now time.Time) []client.Object {
+	expired := []client.Object{}
+	for _, obj := range objs {
+		if expiresAt, ok := api.GetExpiresAt(obj); ok && !expiresAt.After(now) {
+			expired = append(expired, obj)
+		}
+	}
+	return expired
+}
+
+func init() {
+	pruneExpiredRBACCmd.Flags().
+		BoolVarP(&pruneAllNamespaces, "all-namespaces", "A", false, "Prune the expired Roles and RoleBindings in every namespace, instead of the current one")
+	pruneExpiredRBACCmd.Flags().
+		BoolVar(&pruneDryRun, "dry-run", false, "Only list the Roles and RoleBindings that would be deleted")
+	kubeConfigFlags.AddFlags(pruneExpiredRBACCmd.Flags())
+	rootCmd.AddCommand(pruneExpiredRBACCmd)
+}
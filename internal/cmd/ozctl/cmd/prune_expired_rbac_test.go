@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("prune-expired-rbac", func() {
+	now := time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+
+	meta := func(name string, expiresAt string) metav1.ObjectMeta {
+		om := metav1.ObjectMeta{Name: name, Namespace: "test"}
+		if expiresAt != "" {
+			om.Annotations = map[string]string{api.ExpiresAtAnnotation: expiresAt}
+		}
+		return om
+	}
+
+	It("Should only return the Roles and RoleBindings that have expired", func() {
+		objs := []client.Object{
+			&rbacv1.RoleBinding{ObjectMeta: meta("expired-binding", "2023-03-14T14:00:00Z")},
+			&rbacv1.RoleBinding{ObjectMeta: meta("active-binding", "2023-03-14T16:00:00Z")},
+			&rbacv1.RoleBinding{ObjectMeta: meta("unmanaged-binding", "")},
+			&rbacv1.Role{ObjectMeta: meta("expired-role", "2023-03-14T15:00:00Z")},
+			&rbacv1.Role{ObjectMeta: meta("malformed-role", "tomorrow")},
+		}
+
+		names := []string{}
+		for _, obj := range expiredRBAC(objs, now) {
+			names = append(names, obj.GetName())
+		}
+		Expect(names).To(Equal([]string{"expired-binding", "expired-role"}))
+	})
+})
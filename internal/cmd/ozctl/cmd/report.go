@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/audit"
+)
+
+var (
+	reportSince         time.Duration
+	reportAllNamespaces bool
+	reportAuditFiles    []string
+	reportOutput        string
+)
+
+var reportExample = `
+Export the grants in the current namespace over the last 30 days:
+$ ozctl report --since 720h > grants.csv
+
+Export the grants in every namespace, including the ones that were already
+cleaned up, from audit records downloaded from the audit backend:
+$ ozctl report --all-namespaces --audit-file audit-2023-03.jsonl --output json
+...
+`
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Export the history of the access granted over a period",
+	Long: `Summarizes every grant of access over a period - who was granted what, when, for how long and who approved it - for periodic access reviews.
+
+Expired Access Requests are deleted (unless their template sets an expirationGracePeriod), so the Access Requests still in the cluster only cover recent and active grants. For a complete history, pass the audit records shipped by the controller (as newline delimited JSON) with --audit-file. The "Access request closed" records are reported, and take precedence over the Access Requests still in the cluster.`,
+	Example: reportExample,
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get our Kubernetes Client
+		cl, ns := getKubeClient()
+		now := time.Now()
+
+		if reportAllNamespaces {
+			kubeRestCfg, _ := kubeConfigFlags.ToRESTConfig()
+			cl, _ = client.New(kubeRestCfg, client.Options{})
+			ns = ""
+		}
+		requests, err := listAccessRequests(cmd.Context(), cl)
+		if err != nil {
+			cmd.Printf(logError("Error - Could not list the Access Requests: %s\n"), err)
+			os.Exit(1)
+		}
+		grants := grantsFromRequests(requests, now)
+
+		for _, path := range reportAuditFiles {
+			f, err := os.Open(path)
+			if err != nil {
+				cmd.Printf(logError("Error - Could not read the audit records: %s\n"), err)
+				os.Exit(1)
+			}
+			closed, err := grantsFromAudit(f)
+			_ = f.Close()
+			if err != nil {
+				cmd.Printf(logError("Error - Could not parse the audit records in %s: %s\n"), path, err)
+				os.Exit(1)
+			}
+			grants = mergeGrants(grants, closed)
+		}
+
+		out, err := formatReport(filterGrants(grants, ns, now.Add(-reportSince)), reportOutput)
+		if err != nil {
+			cmd.Printf(logError("Error - %s\n"), err)
+			os.Exit(1)
+		}
+		cmd.Print(out)
+	},
+}
+
+// grantRecord is a single grant of access in a report.
+type grantRecord struct {
+	Namespace  string   `json:"namespace"`
+	Name       string   `json:"name"`
+	Requester  string   `json:"requester"`
+	RequestFor string   `json:"requestFor,omitempty"`
+	Template   string   `json:"template,omitempty"`
+	Approvers  []string `json:"approvers,omitempty"`
+	GrantedAt  string   `json:"grantedAt"`
+	EndedAt    string   `json:"endedAt,omitempty"`
+	Duration   string   `json:"duration"`
+	State      string   `json:"state"`
+	Source     string   `json:"source"`
+}
+
+// reportColumns are the CSV columns of a report, in order.
+var reportColumns = []string{
+	"namespace", "name", "requester", "requestFor", "template", "approvers",
+	"grantedAt", "endedAt", "duration", "state", "source",
+}
+
+// grantsFromRequests returns the grants of the supplied Access Requests. The
+// requests whose access resources never became ready were never granted, and
+// are left out. Grants that are still active are reported as held until now.
+func grantsFromRequests(requests []api.IRequestResource, now time.Time) []grantRecord {
+	var grants []grantRecord
+	for _, req := range requests {
+		conditions := *req.GetStatus().GetConditions()
+		ready := meta.FindStatusCondition(conditions, api.ConditionAccessResourcesReady.String())
+		if ready == nil || ready.Status != metav1.ConditionTrue {
+			continue
+		}
+
+		grantedAt := ready.LastTransitionTime.Time
+		endedAt, state := now, "active"
+		if stillValid := meta.FindStatusCondition(
+			conditions, api.ConditionAccessStillValid.String(),
+		); stillValid != nil && stillValid.Status == metav1.ConditionFalse {
+			endedAt, state = stillValid.LastTransitionTime.Time, "expired"
+			// Access that ran out was only flipped on the next reconcile after
+			// it was due to expire - it ended when it was due to.
+			expiresAt := req.GetStatus().(api.IRequestStatus).GetAccessExpiresAt()
+			if expiresAt != nil && expiresAt.Time.Before(endedAt) {
+				endedAt = expiresAt.Time
+			}
+			// The reasons set by the controller, see the internal status package.
+			switch stillValid.Reason {
+			case "Revoked":
+				state = "revoked"
+			case "ForceExpired":
+				state = "forced"
+			}
+		}
+
+		grant := grantRecord{
+			Namespace:  req.GetNamespace(),
+			Name:       req.GetName(),
+			Requester:  api.GetRequester(req),
+			RequestFor: req.GetRequestFor(),
+			Template:   req.GetTemplateName(),
+			Approvers:  api.GetApprovers(req),
+			GrantedAt:  grantedAt.UTC().Format(time.RFC3339),
+			Duration:   endedAt.Sub(grantedAt).Round(time.Second).String(),
+			State:      state,
+			Source:     "cluster",
+		}
+		if state != "active" {
+			grant.EndedAt = endedAt.UTC().Format(time.RFC3339)
+		}
+		grants = append(grants, grant)
+	}
+	return grants
+}
+
+// grantsFromAudit returns the grants recorded in the supplied newline
+// delimited JSON audit records, from their "Access request closed" records.
+// Closed requests that were never granted are left out.
+func grantsFromAudit(r io.Reader) ([]grantRecord, error) {
+	var grants []grantRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		rec := audit.Record{}
+		if err := json.Unmarshal(raw, &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if rec.Message != audit.MessagePrefix+"Access request closed" || rec.Fields["granted"] != true {
+			continue
+		}
+
+		field := func(key string) string {
+			if s, ok := rec.Fields[key].(string); ok {
+				return s
+			}
+			return ""
+		}
+		var approvers []string
+		if approvedBy := field("approvedBy"); approvedBy != "" {
+			approvers = strings.Split(approvedBy, ",")
+		}
+		grants = append(grants, grantRecord{
+			Namespace:  field("namespace"),
+			Name:       field("name"),
+			Requester:  field("requester"),
+			RequestFor: field("requestFor"),
+			Template:   field("template"),
+			Approvers:  approvers,
+			GrantedAt:  field("grantedAt"),
+			EndedAt:    field("expiredAt"),
+			Duration:   field("duration"),
+			State:      field("reason"),
+			Source:     "audit",
+		})
+	}
+	return grants, scanner.Err()
+}
+
+// mergeGrants adds the grants from the audit records to the grants from the
+// cluster. An audit record replaces the grant of the same Access Request, as
+// it is the final word on it.
+func mergeGrants(grants []grantRecord, closed []grantRecord) []grantRecord {
+	index := map[string]int{}
+	for i, grant := range grants {
+		index[grant.Namespace+"/"+grant.Name] = i
+	}
+	for _, grant := range closed {
+		if i, ok := index[grant.Namespace+"/"+grant.Name]; ok {
+			grants[i] = grant
+			continue
+		}
+		index[grant.Namespace+"/"+grant.Name] = len(grants)
+		grants = append(grants, grant)
+	}
+	return grants
+}
+
+// filterGrants returns the grants in the supplied namespace (or all of them,
+// if it is empty) that were granted at or after since, ordered by when they
+// were granted.
+func filterGrants(grants []grantRecord, namespace string, since time.Time) []grantRecord {
+	ret := []grantRecord{}
+	for _, grant := range grants {
+		if namespace != "" && grant.Namespace != namespace {
+			continue
+		}
+		grantedAt, err := time.Parse(time.RFC3339, grant.GrantedAt)
+		if err != nil || grantedAt.Before(since) {
+			continue
+		}
+		ret = append(ret, grant)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].GrantedAt != ret[j].GrantedAt {
+			return ret[i].GrantedAt < ret[j].GrantedAt
+		}
+		return ret[i].Namespace+"/"+ret[i].Name < ret[j].Namespace+"/"+ret[j].Name
+	})
+	return ret
+}
+
+// formatReport renders the grants in the requested output format.
+func formatReport(grants []grantRecord, output string) (string, error) {
+	switch output {
+	case "csv":
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		_ = w.Write(reportColumns)
+		for _, g := range grants {
+			_ = w.Write([]string{
+				g.Namespace, g.Name, g.Requester, g.RequestFor, g.Template,
+				strings.Join(g.Approvers, ";"), g.GrantedAt, g.EndedAt, g.Duration,
+				g.State, g.Source,
+			})
+		}
+		w.Flush()
+		return buf.String(), w.Error()
+	case "json":
+		b, err := json.MarshalIndent(grants, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b) + "\n", nil
+	}
+	return "", fmt.Errorf("invalid --output %q, must be one of csv or json", output)
+}
+
+func init() {
+	reportCmd.Flags().
+		DurationVar(&reportSince, "since", 30*24*time.Hour, "Only report the access granted within this long")
+	reportCmd.Flags().
+		BoolVarP(&reportAllNamespaces, "all-namespaces", "A", false, "Report the access granted in every namespace, instead of the current one")
+	reportCmd.Flags().
+		StringSliceVar(&reportAuditFiles, "audit-file", nil, "Newline delimited JSON audit records to report from, alongside the cluster (may be repeated)")
+	reportCmd.Flags().
+		StringVarP(&reportOutput, "output", "o", "csv", "Output format, one of: csv, json")
+	kubeConfigFlags.AddFlags(reportCmd.Flags())
+	rootCmd.AddCommand(reportCmd)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("report", func() {
+	now := time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+
+	// Audit records, as shipped by the controller to its audit backend.
+	auditRecords := strings.Join([]string{
+		`{"time":"2023-03-01T11:30:00Z","message":"AUDIT - Access request closed","fields":{"name":"alice-old","namespace":"test","requester":"alice","requestFor":"","template":"readonly","approvedBy":"bob,carol","reason":"expired","granted":true,"grantedAt":"2023-03-01T10:00:00Z","expiredAt":"2023-03-01T11:30:00Z","duration":"1h30m0s"}}`,
+		`{"time":"2023-03-02T09:00:00Z","message":"AUDIT - Access request approved","fields":{"name":"dave-abc","namespace":"test","user":"bob","approvals":1}}`,
+		``,
+		`{"time":"2023-03-02T09:30:00Z","message":"AUDIT - Access request closed","fields":{"name":"dave-abc","namespace":"test","requester":"dave","reason":"deleted","granted":false,"grantedAt":"","expiredAt":"2023-03-02T09:30:00Z","duration":"0s"}}`,
+		`{"time":"2023-03-10T12:00:00Z","message":"AUDIT - Access request closed","fields":{"name":"erin-xyz","namespace":"other","requester":"erin","template":"admin","reason":"revoked","granted":true,"grantedAt":"2023-03-10T11:00:00Z","expiredAt":"2023-03-10T12:00:00Z","duration":"1h0m0s"}}`,
+		`{"time":"2023-03-14T14:30:00Z","message":"AUDIT - Access request closed","fields":{"name":"bob-lingering","namespace":"test","requester":"bob","template":"readonly","reason":"forced","granted":true,"grantedAt":"2023-03-14T13:00:00Z","expiredAt":"2023-03-14T14:00:00Z","duration":"1h0m0s"}}`,
+	}, "\n")
+
+	// request returns an ExecAccessRequest from the supplied user, that was
+	// granted at the supplied time, and was expired at the other (if any).
+	request := func(name string, user string, grantedAt time.Time, expiredAt time.Time) *api.ExecAccessRequest {
+		req := &api.ExecAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "test",
+				Annotations: map[string]string{
+					api.RequestedByAnnotation: user,
+					api.ApprovedByAnnotation:  "carol",
+				},
+			},
+			Spec: api.ExecAccessRequestSpec{TemplateName: "readonly"},
+		}
+		if !grantedAt.IsZero() {
+			req.Status.Conditions = append(req.Status.Conditions, metav1.Condition{
+				Type:               api.ConditionAccessResourcesReady.String(),
+				Status:             metav1.ConditionTrue,
+				LastTransitionTime: metav1.NewTime(grantedAt),
+			})
+		}
+		if !expiredAt.IsZero() {
+			req.Status.Conditions = append(req.Status.Conditions, metav1.Condition{
+				Type:               api.ConditionAccessStillValid.String(),
+				Status:             metav1.ConditionFalse,
+				Reason:             "Expired",
+				LastTransitionTime: metav1.NewTime(expiredAt),
+			})
+		}
+		return req
+	}
+
+	requests := []api.IRequestResource{
+		// Active, granted 2 hours ago
+		request("alice-active", "alice", now.Add(-2*time.Hour), time.Time{}),
+		// Expired, but lingering in its grace period - the audit record wins
+		request("bob-lingering", "bob", now.Add(-2*time.Hour), now.Add(-time.Hour)),
+		// Never granted
+		request("carol-pending", "carol", time.Time{}, time.Time{}),
+	}
+
+	grants := func() []grantRecord {
+		closed, err := grantsFromAudit(strings.NewReader(auditRecords))
+		Expect(err).ToNot(HaveOccurred())
+		return mergeGrants(grantsFromRequests(requests, now), closed)
+	}
+
+	It("Should report the grants in the namespace as CSV", func() {
+		out, err := formatReport(filterGrants(grants(), "test", now.Add(-30*24*time.Hour)), "csv")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal(
+			"namespace,name,requester,requestFor,template,approvers,grantedAt,endedAt,duration,state,source\n" +
+				"test,alice-old,alice,,readonly,bob;carol,2023-03-01T10:00:00Z,2023-03-01T11:30:00Z,1h30m0s,expired,audit\n" +
+				"test,alice-active,alice,,readonly,carol,2023-03-14T13:00:00Z,,2h0m0s,active,cluster\n" +
+				"test,bob-lingering,bob,,readonly,,2023-03-14T13:00:00Z,2023-03-14T14:00:00Z,1h0m0s,forced,audit\n",
+		))
+	})
+
+	It("Should report the grants in every namespace since the supplied time as JSON", func() {
+		out, err := formatReport(filterGrants(grants(), "", now.Add(-7*24*time.Hour)), "json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(HavePrefix("[\n  {\n    \"namespace\": \"other\",\n    \"name\": \"erin-xyz\",\n"))
+		Expect(out).ToNot(ContainSubstring("alice-old"))
+		Expect(strings.Count(out, `"source"`)).To(Equal(3))
+	})
+
+	It("Should report access that ran out as ended when it was due to expire", func() {
+		expired := request("dave-expired", "dave", now.Add(-3*time.Hour), now.Add(-90*time.Minute))
+		expiresAt := metav1.NewTime(now.Add(-2 * time.Hour))
+		expired.Status.AccessExpiresAt = &expiresAt
+		grants := grantsFromRequests([]api.IRequestResource{expired}, now)
+		Expect(grants).To(HaveLen(1))
+		Expect(grants[0].EndedAt).To(Equal("2023-03-14T13:00:00Z"))
+		Expect(grants[0].Duration).To(Equal("1h0m0s"))
+	})
+
+	It("Should report an empty period", func() {
+		out, err := formatReport(filterGrants(nil, "", now), "json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(out).To(Equal("[]\n"))
+	})
+
+	It("Should reject malformed audit records and output formats", func() {
+		_, err := grantsFromAudit(strings.NewReader("{\"message\":\n"))
+		Expect(err).To(MatchError(ContainSubstring("line 1")))
+
+		_, err = formatReport(nil, "yaml")
+		Expect(err).To(MatchError(`invalid --output "yaml", must be one of csv or json`))
+	})
+})
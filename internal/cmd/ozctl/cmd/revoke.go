@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var (
+	revokeReason   string
+	revokeIncident string
+)
+
+var revokeExample = `
+Revoke an Access Request before it expires, and record why:
+$ ozctl revoke my-request-abc12 --reason "incident resolved"
+...
+
+Revoke every Access Request tagged with an incident, once it is closed:
+$ ozctl revoke --incident INC-1234 --reason "incident closed"
+...
+`
+
+var revokeCmd = &cobra.Command{
+	Use:     "revoke <Access Request Name>",
+	Short:   "Revoke an Access Request before it expires",
+	Long:    `Revokes the access granted by an Access Request right away. The revocation and its reason are recorded in the audit log. Some Access Templates require a reason to be given. With --incident, every Access Request in the namespace tagged with the incident is revoked instead.`,
+	Example: revokeExample,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if revokeIncident != "" {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get our Kubernetes Client
+		cl, ns := getKubeClient()
+		reason := strings.TrimSpace(revokeReason)
+
+		if revokeIncident != "" {
+			reqs, err := listIncidentRequests(cmd.Context(), cl, revokeIncident)
+			if err != nil {
+				cmd.Printf(logError("Error - Could not list the Access Requests for incident %s: %s\n"),
+					revokeIncident, err)
+				os.Exit(1)
+			}
+			if len(reqs) == 0 {
+				cmd.Printf(logWarning("No Access Requests in %s are tagged with incident %s\n"), ns, revokeIncident)
+				os.Exit(0)
+			}
+
+			failed := false
+			for _, req := range reqs {
+				cmd.Printf(logNotice("Revoking %s... "), req.GetName())
+				revoked, err := revokeAccessRequest(cmd.Context(), cl, req, reason)
+				switch {
+				case err != nil:
+					cmd.Printf(logError("\nError - Revoking %s failed:\n  %s\n"), req.GetName(), err)
+					failed = true
+				case !revoked:
+					cmd.Print(logWarning("already revoked\n"))
+				default:
+					cmd.Print(logSuccess("done!\n"))
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+			return
+		}
+
+		req, err := getAccessRequest(cmd, cl, args[0], ns)
+		if err != nil {
+			cmd.Printf(logError("Error - Could not find Access Request %s: %s\n"), args[0], err)
+			os.Exit(1)
+		}
+
+		cmd.Printf(logNotice("Revoking %s... "), req.GetName())
+		revoked, err := revokeAccessRequest(cmd.Context(), cl, req, reason)
+		if err != nil {
+			cmd.Printf(logError("\nError - Revoking %s failed:\n  %s\n"), req.GetName(), err)
+			os.Exit(1)
+		}
+		if !revoked {
+			cmd.Printf(logWarning("\n%s has already been revoked\n"), req.GetName())
+			os.Exit(0)
+		}
+		cmd.Print(logSuccess("done!\n"))
+	},
+}
+
+// revokeAccessRequest sets the RevokeAnnotation on the supplied Access
+// Request, with the supplied reason. The webhook records our identity
+// alongside it, and the controller tears the access down.
+//
+// Returns:
+//   - false if the request had already been revoked
+//   - An "error" if the template of the request requires a reason and none
+//     was given, or if the request could not be updated
+func revokeAccessRequest(
+	ctx context.Context,
+	cl client.Client,
+	req api.IRequestResource,
+	reason string,
+) (bool, error) {
+	annotations := req.GetAnnotations()
+	if _, revoked := annotations[api.RevokeAnnotation]; revoked {
+		return false, nil
+	}
+
+	// Fail fast, rather than waiting for the webhook to reject the change.
+	// If the template is gone we let the webhook decide.
+	if tmpl, err := req.GetTemplate(ctx, cl); err == nil &&
+		tmpl.GetAccessConfig().IsRevokeReasonRequired() && reason == "" {
+		return false, fmt.Errorf("template %s requires a --reason", tmpl.GetName())
+	}
+
+	patch := client.MergeFrom(req.DeepCopyObject().(client.Object))
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[api.RevokeAnnotation] = reason
+	req.SetAnnotations(annotations)
+	if err := cl.Patch(ctx, req, patch); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func init() {
+	revokeCmd.Flags().
+		StringVarP(&revokeReason, "reason", "r", "", "Reason for revoking the Access Request")
+	revokeCmd.Flags().
+		StringVar(&revokeIncident, "incident", "", "Revoke every Access Request tagged with this incident ID, instead of a single one")
+	kubeConfigFlags.AddFlags(revokeCmd.Flags())
+	rootCmd.AddCommand(revokeCmd)
+}
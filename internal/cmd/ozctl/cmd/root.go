@@ -39,7 +39,7 @@ var rootCmd = &cobra.Command{
 	Long: `
 Manages Oz Access Requests and Approvals.
 
-This tool provides access to create (and approve, in the future) Access Requests
+This tool provides access to create and approve Access Requests
 for resources within a Kubernetes cluster running the Oz RBAC Controller.
 Access Requests are short-lived temporary permissions requests to manage
 existing resources, or requests for dedicated short term resources (like a
@@ -76,6 +76,10 @@ func Execute() {
 		os.Exit(1)
 	}
 
+	// When installed through krew, we are invoked as `kubectl oz`.
+	rootCmd.Use = commandName(os.Args[0])
+	rootCmd.Version = getVersionInfo().Version
+
 	// Set up the root command and make sure that doesn't fail.
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -91,8 +95,8 @@ func verifyUsernameSet() error {
 }
 
 func getDefaultKubeNamespace(cf *genericclioptions.ConfigFlags) string {
-	if *cf.Namespace != "" {
-		return *cf.Namespace
+	if ns := valueOrEnv(*cf.Namespace, envNamespace); ns != "" {
+		return ns
 	}
 
 	clientConfig := cf.ToRawKubeConfigLoader()
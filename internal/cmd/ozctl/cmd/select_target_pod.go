@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// Holder of the optional --interactive flag
+var interactive bool
+
+// isTerminal reports whether stdin is an interactive terminal. It is swapped
+// out in tests.
+var isTerminal = func() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// validateInteractive verifies that the --interactive flag can be honored.
+func validateInteractive() error {
+	if !interactive {
+		return nil
+	}
+	if targetPod != "" {
+		return fmt.Errorf("--interactive and --target-pod cannot be used together")
+	}
+	if !isTerminal() {
+		return fmt.Errorf("--interactive requires a terminal, use --target-pod instead when scripting")
+	}
+	return nil
+}
+
+// selectTargetPod lists the Pods that an ExecAccessRequest against the
+// template could land on, prompts the user to choose one of them (unless there
+// is only one), and sets it as the spec.targetPod of the request.
+func selectTargetPod(cmd *cobra.Command, req *api.ExecAccessRequest) {
+	cl, _ := getKubeClient()
+	tmpl, err := api.GetExecAccessTemplate(cmd.Context(), cl, req.GetTemplateName(), req.GetNamespace())
+	if err != nil {
+		cmd.Printf(logError("Error - Could not find ExecAccessTemplate %s: %s\n"), req.GetTemplateName(), err)
+		os.Exit(1)
+	}
+
+	pods, err := listCandidatePods(cmd.Context(), cl, tmpl, req.Spec.TargetNode)
+	if err != nil {
+		cmd.Printf(logError("Error - Could not list candidate Pods: %s\n"), err)
+		os.Exit(1)
+	}
+
+	// A single candidate is used without asking - there is nothing to choose.
+	name, err := pickCandidatePod(pods)
+	if errors.Is(err, builders.ErrMultipleCandidatePods) {
+		name, err = promptForPod(cmd.InOrStdin(), cmd.OutOrStdout(), pods)
+	} else if err == nil {
+		cmd.Printf(logNotice("Only one candidate pod, using %s\n"), name)
+	}
+	if err != nil {
+		cmd.Printf(logError("Error - %s\n"), err)
+		os.Exit(1)
+	}
+	req.Spec.TargetPod = name
+}
+
+// pickCandidatePod returns the name of the only Pod in the supplied list. If
+// there is more than one, builders.ErrMultipleCandidatePods is returned so that the
+// caller can ask the user to select one.
+func pickCandidatePod(pods []corev1.Pod) (string, error) {
+	switch len(pods) {
+	case 0:
+		return "", errors.New("no candidate pods")
+	case 1:
+		return pods[0].GetName(), nil
+	}
+	return "", fmt.Errorf("%w: %d pods", builders.ErrMultipleCandidatePods, len(pods))
+}
+
+// listCandidatePods returns the running Pods (optionally restricted to a
+// single Node) that the template selects, sorted by name. This mirrors the pod
+// selection logic of the ExecAccessBuilder, so that the user is only offered
+// Pods that the request will actually be allowed to target.
+func listCandidatePods(
+	ctx context.Context,
+	cl client.Client,
+	tmpl *api.ExecAccessTemplate,
+	nodeName string,
+) ([]corev1.Pod, error) {
+	var selector labels.Selector
+	var err error
+	if tmpl.Spec.OnlyCurrentGeneration {
+		selector, err = utils.GetCurrentRevisionSelector(ctx, cl, tmpl)
+	} else {
+		selector, err = utils.GetSelectorLabels(ctx, cl, tmpl)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	podList := &corev1.PodList{}
+	if err := cl.List(ctx, podList,
+		client.InNamespace(tmpl.GetNamespace()),
+		client.MatchingLabelsSelector{Selector: selector},
+	); err != nil {
+		return nil, err
+	}
+
+	pods := []corev1.Pod{}
+	for _, pod := range podList.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if nodeName != "" && pod.Spec.NodeName != nodeName {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+
+	if tmpl.Spec.ResolvePodsByOwnerReference {
+		if pods, err = utils.FilterOwnedPods(ctx, cl, tmpl, pods); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pods) == 0 {
+		return nil, fmt.Errorf("no running pods found for template %s", tmpl.GetName())
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].GetName() < pods[j].GetName() })
+	return pods, nil
+}
+
+// promptForPod prints a numbered list of the supplied Pods and reads the
+// user's choice, asking again until a valid number is entered.
+func promptForPod(in io.Reader, out io.Writer, pods []corev1.Pod) (string, error) {
+	fmt.Fprintln(out, "Select a target pod:")
+	for i, pod := range pods {
+		fmt.Fprintf(out, "  [%d] %s (node: %s)\n", i+1, pod.GetName(), pod.Spec.NodeName)
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "Pod number [1-%d]: ", len(pods))
+		if !scanner.Scan() {
+			return "", fmt.Errorf("no target pod selected")
+		}
+		choice, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil || choice < 1 || choice > len(pods) {
+			fmt.Fprintln(out, logWarning("Invalid selection, please enter one of the numbers above."))
+			continue
+		}
+		return pods[choice-1].GetName(), nil
+	}
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+)
+
+var _ = Describe("selectTargetPod()", func() {
+	var (
+		ctx    = context.Background()
+		labels = map[string]string{"app": "my-app"}
+		tmpl   *api.ExecAccessTemplate
+		cl     client.Client
+	)
+
+	newPod := func(name string, node string, phase corev1.PodPhase, podLabels map[string]string) client.Object {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Labels: podLabels},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	BeforeEach(func() {
+		tmpl = &api.ExecAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "exec-tmpl", Namespace: "test"},
+			Spec: api.ExecAccessTemplateSpec{
+				ControllerTargetRef: &api.CrossVersionObjectReference{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       "my-app",
+				},
+			},
+		}
+		cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "test"},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+				},
+			},
+			newPod("my-app-b", "node-2", corev1.PodRunning, labels),
+			newPod("my-app-a", "node-1", corev1.PodRunning, labels),
+			newPod("my-app-pending", "node-1", corev1.PodPending, labels),
+			newPod("other-app", "node-1", corev1.PodRunning, map[string]string{"app": "other"}),
+		).Build()
+	})
+
+	Context("listCandidatePods()", func() {
+		names := func(pods []corev1.Pod) []string {
+			ret := []string{}
+			for _, pod := range pods {
+				ret = append(ret, pod.GetName())
+			}
+			return ret
+		}
+
+		It("Should return the running pods matching the template selector, sorted", func() {
+			pods, err := listCandidatePods(ctx, cl, tmpl, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names(pods)).To(Equal([]string{"my-app-a", "my-app-b"}))
+		})
+
+		It("Should restrict the pods to the requested node", func() {
+			pods, err := listCandidatePods(ctx, cl, tmpl, "node-2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names(pods)).To(Equal([]string{"my-app-b"}))
+		})
+
+		It("Should fail if no pods are candidates", func() {
+			_, err := listCandidatePods(ctx, cl, tmpl, "node-3")
+			Expect(err).To(MatchError("no running pods found for template exec-tmpl"))
+		})
+
+		It("Should fail if the target controller does not exist", func() {
+			tmpl.Spec.ControllerTargetRef.Name = "missing"
+			_, err := listCandidatePods(ctx, cl, tmpl, "")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("pickCandidatePod()", func() {
+		It("Should auto-select the only candidate pod", func() {
+			cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "my-app", Namespace: "test"},
+					Spec: appsv1.DeploymentSpec{
+						Selector: &metav1.LabelSelector{MatchLabels: labels},
+					},
+				},
+				newPod("my-app-a", "node-1", corev1.PodRunning, labels),
+			).Build()
+			pods, err := listCandidatePods(ctx, cl, tmpl, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			name, err := pickCandidatePod(pods)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(Equal("my-app-a"))
+		})
+
+		It("Should require a selection when several pods are candidates", func() {
+			pods, err := listCandidatePods(ctx, cl, tmpl, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = pickCandidatePod(pods)
+			Expect(err).To(MatchError(builders.ErrMultipleCandidatePods))
+			Expect(err).To(MatchError(ContainSubstring("2 pods")))
+		})
+
+		It("Should fail without any candidates", func() {
+			_, err := pickCandidatePod(nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err).ToNot(MatchError(builders.ErrMultipleCandidatePods))
+		})
+	})
+
+	Context("promptForPod()", func() {
+		pods := []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "my-app-a"}, Spec: corev1.PodSpec{NodeName: "node-1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "my-app-b"}, Spec: corev1.PodSpec{NodeName: "node-2"}},
+		}
+
+		It("Should list the pods and return the chosen one", func() {
+			out := &strings.Builder{}
+			name, err := promptForPod(strings.NewReader("2\n"), out, pods)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(Equal("my-app-b"))
+			Expect(out.String()).To(ContainSubstring("  [1] my-app-a (node: node-1)\n"))
+			Expect(out.String()).To(ContainSubstring("  [2] my-app-b (node: node-2)\n"))
+		})
+
+		It("Should ask again after an invalid choice", func() {
+			out := &strings.Builder{}
+			name, err := promptForPod(strings.NewReader("3\nfoo\n 1 \n"), out, pods)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(name).To(Equal("my-app-a"))
+			Expect(strings.Count(out.String(), "Invalid selection")).To(Equal(2))
+		})
+
+		It("Should fail if no choice is made", func() {
+			_, err := promptForPod(strings.NewReader(""), &strings.Builder{}, pods)
+			Expect(err).To(MatchError("no target pod selected"))
+		})
+	})
+
+	Context("validateInteractive()", func() {
+		BeforeEach(func() {
+			origTerminal := isTerminal
+			DeferCleanup(func() {
+				interactive, targetPod, isTerminal = false, "", origTerminal
+			})
+			interactive = true
+			isTerminal = func() bool { return true }
+		})
+
+		It("Should pass on a terminal", func() {
+			Expect(validateInteractive()).To(Succeed())
+		})
+
+		It("Should refuse to run without a terminal", func() {
+			isTerminal = func() bool { return false }
+			Expect(validateInteractive()).To(MatchError(ContainSubstring("requires a terminal")))
+		})
+
+		It("Should refuse to be combined with --target-pod", func() {
+			targetPod = "my-app-a"
+			Expect(validateInteractive()).To(MatchError(ContainSubstring("cannot be used together")))
+		})
+	})
+})
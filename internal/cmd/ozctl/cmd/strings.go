@@ -4,7 +4,7 @@ package cmd
 //
 // Eg:
 //
-//	  if req.GetStatus().IsReady() {
+//	  if req.IsReady() {
 //		cmd.Printf(logSuccess(successMsg), req.GetStatus().GetAccessMessage())
 //		break
 //	  }
@@ -0,0 +1,13 @@
+package cmd
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOzctlCmd(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ozctl Cmd Suite")
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// Holder for the value of the --to flag
+var transferTo string
+
+var transferExample = `
+Hand an active access request over to the incoming on-call engineer:
+$ ozctl transfer my-request-abc12 --to jane@example.com
+...
+`
+
+var transferCmd = &cobra.Command{
+	Use:     "transfer <Access Request Name> --to <user>",
+	Short:   "Transfer an active Access Request to another user",
+	Long:    `Hands the access granted by an existing Access Request over to another user, without changing when the access expires. Only the requester, or one of the approvers, of the request may transfer it.`,
+	Example: transferExample,
+	Args:    cobra.ExactArgs(1),
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if transferTo == "" {
+			return fmt.Errorf("the --to flag is required")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		// Get our Kubernetes Client
+		cl, ns := getKubeClient()
+
+		req, err := getAccessRequest(cmd, cl, args[0], ns)
+		if err != nil {
+			cmd.Printf(logError("Error - Could not find Access Request %s: %s\n"), args[0], err)
+			os.Exit(1)
+		}
+
+		// Build the patch against the current state of the object, then set
+		// the new transferTo field.
+		patch := client.MergeFrom(req.DeepCopyObject().(client.Object))
+		switch r := req.(type) {
+		case *api.ExecAccessRequest:
+			r.Spec.TransferTo = transferTo
+		case *api.PodAccessRequest:
+			r.Spec.TransferTo = transferTo
+		}
+
+		cmd.Printf(logNotice("Transferring %s to %s... "), req.GetName(), transferTo)
+		if err := cl.Patch(cmd.Context(), req, patch); err != nil {
+			cmd.Printf(logError("\nError - Transferring %s failed:\n  %s\n"), req.GetName(), err)
+			os.Exit(1)
+		}
+		cmd.Printf(logSuccess("done! The original expiration time is unchanged.\n"))
+	},
+}
+
+// getAccessRequest looks up an Access Request by name, trying each of the
+// known Access Request kinds in turn.
+func getAccessRequest(
+	cmd *cobra.Command,
+	cl client.Client,
+	name string,
+	namespace string,
+) (api.IRequestResource, error) {
+	execReq, err := api.GetExecAccessRequest(cmd.Context(), cl, name, namespace)
+	if err == nil {
+		return execReq, nil
+	}
+	podReq, err := api.GetPodAccessRequest(cmd.Context(), cl, name, namespace)
+	if err == nil {
+		return podReq, nil
+	}
+	return nil, err
+}
+
+func init() {
+	transferCmd.Flags().
+		StringVarP(&transferTo, "to", "t", "", "Name of the user to transfer the Access Request to")
+	kubeConfigFlags.AddFlags(transferCmd.Flags())
+	rootCmd.AddCommand(transferCmd)
+}
@@ -21,15 +21,18 @@ Error: - Invalid --template name flag passed in:
   %s
 `)
 
-func verifyTemplate(cmd *cobra.Command, req api.IRequestResource) {
+// verifyTemplate makes sure that the template referenced by the request
+// exists, and returns it. Exits if it does not.
+func verifyTemplate(cmd *cobra.Command, req api.IRequestResource) api.ITemplateResource {
 	client, _ := getKubeClient()
 	cmd.Printf(accessRequestInitMsg, req.GetTemplateName(), requestNamePrefix)
 
 	// Verify the template exists
-	cmd.Printf(verifyingTemplateExistsMsg, req.GetTemplateName(), req.GetNamespace())
-	_, err := req.GetTemplate(cmd.Context(), client)
+	cmd.Printf(verifyingTemplateExistsMsg, req.GetTemplateName(), req.GetTemplateNamespace())
+	tmpl, err := req.GetTemplate(cmd.Context(), client)
 	if err != nil {
 		cmd.Printf(verifyingTemplateExistsFailedMsg, err)
 		os.Exit(1)
 	}
+	return tmpl
 }
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/diranged/oz/internal/version"
+)
+
+// krewPluginPrefix is the prefix that kubectl (and therefore krew) requires
+// plugin binaries to be named with.
+const krewPluginPrefix = "kubectl-"
+
+// controllerVersionTimeout bounds how long `ozctl version` waits on the
+// controller, so that an unreachable controller does not hang it.
+const controllerVersionTimeout = 5 * time.Second
+
+// versionReport is the build information printed by `ozctl version` - that of
+// ozctl itself, and that of the controller if it could be looked up.
+type versionReport struct {
+	version.Info
+	Controller *version.Info `json:"controller,omitempty"`
+}
+
+// getVersionInfo returns the build information of this binary.
+func getVersionInfo() version.Info {
+	info := version.Get()
+	info.Version = semverVersion(info.Version)
+	return info
+}
+
+// getControllerVersion fetches the build information of the controller from
+// its /version endpoint, through the service proxy of the Kubernetes API.
+func getControllerVersion(ctx context.Context) (version.Info, error) {
+	info := version.Info{}
+	restCfg, err := kubeConfigFlags.ToRESTConfig()
+	if err != nil {
+		return info, err
+	}
+	cs, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return info, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, controllerVersionTimeout)
+	defer cancel()
+	raw, err := cs.CoreV1().RESTClient().Get().
+		Namespace(controllerNamespace).
+		Resource("services").
+		Name(controllerService).
+		SubResource("proxy").
+		Suffix("version").
+		DoRaw(ctx)
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return info, fmt.Errorf("invalid response from the controller: %w", err)
+	}
+	info.Version = semverVersion(info.Version)
+	return info, nil
+}
+
+// semverVersion returns the supplied version with the leading "v" that krew
+// expects of plugin versions. Our release tags do not carry one. Development
+// builds are returned untouched.
+func semverVersion(v string) string {
+	if v == "dev" || strings.HasPrefix(v, "v") {
+		return v
+	}
+	return "v" + v
+}
+
+// commandName returns the name that the user invoked this binary as. When it
+// is run as a kubectl plugin (the binary, or its krew symlink, is named
+// kubectl-oz), the user typed `kubectl oz` and that is what the help output
+// should show.
+func commandName(arg0 string) string {
+	name := strings.TrimSuffix(filepath.Base(arg0), ".exe")
+	if !strings.HasPrefix(name, krewPluginPrefix) {
+		return "ozctl"
+	}
+	// kubectl maps dashes in the plugin command to underscores in the
+	// binary name, so map them back.
+	plugin := strings.ReplaceAll(strings.TrimPrefix(name, krewPluginPrefix), "_", "-")
+	return "kubectl " + plugin
+}
+
+var (
+	versionOutput       string
+	versionClientOnly   bool
+	controllerNamespace string
+	controllerService   string
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version of ozctl and the controller",
+	Long: `Prints the version of ozctl, and looks up the version of the controller through the
+service proxy of the Kubernetes API. A warning is printed if the two are different releases,
+or if the controller does not serve a CRD API version that ozctl uses. Pass --client to only
+print the version of ozctl.
+
+The --output=short format prints just the semantic version of ozctl (e.g. v1.2.3), which is
+the format krew uses for plugin versions.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report := versionReport{Info: getVersionInfo()}
+
+		// The short format is what krew reads, so it never reaches out to the cluster.
+		if !versionClientOnly && versionOutput != "short" {
+			controller, err := getControllerVersion(cmd.Context())
+			if err != nil {
+				cmd.PrintErrf(logWarning("Warning - Could not look up the version of the controller: %s\n"), err)
+			} else {
+				report.Controller = &controller
+			}
+		}
+
+		out, err := formatVersion(report, versionOutput)
+		if err != nil {
+			return err
+		}
+		cmd.Print(out)
+
+		if report.Controller != nil {
+			for _, skew := range version.Skew(report.Info, *report.Controller) {
+				cmd.PrintErrf(logWarning("Warning - %s\n"), skew)
+			}
+		}
+		return nil
+	},
+}
+
+// formatVersion renders the build information in the requested output format.
+func formatVersion(report versionReport, output string) (string, error) {
+	switch output {
+	case "":
+		out := describeBuild("ozctl", report.Info)
+		if report.Controller != nil {
+			out += describeBuild("controller", *report.Controller)
+		}
+		return out, nil
+	case "short":
+		return report.Version + "\n", nil
+	case "json":
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b) + "\n", nil
+	}
+	return "", fmt.Errorf("invalid --output %q, must be one of short or json", output)
+}
+
+// describeBuild describes the build information of one binary on one line.
+func describeBuild(name string, info version.Info) string {
+	return fmt.Sprintf("%s %s (commit: %s, built: %s, %s %s, API versions: %s)\n",
+		name, info.Version, info.Commit, info.Date, info.GoVersion, info.Platform,
+		strings.Join(info.APIVersions, ", "))
+}
+
+func init() {
+	versionCmd.Flags().
+		StringVarP(&versionOutput, "output", "o", "", "Output format, one of: short, json")
+	versionCmd.Flags().
+		BoolVar(&versionClientOnly, "client", false, "Only print the version of ozctl, without looking up the controller")
+	versionCmd.Flags().
+		StringVar(&controllerNamespace, "controller-namespace", "oz-system", "Namespace that the controller runs in")
+	versionCmd.Flags().
+		StringVar(&controllerService, "controller-service", "https:oz-controller-manager-metrics-service:8443",
+			"Service that serves the /version endpoint of the controller, as [scheme:]name[:port]")
+	kubeConfigFlags.AddFlags(versionCmd.Flags())
+	rootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/diranged/oz/internal/version"
+)
+
+var _ = Describe("version", func() {
+	info := version.Info{
+		Version:     "v1.2.3",
+		Commit:      "abc1234",
+		Date:        "2023-03-01T00:00:00Z",
+		GoVersion:   "go1.19",
+		Platform:    "darwin/arm64",
+		APIVersions: []string{"crds.wizardofoz.co/v1alpha1"},
+	}
+	controller := version.Info{
+		Version:     "v1.1.0",
+		Commit:      "def5678",
+		Date:        "2023-02-01T00:00:00Z",
+		GoVersion:   "go1.19",
+		Platform:    "linux/amd64",
+		APIVersions: []string{"crds.wizardofoz.co/v1alpha1"},
+	}
+
+	Context("semverVersion()", func() {
+		It("Should add the leading v that krew expects", func() {
+			Expect(semverVersion("1.2.3")).To(Equal("v1.2.3"))
+			Expect(semverVersion("1.2.3-rc1")).To(Equal("v1.2.3-rc1"))
+		})
+
+		It("Should leave versions that already have one alone", func() {
+			Expect(semverVersion("v1.2.3")).To(Equal("v1.2.3"))
+		})
+
+		It("Should leave development builds alone", func() {
+			Expect(semverVersion("dev")).To(Equal("dev"))
+		})
+	})
+
+	Context("formatVersion()", func() {
+		It("Should print the full build information by default", func() {
+			out, err := formatVersion(versionReport{Info: info}, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(
+				"ozctl v1.2.3 (commit: abc1234, built: 2023-03-01T00:00:00Z, go1.19 darwin/arm64, " +
+					"API versions: crds.wizardofoz.co/v1alpha1)\n",
+			))
+		})
+
+		It("Should print the build information of the controller too, once looked up", func() {
+			out, err := formatVersion(versionReport{Info: info, Controller: &controller}, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal(
+				"ozctl v1.2.3 (commit: abc1234, built: 2023-03-01T00:00:00Z, go1.19 darwin/arm64, " +
+					"API versions: crds.wizardofoz.co/v1alpha1)\n" +
+					"controller v1.1.0 (commit: def5678, built: 2023-02-01T00:00:00Z, go1.19 linux/amd64, " +
+					"API versions: crds.wizardofoz.co/v1alpha1)\n",
+			))
+		})
+
+		It("Should print only the semantic version in the short format", func() {
+			out, err := formatVersion(versionReport{Info: info, Controller: &controller}, "short")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(Equal("v1.2.3\n"))
+		})
+
+		It("Should print the build information as JSON", func() {
+			out, err := formatVersion(versionReport{Info: info}, "json")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).ToNot(ContainSubstring("controller"))
+
+			ret := version.Info{}
+			Expect(json.Unmarshal([]byte(out), &ret)).To(Succeed())
+			Expect(ret).To(Equal(info))
+
+			out, err = formatVersion(versionReport{Info: info, Controller: &controller}, "json")
+			Expect(err).ToNot(HaveOccurred())
+			report := versionReport{}
+			Expect(json.Unmarshal([]byte(out), &report)).To(Succeed())
+			Expect(report).To(Equal(versionReport{Info: info, Controller: &controller}))
+		})
+
+		It("Should reject an unknown format", func() {
+			_, err := formatVersion(versionReport{Info: info}, "yaml")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("version skew", func() {
+		It("Should warn when ozctl and the controller are different releases", func() {
+			Expect(version.Skew(info, controller)).To(Equal([]string{
+				"ozctl v1.2.3 and the controller v1.1.0 are different releases, some features may not work",
+			}))
+		})
+	})
+
+	Context("commandName()", func() {
+		It("Should be ozctl when run directly", func() {
+			Expect(commandName("/usr/local/bin/ozctl")).To(Equal("ozctl"))
+		})
+
+		It("Should be the kubectl plugin command when run through krew", func() {
+			Expect(commandName("/home/me/.krew/bin/kubectl-oz")).To(Equal("kubectl oz"))
+			Expect(commandName("kubectl-oz.exe")).To(Equal("kubectl oz"))
+		})
+
+		It("Should map underscores in the binary name back to dashes", func() {
+			Expect(commandName("kubectl-oz_ctl")).To(Equal("kubectl oz-ctl"))
+		})
+	})
+})
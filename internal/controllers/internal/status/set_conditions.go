@@ -3,10 +3,13 @@ package status
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
 )
 
 /*
@@ -48,80 +51,732 @@ func SetTargetTemplateNotExists(
 	)
 }
 
+// ReasonTemplateDeleted is the reason set on the ConditionTargetTemplateExists
+// condition by SetTargetTemplateDeleted.
+const ReasonTemplateDeleted = "Deleted"
+
+// SetTargetTemplateDeleted sets the ConditionTargetTemplateExists condition to
+// False, because the template was deleted while the request held access. The
+// message says what happens to the access.
+func SetTargetTemplateDeleted(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionTargetTemplateExists,
+		metav1.ConditionFalse,
+		ReasonTemplateDeleted,
+		message,
+	)
+}
+
 // SetRequestDurationsNotValid updates the ConditionRequestDurationsValid
 // condition on a Request resource to a failure.
 func SetRequestDurationsNotValid(
 	ctx context.Context,
 	rec hasStatusReconciler,
 	req v1alpha1.IRequestResource,
-	reason string,
+	reason string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionRequestDurationsValid,
+		metav1.ConditionFalse,
+		string(metav1.StatusReasonBadRequest),
+		reason,
+	)
+}
+
+// SetRequestDurationsValid updates the ConditionRequestDurationsValid
+// condition on a Request resource to a success.
+func SetRequestDurationsValid(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	reason string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionRequestDurationsValid,
+		metav1.ConditionTrue,
+		string(metav1.StatusSuccess),
+		reason,
+	)
+}
+
+// SetAccessNotValid updates the ConditionAccessStillValid condition to False.
+func SetAccessNotValid(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessStillValid,
+		metav1.ConditionFalse,
+		string(metav1.StatusReasonTimeout),
+		"Access expired",
+	)
+}
+
+// ReasonAccessRevoked is the reason set on the ConditionAccessStillValid
+// condition by SetAccessRevoked.
+const ReasonAccessRevoked = "Revoked"
+
+// SetAccessRevoked updates the ConditionAccessStillValid condition to False,
+// because a user revoked the access before it expired.
+func SetAccessRevoked(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessStillValid,
+		metav1.ConditionFalse,
+		ReasonAccessRevoked,
+		message,
+	)
+}
+
+// ReasonAccessForceExpired is the reason set on the ConditionAccessStillValid
+// condition by SetAccessForceExpired.
+const ReasonAccessForceExpired = "ForceExpired"
+
+// SetAccessForceExpired updates the ConditionAccessStillValid condition to
+// False, because a user forced the access to expire early.
+func SetAccessForceExpired(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	user string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessStillValid,
+		metav1.ConditionFalse,
+		ReasonAccessForceExpired,
+		fmt.Sprintf("Access expiration forced by %s", user),
+	)
+}
+
+// ReasonAccessDenied is the reason set on the ConditionAccessStillValid
+// condition by SetAccessDenied.
+const ReasonAccessDenied = "Denied"
+
+// SetAccessDenied updates the ConditionAccessStillValid condition to False,
+// because the policy of the namespace does not allow the access.
+func SetAccessDenied(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessStillValid,
+		metav1.ConditionFalse,
+		ReasonAccessDenied,
+		message,
+	)
+}
+
+// ReasonAccessMaintenance is the reason set on the ConditionAccessStillValid
+// condition by SetAccessMaintenance.
+const ReasonAccessMaintenance = "Maintenance"
+
+// SetAccessMaintenance updates the ConditionAccessStillValid condition to
+// False, because the controller is in maintenance mode and is not granting
+// any new access.
+func SetAccessMaintenance(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessStillValid,
+		metav1.ConditionFalse,
+		ReasonAccessMaintenance,
+		message,
+	)
+}
+
+// ReasonAccessOverBudget is the reason set on the ConditionAccessStillValid
+// condition by SetAccessOverBudget.
+const ReasonAccessOverBudget = "OverBudget"
+
+// SetAccessOverBudget updates the ConditionAccessStillValid condition to
+// False, because the requester has used up their daily grant budget.
+func SetAccessOverBudget(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessStillValid,
+		metav1.ConditionFalse,
+		ReasonAccessOverBudget,
+		message,
+	)
+}
+
+// ReasonAccessTargetPodReplaced is the reason set on the
+// ConditionAccessStillValid condition by SetAccessTargetPodReplaced.
+const ReasonAccessTargetPodReplaced = "TargetPodReplaced"
+
+// SetAccessTargetPodReplaced updates the ConditionAccessStillValid condition
+// to False, because the Pod that access was granted to has been replaced by a
+// different Pod with the same name.
+func SetAccessTargetPodReplaced(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessStillValid,
+		metav1.ConditionFalse,
+		ReasonAccessTargetPodReplaced,
+		message,
+	)
+}
+
+// ReasonAccessSessionEnded is the reason set on the ConditionAccessStillValid
+// condition by SetAccessSessionEnded.
+const ReasonAccessSessionEnded = "SessionEnded"
+
+// SetAccessSessionEnded updates the ConditionAccessStillValid condition to
+// False, because the access of another request in its session ended.
+func SetAccessSessionEnded(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessStillValid,
+		metav1.ConditionFalse,
+		ReasonAccessSessionEnded,
+		message,
+	)
+}
+
+// ReasonNotificationDelivered is the reason set on the
+// ConditionNotificationSent condition by SetNotificationSent.
+const ReasonNotificationDelivered = "Delivered"
+
+// SetNotificationSent updates the ConditionNotificationSent condition to True,
+// once the notification about the event has been delivered to the requester.
+func SetNotificationSent(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	event string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionNotificationSent,
+		metav1.ConditionTrue,
+		ReasonNotificationDelivered,
+		fmt.Sprintf("Notification %q delivered to the requester", event),
+	)
+}
+
+// ReasonNotificationFailed is the reason set on the ConditionNotificationSent
+// condition by SetNotificationFailed.
+const ReasonNotificationFailed = "DeliveryFailed"
+
+// SetNotificationFailed updates the ConditionNotificationSent condition to
+// False, because the notification about the event could not be delivered to
+// the requester. It is retried on the next reconcile.
+func SetNotificationFailed(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	event string,
+	err error,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionNotificationSent,
+		metav1.ConditionFalse,
+		ReasonNotificationFailed,
+		fmt.Sprintf("Notification %q could not be delivered to the requester, will retry. ERROR: %s", event, err),
+	)
+}
+
+// ReasonAccessAuthorized is the reason set on the ConditionAccessEffective
+// condition by SetAccessEffective.
+const ReasonAccessAuthorized = "Authorized"
+
+// SetAccessEffective updates the ConditionAccessEffective condition to True,
+// once a SubjectAccessReview has confirmed that the requester may use the
+// access that was granted.
+func SetAccessEffective(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessEffective,
+		metav1.ConditionTrue,
+		ReasonAccessAuthorized,
+		message,
+	)
+}
+
+// ReasonAccessNotAuthorized is the reason set on the ConditionAccessEffective
+// condition by SetAccessNotEffective.
+const ReasonAccessNotAuthorized = "NotAuthorized"
+
+// SetAccessNotEffective updates the ConditionAccessEffective condition to
+// False, because a SubjectAccessReview found that the requester may not use
+// the access that was granted - usually because another policy in the
+// cluster conflicts with it.
+func SetAccessNotEffective(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessEffective,
+		metav1.ConditionFalse,
+		ReasonAccessNotAuthorized,
+		message,
+	)
+}
+
+// ReasonAccessInUse is the reason set on the ConditionInUse condition by
+// SetAccessInUse.
+const ReasonAccessInUse = "Active"
+
+// SetAccessInUse updates the ConditionInUse condition to True, because the
+// access has been active within the idleTimeout of its template, and its
+// expiration has been deferred.
+func SetAccessInUse(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionInUse,
+		metav1.ConditionTrue,
+		ReasonAccessInUse,
+		message,
+	)
+}
+
+// ReasonAccessIdle is the reason set on the ConditionInUse condition by
+// SetAccessIdle.
+const ReasonAccessIdle = "Idle"
+
+// SetAccessIdle updates the ConditionInUse condition to False, because the
+// access has not been active within the idleTimeout of its template, and it
+// expires on time.
+func SetAccessIdle(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionInUse,
+		metav1.ConditionFalse,
+		ReasonAccessIdle,
+		message,
+	)
+}
+
+// ReasonAccessReviewFailed is the reason set on the ConditionAccessEffective
+// condition by SetAccessEffectiveUnknown.
+const ReasonAccessReviewFailed = "ReviewFailed"
+
+// SetAccessEffectiveUnknown updates the ConditionAccessEffective condition to
+// Unknown, because the SubjectAccessReview itself failed. It is retried on
+// the next reconcile.
+func SetAccessEffectiveUnknown(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	err error,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessEffective,
+		metav1.ConditionUnknown,
+		ReasonAccessReviewFailed,
+		fmt.Sprintf("Unable to review the access, will retry. ERROR: %s", err),
+	)
+}
+
+// SetAccessStillValid updates the ConditionAccessStillValid condition to True.
+func SetAccessStillValid(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessStillValid,
+		metav1.ConditionTrue,
+		string(metav1.StatusSuccess),
+		"Access still valid",
+	)
+}
+
+// SetAccessResourcesNotCreated updates the ConditionAccessResourcesCreated condition to False.
+func SetAccessResourcesNotCreated(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	err error,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessResourcesCreated,
+		metav1.ConditionFalse,
+		string(metav1.StatusFailure),
+		fmt.Sprintf("ERROR: %s", err),
+	)
+}
+
+// ReasonTargetPodNotFound is the reason set on the
+// ConditionAccessResourcesCreated and ConditionAccessStillValid conditions of
+// an Access Request whose target Pod does not exist.
+const ReasonTargetPodNotFound = "TargetPodNotFound"
+
+// SetAccessTargetPodNotFound updates the ConditionAccessStillValid condition
+// to False, because the target Pod of the request does not exist, so the
+// access can never be granted (or kept).
+func SetAccessTargetPodNotFound(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessStillValid,
+		metav1.ConditionFalse,
+		ReasonTargetPodNotFound,
+		message,
+	)
+}
+
+// SetAccessResourcesTargetPodNotFound updates the
+// ConditionAccessResourcesCreated condition to False, indicating that the
+// resources will never be created because the target Pod does not exist.
+func SetAccessResourcesTargetPodNotFound(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	err error,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessResourcesCreated,
+		metav1.ConditionFalse,
+		ReasonTargetPodNotFound,
+		fmt.Sprintf("ERROR: %s", err),
+	)
+}
+
+// ReasonWaitingForPod is the reason set on the ConditionAccessResourcesCreated
+// condition of an Access Request that is waiting for a candidate Pod to show
+// up, within the pod selection timeout of its template.
+const ReasonWaitingForPod = "WaitingForPod"
+
+// SetAccessResourcesWaitingForPod updates the ConditionAccessResourcesCreated
+// condition to False, indicating that the resources are waiting on a
+// candidate Pod before they can be created.
+func SetAccessResourcesWaitingForPod(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	err error,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessResourcesCreated,
+		metav1.ConditionFalse,
+		ReasonWaitingForPod,
+		fmt.Sprintf("%s", err),
+	)
+}
+
+// ReasonTargetPodSelectionRequired is the reason set on the
+// ConditionAccessResourcesCreated condition of an Access Request that matches
+// several candidate Pods, through a template that leaves the choice between
+// them to the requester.
+const ReasonTargetPodSelectionRequired = "TargetPodSelectionRequired"
+
+// SetAccessResourcesTargetPodSelectionRequired updates the
+// ConditionAccessResourcesCreated condition to False, indicating that the
+// resources cannot be created until the requester picks a target Pod.
+func SetAccessResourcesTargetPodSelectionRequired(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	err error,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessResourcesCreated,
+		metav1.ConditionFalse,
+		ReasonTargetPodSelectionRequired,
+		fmt.Sprintf("%s", err),
+	)
+}
+
+// SetAccessResourcesQueued updates the ConditionAccessResourcesCreated
+// condition to False, indicating that the resources are waiting on capacity
+// to free up before they can be created.
+func SetAccessResourcesQueued(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	err error,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessResourcesCreated,
+		metav1.ConditionFalse,
+		builders.ReasonAccessResourcesQueued,
+		fmt.Sprintf("%s", err),
+	)
+}
+
+// ReasonAccessResourcesRemoved is the reason set on the
+// ConditionAccessResourcesCreated condition by SetAccessResourcesRemoved.
+const ReasonAccessResourcesRemoved = "Removed"
+
+// SetAccessResourcesRemoved updates the ConditionAccessResourcesCreated
+// condition to False, because the access resources were torn down once the
+// access ended, while the request itself is kept around for a grace period.
+func SetAccessResourcesRemoved(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	deleteAt time.Time,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessResourcesCreated,
+		metav1.ConditionFalse,
+		ReasonAccessResourcesRemoved,
+		fmt.Sprintf("Access resources removed, request will be deleted at %s",
+			deleteAt.UTC().Format(time.RFC3339)),
+	)
+}
+
+// SetAccessResourcesCreated updates the ConditionAccessResourcesCreated condition to True.
+func SetAccessResourcesCreated(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessResourcesCreated,
+		metav1.ConditionTrue,
+		string(metav1.StatusSuccess),
+		message)
+}
+
+// SetAccessResourcesNotReady updates the ConditionAccessResourcesReady condition to False.
+func SetAccessResourcesNotReady(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	err error,
 ) error {
 	return UpdateCondition(
 		ctx,
 		rec,
 		req,
-		v1alpha1.ConditionRequestDurationsValid,
+		v1alpha1.ConditionAccessResourcesReady,
 		metav1.ConditionFalse,
-		string(metav1.StatusReasonBadRequest),
-		reason,
+		"NotYetReady",
+		fmt.Sprintf("%s", err),
 	)
 }
 
-// SetRequestDurationsValid updates the ConditionRequestDurationsValid
-// condition on a Request resource to a success.
-func SetRequestDurationsValid(
+// SetAccessResourcesReady updates the ConditionAccessResourcesReady condition to True.
+func SetAccessResourcesReady(
 	ctx context.Context,
 	rec hasStatusReconciler,
 	req v1alpha1.IRequestResource,
-	reason string,
+	message string,
 ) error {
 	return UpdateCondition(
 		ctx,
 		rec,
 		req,
-		v1alpha1.ConditionRequestDurationsValid,
+		v1alpha1.ConditionAccessResourcesReady,
 		metav1.ConditionTrue,
 		string(metav1.StatusSuccess),
-		reason,
+		message)
+}
+
+// SetAccessNotApproved updates the ConditionAccessApproved condition to False.
+func SetAccessNotApproved(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessApproved,
+		metav1.ConditionFalse,
+		"PendingApproval",
+		message,
 	)
 }
 
-// SetAccessNotValid updates the ConditionAccessStillValid condition to False.
-func SetAccessNotValid(
+// ReasonAccessRejected is the reason set on the ConditionAccessApproved
+// condition by SetAccessRejected.
+const ReasonAccessRejected = "Rejected"
+
+// SetAccessRejected updates the ConditionAccessApproved condition to False,
+// because an external approval system rejected the request.
+func SetAccessRejected(
 	ctx context.Context,
 	rec hasStatusReconciler,
 	req v1alpha1.IRequestResource,
+	message string,
 ) error {
 	return UpdateCondition(
 		ctx,
 		rec,
 		req,
-		v1alpha1.ConditionAccessStillValid,
+		v1alpha1.ConditionAccessApproved,
 		metav1.ConditionFalse,
-		string(metav1.StatusReasonTimeout),
-		"Access expired",
+		ReasonAccessRejected,
+		message,
 	)
 }
 
-// SetAccessStillValid updates the ConditionAccessStillValid condition to True.
-func SetAccessStillValid(
+// ReasonAccessRenewalPendingApproval is the reason set on the
+// ConditionAccessApproved condition by SetAccessRenewalNotApproved.
+const ReasonAccessRenewalPendingApproval = "PendingRenewalApproval"
+
+// SetAccessRenewalNotApproved updates the ConditionAccessApproved condition to
+// False, because an extension of the access that was already granted requires
+// a fresh approval.
+func SetAccessRenewalNotApproved(
 	ctx context.Context,
 	rec hasStatusReconciler,
 	req v1alpha1.IRequestResource,
+	message string,
 ) error {
 	return UpdateCondition(
 		ctx,
 		rec,
 		req,
-		v1alpha1.ConditionAccessStillValid,
+		v1alpha1.ConditionAccessApproved,
+		metav1.ConditionFalse,
+		ReasonAccessRenewalPendingApproval,
+		message,
+	)
+}
+
+// SetAccessApproved updates the ConditionAccessApproved condition to True.
+func SetAccessApproved(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionAccessApproved,
 		metav1.ConditionTrue,
 		string(metav1.StatusSuccess),
-		"Access still valid",
+		message,
 	)
 }
 
-// SetAccessResourcesNotCreated updates the ConditionAccessResourcesCreated condition to False.
-func SetAccessResourcesNotCreated(
+// SetControllerPermissionsDenied updates the ConditionControllerPermissions
+// condition to False, telling the operator that the controller needs more
+// RBAC permissions in the namespace of the request.
+func SetControllerPermissionsDenied(
 	ctx context.Context,
 	rec hasStatusReconciler,
 	req v1alpha1.IRequestResource,
@@ -131,50 +786,123 @@ func SetAccessResourcesNotCreated(
 		ctx,
 		rec,
 		req,
-		v1alpha1.ConditionAccessResourcesCreated,
+		v1alpha1.ConditionControllerPermissions,
 		metav1.ConditionFalse,
-		string(metav1.StatusFailure),
-		fmt.Sprintf("ERROR: %s", err),
+		string(metav1.StatusReasonForbidden),
+		fmt.Sprintf(
+			"The controller is not permitted to create access resources in namespace %s. "+
+				"Grant the controller ServiceAccount permission to manage Roles and RoleBindings "+
+				"there. ERROR: %s",
+			req.GetNamespace(), err,
+		),
 	)
 }
 
-// SetAccessResourcesCreated updates the ConditionAccessResourcesCreated condition to True.
-func SetAccessResourcesCreated(
+// SetControllerPermissionsGranted updates the ConditionControllerPermissions
+// condition to True.
+func SetControllerPermissionsGranted(
 	ctx context.Context,
 	rec hasStatusReconciler,
 	req v1alpha1.IRequestResource,
-	message string,
 ) error {
 	return UpdateCondition(
 		ctx,
 		rec,
 		req,
-		v1alpha1.ConditionAccessResourcesCreated,
+		v1alpha1.ConditionControllerPermissions,
 		metav1.ConditionTrue,
 		string(metav1.StatusSuccess),
-		message)
+		"The controller is permitted to create access resources",
+	)
 }
 
-// SetAccessResourcesNotReady updates the ConditionAccessResourcesReady condition to False.
-func SetAccessResourcesNotReady(
+// SetRequestDuplicate updates the ConditionRequestUnique condition to False,
+// pointing the user at the original Access Request that grants their access.
+func SetRequestDuplicate(
 	ctx context.Context,
 	rec hasStatusReconciler,
 	req v1alpha1.IRequestResource,
-	err error,
+	original string,
 ) error {
 	return UpdateCondition(
 		ctx,
 		rec,
 		req,
-		v1alpha1.ConditionAccessResourcesReady,
+		v1alpha1.ConditionRequestUnique,
 		metav1.ConditionFalse,
-		"NotYetReady",
-		fmt.Sprintf("%s", err),
+		"Duplicate",
+		fmt.Sprintf("Duplicate of %s, which already grants the same access", original),
 	)
 }
 
-// SetAccessResourcesReady updates the ConditionAccessResourcesReady condition to True.
-func SetAccessResourcesReady(
+// SetRequestUnique updates the ConditionRequestUnique condition to True.
+func SetRequestUnique(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionRequestUnique,
+		metav1.ConditionTrue,
+		string(metav1.StatusSuccess),
+		"No other active request grants the same access",
+	)
+}
+
+// ReasonTargetPodShared is the reason set on the ConditionTargetPodExclusive
+// condition by SetTargetPodShared.
+const ReasonTargetPodShared = "Shared"
+
+// SetTargetPodExclusive updates the ConditionTargetPodExclusive condition to
+// True, because no other active Access Request was assigned the same Pod.
+func SetTargetPodExclusive(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	podName string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionTargetPodExclusive,
+		metav1.ConditionTrue,
+		string(metav1.StatusSuccess),
+		fmt.Sprintf("No other active request was assigned Pod %s", podName),
+	)
+}
+
+// SetTargetPodShared updates the ConditionTargetPodExclusive condition to
+// False, naming the other active Access Requests that were assigned the same
+// Pod.
+func SetTargetPodShared(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	podName string,
+	others []string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionTargetPodExclusive,
+		metav1.ConditionFalse,
+		ReasonTargetPodShared,
+		fmt.Sprintf("Pod %s was also assigned to %s", podName, strings.Join(others, ", ")),
+	)
+}
+
+// ReasonApprovalSLOBreached is the reason set on the
+// ConditionApprovalWithinSLO condition by SetApprovalSLOBreached.
+const ReasonApprovalSLOBreached = "Breached"
+
+// SetApprovalWithinSLO updates the ConditionApprovalWithinSLO condition to
+// True, because the request was approved within the approval SLO.
+func SetApprovalWithinSLO(
 	ctx context.Context,
 	rec hasStatusReconciler,
 	req v1alpha1.IRequestResource,
@@ -184,10 +912,31 @@ func SetAccessResourcesReady(
 		ctx,
 		rec,
 		req,
-		v1alpha1.ConditionAccessResourcesReady,
+		v1alpha1.ConditionApprovalWithinSLO,
 		metav1.ConditionTrue,
 		string(metav1.StatusSuccess),
-		message)
+		message,
+	)
+}
+
+// SetApprovalSLOBreached updates the ConditionApprovalWithinSLO condition to
+// False, because the request waited on approval for longer than the approval
+// SLO - whether or not it has been approved since.
+func SetApprovalSLOBreached(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	req v1alpha1.IRequestResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		req,
+		v1alpha1.ConditionApprovalWithinSLO,
+		metav1.ConditionFalse,
+		ReasonApprovalSLOBreached,
+		message,
+	)
 }
 
 /*
@@ -232,6 +981,82 @@ func SetTargetRefNotExists(
 	)
 }
 
+// SetClusterRoleRefExists updates the ConditionClusterRoleRefExists condition
+// on a Template resource to success.
+func SetClusterRoleRefExists(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	tmpl v1alpha1.ITemplateResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		tmpl,
+		v1alpha1.ConditionClusterRoleRefExists,
+		metav1.ConditionTrue,
+		string(metav1.StatusSuccess),
+		message,
+	)
+}
+
+// SetClusterRoleRefNotExists updates the ConditionClusterRoleRefExists
+// condition on a Template resource to a failure based on the Error supplied.
+func SetClusterRoleRefNotExists(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	tmpl v1alpha1.ITemplateResource,
+	err error,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		tmpl,
+		v1alpha1.ConditionClusterRoleRefExists,
+		metav1.ConditionFalse,
+		string(metav1.StatusReasonNotFound),
+		fmt.Sprintf("Error: %s", err),
+	)
+}
+
+// SetNamespacePatternValid updates the ConditionNamespacePatternValid
+// condition on a Template resource to success.
+func SetNamespacePatternValid(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	tmpl v1alpha1.ITemplateResource,
+	message string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		tmpl,
+		v1alpha1.ConditionNamespacePatternValid,
+		metav1.ConditionTrue,
+		string(metav1.StatusSuccess),
+		message,
+	)
+}
+
+// SetNamespacePatternNotValid updates the ConditionNamespacePatternValid
+// condition on a Template resource to a failure based on the Error supplied.
+func SetNamespacePatternNotValid(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	tmpl v1alpha1.ITemplateResource,
+	err error,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		tmpl,
+		v1alpha1.ConditionNamespacePatternValid,
+		metav1.ConditionFalse,
+		string(metav1.StatusReasonInvalid),
+		fmt.Sprintf("Error: %s", err),
+	)
+}
+
 // SetTemplateDurationsNotValid updates the ConditionTemplateDurationsValid
 // condition on a Template resource to a failure.
 func SetTemplateDurationsNotValid(
@@ -269,3 +1094,90 @@ func SetTemplateDurationsValid(
 		reason,
 	)
 }
+
+// ReasonMaxDurationExceedsCeiling is the ConditionMaxDurationWithinCeiling
+// reason used when a template allows for longer access than the controller
+// will ever grant.
+const ReasonMaxDurationExceedsCeiling = "ExceedsCeiling"
+
+// SetMaxDurationWithinCeiling updates the ConditionMaxDurationWithinCeiling
+// condition on a Template resource to a success.
+func SetMaxDurationWithinCeiling(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	tmpl v1alpha1.ITemplateResource,
+	ceiling time.Duration,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		tmpl,
+		v1alpha1.ConditionMaxDurationWithinCeiling,
+		metav1.ConditionTrue,
+		string(metav1.StatusSuccess),
+		fmt.Sprintf("spec.maxDuration is within the absolute maximum duration of %s", ceiling),
+	)
+}
+
+// SetMaxDurationExceedsCeiling updates the ConditionMaxDurationWithinCeiling
+// condition on a Template resource to warn that its spec.maxDuration will
+// never be honored.
+func SetMaxDurationExceedsCeiling(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	tmpl v1alpha1.ITemplateResource,
+	maxDuration time.Duration,
+	ceiling time.Duration,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		tmpl,
+		v1alpha1.ConditionMaxDurationWithinCeiling,
+		metav1.ConditionFalse,
+		ReasonMaxDurationExceedsCeiling,
+		fmt.Sprintf(
+			"Warning: spec.maxDuration (%s) exceeds the absolute maximum duration of %s, "+
+				"requests will be clamped to %s regardless",
+			maxDuration, ceiling, ceiling,
+		),
+	)
+}
+
+// SetTemplateNotValid updates the ConditionTemplateValid condition on a
+// Template resource to a failure.
+func SetTemplateNotValid(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	tmpl v1alpha1.ITemplateResource,
+	reason string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		tmpl,
+		v1alpha1.ConditionTemplateValid,
+		metav1.ConditionFalse,
+		string(metav1.StatusReasonInvalid),
+		reason,
+	)
+}
+
+// SetTemplateValid updates the ConditionTemplateValid condition on a Template
+// resource to a success.
+func SetTemplateValid(
+	ctx context.Context,
+	rec hasStatusReconciler,
+	tmpl v1alpha1.ITemplateResource,
+	reason string,
+) error {
+	return UpdateCondition(
+		ctx,
+		rec,
+		tmpl,
+		v1alpha1.ConditionTemplateValid,
+		metav1.ConditionTrue,
+		string(metav1.StatusSuccess),
+		reason,
+	)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"strings"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -15,28 +17,70 @@ import (
 // when all of the conditions of the resource are known to have been populated. If all Conditions are in the
 // ConditionSuccess status, then Status.Ready is set to true. Otherwise, it is set to False.
 //
+// The same result is also written to the ConditionReady condition, along with a human readable summary of
+// which conditions (if any) are holding the resource back from being ready.
+//
+// Conditions that were observed for an older metadata.generation than the current one are stale, and
+// hold the resource back from being ready until they are set again. The generation that the result was
+// computed for is recorded in Status.ObservedGeneration.
+//
 // Status.Ready is used by the 'ozctl' commandline tool to inform users when their access request
 // has been approved and configured.
 func SetReadyStatus(ctx context.Context, rec hasStatusReconciler, res api.ICoreResource) error {
 	logger := log.FromContext(ctx)
 	logger.V(1).Info("Checking final condition state")
 
-	// Default to everything being ready. We'll iterate though all conditions and then flip this to false if any
-	// of those conditions are not true.
-	ready := true
-
 	// Get the pointer to the conditions list
 	conditions := res.GetStatus().GetConditions()
 
-	// Iterate. If any are not true, then we flip the ready flag to false.
-	for _, cond := range *conditions {
-		if cond.Status != metav1.ConditionTrue {
-			ready = false
-		}
+	// Roll the conditions up into a single aggregate condition.
+	ready, reason, message := summarizeConditions(*conditions, res.GetGeneration())
+	condStatus := metav1.ConditionFalse
+	if ready {
+		condStatus = metav1.ConditionTrue
 	}
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               api.ConditionReady.String(),
+		Status:             condStatus,
+		ObservedGeneration: res.GetGeneration(),
+		Reason:             reason,
+		Message:            message,
+	})
 
 	// Save the flag, and update the object. Return the result of the object update (if its an error).
 	logger.Info(fmt.Sprintf("Setting ready state to %s", strconv.FormatBool(ready)))
 	res.GetStatus().SetReady(ready)
+	res.GetStatus().SetObservedGeneration(res.GetGeneration())
 	return UpdateStatus(ctx, rec, res)
 }
+
+// summarizeConditions iterates through all of the conditions (other than
+// ConditionReady itself) and determines whether or not the resource is ready
+// at the supplied metadata.generation.
+//
+// Returns:
+//   - A "bool" indicating whether or not every condition is True, and
+//     observed for the supplied generation
+//   - A "string" reason suitable for the ConditionReady condition
+//   - A "string" human readable message summarizing the result
+func summarizeConditions(conditions []metav1.Condition, generation int64) (bool, string, string) {
+	var notReady []string
+	for _, cond := range conditions {
+		if cond.Type == api.ConditionReady.String() || api.IsAdvisoryCondition(cond.Type) {
+			continue
+		}
+		if cond.Status != metav1.ConditionTrue {
+			notReady = append(notReady, fmt.Sprintf("%s is %s: %s", cond.Type, cond.Status, cond.Message))
+		} else if cond.ObservedGeneration < generation {
+			notReady = append(notReady, fmt.Sprintf(
+				"%s is stale: observed generation %d, current generation %d",
+				cond.Type, cond.ObservedGeneration, generation,
+			))
+		}
+	}
+
+	if len(notReady) > 0 {
+		return false, string(metav1.StatusFailure), fmt.Sprintf("Not ready: %s", strings.Join(notReady, "; "))
+	}
+	return true, string(metav1.StatusSuccess), "Ready: all conditions are True"
+}
@@ -0,0 +1,192 @@
+package status
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	api "github.com/diranged/oz/internal/api/v1alpha1"
+	testingutils "github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("SetReadyStatus()", Ordered, func() {
+	Context("summarizeConditions()", func() {
+		cond := func(t api.IConditionType, s metav1.ConditionStatus, msg string) metav1.Condition {
+			return metav1.Condition{Type: t.String(), Status: s, Message: msg}
+		}
+
+		DescribeTable("Should map sub-conditions to the aggregate",
+			func(conditions []metav1.Condition, wantReady bool, wantReason string, wantMessage string) {
+				ready, reason, message := summarizeConditions(conditions, 0)
+				Expect(ready).To(Equal(wantReady))
+				Expect(reason).To(Equal(wantReason))
+				Expect(message).To(Equal(wantMessage))
+			},
+			Entry("all conditions true",
+				[]metav1.Condition{
+					cond(api.ConditionTargetTemplateExists, metav1.ConditionTrue, "ok"),
+					cond(api.ConditionAccessResourcesReady, metav1.ConditionTrue, "ok"),
+				},
+				true, "Success", "Ready: all conditions are True",
+			),
+			Entry("one condition false",
+				[]metav1.Condition{
+					cond(api.ConditionTargetTemplateExists, metav1.ConditionTrue, "ok"),
+					cond(api.ConditionAccessResourcesReady, metav1.ConditionFalse, "Pod not ready"),
+				},
+				false, "Failure", "Not ready: AccessResourcesReady is False: Pod not ready",
+			),
+			Entry("multiple conditions not true",
+				[]metav1.Condition{
+					cond(api.ConditionTargetTemplateExists, metav1.ConditionFalse, "missing"),
+					cond(api.ConditionAccessResourcesReady, metav1.ConditionUnknown, "pending"),
+				},
+				false, "Failure",
+				"Not ready: TargetTemplateExists is False: missing; AccessResourcesReady is Unknown: pending",
+			),
+			Entry("a stale Ready condition is ignored",
+				[]metav1.Condition{
+					cond(api.ConditionReady, metav1.ConditionFalse, "old"),
+					cond(api.ConditionTargetTemplateExists, metav1.ConditionTrue, "ok"),
+				},
+				true, "Success", "Ready: all conditions are True",
+			),
+			Entry("an advisory condition is ignored",
+				[]metav1.Condition{
+					cond(api.ConditionMaxDurationWithinCeiling, metav1.ConditionFalse, "too long"),
+					cond(api.ConditionTemplateValid, metav1.ConditionTrue, "ok"),
+				},
+				true, "Success", "Ready: all conditions are True",
+			),
+			Entry("a failed notification does not hold the request back",
+				[]metav1.Condition{
+					cond(api.ConditionNotificationSent, metav1.ConditionFalse, "unavailable"),
+					cond(api.ConditionAccessResourcesReady, metav1.ConditionTrue, "ok"),
+				},
+				true, "Success", "Ready: all conditions are True",
+			),
+			Entry("no conditions at all",
+				[]metav1.Condition{},
+				true, "Success", "Ready: all conditions are True",
+			),
+		)
+
+		It("Should ignore conditions observed for an older generation", func() {
+			conditions := []metav1.Condition{
+				cond(api.ConditionTargetTemplateExists, metav1.ConditionTrue, "ok"),
+				cond(api.ConditionAccessResourcesReady, metav1.ConditionTrue, "ok"),
+			}
+			conditions[0].ObservedGeneration = 2
+			conditions[1].ObservedGeneration = 1
+
+			ready, _, message := summarizeConditions(conditions, 2)
+			Expect(ready).To(BeFalse())
+			Expect(message).To(Equal(
+				"Not ready: AccessResourcesReady is stale: observed generation 1, current generation 2",
+			))
+		})
+	})
+
+	Context("Functional Tests", func() {
+		var namespace *corev1.Namespace
+		ctx := context.Background()
+
+		BeforeAll(func() {
+			By("Creating the Namespace to perform the tests")
+			namespace = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: testingutils.RandomString(8),
+				},
+			}
+			Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+		})
+
+		It("Should write the Ready condition alongside Status.Ready", func() {
+			req := &api.PodAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testingutils.RandomString(8),
+					Namespace: namespace.Name,
+				},
+				Spec: api.PodAccessRequestSpec{
+					TemplateName: "Junk",
+					Duration:     "1h",
+				},
+			}
+			reconciler := &mockReconciler{
+				Client:    k8sClient,
+				Scheme:    k8sClient.Scheme(),
+				APIReader: k8sClient,
+			}
+			Expect(k8sClient.Create(ctx, req)).To(Succeed())
+
+			By("Marking a sub-condition as failed")
+			Expect(SetTargetTemplateNotExists(ctx, reconciler, req, errors.New("not found"))).To(Succeed())
+			Expect(SetReadyStatus(ctx, reconciler, req)).To(Succeed())
+			Expect(req.Status.IsReady()).To(BeFalse())
+			ready := meta.FindStatusCondition(req.Status.Conditions, api.ConditionReady.String())
+			Expect(ready).ToNot(BeNil())
+			Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+			Expect(ready.Message).To(ContainSubstring("TargetTemplateExists is False"))
+
+			By("Fixing the sub-condition, the aggregate should flip back to True")
+			Expect(SetTargetTemplateExists(ctx, reconciler, req)).To(Succeed())
+			Expect(SetReadyStatus(ctx, reconciler, req)).To(Succeed())
+			Expect(req.Status.IsReady()).To(BeTrue())
+			ready = meta.FindStatusCondition(req.Status.Conditions, api.ConditionReady.String())
+			Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("Should not be ready after a spec change, until the conditions are set again", func() {
+			req := &api.PodAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      testingutils.RandomString(8),
+					Namespace: namespace.Name,
+				},
+				Spec: api.PodAccessRequestSpec{
+					TemplateName: "Junk",
+					Duration:     "1h",
+				},
+			}
+			reconciler := &mockReconciler{
+				Client:    k8sClient,
+				Scheme:    k8sClient.Scheme(),
+				APIReader: k8sClient,
+			}
+			Expect(k8sClient.Create(ctx, req)).To(Succeed())
+
+			By("Reconciling the first generation")
+			Expect(SetTargetTemplateExists(ctx, reconciler, req)).To(Succeed())
+			Expect(SetReadyStatus(ctx, reconciler, req)).To(Succeed())
+			Expect(req.IsReady()).To(BeTrue())
+			Expect(req.Status.ObservedGeneration).To(Equal(req.GetGeneration()))
+			cond := meta.FindStatusCondition(req.Status.Conditions, api.ConditionTargetTemplateExists.String())
+			Expect(cond.ObservedGeneration).To(Equal(req.GetGeneration()))
+
+			By("Changing the spec, which bumps the generation")
+			generation := req.GetGeneration()
+			req.Spec.Duration = "2h"
+			Expect(k8sClient.Update(ctx, req)).To(Succeed())
+			Expect(req.GetGeneration()).To(Equal(generation + 1))
+
+			// VERIFY: The old status no longer counts as ready
+			Expect(req.Status.IsReady()).To(BeTrue())
+			Expect(req.IsReady()).To(BeFalse())
+
+			By("Re-computing the ready state without refreshing the conditions")
+			Expect(SetReadyStatus(ctx, reconciler, req)).To(Succeed())
+			Expect(req.IsReady()).To(BeFalse())
+			ready := meta.FindStatusCondition(req.Status.Conditions, api.ConditionReady.String())
+			Expect(ready.Message).To(ContainSubstring("TargetTemplateExists is stale"))
+
+			By("Re-reconciling the conditions for the new generation")
+			Expect(SetTargetTemplateExists(ctx, reconciler, req)).To(Succeed())
+			Expect(SetReadyStatus(ctx, reconciler, req)).To(Succeed())
+			Expect(req.IsReady()).To(BeTrue())
+		})
+	})
+})
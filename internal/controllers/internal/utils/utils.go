@@ -4,10 +4,13 @@ package utils
 import (
 	"context"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
 )
 
 // Refetch uses the "consistent client" (non-caching) to retreive the latest state of the object into the
@@ -28,7 +31,16 @@ func Refetch(ctx context.Context, reader client.Reader, obj client.Object) (*cli
 //
 // **Deletes**
 // On Deletes, we don't need to do any cleanup because we make sure to use
-// OwnerReferences that force Kubernetes to handle the cleanup for us.
+// OwnerReferences that force Kubernetes to handle the cleanup for us. Objects
+// with a finalizer are instead seen as an Update that sets the
+// DeletionTimestamp, which is always passed through.
+//
+// **Approvals**
+// Changes to the ApprovedByAnnotation are always passed through, so that an
+// Access Request waiting on approvals is granted as soon as it is approved.
+// Likewise, changes to the RevokeAnnotation and ExpireAnnotation are passed
+// through so that a revoked or force-expired Access Request is torn down right
+// away.
 //
 // **Status Updates**
 // Our Reconcile() loops make many updates mid-reconcile to the status fields
@@ -42,6 +54,27 @@ func Refetch(ctx context.Context, reader client.Reader, obj client.Object) (*cli
 func IgnoreStatusUpdatesAndDeletion() predicate.Predicate {
 	return predicate.Funcs{
 		UpdateFunc: func(e event.UpdateEvent) bool {
+			// Always pass through an object being marked for deletion, so
+			// that finalizers get a chance to run.
+			if !e.ObjectNew.GetDeletionTimestamp().IsZero() {
+				return true
+			}
+			// Approvals are recorded in an annotation, which does not
+			// change the metadata.Generation, so pass those through too.
+			if e.ObjectOld.GetAnnotations()[v1alpha1.ApprovedByAnnotation] !=
+				e.ObjectNew.GetAnnotations()[v1alpha1.ApprovedByAnnotation] {
+				return true
+			}
+			_, wasRevoked := e.ObjectOld.GetAnnotations()[v1alpha1.RevokeAnnotation]
+			_, isRevoked := e.ObjectNew.GetAnnotations()[v1alpha1.RevokeAnnotation]
+			if wasRevoked != isRevoked {
+				return true
+			}
+			_, wasExpired := e.ObjectOld.GetAnnotations()[v1alpha1.ExpireAnnotation]
+			_, isExpired := e.ObjectNew.GetAnnotations()[v1alpha1.ExpireAnnotation]
+			if wasExpired != isExpired {
+				return true
+			}
 			// Ignore updates to CR status in which case metadata.Generation does not change
 			return e.ObjectOld.GetGeneration() != e.ObjectNew.GetGeneration()
 		},
@@ -51,3 +84,13 @@ func IgnoreStatusUpdatesAndDeletion() predicate.Predicate {
 		},
 	}
 }
+
+// MatchesLabelSelector filters out reconcile requests for objects that do not
+// match the supplied label selector. This allows the work to be sharded
+// across several controller instances, each with their own selector. A nil
+// selector matches every object.
+func MatchesLabelSelector(selector labels.Selector) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return selector == nil || selector.Matches(labels.Set(obj.GetLabels()))
+	})
+}
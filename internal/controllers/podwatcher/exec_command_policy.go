@@ -0,0 +1,164 @@
+package podwatcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// checkCommand enforces the allowedCommands of the Access Templates on an
+// exec into a Pod. It finds the granted Access Requests for the Pod that the
+// exec'ing user is a subject of, and allows the command if any of their
+// templates allows it.
+//
+// This is best-effort: users who are not the subject of any Access Request
+// for the Pod (eg, they have exec access through other RBAC) are not checked
+// at all.
+//
+// Returns:
+//   - A "string" explaining why the command was denied
+//   - A "bool" that is false if the command must be denied
+//   - An "error" if the Access Requests or their templates could not be read
+func (w *PodExecWatcher) checkCommand(
+	ctx context.Context,
+	namespace string,
+	podName string,
+	user authenticationv1.UserInfo,
+	command []string,
+) (string, bool, error) {
+	grants, err := w.getGrants(ctx, namespace, podName)
+	if err != nil {
+		return "", false, err
+	}
+
+	var denied []string
+	for _, req := range grants {
+		tmpl, err := req.GetTemplate(ctx, w.Client)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return "", false, err
+		}
+		if !isAccessSubject(req, tmpl, user) {
+			continue
+		}
+		if tmpl.GetAccessConfig().IsCommandAllowed(command) {
+			return "", true, nil
+		}
+		denied = append(denied, fmt.Sprintf("%s (template %s allows %s)",
+			req.GetName(), tmpl.GetName(), strings.Join(tmpl.GetAccessConfig().GetAllowedCommands(), ", ")))
+	}
+
+	if len(denied) == 0 {
+		return "", true, nil
+	}
+	return fmt.Sprintf("command %q is not allowed by the access granted through %s",
+		strings.Join(command, " "), strings.Join(denied, "; ")), false, nil
+}
+
+// markActive stamps the LastActiveAnnotation on the granted Access Requests
+// for the Pod that the exec'ing user is a subject of, recording that their
+// access is still in use.
+func (w *PodExecWatcher) markActive(
+	ctx context.Context,
+	namespace string,
+	podName string,
+	user authenticationv1.UserInfo,
+) error {
+	grants, err := w.getGrants(ctx, namespace, podName)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, req := range grants {
+		tmpl, err := req.GetTemplate(ctx, w.Client)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !isAccessSubject(req, tmpl, user) {
+			continue
+		}
+
+		patch := client.MergeFrom(req.DeepCopyObject().(client.Object))
+		annotations := req.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[v1alpha1.LastActiveAnnotation] = now
+		req.SetAnnotations(annotations)
+		if err := w.Client.Patch(ctx, req, patch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getGrants returns the granted (ready) Access Requests for the named Pod.
+func (w *PodExecWatcher) getGrants(
+	ctx context.Context,
+	namespace string,
+	podName string,
+) ([]v1alpha1.IPodRequestResource, error) {
+	var grants []v1alpha1.IPodRequestResource
+
+	execReqs := &v1alpha1.ExecAccessRequestList{}
+	if err := w.Client.List(ctx, execReqs, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range execReqs.Items {
+		grants = append(grants, &execReqs.Items[i])
+	}
+
+	podReqs := &v1alpha1.PodAccessRequestList{}
+	if err := w.Client.List(ctx, podReqs, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	for i := range podReqs.Items {
+		grants = append(grants, &podReqs.Items[i])
+	}
+
+	ret := grants[:0]
+	for _, req := range grants {
+		if req.IsReady() && req.GetPodName() == podName {
+			ret = append(ret, req)
+		}
+	}
+	return ret, nil
+}
+
+// isAccessSubject returns true if the user is one of the subjects that the
+// RoleBinding of the Access Request grants access to - see
+// utils.RoleBindingSubjects().
+func isAccessSubject(
+	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
+	user authenticationv1.UserInfo,
+) bool {
+	for _, subject := range utils.RoleBindingSubjects(req, tmpl) {
+		switch subject.Kind {
+		case rbacv1.UserKind:
+			if subject.Name == user.Username {
+				return true
+			}
+		case rbacv1.GroupKind:
+			for _, userGroup := range user.Groups {
+				if subject.Name == userGroup {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package podwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("PodExecWatcher allowedCommands", func() {
+	var (
+		ctx     = context.Background()
+		watcher *PodExecWatcher
+	)
+
+	// execRequest builds the admission request that the API server sends when
+	// the user execs the command into the target Pod.
+	execRequest := func(user authenticationv1.UserInfo, command ...string) admission.Request {
+		raw, err := json.Marshal(&corev1.PodExecOptions{Command: command})
+		Expect(err).ToNot(HaveOccurred())
+		return admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Resource:    metav1.GroupVersionResource{Version: "v1", Resource: "pods"},
+				SubResource: "exec",
+				Name:        "target",
+				Namespace:   "test",
+				Operation:   admissionv1.Connect,
+				UserInfo:    user,
+				Object:      runtime.RawExtension{Raw: raw},
+			},
+		}
+	}
+
+	alice := authenticationv1.UserInfo{Username: "alice", Groups: []string{"devs"}}
+	mallory := authenticationv1.UserInfo{Username: "mallory", Groups: []string{"contractors"}}
+
+	BeforeEach(func() {
+		decoder, err := admission.NewDecoder(scheme.Scheme)
+		Expect(err).ToNot(HaveOccurred())
+
+		tmpl := &v1alpha1.ExecAccessTemplate{
+			ObjectMeta: metav1.ObjectMeta{Name: "readonly", Namespace: "test"},
+			Spec: v1alpha1.ExecAccessTemplateSpec{
+				AccessConfig: v1alpha1.AccessConfig{
+					AllowedGroups:   []string{"devs"},
+					AllowedCommands: []string{"ls", "/bin/cat"},
+				},
+			},
+		}
+		req := &v1alpha1.ExecAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "alice-abcde", Namespace: "test"},
+			Spec:       v1alpha1.ExecAccessRequestSpec{TemplateName: tmpl.GetName()},
+			Status: v1alpha1.ExecAccessRequestStatus{
+				CoreStatus: v1alpha1.CoreStatus{Ready: true},
+				PodName:    "target",
+			},
+		}
+		watcher = &PodExecWatcher{
+			Client:  fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(tmpl, req).Build(),
+			decoder: decoder,
+		}
+	})
+
+	It("Handle() should allow a command on the allowlist of the template", func() {
+		resp := watcher.Handle(ctx, execRequest(alice, "/usr/bin/ls", "-l"))
+		Expect(resp.Allowed).To(BeTrue())
+
+		resp = watcher.Handle(ctx, execRequest(alice, "/bin/cat", "/etc/hosts"))
+		Expect(resp.Allowed).To(BeTrue())
+	})
+
+	It("Handle() should deny a command that is not on the allowlist of the template", func() {
+		resp := watcher.Handle(ctx, execRequest(alice, "/bin/sh"))
+		Expect(resp.Allowed).To(BeFalse())
+		Expect(string(resp.Result.Reason)).To(ContainSubstring(`command "/bin/sh" is not allowed`))
+		Expect(string(resp.Result.Reason)).To(ContainSubstring("alice-abcde (template readonly allows ls, /bin/cat)"))
+
+		// A full path entry only matches that exact path
+		resp = watcher.Handle(ctx, execRequest(alice, "/tmp/cat"))
+		Expect(resp.Allowed).To(BeFalse())
+	})
+
+	It("Handle() should mark the access of the exec'ing user as active", func() {
+		resp := watcher.Handle(ctx, execRequest(alice, "ls"))
+		Expect(resp.Allowed).To(BeTrue())
+
+		req := &v1alpha1.ExecAccessRequest{}
+		err := watcher.Client.Get(ctx, types.NamespacedName{Name: "alice-abcde", Namespace: "test"}, req)
+		Expect(err).ToNot(HaveOccurred())
+		lastActive, ok := v1alpha1.GetLastActive(req, time.Now())
+		Expect(ok).To(BeTrue())
+		Expect(lastActive).To(BeTemporally("~", time.Now(), 2*time.Second))
+	})
+
+	It("Handle() should not mark the access as active on a dry run, or for other users", func() {
+		dryRun := true
+		admissionReq := execRequest(alice, "ls")
+		admissionReq.DryRun = &dryRun
+		Expect(watcher.Handle(ctx, admissionReq).Allowed).To(BeTrue())
+		Expect(watcher.Handle(ctx, execRequest(mallory, "ls")).Allowed).To(BeTrue())
+
+		req := &v1alpha1.ExecAccessRequest{}
+		err := watcher.Client.Get(ctx, types.NamespacedName{Name: "alice-abcde", Namespace: "test"}, req)
+		Expect(err).ToNot(HaveOccurred())
+		_, ok := v1alpha1.GetLastActive(req, time.Now())
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Handle() should leave users that were not granted access by oz alone", func() {
+		resp := watcher.Handle(ctx, execRequest(mallory, "/bin/sh"))
+		Expect(resp.Allowed).To(BeTrue())
+	})
+
+	It("Handle() should leave Pods without a granted Access Request alone", func() {
+		req := execRequest(alice, "/bin/sh")
+		req.Name = "other"
+		resp := watcher.Handle(ctx, req)
+		Expect(resp.Allowed).To(BeTrue())
+	})
+})
@@ -11,6 +11,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/diranged/oz/internal/audit"
 )
 
 // example code: https://github.com/kubernetes-sigs/controller-runtime/blob/master/examples/builtins/validatingwebhook.go
@@ -25,11 +27,15 @@ type PodExecWatcher struct {
 	decoder *admission.Decoder
 }
 
-// +kubebuilder:webhook:path=/watch-v1-pod,mutating=false,failurePolicy=fail,sideEffects=None,groups="",resources=pods/exec,verbs=create;update;connect,versions=v1,name=vpod.kb.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/watch-v1-pod,mutating=false,failurePolicy=fail,sideEffects=NoneOnDryRun,groups="",resources=pods/exec,verbs=create;update;connect,versions=v1,name=vpod.kb.io,admissionReviewVersions=v1
 
 // Handle logs out each time an Exec/Attach call is made on a pod.
 //
-// Right now this is purely an informative log event. When we take care of
+// Execs into Pods that were granted through an Access Request are checked
+// against the allowedCommands of the template (see checkCommand()), and
+// mark those Access Requests as active (see markActive()).
+//
+// Otherwise this is purely an informative log event. When we take care of
 // https://github.com/diranged/oz/issues/24, we can use this handler to push
 // events onto the Pods (and Access Requests) for audit purposes.
 //
@@ -38,6 +44,9 @@ type PodExecWatcher struct {
 // endpoint in the future.
 func (w *PodExecWatcher) Handle(ctx context.Context, req admission.Request) admission.Response {
 	logger := log.FromContext(ctx)
+
+	// The request body contains the command being executed, which may well
+	// contain sensitive data. Run it through the audit redaction rules first.
 	logger.Info(
 		fmt.Sprintf(
 			"Handling %s Operation on %s/%s by %s",
@@ -45,7 +54,7 @@ func (w *PodExecWatcher) Handle(ctx context.Context, req admission.Request) admi
 			req.Resource.Resource,
 			req.Name,
 			req.UserInfo.Username,
-		), "request", ObjectToJSON(req),
+		), audit.KeysAndValues("request", ObjectToJSON(req))...,
 	)
 
 	exec := &corev1.PodExecOptions{}
@@ -55,6 +64,29 @@ func (w *PodExecWatcher) Handle(ctx context.Context, req admission.Request) admi
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 
+	// Enforce the allowedCommands of the templates that granted the access.
+	// This is a best-effort guardrail, so a failure to look up the grants
+	// allows the exec rather than locking everybody out of the Pod.
+	msg, allowed, err := w.checkCommand(ctx, req.Namespace, req.Name, req.UserInfo, exec.Command)
+	if err != nil {
+		logger.Error(err, "Failed to check the exec command against the allowed commands")
+		return admission.Allowed("")
+	}
+	if !allowed {
+		logger.Info(fmt.Sprintf("Denying exec into %s/%s by %s: %s",
+			req.Namespace, req.Name, req.UserInfo.Username, msg))
+		return admission.Denied(msg)
+	}
+
+	// Record that the access is in use, so that templates with an
+	// idleTimeout defer its expiration. Like the command check, a failure
+	// here never blocks the exec.
+	if req.DryRun == nil || !*req.DryRun {
+		if err := w.markActive(ctx, req.Namespace, req.Name, req.UserInfo); err != nil {
+			logger.Error(err, "Failed to record the access as active")
+		}
+	}
+
 	return admission.Allowed("")
 }
 
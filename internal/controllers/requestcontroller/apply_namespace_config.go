@@ -0,0 +1,81 @@
+package requestcontroller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// applyNamespaceConfig resolves the OzConfig of the namespace of the request
+// and returns a copy of the template with it merged in, so that templates
+// which do not set their own requiredApprovals pick up the namespace default.
+// The OzConfig is kept on the RequestContext for the later verification steps.
+// If the namespace has no OzConfig, the template is returned as-is.
+func (r *RequestReconciler) applyNamespaceConfig(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+) (v1alpha1.ITemplateResource, error) {
+	rctx.log.V(1).Info("Resolving namespace configuration...")
+	cfg, err := r.getNamespaceConfig(rctx.Context, rctx.obj.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	rctx.namespaceConfig = cfg
+	return mergeNamespaceConfig(cfg, tmpl), nil
+}
+
+// mergeNamespaceConfig returns a copy of the template with the supplied
+// OzConfig merged into it, or the template itself if the OzConfig is nil.
+func mergeNamespaceConfig(
+	cfg *v1alpha1.OzConfig,
+	tmpl v1alpha1.ITemplateResource,
+) v1alpha1.ITemplateResource {
+	if cfg == nil {
+		return tmpl
+	}
+	tmpl = tmpl.DeepCopyObject().(v1alpha1.ITemplateResource)
+	cfg.ApplyTo(tmpl.GetAccessConfig())
+	return tmpl
+}
+
+// getNamespaceConfig resolves the OzConfig that applies to the supplied
+// namespace. If the namespace has no OzConfig, nil is returned.
+func (r *RequestReconciler) getNamespaceConfig(
+	ctx context.Context,
+	namespace string,
+) (*v1alpha1.OzConfig, error) {
+	cfg, err := v1alpha1.GetOzConfig(ctx, r.Client, namespace)
+	if apierrors.IsNotFound(err) {
+		cfg, err = nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get OzConfig of namespace %s: %w", namespace, err)
+	}
+	return cfg, nil
+}
+
+// getAbsoluteMaxDuration returns the ceiling on the duration of the Access
+// Requests in a namespace with the supplied OzConfig (which may be nil). The
+// OzConfig absoluteMaxDuration replaces the controller-wide
+// AbsoluteMaxDuration, if it is set.
+//
+// Returns:
+//   - An "error" if the OzConfig absoluteMaxDuration is invalid
+func (r *RequestReconciler) getAbsoluteMaxDuration(cfg *v1alpha1.OzConfig) (time.Duration, error) {
+	if cfg == nil {
+		return r.AbsoluteMaxDuration, nil
+	}
+	ceiling, err := cfg.GetAbsoluteMaxDuration()
+	if err != nil {
+		return 0, fmt.Errorf("invalid absoluteMaxDuration in OzConfig %s/%s: %w",
+			cfg.GetNamespace(), cfg.GetName(), err)
+	}
+	if ceiling == 0 {
+		return r.AbsoluteMaxDuration, nil
+	}
+	return ceiling, nil
+}
@@ -0,0 +1,313 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		applyNamespaceConfig() / verifyNotDenied() Tests
+	*/
+	Context("applyNamespaceConfig()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			otherNs    *v1.Namespace
+			ozConfig   *v1alpha1.OzConfig
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			builder    = &mockBuilder{}
+		)
+
+		// newRctx creates an ExecAccessRequest from the supplied requester in
+		// the supplied namespace, and returns a populated RequestContext for it.
+		newRctx := func(namespace string, requester string) *RequestContext {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        utils.RandomString(8),
+					Namespace:   namespace,
+					Annotations: map[string]string{v1alpha1.RequestedByAnnotation: requester},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err := k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			rctx := newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace with an OzConfig, and one without")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+			otherNs = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			err = k8sClient.Create(ctx, otherNs)
+			Expect(err).ToNot(HaveOccurred())
+
+			ozConfig = &v1alpha1.OzConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      v1alpha1.OzConfigName,
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.OzConfigSpec{
+					AbsoluteMaxDuration: "30m",
+					RequiredApprovals:   2,
+					DeniedUsers:         []string{"mallory"},
+				},
+			}
+			err = k8sClient.Create(ctx, ozConfig)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessTemplate that does not require approvals")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+
+			By("Creating the RequestReconciler with a controller-wide ceiling")
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                builder,
+				ReconciliationInterval: 0,
+				AbsoluteMaxDuration:    8 * time.Hour,
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespaces")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, otherNs)).To(Succeed())
+		})
+
+		It("applyNamespaceConfig() should merge the OzConfig into a copy of the template", func() {
+			rctx := newRctx(ns.GetName(), "alice")
+
+			tmpl, err := reconciler.applyNamespaceConfig(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The namespace default applies, the original is untouched
+			Expect(tmpl.GetAccessConfig().GetRequiredApprovals()).To(Equal(2))
+			Expect(template.Spec.AccessConfig.RequiredApprovals).To(Equal(0))
+			Expect(rctx.namespaceConfig).ToNot(BeNil())
+			Expect(rctx.namespaceConfig.Spec.DeniedUsers).To(Equal([]string{"mallory"}))
+		})
+
+		It("applyNamespaceConfig() should leave the template alone in a namespace without an OzConfig", func() {
+			rctx := newRctx(otherNs.GetName(), "alice")
+
+			tmpl, err := reconciler.applyNamespaceConfig(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tmpl).To(BeIdenticalTo(template))
+			Expect(rctx.namespaceConfig).To(BeNil())
+		})
+
+		It("getAbsoluteMaxDuration() should prefer the OzConfig over the controller-wide ceiling", func() {
+			ret, err := reconciler.getAbsoluteMaxDuration(ozConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret).To(Equal(30 * time.Minute))
+
+			ret, err = reconciler.getAbsoluteMaxDuration(nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret).To(Equal(8 * time.Hour))
+
+			ret, err = reconciler.getAbsoluteMaxDuration(&v1alpha1.OzConfig{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ret).To(Equal(8 * time.Hour))
+
+			_, err = reconciler.getAbsoluteMaxDuration(&v1alpha1.OzConfig{
+				Spec: v1alpha1.OzConfigSpec{AbsoluteMaxDuration: "forever"},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("verifyDuration() should clamp access to the absoluteMaxDuration of the namespace", func() {
+			builder.getDurationErr = nil
+			builder.getDurationResp = time.Hour
+			rctx := newRctx(ns.GetName(), "alice")
+			tmpl, err := reconciler.applyNamespaceConfig(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = reconciler.verifyDuration(rctx, tmpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The access ends at the namespace ceiling, not the controller-wide one
+			Expect(rctx.expiresAt).To(Equal(rctx.obj.GetCreationTimestamp().Add(30 * time.Minute)))
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionRequestDurationsValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Message).To(ContainSubstring("clamped to the absolute maximum duration of 30m0s"))
+		})
+
+		It("selectDurationProfile() should only pick the incident durations during an incident", func() {
+			incidentTemplate := template.DeepCopy()
+			incidentTemplate.Spec.AccessConfig.IncidentDurations = &v1alpha1.DurationProfile{
+				DefaultDuration: "4h",
+				MaxDuration:     "8h",
+			}
+			normal := &v1alpha1.OzConfig{}
+			incident := &v1alpha1.OzConfig{Spec: v1alpha1.OzConfigSpec{IncidentMode: true}}
+
+			// No OzConfig, or no declared incident: the normal durations apply
+			for _, cfg := range []*v1alpha1.OzConfig{nil, normal} {
+				tmpl, ok := selectDurationProfile(cfg, incidentTemplate)
+				Expect(ok).To(BeFalse())
+				Expect(tmpl).To(BeIdenticalTo(incidentTemplate))
+			}
+
+			// A declared incident, but the template has no incident durations
+			tmpl, ok := selectDurationProfile(incident, template)
+			Expect(ok).To(BeFalse())
+			Expect(tmpl).To(BeIdenticalTo(template))
+
+			// A declared incident: the incident durations apply to a copy
+			tmpl, ok = selectDurationProfile(incident, incidentTemplate)
+			Expect(ok).To(BeTrue())
+			Expect(tmpl.GetAccessConfig().DefaultDuration).To(Equal("4h"))
+			Expect(tmpl.GetAccessConfig().MaxDuration).To(Equal("8h"))
+			Expect(incidentTemplate.Spec.AccessConfig.DefaultDuration).To(Equal("1h"))
+		})
+
+		It("verifyDuration() should use the incident duration profile while an incident is declared", func() {
+			builder.getDurationErr = nil
+			builder.getDurationResp = 10 * time.Minute
+			incidentTemplate := template.DeepCopy()
+			incidentTemplate.Spec.AccessConfig.IncidentDurations = &v1alpha1.DurationProfile{
+				DefaultDuration: "4h",
+			}
+
+			// Declare an incident in the namespace
+			ozConfig.Spec.IncidentMode = true
+			Expect(k8sClient.Update(ctx, ozConfig)).To(Succeed())
+			DeferCleanup(func() {
+				ozConfig.Spec.IncidentMode = false
+				Expect(k8sClient.Update(ctx, ozConfig)).To(Succeed())
+			})
+
+			rctx := newRctx(ns.GetName(), "alice")
+			tmpl, err := reconciler.applyNamespaceConfig(rctx, incidentTemplate)
+			Expect(err).ToNot(HaveOccurred())
+			_, _, err = reconciler.verifyDuration(rctx, tmpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionRequestDurationsValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Message).To(ContainSubstring("using the incident duration profile"))
+
+			// Once the incident is over, the normal durations apply again
+			ozConfig.Spec.IncidentMode = false
+			Expect(k8sClient.Update(ctx, ozConfig)).To(Succeed())
+			rctx = newRctx(ns.GetName(), "alice")
+			tmpl, err = reconciler.applyNamespaceConfig(rctx, incidentTemplate)
+			Expect(err).ToNot(HaveOccurred())
+			_, _, err = reconciler.verifyDuration(rctx, tmpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			cond = meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionRequestDurationsValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Message).ToNot(ContainSubstring("incident"))
+		})
+
+		It("verifyNotDenied() should allow users that are not denied", func() {
+			rctx := newRctx(ns.GetName(), "alice")
+			_, err := reconciler.applyNamespaceConfig(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = reconciler.verifyNotDenied(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)).To(BeNil())
+		})
+
+		It("verifyNotDenied() should deny a request from a denied user", func() {
+			rctx := newRctx(ns.GetName(), "mallory")
+			_, err := reconciler.applyNamespaceConfig(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = reconciler.verifyNotDenied(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The access is flipped to invalid, so that it is torn down
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessDenied))
+			Expect(cond.Message).To(ContainSubstring("mallory is a denied user"))
+		})
+
+		It("getNamespaceConfig() should always return the current OzConfig", func() {
+			cfg, err := reconciler.getNamespaceConfig(ctx, ns.GetName())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.Spec.RequiredApprovals).To(Equal(2))
+
+			ozConfig.Spec.RequiredApprovals = 3
+			Expect(k8sClient.Update(ctx, ozConfig)).To(Succeed())
+			DeferCleanup(func() {
+				ozConfig.Spec.RequiredApprovals = 2
+				Expect(k8sClient.Update(ctx, ozConfig)).To(Succeed())
+			})
+
+			cfg, err = reconciler.getNamespaceConfig(ctx, ns.GetName())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cfg.Spec.RequiredApprovals).To(Equal(3))
+		})
+	})
+})
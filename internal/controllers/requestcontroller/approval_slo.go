@@ -0,0 +1,81 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// eventApprovalSLOBreached is the reason of the Event emitted on a request
+// once it has waited on approval for longer than the approval SLO.
+const eventApprovalSLOBreached = "ApprovalSLOBreached"
+
+// checkApprovalSLO compares how long the request waited on approval against
+// the ApprovalSLO of the reconciler, and records the outcome in the advisory
+// ConditionApprovalWithinSLO condition. The wait is counted from the creation
+// of the request until the ConditionAccessApproved condition flipped to True
+// - or until now, while it is still waiting.
+//
+// A breach is flagged as soon as the wait passes the SLO, without waiting on
+// the approval itself, so that managers can follow up. It is counted in the
+// approvalSLOBreachesTotal metric and (with a Recorder) emitted as a Warning
+// Event, once per request. While the request waits, the supplied result is
+// shortened so that it is reconciled again when the SLO runs out.
+//
+// It is a no-op unless ApprovalSLO is set, for requests that did not have to
+// wait on approval, and once the access resources have been created - later
+// approvals (eg, of a renewal) are not held to the SLO.
+func (r *RequestReconciler) checkApprovalSLO(rctx *RequestContext, result *ctrl.Result) error {
+	if r.ApprovalSLO <= 0 || accessResourcesCreated(rctx.obj) {
+		return nil
+	}
+	conditions := *rctx.obj.GetStatus().GetConditions()
+	approved := meta.FindStatusCondition(conditions, v1alpha1.ConditionAccessApproved.String())
+	if approved == nil || approved.Message == approvalNotRequiredMsg {
+		return nil
+	}
+
+	created := rctx.obj.GetCreationTimestamp().Time
+	if approved.Status == metav1.ConditionTrue {
+		waited := approved.LastTransitionTime.Sub(created).Round(time.Second)
+		if waited <= r.ApprovalSLO {
+			return status.SetApprovalWithinSLO(rctx.Context, r, rctx.obj, fmt.Sprintf(
+				"Approved after %s, within the approval SLO of %s", waited, r.ApprovalSLO))
+		}
+		return r.setApprovalSLOBreached(rctx, fmt.Sprintf(
+			"Approved after %s, over the approval SLO of %s", waited, r.ApprovalSLO))
+	}
+
+	remaining := created.Add(r.ApprovalSLO).Sub(r.getNow())
+	if remaining > 0 {
+		if result.RequeueAfter == 0 || remaining < result.RequeueAfter {
+			result.RequeueAfter = remaining
+		}
+		return nil
+	}
+	return r.setApprovalSLOBreached(rctx, fmt.Sprintf(
+		"Not approved within the approval SLO of %s", r.ApprovalSLO))
+}
+
+// setApprovalSLOBreached flips the ConditionApprovalWithinSLO condition to
+// False with the supplied message. The first time it does so for a request,
+// the breach is counted and an Event is emitted.
+func (r *RequestReconciler) setApprovalSLOBreached(rctx *RequestContext, message string) error {
+	if !meta.IsStatusConditionFalse(
+		*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionApprovalWithinSLO.String(),
+	) {
+		rctx.log.Info(message)
+		approvalSLOBreachesTotal.WithLabelValues(rctx.obj.GetNamespace()).Inc()
+		if r.Recorder != nil {
+			r.Recorder.Event(rctx.obj, corev1.EventTypeWarning, eventApprovalSLOBreached, message)
+		}
+	}
+	return status.SetApprovalSLOBreached(rctx.Context, r, rctx.obj, message)
+}
@@ -0,0 +1,172 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+var _ = Describe("RequestReconciler", func() {
+	/*
+		checkApprovalSLO() Tests
+	*/
+	Context("checkApprovalSLO()", func() {
+		var (
+			ctx        = context.Background()
+			created    = time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+			recorder   *record.FakeRecorder
+			reconciler *RequestReconciler
+		)
+
+		// newRctx returns a RequestContext for an ExecAccessRequest created at
+		// `created`, with the supplied ConditionAccessApproved condition.
+		newRctx := func(approved metav1.Condition) *RequestContext {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "slow",
+					Namespace:         "slo",
+					CreationTimestamp: metav1.Time{Time: created},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{TemplateName: "fake"},
+			}
+			meta.SetStatusCondition(&request.Status.Conditions, approved)
+
+			cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(request).Build()
+			reconciler.Client = cl
+			reconciler.APIReader = cl
+
+			rctx := newRequestContext(ctx, reconciler.RequestType, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "slow", Namespace: "slo"},
+			})
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+			return rctx
+		}
+
+		// approvedAfter returns an approved condition, flipped to True the
+		// supplied time after the request was created.
+		approvedAfter := func(wait time.Duration) metav1.Condition {
+			return metav1.Condition{
+				Type:               v1alpha1.ConditionAccessApproved.String(),
+				Status:             metav1.ConditionTrue,
+				Reason:             "Success",
+				Message:            "Approved by bob",
+				LastTransitionTime: metav1.Time{Time: created.Add(wait)},
+			}
+		}
+
+		waiting := metav1.Condition{
+			Type:    v1alpha1.ConditionAccessApproved.String(),
+			Status:  metav1.ConditionFalse,
+			Reason:  "Pending",
+			Message: "Waiting on approvals: 0 of 1 received",
+		}
+
+		sloCondition := func(rctx *RequestContext) *metav1.Condition {
+			return meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionApprovalWithinSLO.String(),
+			)
+		}
+
+		BeforeEach(func() {
+			recorder = record.NewFakeRecorder(10)
+			reconciler = &RequestReconciler{
+				Scheme:      scheme.Scheme,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Recorder:    recorder,
+				ApprovalSLO: time.Hour,
+				now:         func() time.Time { return created.Add(30 * time.Minute) },
+			}
+		})
+
+		It("Should mark requests approved within the SLO", func() {
+			rctx := newRctx(approvedAfter(45 * time.Minute))
+
+			Expect(reconciler.checkApprovalSLO(rctx, &reconcile.Result{})).To(Succeed())
+			cond := sloCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Message).To(Equal("Approved after 45m0s, within the approval SLO of 1h0m0s"))
+			Expect(recorder.Events).To(BeEmpty())
+		})
+
+		It("Should flag requests approved after the SLO", func() {
+			before := testutil.ToFloat64(approvalSLOBreachesTotal.WithLabelValues("slo"))
+			rctx := newRctx(approvedAfter(90 * time.Minute))
+
+			Expect(reconciler.checkApprovalSLO(rctx, &reconcile.Result{})).To(Succeed())
+			cond := sloCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonApprovalSLOBreached))
+			Expect(cond.Message).To(Equal("Approved after 1h30m0s, over the approval SLO of 1h0m0s"))
+
+			// VERIFY: The condition is advisory, and does not hold the request back
+			Expect(v1alpha1.IsAdvisoryCondition(cond.Type)).To(BeTrue())
+
+			// VERIFY: The breach is counted and emitted once
+			Expect(reconciler.checkApprovalSLO(rctx, &reconcile.Result{})).To(Succeed())
+			Expect(testutil.ToFloat64(approvalSLOBreachesTotal.WithLabelValues("slo"))).To(Equal(before + 1))
+			Expect(recorder.Events).To(HaveLen(1))
+			Expect(<-recorder.Events).To(Equal(
+				"Warning ApprovalSLOBreached Approved after 1h30m0s, over the approval SLO of 1h0m0s",
+			))
+		})
+
+		It("Should flag requests still waiting once the SLO has passed", func() {
+			before := testutil.ToFloat64(approvalSLOBreachesTotal.WithLabelValues("slo"))
+			reconciler.now = func() time.Time { return created.Add(2 * time.Hour) }
+			rctx := newRctx(waiting)
+
+			Expect(reconciler.checkApprovalSLO(rctx, &reconcile.Result{})).To(Succeed())
+			cond := sloCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(Equal("Not approved within the approval SLO of 1h0m0s"))
+
+			// VERIFY: A late approval does not count the breach twice
+			meta.SetStatusCondition(rctx.obj.GetStatus().GetConditions(), approvedAfter(3*time.Hour))
+			Expect(reconciler.checkApprovalSLO(rctx, &reconcile.Result{})).To(Succeed())
+			Expect(sloCondition(rctx).Message).To(Equal("Approved after 3h0m0s, over the approval SLO of 1h0m0s"))
+			Expect(testutil.ToFloat64(approvalSLOBreachesTotal.WithLabelValues("slo"))).To(Equal(before + 1))
+		})
+
+		It("Should check back on requests still waiting when the SLO runs out", func() {
+			rctx := newRctx(waiting)
+			result := reconcile.Result{RequeueAfter: 2 * time.Hour}
+
+			Expect(reconciler.checkApprovalSLO(rctx, &result)).To(Succeed())
+			Expect(sloCondition(rctx)).To(BeNil())
+			Expect(result.RequeueAfter).To(Equal(30 * time.Minute))
+		})
+
+		It("Should skip requests that did not have to wait on approval", func() {
+			rctx := newRctx(metav1.Condition{
+				Type:               v1alpha1.ConditionAccessApproved.String(),
+				Status:             metav1.ConditionTrue,
+				Reason:             "Success",
+				Message:            approvalNotRequiredMsg,
+				LastTransitionTime: metav1.Time{Time: created.Add(2 * time.Hour)},
+			})
+			Expect(reconciler.checkApprovalSLO(rctx, &reconcile.Result{})).To(Succeed())
+			Expect(sloCondition(rctx)).To(BeNil())
+		})
+
+		It("Should do nothing without an ApprovalSLO", func() {
+			reconciler.ApprovalSLO = 0
+			rctx := newRctx(approvedAfter(2 * time.Hour))
+			Expect(reconciler.checkApprovalSLO(rctx, &reconcile.Result{})).To(Succeed())
+			Expect(sloCondition(rctx)).To(BeNil())
+		})
+	})
+})
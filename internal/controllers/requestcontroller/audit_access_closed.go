@@ -0,0 +1,76 @@
+package requestcontroller
+
+import (
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/audit"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// auditAccessClosed writes the closing audit record for an Access Request once
+// its access resources have been torn down. The record summarizes when access
+// was granted (the time the access resources became ready), when it was
+// revoked, and how long it was actually held for.
+//
+// If the access resources were already removed at the start of an expiration
+// grace period, access ended then - rather than when the request went away.
+//
+// The template and approvers are recorded too, so that the closed records
+// alone are enough to report on the grants over a period (see `ozctl report`).
+//
+// The reason is "expired" when the request was cleaned up because it timed
+// out, "revoked" when a user revoked it with the RevokeAnnotation, "forced"
+// when a user forced it to expire with the ExpireAnnotation, and "deleted"
+// when it was removed by a user before that.
+func (r *RequestReconciler) auditAccessClosed(rctx *RequestContext) {
+	expiredAt := r.getNow()
+	conditions := *rctx.obj.GetStatus().GetConditions()
+	if cond := meta.FindStatusCondition(
+		conditions, v1alpha1.ConditionAccessResourcesCreated.String(),
+	); cond != nil && cond.Reason == status.ReasonAccessResourcesRemoved {
+		expiredAt = cond.LastTransitionTime.Time
+	}
+
+	reason := "deleted"
+	if cond := meta.FindStatusCondition(
+		conditions, v1alpha1.ConditionAccessStillValid.String(),
+	); cond != nil && cond.Status == metav1.ConditionFalse {
+		reason = "expired"
+		switch cond.Reason {
+		case status.ReasonAccessRevoked:
+			reason = "revoked"
+		case status.ReasonAccessForceExpired:
+			reason = "forced"
+		}
+	}
+
+	// If the access resources never became ready, access was never granted.
+	grantedAt := ""
+	duration := time.Duration(0)
+	if cond := meta.FindStatusCondition(
+		conditions, v1alpha1.ConditionAccessResourcesReady.String(),
+	); cond != nil && cond.Status == metav1.ConditionTrue {
+		grantedAt = cond.LastTransitionTime.UTC().Format(time.RFC3339)
+		duration = expiredAt.Sub(cond.LastTransitionTime.Time).Round(time.Second)
+	}
+
+	rctx.log.Info("AUDIT - Access request closed", audit.KeysAndValues(
+		"name", rctx.obj.GetName(),
+		"namespace", rctx.obj.GetNamespace(),
+		"requester", v1alpha1.GetRequester(rctx.obj),
+		"requestFor", rctx.obj.GetRequestFor(),
+		"template", rctx.obj.GetTemplateName(),
+		"justification", rctx.obj.GetJustification(),
+		"approvedBy", strings.Join(v1alpha1.GetApprovers(rctx.obj), ","),
+		"reason", reason,
+		"granted", grantedAt != "",
+		"grantedAt", grantedAt,
+		"expiredAt", expiredAt.UTC().Format(time.RFC3339),
+		"duration", duration.String(),
+	)...)
+}
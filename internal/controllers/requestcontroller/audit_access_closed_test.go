@@ -0,0 +1,158 @@
+package requestcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+var _ = Describe("RequestReconciler", func() {
+	Context("auditAccessClosed()", func() {
+		var (
+			grantedAt  = time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+			expiredAt  = grantedAt.Add(90 * time.Minute)
+			records    []map[string]interface{}
+			reconciler *RequestReconciler
+			request    *v1alpha1.ExecAccessRequest
+			rctx       *RequestContext
+		)
+
+		BeforeEach(func() {
+			records = nil
+			reconciler = &RequestReconciler{
+				now: func() time.Time { return expiredAt },
+			}
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "audit-test",
+					Namespace: "test",
+					Annotations: map[string]string{
+						v1alpha1.RequestedByAnnotation: "alice",
+						v1alpha1.ApprovedByAnnotation:  "bob,carol",
+					},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{TemplateName: "readonly"},
+			}
+			rctx = &RequestContext{
+				Context: context.Background(),
+				obj:     request,
+				log: funcr.NewJSON(func(obj string) {
+					record := map[string]interface{}{}
+					Expect(json.Unmarshal([]byte(obj), &record)).To(Succeed())
+					records = append(records, record)
+				}, funcr.Options{}),
+			}
+		})
+
+		setCondition := func(condType v1alpha1.IConditionType, status metav1.ConditionStatus) {
+			request.Status.Conditions = append(request.Status.Conditions, metav1.Condition{
+				Type:               condType.String(),
+				Status:             status,
+				LastTransitionTime: metav1.NewTime(grantedAt),
+			})
+		}
+
+		It("Should record how long an expired grant was held", func() {
+			setCondition(v1alpha1.ConditionAccessResourcesReady, metav1.ConditionTrue)
+			setCondition(v1alpha1.ConditionAccessStillValid, metav1.ConditionFalse)
+
+			reconciler.auditAccessClosed(rctx)
+
+			Expect(records).To(HaveLen(1))
+			Expect(records[0]).To(HaveKeyWithValue("msg", "AUDIT - Access request closed"))
+			Expect(records[0]).To(HaveKeyWithValue("name", "audit-test"))
+			Expect(records[0]).To(HaveKeyWithValue("namespace", "test"))
+			Expect(records[0]).To(HaveKeyWithValue("requester", "alice"))
+			Expect(records[0]).To(HaveKeyWithValue("template", "readonly"))
+			Expect(records[0]).To(HaveKeyWithValue("approvedBy", "bob,carol"))
+			Expect(records[0]).To(HaveKeyWithValue("reason", "expired"))
+			Expect(records[0]).To(HaveKeyWithValue("granted", true))
+			Expect(records[0]).To(HaveKeyWithValue("grantedAt", "2023-03-01T10:00:00Z"))
+			Expect(records[0]).To(HaveKeyWithValue("expiredAt", "2023-03-01T11:30:00Z"))
+			Expect(records[0]).To(HaveKeyWithValue("duration", "1h30m0s"))
+		})
+
+		It("Should record a request that was revoked", func() {
+			setCondition(v1alpha1.ConditionAccessResourcesReady, metav1.ConditionTrue)
+			setCondition(v1alpha1.ConditionAccessStillValid, metav1.ConditionFalse)
+			request.Status.Conditions[1].Reason = status.ReasonAccessRevoked
+
+			reconciler.auditAccessClosed(rctx)
+
+			Expect(records).To(HaveLen(1))
+			Expect(records[0]).To(HaveKeyWithValue("reason", "revoked"))
+		})
+
+		It("Should record access as ending when the resources were removed for a grace period", func() {
+			setCondition(v1alpha1.ConditionAccessResourcesReady, metav1.ConditionTrue)
+			setCondition(v1alpha1.ConditionAccessStillValid, metav1.ConditionFalse)
+			request.Status.Conditions = append(request.Status.Conditions, metav1.Condition{
+				Type:               v1alpha1.ConditionAccessResourcesCreated.String(),
+				Status:             metav1.ConditionFalse,
+				Reason:             status.ReasonAccessResourcesRemoved,
+				LastTransitionTime: metav1.NewTime(grantedAt.Add(time.Hour)),
+			})
+
+			reconciler.auditAccessClosed(rctx)
+
+			Expect(records).To(HaveLen(1))
+			Expect(records[0]).To(HaveKeyWithValue("reason", "expired"))
+			Expect(records[0]).To(HaveKeyWithValue("expiredAt", "2023-03-01T11:00:00Z"))
+			Expect(records[0]).To(HaveKeyWithValue("duration", "1h0m0s"))
+		})
+
+		It("Should record both identities for a delegated request", func() {
+			request.Spec.RequestFor = "bob"
+			setCondition(v1alpha1.ConditionAccessResourcesReady, metav1.ConditionTrue)
+
+			reconciler.auditAccessClosed(rctx)
+
+			Expect(records).To(HaveLen(1))
+			Expect(records[0]).To(HaveKeyWithValue("requester", "alice"))
+			Expect(records[0]).To(HaveKeyWithValue("requestFor", "bob"))
+		})
+
+		It("Should record a request whose expiration was forced", func() {
+			setCondition(v1alpha1.ConditionAccessResourcesReady, metav1.ConditionTrue)
+			setCondition(v1alpha1.ConditionAccessStillValid, metav1.ConditionFalse)
+			request.Status.Conditions[1].Reason = status.ReasonAccessForceExpired
+
+			reconciler.auditAccessClosed(rctx)
+
+			Expect(records).To(HaveLen(1))
+			Expect(records[0]).To(HaveKeyWithValue("reason", "forced"))
+		})
+
+		It("Should record a request deleted before it expired", func() {
+			setCondition(v1alpha1.ConditionAccessResourcesReady, metav1.ConditionTrue)
+			setCondition(v1alpha1.ConditionAccessStillValid, metav1.ConditionTrue)
+
+			reconciler.auditAccessClosed(rctx)
+
+			Expect(records).To(HaveLen(1))
+			Expect(records[0]).To(HaveKeyWithValue("reason", "deleted"))
+			Expect(records[0]).To(HaveKeyWithValue("duration", "1h30m0s"))
+		})
+
+		It("Should record a request whose access was never granted", func() {
+			setCondition(v1alpha1.ConditionAccessResourcesReady, metav1.ConditionFalse)
+
+			reconciler.auditAccessClosed(rctx)
+
+			Expect(records).To(HaveLen(1))
+			Expect(records[0]).To(HaveKeyWithValue("granted", false))
+			Expect(records[0]).To(HaveKeyWithValue("grantedAt", ""))
+			Expect(records[0]).To(HaveKeyWithValue("expiredAt", "2023-03-01T11:30:00Z"))
+			Expect(records[0]).To(HaveKeyWithValue("duration", "0s"))
+		})
+	})
+})
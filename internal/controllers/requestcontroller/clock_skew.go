@@ -0,0 +1,114 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// serverTimestampGranularity is the precision of the timestamps stamped by the
+// API server (they are serialized in RFC3339, down to the second).
+const serverTimestampGranularity = time.Second
+
+// clockSkewSeconds reports the skew of the local clock against the API server
+// last measured by a RequestReconciler (see measureClockSkew). It is positive
+// when the local clock is ahead, and 0 while the skew is within the
+// ClockSkewTolerance.
+var clockSkewSeconds = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "oz_controller_clock_skew_seconds",
+		Help: "Skew of the controller clock against the API server, positive when it is ahead",
+	},
+)
+
+// lastServerStamp returns the most recent timestamp that the API server
+// stamped onto the supplied object: its creation time, or the time of the
+// last write recorded in its managed fields.
+func lastServerStamp(obj client.Object) time.Time {
+	stamp := obj.GetCreationTimestamp().Time
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Time != nil && entry.Time.After(stamp) {
+			stamp = entry.Time.Time
+		}
+	}
+	return stamp
+}
+
+// measureClockSkew bounds the skew of the local clock against the API server,
+// from a reconcile that ran from start to end on the local clock:
+//
+//   - fetched is the lastServerStamp() of the request when it was fetched.
+//     The API server stamped it before the reconcile ended, so if it lies
+//     after end, the local clock is behind by at least the difference.
+//   - written is the lastServerStamp() of the request once the reconcile was
+//     done. If it is newer than fetched, the request was written during the
+//     reconcile - so if it lies before start, the local clock is ahead by at
+//     least the difference.
+//
+// Returns the skew (positive when the local clock is ahead, negative when it
+// is behind), or 0 if the timestamps are consistent with no skew at all.
+func measureClockSkew(start, end, fetched, written time.Time) time.Duration {
+	if written.After(fetched) {
+		// The write was stamped at some point within the second after written.
+		if ahead := start.Sub(written.Add(serverTimestampGranularity)); ahead > 0 {
+			return ahead
+		}
+	}
+	if behind := written.Sub(end); behind > 0 {
+		return -behind
+	}
+	return 0
+}
+
+// detectClockSkew measures the skew of the local clock against the API server
+// at the end of a reconcile (see measureClockSkew), if a ClockSkewTolerance is
+// set. Skew beyond the tolerance is logged and reported in the
+// oz_controller_clock_skew_seconds metric, and getNow() corrects for it from
+// then on - so that a replica with a skewed clock (eg, right after a leader
+// change) neither expires access early nor keeps it for too long.
+//
+// The skew is only updated when a reconcile measures it beyond the tolerance,
+// or within it again - a reconcile that made no writes can not tell that the
+// local clock is ahead, and leaves the last measurement alone.
+func (r *RequestReconciler) detectClockSkew(rctx *RequestContext, start, end time.Time) {
+	if r.ClockSkewTolerance <= 0 || rctx.fetchedServerStamp.IsZero() {
+		return
+	}
+	written := lastServerStamp(rctx.obj)
+	skew := measureClockSkew(start, end, rctx.fetchedServerStamp, written)
+	if skew == 0 && !written.After(rctx.fetchedServerStamp) {
+		return
+	}
+	if skew < r.ClockSkewTolerance && skew > -r.ClockSkewTolerance {
+		skew = 0
+	}
+	if previous := time.Duration(r.clockSkew.Swap(int64(skew))); previous != skew {
+		if skew == 0 {
+			rctx.log.Info("The local clock is back in sync with the API server")
+		} else {
+			rctx.log.Info(fmt.Sprintf(
+				"WARNING - The local clock is off by %s from the API server, correcting for it",
+				skew,
+			), "skew", skew.String(), "tolerance", r.ClockSkewTolerance.String())
+		}
+	}
+	clockSkewSeconds.Set(skew.Seconds())
+}
+
+// getNow returns the current time of the API server, as best known: the local
+// clock (or the test clock, if one is set), corrected for the skew measured by
+// detectClockSkew().
+func (r *RequestReconciler) getNow() time.Time {
+	return r.localNow().Add(-time.Duration(r.clockSkew.Load()))
+}
+
+// localNow returns the current time of the local clock, or of the test clock
+// if one is set.
+func (r *RequestReconciler) localNow() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,188 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder"
+)
+
+// stampingClient wraps a client.Client, and stamps the time of the API server
+// (serverNow) into the managed fields of every object that it updates - the
+// way the API server does.
+type stampingClient struct {
+	client.Client
+	serverNow func() time.Time
+}
+
+func (c *stampingClient) stamp(obj client.Object) {
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{{
+		Manager:   "manager",
+		Operation: metav1.ManagedFieldsOperationUpdate,
+		Time:      &metav1.Time{Time: c.serverNow().Truncate(time.Second)},
+	}})
+}
+
+func (c *stampingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.stamp(obj)
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *stampingClient) Status() client.StatusWriter {
+	return &stampingStatusWriter{StatusWriter: c.Client.Status(), c: c}
+}
+
+type stampingStatusWriter struct {
+	client.StatusWriter
+	c *stampingClient
+}
+
+func (w *stampingStatusWriter) Update(
+	ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption,
+) error {
+	w.c.stamp(obj)
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+var _ = Describe("RequestReconciler", func() {
+	Context("measureClockSkew()", func() {
+		var (
+			start = time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+			end   = start.Add(2 * time.Second)
+		)
+
+		It("Should find a local clock that is ahead from the writes of the reconcile", func() {
+			fetched := start.Add(-time.Hour)
+			written := start.Add(-10 * time.Minute)
+			Expect(measureClockSkew(start, end, fetched, written)).To(Equal(10*time.Minute - time.Second))
+		})
+
+		It("Should find a local clock that is behind from any server timestamp", func() {
+			fetched := end.Add(10 * time.Minute)
+			Expect(measureClockSkew(start, end, fetched, fetched)).To(Equal(-10 * time.Minute))
+		})
+
+		It("Should not find skew within the bounds of the reconcile", func() {
+			Expect(measureClockSkew(start, end, start.Add(-time.Hour), start)).To(BeZero())
+			Expect(measureClockSkew(start, end, start.Add(-time.Hour), end)).To(BeZero())
+		})
+
+		It("Should not find a local clock that is ahead without writes", func() {
+			fetched := start.Add(-10 * time.Minute)
+			Expect(measureClockSkew(start, end, fetched, fetched)).To(BeZero())
+		})
+	})
+
+	/*
+		These run the reconcile of an ExecAccessRequest (granted for the
+		default duration of its template, 1h) on a controller replica whose
+		clock is skewed against the API server, around the boundary where the
+		access expires.
+	*/
+	Context("Reconcile() with a skewed clock", func() {
+		var (
+			ctx     = context.Background()
+			created = time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+			key     = types.NamespacedName{Name: "debug", Namespace: "clock-skew"}
+
+			// serverNow is the time of the API server, and skew how far the
+			// clock of the replica is off from it.
+			serverNow time.Time
+			skew      time.Duration
+			cl        client.Client
+		)
+
+		BeforeEach(func() {
+			serverNow = created.Add(time.Minute)
+			skew = 0
+			cl = &stampingClient{
+				Client:    newExecAccessClient(key, created.Add(time.Minute)),
+				serverNow: func() time.Time { return serverNow },
+			}
+		})
+
+		newReconciler := func(tolerance time.Duration) *RequestReconciler {
+			return &RequestReconciler{
+				Client:             cl,
+				Scheme:             scheme.Scheme,
+				APIReader:          cl,
+				RequestType:        &v1alpha1.ExecAccessRequest{},
+				Builder:            &execaccessbuilder.ExecAccessBuilder{},
+				ClockSkewTolerance: tolerance,
+				now:                func() time.Time { return serverNow.Add(skew) },
+			}
+		}
+
+		// reconcileAt runs a reconcile at the supplied minute after the
+		// request was created (on the API server), and returns whether the
+		// access is still valid afterwards.
+		reconcileAt := func(r *RequestReconciler, minute int) bool {
+			serverNow = created.Add(time.Duration(minute) * time.Minute)
+			_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			return !meta.IsStatusConditionFalse(
+				request.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+			)
+		}
+
+		// grant runs the reconcile on a replica with an accurate clock, until
+		// the request has been granted.
+		grant := func() {
+			r := newReconciler(0)
+			for i := 0; i < 3; i++ {
+				Expect(reconcileAt(r, 1)).To(BeTrue())
+			}
+		}
+
+		It("Should expire the access early on a replica that is ahead, without a tolerance", func() {
+			grant()
+			skew = 10 * time.Minute
+			r := newReconciler(0)
+			Expect(reconcileAt(r, 50)).To(BeTrue())
+			Expect(reconcileAt(r, 55)).To(BeFalse())
+		})
+
+		It("Should not expire the access early on a replica that is ahead", func() {
+			grant()
+			skew = 10 * time.Minute
+			r := newReconciler(time.Minute)
+			Expect(reconcileAt(r, 50)).To(BeTrue())
+			Expect(r.getNow()).To(BeTemporally("~", serverNow, time.Second))
+
+			Expect(reconcileAt(r, 55)).To(BeTrue())
+			Expect(reconcileAt(r, 59)).To(BeTrue())
+			Expect(reconcileAt(r, 61)).To(BeFalse())
+		})
+
+		It("Should not keep the access for too long on a replica that is behind", func() {
+			grant()
+			skew = -10 * time.Minute
+			r := newReconciler(time.Minute)
+			Expect(reconcileAt(r, 55)).To(BeTrue())
+			Expect(r.getNow()).To(BeTemporally("~", serverNow, time.Second))
+
+			Expect(reconcileAt(r, 59)).To(BeTrue())
+			Expect(reconcileAt(r, 61)).To(BeFalse())
+		})
+
+		It("Should ignore skew within the tolerance", func() {
+			grant()
+			skew = 30 * time.Second
+			r := newReconciler(time.Minute)
+			Expect(reconcileAt(r, 50)).To(BeTrue())
+			Expect(r.getNow()).To(Equal(serverNow.Add(skew)))
+		})
+	})
+})
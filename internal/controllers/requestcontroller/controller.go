@@ -8,16 +8,22 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 
+	"github.com/diranged/oz/internal/api/v1alpha1"
 	"github.com/diranged/oz/internal/controllers/internal/ctrlrequeue"
 	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
 )
 
 //+kubebuilder:rbac:groups=crds.wizardofoz.co,resources=podaccessrequests,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=crds.wizardofoz.co,resources=podaccessrequests/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=crds.wizardofoz.co,resources=podaccessrequests/finalizers,verbs=update
+//+kubebuilder:rbac:groups=crds.wizardofoz.co,resources=ozconfigs,verbs=get;list;watch
 
 //+kubebuilder:rbac:groups=apps,resources=deployments;daemonsets;statefulsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=replicasets,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // Reconcile is a high level entrypoint triggered by Watches on particular
 // Custom Resources within the cluster. This wrapper handles a few common
@@ -33,6 +39,7 @@ func (r *RequestReconciler) Reconcile(
 
 	// Build a RequestContext for this reconciliation loop
 	rctx := newRequestContext(ctx, r.RequestType, req)
+	rctx.onCheck = r.onCheck
 
 	// Boilerplate. Report back on every reconcile how long it took.
 	start := time.Now()
@@ -49,16 +56,26 @@ func (r *RequestReconciler) Reconcile(
 		} else {
 			rctx.log.Info(msg)
 		}
+		rctx.traceEnd(result, err)
 	}()
 
 	// Run the actual reconciliation an return that result. Pass in the
 	// Component object that's already been populated by the cache.
+	clockStart := r.localNow()
 	result, err = r.reconcile(rctx)
+	r.detectClockSkew(rctx, clockStart, r.localNow())
 	return result, err
 }
 
 // reconcile() manages the state for a Component through the generic Installers package.
 //
+// The checks always run in the order below, and each one starts with a call
+// to rctx.traceCheck(). A reconcile may be interrupted at any point (eg, by
+// the controller crashing between creating the RBAC resources and updating
+// the status) and is then run again from the top, so every check must be
+// idempotent: it picks up whatever state an interrupted run left behind,
+// rather than creating it a second time. See idempotency_test.go.
+//
 // revive:disable:confusing-naming
 func (r *RequestReconciler) reconcile(rctx *RequestContext) (ctrl.Result, error) {
 	rctx.log.Info("Starting reconcile loop")
@@ -80,38 +97,190 @@ func (r *RequestReconciler) reconcile(rctx *RequestContext) (ctrl.Result, error)
 		return ctrlrequeue.RequeueError(err)
 	}
 	rctx.log.V(2).Info("Found request", "request", rctx.obj)
+	rctx.fetchedServerStamp = lastServerStamp(rctx.obj)
+
+	// TRACE: Log the full decision tree of this reconcile, if the request asks for it.
+	rctx.startTrace()
+
+	// FINALIZER: Make sure our finalizer is in place, or if the request is
+	// being deleted, tear down the access resources in order and release it.
+	rctx.traceCheck("handleFinalizer", "deleting", !rctx.obj.GetDeletionTimestamp().IsZero())
+	if shouldReturn, result, err := r.handleFinalizer(rctx); shouldReturn {
+		return result, err
+	}
 
 	// VERIFICATION: Check that the Builder can find the template the Request references
+	rctx.traceCheck("verifyTemplate",
+		"templateName", rctx.obj.GetTemplateName(), "templateNamespace", rctx.obj.GetTemplateNamespace())
 	tmpl, err := r.verifyTemplate(rctx)
+	if err != nil {
+		// The template may have been deleted while the request holds access.
+		if shouldReturn, result, err := r.handleTemplateDeleted(rctx, err); shouldReturn {
+			return result, err
+		}
+		rctx.log.Error(err, "Error - will requeue")
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// CONFIGURATION: Merge the OzConfig of the namespace (if any) into the template and the
+	// controller-wide defaults.
+	rctx.traceCheck("applyNamespaceConfig", "namespace", rctx.obj.GetNamespace())
+	tmpl, err = r.applyNamespaceConfig(rctx, tmpl)
 	if err != nil {
 		rctx.log.Error(err, "Error - will requeue")
 		return ctrlrequeue.RequeueError(err)
 	}
 
 	// VERIFICATION: Check the durations on the request and make sure the request has not expired
+	rctx.traceCheck("verifyDuration",
+		"created", rctx.obj.GetCreationTimestamp().UTC().Format(time.RFC3339),
+		"now", r.getNow().UTC().Format(time.RFC3339),
+		"requestedDuration", requestedDuration(rctx.obj),
+		"defaultDuration", tmpl.GetAccessConfig().DefaultDuration,
+		"maxDuration", tmpl.GetAccessConfig().MaxDuration,
+		"incidentMode", rctx.namespaceConfig != nil && rctx.namespaceConfig.Spec.IncidentMode,
+	)
 	if shouldReturn, result, err := r.verifyDuration(rctx, tmpl); shouldReturn {
 		return result, err
 	}
 
-	// VERIFICATION: Handle whether or not the access is expired at this point! If so, delete it.
-	if shouldReturn, result, err := r.isAccessExpired(rctx); shouldReturn {
+	// VERIFICATION: Check whether a user has revoked the access before it expired.
+	rctx.traceCheck("verifyRevocation")
+	if err := r.verifyRevocation(rctx, tmpl); err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: Check whether the namespace denies the access to any of the users involved.
+	rctx.traceCheck("verifyNotDenied",
+		"requestFor", rctx.obj.GetRequestFor(), "transferTo", rctx.obj.GetTransferTo())
+	if err := r.verifyNotDenied(rctx); err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: Check that the template still allows the requester and the namespace.
+	rctx.traceCheck("verifyTemplateAllows", "requester", v1alpha1.GetRequester(rctx.obj))
+	if err := r.verifyTemplateAllows(rctx, tmpl); err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: Check that the justification of the request conforms to the template.
+	rctx.traceCheck("verifyJustification", "justification", rctx.obj.GetJustification())
+	if err := r.verifyJustification(rctx, tmpl); err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: In maintenance mode, no new access is granted.
+	rctx.traceCheck("verifyNotInMaintenance")
+	if err := r.verifyNotInMaintenance(rctx); err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: If the Pod that access was granted to has been replaced, revoke the access.
+	rctx.traceCheck("verifyTargetPod")
+	if err := r.verifyTargetPod(rctx); err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: If another request in the session of this one has ended, end this one too.
+	rctx.traceCheck("verifySession")
+	if err := r.verifySession(rctx); err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: Handle whether or not the access is expired at this point! If so, delete it
+	// (after the template's expirationGracePeriod, if it has one).
+	rctx.traceCheck("isAccessExpired", "expiresAt", rctx.expiresAt.UTC().Format(time.RFC3339))
+	if shouldReturn, result, err := r.isAccessExpired(rctx, tmpl); shouldReturn {
+		return result, err
+	}
+
+	// VERIFICATION: If the same user already has an active request for the same access, mark
+	// this one as a duplicate of it rather than creating a second set of access resources.
+	rctx.traceCheck("verifyNotDuplicate")
+	if shouldReturn, result, err := r.verifyNotDuplicate(rctx); shouldReturn {
+		return result, err
+	}
+
+	// VERIFICATION: If the template requires approvals, hold off on granting any access until
+	// enough distinct users have approved the request.
+	rctx.traceCheck("verifyApprovals",
+		"approvers", v1alpha1.GetApprovers(rctx.obj),
+		"requiredApprovals", tmpl.GetAccessConfig().RequiredApprovals,
+	)
+	shouldReturn, result, err := r.verifyApprovals(rctx, tmpl)
+	if err == nil {
+		// ADVISORY: Flag requests that waited on approval for longer than the approval SLO.
+		rctx.traceCheck("checkApprovalSLO", "approvalSLO", r.ApprovalSLO.String())
+		err = r.checkApprovalSLO(rctx, &result)
+	}
+	if shouldReturn || err != nil {
 		return result, err
 	}
 
 	// VERIFICATION: Make sure all of the access resources are built properly. On any failure,
 	// set up a 30 second delay before the next reconciliation attempt.
+	rctx.traceCheck("verifyAccessResources")
 	if shouldReturn, result, err := r.verifyAccessResources(rctx, tmpl); shouldReturn {
 		return result, err
 	}
 
+	// TTL: Stamp the expiry of the access onto its RBAC resources, as a safety net for when the
+	// controller is down.
+	rctx.traceCheck("stampAccessExpiry", "expiresAt", rctx.expiresAt.UTC().Format(time.RFC3339))
+	if err := r.stampAccessExpiry(rctx); err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: Report when other active requests were assigned the same target Pod. This is
+	// advisory only.
+	rctx.traceCheck("detectSharedPod")
+	if err := r.detectSharedPod(rctx); err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: Check that the subject of the access may actually use it, in case another
+	// policy in the cluster conflicts with the RBAC resources. This is advisory only.
+	rctx.traceCheck("verifyAccessEffective")
+	if err := r.verifyAccessEffective(rctx, tmpl); err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
 	// FINAL: Set Status.Ready state
 	//
 	// TODO: Implement on the ICoreStatus interface a "AreAllConditionsTrue" function and check that.
+	rctx.traceCheck("setReadyStatus")
 	err = status.SetReadyStatus(rctx, r, rctx.obj)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// BUDGET: Record when the access ends, once it is ready, so that it counts towards the
+	// daily grant budget of the requester.
+	if err := r.recordAccessExpiresAt(rctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// GRANT TOKEN: Issue a signed description of the access, once it is ready.
+	if err := r.issueGrantToken(rctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// NOTIFY: Let the requester know how to use their access, once it is ready.
+	if rctx.obj.IsReady() {
+		message := "Access granted"
+		if msg := rctx.obj.GetStatus().(v1alpha1.IRequestStatus).GetAccessMessage(); msg != "" {
+			message = fmt.Sprintf("%s: %s", message, msg)
+		}
+		if err := r.notifyRequester(rctx, notify.EventGranted, message); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// EVENTS: Tell the story of the grant through Kubernetes Events.
+	if err := r.recordGrantMilestones(rctx); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Exit Reconciliation Loop
 	rctx.log.Info("Ending reconcile loop")
 	return ctrlrequeue.RequeueAfter(r.ReconciliationInterval)
@@ -0,0 +1,229 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// clampToDailyGrantBudget shortens the access duration of the request so that
+// the access granted to its requester over the current day (in UTC, per the
+// clock of the reconciler) stays within the DailyGrantBudget. The access
+// already used is counted by getDailyGrantsUsed(). The decision is extended to
+// explain any clamping.
+//
+// Once a request has been granted, it is capped at its own
+// Status.AccessExpiresAt - so that the budget it was granted under stays
+// final - plus whatever is left of the budget. Extending granted access (see
+// clampToApprovedRenewal()) is therefore only possible while the requester
+// has budget left. A request that was denied for going over the budget stays
+// denied.
+//
+// Returns:
+//   - false if the requester has used up their budget, and the request must
+//     be denied
+//   - An "error" if the Access Requests could not be listed
+func (r *RequestReconciler) clampToDailyGrantBudget(
+	rctx *RequestContext,
+	accessDuration time.Duration,
+	decision string,
+) (time.Duration, string, bool, error) {
+	requester := v1alpha1.GetRequester(rctx.obj)
+	if r.DailyGrantBudget <= 0 || requester == "" {
+		return accessDuration, decision, true, nil
+	}
+
+	created := rctx.obj.GetCreationTimestamp().Time
+	reqStatus := rctx.obj.GetStatus().(v1alpha1.IRequestStatus)
+	var granted time.Duration
+	if expiresAt := reqStatus.GetAccessExpiresAt(); expiresAt != nil {
+		if granted = expiresAt.Sub(created); accessDuration <= granted {
+			return accessDuration, decision, true, nil
+		}
+	} else if cond := meta.FindStatusCondition(
+		*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionAccessStillValid.String(),
+	); cond != nil && cond.Reason == status.ReasonAccessOverBudget {
+		return accessDuration, decision, false, nil
+	}
+
+	now := r.getNow().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	used, err := r.getDailyGrantsUsed(rctx, requester, dayStart)
+	if err != nil {
+		return accessDuration, decision, true, err
+	}
+
+	// Granted access is never cut short by the budget, only its extension.
+	remaining := r.DailyGrantBudget - used
+	if granted > 0 {
+		if remaining <= granted {
+			return granted, fmt.Sprintf("%s, not extended past the daily grant budget of %s",
+				decision, r.DailyGrantBudget), true, nil
+		}
+	} else if remaining <= 0 {
+		return accessDuration, decision, false, nil
+	}
+	if accessDuration > remaining {
+		accessDuration = remaining
+		decision = fmt.Sprintf("%s, clamped to the remaining daily grant budget of %s",
+			decision, remaining)
+	}
+	return accessDuration, decision, true, nil
+}
+
+// getDailyGrantsUsed returns the access that the requester has already been
+// granted, or is about to be granted, since the start of the day - through the
+// Access Requests (of any kind, in any namespace) other than the one being
+// reconciled, that they created that day:
+//
+//   - Granted requests count with their Status.AccessExpiresAt.
+//   - Requests that have not been granted yet count with the duration they
+//     ask for, if they were created before the one being reconciled (the name
+//     breaks ties). This way, requests created at once can not each take the
+//     whole budget, and the earlier ones have the first claim on it.
+//
+// Requests whose access ended before it was granted do not count. Neither do
+// the requests that have been deleted - unless the StateStore is set, in
+// which case the access granted through them is still counted from the
+// ledger of the requester (see recordDailyGrant()).
+func (r *RequestReconciler) getDailyGrantsUsed(
+	rctx *RequestContext,
+	requester string,
+	dayStart time.Time,
+) (time.Duration, error) {
+	var requests []v1alpha1.IRequestResource
+
+	// Use the non-cached reader, so that access granted moments ago is not
+	// missed.
+	execReqs := &v1alpha1.ExecAccessRequestList{}
+	if err := r.APIReader.List(rctx.Context, execReqs); err != nil {
+		return 0, err
+	}
+	for i := range execReqs.Items {
+		requests = append(requests, &execReqs.Items[i])
+	}
+
+	podReqs := &v1alpha1.PodAccessRequestList{}
+	if err := r.APIReader.List(rctx.Context, podReqs); err != nil {
+		return 0, err
+	}
+	for i := range podReqs.Items {
+		requests = append(requests, &podReqs.Items[i])
+	}
+
+	var used time.Duration
+	listed := map[types.UID]bool{}
+	for _, req := range requests {
+		if req.GetUID() == rctx.obj.GetUID() || v1alpha1.GetRequester(req) != requester {
+			continue
+		}
+		listed[req.GetUID()] = true
+		created := req.GetCreationTimestamp().Time
+		if created.Before(dayStart) {
+			continue
+		}
+		if expiresAt := req.GetStatus().(v1alpha1.IRequestStatus).GetAccessExpiresAt(); expiresAt != nil {
+			used += expiresAt.Sub(created)
+			continue
+		}
+
+		if !requestedBefore(req, rctx.obj) || !req.GetDeletionTimestamp().IsZero() || meta.IsStatusConditionFalse(
+			*req.GetStatus().GetConditions(), v1alpha1.ConditionAccessStillValid.String(),
+		) {
+			continue
+		}
+		duration, err := r.getPendingDuration(rctx, req)
+		if err != nil {
+			return 0, err
+		}
+		used += duration
+	}
+
+	// The live requests are always more current than the ledger.
+	ledger, err := r.loadDailyGrantLedger(rctx, requester)
+	if err != nil {
+		return 0, err
+	}
+	if ledger.Day != dayOf(dayStart) {
+		return used, nil
+	}
+	for uid, seconds := range ledger.Grants {
+		if uid != rctx.obj.GetUID() && !listed[uid] {
+			used += time.Duration(seconds) * time.Second
+		}
+	}
+	return used, nil
+}
+
+// getPendingDuration returns the duration of access that a request that has
+// not been granted yet asks for, per its template. Requests whose template is
+// gone, or whose duration is invalid, will never be granted and count for
+// nothing.
+func (r *RequestReconciler) getPendingDuration(
+	rctx *RequestContext,
+	req v1alpha1.IRequestResource,
+) (time.Duration, error) {
+	tmpl, err := req.GetTemplate(rctx.Context, r.Client)
+	if apierrors.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	duration, _, err := utils.GetAccessDuration(req, tmpl)
+	if err != nil {
+		return 0, nil
+	}
+	return duration, nil
+}
+
+// requestedBefore returns true if the request a was created before the request
+// b, using their namespaced names to break ties between requests created
+// within the same second.
+func requestedBefore(a, b v1alpha1.IRequestResource) bool {
+	aCreated, bCreated := a.GetCreationTimestamp(), b.GetCreationTimestamp()
+	if !aCreated.Equal(&bCreated) {
+		return aCreated.Before(&bCreated)
+	}
+	return a.GetNamespace()+"/"+a.GetName() < b.GetNamespace()+"/"+b.GetName()
+}
+
+// dailyGrantBudgetMsg explains why a request was denied for going over the
+// daily grant budget of its requester.
+func dailyGrantBudgetMsg(requester string, budget time.Duration) string {
+	return fmt.Sprintf("Access denied: %s has used up their daily grant budget of %s",
+		requester, budget)
+}
+
+// recordAccessExpiresAt records when the access granted by a ready request
+// ends in its Status.AccessExpiresAt (and in the ledger of the requester, see
+// recordDailyGrant()), so that it counts towards the daily grant budget of the
+// requester. It is updated if the access is cut short
+// (eg, by a maintenance drain) or extended. The number of approvers that the
+// access was granted with is recorded alongside, in Status.ApproversAtGrant.
+func (r *RequestReconciler) recordAccessExpiresAt(rctx *RequestContext) error {
+	if !rctx.obj.IsReady() || rctx.expiresAt.IsZero() {
+		return nil
+	}
+
+	// The status is only stored to the second.
+	expiresAt := metav1.NewTime(rctx.expiresAt).Rfc3339Copy()
+	reqStatus := rctx.obj.GetStatus().(v1alpha1.IRequestStatus)
+	if current := reqStatus.GetAccessExpiresAt(); current != nil && current.Equal(&expiresAt) {
+		return nil
+	}
+	// The ledger is written first, so that a failure to write it is retried.
+	if err := r.recordDailyGrant(rctx, expiresAt.Time); err != nil {
+		return err
+	}
+	reqStatus.SetAccessExpiresAt(&expiresAt)
+	reqStatus.SetApproversAtGrant(len(v1alpha1.GetApprovers(rctx.obj)))
+	return status.UpdateStatus(rctx.Context, r, rctx.obj)
+}
@@ -0,0 +1,425 @@
+package requestcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/opstate"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		clampToDailyGrantBudget() Tests
+	*/
+	Context("DailyGrantBudget", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			builder    = &mockBuilder{}
+			requester  string
+		)
+
+		// newRctx creates an ExecAccessRequest from the requester, and
+		// returns a populated RequestContext for it.
+		newRctx := func() *RequestContext {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        utils.RandomString(8),
+					Namespace:   ns.GetName(),
+					Annotations: map[string]string{v1alpha1.RequestedByAnnotation: requester},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err := k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			rctx := newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		// grant records that a new request from the requester was granted
+		// access for the supplied duration.
+		grant := func(duration time.Duration) {
+			rctx := newRctx()
+			expiresAt := metav1.NewTime(rctx.obj.GetCreationTimestamp().Add(duration))
+			rctx.obj.GetStatus().(v1alpha1.IRequestStatus).SetAccessExpiresAt(&expiresAt)
+			Expect(k8sClient.Status().Update(ctx, rctx.obj)).To(Succeed())
+		}
+
+		stillValidCondition := func(rctx *RequestContext) *metav1.Condition {
+			return meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+
+			By("Creating the RequestReconciler with a 3h daily grant budget")
+			reconciler = &RequestReconciler{
+				Client:           k8sClient,
+				Scheme:           k8sClient.Scheme(),
+				APIReader:        k8sClient,
+				RequestType:      &v1alpha1.ExecAccessRequest{},
+				Builder:          builder,
+				DailyGrantBudget: 3 * time.Hour,
+			}
+		})
+
+		BeforeEach(func() {
+			// Every test starts with a fresh budget
+			requester = utils.RandomString(8)
+			builder.getDurationErr = nil
+			builder.getDurationResp = 2 * time.Hour
+			reconciler.now = nil
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("verifyDuration() should grant the full duration to a user under budget", func() {
+			grant(time.Hour)
+			rctx := newRctx()
+
+			shouldEndReconcile, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(rctx.expiresAt).To(Equal(rctx.obj.GetCreationTimestamp().Add(2 * time.Hour)))
+			Expect(stillValidCondition(rctx).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("verifyDuration() should clamp a request that reaches the budget", func() {
+			grant(2 * time.Hour)
+			rctx := newRctx()
+
+			shouldEndReconcile, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+
+			// VERIFY: Only the hour left of the budget is granted, and the decision says why
+			Expect(rctx.expiresAt).To(Equal(rctx.obj.GetCreationTimestamp().Add(time.Hour)))
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionRequestDurationsValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Message).To(ContainSubstring("clamped to the remaining daily grant budget of 1h0m0s"))
+			Expect(stillValidCondition(rctx).Status).To(Equal(metav1.ConditionTrue))
+
+			// VERIFY: Once granted, the access is final - even though it now
+			// counts towards the budget itself
+			rctx.obj.GetStatus().SetReady(true)
+			Expect(reconciler.recordAccessExpiresAt(rctx)).To(Succeed())
+			grant(time.Hour)
+			_, _, err = reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rctx.expiresAt).To(Equal(rctx.obj.GetCreationTimestamp().Add(time.Hour)))
+			Expect(stillValidCondition(rctx).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("verifyDuration() should deny a request from a user over budget", func() {
+			grant(2 * time.Hour)
+			grant(time.Hour)
+			rctx := newRctx()
+
+			shouldEndReconcile, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+
+			// VERIFY: The access is flipped to invalid, so that it is cleaned up
+			cond := stillValidCondition(rctx)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessOverBudget))
+			Expect(cond.Message).To(Equal(
+				"Access denied: " + requester + " has used up their daily grant budget of 3h0m0s",
+			))
+		})
+
+		It("clampToDailyGrantBudget() should start a fresh budget on the next day", func() {
+			grant(3 * time.Hour)
+			rctx := newRctx()
+
+			// The grants of yesterday no longer count
+			reconciler.now = func() time.Time { return time.Now().Add(24 * time.Hour) }
+			duration, _, withinBudget, err := reconciler.clampToDailyGrantBudget(rctx, 2*time.Hour, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(withinBudget).To(BeTrue())
+			Expect(duration).To(Equal(2 * time.Hour))
+		})
+	})
+})
+
+var _ = Describe("RequestReconciler", func() {
+	/*
+		clampToDailyGrantBudget() Tests, with requests that are not granted yet
+	*/
+	Context("DailyGrantBudget with pending requests", func() {
+		var (
+			ctx     = context.Background()
+			now     = time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+			created = metav1.NewTime(now.Add(-time.Minute))
+			cl      client.Client
+			r       *RequestReconciler
+		)
+
+		// newRequest returns an ExecAccessRequest for 2h, made by alice.
+		newRequest := func(name string) *v1alpha1.ExecAccessRequest {
+			return &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name,
+					Namespace:         "budget",
+					UID:               types.UID(name),
+					CreationTimestamp: created,
+					Annotations:       map[string]string{v1alpha1.RequestedByAnnotation: "alice"},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{TemplateName: "web", Duration: "2h"},
+			}
+		}
+
+		// clamp runs clampToDailyGrantBudget() against the named request.
+		clamp := func(name string) (time.Duration, string, bool) {
+			rctx := newRequestContext(ctx, r.RequestType, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: name, Namespace: "budget"},
+			})
+			Expect(r.fetchRequestObject(rctx)).To(Succeed())
+			duration, decision, withinBudget, err := r.clampToDailyGrantBudget(rctx, 2*time.Hour, "")
+			Expect(err).ToNot(HaveOccurred())
+			return duration, decision, withinBudget
+		}
+
+		// grant records that the named request was granted access for the
+		// supplied duration.
+		grant := func(name string, duration time.Duration) {
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: name, Namespace: "budget"}, request)).To(Succeed())
+			expiresAt := metav1.NewTime(created.Add(duration))
+			request.Status.SetAccessExpiresAt(&expiresAt)
+			Expect(cl.Status().Update(ctx, request)).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			tmpl := &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "budget"},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"devs"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+				},
+			}
+			cl = fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(tmpl, newRequest("debug-a"), newRequest("debug-b")).
+				Build()
+			r = &RequestReconciler{
+				Client:           cl,
+				Scheme:           scheme.Scheme,
+				APIReader:        cl,
+				RequestType:      &v1alpha1.ExecAccessRequest{},
+				DailyGrantBudget: 3 * time.Hour,
+				now:              func() time.Time { return now },
+			}
+		})
+
+		It("Should not let requests created together each take the whole budget", func() {
+			// The first of the two has the first claim on the budget
+			duration, _, withinBudget := clamp("debug-a")
+			Expect(withinBudget).To(BeTrue())
+			Expect(duration).To(Equal(2 * time.Hour))
+
+			// The second is clamped to what the first leaves over, even
+			// though the first has not been granted yet
+			duration, decision, withinBudget := clamp("debug-b")
+			Expect(withinBudget).To(BeTrue())
+			Expect(duration).To(Equal(time.Hour))
+			Expect(decision).To(ContainSubstring("clamped to the remaining daily grant budget of 1h0m0s"))
+
+			// With a smaller budget, the second is denied
+			r.DailyGrantBudget = 2 * time.Hour
+			_, _, withinBudget = clamp("debug-b")
+			Expect(withinBudget).To(BeFalse())
+			duration, _, withinBudget = clamp("debug-a")
+			Expect(withinBudget).To(BeTrue())
+			Expect(duration).To(Equal(2 * time.Hour))
+		})
+
+		It("Should only extend granted access while there is budget left", func() {
+			// debug-a was granted an hour, and debug-b never will be
+			grant("debug-a", time.Hour)
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "debug-b", Namespace: "budget"}, request)).To(Succeed())
+			meta.SetStatusCondition(&request.Status.Conditions, metav1.Condition{
+				Type:   v1alpha1.ConditionAccessStillValid.String(),
+				Status: metav1.ConditionFalse,
+				Reason: status.ReasonAccessDenied,
+			})
+			Expect(cl.Status().Update(ctx, request)).To(Succeed())
+
+			// The extension of debug-a to 2h fits within the budget
+			duration, _, withinBudget := clamp("debug-a")
+			Expect(withinBudget).To(BeTrue())
+			Expect(duration).To(Equal(2 * time.Hour))
+
+			// Once the rest of the budget is used, debug-a keeps the hour it
+			// was granted, but is not extended
+			grant("debug-b", 2*time.Hour)
+			duration, decision, withinBudget := clamp("debug-a")
+			Expect(withinBudget).To(BeTrue())
+			Expect(duration).To(Equal(time.Hour))
+			Expect(decision).To(ContainSubstring("not extended past the daily grant budget of 3h0m0s"))
+		})
+	})
+})
+
+var _ = Describe("RequestReconciler", func() {
+	/*
+		recordDailyGrant() Tests
+	*/
+	Context("DailyGrantBudget with a StateStore", func() {
+		var (
+			ctx = context.Background()
+			// Noon, so that the requests are all created on the same day
+			now = time.Now().UTC().Truncate(24 * time.Hour).Add(12 * time.Hour)
+			key = types.NamespacedName{Name: "debug", Namespace: "budget"}
+			cl  client.Client
+			r   *RequestReconciler
+		)
+
+		// clamp runs clampToDailyGrantBudget() for a new request from alice,
+		// asking for an hour of access.
+		clamp := func() (time.Duration, string, bool) {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "debug-2",
+					Namespace:         key.Namespace,
+					UID:               "debug-2-uid",
+					CreationTimestamp: metav1.Time{Time: now},
+					Annotations:       map[string]string{v1alpha1.RequestedByAnnotation: "alice"},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{TemplateName: "web"},
+			}
+			Expect(cl.Create(ctx, request)).To(Succeed())
+			rctx := newRequestContext(ctx, r.RequestType, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: request.GetName(), Namespace: key.Namespace},
+			})
+			Expect(r.fetchRequestObject(rctx)).To(Succeed())
+			duration, decision, ok, err := r.clampToDailyGrantBudget(rctx, time.Hour, "Access granted")
+			Expect(err).ToNot(HaveOccurred())
+			return duration, decision, ok
+		}
+
+		BeforeEach(func() {
+			cl = newExecAccessClient(key, now)
+			r = &RequestReconciler{
+				Client:           cl,
+				Scheme:           scheme.Scheme,
+				APIReader:        cl,
+				RequestType:      &v1alpha1.ExecAccessRequest{},
+				Builder:          &execaccessbuilder.ExecAccessBuilder{},
+				DailyGrantBudget: 90 * time.Minute,
+				StateStore:       &opstate.Store{Client: cl, Namespace: "oz-system"},
+				now:              func() time.Time { return now },
+			}
+		})
+
+		It("Should keep counting the access of a deleted request", func() {
+			By("Granting an hour of access")
+			for i := 0; i < 3; i++ {
+				_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			}
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			Expect(request.Status.IsReady()).To(BeTrue())
+
+			By("Deleting the request")
+			Expect(cl.Delete(ctx, request)).To(Succeed())
+			_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(apierrors.IsNotFound(cl.Get(ctx, key, request))).To(BeTrue())
+
+			// VERIFY: Only the rest of the budget is left for a new request
+			duration, decision, ok := clamp()
+			Expect(ok).To(BeTrue())
+			Expect(duration).To(Equal(30 * time.Minute))
+			Expect(decision).To(ContainSubstring("clamped to the remaining daily grant budget of 30m0s"))
+		})
+
+		It("Should only count the grants of the current day", func() {
+			Expect(cl.Delete(ctx, &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+			})).To(Succeed())
+			ledger := &dailyGrantLedger{
+				Requester: "alice",
+				Day:       dayOf(now.AddDate(0, 0, -1)),
+				Grants:    map[types.UID]int64{"yesterday-uid": 3600},
+			}
+			value, err := json.Marshal(ledger)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.StateStore.Set(ctx, dailyGrantsState, dailyGrantLedgerKey("alice"), string(value))).
+				To(Succeed())
+
+			duration, _, ok := clamp()
+			Expect(ok).To(BeTrue())
+			Expect(duration).To(Equal(time.Hour))
+		})
+	})
+})
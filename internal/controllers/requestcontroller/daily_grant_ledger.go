@@ -0,0 +1,110 @@
+package requestcontroller
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// dailyGrantsState names the operational state (see opstate.Store) that the
+// dailyGrantLedger of each requester is persisted in.
+const dailyGrantsState = "daily-grants"
+
+// dailyGrantsMu serializes the updates of the dailyGrantLedgers, which are
+// shared by the reconcilers of every kind of Access Request.
+var dailyGrantsMu sync.Mutex
+
+// dailyGrantLedger records the access granted to a requester over a single
+// day (in UTC), by the UID of the Access Request it was granted through. It
+// outlives the Access Requests, so that deleting a request does not hand its
+// access back to the DailyGrantBudget.
+type dailyGrantLedger struct {
+	Requester string `json:"requester"`
+
+	// Day is the day that the access was granted on, as YYYY-MM-DD.
+	Day string `json:"day"`
+
+	// Grants maps the UID of each request to the seconds of access that
+	// it was granted.
+	Grants map[types.UID]int64 `json:"grants"`
+}
+
+// dailyGrantLedgerKey returns the key that the ledger of the requester is
+// stored under. User names may hold characters (eg, "@" or ":") that are not
+// valid in ConfigMap keys, so they are hashed.
+func dailyGrantLedgerKey(requester string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(requester)))
+}
+
+// dayOf returns the day (in UTC) that the supplied time falls on, in the
+// format of dailyGrantLedger.Day.
+func dayOf(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// loadDailyGrantLedger returns the ledger of the requester, as last stored.
+// It is empty unless the StateStore is set, and has recorded grants to them.
+func (r *RequestReconciler) loadDailyGrantLedger(
+	rctx *RequestContext,
+	requester string,
+) (*dailyGrantLedger, error) {
+	ledger := &dailyGrantLedger{Requester: requester, Grants: map[types.UID]int64{}}
+	if r.StateStore == nil {
+		return ledger, nil
+	}
+
+	value, ok, err := r.StateStore.Get(rctx.Context, dailyGrantsState, dailyGrantLedgerKey(requester))
+	if err != nil || !ok {
+		return ledger, err
+	}
+	if err := json.Unmarshal([]byte(value), ledger); err != nil {
+		return nil, fmt.Errorf("invalid daily grant ledger of %s: %w", requester, err)
+	}
+	if ledger.Grants == nil {
+		ledger.Grants = map[types.UID]int64{}
+	}
+	return ledger, nil
+}
+
+// recordDailyGrant records the access granted through the request, which ends
+// at expiresAt, in the ledger of its requester for the day it was created on.
+// The ledger only holds a single day, so recording the first grant of a new
+// day drops the grants of the day before - while access granted on a day
+// before the one that the ledger holds is not recorded at all, as it no
+// longer counts towards the budget.
+func (r *RequestReconciler) recordDailyGrant(rctx *RequestContext, expiresAt time.Time) error {
+	requester := v1alpha1.GetRequester(rctx.obj)
+	if r.StateStore == nil || r.DailyGrantBudget <= 0 || requester == "" {
+		return nil
+	}
+
+	dailyGrantsMu.Lock()
+	defer dailyGrantsMu.Unlock()
+
+	ledger, err := r.loadDailyGrantLedger(rctx, requester)
+	if err != nil {
+		return err
+	}
+	created := rctx.obj.GetCreationTimestamp().Time
+	switch day := dayOf(created); {
+	case day < ledger.Day:
+		return nil
+	case day > ledger.Day:
+		ledger.Day = day
+		ledger.Grants = map[types.UID]int64{}
+	}
+	ledger.Requester = requester
+	ledger.Grants[rctx.obj.GetUID()] = int64(expiresAt.Sub(created) / time.Second)
+
+	value, err := json.Marshal(ledger)
+	if err != nil {
+		return err
+	}
+	return r.StateStore.Set(rctx.Context, dailyGrantsState, dailyGrantLedgerKey(requester), string(value))
+}
@@ -0,0 +1,93 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// detectSharedPod looks up the other active Access Requests of the same kind
+// that were assigned the target Pod of this request (through the
+// FieldSelectorStatusPodName index), and records them in the advisory
+// ConditionTargetPodExclusive condition. Whether the Pod was shared on
+// purpose (eg, a template with a maxGrantsPerPod) or by mistake (eg, two
+// requests racing for the same Pod), the access is left in place - this only
+// surfaces it, and counts it in the oz_access_shared_pod_assignments_total
+// metric.
+//
+// It is a no-op unless DetectSharedPods is set, and for requests that have not
+// been assigned a Pod.
+func (r *RequestReconciler) detectSharedPod(rctx *RequestContext) error {
+	if !r.DetectSharedPods {
+		return nil
+	}
+	podReq, ok := rctx.obj.(v1alpha1.IPodRequestResource)
+	if !ok || podReq.GetPodName() == "" {
+		return nil
+	}
+	podName := podReq.GetPodName()
+
+	rctx.log.V(1).Info("Checking for other requests assigned the same Pod...")
+	others, err := r.findSharingRequests(rctx, podName)
+	if err != nil {
+		return err
+	}
+	if len(others) == 0 {
+		return status.SetTargetPodExclusive(rctx.Context, r, rctx.obj, podName)
+	}
+
+	if !meta.IsStatusConditionFalse(
+		*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionTargetPodExclusive.String(),
+	) {
+		rctx.log.Info(fmt.Sprintf("Pod %s was also assigned to %v", podName, others))
+		sharedPodAssignmentsTotal.WithLabelValues(rctx.obj.GetNamespace()).Inc()
+	}
+	return status.SetTargetPodShared(rctx.Context, r, rctx.obj, podName, others)
+}
+
+// findSharingRequests returns the sorted names of the other active Access
+// Requests (see isActiveOriginal) in the namespace of the request that were
+// assigned the named Pod.
+func (r *RequestReconciler) findSharingRequests(
+	rctx *RequestContext,
+	podName string,
+) ([]string, error) {
+	gvk, err := apiutil.GVKForObject(rctx.obj, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	gvk.Kind += "List"
+	listObj, err := r.Scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	list := listObj.(client.ObjectList)
+	if err := r.Client.List(rctx.Context, list,
+		client.InNamespace(rctx.obj.GetNamespace()),
+		client.MatchingFields{v1alpha1.FieldSelectorStatusPodName: podName},
+	); err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	others := []string{}
+	for _, item := range items {
+		other, ok := item.(v1alpha1.IPodRequestResource)
+		if !ok || other.GetUID() == rctx.obj.GetUID() ||
+			other.GetPodName() != podName || !isActiveOriginal(other) {
+			continue
+		}
+		others = append(others, other.GetName())
+	}
+	sort.Strings(others)
+	return others, nil
+}
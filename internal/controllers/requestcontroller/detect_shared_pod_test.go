@@ -0,0 +1,135 @@
+package requestcontroller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+var _ = Describe("RequestReconciler", func() {
+	/*
+		detectSharedPod() Tests
+	*/
+	Context("detectSharedPod()", func() {
+		var (
+			ctx        = context.Background()
+			cl         client.Client
+			reconciler *RequestReconciler
+		)
+
+		// granted returns an ExecAccessRequest that was granted access to
+		// the supplied Pod.
+		granted := func(name, podName string) *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shared", UID: types.UID(name)},
+				Spec:       v1alpha1.ExecAccessRequestSpec{TemplateName: "fake"},
+			}
+			request.Status.PodName = podName
+			meta.SetStatusCondition(&request.Status.Conditions, metav1.Condition{
+				Type:   v1alpha1.ConditionAccessResourcesCreated.String(),
+				Status: metav1.ConditionTrue,
+				Reason: "Created",
+			})
+			return request
+		}
+
+		// detect runs detectSharedPod() against the named request, and
+		// returns its ConditionTargetPodExclusive condition.
+		detect := func(name string) *metav1.Condition {
+			rctx := newRequestContext(ctx, reconciler.RequestType, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: name, Namespace: "shared"},
+			})
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+			Expect(reconciler.detectSharedPod(rctx)).To(Succeed())
+			return meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionTargetPodExclusive.String(),
+			)
+		}
+
+		BeforeEach(func() {
+			expired := granted("expired", "pod-a")
+			meta.SetStatusCondition(&expired.Status.Conditions, metav1.Condition{
+				Type:   v1alpha1.ConditionAccessStillValid.String(),
+				Status: metav1.ConditionFalse,
+				Reason: "Expired",
+			})
+
+			cl = fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithIndex(&v1alpha1.ExecAccessRequest{}, v1alpha1.FieldSelectorStatusPodName,
+					func(obj client.Object) []string {
+						return []string{obj.(*v1alpha1.ExecAccessRequest).GetPodName()}
+					}).
+				WithObjects(
+					granted("first", "pod-a"),
+					granted("second", "pod-a"),
+					granted("alone", "pod-b"),
+					granted("waiting", ""),
+					expired,
+				).
+				Build()
+			reconciler = &RequestReconciler{
+				Client:           cl,
+				Scheme:           scheme.Scheme,
+				APIReader:        cl,
+				RequestType:      &v1alpha1.ExecAccessRequest{},
+				DetectSharedPods: true,
+			}
+		})
+
+		It("Should report the requests that were assigned the same Pod concurrently", func() {
+			before := testutil.ToFloat64(sharedPodAssignmentsTotal.WithLabelValues("shared"))
+
+			cond := detect("first")
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonTargetPodShared))
+			Expect(cond.Message).To(Equal("Pod pod-a was also assigned to second"))
+
+			cond = detect("second")
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(Equal("Pod pod-a was also assigned to first"))
+
+			// VERIFY: The condition is advisory, and does not hold the request back
+			Expect(v1alpha1.IsAdvisoryCondition(cond.Type)).To(BeTrue())
+
+			// VERIFY: Each request is counted once, however often it is reconciled
+			detect("first")
+			Expect(testutil.ToFloat64(sharedPodAssignmentsTotal.WithLabelValues("shared"))).
+				To(Equal(before + 2))
+		})
+
+		It("Should mark requests alone on their Pod as exclusive", func() {
+			cond := detect("alone")
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Message).To(Equal("No other active request was assigned Pod pod-b"))
+		})
+
+		It("Should mark a request exclusive once the other request is gone", func() {
+			Expect(detect("first").Status).To(Equal(metav1.ConditionFalse))
+			Expect(cl.Delete(ctx, granted("second", "pod-a"))).To(Succeed())
+			Expect(detect("first").Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("Should skip requests that have not been assigned a Pod", func() {
+			Expect(detect("waiting")).To(BeNil())
+		})
+
+		It("Should do nothing unless DetectSharedPods is set", func() {
+			reconciler.DetectSharedPods = false
+			Expect(detect("first")).To(BeNil())
+		})
+	})
+})
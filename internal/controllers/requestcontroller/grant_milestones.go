@@ -0,0 +1,135 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+)
+
+// grantMilestone is a point in the life of a grant that a Kubernetes Event is
+// emitted for. The value is used as the reason of the Event.
+type grantMilestone string
+
+const (
+	// milestoneGranted is reached once the access is ready.
+	milestoneGranted grantMilestone = "AccessGranted"
+
+	// milestoneHalfLife is reached once half of the access duration has passed.
+	milestoneHalfLife grantMilestone = "AccessHalfLife"
+
+	// milestoneNearExpiry is reached once the access is within the
+	// ExpiringWithin window of the Notifier (or notify.DefaultExpiringWithin)
+	// of its end.
+	milestoneNearExpiry grantMilestone = "AccessNearExpiry"
+
+	// milestoneExpired is reached once the access duration has passed.
+	milestoneExpired grantMilestone = "AccessExpired"
+)
+
+// recordGrantMilestones emits a Kubernetes Event for each milestone that the
+// granted access has reached, so that `kubectl get events` tells the story of
+// the grant. Each Event is only emitted once - the milestones that have been
+// emitted are recorded in the Status.GrantMilestones field. Milestones are
+// only checked on reconcile, so an Event may come some time after the
+// milestone itself, but never before it.
+//
+// The Events carry the RemainingTimeAnnotation and ExpiresAtAnnotation. The
+// duration of the grant is observed in the grantDurationSeconds histogram
+// along with the milestoneGranted Event.
+func (r *RequestReconciler) recordGrantMilestones(rctx *RequestContext) error {
+	if r.Recorder == nil || !rctx.obj.IsReady() || rctx.expiresAt.IsZero() {
+		return nil
+	}
+
+	created := rctx.obj.GetCreationTimestamp().Time
+	halfLife := created.Add(rctx.expiresAt.Sub(created) / 2)
+	nearExpiry := rctx.expiresAt.Add(-r.getExpiringWithin())
+	now := r.getNow()
+
+	due := []grantMilestone{milestoneGranted}
+	if !now.Before(halfLife) {
+		due = append(due, milestoneHalfLife)
+	}
+	if !now.Before(nearExpiry) {
+		due = append(due, milestoneNearExpiry)
+	}
+	return r.emitGrantMilestones(rctx, rctx.expiresAt, due...)
+}
+
+// recordGrantExpired emits the milestoneExpired Event for access that has
+// expired at the supplied time. Requests that were never granted have no
+// grant to tell the story of, and are skipped.
+func (r *RequestReconciler) recordGrantExpired(rctx *RequestContext, expiresAt time.Time) error {
+	reqStatus := rctx.obj.GetStatus().(v1alpha1.IRequestStatus)
+	if r.Recorder == nil || !reqStatus.HasGrantMilestone(string(milestoneGranted)) {
+		return nil
+	}
+	return r.emitGrantMilestones(rctx, expiresAt, milestoneExpired)
+}
+
+// emitGrantMilestones emits the Events for the supplied milestones that have
+// not been emitted yet, and records them in the status.
+func (r *RequestReconciler) emitGrantMilestones(
+	rctx *RequestContext,
+	expiresAt time.Time,
+	milestones ...grantMilestone,
+) error {
+	reqStatus := rctx.obj.GetStatus().(v1alpha1.IRequestStatus)
+	remaining := expiresAt.Sub(r.getNow()).Round(time.Second)
+	if remaining < 0 {
+		remaining = 0
+	}
+	annotations := map[string]string{
+		v1alpha1.RemainingTimeAnnotation: remaining.String(),
+		v1alpha1.ExpiresAtAnnotation:     expiresAt.UTC().Format(time.RFC3339),
+	}
+
+	emitted := false
+	for _, milestone := range milestones {
+		if reqStatus.HasGrantMilestone(string(milestone)) {
+			continue
+		}
+		r.Recorder.AnnotatedEventf(rctx.obj, annotations, corev1.EventTypeNormal, string(milestone),
+			"%s, %s remaining (expires at %s)",
+			milestoneMsg(milestone), remaining, annotations[v1alpha1.ExpiresAtAnnotation])
+		reqStatus.AddGrantMilestone(string(milestone))
+		if milestone == milestoneGranted {
+			observeGrantDuration(rctx.obj, expiresAt.Sub(rctx.obj.GetCreationTimestamp().Time))
+		}
+		emitted = true
+	}
+	if !emitted {
+		return nil
+	}
+	return status.UpdateStatus(rctx.Context, r, rctx.obj)
+}
+
+// milestoneMsg returns the human readable description of a milestone.
+func milestoneMsg(milestone grantMilestone) string {
+	switch milestone {
+	case milestoneGranted:
+		return "Access granted"
+	case milestoneHalfLife:
+		return "Access is half way through its duration"
+	case milestoneNearExpiry:
+		return "Access is about to expire"
+	case milestoneExpired:
+		return "Access expired"
+	}
+	return fmt.Sprintf("Access reached %s", milestone)
+}
+
+// getExpiringWithin returns how long before the end of the access it is
+// considered to be about to expire - the same window that the requester is
+// warned in, if a Notifier is configured.
+func (r *RequestReconciler) getExpiringWithin() time.Duration {
+	if r.Notifier != nil {
+		return r.Notifier.GetExpiringWithin()
+	}
+	return notify.DefaultExpiringWithin
+}
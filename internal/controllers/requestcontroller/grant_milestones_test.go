@@ -0,0 +1,147 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		recordGrantMilestones() Tests
+	*/
+	Context("recordGrantMilestones()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			reconciler *RequestReconciler
+			recorder   *record.FakeRecorder
+		)
+
+		// reconcileAt runs a reconcile with the clock set to the supplied
+		// offset from the creation of the request.
+		reconcileAt := func(offset time.Duration) {
+			reconciler.now = func() time.Time {
+				return request.GetCreationTimestamp().Add(offset)
+			}
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      request.GetName(),
+					Namespace: request.GetNamespace(),
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		// events drains the Events recorded so far.
+		events := func() []string {
+			ret := []string{}
+			for {
+				select {
+				case event := <-recorder.Events:
+					ret = append(ret, event)
+				default:
+					return ret
+				}
+			}
+		}
+
+		BeforeEach(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(k8sClient.Delete, ctx, ns)
+
+			By("Creating the RequestReconciler with a Recorder")
+			recorder = record.NewFakeRecorder(10)
+			reconciler = &RequestReconciler{
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				APIReader:   k8sClient,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder: &mockBuilder{
+					getTemplateResp:             &v1alpha1.ExecAccessTemplate{},
+					getDurationResp:             time.Hour,
+					createResourcesResp:         "Role XYZ created",
+					accessResourcesAreReadyResp: true,
+				},
+				ReconciliationInterval: time.Minute,
+				Recorder:               recorder,
+			}
+
+			By("Creating an ExecAccessRequest")
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: "bogus",
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Reconcile() should emit each milestone of the grant once", func() {
+			By("Granting the access")
+			reconcileAt(time.Minute)
+			reconcileAt(2 * time.Minute)
+			Expect(events()).To(ConsistOf(
+				HavePrefix("Normal AccessGranted Access granted, 59m0s remaining"),
+			))
+
+			By("Passing the half-life of the access")
+			reconcileAt(31 * time.Minute)
+			reconcileAt(32 * time.Minute)
+			Expect(events()).To(ConsistOf(
+				HavePrefix("Normal AccessHalfLife Access is half way through its duration, 29m0s remaining"),
+			))
+
+			By("Getting close to the end of the access")
+			reconcileAt(50 * time.Minute)
+			reconcileAt(51 * time.Minute)
+			Expect(events()).To(ConsistOf(
+				HavePrefix("Normal AccessNearExpiry Access is about to expire, 10m0s remaining"),
+			))
+
+			By("Expiring the access")
+			reconcileAt(61 * time.Minute)
+			Expect(events()).To(ConsistOf(
+				HavePrefix("Normal AccessExpired Access expired, 0s remaining"),
+			))
+		})
+
+		It("Reconcile() should catch up on the milestones that were missed", func() {
+			reconcileAt(55 * time.Minute)
+			reconcileAt(56 * time.Minute)
+
+			Expect(events()).To(ConsistOf(
+				HavePrefix("Normal AccessGranted "),
+				HavePrefix("Normal AccessHalfLife "),
+				HavePrefix("Normal AccessNearExpiry "),
+			))
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.Status.GrantMilestones).To(Equal(
+				[]string{"AccessGranted", "AccessHalfLife", "AccessNearExpiry"},
+			))
+		})
+	})
+})
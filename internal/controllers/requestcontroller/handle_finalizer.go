@@ -0,0 +1,113 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// handleFinalizer manages the finalizer (see getFinalizer()) on the Access
+// Request.
+//
+// While the request is live, the finalizer is added if it is missing (unless
+// DisableFinalizer is set). Once the
+// request has been marked for deletion, the Builder's DeleteAccessResources()
+// method is called to tear down the access resources, along with any adopted
+// RoleBinding. The builders always remove the RoleBinding first (cutting off
+// access), then the Role, and only then any Pod that was created for the
+// request. The finalizer is released only
+// after all of that has succeeded, so that we never rely on the unordered
+// OwnerReference garbage collection to revoke access. The same goes for the
+// finalizers that earlier configurations placed (see heldFinalizers()), which
+// are released along with ours. Once the finalizers are released, a closing
+// audit record is written for the request.
+//
+// Returns:
+//   - shouldEndReconcile: true if the request is being deleted, or on error
+//   - result: a ctrl.Result{} object
+//   - resultErr: any error encountered while updating the resources
+func (r *RequestReconciler) handleFinalizer(
+	rctx *RequestContext,
+) (shouldEndReconcile bool, result ctrl.Result, resultErr error) {
+	finalizer := r.getFinalizer()
+	if rctx.obj.GetDeletionTimestamp().IsZero() {
+		if r.DisableFinalizer || ctrlutil.ContainsFinalizer(rctx.obj, finalizer) {
+			return false, result, nil
+		}
+		rctx.log.V(1).Info("Adding finalizer", "finalizer", finalizer)
+		ctrlutil.AddFinalizer(rctx.obj, finalizer)
+		if err := r.Update(rctx.Context, rctx.obj); err != nil {
+			return true, result, err
+		}
+		return false, result, nil
+	}
+
+	// The request is being deleted. If none of our finalizers are on it
+	// (anymore), there is nothing left for us to do.
+	held := r.heldFinalizers(rctx.obj)
+	if len(held) == 0 {
+		return true, result, nil
+	}
+
+	rctx.log.Info("Request is being deleted, removing access resources")
+	if err := r.deleteAccessResources(rctx); err != nil {
+		return true, result, err
+	}
+
+	rctx.log.V(1).Info("Removing finalizers", "finalizers", held)
+	for _, f := range held {
+		ctrlutil.RemoveFinalizer(rctx.obj, f)
+	}
+	if err := r.Update(rctx.Context, rctx.obj); err != nil {
+		return true, result, err
+	}
+
+	// Access has now been revoked for good, write the closing audit record.
+	r.auditAccessClosed(rctx)
+	return true, result, nil
+}
+
+// getFinalizer returns the name of the finalizer placed on the Access
+// Requests, defaulting to v1alpha1.RequestFinalizer.
+func (r *RequestReconciler) getFinalizer() string {
+	if r.Finalizer != "" {
+		return r.Finalizer
+	}
+	return v1alpha1.RequestFinalizer
+}
+
+// heldFinalizers returns those of our finalizers that the supplied request
+// carries: the current one, v1alpha1.RequestFinalizer and the
+// PreviousFinalizers. A request created before --finalizer-name was changed
+// (or --disable-finalizer was set) must not be stranded by it.
+func (r *RequestReconciler) heldFinalizers(obj client.Object) []string {
+	var held []string
+	seen := map[string]bool{}
+	for _, f := range append([]string{r.getFinalizer(), v1alpha1.RequestFinalizer}, r.PreviousFinalizers...) {
+		if !seen[f] && ctrlutil.ContainsFinalizer(obj, f) {
+			held = append(held, f)
+		}
+		seen[f] = true
+	}
+	return held
+}
+
+// ValidateFinalizerName returns an error if the supplied name can not be used
+// as the Finalizer of a RequestReconciler. Finalizers must be qualified names
+// with a domain prefix, like v1alpha1.RequestFinalizer.
+func ValidateFinalizerName(name string) error {
+	if !strings.Contains(name, "/") {
+		return fmt.Errorf("invalid finalizer name %q: must have a domain prefix (eg %q)",
+			name, v1alpha1.RequestFinalizer)
+	}
+	if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+		return fmt.Errorf("invalid finalizer name %q: %s", name, strings.Join(errs, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,337 @@
+package requestcontroller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder"
+	bldutils "github.com/diranged/oz/internal/builders/utils"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	Context("handleFinalizer()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			reconciler *RequestReconciler
+			builder    = &mockBuilder{}
+			rctx       *RequestContext
+		)
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessRequest built to test against")
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "handlefinalizer-test",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: "junk",
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler")
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                builder,
+				ReconciliationInterval: 0,
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		BeforeEach(func() {
+			By("Creating the RequestContext")
+			rctx = newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err := reconciler.fetchRequestObject(rctx)
+			Expect(err).To(BeNil())
+		})
+
+		It("handleFinalizer() should add the finalizer to a live request", func() {
+			shouldEndReconcile, _, err := reconciler.handleFinalizer(rctx)
+
+			// VERIFY: The reconcile moves on
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The finalizer was saved to the cluster
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			}, request)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(ctrlutil.ContainsFinalizer(request, v1alpha1.RequestFinalizer)).To(BeTrue())
+			Expect(builder.deleteResourcesCalled).To(BeFalse())
+		})
+
+		It("handleFinalizer() should keep the finalizer if resource deletion fails", func() {
+			By("Deleting the request")
+			err := k8sClient.Delete(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).To(BeNil())
+
+			builder.deleteResourcesErr = errors.New("failed")
+			shouldEndReconcile, _, err := reconciler.handleFinalizer(rctx)
+
+			// VERIFY: The reconcile ends with the error, and the finalizer remains
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err.Error()).To(Equal("failed"))
+			Expect(builder.deleteResourcesCalled).To(BeTrue())
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			}, request)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(ctrlutil.ContainsFinalizer(request, v1alpha1.RequestFinalizer)).To(BeTrue())
+		})
+
+		It("handleFinalizer() should remove resources and release the finalizer", func() {
+			builder.deleteResourcesErr = nil
+			builder.deleteResourcesCalled = false
+			shouldEndReconcile, _, err := reconciler.handleFinalizer(rctx)
+
+			// VERIFY: The reconcile ends, without error
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.deleteResourcesCalled).To(BeTrue())
+
+			// VERIFY: The request is now gone
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			}, request)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("handleFinalizer() with a custom or disabled finalizer", func() {
+		var (
+			ctx = context.Background()
+			// The conditions are stamped with the wall clock, so the test
+			// clock must start from it for the request to expire.
+			now     = time.Now().UTC().Truncate(time.Second)
+			key     = types.NamespacedName{Name: "debug", Namespace: "finalizers"}
+			cl      client.Client
+			builder *mockBuilder
+		)
+
+		BeforeEach(func() {
+			cl = newExecAccessClient(key, now)
+			builder = &mockBuilder{}
+		})
+
+		// newReconciler returns a RequestReconciler for ExecAccessRequests
+		// working through cl, with the supplied finalizer settings.
+		newReconciler := func(b builders.IBuilder, finalizer string, disable bool) *RequestReconciler {
+			return &RequestReconciler{
+				Client:           cl,
+				Scheme:           scheme.Scheme,
+				APIReader:        cl,
+				RequestType:      &v1alpha1.ExecAccessRequest{},
+				Builder:          b,
+				Finalizer:        finalizer,
+				DisableFinalizer: disable,
+				now:              func() time.Time { return now },
+			}
+		}
+
+		// newContext returns a RequestContext for the request, as fetched
+		// from cl.
+		newContext := func(r *RequestReconciler) *RequestContext {
+			rctx := newRequestContext(ctx, r.RequestType, reconcile.Request{NamespacedName: key})
+			Expect(r.fetchRequestObject(rctx)).To(Succeed())
+			return rctx
+		}
+
+		getRequest := func() (*v1alpha1.ExecAccessRequest, error) {
+			request := &v1alpha1.ExecAccessRequest{}
+			return request, cl.Get(ctx, key, request)
+		}
+
+		It("Should add and release the configured finalizer", func() {
+			r := newReconciler(builder, "example.com/oz-cleanup", false)
+
+			shouldEndReconcile, _, err := r.handleFinalizer(newContext(r))
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+			request, err := getRequest()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.GetFinalizers()).To(Equal([]string{"example.com/oz-cleanup"}))
+
+			By("Deleting the request")
+			Expect(cl.Delete(ctx, request)).To(Succeed())
+			shouldEndReconcile, _, err = r.handleFinalizer(newContext(r))
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.deleteResourcesCalled).To(BeTrue())
+			_, err = getRequest()
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should not add a finalizer when disabled", func() {
+			r := newReconciler(builder, "", true)
+
+			shouldEndReconcile, _, err := r.handleFinalizer(newContext(r))
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+			request, err := getRequest()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.GetFinalizers()).To(BeEmpty())
+		})
+
+		It("Should still release a finalizer that was added before it was disabled", func() {
+			request, err := getRequest()
+			Expect(err).ToNot(HaveOccurred())
+			ctrlutil.AddFinalizer(request, v1alpha1.RequestFinalizer)
+			Expect(cl.Update(ctx, request)).To(Succeed())
+			Expect(cl.Delete(ctx, request)).To(Succeed())
+
+			r := newReconciler(builder, "", true)
+			shouldEndReconcile, _, err := r.handleFinalizer(newContext(r))
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.deleteResourcesCalled).To(BeTrue())
+			_, err = getRequest()
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should release the finalizers of earlier configurations on deletion", func() {
+			By("Adding the finalizer under the old name")
+			r := newReconciler(builder, "example.com/oz-cleanup", false)
+			_, _, err := r.handleFinalizer(newContext(r))
+			Expect(err).ToNot(HaveOccurred())
+			request, err := getRequest()
+			Expect(err).ToNot(HaveOccurred())
+			ctrlutil.AddFinalizer(request, v1alpha1.RequestFinalizer)
+			ctrlutil.AddFinalizer(request, "example.com/someone-else")
+			Expect(cl.Update(ctx, request)).To(Succeed())
+
+			By("Switching to a new name, and deleting the request")
+			r = newReconciler(builder, "example.com/oz-cleanup-v2", false)
+			r.PreviousFinalizers = []string{"example.com/oz-cleanup"}
+			Expect(cl.Delete(ctx, request)).To(Succeed())
+			shouldEndReconcile, _, err := r.handleFinalizer(newContext(r))
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.deleteResourcesCalled).To(BeTrue())
+
+			// VERIFY: Only the finalizers of other controllers are left
+			request, err = getRequest()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.GetFinalizers()).To(Equal([]string{"example.com/someone-else"}))
+		})
+
+		It("Should release a custom finalizer once the finalizer is disabled", func() {
+			r := newReconciler(builder, "example.com/oz-cleanup", false)
+			_, _, err := r.handleFinalizer(newContext(r))
+			Expect(err).ToNot(HaveOccurred())
+			request, err := getRequest()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cl.Delete(ctx, request)).To(Succeed())
+
+			r = newReconciler(builder, "", true)
+			r.PreviousFinalizers = []string{"example.com/oz-cleanup"}
+			shouldEndReconcile, _, err := r.handleFinalizer(newContext(r))
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.deleteResourcesCalled).To(BeTrue())
+			_, err = getRequest()
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("Should still clean up expired access when disabled", func() {
+			r := newReconciler(&execaccessbuilder.ExecAccessBuilder{}, "", true)
+			reconcileRequest := func() {
+				_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			}
+			for i := 0; i < 3; i++ {
+				reconcileRequest()
+			}
+
+			By("Granting access without a finalizer")
+			request, err := getRequest()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.GetFinalizers()).To(BeEmpty())
+			Expect(request.Status.IsReady()).To(BeTrue())
+
+			// The access resources are owned by the request, so that the
+			// garbage collection removes them if it is deleted by hand.
+			name := types.NamespacedName{Name: bldutils.GenerateResourceName(request), Namespace: key.Namespace}
+			for _, obj := range []client.Object{&rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+				Expect(cl.Get(ctx, name, obj)).To(Succeed())
+				Expect(obj.GetOwnerReferences()).To(ContainElement(
+					HaveField("UID", request.GetUID()),
+				))
+			}
+
+			By("Tearing the access down once it has expired")
+			r.now = func() time.Time { return now.Add(2 * time.Hour) }
+			for i := 0; i < 3; i++ {
+				reconcileRequest()
+			}
+			_, err = getRequest()
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			for _, obj := range []client.Object{&rbacv1.Role{}, &rbacv1.RoleBinding{}} {
+				Expect(apierrors.IsNotFound(cl.Get(ctx, name, obj))).To(BeTrue())
+			}
+		})
+	})
+})
+
+var _ = Describe("ValidateFinalizerName()", func() {
+	It("Should accept a qualified name with a domain prefix", func() {
+		Expect(ValidateFinalizerName(v1alpha1.RequestFinalizer)).To(Succeed())
+		Expect(ValidateFinalizerName("example.com/oz-cleanup")).To(Succeed())
+	})
+
+	It("Should reject a name without a domain prefix, or an invalid one", func() {
+		Expect(ValidateFinalizerName("cleanup")).To(MatchError(ContainSubstring("domain prefix")))
+		Expect(ValidateFinalizerName("example.com/not valid")).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,369 @@
+package requestcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder"
+)
+
+// errCrash is what the idempotency harness panics with to simulate the
+// controller crashing in the middle of a reconcile.
+var errCrash = errors.New("simulated crash")
+
+// crashingClient wraps a client.Client, and crashes (panics with errCrash)
+// right before the write numbered crashAt (counting from 1) goes through. A
+// crashAt of 0 never crashes.
+type crashingClient struct {
+	client.Client
+	writes  int
+	crashAt int
+}
+
+func (c *crashingClient) write() {
+	c.writes++
+	if c.writes == c.crashAt {
+		panic(errCrash)
+	}
+}
+
+func (c *crashingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.write()
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func (c *crashingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.write()
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+func (c *crashingClient) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption,
+) error {
+	c.write()
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func (c *crashingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.write()
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+func (c *crashingClient) Status() client.StatusWriter {
+	return &crashingStatusWriter{StatusWriter: c.Client.Status(), c: c}
+}
+
+type crashingStatusWriter struct {
+	client.StatusWriter
+	c *crashingClient
+}
+
+func (w *crashingStatusWriter) Update(
+	ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption,
+) error {
+	w.c.write()
+	return w.StatusWriter.Update(ctx, obj, opts...)
+}
+
+func (w *crashingStatusWriter) Patch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption,
+) error {
+	w.c.write()
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+// newExecAccessClient returns a client populated with an ExecAccessRequest
+// (named by key, and created a minute before now), its template, and the
+// Deployment and Pod that the template targets - everything needed for the
+// request to be granted by the ExecAccessBuilder.
+func newExecAccessClient(key types.NamespacedName, now time.Time) client.Client {
+	labels := map[string]string{"app": "web"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: key.Namespace},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-1",
+			Namespace: key.Namespace,
+			Labels:    labels,
+			UID:       "web-1-uid",
+		},
+		Spec: corev1.PodSpec{NodeName: "node-1"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: "10.0.0.1",
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	tmpl := &v1alpha1.ExecAccessTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: key.Namespace,
+			UID:       "template-uid",
+		},
+		Spec: v1alpha1.ExecAccessTemplateSpec{
+			AccessConfig: v1alpha1.AccessConfig{
+				AllowedGroups:   []string{"devs"},
+				DefaultDuration: "1h",
+				MaxDuration:     "2h",
+			},
+			ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "web",
+			},
+		},
+	}
+	request := &v1alpha1.ExecAccessRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              key.Name,
+			Namespace:         key.Namespace,
+			UID:               "5e8d2a7c-request",
+			CreationTimestamp: metav1.Time{Time: now.Add(-time.Minute)},
+			Annotations: map[string]string{
+				v1alpha1.RequestedByAnnotation: "alice",
+			},
+		},
+		Spec: v1alpha1.ExecAccessRequestSpec{TemplateName: "web"},
+	}
+	return fake.NewClientBuilder().
+		WithScheme(scheme.Scheme).
+		WithObjects(deployment, pod, tmpl, request).
+		WithIndex(&corev1.Pod{}, v1alpha1.FieldSelectorStatusPhase, func(o client.Object) []string {
+			return []string{string(o.(*corev1.Pod).Status.Phase)}
+		}).
+		Build()
+}
+
+var _ = Describe("RequestReconciler", func() {
+	/*
+		Reconcile() idempotency Tests
+
+		These interrupt the reconcile of an ExecAccessRequest at every step
+		boundary (the start of each check), and in the middle of each step
+		(right before each of its writes into the cluster) - the way a crash
+		of the controller would. The reconcile is then run again from
+		scratch, and must end up in the same state as a reconcile that was
+		never interrupted.
+	*/
+	Context("Reconcile() idempotency", func() {
+		var (
+			ctx = context.Background()
+			now = time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+			key = types.NamespacedName{Name: "debug", Namespace: "idempotency"}
+		)
+
+		// newClient returns a fresh client, populated with the request under
+		// test (see newExecAccessClient).
+		newClient := func() client.Client {
+			return newExecAccessClient(key, now)
+		}
+
+		// newReconciler returns a RequestReconciler for ExecAccessRequests
+		// working through the supplied client, that calls the supplied
+		// onCheck hook at the start of each check.
+		newReconciler := func(cl client.Client, onCheck func(string)) *RequestReconciler {
+			return &RequestReconciler{
+				Client:      cl,
+				Scheme:      scheme.Scheme,
+				APIReader:   cl,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &execaccessbuilder.ExecAccessBuilder{},
+				now:         func() time.Time { return now },
+				onCheck:     onCheck,
+			}
+		}
+
+		// reconcileOnce runs a single reconcile, and reports whether it was
+		// crashed. Any panic other than errCrash is passed on.
+		reconcileOnce := func(r *RequestReconciler) (crashed bool) {
+			defer func() {
+				if p := recover(); p != nil {
+					if p != errCrash {
+						panic(p)
+					}
+					crashed = true
+				}
+			}()
+			_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			return false
+		}
+
+		// settle runs the reconcile (without interruptions) a few times over,
+		// until the request is granted.
+		settle := func(cl client.Client) {
+			r := newReconciler(cl, nil)
+			for i := 0; i < 3; i++ {
+				reconcileOnce(r)
+			}
+		}
+
+		// snapshot summarizes the state of the request and of its access
+		// resources, leaving out timestamps and resource versions.
+		snapshot := func(cl client.Client) []string {
+			state := []string{}
+
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			for _, cond := range request.Status.Conditions {
+				state = append(state, fmt.Sprintf("condition %s=%s (%s): %s",
+					cond.Type, cond.Status, cond.Reason, cond.Message))
+			}
+			state = append(state,
+				fmt.Sprintf("ready=%t", request.Status.Ready),
+				fmt.Sprintf("podName=%s", request.Status.PodName),
+				fmt.Sprintf("accessMessage=%s", request.Status.AccessMessage),
+				fmt.Sprintf("finalizers=%v", request.Finalizers),
+				fmt.Sprintf("ownerReferences=%d", len(request.OwnerReferences)),
+			)
+
+			roles := &rbacv1.RoleList{}
+			Expect(cl.List(ctx, roles)).To(Succeed())
+			for _, role := range roles.Items {
+				state = append(state, fmt.Sprintf("role %s: %v", role.Name, role.Rules))
+			}
+			bindings := &rbacv1.RoleBindingList{}
+			Expect(cl.List(ctx, bindings)).To(Succeed())
+			for _, rb := range bindings.Items {
+				state = append(state, fmt.Sprintf("rolebinding %s: %v %v",
+					rb.Name, rb.RoleRef, rb.Subjects))
+			}
+			sort.Strings(state)
+			return state
+		}
+
+		// The state of a reconcile that is never interrupted, and the checks
+		// and writes it went through.
+		var (
+			expected []string
+			checks   []string
+			writes   int
+		)
+
+		BeforeEach(func() {
+			checks = []string{}
+			cl := &crashingClient{Client: newClient()}
+			reconcileOnce(newReconciler(cl, func(check string) {
+				checks = append(checks, check)
+			}))
+			writes = cl.writes
+			settle(cl)
+			expected = snapshot(cl)
+		})
+
+		It("Should grant the access, in order, when it is not interrupted", func() {
+			Expect(expected).To(ContainElement("ready=true"))
+			Expect(expected).To(ContainElement("podName=web-1"))
+			Expect(checks).To(Equal([]string{
+				"handleFinalizer",
+				"verifyTemplate",
+				"applyNamespaceConfig",
+				"verifyDuration",
+				"verifyRevocation",
+				"verifyNotDenied",
+				"verifyTemplateAllows",
+				"verifyJustification",
+				"verifyNotInMaintenance",
+				"verifyTargetPod",
+				"verifySession",
+				"isAccessExpired",
+				"verifyNotDuplicate",
+				"verifyApprovals",
+				"checkApprovalSLO",
+				"verifyAccessResources",
+				"stampAccessExpiry",
+				"detectSharedPod",
+				"verifyAccessEffective",
+				"setReadyStatus",
+			}))
+			Expect(writes).To(BeNumerically(">", 0))
+		})
+
+		It("Should end up in the same state when interrupted at any step boundary", func() {
+			for i, check := range checks {
+				cl := newClient()
+				seen := 0
+				crashed := reconcileOnce(newReconciler(cl, func(string) {
+					if seen == i {
+						panic(errCrash)
+					}
+					seen++
+				}))
+				Expect(crashed).To(BeTrue(), "before check %d (%s)", i, check)
+
+				settle(cl)
+				Expect(snapshot(cl)).To(Equal(expected), "interrupted before check %d (%s)", i, check)
+			}
+		})
+
+		It("Should end up in the same state when interrupted at any write", func() {
+			for i := 1; i <= writes; i++ {
+				cl := &crashingClient{Client: newClient(), crashAt: i}
+				crashed := reconcileOnce(newReconciler(cl, nil))
+				Expect(crashed).To(BeTrue(), "before write %d", i)
+
+				settle(cl.Client)
+				Expect(snapshot(cl.Client)).To(Equal(expected), "interrupted before write %d", i)
+			}
+		})
+
+		It("Should finish tearing the access down when interrupted at any write", func() {
+			// deleteGranted returns a client holding a granted request, that
+			// has just been deleted.
+			deleteGranted := func() *crashingClient {
+				cl := &crashingClient{Client: newClient()}
+				settle(cl.Client)
+				request := &v1alpha1.ExecAccessRequest{}
+				Expect(cl.Get(ctx, key, request)).To(Succeed())
+				Expect(cl.Client.Delete(ctx, request)).To(Succeed())
+				return cl
+			}
+
+			cl := deleteGranted()
+			reconcileOnce(newReconciler(cl, nil))
+			teardownWrites := cl.writes
+			Expect(teardownWrites).To(BeNumerically(">", 0))
+
+			for i := 1; i <= teardownWrites; i++ {
+				cl := deleteGranted()
+				cl.crashAt = i
+				crashed := reconcileOnce(newReconciler(cl, nil))
+				Expect(crashed).To(BeTrue(), "before write %d", i)
+
+				settle(cl.Client)
+				request := &v1alpha1.ExecAccessRequest{}
+				Expect(apierrors.IsNotFound(cl.Get(ctx, key, request))).
+					To(BeTrue(), "interrupted before write %d", i)
+				roles := &rbacv1.RoleList{}
+				Expect(cl.List(ctx, roles)).To(Succeed())
+				Expect(roles.Items).To(BeEmpty(), "interrupted before write %d", i)
+				bindings := &rbacv1.RoleBindingList{}
+				Expect(cl.List(ctx, bindings)).To(Succeed())
+				Expect(bindings.Items).To(BeEmpty(), "interrupted before write %d", i)
+			}
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// extendWhileInUse defers the expiration of access that is still in use. If
+// the template sets an idleTimeout and the access was last active (see
+// v1alpha1.GetLastActive) within it, the access duration is extended until
+// the access has been idle for the idleTimeout - but never past the
+// maxDuration of the template, clamped the same way as the access duration
+// itself. The ConditionInUse condition records whether the access is in use.
+//
+// Templates without an idleTimeout are left alone.
+//
+// Returns:
+//   - The (possibly extended) access duration, and the decision explaining it
+//   - An "error" if the maxDuration of the template could not be clamped, or
+//     the condition could not be updated
+func (r *RequestReconciler) extendWhileInUse(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+	accessDuration time.Duration,
+	decision string,
+) (time.Duration, string, error) {
+	idleTimeout, err := tmpl.GetAccessConfig().GetIdleTimeout()
+	if err != nil || idleTimeout <= 0 {
+		// An invalid idleTimeout is reported on the template itself.
+		return accessDuration, decision, nil
+	}
+
+	lastActive, active := v1alpha1.GetLastActive(rctx.obj, r.getNow())
+	if !active || r.getNow().Sub(lastActive) > idleTimeout {
+		return accessDuration, decision, status.SetAccessIdle(rctx.Context, r, rctx.obj,
+			fmt.Sprintf("Access has not been active within the idle timeout of %s", idleTimeout))
+	}
+
+	created := rctx.obj.GetCreationTimestamp().Time
+	maxDuration, err := tmpl.GetAccessConfig().GetMaxDuration()
+	if err != nil {
+		return accessDuration, decision, err
+	}
+	ceiling, _, err := r.clampAccessDuration(created, tmpl, rctx.namespaceConfig, maxDuration, "")
+	if err != nil {
+		return accessDuration, decision, err
+	}
+
+	deferred := lastActive.Add(idleTimeout).Sub(created)
+	if deferred > ceiling {
+		deferred = ceiling
+	}
+	if deferred > accessDuration {
+		accessDuration = deferred
+		decision = fmt.Sprintf("%s, deferred while in use until %s",
+			decision, created.Add(accessDuration).UTC().Format(time.RFC3339))
+	}
+
+	return accessDuration, decision, status.SetAccessInUse(rctx.Context, r, rctx.obj,
+		fmt.Sprintf("Access was last active at %s", lastActive.UTC().Format(time.RFC3339)))
+}
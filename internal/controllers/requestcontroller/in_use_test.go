@@ -0,0 +1,191 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		extendWhileInUse() Tests
+	*/
+	Context("extendWhileInUse()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+		)
+
+		// newRctx creates an ExecAccessRequest that was last active at the
+		// supplied time (if any), and returns a populated RequestContext for it.
+		newRctx := func(lastActive *time.Time) *RequestContext {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{TemplateName: template.GetName()},
+			}
+			if lastActive != nil {
+				request.SetAnnotations(map[string]string{
+					v1alpha1.LastActiveAnnotation: lastActive.UTC().Format(time.RFC3339),
+				})
+			}
+			err := k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			rctx := newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		inUseCondition := func(rctx *RequestContext) *metav1.Condition {
+			return meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionInUse.String(),
+			)
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "fake", Namespace: ns.GetName()},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"devs"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+						IdleTimeout:     "15m",
+					},
+				},
+			}
+
+			By("Creating the RequestReconciler")
+			reconciler = &RequestReconciler{
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				APIReader:   k8sClient,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &mockBuilder{},
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("Should defer the expiration of access that is still in use", func() {
+			now := time.Now()
+			lastActive := now.Add(55 * time.Minute)
+			rctx := newRctx(&lastActive)
+			created := rctx.obj.GetCreationTimestamp().Time
+
+			// The access was due to expire an hour in, but was active at 55m.
+			reconciler.now = func() time.Time { return created.Add(time.Hour) }
+			duration, decision, err := reconciler.extendWhileInUse(rctx, template, time.Hour, "Access granted")
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The access now lasts until it has been idle for 15m
+			expected := lastActive.Truncate(time.Second).Add(15 * time.Minute).Sub(created)
+			Expect(duration).To(Equal(expected))
+			Expect(decision).To(HavePrefix("Access granted, deferred while in use until "))
+			cond := inUseCondition(rctx)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessInUse))
+			Expect(v1alpha1.IsAdvisoryCondition(cond.Type)).To(BeTrue())
+		})
+
+		It("Should never defer the expiration past the maxDuration", func() {
+			rctx := newRctx(nil)
+			created := rctx.obj.GetCreationTimestamp().Time
+			lastActive := created.Add(110 * time.Minute)
+			rctx.obj.SetAnnotations(map[string]string{
+				v1alpha1.LastActiveAnnotation: lastActive.UTC().Format(time.RFC3339),
+			})
+
+			reconciler.now = func() time.Time { return created.Add(115 * time.Minute) }
+			duration, _, err := reconciler.extendWhileInUse(rctx, template, time.Hour, "Access granted")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(duration).To(Equal(2 * time.Hour))
+		})
+
+		It("Should let idle access expire on time", func() {
+			rctx := newRctx(nil)
+			created := rctx.obj.GetCreationTimestamp().Time
+			lastActive := created.Add(30 * time.Minute)
+			rctx.obj.SetAnnotations(map[string]string{
+				v1alpha1.LastActiveAnnotation: lastActive.UTC().Format(time.RFC3339),
+			})
+
+			// Idle for 30m by the time the access was due to expire
+			reconciler.now = func() time.Time { return created.Add(time.Hour) }
+			duration, decision, err := reconciler.extendWhileInUse(rctx, template, time.Hour, "Access granted")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(duration).To(Equal(time.Hour))
+			Expect(decision).To(Equal("Access granted"))
+			cond := inUseCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessIdle))
+		})
+
+		It("Should ignore activity stamped in the future", func() {
+			rctx := newRctx(nil)
+			created := rctx.obj.GetCreationTimestamp().Time
+			lastActive := created.Add(24 * time.Hour)
+			rctx.obj.SetAnnotations(map[string]string{
+				v1alpha1.LastActiveAnnotation: lastActive.UTC().Format(time.RFC3339),
+			})
+
+			// A timestamp a day ahead must not keep the access in use
+			reconciler.now = func() time.Time { return created.Add(time.Hour) }
+			duration, decision, err := reconciler.extendWhileInUse(rctx, template, time.Hour, "Access granted")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(duration).To(Equal(time.Hour))
+			Expect(decision).To(Equal("Access granted"))
+			cond := inUseCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessIdle))
+		})
+
+		It("Should leave templates without an idleTimeout alone", func() {
+			rctx := newRctx(nil)
+			tmpl := template.DeepCopy()
+			tmpl.Spec.AccessConfig.IdleTimeout = ""
+
+			duration, _, err := reconciler.extendWhileInUse(rctx, tmpl, time.Hour, "Access granted")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(duration).To(Equal(time.Hour))
+			Expect(inUseCondition(rctx)).To(BeNil())
+		})
+	})
+})
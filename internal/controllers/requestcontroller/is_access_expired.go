@@ -2,16 +2,33 @@ package requestcontroller
 
 import (
 	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
 )
 
+// isAccessExpired deletes the request once the ConditionAccessStillValid
+// condition has been flipped to False. If the request is part of a session,
+// the session is ended first (see endSession()).
+//
+// If the template sets an expirationGracePeriod, the access resources are
+// removed right away but the request itself is kept until the grace period
+// (counted from when the access ended, see accessEndedAt()) has passed, so
+// that it can still be inspected. An invalid grace period is surfaced on the
+// template, and treated as no grace period at all here.
+//
+// Requests with the CleanupExemptAnnotation are kept the same way (without
+// their access) until they reach the hard absolute maximum lifetime - see
+// cleanupExemptUntil().
 func (r *RequestReconciler) isAccessExpired(
 	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
 ) (shouldEndReconcile bool, result ctrl.Result, resultErr error) {
 	rctx.log.V(1).Info("Checking if access has expired...")
 	conditions := rctx.obj.GetStatus().GetConditions()
@@ -35,7 +52,36 @@ func (r *RequestReconciler) isAccessExpired(
 		)
 		shouldEndReconcile = true
 		result = ctrl.Result{}
-		resultErr = r.Delete(rctx.Context, rctx.obj)
+
+		// The access of every other request in the session ends with this one.
+		if err := r.endSession(rctx); err != nil {
+			return true, result, err
+		}
+
+		grace, _ := tmpl.GetAccessConfig().GetExpirationGracePeriod()
+		deleteAt := accessEndedAt(rctx.obj, cond).Add(grace)
+		if v1alpha1.IsCleanupExempt(rctx.obj) {
+			if exemptUntil := r.cleanupExemptUntil(rctx); exemptUntil.After(deleteAt) {
+				deleteAt = exemptUntil
+			}
+		}
+		if remaining := deleteAt.Sub(r.getNow()); remaining > 0 {
+			rctx.log.Info(fmt.Sprintf(
+				"Removing access resources, request will be deleted in %s", remaining.Round(time.Second),
+			))
+			return true, ctrl.Result{RequeueAfter: remaining}, r.removeAccessResources(rctx, deleteAt)
+		}
+
+		// Without our finalizer, deleting the request would leave its access
+		// to the unordered OwnerReference garbage collection - so tear it down
+		// here, and write the closing audit record once the request is gone.
+		if ctrlutil.ContainsFinalizer(rctx.obj, r.getFinalizer()) {
+			resultErr = r.Delete(rctx.Context, rctx.obj)
+		} else if resultErr = r.deleteAccessResources(rctx); resultErr == nil {
+			if resultErr = r.Delete(rctx.Context, rctx.obj); resultErr == nil {
+				r.auditAccessClosed(rctx)
+			}
+		}
 	} else {
 		rctx.log.V(1).Info(
 			fmt.Sprintf(
@@ -51,3 +97,48 @@ func (r *RequestReconciler) isAccessExpired(
 
 	return shouldEndReconcile, result, resultErr
 }
+
+// accessEndedAt returns when the access of an expired request ended: when it
+// was due to expire (its Status.AccessExpiresAt, which is derived from the
+// creation time stamped by the API server), or when the supplied
+// ConditionAccessStillValid condition flipped to False (stamped with the
+// clock of whichever controller replica flipped it), whichever came first.
+func accessEndedAt(req v1alpha1.IRequestResource, cond *metav1.Condition) time.Time {
+	endedAt := cond.LastTransitionTime.Time
+	expiresAt := req.GetStatus().(v1alpha1.IRequestStatus).GetAccessExpiresAt()
+	if expiresAt != nil && expiresAt.Time.Before(endedAt) {
+		return expiresAt.Time
+	}
+	return endedAt
+}
+
+// maxCleanupExemption is the hard lifetime of a request with the
+// CleanupExemptAnnotation, when no absolute maximum duration is configured.
+const maxCleanupExemption = 24 * time.Hour
+
+// cleanupExemptUntil returns when a request with the CleanupExemptAnnotation
+// is deleted regardless: once it has lived for the absolute maximum duration
+// (of the controller, or of the OzConfig of the namespace), or for
+// maxCleanupExemption if there is none.
+func (r *RequestReconciler) cleanupExemptUntil(rctx *RequestContext) time.Time {
+	lifetime, err := r.getAbsoluteMaxDuration(rctx.namespaceConfig)
+	if err != nil || lifetime == 0 {
+		lifetime = maxCleanupExemption
+	}
+	return rctx.obj.GetCreationTimestamp().Add(lifetime)
+}
+
+// removeAccessResources tears down the access resources of an expired request
+// that is being kept around for its grace period, and marks the request as no
+// longer ready. It is safe to call on every reconcile during the grace period.
+func (r *RequestReconciler) removeAccessResources(rctx *RequestContext, deleteAt time.Time) error {
+	if err := r.deleteAccessResources(rctx); err != nil {
+		return err
+	}
+	// The grant token no longer describes any access.
+	rctx.obj.GetStatus().(v1alpha1.IRequestStatus).SetGrantToken("")
+	if err := status.SetAccessResourcesRemoved(rctx.Context, r, rctx.obj, deleteAt); err != nil {
+		return err
+	}
+	return status.SetReadyStatus(rctx, r, rctx.obj)
+}
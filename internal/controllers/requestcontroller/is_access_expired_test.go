@@ -8,11 +8,14 @@ import (
 	. "github.com/onsi/gomega"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
 	"github.com/diranged/oz/internal/testing/utils"
 )
 
@@ -117,7 +120,7 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 			Expect(err).ToNot(HaveOccurred())
 
 			// Execute
-			shouldEndReconcile, _, err := reconciler.isAccessExpired(rctx)
+			shouldEndReconcile, _, err := reconciler.isAccessExpired(rctx, template)
 
 			// VERIFY: No, do not end
 			Expect(shouldEndReconcile).To(BeFalse())
@@ -145,7 +148,7 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 				Expect(err).ToNot(HaveOccurred())
 
 				// Execute
-				shouldEndReconcile, _, err := reconciler.isAccessExpired(rctx)
+				shouldEndReconcile, _, err := reconciler.isAccessExpired(rctx, template)
 
 				// VERIFY: Yes, end the reconcile
 				Expect(shouldEndReconcile).To(BeTrue())
@@ -162,4 +165,297 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 			},
 		)
 	})
+
+	Context("isAccessExpired() with an expirationGracePeriod", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			builder    *mockBuilder
+			rctx       *RequestContext
+			expiredAt  = time.Now().Truncate(time.Second)
+		)
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessTemplate with a grace period")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:         []string{"foo"},
+						DefaultDuration:       "1h",
+						MaxDuration:           "2h",
+						ExpirationGracePeriod: "10m",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an expired ExecAccessRequest built to test against")
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "isaccessexpired-grace-test",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			request.Status.Conditions = []metav1.Condition{
+				{
+					Type:               string(v1alpha1.ConditionAccessStillValid),
+					Status:             metav1.ConditionFalse,
+					ObservedGeneration: 1,
+					LastTransitionTime: metav1.NewTime(expiredAt),
+					Reason:             string(metav1.StatusReasonTimeout),
+					Message:            "Access expired",
+				},
+			}
+			request.Status.Ready = true
+			err = k8sClient.Status().Update(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler")
+			builder = &mockBuilder{}
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                builder,
+				ReconciliationInterval: 0,
+			}
+
+			By("Creating the RequestContext")
+			rctx = newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).To(BeNil())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("isAccessExpired() should revoke the access, but keep the request during the grace period", func() {
+			reconciler.now = func() time.Time { return expiredAt.Add(time.Minute) }
+
+			shouldEndReconcile, result, err := reconciler.isAccessExpired(rctx, template)
+
+			// VERIFY: End the reconcile, and come back once the grace period is over
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(9 * time.Minute))
+
+			// VERIFY: The access resources are gone, but the request is not
+			Expect(builder.deleteResourcesCalled).To(BeTrue())
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.GetDeletionTimestamp().IsZero()).To(BeTrue())
+			Expect(request.IsReady()).To(BeFalse())
+			cond := meta.FindStatusCondition(
+				request.Status.Conditions,
+				v1alpha1.ConditionAccessResourcesCreated.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessResourcesRemoved))
+			Expect(cond.Message).To(ContainSubstring(
+				expiredAt.Add(10 * time.Minute).UTC().Format(time.RFC3339),
+			))
+		})
+
+		It("isAccessExpired() should delete the request once the grace period is over", func() {
+			reconciler.now = func() time.Time { return expiredAt.Add(10 * time.Minute) }
+
+			shouldEndReconcile, _, err := reconciler.isAccessExpired(rctx, template)
+
+			// VERIFY: The object was deleted
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, &v1alpha1.ExecAccessRequest{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Context("isAccessExpired() with the CleanupExemptAnnotation", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			builder    *mockBuilder
+			rctx       *RequestContext
+			createdAt  time.Time
+		)
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessTemplate without a grace period")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an expired, exempt ExecAccessRequest built to test against")
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "isaccessexpired-exempt-test",
+					Namespace: ns.GetName(),
+					Annotations: map[string]string{
+						v1alpha1.CleanupExemptAnnotation: "true",
+					},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			createdAt = request.GetCreationTimestamp().Time
+			request.Status.Conditions = []metav1.Condition{
+				{
+					Type:               string(v1alpha1.ConditionAccessStillValid),
+					Status:             metav1.ConditionFalse,
+					ObservedGeneration: 1,
+					LastTransitionTime: metav1.NewTime(createdAt.Add(time.Hour)),
+					Reason:             string(metav1.StatusReasonTimeout),
+					Message:            "Access expired",
+				},
+			}
+			request.Status.Ready = true
+			err = k8sClient.Status().Update(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler")
+			builder = &mockBuilder{}
+			reconciler = &RequestReconciler{
+				Client:              k8sClient,
+				Scheme:              k8sClient.Scheme(),
+				APIReader:           k8sClient,
+				RequestType:         &v1alpha1.ExecAccessRequest{},
+				Builder:             builder,
+				AbsoluteMaxDuration: 3 * time.Hour,
+			}
+
+			By("Creating the RequestContext")
+			rctx = newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).To(BeNil())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("isAccessExpired() should revoke the access, but keep the request until the hard lifetime", func() {
+			reconciler.now = func() time.Time { return createdAt.Add(time.Hour + time.Minute) }
+
+			shouldEndReconcile, result, err := reconciler.isAccessExpired(rctx, template)
+
+			// VERIFY: End the reconcile, and come back once the absolute max duration is reached
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(time.Hour + 59*time.Minute))
+
+			// VERIFY: The access resources are gone, but the request is not
+			Expect(builder.deleteResourcesCalled).To(BeTrue())
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.GetDeletionTimestamp().IsZero()).To(BeTrue())
+			Expect(request.IsReady()).To(BeFalse())
+		})
+
+		It("isAccessExpired() should delete the request once it reaches the hard lifetime", func() {
+			reconciler.now = func() time.Time { return createdAt.Add(3 * time.Hour) }
+
+			shouldEndReconcile, _, err := reconciler.isAccessExpired(rctx, template)
+
+			// VERIFY: The object was deleted
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, &v1alpha1.ExecAccessRequest{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
 })
@@ -0,0 +1,33 @@
+package requestcontroller
+
+import (
+	"fmt"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/granttoken"
+)
+
+// issueGrantToken signs a grant token describing the access granted by a ready
+// request, and records it in the request status. The token is re-issued
+// whenever the grant it describes changes (eg, the request was transferred),
+// or the GrantTokenSigner has been rotated to a new key.
+func (r *RequestReconciler) issueGrantToken(rctx *RequestContext) error {
+	if r.GrantTokenSigner == nil || !rctx.obj.IsReady() || rctx.expiresAt.IsZero() {
+		return nil
+	}
+
+	st := rctx.obj.GetStatus().(v1alpha1.IRequestStatus)
+	claims := granttoken.ForRequest(rctx.obj, r.getNow(), rctx.expiresAt)
+	if r.GrantTokenSigner.IsCurrent(st.GetGrantToken(), claims) {
+		return nil
+	}
+
+	token, err := r.GrantTokenSigner.Sign(claims)
+	if err != nil {
+		return fmt.Errorf("unable to sign grant token: %w", err)
+	}
+	st.SetGrantToken(token)
+	rctx.log.Info("Issued grant token", "kid", r.GrantTokenSigner.KeyID(), "expiresAt", rctx.expiresAt)
+	return status.UpdateStatus(rctx.Context, r, rctx.obj)
+}
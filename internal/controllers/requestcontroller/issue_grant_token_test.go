@@ -0,0 +1,153 @@
+package requestcontroller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/granttoken"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+func newSigningKey() []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	der, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		issueGrantToken() Tests
+	*/
+	Context("issueGrantToken()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			reconciler *RequestReconciler
+			oldKey     = newSigningKey()
+			newKey     = newSigningKey()
+		)
+
+		reconcileRequest := func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      request.GetName(),
+					Namespace: request.GetNamespace(),
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, request)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessRequest")
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "grant-token-test",
+					Namespace:   ns.GetName(),
+					Annotations: map[string]string{v1alpha1.RequestedByAnnotation: "alice"},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: "bogus",
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler with a GrantTokenSigner")
+			signer, err := granttoken.NewSigner("", []string{"downstream"}, oldKey)
+			Expect(err).ToNot(HaveOccurred())
+			reconciler = &RequestReconciler{
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				APIReader:   k8sClient,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder: &mockBuilder{
+					getTemplateResp:             &v1alpha1.ExecAccessTemplate{},
+					getDurationResp:             time.Hour,
+					createResourcesResp:         "Role XYZ created",
+					accessResourcesAreReadyResp: true,
+				},
+				ReconciliationInterval: time.Minute,
+				GrantTokenSigner:       signer,
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		var issued string
+
+		It("Reconcile() should issue a grant token once the access is ready", func() {
+			reconcileRequest()
+
+			// VERIFY: The token describes the grant
+			Expect(request.IsReady()).To(BeTrue())
+			issued = request.Status.GrantToken
+			claims, kid, err := reconciler.GrantTokenSigner.Verify(issued)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(kid).To(Equal(reconciler.GrantTokenSigner.KeyID()))
+			Expect(claims.Subject).To(Equal("alice"))
+			Expect(claims.ID).To(Equal(string(request.GetUID())))
+			Expect(claims.Audience).To(ConsistOf("downstream"))
+			Expect(claims.Name).To(Equal("grant-token-test"))
+			Expect(claims.Namespace).To(Equal(ns.GetName()))
+			Expect(claims.Template).To(Equal("bogus"))
+			Expect(claims.ExpiresAt.Unix()).To(Equal(request.GetCreationTimestamp().Add(time.Hour).Unix()))
+		})
+
+		It("Reconcile() should keep the token while the grant is unchanged", func() {
+			reconcileRequest()
+
+			Expect(request.Status.GrantToken).To(Equal(issued))
+		})
+
+		It("Reconcile() should re-issue the token after the signing key is rotated", func() {
+			signer, err := granttoken.NewSigner("", []string{"downstream"}, newKey, oldKey)
+			Expect(err).ToNot(HaveOccurred())
+			reconciler.GrantTokenSigner = signer
+
+			reconcileRequest()
+
+			// VERIFY: Signed by the new key, and the old token still verifies
+			Expect(request.Status.GrantToken).ToNot(Equal(issued))
+			_, kid, err := signer.Verify(request.Status.GrantToken)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(kid).To(Equal(signer.KeyID()))
+			_, _, err = signer.Verify(issued)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
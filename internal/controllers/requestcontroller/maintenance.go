@@ -0,0 +1,73 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+)
+
+// maintenanceDeniedMsg explains why a request was denied in maintenance mode.
+const maintenanceDeniedMsg = "Access denied: oz is in maintenance mode and is not granting new access"
+
+// MaintenanceMode puts the RequestReconciler into maintenance mode, for
+// controlled shutdowns and upgrades. While in maintenance mode, Access
+// Requests that have not been granted yet are denied, and (optionally) the
+// access that was already granted is drained.
+type MaintenanceMode struct {
+	// DrainAt is optional. If set, the access granted by every Access
+	// Request ends at this time at the latest, so that no elevated access
+	// lingers during the maintenance. Requesters are warned ahead of time
+	// like they are about any other access that is about to expire.
+	DrainAt time.Time
+}
+
+// verifyNotInMaintenance denies the request if the controller is in
+// maintenance mode and the request has not created its access resources yet.
+// Requests that are already on their way out are skipped. The
+// ConditionAccessStillValid condition is flipped to False, so that
+// isAccessExpired() cleans the request up. Access that was already granted
+// is left alone here - it is drained by clampAccessDuration() instead.
+func (r *RequestReconciler) verifyNotInMaintenance(rctx *RequestContext) error {
+	if r.Maintenance == nil {
+		return nil
+	}
+	conditions := *rctx.obj.GetStatus().GetConditions()
+	if meta.IsStatusConditionTrue(conditions, v1alpha1.ConditionAccessResourcesCreated.String()) ||
+		meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionAccessStillValid.String()) {
+		return nil
+	}
+
+	rctx.log.Info(maintenanceDeniedMsg)
+	if err := status.SetAccessMaintenance(rctx.Context, r, rctx.obj, maintenanceDeniedMsg); err != nil {
+		return err
+	}
+	return r.notifyRequester(rctx, notify.EventDenied, maintenanceDeniedMsg)
+}
+
+// clampToMaintenanceDrain shortens the access duration of a request created
+// at the supplied time so that it ends by the DrainAt of the MaintenanceMode,
+// if one is set. The decision is extended to explain any clamping.
+func (r *RequestReconciler) clampToMaintenanceDrain(
+	created time.Time,
+	accessDuration time.Duration,
+	decision string,
+) (time.Duration, string) {
+	if r.Maintenance == nil || r.Maintenance.DrainAt.IsZero() {
+		return accessDuration, decision
+	}
+	drainAt := r.Maintenance.DrainAt
+	if !created.Add(accessDuration).After(drainAt) {
+		return accessDuration, decision
+	}
+	accessDuration = drainAt.Sub(created)
+	if accessDuration < 0 {
+		accessDuration = 0
+	}
+	return accessDuration, fmt.Sprintf("%s, clamped to the maintenance drain at %s",
+		decision, drainAt.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,185 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		verifyNotInMaintenance() / clampToMaintenanceDrain() Tests
+	*/
+	Context("MaintenanceMode", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			builder    = &mockBuilder{}
+		)
+
+		// newRctx creates an ExecAccessRequest, and returns a populated
+		// RequestContext for it.
+		newRctx := func() *RequestContext {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err := k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			rctx := newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+
+			By("Creating the RequestReconciler in maintenance mode")
+			reconciler = &RequestReconciler{
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				APIReader:   k8sClient,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     builder,
+				Maintenance: &MaintenanceMode{},
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("verifyNotInMaintenance() should deny a new request", func() {
+			rctx := newRctx()
+
+			err := reconciler.verifyNotInMaintenance(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The access is flipped to invalid, so that it is cleaned up
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessMaintenance))
+			Expect(cond.Message).To(ContainSubstring("maintenance mode"))
+		})
+
+		It("verifyNotInMaintenance() should leave a request that was already granted alone", func() {
+			rctx := newRctx()
+			err := status.SetAccessResourcesCreated(ctx, reconciler, rctx.obj, "created")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = reconciler.verifyNotInMaintenance(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)).To(BeNil())
+		})
+
+		It("verifyDuration() should leave granted access alone without a drain", func() {
+			builder.getDurationErr = nil
+			builder.getDurationResp = time.Hour
+			rctx := newRctx()
+
+			_, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rctx.expiresAt).To(Equal(rctx.obj.GetCreationTimestamp().Add(time.Hour)))
+		})
+
+		It("verifyDuration() should end granted access at the maintenance drain", func() {
+			builder.getDurationErr = nil
+			builder.getDurationResp = time.Hour
+			rctx := newRctx()
+			created := rctx.obj.GetCreationTimestamp().Time
+
+			// Drain the access 10 minutes into its hour
+			reconciler.Maintenance = &MaintenanceMode{DrainAt: created.Add(10 * time.Minute)}
+			reconciler.now = func() time.Time { return created.Add(5 * time.Minute) }
+			DeferCleanup(func() {
+				reconciler.Maintenance = &MaintenanceMode{}
+				reconciler.now = nil
+			})
+
+			_, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The access still runs until the drain, and says why
+			Expect(rctx.expiresAt).To(Equal(created.Add(10 * time.Minute)))
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionRequestDurationsValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Message).To(ContainSubstring("clamped to the maintenance drain"))
+			Expect(meta.IsStatusConditionTrue(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)).To(BeTrue())
+
+			// VERIFY: Once the drain passes, the access is expired
+			reconciler.now = func() time.Time { return created.Add(11 * time.Minute) }
+			_, _, err = reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.IsStatusConditionFalse(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)).To(BeTrue())
+		})
+	})
+})
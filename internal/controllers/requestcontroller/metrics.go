@@ -0,0 +1,95 @@
+package requestcontroller
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// traceIDExemplarLabel is the exemplar label that holds the trace ID of an
+// observation, as expected by Grafana and friends.
+const traceIDExemplarLabel = "trace_id"
+
+// grantDurationSeconds measures how long the access granted by Access Requests
+// lasts for, once it is granted. Observations carry the trace ID of the
+// request as an exemplar, if it has a TraceParentAnnotation.
+var grantDurationSeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name: "oz_access_grant_duration_seconds",
+		Help: "Duration of the access granted by Access Requests",
+		// 5m through to ~42h
+		Buckets: prometheus.ExponentialBuckets(300, 2, 10),
+	},
+)
+
+// sharedPodAssignmentsTotal counts the Access Requests found to share their
+// target Pod with other active requests (see detectSharedPod), by namespace.
+// Each request is counted once per time it starts sharing its Pod.
+var sharedPodAssignmentsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "oz_access_shared_pod_assignments_total",
+		Help: "Access Requests found to share their target Pod with other active requests",
+	},
+	[]string{"namespace"},
+)
+
+// approvalSLOBreachesTotal counts the Access Requests that waited on approval
+// for longer than the approval SLO (see checkApprovalSLO), by namespace. Each
+// request is counted once.
+var approvalSLOBreachesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "oz_access_approval_slo_breaches_total",
+		Help: "Access Requests that waited on approval for longer than the approval SLO",
+	},
+	[]string{"namespace"},
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		grantDurationSeconds, sharedPodAssignmentsTotal, approvalSLOBreachesTotal, clockSkewSeconds,
+	)
+}
+
+// observeGrantDuration records the duration of the access granted by the
+// supplied request in the grantDurationSeconds histogram. If the request
+// carries a valid TraceParentAnnotation, its trace ID is attached as an
+// exemplar.
+func observeGrantDuration(req v1alpha1.IRequestResource, duration time.Duration) {
+	traceID, ok := traceIDFromTraceParent(req.GetAnnotations()[v1alpha1.TraceParentAnnotation])
+	if !ok {
+		grantDurationSeconds.Observe(duration.Seconds())
+		return
+	}
+	grantDurationSeconds.(prometheus.ExemplarObserver).ObserveWithExemplar(
+		duration.Seconds(), prometheus.Labels{traceIDExemplarLabel: traceID},
+	)
+}
+
+// traceIDFromTraceParent returns the trace ID of a W3C traceparent
+// ("00-<trace-id>-<parent-id>-<flags>"), and whether it is valid at all.
+func traceIDFromTraceParent(traceParent string) (string, bool) {
+	parts := strings.Split(traceParent, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", false
+	}
+	traceID := parts[1]
+	if len(traceID) != 32 || !isLowerHex(traceID) || traceID == strings.Repeat("0", 32) {
+		return "", false
+	}
+	return traceID, true
+}
+
+// isLowerHex returns true if the supplied string only holds lower case
+// hexadecimal digits.
+func isLowerHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package requestcontroller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("observeGrantDuration()", func() {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	// exemplarOf returns the exemplar of the grantDurationSeconds bucket that
+	// an observation of the supplied duration lands in.
+	exemplarOf := func(duration time.Duration) *dto.Exemplar {
+		metric := &dto.Metric{}
+		Expect(grantDurationSeconds.Write(metric)).To(Succeed())
+		for _, bucket := range metric.GetHistogram().GetBucket() {
+			if duration.Seconds() <= bucket.GetUpperBound() {
+				return bucket.GetExemplar()
+			}
+		}
+		return nil
+	}
+
+	newRequest := func(annotations map[string]string) *v1alpha1.ExecAccessRequest {
+		return &v1alpha1.ExecAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", Annotations: annotations},
+		}
+	}
+
+	It("Should attach the trace ID as an exemplar when a trace context is present", func() {
+		observeGrantDuration(newRequest(map[string]string{
+			v1alpha1.TraceParentAnnotation: "00-" + traceID + "-00f067aa0ba902b7-01",
+		}), 4*time.Minute)
+
+		exemplar := exemplarOf(4 * time.Minute)
+		Expect(exemplar).ToNot(BeNil())
+		Expect(exemplar.GetValue()).To(Equal((4 * time.Minute).Seconds()))
+		Expect(exemplar.GetLabel()).To(HaveLen(1))
+		Expect(exemplar.GetLabel()[0].GetName()).To(Equal(traceIDExemplarLabel))
+		Expect(exemplar.GetLabel()[0].GetValue()).To(Equal(traceID))
+	})
+
+	It("Should not attach an exemplar without a trace context", func() {
+		observeGrantDuration(newRequest(nil), 20*time.Hour)
+		Expect(exemplarOf(20 * time.Hour)).To(BeNil())
+	})
+
+	DescribeTable("traceIDFromTraceParent()",
+		func(traceParent string, expected string, valid bool) {
+			ret, ok := traceIDFromTraceParent(traceParent)
+			Expect(ok).To(Equal(valid))
+			Expect(ret).To(Equal(expected))
+		},
+		Entry("valid", "00-"+traceID+"-00f067aa0ba902b7-01", traceID, true),
+		Entry("empty", "", "", false),
+		Entry("too few fields", "00-"+traceID, "", false),
+		Entry("invalid version", "ff-"+traceID+"-00f067aa0ba902b7-01", "", false),
+		Entry("short trace ID", "00-4bf92f35-00f067aa0ba902b7-01", "", false),
+		Entry("upper case trace ID", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "", false),
+		Entry("all-zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", "", false),
+	)
+})
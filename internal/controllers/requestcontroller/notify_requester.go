@@ -0,0 +1,63 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+)
+
+// notifyRequester sends the requester of the Access Request a notification
+// about the event, if a Notifier is configured and they have not already been
+// notified about it. Each event that was delivered is recorded in the
+// Status.RequesterNotified field, so that it is only ever delivered once.
+//
+// The outcome of each delivery is recorded in the ConditionNotificationSent
+// condition. Delivery failures are logged rather than failing the reconcile,
+// and the notification is retried on the next reconcile. Requesters without a
+// notification destination are skipped.
+func (r *RequestReconciler) notifyRequester(
+	rctx *RequestContext,
+	event notify.Event,
+	message string,
+) error {
+	if r.Notifier == nil {
+		return nil
+	}
+	reqStatus := rctx.obj.GetStatus().(v1alpha1.IRequestStatus)
+	if reqStatus.HasNotifiedRequester(string(event)) {
+		return nil
+	}
+
+	sent, err := r.Notifier.NotifyRequester(rctx.Context, rctx.obj, event, message)
+	if err != nil {
+		rctx.log.Error(err, "Failed to notify requester, will retry", "event", event)
+		return status.SetNotificationFailed(rctx.Context, r, rctx.obj, string(event), err)
+	}
+	if !sent {
+		rctx.log.V(1).Info("Requester has no notification destination", "event", event)
+		return nil
+	}
+
+	rctx.log.Info("Notified requester", "event", event, "notifier", r.Notifier.Notifier.Name())
+	reqStatus.AddRequesterNotified(string(event))
+	return status.SetNotificationSent(rctx.Context, r, rctx.obj, string(event))
+}
+
+// notifyExpiring warns the requester of a granted Access Request once it is
+// within the ExpiringWithin window of the Notifier.
+func (r *RequestReconciler) notifyExpiring(rctx *RequestContext, expiresAt time.Time) error {
+	if r.Notifier == nil || !rctx.obj.IsReady() {
+		return nil
+	}
+	remaining := expiresAt.Sub(r.getNow())
+	if remaining > r.Notifier.GetExpiringWithin() {
+		return nil
+	}
+	return r.notifyRequester(rctx, notify.EventExpiring, fmt.Sprintf(
+		"Access expires in %s, at %s",
+		remaining.Round(time.Second), expiresAt.UTC().Format(time.RFC3339),
+	))
+}
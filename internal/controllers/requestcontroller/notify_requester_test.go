@@ -0,0 +1,235 @@
+package requestcontroller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+// fakeNotifier records every notification delivered to it, and can be told to
+// fail.
+type fakeNotifier struct {
+	notifications []notify.Notification
+	err           error
+}
+
+func (n *fakeNotifier) Name() string { return "fake" }
+
+func (n *fakeNotifier) Notify(_ context.Context, notification notify.Notification) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func (n *fakeNotifier) events() []notify.Event {
+	ret := []notify.Event{}
+	for _, notification := range n.notifications {
+		ret = append(ret, notification.Event)
+	}
+	return ret
+}
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		notifyRequester() Tests
+	*/
+	Context("notifyRequester()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			reconciler *RequestReconciler
+			builder    *mockBuilder
+			notifier   *fakeNotifier
+		)
+
+		// newRequest creates an ExecAccessRequest, recording the requester the
+		// same way that the mutating webhook would.
+		newRequest := func(requester string) {
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        utils.RandomString(8),
+					Namespace:   ns.GetName(),
+					Annotations: map[string]string{v1alpha1.RequestedByAnnotation: requester},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: "bogus",
+				},
+			}
+			err := k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		reconcileRequest := func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      request.GetName(),
+					Namespace: request.GetNamespace(),
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, request)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(k8sClient.Delete, ctx, ns)
+
+			By("Creating the RequestReconciler with a Notifier")
+			builder = &mockBuilder{
+				getTemplateResp:             &v1alpha1.ExecAccessTemplate{},
+				getDurationResp:             time.Hour,
+				createResourcesResp:         "Role XYZ created",
+				accessResourcesAreReadyResp: true,
+			}
+			notifier = &fakeNotifier{}
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                builder,
+				ReconciliationInterval: time.Minute,
+				Notifier: &notify.RequesterNotifier{
+					Notifier:     notifier,
+					Destinations: map[string]string{"alice": "U024BE7LH"},
+				},
+			}
+		})
+
+		It("Reconcile() should notify the requester once when their access is granted", func() {
+			newRequest("alice")
+
+			reconcileRequest()
+			reconcileRequest()
+
+			// VERIFY: A single notification, addressed to the requester
+			Expect(notifier.notifications).To(HaveLen(1))
+			notification := notifier.notifications[0]
+			Expect(notification.Event).To(Equal(notify.EventGranted))
+			Expect(notification.Destination).To(Equal("U024BE7LH"))
+			Expect(notification.User).To(Equal("alice"))
+			Expect(notification.Kind).To(Equal("ExecAccessRequest"))
+			Expect(notification.Name).To(Equal(request.GetName()))
+			Expect(notification.Namespace).To(Equal(ns.GetName()))
+			Expect(notification.Message).To(Equal("Access granted"))
+			Expect(request.Status.RequesterNotified).To(Equal([]string{"granted"}))
+		})
+
+		It("Reconcile() should retry a notification that failed to be delivered", func() {
+			newRequest("alice")
+			notifier.err = errors.New("unavailable")
+
+			// VERIFY: The failure does not fail the reconcile, but is surfaced
+			reconcileRequest()
+			Expect(request.IsReady()).To(BeTrue())
+			Expect(request.Status.RequesterNotified).To(BeEmpty())
+			cond := meta.FindStatusCondition(request.Status.Conditions, v1alpha1.ConditionNotificationSent.String())
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonNotificationFailed))
+			Expect(cond.Message).To(ContainSubstring("unavailable"))
+
+			notifier.err = nil
+			reconcileRequest()
+			Expect(notifier.events()).To(Equal([]notify.Event{notify.EventGranted}))
+			cond = meta.FindStatusCondition(request.Status.Conditions, v1alpha1.ConditionNotificationSent.String())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("Reconcile() should record the delivery in the NotificationSent condition", func() {
+			newRequest("alice")
+
+			reconcileRequest()
+
+			cond := meta.FindStatusCondition(request.Status.Conditions, v1alpha1.ConditionNotificationSent.String())
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Reason).To(Equal(status.ReasonNotificationDelivered))
+			Expect(cond.Message).To(Equal(`Notification "granted" delivered to the requester`))
+		})
+
+		It("Reconcile() should skip requesters without a notification destination", func() {
+			newRequest("bob")
+
+			reconcileRequest()
+
+			Expect(request.IsReady()).To(BeTrue())
+			Expect(notifier.notifications).To(BeEmpty())
+			Expect(meta.FindStatusCondition(
+				request.Status.Conditions, v1alpha1.ConditionNotificationSent.String(),
+			)).To(BeNil())
+		})
+
+		It("Reconcile() should warn the requester once their access is about to expire", func() {
+			newRequest("alice")
+			reconcileRequest()
+
+			By("Moving the clock to 10 minutes before the request expires")
+			reconciler.now = func() time.Time {
+				return request.GetCreationTimestamp().Add(50 * time.Minute)
+			}
+			reconcileRequest()
+			reconcileRequest()
+
+			// VERIFY: Granted, then a single warning
+			Expect(notifier.events()).To(Equal([]notify.Event{notify.EventGranted, notify.EventExpiring}))
+			Expect(notifier.notifications[1].Message).To(HavePrefix("Access expires in 10m0s, at "))
+		})
+
+		It("Reconcile() should notify the requester when their access is revoked", func() {
+			newRequest("alice")
+			reconcileRequest()
+
+			By("Revoking the request, the way the mutating webhook records it")
+			request.Annotations[v1alpha1.RevokeAnnotation] = "incident resolved"
+			request.Annotations[v1alpha1.RevokedByAnnotation] = "bob"
+			err := k8sClient.Update(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			reconcileRequest()
+
+			Expect(notifier.events()).To(Equal([]notify.Event{notify.EventGranted, notify.EventRevoked}))
+			Expect(notifier.notifications[1].Message).To(Equal("Access revoked by bob: incident resolved"))
+		})
+
+		It("Reconcile() should notify the requester when their request is denied", func() {
+			newRequest("alice")
+			builder.getDurationErr = fmt.Errorf("%w: 3h is longer than 2h", builders.ErrRequestDurationTooLong)
+
+			reconcileRequest()
+
+			Expect(notifier.events()).To(Equal([]notify.Event{notify.EventDenied}))
+			Expect(notifier.notifications[0].Message).To(ContainSubstring("Access denied: "))
+			Expect(notifier.notifications[0].Message).To(ContainSubstring("3h is longer than 2h"))
+		})
+	})
+})
@@ -0,0 +1,33 @@
+package requestcontroller
+
+import (
+	"errors"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+)
+
+// prepareAccessResources asks the Builder to prepare the access resources it
+// can while the request is waiting on approvals. This only makes the access
+// quicker to grant once approved, so failures are logged rather than failing
+// the reconcile - the resources are created as usual on approval.
+//
+// Anything prepared here is owned by the request, and is cleaned up by the
+// finalizer if the request is denied or expires unapproved.
+func (r *RequestReconciler) prepareAccessResources(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+) {
+	statusStr, err := r.Builder.PrepareAccessResources(rctx.Context, r.Client, rctx.obj, tmpl)
+	if errors.Is(err, builders.ErrMaxPodsReached) {
+		rctx.log.V(1).Info("Not preparing Access Resources", "reason", err.Error())
+		return
+	}
+	if err != nil {
+		rctx.log.Error(err, "Failed to prepare Access Resources")
+		return
+	}
+	if statusStr != "" {
+		rctx.log.V(1).Info(statusStr)
+	}
+}
@@ -0,0 +1,116 @@
+package requestcontroller
+
+import (
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+)
+
+// verifySession checks whether the session of the request has been ended by
+// another request in it (see endSession()). If so, the
+// ConditionAccessStillValid condition is flipped to False, so that
+// isAccessExpired() tears the access down right away. Requests whose access
+// already ended for another reason keep that reason.
+func (r *RequestReconciler) verifySession(rctx *RequestContext) error {
+	endedBy, ended := v1alpha1.GetSessionEnded(rctx.obj)
+	if !ended || meta.IsStatusConditionFalse(
+		*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionAccessStillValid.String(),
+	) {
+		return nil
+	}
+
+	message := fmt.Sprintf("Access ended with session %s, when the access of %s ended",
+		rctx.obj.GetSessionID(), endedBy)
+	rctx.log.Info(message)
+	if err := status.SetAccessSessionEnded(rctx.Context, r, rctx.obj, message); err != nil {
+		return err
+	}
+	return r.notifyRequester(rctx, notify.EventRevoked, message)
+}
+
+// endSession cascades the end of the access of the request to every other
+// request in its session, by setting the SessionEndedAnnotation on them.
+// Only the requests made by the same requester are ended, so that joining
+// the session of another user does not let anyone end their access. Requests
+// that are being deleted, or whose access has already ended, are left alone.
+// It is safe to call on every reconcile of an expired request.
+func (r *RequestReconciler) endSession(rctx *RequestContext) error {
+	sessionID := rctx.obj.GetSessionID()
+	if sessionID == "" {
+		return nil
+	}
+
+	members, err := listSessionRequests(rctx, r.APIReader, rctx.obj.GetNamespace(), sessionID)
+	if err != nil {
+		return err
+	}
+	requester := v1alpha1.GetRequester(rctx.obj)
+	for _, member := range members {
+		if member.GetUID() == rctx.obj.GetUID() || !member.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		if v1alpha1.GetRequester(member) != requester {
+			continue
+		}
+		if _, ended := v1alpha1.GetSessionEnded(member); ended || meta.IsStatusConditionFalse(
+			*member.GetStatus().GetConditions(), v1alpha1.ConditionAccessStillValid.String(),
+		) {
+			continue
+		}
+
+		patch := client.MergeFrom(member.DeepCopyObject().(client.Object))
+		annotations := member.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[v1alpha1.SessionEndedAnnotation] = rctx.obj.GetName()
+		member.SetAnnotations(annotations)
+		if err := r.Patch(rctx.Context, member, patch); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		rctx.log.Info(fmt.Sprintf("Ending the access of %s, in session %s", member.GetName(), sessionID))
+	}
+	return nil
+}
+
+// listSessionRequests returns the Access Requests of every kind in the
+// supplied namespace that share the supplied session, looked up by their
+// SessionIDLabel.
+func listSessionRequests(
+	rctx *RequestContext,
+	reader client.Reader,
+	namespace string,
+	sessionID string,
+) ([]v1alpha1.IRequestResource, error) {
+	var members []v1alpha1.IRequestResource
+	opts := []client.ListOption{
+		client.InNamespace(namespace),
+		client.MatchingLabels{v1alpha1.SessionIDLabel: sessionID},
+	}
+
+	execReqs := &v1alpha1.ExecAccessRequestList{}
+	if err := reader.List(rctx.Context, execReqs, opts...); err != nil {
+		return nil, err
+	}
+	for i := range execReqs.Items {
+		members = append(members, &execReqs.Items[i])
+	}
+
+	podReqs := &v1alpha1.PodAccessRequestList{}
+	if err := reader.List(rctx.Context, podReqs, opts...); err != nil {
+		return nil, err
+	}
+	for i := range podReqs.Items {
+		members = append(members, &podReqs.Items[i])
+	}
+	return members, nil
+}
@@ -0,0 +1,275 @@
+package requestcontroller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		verifySession() / endSession() Tests
+	*/
+	Context("Sessions", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			builder    = &mockBuilder{}
+		)
+
+		// newRequest creates an ExecAccessRequest in the supplied session (if
+		// any), labelled the way the mutating webhook would label it.
+		newRequest := func(sessionID string) *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+					SessionID:    sessionID,
+				},
+			}
+			if sessionID != "" {
+				request.SetLabels(map[string]string{v1alpha1.SessionIDLabel: sessionID})
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+			return request
+		}
+
+		// newRctx returns a populated RequestContext for the supplied request.
+		newRctx := func(request *v1alpha1.ExecAccessRequest) *RequestContext {
+			rctx := newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+			return rctx
+		}
+
+		// fetch returns the current revision of the supplied request.
+		fetch := func(request *v1alpha1.ExecAccessRequest) (*v1alpha1.ExecAccessRequest, error) {
+			current := &v1alpha1.ExecAccessRequest{}
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, current)
+			return current, err
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+			By("Should have an ExecAccessTemplate to test against")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+
+			By("Creating the RequestReconciler")
+			reconciler = &RequestReconciler{
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				APIReader:   k8sClient,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     builder,
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("Should end every request in the session when one of them is revoked", func() {
+			first := newRequest("debug-1")
+			second := newRequest("debug-1")
+			third := newRequest("debug-1")
+			otherSession := newRequest("debug-2")
+			noSession := newRequest("")
+
+			// The first request is revoked, and expires
+			rctx := newRctx(first)
+			Expect(status.SetAccessRevoked(ctx, reconciler, rctx.obj, "Access revoked by bob")).To(Succeed())
+			shouldEndReconcile, _, err := reconciler.isAccessExpired(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeTrue())
+			_, err = fetch(first)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+
+			// The rest of the session is ended by it, and nothing else
+			for _, member := range []*v1alpha1.ExecAccessRequest{second, third} {
+				current, err := fetch(member)
+				Expect(err).ToNot(HaveOccurred())
+				endedBy, ended := v1alpha1.GetSessionEnded(current)
+				Expect(ended).To(BeTrue())
+				Expect(endedBy).To(Equal(first.GetName()))
+			}
+			for _, outsider := range []*v1alpha1.ExecAccessRequest{otherSession, noSession} {
+				current, err := fetch(outsider)
+				Expect(err).ToNot(HaveOccurred())
+				_, ended := v1alpha1.GetSessionEnded(current)
+				Expect(ended).To(BeFalse())
+			}
+
+			// Each of them expires on its next reconcile
+			for _, member := range []*v1alpha1.ExecAccessRequest{second, third} {
+				rctx := newRctx(member)
+				Expect(status.SetAccessStillValid(ctx, reconciler, rctx.obj)).To(Succeed())
+				Expect(reconciler.verifySession(rctx)).To(Succeed())
+				cond := meta.FindStatusCondition(
+					*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionAccessStillValid.String(),
+				)
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Reason).To(Equal(status.ReasonAccessSessionEnded))
+				Expect(cond.Message).To(Equal(
+					"Access ended with session debug-1, when the access of " + first.GetName() + " ended",
+				))
+
+				shouldEndReconcile, _, err := reconciler.isAccessExpired(rctx, template)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(shouldEndReconcile).To(BeTrue())
+				_, err = fetch(member)
+				Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			}
+		})
+
+		It("Should keep the reason of a request that had already ended", func() {
+			first := newRequest("debug-3")
+			second := newRequest("debug-3")
+
+			// Both expire at once
+			secondRctx := newRctx(second)
+			Expect(status.SetAccessNotValid(ctx, reconciler, secondRctx.obj)).To(Succeed())
+			rctx := newRctx(first)
+			Expect(status.SetAccessNotValid(ctx, reconciler, rctx.obj)).To(Succeed())
+			_, _, err := reconciler.isAccessExpired(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			// The second request is left alone
+			current, err := fetch(second)
+			Expect(err).ToNot(HaveOccurred())
+			_, ended := v1alpha1.GetSessionEnded(current)
+			Expect(ended).To(BeFalse())
+			Expect(reconciler.verifySession(secondRctx)).To(Succeed())
+			cond := meta.FindStatusCondition(
+				*secondRctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionAccessStillValid.String(),
+			)
+			Expect(cond.Reason).To(Equal(string(metav1.StatusReasonTimeout)))
+		})
+	})
+})
+
+var _ = Describe("RequestReconciler", func() {
+	/*
+		endSession() Tests, with requests made by different users
+	*/
+	Context("endSession() across requesters", func() {
+		ctx := context.Background()
+
+		// member returns an ExecAccessRequest made by the supplied user, in
+		// the debug-1 session.
+		member := func(name, requester string) *v1alpha1.ExecAccessRequest {
+			return &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        name,
+					Namespace:   "sessions",
+					UID:         types.UID(name),
+					Labels:      map[string]string{v1alpha1.SessionIDLabel: "debug-1"},
+					Annotations: map[string]string{v1alpha1.RequestedByAnnotation: requester},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{TemplateName: "fake", SessionID: "debug-1"},
+			}
+		}
+
+		It("Should not end the requests of other users that joined the session", func() {
+			cl := fake.NewClientBuilder().
+				WithScheme(scheme.Scheme).
+				WithObjects(
+					member("alice-1", "alice"),
+					member("alice-2", "alice"),
+					member("mallory-1", "mallory"),
+				).
+				Build()
+			reconciler := &RequestReconciler{
+				Client:      cl,
+				Scheme:      scheme.Scheme,
+				APIReader:   cl,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+			}
+
+			// The request of mallory, who joined the session, ends
+			rctx := newRequestContext(ctx, reconciler.RequestType, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "mallory-1", Namespace: "sessions"},
+			})
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+			Expect(reconciler.endSession(rctx)).To(Succeed())
+
+			// The requests of alice are left alone
+			for _, name := range []string{"alice-1", "alice-2"} {
+				current := &v1alpha1.ExecAccessRequest{}
+				Expect(cl.Get(ctx, types.NamespacedName{Name: name, Namespace: "sessions"}, current)).
+					To(Succeed())
+				_, ended := v1alpha1.GetSessionEnded(current)
+				Expect(ended).To(BeFalse())
+			}
+
+			// While the end of the access of alice still ends the rest of the session
+			rctx = newRequestContext(ctx, reconciler.RequestType, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "alice-1", Namespace: "sessions"},
+			})
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+			Expect(reconciler.endSession(rctx)).To(Succeed())
+
+			current := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "alice-2", Namespace: "sessions"}, current)).
+				To(Succeed())
+			endedBy, ended := v1alpha1.GetSessionEnded(current)
+			Expect(ended).To(BeTrue())
+			Expect(endedBy).To(Equal("alice-1"))
+
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "mallory-1", Namespace: "sessions"}, current)).
+				To(Succeed())
+			_, ended = v1alpha1.GetSessionEnded(current)
+			Expect(ended).To(BeFalse())
+		})
+	})
+})
@@ -3,12 +3,13 @@ package requestcontroller
 import (
 	"github.com/diranged/oz/internal/controllers/internal/utils"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 )
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *RequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(r.RequestType).
+		For(r.RequestType, builder.WithPredicates(utils.MatchesLabelSelector(r.LabelSelector))).
 		WithEventFilter(utils.IgnoreStatusUpdatesAndDeletion()).
 		Complete(r)
 }
@@ -0,0 +1,48 @@
+package requestcontroller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/utils"
+)
+
+var _ = Describe("RequestReconciler", func() {
+	Context("SetupWithManager()", func() {
+		newRequest := func(lbls map[string]string) *v1alpha1.ExecAccessRequest {
+			return &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "test", Labels: lbls},
+			}
+		}
+
+		It("Should ignore requests that do not match the LabelSelector", func() {
+			selector, err := labels.Parse("shard=a")
+			Expect(err).ToNot(HaveOccurred())
+			reconciler := &RequestReconciler{LabelSelector: selector}
+			filter := utils.MatchesLabelSelector(reconciler.LabelSelector)
+
+			// VERIFY: Only the request in the shard is reconciled
+			Expect(filter.Create(event.CreateEvent{
+				Object: newRequest(map[string]string{"shard": "a"}),
+			})).To(BeTrue())
+			Expect(filter.Create(event.CreateEvent{
+				Object: newRequest(map[string]string{"shard": "b"}),
+			})).To(BeFalse())
+			Expect(filter.Create(event.CreateEvent{Object: newRequest(nil)})).To(BeFalse())
+			Expect(filter.Update(event.UpdateEvent{
+				ObjectOld: newRequest(nil),
+				ObjectNew: newRequest(nil),
+			})).To(BeFalse())
+		})
+
+		It("Should reconcile every request without a LabelSelector", func() {
+			filter := utils.MatchesLabelSelector((&RequestReconciler{}).LabelSelector)
+			Expect(filter.Create(event.CreateEvent{Object: newRequest(nil)})).To(BeTrue())
+		})
+	})
+})
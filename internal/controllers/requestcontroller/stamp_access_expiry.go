@@ -0,0 +1,21 @@
+package requestcontroller
+
+import (
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+)
+
+// stampAccessExpiry records when the access of the request ends on the Role
+// and RoleBinding created for it (see utils.StampAccessExpiry), so that they
+// can be pruned even if the controller is not around to expire them. The
+// expiry is updated whenever the access is extended or cut short.
+//
+// It is a no-op unless StampAccessExpiry is set. Requests adopting an existing
+// RoleBinding are skipped - that RoleBinding was not created by the controller.
+func (r *RequestReconciler) stampAccessExpiry(rctx *RequestContext) error {
+	if !r.StampAccessExpiry || rctx.expiresAt.IsZero() ||
+		v1alpha1.GetAdoptedRoleBinding(rctx.obj) != "" {
+		return nil
+	}
+	return utils.StampAccessExpiry(rctx.Context, r.Client, rctx.obj, rctx.expiresAt)
+}
@@ -0,0 +1,138 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	builderutils "github.com/diranged/oz/internal/builders/utils"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		stampAccessExpiry() Tests
+	*/
+	Context("stampAccessExpiry()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			template   *v1alpha1.ExecAccessTemplate
+			rctx       *RequestContext
+			reconciler *RequestReconciler
+			expiresAt  = time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+		)
+
+		// expiryOf returns the ExpiresAtAnnotation of the named RBAC resource
+		// of the request, as it is in the cluster.
+		expiryOf := func(obj client.Object) string {
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      builderutils.GenerateResourceName(rctx.obj),
+				Namespace: ns.GetName(),
+			}, obj)).To(Succeed())
+			return obj.GetAnnotations()[v1alpha1.ExpiresAtAnnotation]
+		}
+
+		createRBAC := func() {
+			role, err := builderutils.CreateRole(ctx, k8sClient, rctx.obj, template, []rbacv1.PolicyRule{{
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+				Verbs:     []string{"get"},
+			}})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = builderutils.CreateRoleBinding(ctx, k8sClient, rctx.obj, template, rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "Role",
+				Name:     role.GetName(),
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "fake", Namespace: ns.GetName()},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{AllowedGroups: []string{"devs"}},
+				},
+			}
+
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{TemplateName: template.GetName()},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+
+			By("Creating the RequestReconciler")
+			reconciler = &RequestReconciler{
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				APIReader:   k8sClient,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &mockBuilder{},
+			}
+			rctx = newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+			rctx.expiresAt = expiresAt
+
+			By("Creating the Role and RoleBinding of the request")
+			createRBAC()
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("Should leave the RBAC resources alone unless StampAccessExpiry is set", func() {
+			Expect(reconciler.stampAccessExpiry(rctx)).To(Succeed())
+			Expect(expiryOf(&rbacv1.Role{})).To(BeEmpty())
+			Expect(expiryOf(&rbacv1.RoleBinding{})).To(BeEmpty())
+		})
+
+		It("Should stamp the expiry of the access onto the Role and RoleBinding", func() {
+			reconciler.StampAccessExpiry = true
+			Expect(reconciler.stampAccessExpiry(rctx)).To(Succeed())
+			Expect(expiryOf(&rbacv1.Role{})).To(Equal("2023-03-14T15:00:00Z"))
+			Expect(expiryOf(&rbacv1.RoleBinding{})).To(Equal("2023-03-14T15:00:00Z"))
+		})
+
+		It("Should keep the expiry when the RBAC resources are updated by the builder", func() {
+			createRBAC()
+			Expect(expiryOf(&rbacv1.Role{})).To(Equal("2023-03-14T15:00:00Z"))
+			Expect(expiryOf(&rbacv1.RoleBinding{})).To(Equal("2023-03-14T15:00:00Z"))
+		})
+
+		It("Should move the expiry along when the access is extended", func() {
+			rctx.expiresAt = expiresAt.Add(time.Hour)
+			Expect(reconciler.stampAccessExpiry(rctx)).To(Succeed())
+			Expect(expiryOf(&rbacv1.Role{})).To(Equal("2023-03-14T16:00:00Z"))
+			Expect(expiryOf(&rbacv1.RoleBinding{})).To(Equal("2023-03-14T16:00:00Z"))
+		})
+	})
+})
@@ -19,6 +19,7 @@ package requestcontroller
 import (
 	"context"
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -94,6 +95,9 @@ var _ = AfterSuite(func() {
 // builders - this lets us test failures and really focus only on the
 // reconciler logic itself.
 type mockBuilder struct {
+	// mu guards the call counters, for tests that reconcile concurrently
+	mu sync.Mutex
+
 	getTemplateResp v1alpha1.ITemplateResource
 	getTemplateErr  error
 
@@ -102,11 +106,22 @@ type mockBuilder struct {
 
 	setOwnerReferenceErr error
 
-	createResourcesResp string
-	createResourcesErr  error
+	getTargetLabelsResp map[string]string
+	getTargetLabelsErr  error
+
+	prepareResourcesResp  string
+	prepareResourcesErr   error
+	prepareResourcesCalls int
+
+	createResourcesResp  string
+	createResourcesErr   error
+	createResourcesCalls []string
 
 	accessResourcesAreReadyResp bool
 	accessResourcesAreReadyErr  error
+
+	deleteResourcesCalled bool
+	deleteResourcesErr    error
 }
 
 // https://stackoverflow.com/questions/33089523/how-to-mark-golang-struct-as-implementing-interface
@@ -120,6 +135,8 @@ func (b *mockBuilder) GetTemplate(
 	_ client.Client,
 	_ v1alpha1.IRequestResource,
 ) (v1alpha1.ITemplateResource, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.getTemplateResp, b.getTemplateErr
 }
 
@@ -139,12 +156,36 @@ func (b *mockBuilder) SetRequestOwnerReference(
 	return b.setOwnerReferenceErr
 }
 
-func (b *mockBuilder) CreateAccessResources(
+func (b *mockBuilder) GetTargetLabels(
+	_ context.Context,
+	_ client.Client,
+	_ v1alpha1.IRequestResource,
+	_ v1alpha1.ITemplateResource,
+) (map[string]string, error) {
+	return b.getTargetLabelsResp, b.getTargetLabelsErr
+}
+
+func (b *mockBuilder) PrepareAccessResources(
 	_ context.Context,
 	_ client.Client,
 	_ v1alpha1.IRequestResource,
 	_ v1alpha1.ITemplateResource,
 ) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prepareResourcesCalls++
+	return b.prepareResourcesResp, b.prepareResourcesErr
+}
+
+func (b *mockBuilder) CreateAccessResources(
+	_ context.Context,
+	_ client.Client,
+	req v1alpha1.IRequestResource,
+	_ v1alpha1.ITemplateResource,
+) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.createResourcesCalls = append(b.createResourcesCalls, req.GetName())
 	return b.createResourcesResp, b.createResourcesErr
 }
 
@@ -156,3 +197,12 @@ func (b *mockBuilder) AccessResourcesAreReady(
 ) (bool, error) {
 	return b.accessResourcesAreReadyResp, b.accessResourcesAreReadyErr
 }
+
+func (b *mockBuilder) DeleteAccessResources(
+	_ context.Context,
+	_ client.Client,
+	_ v1alpha1.IRequestResource,
+) error {
+	b.deleteResourcesCalled = true
+	return b.deleteResourcesErr
+}
@@ -0,0 +1,194 @@
+package requestcontroller
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// TemplateDeletedPolicy decides what happens to the access granted by an
+// Access Request when its template is deleted before the access expires.
+type TemplateDeletedPolicy string
+
+const (
+	// RevokeOnTemplateDeleted revokes the access right away. This is the
+	// default.
+	RevokeOnTemplateDeleted TemplateDeletedPolicy = "revoke"
+
+	// KeepOnTemplateDeleted lets the access ride out the duration it was
+	// granted for. Requests are no longer owned by their template, so that
+	// deleting the template does not garbage collect them.
+	KeepOnTemplateDeleted TemplateDeletedPolicy = "keep"
+)
+
+// ParseTemplateDeletedPolicy returns the TemplateDeletedPolicy named by the
+// supplied string, or an error if there is no such policy.
+func ParseTemplateDeletedPolicy(s string) (TemplateDeletedPolicy, error) {
+	switch policy := TemplateDeletedPolicy(s); policy {
+	case RevokeOnTemplateDeleted, KeepOnTemplateDeleted:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown template deleted policy %q (must be %q or %q)",
+		s, RevokeOnTemplateDeleted, KeepOnTemplateDeleted)
+}
+
+// handleTemplateDeleted decides what happens to a request whose template
+// could not be found (see verifyTemplate), according to the
+// OnTemplateDeleted policy of the reconciler.
+//
+// Requests that were never granted access are left alone - reconciliation
+// fails as before, until the template shows up. For requests holding access:
+//
+//   - With RevokeOnTemplateDeleted, the access is revoked (the
+//     ConditionAccessStillValid condition flips to False) and the request is
+//     deleted, tearing down its access resources.
+//   - With KeepOnTemplateDeleted, the access is kept until the recorded
+//     Status.AccessExpiresAt (or the maintenance drain, if that comes
+//     first), and the request is requeued for then. Once it has passed, the
+//     access expires and the request is deleted. Access that is revoked,
+//     denied or force-expired in the meantime ends right away (see
+//     verifyKeptAccess()). Requests without a recorded expiry are revoked, as
+//     above.
+//
+// Either way the ConditionTargetTemplateExists condition says what is
+// happening to the access.
+func (r *RequestReconciler) handleTemplateDeleted(
+	rctx *RequestContext,
+	templateErr error,
+) (shouldEndReconcile bool, result ctrl.Result, resultErr error) {
+	if !errors.Is(templateErr, builders.ErrTemplateDoesNotExist) || !accessResourcesCreated(rctx.obj) ||
+		!rctx.obj.GetDeletionTimestamp().IsZero() {
+		return false, result, nil
+	}
+	template := rctx.obj.GetTemplateName()
+
+	expiresAt := rctx.obj.GetStatus().(v1alpha1.IRequestStatus).GetAccessExpiresAt()
+	if r.OnTemplateDeleted == KeepOnTemplateDeleted && expiresAt != nil {
+		ended, err := r.verifyKeptAccess(rctx)
+		if err != nil {
+			return true, result, err
+		}
+		if ended {
+			rctx.log.Info(fmt.Sprintf("Template %s was deleted, and the access has ended", template))
+			if err := status.SetTargetTemplateDeleted(rctx.Context, r, rctx.obj, fmt.Sprintf(
+				"Template %s was deleted, and the access has ended", template,
+			)); err != nil {
+				return true, result, err
+			}
+			if err := r.endSession(rctx); err != nil {
+				return true, result, err
+			}
+			return true, result, r.Delete(rctx.Context, rctx.obj)
+		}
+
+		keptUntil := expiresAt.Time
+		if r.Maintenance != nil && !r.Maintenance.DrainAt.IsZero() && r.Maintenance.DrainAt.Before(keptUntil) {
+			keptUntil = r.Maintenance.DrainAt
+		}
+		if remaining := keptUntil.Sub(r.getNow()); remaining > 0 {
+			rctx.log.Info(fmt.Sprintf("Template %s was deleted, keeping access for %s",
+				template, remaining.Round(time.Second)))
+			if err := status.SetTargetTemplateDeleted(rctx.Context, r, rctx.obj, fmt.Sprintf(
+				"Template %s was deleted, the access is kept until it expires at %s",
+				template, keptUntil.UTC().Format(time.RFC3339),
+			)); err != nil {
+				return true, result, err
+			}
+			return true, ctrl.Result{RequeueAfter: remaining}, nil
+		}
+		rctx.log.Info(fmt.Sprintf("Template %s was deleted, and the access has expired", template))
+		if err := status.SetTargetTemplateDeleted(rctx.Context, r, rctx.obj, fmt.Sprintf(
+			"Template %s was deleted, the access expired at %s",
+			template, keptUntil.UTC().Format(time.RFC3339),
+		)); err != nil {
+			return true, result, err
+		}
+		if err := status.SetAccessNotValid(rctx.Context, r, rctx.obj); err != nil {
+			return true, result, err
+		}
+		return true, result, r.Delete(rctx.Context, rctx.obj)
+	}
+
+	rctx.log.Info(fmt.Sprintf("Template %s was deleted, revoking access", template))
+	if err := status.SetTargetTemplateDeleted(rctx.Context, r, rctx.obj, fmt.Sprintf(
+		"Template %s was deleted, the access has been revoked", template,
+	)); err != nil {
+		return true, result, err
+	}
+	if err := status.SetAccessRevoked(rctx.Context, r, rctx.obj, fmt.Sprintf(
+		"Access revoked because template %s was deleted", template,
+	)); err != nil {
+		return true, result, err
+	}
+	return true, result, r.Delete(rctx.Context, rctx.obj)
+}
+
+// verifyKeptAccess runs the checks that end access without needing its
+// template, against a request that keeps its access after its template was
+// deleted: revocation, the deniedUsers of the OzConfig of the namespace, a
+// forced expiration and the end of its session.
+//
+// Returns:
+//   - true if the access has ended (the ConditionAccessStillValid condition
+//     is False), and the request must be deleted
+//   - An "error" if any of the checks failed
+func (r *RequestReconciler) verifyKeptAccess(rctx *RequestContext) (bool, error) {
+	ended := func() bool {
+		return meta.IsStatusConditionFalse(
+			*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionAccessStillValid.String(),
+		)
+	}
+	if ended() {
+		return true, nil
+	}
+
+	cfg, err := r.getNamespaceConfig(rctx.Context, rctx.obj.GetNamespace())
+	if err != nil {
+		return false, err
+	}
+	rctx.namespaceConfig = cfg
+
+	if err := r.verifyRevocation(rctx, nil); err != nil || ended() {
+		return ended(), err
+	}
+	if err := r.verifyNotDenied(rctx); err != nil || ended() {
+		return ended(), err
+	}
+	if user, forced := v1alpha1.GetForcedExpiration(rctx.obj); forced {
+		rctx.log.Info("Access expiration was forced", "user", user)
+		return true, status.SetAccessForceExpired(rctx.Context, r, rctx.obj, user)
+	}
+	if err := r.verifySession(rctx); err != nil {
+		return false, err
+	}
+	return ended(), nil
+}
+
+// releaseFromTemplate removes the OwnerReference to the supplied template from
+// the request, if it has one, so that deleting the template does not garbage
+// collect the request.
+func (r *RequestReconciler) releaseFromTemplate(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+) error {
+	refs := rctx.obj.GetOwnerReferences()
+	kept := make([]metav1.OwnerReference, 0, len(refs))
+	for _, ref := range refs {
+		if ref.UID != tmpl.GetUID() {
+			kept = append(kept, ref)
+		}
+	}
+	if len(kept) == len(refs) {
+		return nil
+	}
+	rctx.obj.SetOwnerReferences(kept)
+	return r.Update(rctx.Context, rctx.obj)
+}
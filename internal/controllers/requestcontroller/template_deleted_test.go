@@ -0,0 +1,272 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+var _ = Describe("RequestReconciler", func() {
+	/*
+		handleTemplateDeleted() Tests
+	*/
+	Context("handleTemplateDeleted()", func() {
+		var (
+			ctx        = context.Background()
+			now        = time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+			cl         client.Client
+			request    *v1alpha1.ExecAccessRequest
+			template   *v1alpha1.ExecAccessTemplate
+			builder    *mockBuilder
+			reconciler *RequestReconciler
+		)
+
+		// reconcileTemplate runs verifyTemplate() against the request, handing
+		// its error to handleTemplateDeleted() like Reconcile() does.
+		reconcileTemplate := func() (bool, reconcile.Result, error) {
+			rctx := newRequestContext(ctx, reconciler.RequestType, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: request.GetName(), Namespace: request.GetNamespace()},
+			})
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+			_, err := reconciler.verifyTemplate(rctx)
+			if err == nil {
+				return false, reconcile.Result{}, nil
+			}
+			return reconciler.handleTemplateDeleted(rctx, err)
+		}
+
+		// current returns the request as it is in the cluster, or nil if it
+		// has been deleted.
+		current := func() *v1alpha1.ExecAccessRequest {
+			obj := &v1alpha1.ExecAccessRequest{}
+			err := cl.Get(ctx, client.ObjectKeyFromObject(request), obj)
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			Expect(err).ToNot(HaveOccurred())
+			return obj
+		}
+
+		BeforeEach(func() {
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "readonly", Namespace: "ns", UID: "template-uid"},
+			}
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "granted",
+					Namespace: "ns",
+					OwnerReferences: []metav1.OwnerReference{{
+						APIVersion: v1alpha1.GroupVersion.String(),
+						Kind:       "ExecAccessTemplate",
+						Name:       template.GetName(),
+						UID:        template.GetUID(),
+					}},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{TemplateName: template.GetName()},
+			}
+			request.Status.PodName = "target"
+			request.Status.AccessExpiresAt = &metav1.Time{Time: now.Add(30 * time.Minute)}
+			meta.SetStatusCondition(&request.Status.Conditions, metav1.Condition{
+				Type:   v1alpha1.ConditionAccessResourcesCreated.String(),
+				Status: metav1.ConditionTrue,
+				Reason: "Created",
+			})
+
+			cl = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(request).Build()
+			builder = &mockBuilder{getTemplateErr: builders.ErrTemplateDoesNotExist}
+			reconciler = &RequestReconciler{
+				Client:      cl,
+				Scheme:      scheme.Scheme,
+				APIReader:   cl,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     builder,
+				now:         func() time.Time { return now },
+			}
+		})
+
+		It("Should revoke the access by default when the template is deleted mid-grant", func() {
+			shouldEnd, result, err := reconcileTemplate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEnd).To(BeTrue())
+			Expect(result).To(Equal(reconcile.Result{}))
+
+			// VERIFY: The request (and with it, its access) is gone
+			Expect(current()).To(BeNil())
+		})
+
+		It("Should record why the access was revoked", func() {
+			reconciler.OnTemplateDeleted = RevokeOnTemplateDeleted
+			rctx := newRequestContext(ctx, reconciler.RequestType, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: request.GetName(), Namespace: request.GetNamespace()},
+			})
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+
+			shouldEnd, _, err := reconciler.handleTemplateDeleted(rctx, builders.ErrTemplateDoesNotExist)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEnd).To(BeTrue())
+
+			conditions := *rctx.obj.GetStatus().GetConditions()
+			cond := meta.FindStatusCondition(conditions, v1alpha1.ConditionTargetTemplateExists.String())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonTemplateDeleted))
+			Expect(cond.Message).To(Equal("Template readonly was deleted, the access has been revoked"))
+			cond = meta.FindStatusCondition(conditions, v1alpha1.ConditionAccessStillValid.String())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessRevoked))
+		})
+
+		It("Should keep the access until it expires with the keep policy", func() {
+			reconciler.OnTemplateDeleted = KeepOnTemplateDeleted
+
+			shouldEnd, result, err := reconcileTemplate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEnd).To(BeTrue())
+			Expect(result.RequeueAfter).To(Equal(30 * time.Minute))
+
+			// VERIFY: The request is kept, and says why
+			kept := current()
+			Expect(kept).ToNot(BeNil())
+			cond := meta.FindStatusCondition(kept.Status.Conditions, v1alpha1.ConditionTargetTemplateExists.String())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonTemplateDeleted))
+			Expect(cond.Message).To(Equal(
+				"Template readonly was deleted, the access is kept until it expires at 2023-03-14T15:30:00Z",
+			))
+			Expect(meta.FindStatusCondition(kept.Status.Conditions, v1alpha1.ConditionAccessStillValid.String())).
+				To(BeNil())
+
+			// VERIFY: Once the access has expired, the request is deleted
+			reconciler.now = func() time.Time { return now.Add(31 * time.Minute) }
+			shouldEnd, _, err = reconcileTemplate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEnd).To(BeTrue())
+			Expect(current()).To(BeNil())
+		})
+
+		It("Should still honour a revocation with the keep policy", func() {
+			reconciler.OnTemplateDeleted = KeepOnTemplateDeleted
+			request.Annotations = map[string]string{
+				v1alpha1.RevokeAnnotation:    "",
+				v1alpha1.RevokedByAnnotation: "bob",
+			}
+			Expect(cl.Update(ctx, request)).To(Succeed())
+			rctx := newRequestContext(ctx, reconciler.RequestType, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: request.GetName(), Namespace: request.GetNamespace()},
+			})
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+
+			shouldEnd, result, err := reconciler.handleTemplateDeleted(rctx, builders.ErrTemplateDoesNotExist)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEnd).To(BeTrue())
+			Expect(result).To(Equal(reconcile.Result{}))
+
+			// VERIFY: The access ended because of the revocation, and the
+			// request is gone
+			conditions := *rctx.obj.GetStatus().GetConditions()
+			cond := meta.FindStatusCondition(conditions, v1alpha1.ConditionAccessStillValid.String())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessRevoked))
+			Expect(cond.Message).To(Equal("Access revoked by bob"))
+			cond = meta.FindStatusCondition(conditions, v1alpha1.ConditionTargetTemplateExists.String())
+			Expect(cond.Message).To(Equal("Template readonly was deleted, and the access has ended"))
+			Expect(current()).To(BeNil())
+		})
+
+		It("Should still honour the deny-list and forced expirations with the keep policy", func() {
+			reconciler.OnTemplateDeleted = KeepOnTemplateDeleted
+			request.Annotations = map[string]string{v1alpha1.RequestedByAnnotation: "mallory"}
+			Expect(cl.Update(ctx, request)).To(Succeed())
+			Expect(cl.Create(ctx, &v1alpha1.OzConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: v1alpha1.OzConfigName, Namespace: "ns"},
+				Spec:       v1alpha1.OzConfigSpec{DeniedUsers: []string{"mallory"}},
+			})).To(Succeed())
+
+			_, _, err := reconcileTemplate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current()).To(BeNil())
+
+			// The same goes for a forced expiration
+			request.ResourceVersion = ""
+			request.Annotations = map[string]string{v1alpha1.ExpireAnnotation: "bob"}
+			Expect(cl.Create(ctx, request)).To(Succeed())
+			rctx := newRequestContext(ctx, reconciler.RequestType, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: request.GetName(), Namespace: request.GetNamespace()},
+			})
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+			_, _, err = reconciler.handleTemplateDeleted(rctx, builders.ErrTemplateDoesNotExist)
+			Expect(err).ToNot(HaveOccurred())
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionAccessStillValid.String(),
+			)
+			Expect(cond.Reason).To(Equal(status.ReasonAccessForceExpired))
+			Expect(current()).To(BeNil())
+		})
+
+		It("Should only keep the access until the maintenance drain with the keep policy", func() {
+			reconciler.OnTemplateDeleted = KeepOnTemplateDeleted
+			reconciler.Maintenance = &MaintenanceMode{DrainAt: now.Add(10 * time.Minute)}
+
+			shouldEnd, result, err := reconcileTemplate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEnd).To(BeTrue())
+			Expect(result.RequeueAfter).To(Equal(10 * time.Minute))
+			cond := meta.FindStatusCondition(current().Status.Conditions, v1alpha1.ConditionTargetTemplateExists.String())
+			Expect(cond.Message).To(Equal(
+				"Template readonly was deleted, the access is kept until it expires at 2023-03-14T15:10:00Z",
+			))
+
+			// VERIFY: Once drained, the request is deleted
+			reconciler.now = func() time.Time { return now.Add(11 * time.Minute) }
+			_, _, err = reconcileTemplate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current()).To(BeNil())
+		})
+
+		It("Should release requests from their template with the keep policy", func() {
+			reconciler.OnTemplateDeleted = KeepOnTemplateDeleted
+			builder.getTemplateErr = nil
+			builder.getTemplateResp = template
+
+			shouldEnd, _, err := reconcileTemplate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEnd).To(BeFalse())
+
+			// VERIFY: Deleting the template will not garbage collect the request
+			Expect(current().GetOwnerReferences()).To(BeEmpty())
+		})
+
+		It("Should leave requests that were never granted access alone", func() {
+			meta.RemoveStatusCondition(&request.Status.Conditions, v1alpha1.ConditionAccessResourcesCreated.String())
+			Expect(cl.Status().Update(ctx, request)).To(Succeed())
+
+			shouldEnd, _, err := reconcileTemplate()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEnd).To(BeFalse())
+			Expect(current()).ToNot(BeNil())
+		})
+
+		It("Should reject unknown policies", func() {
+			policy, err := ParseTemplateDeletedPolicy("keep")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(policy).To(Equal(KeepOnTemplateDeleted))
+
+			_, err = ParseTemplateDeletedPolicy("ignore")
+			Expect(err).To(MatchError(ContainSubstring(`unknown template deleted policy "ignore"`)))
+		})
+	})
+})
@@ -0,0 +1,107 @@
+package requestcontroller
+
+import (
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// decisionTrace logs the decision tree of a single reconcile of an Access
+// Request that carries the TraceAnnotation: each check it went through, with
+// its inputs, and the conditions that the check changed.
+type decisionTrace struct {
+	log logr.Logger
+
+	// check is the name of the check in progress, and before holds a copy of
+	// the conditions of the request from when it started.
+	check  string
+	before []metav1.Condition
+}
+
+// startTrace turns on the decision trace for the rest of this reconcile, if
+// the request carries the TraceAnnotation. Requests without it are left
+// alone - they are only logged at the usual verbosity.
+func (rctx *RequestContext) startTrace() {
+	if !v1alpha1.IsTraced(rctx.obj) {
+		return
+	}
+	rctx.trace = &decisionTrace{log: rctx.log.WithName("trace")}
+	rctx.trace.log.Info("TRACE - Reconcile started",
+		"generation", rctx.obj.GetGeneration(),
+		"resourceVersion", rctx.obj.GetResourceVersion(),
+		"requester", v1alpha1.GetRequester(rctx.obj),
+		"requesterGroups", v1alpha1.GetRequesterGroups(rctx.obj),
+		"template", rctx.obj.GetTemplateName(),
+		"ready", rctx.obj.IsReady(),
+	)
+}
+
+// traceCheck records the outcome of the previous check (if any), and the
+// start of the named check along with the supplied inputs (alternating
+// key/value pairs). It is a no-op unless the trace was started.
+//
+// Each check of the reconcile starts here, so this is also where the onCheck
+// hook of the RequestContext (if any) is called.
+func (rctx *RequestContext) traceCheck(check string, inputs ...any) {
+	if rctx.onCheck != nil {
+		rctx.onCheck(check)
+	}
+	if rctx.trace == nil {
+		return
+	}
+	rctx.traceOutcome()
+	rctx.trace.check = check
+	rctx.trace.before = append([]metav1.Condition{}, *rctx.obj.GetStatus().GetConditions()...)
+	rctx.trace.log.Info("TRACE - Check "+check, inputs...)
+}
+
+// traceOutcome records the conditions that the check in progress changed.
+func (rctx *RequestContext) traceOutcome() {
+	if rctx.trace.check == "" {
+		return
+	}
+	changed := []string{}
+	for _, cond := range *rctx.obj.GetStatus().GetConditions() {
+		prev := meta.FindStatusCondition(rctx.trace.before, cond.Type)
+		if prev != nil && prev.Status == cond.Status && prev.Reason == cond.Reason &&
+			prev.Message == cond.Message {
+			continue
+		}
+		changed = append(changed, cond.Type+"="+string(cond.Status)+" ("+cond.Reason+"): "+cond.Message)
+	}
+	rctx.trace.log.Info("TRACE - Outcome of "+rctx.trace.check, "changed", changed)
+	rctx.trace.check = ""
+}
+
+// traceEnd records the outcome of the last check, and the result of the
+// reconcile. It is a no-op unless the trace was started.
+func (rctx *RequestContext) traceEnd(result ctrl.Result, err error) {
+	if rctx.trace == nil {
+		return
+	}
+	rctx.traceOutcome()
+	keysAndValues := []any{
+		"ready", rctx.obj.IsReady(),
+		"requeueAfter", result.RequeueAfter.String(),
+	}
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err.Error())
+	}
+	rctx.trace.log.Info("TRACE - Reconcile finished", keysAndValues...)
+}
+
+// requestedDuration describes the duration that the supplied request asked
+// for, for the trace.
+func requestedDuration(req v1alpha1.IRequestResource) string {
+	duration, err := req.GetDuration()
+	if err != nil {
+		return err.Error()
+	}
+	if duration == 0 {
+		return "(default)"
+	}
+	return duration.String()
+}
@@ -0,0 +1,101 @@
+package requestcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("RequestReconciler", func() {
+	Context("decision trace", func() {
+		var (
+			records []map[string]interface{}
+			request *v1alpha1.ExecAccessRequest
+			rctx    *RequestContext
+		)
+
+		BeforeEach(func() {
+			records = nil
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "trace-test",
+					Namespace:   "test",
+					Annotations: map[string]string{v1alpha1.RequestedByAnnotation: "alice"},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{TemplateName: "readonly", Duration: "30m"},
+			}
+			rctx = &RequestContext{
+				Context: context.Background(),
+				obj:     request,
+				log: funcr.NewJSON(func(obj string) {
+					record := map[string]interface{}{}
+					Expect(json.Unmarshal([]byte(obj), &record)).To(Succeed())
+					records = append(records, record)
+				}, funcr.Options{}),
+			}
+		})
+
+		// reconcileSteps walks rctx through two checks, the second of which
+		// denies the request.
+		reconcileSteps := func() {
+			rctx.startTrace()
+			rctx.traceCheck("verifyTemplate", "templateName", "readonly")
+			rctx.traceCheck("verifyDuration", "requestedDuration", requestedDuration(request))
+			request.Status.Conditions = append(request.Status.Conditions, metav1.Condition{
+				Type:    v1alpha1.ConditionRequestDurationsValid.String(),
+				Status:  metav1.ConditionFalse,
+				Reason:  "Invalid",
+				Message: "too long",
+			})
+			rctx.traceEnd(ctrl.Result{RequeueAfter: time.Minute}, errors.New("boom"))
+		}
+
+		It("Should log the decision tree of a request with the TraceAnnotation", func() {
+			request.Annotations[v1alpha1.TraceAnnotation] = "true"
+
+			reconcileSteps()
+
+			msgs := []string{}
+			for _, record := range records {
+				msgs = append(msgs, record["msg"].(string))
+			}
+			Expect(msgs).To(Equal([]string{
+				"TRACE - Reconcile started",
+				"TRACE - Check verifyTemplate",
+				"TRACE - Outcome of verifyTemplate",
+				"TRACE - Check verifyDuration",
+				"TRACE - Outcome of verifyDuration",
+				"TRACE - Reconcile finished",
+			}))
+
+			// VERIFY: The inputs and outcome of each check are logged
+			Expect(records[0]).To(HaveKeyWithValue("requester", "alice"))
+			Expect(records[1]).To(HaveKeyWithValue("templateName", "readonly"))
+			Expect(records[2]).To(HaveKeyWithValue("changed", BeEmpty()))
+			Expect(records[3]).To(HaveKeyWithValue("requestedDuration", "30m0s"))
+			Expect(records[4]).To(HaveKeyWithValue("changed", ConsistOf(
+				"AccessDurationsValid=False (Invalid): too long",
+			)))
+			Expect(records[5]).To(HaveKeyWithValue("requeueAfter", "1m0s"))
+			Expect(records[5]).To(HaveKeyWithValue("error", "boom"))
+		})
+
+		It("Should not log anything for requests without the TraceAnnotation", func() {
+			reconcileSteps()
+			Expect(records).To(BeEmpty())
+
+			request.Annotations[v1alpha1.TraceAnnotation] = "false"
+			reconcileSteps()
+			Expect(records).To(BeEmpty())
+		})
+	})
+})
@@ -5,12 +5,19 @@ package requestcontroller
 import (
 	"context"
 	"reflect"
+	"sync/atomic"
 	"time"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
 	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/externalapproval"
+	"github.com/diranged/oz/internal/granttoken"
+	"github.com/diranged/oz/internal/notify"
+	"github.com/diranged/oz/internal/opstate"
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -22,6 +29,17 @@ import (
 // readiness checks.
 var DefaultVerifyResourcesRequeueInterval = (5 * time.Second)
 
+// DefaultForbiddenRequeueInterval is the time inbetween reconcile attempts
+// used when the controller has been forbidden from creating the access
+// resources. Fixing this requires an operator to change the RBAC permissions
+// of the controller, so there is no point in retrying aggressively.
+var DefaultForbiddenRequeueInterval = (5 * time.Minute)
+
+// DefaultExternalApprovalPollInterval is the time inbetween polls of the
+// approval-status endpoint of a template with an externalApproval, while the
+// external system has not approved (or rejected) a request yet.
+var DefaultExternalApprovalPollInterval = (1 * time.Minute)
+
 // RequestReconciler is configured watch for a particular type (RequestType) of
 // Access Requests, and execute the reconciler logic against them with a
 // particular Builder (Builder). The business logic of what happens in any type
@@ -52,6 +70,121 @@ type RequestReconciler struct {
 	// Frequency to re-reconcile when the access resources have not become
 	// available yet for an Access Request.
 	VerifyResourcesRequeueInterval *time.Duration
+
+	// AbsoluteMaxDuration is an optional ceiling on the duration of every
+	// Access Request, regardless of what the template allows. Zero means
+	// there is no ceiling. The OzConfig of a namespace may replace it.
+	AbsoluteMaxDuration time.Duration
+
+	// DailyGrantBudget is an optional cap on the access granted to each
+	// user per day, summed across all of their Access Requests (including
+	// the ones that are still waiting to be granted). Requests are clamped
+	// to what is left of the budget, and denied once it is used up. Granted
+	// access is never cut short, but is only extended while there is budget
+	// left. Zero means there is no budget.
+	DailyGrantBudget time.Duration
+
+	// StateStore is optional. If set, the access granted to each user is
+	// recorded in it per day, so that the DailyGrantBudget keeps counting the
+	// access of the Access Requests that have been deleted since.
+	StateStore *opstate.Store
+
+	// Notifier is optional. If set, the requester of each Access Request is
+	// notified when their access is granted, denied, about to expire or
+	// revoked.
+	Notifier *notify.RequesterNotifier
+
+	// Recorder is optional. If set, Kubernetes Events are emitted on each
+	// Access Request at the milestones of its grant (granted, half-life,
+	// near expiry and expired).
+	Recorder record.EventRecorder
+
+	// GrantTokenSigner is optional. If set, a signed grant token describing
+	// the access is issued into the status of each granted Access Request.
+	GrantTokenSigner *granttoken.Signer
+
+	// LabelSelector is optional. If set, only the Access Requests matching
+	// it are reconciled - allowing the requests to be sharded across several
+	// controller instances.
+	LabelSelector labels.Selector
+
+	// ExternalApprovalChecker is used to poll the approval-status endpoints
+	// of templates with an externalApproval. Defaults to an
+	// externalapproval.Checker with the externalapproval.DefaultTimeout.
+	ExternalApprovalChecker *externalapproval.Checker
+
+	// Frequency to poll the approval-status endpoint of a template with an
+	// externalApproval. Defaults to DefaultExternalApprovalPollInterval.
+	ExternalApprovalPollInterval time.Duration
+
+	// Maintenance is optional. If set, the controller is in maintenance
+	// mode: no new access is granted, and the access that was already
+	// granted may be drained.
+	Maintenance *MaintenanceMode
+
+	// AccessReviewer is optional. If set, the access granted by each Access
+	// Request is verified with a SubjectAccessReview once it is ready, and
+	// the answer is recorded in the ConditionAccessEffective condition.
+	AccessReviewer AccessReviewer
+
+	// StampAccessExpiry is optional. If set, the Roles and RoleBindings
+	// created for each Access Request carry the ExpiresAtAnnotation, so that
+	// a fallback like `ozctl prune-expired-rbac` can remove the access once
+	// it has expired, even while the controller is down.
+	StampAccessExpiry bool
+
+	// DetectSharedPods is optional. If set, each Access Request that was
+	// assigned a Pod reports whether any other active request was assigned
+	// the same one, in the ConditionTargetPodExclusive condition. It relies
+	// on the FieldSelectorStatusPodName index of the cached Client.
+	DetectSharedPods bool
+
+	// OnTemplateDeleted decides what happens to the access granted by a
+	// request when its template is deleted before the access expires (see
+	// handleTemplateDeleted). Defaults to RevokeOnTemplateDeleted.
+	OnTemplateDeleted TemplateDeletedPolicy
+
+	// ApprovalSLO is optional. If set, requests that wait on approval for
+	// longer than this are flagged in the ConditionApprovalWithinSLO
+	// condition, counted in the oz_access_approval_slo_breaches_total metric
+	// and (with a Recorder) get an ApprovalSLOBreached Event. Zero disables
+	// the check.
+	ApprovalSLO time.Duration
+
+	// Finalizer is the name of the finalizer placed on each Access Request
+	// (see handleFinalizer). Defaults to v1alpha1.RequestFinalizer.
+	Finalizer string
+
+	// PreviousFinalizers is optional. It lists the names that Finalizer was
+	// set to before, so that the requests still carrying them are cleaned up
+	// and released on deletion. v1alpha1.RequestFinalizer is always released.
+	PreviousFinalizers []string
+
+	// DisableFinalizer is optional. If set, no finalizer is placed on the
+	// Access Requests. Expired requests still have their access resources
+	// torn down before they are deleted, but the resources of requests that
+	// are deleted by hand are left to the (unordered) OwnerReference garbage
+	// collection. Requests that already carry the finalizer keep it until
+	// they are deleted.
+	DisableFinalizer bool
+
+	// ClockSkewTolerance is optional. If set, the skew of the local clock
+	// against the API server is measured on every reconcile, from the
+	// timestamps that the API server stamps onto the Access Requests. Skew
+	// beyond the tolerance is logged, and corrected for in the expiration
+	// decisions (see detectClockSkew). Zero disables the detection.
+	ClockSkewTolerance time.Duration
+
+	// clockSkew is the skew (in nanoseconds) last measured by
+	// detectClockSkew(), positive when the local clock is ahead.
+	clockSkew atomic.Int64
+
+	// now is swapped out in tests
+	now func() time.Time
+
+	// onCheck is swapped out in tests, to interrupt the reconcile at the
+	// start of each of its checks (see traceCheck())
+	onCheck func(check string)
 }
 
 // GetAPIReader conforms to the internal.status.hasStatusReconciler interface.
@@ -67,6 +200,30 @@ type RequestContext struct {
 	obj          v1alpha1.IRequestResource
 	req          ctrl.Request
 	log          logr.Logger
+
+	// namespaceConfig is the OzConfig of the namespace of the request (nil
+	// if it has none), once it has been resolved by applyNamespaceConfig().
+	namespaceConfig *v1alpha1.OzConfig
+
+	// expiresAt is when the access granted by the request ends, once it has
+	// been computed by verifyDuration().
+	expiresAt time.Time
+
+	// renewalPending describes the extension of the request that is waiting
+	// on a fresh approval, if verifyDuration() held the access back for one.
+	renewalPending string
+
+	// trace logs the decision tree of this reconcile, if the request carries
+	// the TraceAnnotation (see startTrace()). It is nil otherwise.
+	trace *decisionTrace
+
+	// onCheck is called at the start of each check, if set (see
+	// RequestReconciler.onCheck).
+	onCheck func(check string)
+
+	// fetchedServerStamp is the lastServerStamp() of the request as it was
+	// fetched at the start of the reconcile (see detectClockSkew()).
+	fetchedServerStamp time.Time
 }
 
 func newRequestContext(
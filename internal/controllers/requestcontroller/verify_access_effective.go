@@ -0,0 +1,138 @@
+package requestcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// AccessReviewer answers whether the subject of a SubjectAccessReview is
+// authorized to perform the action described by it.
+type AccessReviewer interface {
+	Review(
+		ctx context.Context,
+		review *authorizationv1.SubjectAccessReview,
+	) (*authorizationv1.SubjectAccessReviewStatus, error)
+}
+
+// SubjectAccessReviewer is the AccessReviewer that asks the Kubernetes API,
+// by creating the SubjectAccessReview through its Client.
+type SubjectAccessReviewer struct {
+	Client client.Client
+}
+
+// Review conforms to the AccessReviewer interface.
+func (s *SubjectAccessReviewer) Review(
+	ctx context.Context,
+	review *authorizationv1.SubjectAccessReview,
+) (*authorizationv1.SubjectAccessReviewStatus, error) {
+	if err := s.Client.Create(ctx, review); err != nil {
+		return nil, err
+	}
+	return &review.Status, nil
+}
+
+// verifyAccessEffective asks the AccessReviewer whether the subject of the
+// access may actually exec into the Pod the access was granted to. Even with
+// the RBAC resources in place, the cluster may have other authorizers that
+// deny it. The answer is recorded in the advisory ConditionAccessEffective
+// condition - the access is not torn down, as the conflicting policy may be
+// deliberate.
+//
+// Requests adopting an existing RoleBinding, and requests that have not been
+// assigned a Pod yet, are skipped. A failed review is recorded, rather than
+// failing the reconcile.
+func (r *RequestReconciler) verifyAccessEffective(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+) error {
+	if r.AccessReviewer == nil || v1alpha1.GetAdoptedRoleBinding(rctx.obj) != "" {
+		return nil
+	}
+	podReq, ok := rctx.obj.(v1alpha1.IPodRequestResource)
+	if !ok || podReq.GetPodName() == "" {
+		return nil
+	}
+	user, groups := accessSubject(rctx.obj)
+	if user == "" {
+		return nil
+	}
+
+	rctx.log.V(1).Info("Verifying that the access is effective...")
+	var denied []string
+	for _, attrs := range accessReviewAttributes(rctx.obj, tmpl, podReq.GetPodName()) {
+		attrs := attrs
+		reviewStatus, err := r.AccessReviewer.Review(rctx.Context, &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:               user,
+				Groups:             groups,
+				ResourceAttributes: &attrs,
+			},
+		})
+		if err != nil {
+			rctx.log.Error(err, "Unable to review the access")
+			return status.SetAccessEffectiveUnknown(rctx.Context, r, rctx.obj, err)
+		}
+		if !reviewStatus.Allowed {
+			action := fmt.Sprintf("%s %s/%s", attrs.Verb, attrs.Resource, attrs.Subresource)
+			if reviewStatus.Reason != "" {
+				action = fmt.Sprintf("%s (%s)", action, reviewStatus.Reason)
+			}
+			denied = append(denied, action)
+		}
+	}
+
+	if len(denied) > 0 {
+		message := fmt.Sprintf("%s may not %s on Pod %s despite the access granted",
+			user, strings.Join(denied, ", "), podReq.GetPodName())
+		rctx.log.Info(message)
+		return status.SetAccessNotEffective(rctx.Context, r, rctx.obj, message)
+	}
+	return status.SetAccessEffective(rctx.Context, r, rctx.obj,
+		fmt.Sprintf("%s may exec into Pod %s", user, podReq.GetPodName()))
+}
+
+// accessSubject returns the user (and their groups) that the access of the
+// supplied request is bound to. This mirrors the subjects of the RoleBinding:
+// a transferred or delegated request is bound to that user alone, otherwise
+// the access is used by the requester through their groups.
+func accessSubject(req v1alpha1.IRequestResource) (string, []string) {
+	if user := req.GetTransferTo(); user != "" {
+		return user, nil
+	}
+	if user := req.GetRequestFor(); user != "" {
+		return user, nil
+	}
+	return v1alpha1.GetRequester(req), v1alpha1.GetRequesterGroups(req)
+}
+
+// accessReviewAttributes returns the ResourceAttributes of each exec verb
+// granted to the supplied request on the supplied Pod.
+func accessReviewAttributes(
+	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
+	podName string,
+) []authorizationv1.ResourceAttributes {
+	verbs := v1alpha1.NarrowExecVerbs(utils.ExecVerbs(req, tmpl), req.GetRequestedVerbs())
+
+	attrs := make([]authorizationv1.ResourceAttributes, 0, len(verbs))
+	for _, verb := range verbs {
+		attrs = append(attrs, authorizationv1.ResourceAttributes{
+			Namespace:   req.GetNamespace(),
+			Verb:        verb,
+			Resource:    "pods",
+			Subresource: "exec",
+			Name:        podName,
+		})
+	}
+	return attrs
+}
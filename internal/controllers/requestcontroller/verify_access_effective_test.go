@@ -0,0 +1,211 @@
+package requestcontroller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+// stubAccessReviewer answers every SubjectAccessReview with the verbs in
+// allowed, and records the reviews it was asked.
+type stubAccessReviewer struct {
+	allowed map[string]bool
+	err     error
+	reviews []authorizationv1.SubjectAccessReviewSpec
+}
+
+func (s *stubAccessReviewer) Review(
+	_ context.Context,
+	review *authorizationv1.SubjectAccessReview,
+) (*authorizationv1.SubjectAccessReviewStatus, error) {
+	s.reviews = append(s.reviews, review.Spec)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.allowed[review.Spec.ResourceAttributes.Verb] {
+		return &authorizationv1.SubjectAccessReviewStatus{Allowed: true}, nil
+	}
+	return &authorizationv1.SubjectAccessReviewStatus{
+		Denied: true,
+		Reason: "blocked by policy",
+	}, nil
+}
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		verifyAccessEffective() Tests
+	*/
+	Context("verifyAccessEffective()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			template   *v1alpha1.ExecAccessTemplate
+			reviewer   *stubAccessReviewer
+			reconciler *RequestReconciler
+		)
+
+		// newRctx creates an ExecAccessRequest from alice that was granted
+		// access to the supplied Pod, and returns a populated RequestContext
+		// for it.
+		newRctx := func(podName string) *RequestContext {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+					Annotations: map[string]string{
+						v1alpha1.RequestedByAnnotation:       "alice",
+						v1alpha1.RequestedByGroupsAnnotation: "devs,oncall",
+					},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err := k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			request.Status.PodName = podName
+			err = k8sClient.Status().Update(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			rctx := newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		effectiveCondition := func(rctx *RequestContext) *metav1.Condition {
+			return meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessEffective.String(),
+			)
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{Name: "fake", Namespace: ns.GetName()},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{AllowedGroups: []string{"devs"}},
+				},
+			}
+
+			By("Creating the RequestReconciler")
+			reconciler = &RequestReconciler{
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				APIReader:   k8sClient,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &mockBuilder{},
+			}
+		})
+
+		BeforeEach(func() {
+			reviewer = &stubAccessReviewer{}
+			reconciler.AccessReviewer = reviewer
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("Should mark the access effective when the review allows it", func() {
+			reviewer.allowed = map[string]bool{}
+			for _, verb := range v1alpha1.DefaultExecVerbs {
+				reviewer.allowed[verb] = true
+			}
+			rctx := newRctx("target")
+
+			err := reconciler.verifyAccessEffective(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			cond := effectiveCondition(rctx)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Message).To(Equal("alice may exec into Pod target"))
+
+			// VERIFY: Each exec verb was reviewed for the requester
+			Expect(reviewer.reviews).To(HaveLen(len(v1alpha1.DefaultExecVerbs)))
+			Expect(reviewer.reviews[0].User).To(Equal("alice"))
+			Expect(reviewer.reviews[0].Groups).To(Equal([]string{"devs", "oncall"}))
+			Expect(*reviewer.reviews[0].ResourceAttributes).To(Equal(authorizationv1.ResourceAttributes{
+				Namespace:   ns.GetName(),
+				Verb:        v1alpha1.DefaultExecVerbs[0],
+				Resource:    "pods",
+				Subresource: "exec",
+				Name:        "target",
+			}))
+		})
+
+		It("Should mark the access not effective when the review denies it", func() {
+			reviewer.allowed = map[string]bool{"update": true, "delete": true, "get": true, "list": true}
+			rctx := newRctx("target")
+
+			err := reconciler.verifyAccessEffective(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			cond := effectiveCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessNotAuthorized))
+			Expect(cond.Message).To(Equal(
+				"alice may not create pods/exec (blocked by policy) on Pod target despite the access granted",
+			))
+
+			// VERIFY: The condition is advisory, and does not hold the request back
+			Expect(v1alpha1.IsAdvisoryCondition(cond.Type)).To(BeTrue())
+		})
+
+		It("Should record a failed review, without failing the reconcile", func() {
+			reviewer.err = errors.New("forbidden")
+			rctx := newRctx("target")
+
+			err := reconciler.verifyAccessEffective(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			cond := effectiveCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionUnknown))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessReviewFailed))
+		})
+
+		It("Should skip requests that have not been assigned a Pod", func() {
+			rctx := newRctx("")
+
+			err := reconciler.verifyAccessEffective(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(effectiveCondition(rctx)).To(BeNil())
+			Expect(reviewer.reviews).To(BeEmpty())
+		})
+
+		It("Should skip the review without an AccessReviewer", func() {
+			reconciler.AccessReviewer = nil
+			rctx := newRctx("target")
+
+			err := reconciler.verifyAccessEffective(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(effectiveCondition(rctx)).To(BeNil())
+		})
+	})
+})
@@ -1,11 +1,16 @@
 package requestcontroller
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
 	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -20,12 +25,86 @@ func (r *RequestReconciler) verifyAccessResources(
 	rctx *RequestContext,
 	tmpl v1alpha1.ITemplateResource,
 ) (shouldReturn bool, result ctrl.Result, resultErr error) {
+	// Requests adopting an existing RoleBinding create nothing of their own.
+	if v1alpha1.GetAdoptedRoleBinding(rctx.obj) != "" {
+		return r.verifyAdoptedRoleBinding(rctx)
+	}
+
 	{ // Create the resources
 		var statusStr string
 		var err error
 
 		rctx.log.V(1).Info("Making sure Access Resources have been created")
 		if statusStr, err = r.Builder.CreateAccessResources(rctx.Context, r.Client, rctx.obj, tmpl); err != nil {
+			// If the template (or the target Pod) is at capacity, the request
+			// is queued. This is not a failure of the reconcile, so we simply
+			// check back later.
+			if errors.Is(err, builders.ErrMaxPodsReached) ||
+				errors.Is(err, builders.ErrMaxGrantsPerPodReached) {
+				interval := r.getVerifyResourcesRequeueInterval()
+				if err := status.SetAccessResourcesQueued(rctx.Context, r, rctx.obj,
+					fmt.Errorf("%w... will check again in %s", err, interval)); err != nil {
+					return true, result, err
+				}
+				return true, ctrl.Result{RequeueAfter: interval}, nil
+			}
+
+			// If no Pod is available yet, but one may still show up within
+			// the pod selection timeout, check back shortly.
+			if errors.Is(err, builders.ErrPodSelectionPending) {
+				interval := r.getVerifyResourcesRequeueInterval()
+				if err := status.SetAccessResourcesWaitingForPod(rctx.Context, r, rctx.obj,
+					fmt.Errorf("%w... will check again in %s", err, interval)); err != nil {
+					return true, result, err
+				}
+				return true, ctrl.Result{RequeueAfter: interval}, nil
+			}
+
+			// If several Pods match, and the template leaves the choice to the
+			// requester, nothing can be granted until they create a request
+			// naming one of them. Retrying will not change that.
+			if errors.Is(err, builders.ErrMultipleCandidatePods) {
+				rctx.log.Info("Several candidate Pods, a target Pod has to be selected", "error", err.Error())
+				if err := status.SetAccessResourcesTargetPodSelectionRequired(rctx.Context, r, rctx.obj, err); err != nil {
+					return true, result, err
+				}
+				return true, result, nil
+			}
+
+			// If the request was modified while the builder was working on
+			// it, its status could not be saved. Start over from the latest
+			// version of the request right away - this is not a failure.
+			if errors.Is(err, builders.ErrStatusConflict) {
+				rctx.log.V(1).Info("Access Request was modified, requeueing", "error", err.Error())
+				return true, ctrl.Result{Requeue: true}, nil
+			}
+
+			// If the target Pod does not exist, retrying will never create
+			// the access resources. Mark the request as failed, and end its
+			// access so that isAccessExpired() tears it down on the next
+			// reconcile, rather than leaving it around (or retrying it)
+			// forever.
+			if errors.Is(err, builders.ErrTargetPodNotFound) {
+				rctx.log.Info("Target Pod not found, access cannot be granted", "error", err.Error())
+				if err := status.SetAccessResourcesTargetPodNotFound(rctx.Context, r, rctx.obj, err); err != nil {
+					return true, result, err
+				}
+				return true, ctrl.Result{Requeue: true}, r.endAccessTargetPodNotFound(rctx, err)
+			}
+
+			// If the controller itself is not allowed to create the resources,
+			// retrying will not help until an operator grants it the RBAC
+			// permissions. Surface that clearly, and check back infrequently
+			// rather than failing the reconcile and retrying in a tight loop.
+			if apierrors.IsForbidden(err) {
+				rctx.log.Error(err, "Controller is forbidden from creating Access Resources")
+				_ = status.SetAccessResourcesNotCreated(rctx.Context, r, rctx.obj, err)
+				if err := status.SetControllerPermissionsDenied(rctx.Context, r, rctx.obj, err); err != nil {
+					return true, result, err
+				}
+				return true, ctrl.Result{RequeueAfter: DefaultForbiddenRequeueInterval}, nil
+			}
+
 			// NOTE: Blindly ignoring the error return here because we are already
 			// returning an error which will fail the reconciliation.
 			_ = status.SetAccessResourcesNotCreated(rctx.Context, r, rctx.obj, err)
@@ -34,6 +113,16 @@ func (r *RequestReconciler) verifyAccessResources(
 		if err := status.SetAccessResourcesCreated(rctx.Context, r, rctx.obj, statusStr); err != nil {
 			return true, result, err
 		}
+
+		// Clear a previous permissions failure, now that it has been fixed.
+		if meta.FindStatusCondition(
+			*rctx.obj.GetStatus().GetConditions(),
+			v1alpha1.ConditionControllerPermissions.String(),
+		) != nil {
+			if err := status.SetControllerPermissionsGranted(rctx.Context, r, rctx.obj); err != nil {
+				return true, result, err
+			}
+		}
 	}
 
 	{ // Check if the resources are ready
@@ -64,3 +153,19 @@ func (r *RequestReconciler) verifyAccessResources(
 	// Finally, do not requeue, do not end reconciliation. Move forward.
 	return false, result, nil
 }
+
+// endAccessTargetPodNotFound flips the ConditionAccessStillValid condition to
+// False because the target Pod of the request does not exist, and lets the
+// requester know. Requests that were already granted access are told that it
+// was revoked, the others that it was denied.
+func (r *RequestReconciler) endAccessTargetPodNotFound(rctx *RequestContext, podErr error) error {
+	event := notify.EventDenied
+	if rctx.obj.GetStatus().(v1alpha1.IRequestStatus).GetAccessExpiresAt() != nil {
+		event = notify.EventRevoked
+	}
+	message := fmt.Sprintf("Access ended, the target Pod does not exist: %s", podErr)
+	if err := status.SetAccessTargetPodNotFound(rctx.Context, r, rctx.obj, message); err != nil {
+		return err
+	}
+	return r.notifyRequester(rctx, event, message)
+}
@@ -3,18 +3,25 @@ package requestcontroller
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder"
+	"github.com/diranged/oz/internal/controllers/internal/status"
 	"github.com/diranged/oz/internal/testing/utils"
 )
 
@@ -143,6 +150,232 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 			Expect(cond.Reason).To(Equal(string(metav1.StatusFailure)))
 		})
 
+		It("verifyAccessResources() should requeue without error if the pod cap is reached", func() {
+			// Make the Mock return the pod cap error on CreateAccessResources()
+			builder.createResourcesErr = fmt.Errorf("%w: 1 of 1 pods in use", builders.ErrMaxPodsReached)
+			builder.createResourcesResp = ""
+
+			shouldEndReconcile, result, err := reconciler.verifyAccessResources(rctx, template)
+
+			// VERIFY: Yes, end the reconcile
+			Expect(shouldEndReconcile).To(BeTrue())
+
+			// VERIFY: Yes, result{} contains a delay
+			Expect(result.RequeueAfter).To(Equal(DefaultVerifyResourcesRequeueInterval))
+
+			// VERIFY: No, the request is queued rather than failed
+			Expect(err).ToNot(HaveOccurred())
+
+			// Refetch our Request object... reconiliation has mutated its
+			// .Status fields.
+			By("Refetching our Request...")
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			}, request)
+			Expect(err).To(Not(HaveOccurred()))
+
+			// VERIFY: ConditionAccessResourcesCreated = False, Queued
+			cond := meta.FindStatusCondition(
+				*request.GetStatus().GetConditions(),
+				string(v1alpha1.ConditionAccessResourcesCreated.String()),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal("Queued"))
+		})
+
+		It("verifyAccessResources() should requeue without error if the target pod is at its cap", func() {
+			// Make the Mock return the per-pod grant cap error on CreateAccessResources()
+			builder.createResourcesErr = fmt.Errorf("%w: 2 of 2 grants on pod foo in use",
+				builders.ErrMaxGrantsPerPodReached)
+			builder.createResourcesResp = ""
+
+			shouldEndReconcile, result, err := reconciler.verifyAccessResources(rctx, template)
+
+			// VERIFY: Yes, end the reconcile, and check back later without an error
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(result.RequeueAfter).To(Equal(DefaultVerifyResourcesRequeueInterval))
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: ConditionAccessResourcesCreated = False, Queued
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				string(v1alpha1.ConditionAccessResourcesCreated.String()),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(builders.ReasonAccessResourcesQueued))
+		})
+
+		It("verifyAccessResources() should requeue without error while waiting for a pod", func() {
+			// Make the Mock return no candidate Pods, within the pod selection timeout
+			builder.createResourcesErr = builders.WithKind(builders.ErrPodSelectionPending,
+				builders.WithKind(builders.ErrNoCandidatePods, errors.New("no pods found maching selector")))
+			builder.createResourcesResp = ""
+
+			shouldEndReconcile, result, err := reconciler.verifyAccessResources(rctx, template)
+
+			// VERIFY: Yes, end the reconcile and check back shortly
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(result.RequeueAfter).To(Equal(DefaultVerifyResourcesRequeueInterval))
+			Expect(err).ToNot(HaveOccurred())
+
+			// Refetch our Request object... reconiliation has mutated its
+			// .Status fields.
+			By("Refetching our Request...")
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			}, request)
+			Expect(err).To(Not(HaveOccurred()))
+
+			// VERIFY: ConditionAccessResourcesCreated = False, WaitingForPod
+			cond := meta.FindStatusCondition(
+				*request.GetStatus().GetConditions(),
+				string(v1alpha1.ConditionAccessResourcesCreated.String()),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonWaitingForPod))
+			Expect(cond.Message).To(HavePrefix("no pods found maching selector... will check again in"))
+		})
+
+		It("verifyAccessResources() should requeue right away without error on a status conflict", func() {
+			// Make the Mock return a conflict writing back the request status
+			builder.createResourcesErr = builders.WithKind(builders.ErrStatusConflict,
+				apierrors.NewConflict(
+					schema.GroupResource{Group: "crds.wizardofoz.co", Resource: "execaccessrequests"},
+					request.GetName(), errors.New("the object has been modified"),
+				))
+			builder.createResourcesResp = ""
+
+			shouldEndReconcile, result, err := reconciler.verifyAccessResources(rctx, template)
+
+			// VERIFY: Yes, end the reconcile and start over right away
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(result.Requeue).To(BeTrue())
+			Expect(result.RequeueAfter).To(BeZero())
+
+			// VERIFY: No error, the conflict is not a failure
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("verifyAccessResources() should end the access without error if the target pod does not exist", func() {
+			// Make the Mock return a missing target Pod
+			builder.createResourcesErr = builders.WithKind(builders.ErrTargetPodNotFound,
+				errors.New("pod named foo not found"))
+			builder.createResourcesResp = ""
+
+			shouldEndReconcile, result, err := reconciler.verifyAccessResources(rctx, template)
+
+			// VERIFY: Yes, end the reconcile, and requeue right away to tear
+			// the request down, without an error
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(result.Requeue).To(BeTrue())
+			Expect(result.RequeueAfter).To(BeZero())
+			Expect(err).ToNot(HaveOccurred())
+
+			// Refetch our Request object... reconiliation has mutated its
+			// .Status fields.
+			By("Refetching our Request...")
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			}, request)
+			Expect(err).To(Not(HaveOccurred()))
+
+			// VERIFY: ConditionAccessResourcesCreated = False, TargetPodNotFound
+			cond := meta.FindStatusCondition(
+				*request.GetStatus().GetConditions(),
+				string(v1alpha1.ConditionAccessResourcesCreated.String()),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonTargetPodNotFound))
+			Expect(cond.Message).To(Equal("ERROR: pod named foo not found"))
+
+			// VERIFY: ConditionAccessStillValid = False, TargetPodNotFound
+			cond = meta.FindStatusCondition(
+				*request.GetStatus().GetConditions(),
+				string(v1alpha1.ConditionAccessStillValid.String()),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonTargetPodNotFound))
+			Expect(cond.Message).To(Equal(
+				"Access ended, the target Pod does not exist: pod named foo not found",
+			))
+		})
+
+		It("verifyAccessResources() should requeue slowly without error if the controller is forbidden", func() {
+			// Make the Mock return a Forbidden error from the API, as if the
+			// controller could not create the Role
+			builder.createResourcesErr = fmt.Errorf("failed to create role: %w",
+				apierrors.NewForbidden(
+					schema.GroupResource{Group: "rbac.authorization.k8s.io", Resource: "roles"},
+					"foo", errors.New("RBAC: access denied"),
+				))
+			builder.createResourcesResp = ""
+
+			shouldEndReconcile, result, err := reconciler.verifyAccessResources(rctx, template)
+
+			// VERIFY: Yes, end the reconcile
+			Expect(shouldEndReconcile).To(BeTrue())
+
+			// VERIFY: Yes, result{} contains the long delay
+			Expect(result.RequeueAfter).To(Equal(DefaultForbiddenRequeueInterval))
+
+			// VERIFY: No error, which would trigger a tight retry loop
+			Expect(err).ToNot(HaveOccurred())
+
+			// Refetch our Request object... reconiliation has mutated its
+			// .Status fields.
+			By("Refetching our Request...")
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			}, request)
+			Expect(err).To(Not(HaveOccurred()))
+
+			// VERIFY: ConditionControllerPermissions = False, Forbidden
+			cond := meta.FindStatusCondition(
+				*request.GetStatus().GetConditions(),
+				string(v1alpha1.ConditionControllerPermissions.String()),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(string(metav1.StatusReasonForbidden)))
+			Expect(cond.Message).To(ContainSubstring(
+				fmt.Sprintf("not permitted to create access resources in namespace %s", ns.GetName()),
+			))
+		})
+
+		It("verifyAccessResources() should clear the ControllerPermissions condition once fixed", func() {
+			builder.createResourcesErr = nil
+			builder.createResourcesResp = "Role-XXX created"
+			builder.accessResourcesAreReadyErr = nil
+			builder.accessResourcesAreReadyResp = false
+
+			_, _, err := reconciler.verifyAccessResources(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Refetching our Request...")
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			}, request)
+			Expect(err).To(Not(HaveOccurred()))
+
+			// VERIFY: ConditionControllerPermissions = True
+			cond := meta.FindStatusCondition(
+				*request.GetStatus().GetConditions(),
+				string(v1alpha1.ConditionControllerPermissions.String()),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		})
+
 		It("verifyAccessResources() should return if access resources are not ready", func() {
 			// Make the Mock return an unexpected error on getAccesssDuration()
 			builder.createResourcesErr = nil
@@ -288,3 +521,123 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 		})
 	})
 })
+
+var _ = Describe("RequestReconciler", func() {
+	/*
+		Reconcile() Tests, for a request whose target Pod does not exist
+	*/
+	Context("Reconcile() without the target pod", func() {
+		var (
+			ctx = context.Background()
+			now = time.Now().UTC().Truncate(time.Second)
+			key = types.NamespacedName{Name: "missing", Namespace: "verifyaccessresources"}
+		)
+
+		It("Should end the access, and then delete the request", func() {
+			cl := newExecAccessClient(key, now)
+			tmpl := &v1alpha1.ExecAccessTemplate{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "web", Namespace: key.Namespace}, tmpl)).To(Succeed())
+
+			By("Targeting a Pod that does not exist")
+			r := &RequestReconciler{
+				Client:      cl,
+				Scheme:      scheme.Scheme,
+				APIReader:   cl,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder: &mockBuilder{
+					getTemplateResp: tmpl,
+					getDurationResp: time.Hour,
+					createResourcesErr: builders.WithKind(builders.ErrTargetPodNotFound,
+						errors.New("pod named web-2 not found")),
+				},
+				now: func() time.Time { return now },
+			}
+			request := &v1alpha1.ExecAccessRequest{}
+
+			By("Reconciling until the access can not be granted")
+			var result reconcile.Result
+			for i := 0; i < 3; i++ {
+				var err error
+				result, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cl.Get(ctx, key, request)).To(Succeed())
+				if meta.IsStatusConditionFalse(
+					request.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+				) {
+					break
+				}
+			}
+			cond := meta.FindStatusCondition(
+				request.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonTargetPodNotFound))
+			Expect(result.Requeue).To(BeTrue())
+
+			By("Tearing the request down on the requeue")
+			for i := 0; i < 2; i++ {
+				_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(apierrors.IsNotFound(cl.Get(ctx, key, request))).To(BeTrue())
+		})
+	})
+
+	/*
+		Reconcile() Tests, for a request matching several candidate Pods
+		through a template that leaves the choice to the requester
+	*/
+	Context("Reconcile() with several candidate pods", func() {
+		var (
+			ctx = context.Background()
+			now = time.Now().UTC().Truncate(time.Second)
+			key = types.NamespacedName{Name: "ambiguous", Namespace: "verifyaccessresources"}
+		)
+
+		It("Should wait for the requester to select a target pod", func() {
+			cl := newExecAccessClient(key, now)
+			tmpl := &v1alpha1.ExecAccessTemplate{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "web", Namespace: key.Namespace}, tmpl)).To(Succeed())
+			tmpl.Spec.PodSelectionStrategy = v1alpha1.ManualPodSelection
+			Expect(cl.Update(ctx, tmpl)).To(Succeed())
+
+			pod := &v1.Pod{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "web-1", Namespace: key.Namespace}, pod)).To(Succeed())
+			pod.ObjectMeta = metav1.ObjectMeta{Name: "web-2", Namespace: key.Namespace, Labels: pod.Labels}
+			Expect(cl.Create(ctx, pod)).To(Succeed())
+
+			r := &RequestReconciler{
+				Client:      cl,
+				Scheme:      scheme.Scheme,
+				APIReader:   cl,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &execaccessbuilder.ExecAccessBuilder{},
+				now:         func() time.Time { return now },
+			}
+			request := &v1alpha1.ExecAccessRequest{}
+
+			By("Reconciling until the access resources are attempted")
+			var result reconcile.Result
+			for i := 0; i < 3; i++ {
+				var err error
+				result, err = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+
+			// VERIFY: The request is left waiting for a new one naming its Pod
+			cond := meta.FindStatusCondition(
+				request.Status.Conditions, v1alpha1.ConditionAccessResourcesCreated.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonTargetPodSelectionRequired))
+			Expect(cond.Message).To(Equal("2 pods match template web (web-1, web-2), " +
+				"set one of them as the targetPod of a new request"))
+			Expect(result).To(Equal(reconcile.Result{}))
+			Expect(request.Status.PodName).To(BeEmpty())
+			Expect(request.Status.Ready).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,53 @@
+package requestcontroller
+
+import (
+	"fmt"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/utils"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// verifyAdoptedRoleBinding stands in for verifyAccessResources() on requests
+// that adopt a pre-existing RoleBinding (see the AdoptRoleBindingAnnotation).
+// Rather than asking the Builder to create access resources, the RoleBinding
+// is taken over by the request - which then expires it like any other access.
+//
+// Until the RoleBinding has been marked for adoption by the request, an error
+// is returned so that reconciliation is retried.
+func (r *RequestReconciler) verifyAdoptedRoleBinding(
+	rctx *RequestContext,
+) (shouldReturn bool, result ctrl.Result, resultErr error) {
+	rctx.log.V(1).Info("Making sure the existing RoleBinding has been adopted")
+	rb, err := utils.AdoptRoleBinding(rctx.Context, r.Client, rctx.obj)
+	if err != nil {
+		// NOTE: Blindly ignoring the error return here because we are already
+		// returning an error which will fail the reconciliation.
+		_ = status.SetAccessResourcesNotCreated(rctx.Context, r, rctx.obj, err)
+		return true, result, err
+	}
+
+	rctx.obj.GetStatus().(v1alpha1.IRequestStatus).SetAccessMessage(fmt.Sprintf(
+		"Managing the existing RoleBinding %s until the request expires", rb.GetName(),
+	))
+	if err := status.SetAccessResourcesCreated(rctx.Context, r, rctx.obj,
+		fmt.Sprintf("Success. Adopted RoleBinding %s", rb.GetName())); err != nil {
+		return true, result, err
+	}
+	if err := status.SetAccessResourcesReady(rctx.Context, r, rctx.obj, "Ready"); err != nil {
+		return true, result, err
+	}
+	return false, result, nil
+}
+
+// deleteAccessResources tears down the access resources of the request - the
+// adopted RoleBinding first, if there is one, and then everything created by
+// the Builder.
+func (r *RequestReconciler) deleteAccessResources(rctx *RequestContext) error {
+	if err := utils.DeleteAdoptedRoleBinding(rctx.Context, r.Client, rctx.obj); err != nil {
+		return err
+	}
+	return r.Builder.DeleteAccessResources(rctx.Context, r.Client, rctx.obj)
+}
@@ -0,0 +1,174 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		verifyAdoptedRoleBinding() Tests
+	*/
+	Context("verifyAdoptedRoleBinding()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			rb         *rbacv1.RoleBinding
+			reconciler *RequestReconciler
+			builder    *mockBuilder
+		)
+
+		reconcileRequest := func() error {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      request.GetName(),
+					Namespace: request.GetNamespace(),
+				},
+			})
+			return err
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessRequest adopting a RoleBinding")
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "adopt-test",
+					Namespace: ns.GetName(),
+					Annotations: map[string]string{
+						v1alpha1.AdoptRoleBindingAnnotation: "ad-hoc",
+						v1alpha1.RequestedByAnnotation:      "alice",
+					},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: "bogus",
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have the pre-existing RoleBinding")
+			rb = &rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "ad-hoc",
+					Namespace: ns.GetName(),
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: rbacv1.GroupName,
+					Kind:     "ClusterRole",
+					Name:     "edit",
+				},
+				Subjects: []rbacv1.Subject{
+					{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "alice"},
+				},
+			}
+			err = k8sClient.Create(ctx, rb)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler")
+			builder = &mockBuilder{
+				getTemplateResp: &v1alpha1.ExecAccessTemplate{},
+				getDurationResp: time.Hour,
+			}
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                builder,
+				ReconciliationInterval: time.Minute,
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Reconcile() should wait until the RoleBinding is marked for adoption", func() {
+			err := reconcileRequest()
+
+			// VERIFY: Not adopted, and the builder was never asked for resources
+			Expect(err).To(MatchError(ContainSubstring("has not been marked for adoption by adopt-test")))
+			Expect(builder.createResourcesCalls).To(BeEmpty())
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: rb.GetName(), Namespace: rb.GetNamespace()}, rb)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rb.GetOwnerReferences()).To(BeEmpty())
+		})
+
+		It("Reconcile() should adopt the RoleBinding and grant the request", func() {
+			rb.SetAnnotations(map[string]string{v1alpha1.AdoptedByAnnotation: request.GetName()})
+			err := k8sClient.Update(ctx, rb)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = reconcileRequest()
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The request is ready, and owns the RoleBinding
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.IsReady()).To(BeTrue())
+			Expect(builder.createResourcesCalls).To(BeEmpty())
+			cond := meta.FindStatusCondition(
+				request.Status.Conditions,
+				v1alpha1.ConditionAccessResourcesCreated.String(),
+			)
+			Expect(cond.Message).To(Equal("Success. Adopted RoleBinding ad-hoc"))
+			Expect(request.Status.AccessMessage).To(ContainSubstring("RoleBinding ad-hoc"))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: rb.GetName(), Namespace: rb.GetNamespace()}, rb)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rb.GetOwnerReferences()).To(HaveLen(1))
+			Expect(rb.GetOwnerReferences()[0].UID).To(Equal(request.GetUID()))
+		})
+
+		It("Reconcile() should delete the adopted RoleBinding once the request expires", func() {
+			reconciler.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+			By("Expiring the request")
+			err := reconcileRequest()
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Running the finalizer")
+			err = reconcileRequest()
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: Both the request and the RoleBinding are gone
+			Expect(builder.deleteResourcesCalled).To(BeTrue())
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: rb.GetName(), Namespace: rb.GetNamespace()}, rb)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, &v1alpha1.ExecAccessRequest{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,137 @@
+package requestcontroller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/ctrlrequeue"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// verifyApprovals checks whether the request has been approved by the number
+// of distinct users that the template requires. The approvers recorded by the
+// webhook are copied into the Status.Approvers field, and the
+// ConditionAccessApproved condition is only flipped to True once the threshold
+// is met. Until then, reconciliation ends before any access resources are
+// created - other than those that the Builder prepares ahead of approval.
+//
+// Templates with an approvalRequiredSelector only require approvals for
+// requests whose target Pod matches it - for any other target the request is
+// marked as approved without waiting on anybody.
+//
+// Templates with an externalApproval wait on the external approval system
+// instead (see verifyExternalApproval).
+//
+// Extensions that are waiting on a fresh approval (see clampToApprovedRenewal)
+// end reconciliation here, whatever the template requires otherwise.
+//
+// Templates that do not require approvals are skipped entirely - if the
+// condition was left behind from when they did, it is removed so that it does
+// not go stale.
+func (r *RequestReconciler) verifyApprovals(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+) (shouldEndReconcile bool, result ctrl.Result, resultErr error) {
+	if rctx.renewalPending != "" {
+		return r.waitOnRenewalApproval(rctx)
+	}
+
+	if external := tmpl.GetAccessConfig().GetExternalApproval(); external != nil {
+		return r.verifyExternalApproval(rctx, tmpl, external)
+	}
+
+	required := tmpl.GetAccessConfig().GetRequiredApprovals()
+	if required <= 0 {
+		conditions := rctx.obj.GetStatus().GetConditions()
+		if meta.FindStatusCondition(*conditions, v1alpha1.ConditionAccessApproved.String()) == nil {
+			return false, result, nil
+		}
+		meta.RemoveStatusCondition(conditions, v1alpha1.ConditionAccessApproved.String())
+		return false, result, status.UpdateStatus(rctx.Context, r, rctx.obj)
+	}
+
+	approvalRequired, err := r.approvalRequired(rctx.Context, rctx.obj, tmpl)
+	if err != nil {
+		// We cannot tell whether approval is required, so require it.
+		rctx.log.Error(err, "Failed to evaluate approvalRequiredSelector")
+		if err := status.SetAccessNotApproved(rctx.Context, r, rctx.obj,
+			fmt.Sprintf("Unable to determine whether approval is required: %s", err)); err != nil {
+			return true, result, err
+		}
+		if err := status.SetReadyStatus(rctx, r, rctx.obj); err != nil {
+			return true, result, err
+		}
+		result, resultErr = ctrlrequeue.RequeueAfter(r.ReconciliationInterval)
+		return true, result, resultErr
+	}
+	if !approvalRequired {
+		return false, result, status.SetAccessApproved(rctx.Context, r, rctx.obj,
+			approvalNotRequiredMsg)
+	}
+
+	rctx.log.V(1).Info("Checking Access Request approvals...")
+	approvers := v1alpha1.GetApprovers(rctx.obj)
+	rctx.obj.GetStatus().(v1alpha1.IRequestStatus).SetApprovers(approvers)
+
+	if len(approvers) < required {
+		rctx.log.Info(fmt.Sprintf("Waiting on approvals (%d of %d)", len(approvers), required))
+		if err := status.SetAccessNotApproved(rctx.Context, r, rctx.obj,
+			fmt.Sprintf("Waiting on approvals: %d of %d received", len(approvers), required)); err != nil {
+			return true, result, err
+		}
+		if err := status.SetReadyStatus(rctx, r, rctx.obj); err != nil {
+			return true, result, err
+		}
+
+		// Prepare what the Builder can ahead of the approval (eg, a warm
+		// Pod), without granting any access.
+		r.prepareAccessResources(rctx, tmpl)
+
+		// Approvals trigger a reconcile on their own, but keep checking back
+		// so that the request still expires if nobody ever approves it.
+		result, resultErr = ctrlrequeue.RequeueAfter(r.ReconciliationInterval)
+		return true, result, resultErr
+	}
+
+	return false, result, status.SetAccessApproved(rctx.Context, r, rctx.obj,
+		fmt.Sprintf("Approved by %s", strings.Join(approvers, ", ")))
+}
+
+// approvalNotRequiredMsg explains why a request against a template with
+// requiredApprovals was approved without waiting on anybody.
+const approvalNotRequiredMsg = "Approval not required, target does not match approvalRequiredSelector"
+
+// approvalRequired returns whether the supplied request must wait on the
+// template's requiredApprovals, which is always the case unless the template
+// has an approvalRequiredSelector that the request's target Pod does not
+// match.
+func (r *RequestReconciler) approvalRequired(
+	ctx context.Context,
+	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
+) (bool, error) {
+	cfg := tmpl.GetAccessConfig()
+	if cfg.GetRequiredApprovals() <= 0 {
+		return false, nil
+	}
+	sel := cfg.GetApprovalRequiredSelector()
+	if sel == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(sel)
+	if err != nil {
+		return false, err
+	}
+	targetLabels, err := r.Builder.GetTargetLabels(ctx, r.Client, req, tmpl)
+	if err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(targetLabels)), nil
+}
@@ -0,0 +1,290 @@
+package requestcontroller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		verifyApprovals() Tests
+	*/
+	Context("verifyApprovals()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			builder    = &mockBuilder{}
+			rctx       *RequestContext
+		)
+
+		// setApprovers writes the ApprovedByAnnotation the same way that the
+		// mutating webhook would, and re-populates the rctx.obj.
+		setApprovers := func(approvers string) {
+			rctx.obj.SetAnnotations(map[string]string{v1alpha1.ApprovedByAnnotation: approvers})
+			err := k8sClient.Update(ctx, rctx.obj)
+			Expect(err).ToNot(HaveOccurred())
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessTemplate that requires two approvals")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:     []string{"foo"},
+						DefaultDuration:   "1h",
+						MaxDuration:       "2h",
+						RequiredApprovals: 2,
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessRequest built to test against")
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "verifyapprovals-test",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler")
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                builder,
+				ReconciliationInterval: 0,
+			}
+
+			By("Creating the RequestContext")
+			rctx = newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+
+			By("Populuating the rctx.obj object...")
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).To(BeNil())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("verifyApprovals() should skip templates that do not require approvals", func() {
+			tmpl := template.DeepCopy()
+			tmpl.Spec.AccessConfig.RequiredApprovals = 0
+
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, tmpl)
+
+			// VERIFY: Do not end, and no condition is set
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessApproved.String(),
+			)).To(BeNil())
+		})
+
+		It("verifyApprovals() should end the reconcile with no approvals", func() {
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, template)
+
+			// VERIFY: End the reconcile, access is not approved
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessApproved.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(Equal("Waiting on approvals: 0 of 2 received"))
+			Expect(rctx.obj.GetStatus().IsReady()).To(BeFalse())
+
+			// VERIFY: The access resources are prepared, but not created
+			Expect(builder.prepareResourcesCalls).To(Equal(1))
+			Expect(builder.createResourcesCalls).To(BeEmpty())
+		})
+
+		It("verifyApprovals() should keep waiting if the access resources cannot be prepared", func() {
+			builder.prepareResourcesErr = errors.New("no room for a warm pod")
+			DeferCleanup(func() { builder.prepareResourcesErr = nil })
+
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, template)
+
+			// VERIFY: The failure to prepare is not a failure of the reconcile
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(builder.prepareResourcesCalls).To(Equal(2))
+		})
+
+		It("verifyApprovals() should still wait after a single approval", func() {
+			setApprovers("alice")
+
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, template)
+
+			// VERIFY: End the reconcile, access is not approved
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessApproved.String(),
+			)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(Equal("Waiting on approvals: 1 of 2 received"))
+			Expect(rctx.obj.GetStatus().(v1alpha1.IRequestStatus).GetApprovers()).
+				To(Equal([]string{"alice"}))
+		})
+
+		It("verifyApprovals() should approve once two distinct users have approved", func() {
+			setApprovers("alice,bob")
+
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, template)
+
+			// VERIFY: Do not end, access is approved
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessApproved.String(),
+			)
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Message).To(Equal("Approved by alice, bob"))
+			Expect(builder.prepareResourcesCalls).To(Equal(3))
+			Expect(rctx.obj.GetStatus().(v1alpha1.IRequestStatus).GetApprovers()).
+				To(Equal([]string{"alice", "bob"}))
+		})
+
+		It("verifyApprovals() should drop the condition once the template stops requiring approvals", func() {
+			tmpl := template.DeepCopy()
+			tmpl.Spec.AccessConfig.RequiredApprovals = 0
+
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, tmpl)
+
+			// VERIFY: Do not end, and the condition left behind is removed
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessApproved.String(),
+			)).To(BeNil())
+		})
+
+		It("verifyApprovals() should require approval for targets matching the approvalRequiredSelector", func() {
+			setApprovers("alice")
+			tmpl := template.DeepCopy()
+			tmpl.Spec.AccessConfig.ApprovalRequiredSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": "production"},
+			}
+			builder.getTargetLabelsResp = map[string]string{"app": "foo", "env": "production"}
+
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, tmpl)
+
+			// VERIFY: End the reconcile, access is not approved
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessApproved.String(),
+			)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(Equal("Waiting on approvals: 1 of 2 received"))
+		})
+
+		It("verifyApprovals() should auto-approve targets not matching the approvalRequiredSelector", func() {
+			tmpl := template.DeepCopy()
+			tmpl.Spec.AccessConfig.ApprovalRequiredSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": "production"},
+			}
+			builder.getTargetLabelsResp = map[string]string{"app": "foo", "env": "staging"}
+
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, tmpl)
+
+			// VERIFY: Do not end, access is approved without any more approvals
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessApproved.String(),
+			)
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Message).To(Equal(
+				"Approval not required, target does not match approvalRequiredSelector",
+			))
+		})
+
+		It("verifyApprovals() should require approval if the target cannot be determined", func() {
+			tmpl := template.DeepCopy()
+			tmpl.Spec.AccessConfig.ApprovalRequiredSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": "production"},
+			}
+			builder.getTargetLabelsResp = nil
+			builder.getTargetLabelsErr = errors.New("no pods found")
+			DeferCleanup(func() { builder.getTargetLabelsErr = nil })
+
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, tmpl)
+
+			// VERIFY: End the reconcile, access is not approved
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessApproved.String(),
+			)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(Equal(
+				"Unable to determine whether approval is required: no pods found",
+			))
+		})
+	})
+})
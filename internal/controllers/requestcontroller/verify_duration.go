@@ -2,12 +2,15 @@ package requestcontroller
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
 	"github.com/diranged/oz/internal/builders"
 	"github.com/diranged/oz/internal/controllers/internal/ctrlrequeue"
 	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+	"k8s.io/apimachinery/pkg/api/meta"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
@@ -20,6 +23,10 @@ func (r *RequestReconciler) verifyDuration(
 
 	rctx.log.V(1).Info("Computing Access Request duration...")
 
+	// While an incident is declared in the namespace, the incident duration
+	// profile of the template replaces its normal durations.
+	tmpl, incident := selectDurationProfile(rctx.namespaceConfig, tmpl)
+
 	// Get the accessDuration and decision from the builder
 	accessDuration, decision, err := r.Builder.GetAccessDuration(rctx.obj, tmpl)
 	// If an error is returned, determine whether its something wrong with the
@@ -40,24 +47,171 @@ func (r *RequestReconciler) verifyDuration(
 			result, resultErr = ctrlrequeue.RequeueError(err)
 		}
 
-		// Update the status, and return the results
+		// Update the status, and return the results. Unless the error was
+		// transient, the request will never be granted - so tell the requester.
 		_ = status.SetRequestDurationsNotValid(rctx.Context, r, rctx.obj, err.Error())
+		if resultErr == nil {
+			_ = r.notifyRequester(rctx, notify.EventDenied, fmt.Sprintf("Access denied: %s", err))
+		}
 		return shouldEndReconcile, result, resultErr
 	}
 
+	if incident {
+		decision = fmt.Sprintf("%s, using the incident duration profile", decision)
+	}
+
+	// Clamp the access to the end of day, and to the absolute maximum duration.
+	accessDuration, decision, err = r.clampAccessDuration(
+		rctx.obj.GetCreationTimestamp().Time, tmpl, rctx.namespaceConfig, accessDuration, decision,
+	)
+	if err != nil {
+		rctx.log.Error(err, "Invalid duration ceiling, will not requeue.")
+		_ = status.SetRequestDurationsNotValid(rctx.Context, r, rctx.obj, err.Error())
+		_ = r.notifyRequester(rctx, notify.EventDenied, fmt.Sprintf("Access denied: %s", err))
+		result, resultErr = ctrlrequeue.NoRequeue()
+		return true, result, resultErr
+	}
+
+	// Access that is still in use does not expire until it goes idle.
+	accessDuration, decision, err = r.extendWhileInUse(rctx, tmpl, accessDuration, decision)
+	if err != nil {
+		return true, result, err
+	}
+
+	// Never grant a user more access in a day than the daily grant budget.
+	accessDuration, decision, withinBudget, err := r.clampToDailyGrantBudget(rctx, accessDuration, decision)
+	if err != nil {
+		rctx.log.Error(err, "Unable to check the daily grant budget, will requeue")
+		result, resultErr = ctrlrequeue.RequeueError(err)
+		return true, result, resultErr
+	}
+
+	// Large extensions of access that was already granted wait on a fresh approval.
+	accessDuration, decision, err = r.clampToApprovedRenewal(rctx, tmpl, accessDuration, decision)
+	if err != nil {
+		return true, result, err
+	}
+
+	// Never let the access outlast the window that its approval covers.
+	accessDuration, decision = r.clampToApprovedWindow(rctx, accessDuration, decision)
+
 	// Success, update the resource
 	if err := status.SetRequestDurationsValid(rctx.Context, r, rctx.obj, decision); err != nil {
 		return true, ctrl.Result{}, err
 	}
 
+	// Once the access has ended (it expired, or was revoked, denied, drained
+	// and so on), leave the ConditionAccessStillValid condition alone. Setting
+	// it again would reset its LastTransitionTime, which accessEndedAt() counts
+	// the grace period from.
+	if meta.IsStatusConditionFalse(
+		*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionAccessStillValid.String(),
+	) {
+		return false, result, nil
+	}
+
+	// If the requester has used up their daily grant budget, flip the access
+	// to invalid so that isAccessExpired() cleans the request up.
+	if !withinBudget {
+		message := dailyGrantBudgetMsg(v1alpha1.GetRequester(rctx.obj), r.DailyGrantBudget)
+		rctx.log.Info(message)
+		if err := status.SetAccessOverBudget(rctx.Context, r, rctx.obj, message); err != nil {
+			return false, result, err
+		}
+		return false, result, r.notifyRequester(rctx, notify.EventDenied, message)
+	}
+
+	// If a user forced the access to expire early, treat it as expired.
+	if user, forced := v1alpha1.GetForcedExpiration(rctx.obj); forced {
+		rctx.log.Info("Access expiration was forced", "user", user)
+		return false, result, status.SetAccessForceExpired(rctx.Context, r, rctx.obj, user)
+	}
+
 	// If the access is expired at this point, update that condition too.
-	if rctx.obj.GetUptime() > accessDuration {
+	if r.getNow().Sub(rctx.obj.GetCreationTimestamp().Time) > accessDuration {
 		// No we should not end the reconcile - the access is invalid ... but
 		// that means we need to finish the reconcile to trigger the deletion
 		// phase. Only requeue if the SetAccessNotValid() step fails.
+		expiresAt := rctx.obj.GetCreationTimestamp().Add(accessDuration)
+		if err := r.recordGrantExpired(rctx, expiresAt); err != nil {
+			return false, result, err
+		}
 		return false, result, status.SetAccessNotValid(rctx.Context, r, rctx.obj)
 	}
 
-	// End by setting the access to still-valid
-	return false, result, status.SetAccessStillValid(rctx.Context, r, rctx.obj)
+	// End by setting the access to still-valid, and warn the requester if it
+	// is about to expire.
+	rctx.expiresAt = rctx.obj.GetCreationTimestamp().Add(accessDuration)
+	if err := status.SetAccessStillValid(rctx.Context, r, rctx.obj); err != nil {
+		return false, result, err
+	}
+	return false, result, r.notifyExpiring(rctx, rctx.expiresAt)
+}
+
+// clampAccessDuration shortens the access duration picked by the Builder for a
+// request created at the supplied time, so that it ends by midnight if the
+// template requires it, never exceeds the AbsoluteMaxDuration (or the
+// absoluteMaxDuration of the OzConfig of the namespace, which may be nil), and
+// ends by the maintenance drain, if there is one.
+// The decision is extended to explain any clamping.
+//
+// Returns:
+//   - An "error" if the template expireAtEndOfDay time zone, or the OzConfig
+//     absoluteMaxDuration, is invalid
+func (r *RequestReconciler) clampAccessDuration(
+	created time.Time,
+	tmpl v1alpha1.ITemplateResource,
+	cfg *v1alpha1.OzConfig,
+	accessDuration time.Duration,
+	decision string,
+) (time.Duration, string, error) {
+	// If the template requires it, clamp the access so that it ends at midnight.
+	if eod := tmpl.GetAccessConfig().GetExpireAtEndOfDay(); eod != nil {
+		midnight, err := eod.NextMidnight(created)
+		if err != nil {
+			return accessDuration, decision, err
+		}
+		if created.Add(accessDuration).After(midnight) {
+			accessDuration = midnight.Sub(created)
+			decision = fmt.Sprintf("%s, clamped to the end of day at %s",
+				decision, midnight.Format(time.RFC3339))
+		}
+	}
+
+	// Never grant access for longer than the namespace (or controller-wide) ceiling.
+	ceiling, err := r.getAbsoluteMaxDuration(cfg)
+	if err != nil {
+		return accessDuration, decision, err
+	}
+	if ceiling > 0 && accessDuration > ceiling {
+		accessDuration = ceiling
+		decision = fmt.Sprintf("%s, clamped to the absolute maximum duration of %s",
+			decision, ceiling)
+	}
+
+	// During a maintenance drain, no access outlives the drain.
+	accessDuration, decision = r.clampToMaintenanceDrain(created, accessDuration, decision)
+
+	return accessDuration, decision, nil
+}
+
+// selectDurationProfile picks the durations that apply to the supplied
+// template. If the OzConfig of the namespace (which may be nil) declares an
+// incident and the template sets incidentDurations, a copy of the template
+// with those durations is returned, along with true. Otherwise the template
+// itself is returned.
+func selectDurationProfile(
+	cfg *v1alpha1.OzConfig,
+	tmpl v1alpha1.ITemplateResource,
+) (v1alpha1.ITemplateResource, bool) {
+	if cfg == nil || !cfg.Spec.IncidentMode {
+		return tmpl, false
+	}
+	profile := tmpl.GetAccessConfig().GetIncidentDurations()
+	if profile == nil {
+		return tmpl, false
+	}
+	tmpl = tmpl.DeepCopyObject().(v1alpha1.ITemplateResource)
+	profile.ApplyTo(tmpl.GetAccessConfig())
+	return tmpl, true
 }
@@ -9,13 +9,18 @@ import (
 	. "github.com/onsi/gomega"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
 	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder"
+	"github.com/diranged/oz/internal/controllers/internal/status"
 	"github.com/diranged/oz/internal/testing/utils"
 )
 
@@ -308,5 +313,405 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
 			Expect(cond.Reason).To(Equal("Success"))
 		})
+
+		It("verifyDuration() should clamp access to the AbsoluteMaxDuration", func() {
+			builder.getDurationErr = nil
+			builder.getDurationResp = time.Hour
+			reconciler.AbsoluteMaxDuration = 30 * time.Minute
+			reconciler.now = func() time.Time {
+				return rctx.obj.GetCreationTimestamp().Add(45 * time.Minute)
+			}
+			DeferCleanup(func() {
+				reconciler.AbsoluteMaxDuration = 0
+				reconciler.now = nil
+			})
+
+			_, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).To(BeNil())
+
+			By("Refetching our Request...")
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			}, request)
+			Expect(err).To(Not(HaveOccurred()))
+
+			// VERIFY: The decision records the clamp
+			cond := meta.FindStatusCondition(
+				*request.GetStatus().GetConditions(),
+				v1alpha1.ConditionRequestDurationsValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Message).To(ContainSubstring("clamped to the absolute maximum duration of 30m0s"))
+
+			// VERIFY: The access has expired, even though the template duration has not elapsed
+			cond = meta.FindStatusCondition(
+				*request.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		})
+	})
+
+	Context("verifyDuration() with ExpireAtEndOfDay", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			builder    = &mockBuilder{}
+			rctx       *RequestContext
+			now        time.Time
+			created    time.Time
+			midnight   time.Time
+		)
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessTemplate that expires access at the end of the day")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "48h",
+						ExpireAtEndOfDay: &v1alpha1.EndOfDayConfig{
+							TimeZone: "America/New_York",
+						},
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessRequest built to test against")
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "endofday-test",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler with a fake clock")
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                builder,
+				ReconciliationInterval: 0,
+				now:                    func() time.Time { return now },
+			}
+
+			By("Creating the RequestContext")
+			rctx = newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+
+			By("Populuating the rctx.obj object...")
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).To(BeNil())
+
+			created = rctx.obj.GetCreationTimestamp().Time
+			midnight, err = template.Spec.AccessConfig.ExpireAtEndOfDay.NextMidnight(created)
+			Expect(err).To(BeNil())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		getCondition := func(condType v1alpha1.IConditionType) *metav1.Condition {
+			By("Refetching our Request...")
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.Name,
+				Namespace: request.Namespace,
+			}, request)
+			Expect(err).To(Not(HaveOccurred()))
+
+			cond := meta.FindStatusCondition(*request.GetStatus().GetConditions(), condType.String())
+			Expect(cond).ToNot(BeNil())
+			return cond
+		}
+
+		It("verifyDuration() should clamp access that would outlast midnight", func() {
+			// Make the Mock return a duration that runs well past midnight
+			builder.getDurationErr = nil
+			builder.getDurationResp = midnight.Sub(created) + 12*time.Hour
+
+			By("Checking just before midnight")
+			now = midnight.Add(-time.Second)
+			_, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).To(BeNil())
+
+			// VERIFY: The decision records the clamp, and access is still valid
+			cond := getCondition(v1alpha1.ConditionRequestDurationsValid)
+			Expect(cond.Message).To(ContainSubstring(
+				fmt.Sprintf("clamped to the end of day at %s", midnight.Format(time.RFC3339)),
+			))
+			Expect(getCondition(v1alpha1.ConditionAccessStillValid).Status).To(Equal(metav1.ConditionTrue))
+
+			By("Checking just after midnight")
+			now = midnight.Add(time.Second)
+			_, _, err = reconciler.verifyDuration(rctx, template)
+			Expect(err).To(BeNil())
+
+			// VERIFY: The access has expired, even though the duration has not elapsed
+			Expect(getCondition(v1alpha1.ConditionAccessStillValid).Status).To(Equal(metav1.ConditionFalse))
+		})
+
+		It("verifyDuration() should not change access that ends before midnight", func() {
+			// Make the Mock return a duration that ends before midnight
+			duration := midnight.Sub(created) / 2
+			builder.getDurationErr = nil
+			builder.getDurationResp = duration
+
+			By("Checking before the duration has elapsed")
+			now = created.Add(duration - time.Millisecond)
+			_, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).To(BeNil())
+
+			// VERIFY: The decision is passed through untouched
+			cond := getCondition(v1alpha1.ConditionRequestDurationsValid)
+			Expect(cond.Message).To(Equal("test"))
+			Expect(getCondition(v1alpha1.ConditionAccessStillValid).Status).To(Equal(metav1.ConditionTrue))
+
+			By("Checking after the duration has elapsed, but before midnight")
+			now = created.Add(duration + time.Millisecond)
+			_, _, err = reconciler.verifyDuration(rctx, template)
+			Expect(err).To(BeNil())
+
+			// VERIFY: The access expires on the normal schedule
+			Expect(getCondition(v1alpha1.ConditionAccessStillValid).Status).To(Equal(metav1.ConditionFalse))
+		})
+	})
+
+	/*
+		Forced expiration Tests
+	*/
+	Context("verifyDuration() with a forced expiration", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			reconciler *RequestReconciler
+			builder    *mockBuilder
+		)
+
+		reconcileRequest := func() {
+			_, err := reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      request.GetName(),
+					Namespace: request.GetNamespace(),
+				},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessRequest built to test against")
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "forceexpire-test",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: "bogus",
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler")
+			builder = &mockBuilder{
+				getTemplateResp:             &v1alpha1.ExecAccessTemplate{},
+				getDurationResp:             time.Hour,
+				createResourcesResp:         "Role XYZ created",
+				accessResourcesAreReadyResp: true,
+			}
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                builder,
+				ReconciliationInterval: time.Minute,
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Reconcile() should grant access while the request is within its duration", func() {
+			reconcileRequest()
+
+			err := k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.IsReady()).To(BeTrue())
+			Expect(builder.deleteResourcesCalled).To(BeFalse())
+		})
+
+		It("Reconcile() should clean up the access on the next reconcile once it is forced to expire", func() {
+			By("Forcing the expiration, the way the mutating webhook records it")
+			request.SetAnnotations(map[string]string{v1alpha1.ExpireAnnotation: "alice"})
+			err := k8sClient.Update(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Reconciling, which expires and deletes the request")
+			reconcileRequest()
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, request)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(request.GetDeletionTimestamp().IsZero()).To(BeFalse())
+			cond := meta.FindStatusCondition(
+				request.Status.Conditions,
+				v1alpha1.ConditionAccessStillValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(Equal("Access expiration forced by alice"))
+
+			By("Reconciling the deletion, which tears down the access resources")
+			reconcileRequest()
+			Expect(builder.deleteResourcesCalled).To(BeTrue())
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, request)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("RequestReconciler", func() {
+	Context("verifyDuration() once the access has ended", func() {
+		var (
+			ctx = context.Background()
+			now = time.Now().UTC().Truncate(time.Second)
+			key = types.NamespacedName{Name: "revoked", Namespace: "verifyduration"}
+			cl  client.Client
+			r   *RequestReconciler
+		)
+
+		reconcileRequest := func() {
+			_, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			cl = newExecAccessClient(key, now)
+			r = &RequestReconciler{
+				Client:      cl,
+				Scheme:      scheme.Scheme,
+				APIReader:   cl,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &execaccessbuilder.ExecAccessBuilder{},
+				now:         func() time.Time { return now },
+			}
+
+			By("Keeping expired requests around for an hour")
+			tmpl := &v1alpha1.ExecAccessTemplate{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "web", Namespace: key.Namespace}, tmpl)).To(Succeed())
+			tmpl.Spec.AccessConfig.ExpirationGracePeriod = "1h"
+			Expect(cl.Update(ctx, tmpl)).To(Succeed())
+
+			By("Granting the access")
+			for i := 0; i < 3; i++ {
+				reconcileRequest()
+			}
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			Expect(request.Status.IsReady()).To(BeTrue())
+		})
+
+		It("Should keep the time the access was revoked at", func() {
+			By("Revoking the access two hours ago")
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			request.Annotations[v1alpha1.RevokeAnnotation] = "incident resolved"
+			request.Annotations[v1alpha1.RevokedByAnnotation] = "bob"
+			Expect(cl.Update(ctx, request)).To(Succeed())
+			revokedAt := metav1.NewTime(now.Add(-2 * time.Hour))
+			meta.SetStatusCondition(&request.Status.Conditions, metav1.Condition{
+				Type:               v1alpha1.ConditionAccessStillValid.String(),
+				Status:             metav1.ConditionFalse,
+				Reason:             status.ReasonAccessRevoked,
+				Message:            "Access revoked by bob: incident resolved",
+				LastTransitionTime: revokedAt,
+			})
+			Expect(cl.Status().Update(ctx, request)).To(Succeed())
+
+			By("Checking the duration, which leaves the condition alone")
+			rctx := newRequestContext(ctx, r.RequestType, reconcile.Request{NamespacedName: key})
+			Expect(r.fetchRequestObject(rctx)).To(Succeed())
+			tmpl, err := rctx.obj.GetTemplate(ctx, cl)
+			Expect(err).ToNot(HaveOccurred())
+			shouldEnd, _, err := r.verifyDuration(rctx, tmpl)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEnd).To(BeFalse())
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			cond := meta.FindStatusCondition(
+				request.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+			)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessRevoked))
+			Expect(cond.LastTransitionTime.Time).To(BeTemporally("==", revokedAt.Time))
+
+			By("Deleting the request, whose grace period is over")
+			reconcileRequest()
+			reconcileRequest()
+			err = cl.Get(ctx, key, request)
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+		})
 	})
 })
@@ -0,0 +1,132 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/ctrlrequeue"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/externalapproval"
+	"github.com/diranged/oz/internal/notify"
+)
+
+// verifyExternalApproval polls the approval-status endpoint of a template with
+// an externalApproval, and maps the state it reports to the
+// ConditionAccessApproved condition:
+//
+//   - pending: the condition is False, and the endpoint is polled again after
+//     the ExternalApprovalPollInterval.
+//   - approved: the condition is True. Approvals are final, the endpoint is
+//     not polled again once the request has been approved.
+//   - rejected: the condition is False, and the requester is told. The
+//     request is never granted, and is cleaned up once it expires.
+//
+// Until the request is approved, reconciliation ends before any access
+// resources are created - other than those that the Builder prepares ahead of
+// approval. If the endpoint cannot be reached, the error is returned so that
+// the endpoint is retried with the exponential backoff of the controller.
+func (r *RequestReconciler) verifyExternalApproval(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+	cfg *v1alpha1.ExternalApprovalConfig,
+) (shouldEndReconcile bool, result ctrl.Result, resultErr error) {
+	if meta.IsStatusConditionTrue(
+		*rctx.obj.GetStatus().GetConditions(),
+		v1alpha1.ConditionAccessApproved.String(),
+	) {
+		return false, result, nil
+	}
+
+	rctx.log.V(1).Info("Checking Access Request external approval...")
+	approval, err := r.getExternalApprovalChecker().Check(rctx.Context, cfg.URL, rctx.obj)
+	if err != nil {
+		rctx.log.Error(err, "Failed to check external approval, will retry")
+		if err := status.SetAccessNotApproved(rctx.Context, r, rctx.obj,
+			fmt.Sprintf("Unable to check external approval: %s", err)); err != nil {
+			return true, result, err
+		}
+		if err := status.SetReadyStatus(rctx, r, rctx.obj); err != nil {
+			return true, result, err
+		}
+		result, resultErr = ctrlrequeue.RequeueError(err)
+		return true, result, resultErr
+	}
+
+	message := externalApprovalMsg(approval)
+	switch approval.State {
+	case externalapproval.StateApproved:
+		rctx.log.Info(message)
+		return false, result, status.SetAccessApproved(rctx.Context, r, rctx.obj, message)
+
+	case externalapproval.StateRejected:
+		rctx.log.Info(message)
+		if err := status.SetAccessRejected(rctx.Context, r, rctx.obj, message); err != nil {
+			return true, result, err
+		}
+		if err := status.SetReadyStatus(rctx, r, rctx.obj); err != nil {
+			return true, result, err
+		}
+		if err := r.notifyRequester(rctx, notify.EventDenied, message); err != nil {
+			return true, result, err
+		}
+
+		// Keep checking back, so that the request still expires.
+		result, resultErr = ctrlrequeue.RequeueAfter(r.ReconciliationInterval)
+		return true, result, resultErr
+	}
+
+	rctx.log.Info(message)
+	if err := status.SetAccessNotApproved(rctx.Context, r, rctx.obj, message); err != nil {
+		return true, result, err
+	}
+	if err := status.SetReadyStatus(rctx, r, rctx.obj); err != nil {
+		return true, result, err
+	}
+
+	// Prepare what the Builder can ahead of the approval (eg, a warm Pod),
+	// without granting any access.
+	r.prepareAccessResources(rctx, tmpl)
+
+	result, resultErr = ctrlrequeue.RequeueAfter(r.getExternalApprovalPollInterval())
+	return true, result, resultErr
+}
+
+// externalApprovalMsg describes the Status reported by an external approval
+// system.
+func externalApprovalMsg(approval *externalapproval.Status) string {
+	var message string
+	switch approval.State {
+	case externalapproval.StateApproved:
+		message = "Approved externally"
+	case externalapproval.StateRejected:
+		message = "Rejected externally"
+	default:
+		message = "Waiting on external approval"
+	}
+	if approval.Message != "" {
+		message = fmt.Sprintf("%s: %s", message, approval.Message)
+	}
+	return message
+}
+
+// getExternalApprovalChecker returns the ExternalApprovalChecker, or a
+// default externalapproval.Checker if none is set.
+func (r *RequestReconciler) getExternalApprovalChecker() *externalapproval.Checker {
+	if r.ExternalApprovalChecker != nil {
+		return r.ExternalApprovalChecker
+	}
+	return &externalapproval.Checker{}
+}
+
+// getExternalApprovalPollInterval returns the ExternalApprovalPollInterval,
+// or the DefaultExternalApprovalPollInterval if it is not set.
+func (r *RequestReconciler) getExternalApprovalPollInterval() time.Duration {
+	if r.ExternalApprovalPollInterval > 0 {
+		return r.ExternalApprovalPollInterval
+	}
+	return DefaultExternalApprovalPollInterval
+}
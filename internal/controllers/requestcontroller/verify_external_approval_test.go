@@ -0,0 +1,189 @@
+package requestcontroller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		verifyExternalApproval() Tests
+	*/
+	Context("verifyExternalApproval()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			server     *httptest.Server
+			polls      int
+			httpStatus int
+			response   string
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			builder    = &mockBuilder{}
+		)
+
+		// newRctx creates an ExecAccessRequest, and returns a populated
+		// RequestContext for it.
+		newRctx := func() *RequestContext {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err := k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			rctx := newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		approvedCondition := func(rctx *RequestContext) *metav1.Condition {
+			return meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessApproved.String(),
+			)
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have a stub external approval endpoint")
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				polls++
+				w.WriteHeader(httpStatus)
+				_, _ = w.Write([]byte(response))
+			}))
+
+			By("Should have an ExecAccessTemplate that defers to the external approval system")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:    []string{"foo"},
+						DefaultDuration:  "1h",
+						MaxDuration:      "2h",
+						ExternalApproval: &v1alpha1.ExternalApprovalConfig{URL: server.URL},
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+
+			By("Creating the RequestReconciler")
+			reconciler = &RequestReconciler{
+				Client:                       k8sClient,
+				Scheme:                       k8sClient.Scheme(),
+				APIReader:                    k8sClient,
+				RequestType:                  &v1alpha1.ExecAccessRequest{},
+				Builder:                      builder,
+				ExternalApprovalPollInterval: 30 * time.Second,
+			}
+		})
+
+		BeforeEach(func() {
+			polls, httpStatus = 0, http.StatusOK
+		})
+
+		AfterAll(func() {
+			server.Close()
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("verifyApprovals() should wait while pending, then proceed once approved", func() {
+			rctx := newRctx()
+
+			// The external system has not decided yet
+			response = `{"state": "pending", "message": "CHG0012345 awaiting CAB"}`
+			shouldEndReconcile, result, err := reconciler.verifyApprovals(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(result.RequeueAfter).To(Equal(30 * time.Second))
+			cond := approvedCondition(rctx)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(Equal("Waiting on external approval: CHG0012345 awaiting CAB"))
+
+			// The external system approves the request
+			response = `{"state": "approved", "message": "CHG0012345 approved by bob"}`
+			shouldEndReconcile, _, err = reconciler.verifyApprovals(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+			cond = approvedCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Message).To(Equal("Approved externally: CHG0012345 approved by bob"))
+			Expect(polls).To(Equal(2))
+
+			// Approvals are final - the endpoint is not polled again
+			response = `{"state": "pending"}`
+			shouldEndReconcile, _, err = reconciler.verifyApprovals(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(polls).To(Equal(2))
+		})
+
+		It("verifyApprovals() should never grant a rejected request", func() {
+			rctx := newRctx()
+
+			response = `{"state": "rejected"}`
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeTrue())
+			cond := approvedCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessRejected))
+			Expect(cond.Message).To(Equal("Rejected externally"))
+		})
+
+		It("verifyApprovals() should return the error when the endpoint fails, to back off", func() {
+			rctx := newRctx()
+
+			httpStatus = http.StatusBadGateway
+			shouldEndReconcile, _, err := reconciler.verifyApprovals(rctx, template)
+			Expect(err).To(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeTrue())
+			cond := approvedCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(ContainSubstring("Unable to check external approval"))
+		})
+	})
+})
@@ -0,0 +1,42 @@
+package requestcontroller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+)
+
+// verifyJustification denies the request if its `spec.justification` does not
+// conform to the justificationPattern of the template, and it has not created
+// its access resources yet. The webhook already rejects such requests when
+// they are created - this catches the ones that got past it (eg, created
+// while the webhook was unavailable, or before the pattern was added to the
+// template). Access that was already granted is left alone, so that changing
+// the pattern does not revoke it. The ConditionAccessStillValid condition is
+// flipped to False, so that isAccessExpired() cleans the request up.
+func (r *RequestReconciler) verifyJustification(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+) error {
+	conditions := *rctx.obj.GetStatus().GetConditions()
+	if meta.IsStatusConditionTrue(conditions, v1alpha1.ConditionAccessResourcesCreated.String()) ||
+		meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionAccessStillValid.String()) {
+		return nil
+	}
+
+	err := tmpl.GetAccessConfig().ValidateJustification(rctx.obj.GetJustification())
+	if err == nil {
+		return nil
+	}
+
+	message := fmt.Sprintf("Access denied by template %s: %s", tmpl.GetName(), err)
+	rctx.log.Info(message)
+	if err := status.SetAccessDenied(rctx.Context, r, rctx.obj, message); err != nil {
+		return err
+	}
+	return r.notifyRequester(rctx, notify.EventDenied, message)
+}
@@ -0,0 +1,136 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+var _ = Describe("RequestReconciler", func() {
+	Context("verifyJustification()", func() {
+		var (
+			ctx = context.Background()
+			now = time.Now().UTC().Truncate(time.Second)
+			key = types.NamespacedName{Name: "debug", Namespace: "justification"}
+			cl  client.Client
+			r   *RequestReconciler
+		)
+
+		// setJustification requires the template to set a pattern, and the
+		// request to state the supplied justification.
+		setJustification := func(justification string) {
+			tmpl := &v1alpha1.ExecAccessTemplate{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "web", Namespace: key.Namespace}, tmpl)).To(Succeed())
+			tmpl.Spec.AccessConfig.JustificationPattern = `JIRA-\d+`
+			Expect(cl.Update(ctx, tmpl)).To(Succeed())
+
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			request.Spec.Justification = justification
+			Expect(cl.Update(ctx, request)).To(Succeed())
+		}
+
+		// verify runs verifyJustification() against the request and its
+		// template, and returns the request afterwards.
+		verify := func() *v1alpha1.ExecAccessRequest {
+			rctx := newRequestContext(ctx, r.RequestType, reconcile.Request{NamespacedName: key})
+			Expect(r.fetchRequestObject(rctx)).To(Succeed())
+			tmpl, err := rctx.obj.GetTemplate(ctx, cl)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.verifyJustification(rctx, tmpl)).To(Succeed())
+
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			return request
+		}
+
+		BeforeEach(func() {
+			cl = newExecAccessClient(key, now)
+			r = &RequestReconciler{
+				Client:      cl,
+				Scheme:      scheme.Scheme,
+				APIReader:   cl,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &execaccessbuilder.ExecAccessBuilder{},
+				now:         func() time.Time { return now },
+			}
+		})
+
+		It("Should allow any justification without a pattern", func() {
+			request := verify()
+			Expect(meta.FindStatusCondition(
+				request.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+			)).To(BeNil())
+		})
+
+		It("Should allow a conforming justification", func() {
+			setJustification("JIRA-1234")
+			request := verify()
+			Expect(meta.FindStatusCondition(
+				request.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+			)).To(BeNil())
+
+			By("Granting the access")
+			for i := 0; i < 3; i++ {
+				_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			Expect(request.Status.IsReady()).To(BeTrue())
+		})
+
+		It("Should deny a non-conforming justification", func() {
+			setJustification("see JIRA-1234")
+			request := verify()
+			cond := meta.FindStatusCondition(
+				request.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessDenied))
+			Expect(cond.Message).To(Equal(
+				`Access denied by template web: justification "see JIRA-1234" does not match "JIRA-\\d+"`,
+			))
+		})
+
+		It("Should deny a missing justification, and never grant the access", func() {
+			setJustification("")
+			for i := 0; i < 3; i++ {
+				_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			}
+
+			By("Cleaning up the denied request")
+			err := cl.Get(ctx, key, &v1alpha1.ExecAccessRequest{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			bindings := &rbacv1.RoleBindingList{}
+			Expect(cl.List(ctx, bindings, client.InNamespace(key.Namespace))).To(Succeed())
+			Expect(bindings.Items).To(BeEmpty())
+		})
+
+		It("Should leave access that was already granted alone", func() {
+			for i := 0; i < 3; i++ {
+				_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			}
+			setJustification("")
+			request := verify()
+			Expect(request.Status.IsReady()).To(BeTrue())
+			Expect(meta.IsStatusConditionFalse(
+				request.Status.Conditions, v1alpha1.ConditionAccessStillValid.String(),
+			)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,45 @@
+package requestcontroller
+
+import (
+	"fmt"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+)
+
+// verifyNotDenied checks the requester, the beneficiary (spec.requestFor) and
+// the transferee (spec.transferTo) of the request against the deniedUsers of
+// the OzConfig of its namespace. If any of them is denied, the
+// ConditionAccessStillValid condition is flipped to False, so that
+// isAccessExpired() tears down any access that was already granted.
+func (r *RequestReconciler) verifyNotDenied(rctx *RequestContext) error {
+	cfg := rctx.namespaceConfig
+	if cfg == nil {
+		return nil
+	}
+
+	rctx.log.V(1).Info("Checking Access Request against the namespace deny-list...")
+	user, denied := cfg.GetDeniedUser(
+		v1alpha1.GetRequester(rctx.obj),
+		rctx.obj.GetRequestFor(),
+		rctx.obj.GetTransferTo(),
+	)
+	if !denied {
+		return nil
+	}
+
+	message := deniedUserMsg(cfg, user)
+	rctx.log.Info(message)
+	if err := status.SetAccessDenied(rctx.Context, r, rctx.obj, message); err != nil {
+		return err
+	}
+	return r.notifyRequester(rctx, notify.EventDenied, message)
+}
+
+// deniedUserMsg explains why a request involving a user on the deniedUsers
+// list of the supplied OzConfig was denied.
+func deniedUserMsg(cfg *v1alpha1.OzConfig, user string) string {
+	return fmt.Sprintf("Access denied by OzConfig %s/%s: %s is a denied user",
+		cfg.GetNamespace(), cfg.GetName(), user)
+}
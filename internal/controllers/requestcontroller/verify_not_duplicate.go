@@ -0,0 +1,163 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/ctrlrequeue"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// verifyNotDuplicate checks whether an earlier Access Request from the same
+// user already asks for the same access (see IRequestResource.IsEquivalentTo).
+// If so, this request is marked as a duplicate of it in Status.DuplicateOf and
+// reconciliation ends before any access resources are created. The duplicate
+// still expires on its own schedule, and takes over as a regular request if
+// the original goes away first.
+//
+// Requests that have already created their access resources are never turned
+// into duplicates.
+func (r *RequestReconciler) verifyNotDuplicate(
+	rctx *RequestContext,
+) (shouldEndReconcile bool, result ctrl.Result, resultErr error) {
+	rctx.log.V(1).Info("Checking for duplicate Access Requests...")
+	original, err := r.findOriginalRequest(rctx)
+	if err != nil {
+		return true, result, err
+	}
+
+	reqStatus := rctx.obj.GetStatus().(v1alpha1.IRequestStatus)
+	if original == nil {
+		if reqStatus.GetDuplicateOf() == "" {
+			// Keep a condition left over from an earlier duplicate current.
+			if meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(), v1alpha1.ConditionRequestUnique.String(),
+			) != nil {
+				return false, result, status.SetRequestUnique(rctx.Context, r, rctx.obj)
+			}
+			return false, result, nil
+		}
+		rctx.log.Info(fmt.Sprintf("%s is gone, no longer a duplicate", reqStatus.GetDuplicateOf()))
+		reqStatus.SetDuplicateOf("")
+		reqStatus.SetAccessMessage("")
+		return false, result, status.SetRequestUnique(rctx.Context, r, rctx.obj)
+	}
+
+	rctx.log.Info(fmt.Sprintf("Duplicate of %s, not creating access resources", original.GetName()))
+	reqStatus.SetDuplicateOf(original.GetName())
+	reqStatus.SetAccessMessage(fmt.Sprintf(
+		"Duplicate request, use the access granted by %s instead", original.GetName(),
+	))
+	if err := status.SetRequestDuplicate(rctx.Context, r, rctx.obj, original.GetName()); err != nil {
+		return true, result, err
+	}
+	if err := status.SetReadyStatus(rctx, r, rctx.obj); err != nil {
+		return true, result, err
+	}
+
+	// Keep checking back, so that the request still expires - or takes over
+	// from the original if that goes away first.
+	result, resultErr = ctrlrequeue.RequeueAfter(r.ReconciliationInterval)
+	return true, result, resultErr
+}
+
+// findOriginalRequest returns the active Access Request that this request is a
+// duplicate of, or nil. Candidates are ordered so that every equivalent
+// request picks the same original, even when they are reconciled concurrently:
+// requests that already created their access resources come first, then the
+// oldest, then by name.
+func (r *RequestReconciler) findOriginalRequest(
+	rctx *RequestContext,
+) (v1alpha1.IRequestResource, error) {
+	requester := v1alpha1.GetRequester(rctx.obj)
+	// Requests adopting a RoleBinding each manage a different one, so they
+	// are never duplicates.
+	if requester == "" || accessResourcesCreated(rctx.obj) ||
+		v1alpha1.GetAdoptedRoleBinding(rctx.obj) != "" {
+		return nil, nil
+	}
+
+	// Use the non-cached reader, so that a request created moments ago by the
+	// same user is not missed.
+	gvk, err := apiutil.GVKForObject(rctx.obj, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	gvk.Kind += "List"
+	listObj, err := r.Scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	list := listObj.(client.ObjectList)
+	if err := r.APIReader.List(rctx.Context, list, client.InNamespace(rctx.obj.GetNamespace())); err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []v1alpha1.IRequestResource{rctx.obj}
+	for _, item := range items {
+		other, ok := item.(v1alpha1.IRequestResource)
+		if !ok || other.GetUID() == rctx.obj.GetUID() {
+			continue
+		}
+		if v1alpha1.GetRequester(other) != requester ||
+			v1alpha1.GetAdoptedRoleBinding(other) != "" ||
+			!rctx.obj.IsEquivalentTo(other) ||
+			!isActiveOriginal(other) {
+			continue
+		}
+		candidates = append(candidates, other)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if ac, bc := accessResourcesCreated(a), accessResourcesCreated(b); ac != bc {
+			return ac
+		}
+		if at, bt := a.GetCreationTimestamp(), b.GetCreationTimestamp(); !at.Equal(&bt) {
+			return at.Before(&bt)
+		}
+		return a.GetName() < b.GetName()
+	})
+
+	if candidates[0].GetUID() == rctx.obj.GetUID() {
+		return nil, nil
+	}
+	return candidates[0], nil
+}
+
+// isActiveOriginal returns true if the request could be granting access right
+// now - as opposed to being deleted, revoked, expired or a duplicate itself.
+func isActiveOriginal(req v1alpha1.IRequestResource) bool {
+	if !req.GetDeletionTimestamp().IsZero() {
+		return false
+	}
+	if _, revoked := req.GetAnnotations()[v1alpha1.RevokeAnnotation]; revoked {
+		return false
+	}
+	if req.GetStatus().(v1alpha1.IRequestStatus).GetDuplicateOf() != "" {
+		return false
+	}
+	return !meta.IsStatusConditionFalse(
+		*req.GetStatus().GetConditions(), v1alpha1.ConditionAccessStillValid.String(),
+	)
+}
+
+// accessResourcesCreated returns true if the access resources of the request
+// have already been created.
+func accessResourcesCreated(req v1alpha1.IRequestResource) bool {
+	return meta.IsStatusConditionPresentAndEqual(
+		*req.GetStatus().GetConditions(),
+		v1alpha1.ConditionAccessResourcesCreated.String(),
+		metav1.ConditionTrue,
+	)
+}
@@ -0,0 +1,200 @@
+package requestcontroller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		verifyNotDuplicate() Tests
+	*/
+	Context("verifyNotDuplicate()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			reconciler *RequestReconciler
+			builder    *mockBuilder
+		)
+
+		// newRequest creates an ExecAccessRequest, recording the requester the
+		// same way that the mutating webhook would.
+		newRequest := func(name string, requester string, targetPod string) *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        name,
+					Namespace:   ns.GetName(),
+					Annotations: map[string]string{v1alpha1.RequestedByAnnotation: requester},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: "bogus",
+					TargetPod:    targetPod,
+				},
+			}
+			err := k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			return request
+		}
+
+		newContext := func(request *v1alpha1.ExecAccessRequest) *RequestContext {
+			rctx := newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err := reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		BeforeEach(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(k8sClient.Delete, ctx, ns)
+
+			By("Creating the RequestReconciler")
+			builder = &mockBuilder{
+				getTemplateResp:             &v1alpha1.ExecAccessTemplate{},
+				getDurationResp:             time.Hour,
+				createResourcesResp:         "Role XYZ created",
+				accessResourcesAreReadyResp: true,
+			}
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                builder,
+				ReconciliationInterval: time.Minute,
+			}
+		})
+
+		It("verifyNotDuplicate() should ignore requests from other users or for other targets", func() {
+			newRequest("first", "alice", "pod-a")
+			newRequest("second", "bob", "pod-a")
+			third := newRequest("third", "alice", "pod-b")
+
+			shouldEndReconcile, _, err := reconciler.verifyNotDuplicate(newContext(third))
+
+			// VERIFY: Not a duplicate
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("verifyNotDuplicate() should ignore requests without a recorded requester", func() {
+			newRequest("first", "", "pod-a")
+			second := newRequest("second", "", "pod-a")
+
+			shouldEndReconcile, _, err := reconciler.verifyNotDuplicate(newContext(second))
+
+			// VERIFY: Not a duplicate
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("verifyNotDuplicate() should mark a newer equivalent request as a duplicate, until the original is gone", func() {
+			first := newRequest("first", "alice", "pod-a")
+			second := newRequest("second", "alice", "pod-a")
+			rctx := newContext(second)
+
+			shouldEndReconcile, _, err := reconciler.verifyNotDuplicate(rctx)
+
+			// VERIFY: End the reconcile, and point at the original
+			Expect(shouldEndReconcile).To(BeTrue())
+			Expect(err).ToNot(HaveOccurred())
+			reqStatus := rctx.obj.GetStatus().(v1alpha1.IRequestStatus)
+			Expect(reqStatus.GetDuplicateOf()).To(Equal("first"))
+			Expect(reqStatus.GetAccessMessage()).To(ContainSubstring("use the access granted by first"))
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionRequestUnique.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(rctx.obj.GetStatus().IsReady()).To(BeFalse())
+
+			By("Deleting the original request")
+			err = k8sClient.Delete(ctx, first)
+			Expect(err).ToNot(HaveOccurred())
+
+			shouldEndReconcile, _, err = reconciler.verifyNotDuplicate(rctx)
+
+			// VERIFY: The duplicate takes over
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reqStatus.GetDuplicateOf()).To(BeEmpty())
+			Expect(meta.IsStatusConditionTrue(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionRequestUnique.String(),
+			)).To(BeTrue())
+		})
+
+		It("Reconcile() should grant access only once for concurrently created duplicates", func() {
+			requests := []*v1alpha1.ExecAccessRequest{
+				newRequest("concurrent-b", "alice", "pod-a"),
+				newRequest("concurrent-a", "alice", "pod-a"),
+			}
+
+			var wg sync.WaitGroup
+			errs := make([]error, len(requests))
+			for i, request := range requests {
+				wg.Add(1)
+				go func(i int, request *v1alpha1.ExecAccessRequest) {
+					defer wg.Done()
+					_, errs[i] = reconciler.Reconcile(ctx, reconcile.Request{
+						NamespacedName: types.NamespacedName{
+							Name:      request.GetName(),
+							Namespace: request.GetNamespace(),
+						},
+					})
+				}(i, request)
+			}
+			wg.Wait()
+			Expect(errs).To(HaveEach(BeNil()))
+
+			// VERIFY: Only one set of access resources was created
+			Expect(builder.createResourcesCalls).To(HaveLen(1))
+			original := builder.createResourcesCalls[0]
+
+			// VERIFY: The other request references the one that was granted
+			duplicates := []string{}
+			for _, request := range requests {
+				err := k8sClient.Get(ctx, types.NamespacedName{
+					Name:      request.GetName(),
+					Namespace: request.GetNamespace(),
+				}, request)
+				Expect(err).ToNot(HaveOccurred())
+				if request.Status.DuplicateOf != "" {
+					Expect(request.Status.DuplicateOf).To(Equal(original))
+					duplicates = append(duplicates, request.GetName())
+				}
+			}
+			Expect(duplicates).To(HaveLen(1))
+			Expect(duplicates[0]).ToNot(Equal(original))
+		})
+	})
+})
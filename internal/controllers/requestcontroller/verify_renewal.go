@@ -0,0 +1,128 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/ctrlrequeue"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// clampToApprovedRenewal holds back the extension of a request whose access
+// has already been granted (ie, it has a Status.AccessExpiresAt), when the
+// extension is longer than the renewalApprovalThreshold of the template.
+// Until the users that approved the request since its access was granted
+// reach the requiredApprovals of the template (or one, if it does not
+// otherwise require approvals), the access duration is clamped to when the
+// access was originally granted until, and rctx.renewalPending is set so that
+// verifyApprovals() waits on the approval. The decision is extended to
+// explain any clamping.
+//
+// If the threshold cannot be parsed, every extension requires a fresh
+// approval.
+func (r *RequestReconciler) clampToApprovedRenewal(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+	accessDuration time.Duration,
+	decision string,
+) (time.Duration, string, error) {
+	reqStatus := rctx.obj.GetStatus().(v1alpha1.IRequestStatus)
+	grantedUntil := reqStatus.GetAccessExpiresAt()
+	cfg := tmpl.GetAccessConfig()
+	if grantedUntil == nil || cfg.RenewalApprovalThreshold == "" {
+		return accessDuration, decision, nil
+	}
+
+	threshold, err := cfg.GetRenewalApprovalThreshold()
+	if err != nil {
+		// We cannot tell how large an extension may be, so require approval.
+		rctx.log.Error(err, "Failed to parse renewalApprovalThreshold")
+		threshold = 0
+	}
+
+	created := rctx.obj.GetCreationTimestamp().Time
+	extendedUntil := created.Add(accessDuration)
+	extension := extendedUntil.Sub(grantedUntil.Time)
+	if extension <= threshold {
+		return accessDuration, decision, nil
+	}
+
+	required := cfg.GetRequiredApprovals()
+	if required < 1 {
+		required = 1
+	}
+	var approvers []string
+	if all := v1alpha1.GetApprovers(rctx.obj); reqStatus.GetApproversAtGrant() < len(all) {
+		approvers = all[reqStatus.GetApproversAtGrant():]
+	}
+
+	if len(approvers) >= required {
+		decision = fmt.Sprintf("%s, extended by %s with the approval of %s",
+			decision, extension, strings.Join(approvers, ", "))
+		return accessDuration, decision, status.SetAccessApproved(rctx.Context, r, rctx.obj,
+			fmt.Sprintf("Extension approved by %s", strings.Join(approvers, ", ")))
+	}
+
+	rctx.renewalPending = fmt.Sprintf(
+		"Waiting on approvals of the extension until %s: %d of %d received",
+		extendedUntil.Format(time.RFC3339), len(approvers), required,
+	)
+	decision = fmt.Sprintf("%s, held at %s until the extension of %s is approved",
+		decision, grantedUntil.Format(time.RFC3339), extension)
+	return grantedUntil.Sub(created), decision, nil
+}
+
+// clampToApprovedWindow makes sure that the access of a request never
+// outlasts the window covered by its approval (see
+// v1alpha1.GetApprovedUntil), so that an extension can not silently outlive
+// what the approvers agreed to. Extending past the window takes a fresh
+// approval for a longer one. The decision is extended to explain any
+// clamping.
+func (r *RequestReconciler) clampToApprovedWindow(
+	rctx *RequestContext,
+	accessDuration time.Duration,
+	decision string,
+) (time.Duration, string) {
+	approvedUntil, ok := v1alpha1.GetApprovedUntil(rctx.obj)
+	if !ok {
+		return accessDuration, decision
+	}
+
+	created := rctx.obj.GetCreationTimestamp().Time
+	if !created.Add(accessDuration).After(approvedUntil) {
+		return accessDuration, decision
+	}
+	window := approvedUntil.Sub(created)
+	if window < 0 {
+		window = 0
+	}
+	rctx.log.Info("Refusing to extend the access past the approved window",
+		"approvedUntil", approvedUntil.Format(time.RFC3339))
+	return window, fmt.Sprintf("%s, clamped to the window approved until %s",
+		decision, approvedUntil.Format(time.RFC3339))
+}
+
+// waitOnRenewalApproval flips the ConditionAccessApproved condition of a
+// request whose extension is waiting on a fresh approval to False, and ends
+// reconciliation until it is approved. The access that was already granted
+// still expires on time.
+func (r *RequestReconciler) waitOnRenewalApproval(
+	rctx *RequestContext,
+) (shouldEndReconcile bool, result ctrl.Result, resultErr error) {
+	rctx.log.Info(rctx.renewalPending)
+	if err := status.SetAccessRenewalNotApproved(rctx.Context, r, rctx.obj, rctx.renewalPending); err != nil {
+		return true, result, err
+	}
+	if err := status.SetReadyStatus(rctx, r, rctx.obj); err != nil {
+		return true, result, err
+	}
+
+	// Approvals trigger a reconcile on their own, but keep checking back so
+	// that the access still expires if nobody ever approves the extension.
+	result, resultErr = ctrlrequeue.RequeueAfter(r.ReconciliationInterval)
+	return true, result, resultErr
+}
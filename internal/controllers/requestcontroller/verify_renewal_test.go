@@ -0,0 +1,220 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		clampToApprovedRenewal() Tests
+	*/
+	Context("RenewalApprovalThreshold", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			builder    = &mockBuilder{}
+		)
+
+		// getRctx returns a freshly populated RequestContext for the named
+		// ExecAccessRequest.
+		getRctx := func(name string) *RequestContext {
+			rctx := newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      name,
+						Namespace: ns.GetName(),
+					},
+				},
+			)
+			Expect(reconciler.fetchRequestObject(rctx)).To(Succeed())
+			return rctx
+		}
+
+		// newGrantedRctx creates an ExecAccessRequest that was approved by
+		// alice and granted access for an hour, and returns a populated
+		// RequestContext for it.
+		newGrantedRctx := func() *RequestContext {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        utils.RandomString(8),
+					Namespace:   ns.GetName(),
+					Annotations: map[string]string{v1alpha1.ApprovedByAnnotation: "alice"},
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			Expect(k8sClient.Create(ctx, request)).To(Succeed())
+
+			expiresAt := metav1.NewTime(request.GetCreationTimestamp().Add(time.Hour))
+			request.Status.SetAccessExpiresAt(&expiresAt)
+			request.Status.SetApproversAtGrant(1)
+			Expect(k8sClient.Status().Update(ctx, request)).To(Succeed())
+			return getRctx(request.GetName())
+		}
+
+		approvedCondition := func(rctx *RequestContext) *metav1.Condition {
+			return meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessApproved.String(),
+			)
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:            []string{"foo"},
+						DefaultDuration:          "1h",
+						MaxDuration:              "4h",
+						RequiredApprovals:        1,
+						RenewalApprovalThreshold: "30m",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+
+			By("Creating the RequestReconciler")
+			reconciler = &RequestReconciler{
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				APIReader:   k8sClient,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     builder,
+			}
+		})
+
+		BeforeEach(func() {
+			builder.getDurationErr = nil
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("Should grant a small extension without a fresh approval", func() {
+			rctx := newGrantedRctx()
+
+			// Extend the access by 15m, under the 30m threshold
+			builder.getDurationResp = time.Hour + 15*time.Minute
+			shouldEndReconcile, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(rctx.renewalPending).To(BeEmpty())
+			Expect(rctx.expiresAt).To(Equal(rctx.obj.GetCreationTimestamp().Add(time.Hour + 15*time.Minute)))
+
+			// The original approval still stands
+			shouldEndReconcile, _, err = reconciler.verifyApprovals(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(approvedCondition(rctx).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("Should hold a large extension until it is approved again", func() {
+			rctx := newGrantedRctx()
+
+			// Extend the access by 2h, over the 30m threshold
+			builder.getDurationResp = 3 * time.Hour
+			shouldEndReconcile, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(rctx.renewalPending).To(ContainSubstring("0 of 1 received"))
+			Expect(rctx.expiresAt).To(Equal(rctx.obj.GetCreationTimestamp().Add(time.Hour)))
+
+			// The approval is reset, even though alice already approved the request
+			shouldEndReconcile, _, err = reconciler.verifyApprovals(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeTrue())
+			cond := approvedCondition(rctx)
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessRenewalPendingApproval))
+
+			// Bob approves the request after it was granted
+			rctx.obj.SetAnnotations(map[string]string{v1alpha1.ApprovedByAnnotation: "alice,bob"})
+			Expect(k8sClient.Update(ctx, rctx.obj)).To(Succeed())
+
+			rctx = getRctx(rctx.obj.GetName())
+			shouldEndReconcile, _, err = reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(rctx.renewalPending).To(BeEmpty())
+			Expect(rctx.expiresAt).To(Equal(rctx.obj.GetCreationTimestamp().Add(3 * time.Hour)))
+
+			shouldEndReconcile, _, err = reconciler.verifyApprovals(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(approvedCondition(rctx).Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		// approveUntil records that the approval of the request covers its
+		// access until the supplied time after it was created.
+		approveUntil := func(rctx *RequestContext, window time.Duration) *RequestContext {
+			approvedUntil := rctx.obj.GetCreationTimestamp().Add(window).UTC().Format(time.RFC3339)
+			rctx.obj.SetAnnotations(map[string]string{
+				v1alpha1.ApprovedByAnnotation:    "alice",
+				v1alpha1.ApprovedUntilAnnotation: approvedUntil,
+			})
+			Expect(k8sClient.Update(ctx, rctx.obj)).To(Succeed())
+			return getRctx(rctx.obj.GetName())
+		}
+
+		It("Should grant an extension within the approved window", func() {
+			rctx := approveUntil(newGrantedRctx(), 2*time.Hour)
+
+			builder.getDurationResp = time.Hour + 15*time.Minute
+			shouldEndReconcile, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(rctx.expiresAt).To(Equal(rctx.obj.GetCreationTimestamp().Add(time.Hour + 15*time.Minute)))
+		})
+
+		It("Should refuse to extend the access beyond the approved window", func() {
+			rctx := approveUntil(newGrantedRctx(), time.Hour+10*time.Minute)
+
+			// Extend by 20m - under the renewal threshold, but past the window
+			builder.getDurationResp = time.Hour + 20*time.Minute
+			shouldEndReconcile, _, err := reconciler.verifyDuration(rctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(shouldEndReconcile).To(BeFalse())
+			Expect(rctx.expiresAt).To(Equal(rctx.obj.GetCreationTimestamp().Add(time.Hour + 10*time.Minute)))
+
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionRequestDurationsValid.String(),
+			)
+			Expect(cond.Message).To(ContainSubstring("clamped to the window approved until"))
+		})
+	})
+})
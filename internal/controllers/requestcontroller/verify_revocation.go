@@ -0,0 +1,48 @@
+package requestcontroller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+)
+
+// verifyRevocation checks whether a user has revoked the request with the
+// RevokeAnnotation. If so, the ConditionAccessStillValid condition is flipped
+// to False, so that isAccessExpired() tears the access down right away.
+//
+// The mutating webhook already rejects revocations without a reason when the
+// template requires one. Should one slip through anyway (for example, if the
+// template was changed afterwards), it is ignored here rather than honoured.
+// Without a template (see verifyKeptAccess()), every revocation is honoured.
+func (r *RequestReconciler) verifyRevocation(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+) error {
+	reason, revoked := rctx.obj.GetAnnotations()[v1alpha1.RevokeAnnotation]
+	if !revoked {
+		return nil
+	}
+
+	rctx.log.V(1).Info("Checking Access Request revocation...")
+	reason = strings.TrimSpace(reason)
+	if reason == "" && tmpl != nil && tmpl.GetAccessConfig().IsRevokeReasonRequired() {
+		rctx.log.Info(fmt.Sprintf(
+			"Ignoring revocation without a reason, template %s requires one",
+			tmpl.GetName(),
+		))
+		return nil
+	}
+
+	message := fmt.Sprintf("Access revoked by %s", rctx.obj.GetAnnotations()[v1alpha1.RevokedByAnnotation])
+	if reason != "" {
+		message = fmt.Sprintf("%s: %s", message, reason)
+	}
+	rctx.log.Info(message)
+	if err := status.SetAccessRevoked(rctx.Context, r, rctx.obj, message); err != nil {
+		return err
+	}
+	return r.notifyRequester(rctx, notify.EventRevoked, message)
+}
@@ -0,0 +1,165 @@
+package requestcontroller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		verifyRevocation() Tests
+	*/
+	Context("verifyRevocation()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			request    *v1alpha1.ExecAccessRequest
+			template   *v1alpha1.ExecAccessTemplate
+			reconciler *RequestReconciler
+			rctx       *RequestContext
+		)
+
+		// setRevocation writes the revoke annotations the same way that the
+		// mutating webhook would, and re-populates the rctx.obj.
+		setRevocation := func(reason string) {
+			rctx.obj.SetAnnotations(map[string]string{
+				v1alpha1.RevokeAnnotation:    reason,
+				v1alpha1.RevokedByAnnotation: "alice",
+			})
+			err := k8sClient.Update(ctx, rctx.obj)
+			Expect(err).ToNot(HaveOccurred())
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		accessStillValid := func() *metav1.Condition {
+			return meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessTemplate that requires a reason to revoke")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:       []string{"foo"},
+						DefaultDuration:     "1h",
+						MaxDuration:         "2h",
+						RequireRevokeReason: true,
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have an ExecAccessRequest built to test against")
+			request = &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "verifyrevocation-test",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			err = k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler")
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                &mockBuilder{},
+				ReconciliationInterval: 0,
+			}
+
+			By("Creating the RequestContext")
+			rctx = newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+
+			By("Populuating the rctx.obj object...")
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).To(BeNil())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("verifyRevocation() should do nothing if the request is not revoked", func() {
+			err := reconciler.verifyRevocation(rctx, template)
+
+			// VERIFY: No condition is set
+			Expect(err).ToNot(HaveOccurred())
+			Expect(accessStillValid()).To(BeNil())
+		})
+
+		It("verifyRevocation() should ignore a revocation without a required reason", func() {
+			setRevocation("")
+
+			err := reconciler.verifyRevocation(rctx, template)
+
+			// VERIFY: No condition is set, the access is left alone
+			Expect(err).ToNot(HaveOccurred())
+			Expect(accessStillValid()).To(BeNil())
+		})
+
+		It("verifyRevocation() should revoke the access with a reason", func() {
+			setRevocation("incident resolved")
+
+			err := reconciler.verifyRevocation(rctx, template)
+
+			// VERIFY: The access is no longer valid, and says why
+			Expect(err).ToNot(HaveOccurred())
+			cond := accessStillValid()
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessRevoked))
+			Expect(cond.Message).To(Equal("Access revoked by alice: incident resolved"))
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package requestcontroller
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+)
+
+// verifyTargetPod makes sure that the Pod that access was granted to is still
+// the same Pod. Pods are frequently replaced (for example, a StatefulSet Pod
+// is recreated with the same name), and the RBAC rules granted to a request
+// are scoped to the Pod name only. If the UID of the Pod no longer matches
+// the UID recorded in the status at grant time, the ConditionAccessStillValid
+// condition is flipped to False so that isAccessExpired() tears the access
+// down.
+//
+// Requests that have not been assigned a Pod yet, or that were granted before
+// the UID was recorded, are skipped. A Pod that is gone entirely is left to
+// the builder to deal with.
+func (r *RequestReconciler) verifyTargetPod(rctx *RequestContext) error {
+	podReq, ok := rctx.obj.(v1alpha1.IPodRequestResource)
+	if !ok || podReq.GetPodName() == "" || podReq.GetPodUID() == "" {
+		return nil
+	}
+
+	rctx.log.V(1).Info("Verifying that the Target Pod has not been replaced...")
+	pod := &corev1.Pod{}
+	err := r.Get(rctx.Context, types.NamespacedName{
+		Name:      podReq.GetPodName(),
+		Namespace: podReq.GetNamespace(),
+	}, pod)
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if pod.GetUID() == podReq.GetPodUID() {
+		return nil
+	}
+
+	message := fmt.Sprintf("Target Pod %s was replaced (uid %s, expected %s), access revoked",
+		pod.GetName(), pod.GetUID(), podReq.GetPodUID())
+	rctx.log.Info(message)
+	if err := status.SetAccessTargetPodReplaced(rctx.Context, r, rctx.obj, message); err != nil {
+		return err
+	}
+	return r.notifyRequester(rctx, notify.EventRevoked, message)
+}
@@ -0,0 +1,151 @@
+package requestcontroller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		verifyTargetPod() Tests
+	*/
+	Context("verifyTargetPod()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			pod        *v1.Pod
+			reconciler *RequestReconciler
+		)
+
+		// newRctx creates an ExecAccessRequest that was granted access to the
+		// target Pod with the supplied UID, and returns a populated
+		// RequestContext for it.
+		newRctx := func(podName string, uid types.UID) *RequestContext {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: "fake",
+				},
+			}
+			err := k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			request.Status.PodName = podName
+			request.Status.PodUID = uid
+			err = k8sClient.Status().Update(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			rctx := newRequestContext(
+				ctx,
+				reconciler.RequestType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      request.GetName(),
+						Namespace: request.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: utils.RandomString(8)}}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have a target Pod")
+			pod = &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "target",
+					Namespace: ns.GetName(),
+				},
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{{Name: "test", Image: "nginx:latest"}},
+				},
+			}
+			err = k8sClient.Create(ctx, pod)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler")
+			reconciler = &RequestReconciler{
+				Client:      k8sClient,
+				Scheme:      k8sClient.Scheme(),
+				APIReader:   k8sClient,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &mockBuilder{},
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+		})
+
+		It("Should leave access to the same Pod alone", func() {
+			rctx := newRctx(pod.GetName(), pod.GetUID())
+
+			err := reconciler.verifyTargetPod(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)).To(BeNil())
+		})
+
+		It("Should skip requests granted before the Pod UID was recorded", func() {
+			rctx := newRctx(pod.GetName(), "")
+
+			err := reconciler.verifyTargetPod(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)).To(BeNil())
+		})
+
+		It("Should skip a target Pod that no longer exists", func() {
+			rctx := newRctx("missing", "1234")
+
+			err := reconciler.verifyTargetPod(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)).To(BeNil())
+		})
+
+		It("Should revoke access when the Pod has been replaced", func() {
+			rctx := newRctx(pod.GetName(), "an-older-pod-uid")
+
+			err := reconciler.verifyTargetPod(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The access is flipped to invalid, so that it is torn down
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionAccessStillValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessTargetPodReplaced))
+			Expect(cond.Message).To(ContainSubstring("Target Pod target was replaced"))
+		})
+	})
+})
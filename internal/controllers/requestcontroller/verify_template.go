@@ -35,7 +35,23 @@ func (r *RequestReconciler) verifyTemplate(
 	}
 
 	// UPDATE: Set the OwnerReference for the request - so if the template is
-	// deleted, all requests are deleted.
+	// deleted, all requests are deleted. OwnerReferences cannot cross
+	// namespaces, so requests using a template shared from another namespace
+	// are left without one.
+	if rctx.obj.GetTemplateNamespace() != rctx.obj.GetNamespace() {
+		return tmpl, nil
+	}
+
+	// When access outlives the template (see KeepOnTemplateDeleted), the
+	// request must not be garbage collected along with it - so it is released
+	// from the template instead.
+	if r.OnTemplateDeleted == KeepOnTemplateDeleted {
+		if err := r.releaseFromTemplate(rctx, tmpl); err != nil {
+			rctx.log.Error(err, "Error releasing the request from its template")
+			return nil, err
+		}
+		return tmpl, nil
+	}
 	if err := r.Builder.SetRequestOwnerReference(rctx.Context, r.Client, rctx.obj, tmpl); err != nil {
 		rctx.log.Error(err, "Error setting owner reference")
 		return nil, err
@@ -0,0 +1,64 @@
+package requestcontroller
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/notify"
+)
+
+// verifyTemplateAllows denies the request if the template does not allow its
+// requester (or the user it was transferred to) through allowedRequesters, or
+// its namespace through allowedNamespaces. The webhook already checks both
+// when the request is created or its spec changes - this catches the requests
+// that got past it (eg, created while the webhook was unavailable), and those
+// whose template has been narrowed since. The ConditionAccessStillValid
+// condition is flipped to False, so that isAccessExpired() tears down any
+// access that was already granted.
+func (r *RequestReconciler) verifyTemplateAllows(
+	rctx *RequestContext,
+	tmpl v1alpha1.ITemplateResource,
+) error {
+	conditions := *rctx.obj.GetStatus().GetConditions()
+	if meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionAccessStillValid.String()) {
+		return nil
+	}
+
+	message := templateDisallowsMsg(rctx.obj, tmpl)
+	if message == "" {
+		return nil
+	}
+
+	rctx.log.Info(message)
+	if err := status.SetAccessDenied(rctx.Context, r, rctx.obj, message); err != nil {
+		return err
+	}
+	return r.notifyRequester(rctx, notify.EventDenied, message)
+}
+
+// templateDisallowsMsg explains why the supplied template does not allow the
+// request, or returns an empty string if it does.
+func templateDisallowsMsg(req v1alpha1.IRequestResource, tmpl v1alpha1.ITemplateResource) string {
+	cfg := tmpl.GetAccessConfig()
+	if !cfg.AllowsNamespace(req.GetNamespace()) {
+		return fmt.Sprintf("Access denied by template %s: requests from namespace %s are not allowed",
+			tmpl.GetName(), req.GetNamespace())
+	}
+
+	requesters := cfg.GetAllowedRequesters()
+	if requesters == nil {
+		return ""
+	}
+	if requester := v1alpha1.GetRequester(req); !requesters.Allows(requester, v1alpha1.GetRequesterGroups(req)) {
+		return fmt.Sprintf("Access denied by template %s: %q is not an allowed requester",
+			tmpl.GetName(), requester)
+	}
+	if user := req.GetTransferTo(); user != "" && !requesters.Allows(user, nil) {
+		return fmt.Sprintf("Access denied by template %s: %q is not an allowed requester",
+			tmpl.GetName(), user)
+	}
+	return ""
+}
@@ -0,0 +1,137 @@
+package requestcontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+var _ = Describe("RequestReconciler", func() {
+	Context("verifyTemplateAllows()", func() {
+		var (
+			ctx = context.Background()
+			now = time.Now().UTC().Truncate(time.Second)
+			key = types.NamespacedName{Name: "debug", Namespace: "allowed"}
+			cl  client.Client
+			r   *RequestReconciler
+		)
+
+		// updateTemplate applies the supplied change to the template of the
+		// request.
+		updateTemplate := func(change func(cfg *v1alpha1.AccessConfig)) {
+			tmpl := &v1alpha1.ExecAccessTemplate{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "web", Namespace: key.Namespace}, tmpl)).To(Succeed())
+			change(&tmpl.Spec.AccessConfig)
+			Expect(cl.Update(ctx, tmpl)).To(Succeed())
+		}
+
+		// verify runs verifyTemplateAllows() against the request and its
+		// template, and returns the ConditionAccessStillValid condition
+		// afterwards.
+		verify := func() *metav1.Condition {
+			rctx := newRequestContext(ctx, r.RequestType, reconcile.Request{NamespacedName: key})
+			Expect(r.fetchRequestObject(rctx)).To(Succeed())
+			tmpl, err := rctx.obj.GetTemplate(ctx, cl)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.verifyTemplateAllows(rctx, tmpl)).To(Succeed())
+
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			return meta.FindStatusCondition(request.Status.Conditions, v1alpha1.ConditionAccessStillValid.String())
+		}
+
+		BeforeEach(func() {
+			cl = newExecAccessClient(key, now)
+			r = &RequestReconciler{
+				Client:      cl,
+				Scheme:      scheme.Scheme,
+				APIReader:   cl,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &execaccessbuilder.ExecAccessBuilder{},
+				now:         func() time.Time { return now },
+			}
+		})
+
+		It("Should allow a request that the template does not limit", func() {
+			Expect(verify()).To(BeNil())
+		})
+
+		It("Should allow a request from an allowed requester and namespace", func() {
+			updateTemplate(func(cfg *v1alpha1.AccessConfig) {
+				cfg.AllowedRequesters = &v1alpha1.AllowedRequesters{Users: []string{"alice"}}
+				cfg.AllowedNamespaces = []string{"allow*"}
+			})
+			Expect(verify()).To(BeNil())
+		})
+
+		It("Should deny a request from a requester that is not allowed", func() {
+			updateTemplate(func(cfg *v1alpha1.AccessConfig) {
+				cfg.AllowedRequesters = &v1alpha1.AllowedRequesters{Groups: []string{"admins"}}
+			})
+			cond := verify()
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonAccessDenied))
+			Expect(cond.Message).To(Equal(`Access denied by template web: "alice" is not an allowed requester`))
+		})
+
+		It("Should deny a request transferred to a user that is not allowed", func() {
+			updateTemplate(func(cfg *v1alpha1.AccessConfig) {
+				cfg.AllowedRequesters = &v1alpha1.AllowedRequesters{Users: []string{"alice"}}
+			})
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			request.Spec.TransferTo = "mallory"
+			Expect(cl.Update(ctx, request)).To(Succeed())
+
+			cond := verify()
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Message).To(Equal(`Access denied by template web: "mallory" is not an allowed requester`))
+		})
+
+		It("Should revoke granted access once the namespace is no longer allowed", func() {
+			By("Granting the access")
+			for i := 0; i < 3; i++ {
+				_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			}
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			Expect(request.Status.IsReady()).To(BeTrue())
+
+			By("Narrowing the allowedNamespaces of the template")
+			updateTemplate(func(cfg *v1alpha1.AccessConfig) {
+				cfg.AllowedNamespaces = []string{"team-*"}
+			})
+			cond := verify()
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Message).To(Equal(
+				"Access denied by template web: requests from namespace allowed are not allowed",
+			))
+
+			By("Cleaning up the denied request")
+			for i := 0; i < 3; i++ {
+				_, _ = r.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			}
+			err := cl.Get(ctx, key, &v1alpha1.ExecAccessRequest{})
+			Expect(apierrors.IsNotFound(err)).To(BeTrue())
+			bindings := &rbacv1.RoleBindingList{}
+			Expect(cl.List(ctx, bindings, client.InNamespace(key.Namespace))).To(Succeed())
+			Expect(bindings.Items).To(BeEmpty())
+		})
+	})
+})
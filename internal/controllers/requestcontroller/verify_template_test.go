@@ -2,6 +2,7 @@ package requestcontroller
 
 import (
 	"context"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -10,10 +11,13 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/diranged/oz/internal/api/v1alpha1"
 	"github.com/diranged/oz/internal/builders"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder"
 	"github.com/diranged/oz/internal/testing/utils"
 )
 
@@ -170,3 +174,78 @@ var _ = Describe("RequestReconciler", Ordered, func() {
 		})
 	})
 })
+
+var _ = Describe("RequestReconciler", func() {
+	/*
+		Reconcile() Tests of an ExecAccessRequest against a template with
+		parameters, all the way through the ExecAccessBuilder.
+	*/
+	Context("Reconcile() with template parameters", func() {
+		var (
+			ctx        = context.Background()
+			now        = time.Date(2023, 3, 14, 15, 0, 0, 0, time.UTC)
+			key        = types.NamespacedName{Name: "debug", Namespace: "parameters"}
+			cl         client.Client
+			reconciler *RequestReconciler
+		)
+
+		// requestWith sets the parameter values of the request under test,
+		// and reconciles it a few times over - enough for it to be granted.
+		requestWith := func(values map[string]string) *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			request.Spec.ParameterValues = values
+			Expect(cl.Update(ctx, request)).To(Succeed())
+
+			for i := 0; i < 3; i++ {
+				_, _ = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+			return request
+		}
+
+		BeforeEach(func() {
+			cl = newExecAccessClient(key, now)
+			reconciler = &RequestReconciler{
+				Client:      cl,
+				Scheme:      scheme.Scheme,
+				APIReader:   cl,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &execaccessbuilder.ExecAccessBuilder{},
+				now:         func() time.Time { return now },
+			}
+
+			// Make the template generic over the app that it targets
+			tmpl := &v1alpha1.ExecAccessTemplate{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "web", Namespace: key.Namespace}, tmpl)).To(Succeed())
+			tmpl.Spec.ControllerTargetRef.Name = "${app}"
+			tmpl.Spec.AccessConfig.AllowedCommands = []string{"/srv/${app}/bin/console"}
+			tmpl.Spec.AccessConfig.Parameters = []v1alpha1.TemplateParameter{
+				{Name: "app", Required: true, Pattern: "[a-z]+"},
+			}
+			Expect(cl.Update(ctx, tmpl)).To(Succeed())
+		})
+
+		It("Should grant access to a Pod of the controller named by the parameter values", func() {
+			request := requestWith(map[string]string{"app": "web"})
+			Expect(request.Status.PodName).To(Equal("web-1"))
+			Expect(request.Status.Ready).To(BeTrue())
+
+			// VERIFY: The commands allowed on the Pod are substituted too
+			tmpl, err := request.GetTemplate(ctx, cl)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tmpl.GetAccessConfig().GetAllowedCommands()).To(Equal([]string{"/srv/web/bin/console"}))
+		})
+
+		It("Should not grant access with values that no longer satisfy the template", func() {
+			request := requestWith(map[string]string{"app": "Web"})
+			Expect(request.Status.PodName).To(BeEmpty())
+			Expect(request.Status.Ready).To(BeFalse())
+
+			cond := meta.FindStatusCondition(request.Status.Conditions, v1alpha1.ConditionTargetTemplateExists.String())
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(ContainSubstring(`parameter "app" value "Web" does not match pattern "[a-z]+"`))
+		})
+	})
+})
@@ -0,0 +1,174 @@
+package requestcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders"
+)
+
+// WhatIfDecision is the outcome that WhatIf() predicts for an Access Request.
+type WhatIfDecision string
+
+const (
+	// WhatIfGrant means that access would be granted right away.
+	WhatIfGrant WhatIfDecision = "Grant"
+
+	// WhatIfDeny means that the request would be rejected, either by the
+	// validating webhook or by the reconciler.
+	WhatIfDeny WhatIfDecision = "Deny"
+
+	// WhatIfQueue means that the request would be accepted, but would wait
+	// (eg, on approvals) before any access is granted.
+	WhatIfQueue WhatIfDecision = "Queue"
+)
+
+// WhatIfResult describes the decision that oz would make for a hypothetical
+// Access Request, and why.
+type WhatIfResult struct {
+	Decision WhatIfDecision `json:"decision"`
+	Reasons  []string       `json:"reasons"`
+
+	// Duration is the access duration that would be granted, if the request
+	// gets far enough for one to be picked.
+	Duration string `json:"duration,omitempty"`
+}
+
+// WhatIfInput is the body accepted by the WhatIfHandler. The request and
+// template are full objects of the kinds handled by the RequestReconciler,
+// and neither needs to exist in the cluster.
+type WhatIfInput struct {
+	User     authenticationv1.UserInfo `json:"user"`
+	Request  json.RawMessage           `json:"request"`
+	Template json.RawMessage           `json:"template"`
+}
+
+// WhatIf predicts the decision that oz would make if the supplied user created
+// the supplied Access Request against the supplied template - without
+// creating anything. It runs the same checks as the validating webhook, and
+// then the namespace policy, duration and approval steps of the reconciler.
+//
+// Decisions that depend on the state of the cluster at the time access is
+// granted (duplicate requests, spec.maxPods, missing RBAC permissions) are
+// not predicted. The builders run under a builders.WithDryRun() context, so
+// nothing they would pick (eg, the target Pod) is recorded.
+func (r *RequestReconciler) WhatIf(
+	ctx context.Context,
+	user authenticationv1.UserInfo,
+	req v1alpha1.IRequestResource,
+	tmpl v1alpha1.ITemplateResource,
+) WhatIfResult {
+	// Never touch the caller's object - the builders record their choices on
+	// it - nor anything else.
+	ctx = builders.WithDryRun(ctx)
+	req = req.DeepCopyObject().(v1alpha1.IRequestResource)
+	if req.GetNamespace() == "" {
+		req.SetNamespace(tmpl.GetNamespace())
+	}
+	if req.GetCreationTimestamp().Time.IsZero() {
+		req.SetCreationTimestamp(metav1.NewTime(r.getNow()))
+	}
+
+	// The webhook checks, run exactly as they would be on creation.
+	admissionReq := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			UserInfo:  user,
+		},
+	}
+	if err := v1alpha1.ValidateAccessRequest(logr.Discard(), admissionReq, req, tmpl); err != nil {
+		return WhatIfResult{Decision: WhatIfDeny, Reasons: []string{err.Error()}}
+	}
+
+	// The policy of the namespace, exactly as the reconciler would apply it.
+	cfg, err := r.getNamespaceConfig(ctx, req.GetNamespace())
+	if err != nil {
+		return WhatIfResult{Decision: WhatIfDeny, Reasons: []string{err.Error()}}
+	}
+	tmpl = mergeNamespaceConfig(cfg, tmpl)
+	if cfg != nil {
+		if denied, ok := cfg.GetDeniedUser(user.Username, req.GetRequestFor(), req.GetTransferTo()); ok {
+			return WhatIfResult{Decision: WhatIfDeny, Reasons: []string{deniedUserMsg(cfg, denied)}}
+		}
+	}
+
+	// The duration picked by the reconciler.
+	accessDuration, decision, err := r.Builder.GetAccessDuration(req, tmpl)
+	if err == nil {
+		accessDuration, decision, err = r.clampAccessDuration(
+			req.GetCreationTimestamp().Time, tmpl, cfg, accessDuration, decision,
+		)
+	}
+	if err != nil {
+		return WhatIfResult{Decision: WhatIfDeny, Reasons: []string{err.Error()}}
+	}
+	result := WhatIfResult{
+		Decision: WhatIfGrant,
+		Reasons:  []string{decision},
+		Duration: accessDuration.String(),
+	}
+
+	// The approvals required by the template.
+	approvalRequired, err := r.approvalRequired(ctx, req, tmpl)
+	required := tmpl.GetAccessConfig().GetRequiredApprovals()
+	approvers := v1alpha1.GetApprovers(req)
+	switch {
+	case err != nil:
+		result.Decision = WhatIfQueue
+		result.Reasons = append(result.Reasons,
+			fmt.Sprintf("Unable to determine whether approval is required: %s", err))
+	case !approvalRequired && required > 0:
+		result.Reasons = append(result.Reasons, approvalNotRequiredMsg)
+	case !approvalRequired:
+	case len(approvers) < required:
+		result.Decision = WhatIfQueue
+		result.Reasons = append(result.Reasons,
+			fmt.Sprintf("Waiting on approvals: %d of %d received", len(approvers), required))
+	default:
+		result.Reasons = append(result.Reasons,
+			fmt.Sprintf("Approved by %s", strings.Join(approvers, ", ")))
+	}
+
+	return result
+}
+
+// WhatIfHandler returns an http.Handler that decodes a WhatIfInput from the
+// body of a POST, and responds with the WhatIfResult as JSON. The templateType
+// must be the kind of template that the RequestType is made against.
+func (r *RequestReconciler) WhatIfHandler(templateType v1alpha1.ITemplateResource) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, httpReq *http.Request) {
+		if httpReq.Method != http.MethodPost {
+			http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+			return
+		}
+
+		input := WhatIfInput{}
+		req := r.RequestType.DeepCopyObject().(v1alpha1.IRequestResource)
+		tmpl := templateType.DeepCopyObject().(v1alpha1.ITemplateResource)
+		if err := json.NewDecoder(httpReq.Body).Decode(&input); err != nil {
+			http.Error(w, fmt.Sprintf("invalid body: %s", err), http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(input.Request, req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request: %s", err), http.StatusBadRequest)
+			return
+		}
+		if err := json.Unmarshal(input.Template, tmpl); err != nil {
+			http.Error(w, fmt.Sprintf("invalid template: %s", err), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(r.WhatIf(httpReq.Context(), input.User, req, tmpl))
+	})
+}
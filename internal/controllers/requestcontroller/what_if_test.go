@@ -0,0 +1,309 @@
+package requestcontroller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/builders/execaccessbuilder"
+	"github.com/diranged/oz/internal/opstate"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("RequestReconciler", Ordered, func() {
+	/*
+		WhatIf() Tests
+	*/
+	Context("WhatIf()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			reconciler *RequestReconciler
+			builder    *mockBuilder
+			template   *v1alpha1.ExecAccessTemplate
+			user       = authenticationv1.UserInfo{Username: "alice", Groups: []string{"devs"}}
+		)
+
+		newRequest := func(approvers string) *v1alpha1.ExecAccessRequest {
+			request := &v1alpha1.ExecAccessRequest{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessRequestSpec{
+					TemplateName: template.GetName(),
+				},
+			}
+			if approvers != "" {
+				request.SetAnnotations(map[string]string{v1alpha1.ApprovedByAnnotation: approvers})
+			}
+			return request
+		}
+
+		// reconciledDecision creates the request for real, reconciles it, and
+		// maps the resulting status onto the decision that should have been
+		// predicted for it.
+		reconciledDecision := func(request *v1alpha1.ExecAccessRequest) WhatIfDecision {
+			err := k8sClient.Create(ctx, request)
+			Expect(err).ToNot(HaveOccurred())
+			_, _ = reconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{
+					Name:      request.GetName(),
+					Namespace: request.GetNamespace(),
+				},
+			})
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      request.GetName(),
+				Namespace: request.GetNamespace(),
+			}, request)
+			Expect(err).ToNot(HaveOccurred())
+
+			conditions := *request.GetStatus().GetConditions()
+			switch {
+			case request.Status.IsReady():
+				return WhatIfGrant
+			case meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionAccessApproved.String()):
+				return WhatIfQueue
+			case meta.IsStatusConditionFalse(conditions, v1alpha1.ConditionRequestDurationsValid.String()):
+				return WhatIfDeny
+			}
+			return ""
+		}
+
+		BeforeEach(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+			DeferCleanup(k8sClient.Delete, ctx, ns)
+
+			By("Should have an ExecAccessTemplate to make requests against")
+			template = &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"devs"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+				},
+			}
+
+			By("Creating the RequestReconciler")
+			builder = &mockBuilder{
+				getTemplateResp:             template,
+				getDurationResp:             time.Hour,
+				createResourcesResp:         "Role XYZ created",
+				accessResourcesAreReadyResp: true,
+			}
+			reconciler = &RequestReconciler{
+				Client:                 k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				APIReader:              k8sClient,
+				RequestType:            &v1alpha1.ExecAccessRequest{},
+				Builder:                builder,
+				ReconciliationInterval: time.Minute,
+			}
+		})
+
+		It("WhatIf() should predict a grant for a template without approvals", func() {
+			request := newRequest("")
+
+			ret := reconciler.WhatIf(ctx, user, request, template)
+
+			// VERIFY: Granted for the duration picked by the builder
+			Expect(ret.Decision).To(Equal(WhatIfGrant))
+			Expect(ret.Duration).To(Equal("1h0m0s"))
+			Expect(ret.Reasons).To(Equal([]string{"test"}))
+			Expect(reconciledDecision(request)).To(Equal(ret.Decision))
+		})
+
+		It("WhatIf() should predict the clamped duration", func() {
+			reconciler.AbsoluteMaxDuration = 30 * time.Minute
+			request := newRequest("")
+
+			ret := reconciler.WhatIf(ctx, user, request, template)
+
+			// VERIFY: Granted for the ceiling
+			Expect(ret.Decision).To(Equal(WhatIfGrant))
+			Expect(ret.Duration).To(Equal("30m0s"))
+			Expect(ret.Reasons[0]).To(ContainSubstring("clamped to the absolute maximum duration of 30m0s"))
+			Expect(reconciledDecision(request)).To(Equal(ret.Decision))
+		})
+
+		It("WhatIf() should predict a queue for a request waiting on approvals", func() {
+			template.Spec.AccessConfig.RequiredApprovals = 2
+			request := newRequest("bob")
+
+			ret := reconciler.WhatIf(ctx, user, request, template)
+
+			// VERIFY: Queued until another approval arrives
+			Expect(ret.Decision).To(Equal(WhatIfQueue))
+			Expect(ret.Reasons).To(ContainElement("Waiting on approvals: 1 of 2 received"))
+			Expect(reconciledDecision(request)).To(Equal(ret.Decision))
+		})
+
+		It("WhatIf() should predict a grant for an approved request", func() {
+			template.Spec.AccessConfig.RequiredApprovals = 2
+			request := newRequest("bob,carol")
+
+			ret := reconciler.WhatIf(ctx, user, request, template)
+
+			// VERIFY: Granted
+			Expect(ret.Decision).To(Equal(WhatIfGrant))
+			Expect(ret.Reasons).To(ContainElement("Approved by bob, carol"))
+			Expect(reconciledDecision(request)).To(Equal(ret.Decision))
+		})
+
+		It("WhatIf() should predict a grant for a target outside of the approvalRequiredSelector", func() {
+			template.Spec.AccessConfig.RequiredApprovals = 2
+			template.Spec.AccessConfig.ApprovalRequiredSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"env": "production"},
+			}
+			builder.getTargetLabelsResp = map[string]string{"env": "staging"}
+			request := newRequest("")
+
+			ret := reconciler.WhatIf(ctx, user, request, template)
+
+			// VERIFY: Granted without approvals
+			Expect(ret.Decision).To(Equal(WhatIfGrant))
+			Expect(ret.Reasons).To(ContainElement(approvalNotRequiredMsg))
+			Expect(reconciledDecision(request)).To(Equal(ret.Decision))
+		})
+
+		It("WhatIf() should predict a denial when the duration is invalid", func() {
+			builder.getDurationErr = errors.New("invalid duration")
+			request := newRequest("")
+
+			ret := reconciler.WhatIf(ctx, user, request, template)
+
+			// VERIFY: Denied
+			Expect(ret.Decision).To(Equal(WhatIfDeny))
+			Expect(ret.Reasons).To(Equal([]string{"invalid duration"}))
+			Expect(ret.Duration).To(BeEmpty())
+			Expect(reconciledDecision(request)).To(Equal(ret.Decision))
+		})
+
+		It("WhatIf() should predict the webhook rejecting requests against a paused template", func() {
+			template.Spec.AccessConfig.Paused = true
+
+			ret := reconciler.WhatIf(ctx, user, newRequest(""), template)
+
+			// VERIFY: Denied by the webhook
+			Expect(ret.Decision).To(Equal(WhatIfDeny))
+			Expect(ret.Reasons[0]).To(ContainSubstring("is paused"))
+		})
+
+		It("WhatIf() should predict the webhook rejecting delegation by a non-delegator", func() {
+			request := newRequest("")
+			request.Spec.RequestFor = "bob"
+
+			ret := reconciler.WhatIf(ctx, user, request, template)
+
+			// VERIFY: Denied by the webhook
+			Expect(ret.Decision).To(Equal(WhatIfDeny))
+			Expect(ret.Reasons[0]).To(ContainSubstring("alice is not allowed to request access on behalf"))
+		})
+
+		It("WhatIfHandler() should serve the prediction as JSON", func() {
+			template.Spec.AccessConfig.RequiredApprovals = 1
+			body, err := json.Marshal(map[string]any{
+				"user":     user,
+				"request":  newRequest(""),
+				"template": template,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			handler := reconciler.WhatIfHandler(&v1alpha1.ExecAccessTemplate{})
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/whatif", strings.NewReader(string(body))))
+
+			// VERIFY: The prediction is returned
+			Expect(w.Code).To(Equal(http.StatusOK))
+			ret := WhatIfResult{}
+			Expect(json.Unmarshal(w.Body.Bytes(), &ret)).To(Succeed())
+			Expect(ret.Decision).To(Equal(WhatIfQueue))
+			Expect(ret.Reasons).To(ContainElement("Waiting on approvals: 0 of 1 received"))
+
+			By("Rejecting anything but a POST")
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/whatif", nil))
+			Expect(w.Code).To(Equal(http.StatusMethodNotAllowed))
+
+			By("Rejecting a malformed body")
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/whatif", strings.NewReader("{")))
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+})
+
+var _ = Describe("RequestReconciler", func() {
+	Context("WhatIf() with a real builder", func() {
+		var (
+			ctx  = context.Background()
+			now  = time.Now().UTC().Truncate(time.Second)
+			key  = types.NamespacedName{Name: "debug", Namespace: "whatif"}
+			user = authenticationv1.UserInfo{Username: "alice", Groups: []string{"devs"}}
+		)
+
+		It("WhatIf() should not record the Pod it would pick", func() {
+			cl := newExecAccessClient(key, now)
+			state := &opstate.Store{Client: cl, Namespace: "oz-system"}
+			r := &RequestReconciler{
+				Client:      cl,
+				Scheme:      scheme.Scheme,
+				APIReader:   cl,
+				RequestType: &v1alpha1.ExecAccessRequest{},
+				Builder:     &execaccessbuilder.ExecAccessBuilder{StateStore: state},
+				now:         func() time.Time { return now },
+			}
+
+			template := &v1alpha1.ExecAccessTemplate{}
+			Expect(cl.Get(ctx, types.NamespacedName{Name: "web", Namespace: key.Namespace}, template)).To(Succeed())
+			template.Spec.PodSelectionStrategy = v1alpha1.LeastRecentlyUsedPodSelection
+			template.Spec.AccessConfig.RequiredApprovals = 1
+			template.Spec.AccessConfig.ApprovalRequiredSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "web"},
+			}
+			request := &v1alpha1.ExecAccessRequest{}
+			Expect(cl.Get(ctx, key, request)).To(Succeed())
+
+			ret := r.WhatIf(ctx, user, request, template)
+
+			// VERIFY: The Pod was looked at, to find out that approval is required
+			Expect(ret.Decision).To(Equal(WhatIfQueue))
+			Expect(ret.Reasons).To(ContainElement("Waiting on approvals: 0 of 1 received"))
+
+			// VERIFY: Neither the request, nor the Pod usage, was touched
+			Expect(request.Status.PodName).To(BeEmpty())
+			configMaps := &v1.ConfigMapList{}
+			Expect(cl.List(ctx, configMaps, client.InNamespace("oz-system"))).To(Succeed())
+			Expect(configMaps.Items).To(BeEmpty())
+		})
+	})
+})
@@ -102,9 +102,45 @@ func (r *TemplateReconciler) reconcile(rctx *RequestContext) (ctrl.Result, error
 		return ctrlrequeue.RequeueError(err)
 	}
 
+	// VERIFICATION: Warn if the MaxDuration is above the controller-wide ceiling.
+	//
+	// An error is only returned if the conditions update fails. Otherwise we
+	// continue to move on.
+	err = r.verifyMaxDurationCeiling(rctx)
+	if err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: Make sure that the ClusterRole referenced by the template (if any) exists.
+	//
+	// An error is only returned if the conditions update fails. Otherwise we
+	// continue to move on.
+	err = r.verifyClusterRoleRef(rctx)
+	if err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
+	// VERIFICATION: Make sure that the namespacePattern of a shared template (if any) is valid.
+	//
+	// An error is only returned if the conditions update fails. Otherwise we
+	// continue to move on.
+	err = r.verifyNamespacePattern(rctx)
+	if err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
 	// TODO:
 	// VERIFICATION: Ensure that the allowedGroups match valid group name strings
 
+	// VERIFICATION: Roll up the checks above into the ConditionTemplateValid condition.
+	//
+	// An error is only returned if the conditions update fails. Otherwise we
+	// continue to move on.
+	err = r.verifyTemplateValid(rctx)
+	if err != nil {
+		return ctrlrequeue.RequeueError(err)
+	}
+
 	// FINAL: Set Status.Ready state
 	err = status.SetReadyStatus(rctx, r, rctx.obj)
 	if err != nil {
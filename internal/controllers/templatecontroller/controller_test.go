@@ -218,5 +218,125 @@ var _ = Describe("TemplateReconciler", Ordered, func() {
 				},
 			)
 		})
+
+		Context("Reconcile() with inconsistent durations", func() {
+			var (
+				ctx        = context.Background()
+				ns         *corev1.Namespace
+				deployment *appsv1.Deployment
+				reconciler *TemplateReconciler
+			)
+
+			// reconcileTemplate creates a template with the supplied durations,
+			// reconciles it, and returns its refetched ConditionTemplateValid.
+			reconcileTemplate := func(defaultDuration string, maxDuration string) (*v1alpha1.ExecAccessTemplate, *metav1.Condition) {
+				template := &v1alpha1.ExecAccessTemplate{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      utils.RandomString(8),
+						Namespace: ns.GetName(),
+					},
+					Spec: v1alpha1.ExecAccessTemplateSpec{
+						AccessConfig: v1alpha1.AccessConfig{
+							AllowedGroups:   []string{"foo"},
+							DefaultDuration: defaultDuration,
+							MaxDuration:     maxDuration,
+						},
+						ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+							APIVersion: "apps/v1",
+							Kind:       "Deployment",
+							Name:       deployment.GetName(),
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, template)).To(Succeed())
+
+				_, err := reconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      template.GetName(),
+						Namespace: template.GetNamespace(),
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(k8sClient.Get(ctx, types.NamespacedName{
+					Name:      template.GetName(),
+					Namespace: template.GetNamespace(),
+				}, template)).To(Succeed())
+				return template, meta.FindStatusCondition(
+					*template.GetStatus().GetConditions(),
+					v1alpha1.ConditionTemplateValid.String(),
+				)
+			}
+
+			BeforeAll(func() {
+				By("Should have a namespace to execute tests in")
+				ns = &corev1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: utils.RandomString(8),
+					},
+				}
+				Expect(k8sClient.Create(ctx, ns)).To(Succeed())
+
+				By("Creating a Deployment to reference for the test")
+				deployment = &appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "deployment-test",
+						Namespace: ns.Name,
+					},
+					Spec: appsv1.DeploymentSpec{
+						Selector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"testLabel": "testValue"},
+						},
+						Template: corev1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{
+								Labels: map[string]string{"testLabel": "testValue"},
+							},
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: "test", Image: "nginx:latest"}},
+							},
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+				By("Creating the TemplateReconciler")
+				reconciler = &TemplateReconciler{
+					Client:                 k8sClient,
+					Scheme:                 k8sClient.Scheme(),
+					APIReader:              k8sClient,
+					TemplateType:           &v1alpha1.ExecAccessTemplate{},
+					ReconciliationInterval: time.Minute,
+				}
+			})
+
+			AfterAll(func() {
+				By("Should delete the namespace")
+				Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+			})
+
+			It("Reconcile() should mark a template whose defaultDuration exceeds its maxDuration invalid", func() {
+				template, cond := reconcileTemplate("3h", "2h")
+
+				// VERIFY: ConditionTemplateValid = False, explaining why
+				Expect(cond).ToNot(BeNil())
+				Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+				Expect(cond.Message).To(Equal(
+					"Condition TemplateDurationsValid is False: Error: spec.defaultDuration " +
+						"can not be greater than spec.maxDuration (3h0m0s > 2h0m0s)",
+				))
+				Expect(template.Status.IsReady()).To(BeFalse())
+			})
+
+			It("Reconcile() should mark a template with consistent durations valid", func() {
+				for _, durations := range [][]string{{"1h", "2h"}, {"2h", "2h"}} {
+					template, cond := reconcileTemplate(durations[0], durations[1])
+
+					// VERIFY: ConditionTemplateValid = True
+					Expect(cond).ToNot(BeNil())
+					Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+					Expect(template.Status.IsReady()).To(BeTrue())
+				}
+			})
+		})
 	})
 })
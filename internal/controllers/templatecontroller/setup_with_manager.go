@@ -1,14 +1,112 @@
 package templatecontroller
 
 import (
-	"github.com/diranged/oz/internal/controllers/internal/utils"
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/utils"
 )
 
 // SetupWithManager sets up the controller with the Manager.
+//
+// The controllers that templates can target are watched as well, so that a
+// template is re-verified as soon as its target controller is created or
+// deleted - rather than on the next ReconciliationInterval. The LabelSelector
+// only applies to the templates themselves, not to the controllers they
+// target.
 func (r *TemplateReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(r.TemplateType).
+		For(r.TemplateType, builder.WithPredicates(utils.MatchesLabelSelector(r.LabelSelector))).
+		Watches(
+			&source.Kind{Type: &appsv1.Deployment{}},
+			handler.EnqueueRequestsFromMapFunc(r.templatesTargeting(v1alpha1.DeploymentController)),
+		).
+		Watches(
+			&source.Kind{Type: &appsv1.StatefulSet{}},
+			handler.EnqueueRequestsFromMapFunc(r.templatesTargeting(v1alpha1.StatefulSetController)),
+		).
+		Watches(
+			&source.Kind{Type: &appsv1.DaemonSet{}},
+			handler.EnqueueRequestsFromMapFunc(r.templatesTargeting(v1alpha1.DaemonSetController)),
+		).
 		WithEventFilter(utils.IgnoreStatusUpdatesAndDeletion()).
 		Complete(r)
 }
+
+// templatesTargeting returns a handler.MapFunc that maps a controller of the
+// supplied kind to every template in its namespace that targets it, and
+// matches the LabelSelector.
+func (r *TemplateReconciler) templatesTargeting(kind v1alpha1.ControllerKind) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		ctx := context.Background()
+		log := ctrl.LoggerFrom(ctx).WithName("TemplateReconciler")
+
+		templates, err := r.listTemplates(ctx, obj.GetNamespace())
+		if err != nil {
+			log.Error(err, "Failed to list templates for target controller",
+				"kind", kind, "name", obj.GetName(), "namespace", obj.GetNamespace())
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, tmpl := range templates {
+			ref := tmpl.GetTargetRef()
+			if ref == nil || ref.Kind != kind || ref.Name != obj.GetName() {
+				continue
+			}
+			if r.LabelSelector != nil && !r.LabelSelector.Matches(labels.Set(tmpl.GetLabels())) {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+				Name:      tmpl.GetName(),
+				Namespace: tmpl.GetNamespace(),
+			}})
+		}
+		return requests
+	}
+}
+
+// listTemplates returns all of the templates of the TemplateType in the
+// supplied namespace.
+func (r *TemplateReconciler) listTemplates(
+	ctx context.Context,
+	namespace string,
+) ([]v1alpha1.ITemplateResource, error) {
+	gvk, err := apiutil.GVKForObject(r.TemplateType, r.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	gvk.Kind += "List"
+	listObj, err := r.Scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	list := listObj.(client.ObjectList)
+	if err := r.Client.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]v1alpha1.ITemplateResource, 0, len(items))
+	for _, item := range items {
+		if tmpl, ok := item.(v1alpha1.ITemplateResource); ok {
+			templates = append(templates, tmpl)
+		}
+	}
+	return templates, nil
+}
@@ -0,0 +1,162 @@
+package templatecontroller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("TemplateReconciler", Ordered, func() {
+	Context("templatesTargeting()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *corev1.Namespace
+			reconciler *TemplateReconciler
+			deployment *appsv1.Deployment
+			template   *v1alpha1.ExecAccessTemplate
+		)
+
+		newTemplate := func(kind v1alpha1.ControllerKind, name string) *v1alpha1.ExecAccessTemplate {
+			tmpl := &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       kind,
+						Name:       name,
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, tmpl)
+			Expect(err).ToNot(HaveOccurred())
+			return tmpl
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the TemplateReconciler")
+			reconciler = &TemplateReconciler{
+				Client:                 k8sClient,
+				APIReader:              k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				TemplateType:           &v1alpha1.ExecAccessTemplate{},
+				ReconciliationInterval: 0,
+			}
+
+			By("Creating a Deployment to reference for the test")
+			deployment = &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "deployment-test",
+					Namespace: ns.Name,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"testLabel": "testValue"},
+					},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: map[string]string{"testLabel": "testValue"},
+						},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "test", Image: "nginx:latest"}},
+						},
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, deployment)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Creating templates that target it, and other controllers")
+			template = newTemplate(v1alpha1.DeploymentController, deployment.GetName())
+			newTemplate(v1alpha1.DeploymentController, "other")
+			newTemplate(v1alpha1.StatefulSetController, deployment.GetName())
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("templatesTargeting() should only map to the templates targeting the controller", func() {
+			requests := reconciler.templatesTargeting(v1alpha1.DeploymentController)(deployment)
+
+			// VERIFY: Only the template pointing at this Deployment is enqueued
+			Expect(requests).To(Equal([]reconcile.Request{{
+				NamespacedName: types.NamespacedName{
+					Name:      template.GetName(),
+					Namespace: template.GetNamespace(),
+				},
+			}}))
+		})
+
+		It("verifyTargetRef() should catch a target controller deleted after the fact", func() {
+			rctx := newRequestContext(
+				ctx,
+				reconciler.TemplateType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      template.GetName(),
+						Namespace: template.GetNamespace(),
+					},
+				},
+			)
+			err := reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Verifying the template while the Deployment exists")
+			err = reconciler.verifyTargetRef(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.IsStatusConditionTrue(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionTargetRefExists.String(),
+			)).To(BeTrue())
+
+			By("Deleting the Deployment, which enqueues the template")
+			err = k8sClient.Delete(ctx, deployment)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(reconciler.templatesTargeting(v1alpha1.DeploymentController)(deployment)).
+				To(HaveLen(1))
+
+			By("Verifying the template again")
+			err = reconciler.verifyTargetRef(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: ConditionTargetRefExists = False
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionTargetRefExists.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(string(metav1.StatusReasonNotFound)))
+		})
+	})
+})
@@ -10,6 +10,7 @@ import (
 	"github.com/diranged/oz/internal/api/v1alpha1"
 	"github.com/diranged/oz/internal/builders"
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -42,6 +43,16 @@ type TemplateReconciler struct {
 
 	// Frequency to re-reconcile successfully reconciled templates
 	ReconciliationInterval time.Duration
+
+	// AbsoluteMaxDuration is the ceiling that the Access Request reconcilers
+	// clamp every request to. Templates with a longer maxDuration are warned
+	// about. Zero means there is no ceiling.
+	AbsoluteMaxDuration time.Duration
+
+	// LabelSelector is optional. If set, only the Access Templates matching
+	// it are reconciled - allowing the templates to be sharded across
+	// several controller instances.
+	LabelSelector labels.Selector
 }
 
 // GetAPIReader conforms to the internal.status.hasStatusReconciler interface.
@@ -0,0 +1,40 @@
+package templatecontroller
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;list;watch
+
+// verifyClusterRoleRef ensures that the ClusterRole referenced by the
+// Spec.accessConfig.clusterRoleRef field exists. Any failure results in the
+// resource ConditionClusterRoleRefExists condition being set to False, which
+// in turn marks the whole template invalid.
+//
+// Templates that do not reference a ClusterRole do not carry the condition at
+// all - if it was left behind from an earlier revision, it is removed.
+//
+// Returns:
+//   - An "error" only if the UpdateCondition function fails
+func (r *TemplateReconciler) verifyClusterRoleRef(rctx *RequestContext) error {
+	name := rctx.obj.GetAccessConfig().GetClusterRoleRef()
+	if name == "" {
+		conditions := rctx.obj.GetStatus().GetConditions()
+		if meta.FindStatusCondition(*conditions, v1alpha1.ConditionClusterRoleRefExists.String()) == nil {
+			return nil
+		}
+		meta.RemoveStatusCondition(conditions, v1alpha1.ConditionClusterRoleRefExists.String())
+		return status.UpdateStatus(rctx.Context, r, rctx.obj)
+	}
+
+	rctx.log.Info("Beginning ClusterRoleRef Verification")
+	if err := r.Client.Get(rctx.Context, types.NamespacedName{Name: name}, &rbacv1.ClusterRole{}); err != nil {
+		return status.SetClusterRoleRefNotExists(rctx.Context, r, rctx.obj, err)
+	}
+	return status.SetClusterRoleRefExists(rctx.Context, r, rctx.obj, "Success")
+}
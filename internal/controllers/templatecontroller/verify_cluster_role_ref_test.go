@@ -0,0 +1,169 @@
+package templatecontroller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("TemplateReconciler", Ordered, func() {
+	Context("verifyClusterRoleRef()", func() {
+		var (
+			ctx         = context.Background()
+			ns          *corev1.Namespace
+			reconciler  *TemplateReconciler
+			clusterRole *rbacv1.ClusterRole
+		)
+
+		// newTemplate creates an ExecAccessTemplate that references the named
+		// ClusterRole, and returns a populated RequestContext for it.
+		newTemplate := func(clusterRoleRef string) *RequestContext {
+			template := &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+						ClusterRoleRef:  clusterRoleRef,
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "fake",
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			rctx := newRequestContext(
+				ctx,
+				reconciler.TemplateType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      template.GetName(),
+						Namespace: template.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Should have a ClusterRole to reference")
+			clusterRole = &rbacv1.ClusterRole{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+				Rules: []rbacv1.PolicyRule{
+					{
+						APIGroups: []string{""},
+						Resources: []string{"pods"},
+						Verbs:     []string{"get"},
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, clusterRole)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the TemplateReconciler")
+			reconciler = &TemplateReconciler{
+				Client:                 k8sClient,
+				APIReader:              k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				TemplateType:           &v1alpha1.ExecAccessTemplate{},
+				ReconciliationInterval: 0,
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace and ClusterRole")
+			Expect(k8sClient.Delete(ctx, ns)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, clusterRole)).To(Succeed())
+		})
+
+		It("verifyClusterRoleRef() should succeed with an existing ClusterRole", func() {
+			rctx := newTemplate(clusterRole.GetName())
+
+			err := reconciler.verifyClusterRoleRef(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: ConditionClusterRoleRefExists = True
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionClusterRoleRefExists.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+		})
+
+		It("verifyClusterRoleRef() should mark the template invalid with a missing ClusterRole", func() {
+			rctx := newTemplate("missing")
+
+			err := reconciler.verifyClusterRoleRef(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: ConditionClusterRoleRefExists = False
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionClusterRoleRefExists.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(string(metav1.StatusReasonNotFound)))
+
+			// VERIFY: The template is rolled up as invalid
+			err = reconciler.verifyTemplateValid(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			valid := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionTemplateValid.String(),
+			)
+			Expect(valid.Status).To(Equal(metav1.ConditionFalse))
+			Expect(valid.Message).To(MatchRegexp("ClusterRoleRefExists is False"))
+		})
+
+		It("verifyClusterRoleRef() should drop a stale condition once the reference is removed", func() {
+			rctx := newTemplate("missing")
+			err := reconciler.verifyClusterRoleRef(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Removing the reference from the template")
+			rctx.obj.GetAccessConfig().ClusterRoleRef = ""
+			err = reconciler.verifyClusterRoleRef(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The condition is gone
+			Expect(meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionClusterRoleRefExists.String(),
+			)).To(BeNil())
+		})
+	})
+})
@@ -7,9 +7,12 @@ import (
 )
 
 // verifyDuration walks through the AccessConfig settings for an
-// ITemplateResource and verifies that the inputs are sane. Conditions are
-// updated if they are not, but errors are only returned if the condition
-// update process fails.
+// ITemplateResource and verifies that the inputs are sane - in particular,
+// that the defaultDuration is not greater than the maxDuration, which would
+// otherwise only surface once a request without a duration came in.
+// Conditions are updated if they are not (and rolled up into
+// ConditionTemplateValid by verifyTemplateValid()), but errors are only
+// returned if the condition update process fails.
 func (r *TemplateReconciler) verifyDuration(rctx *RequestContext) error {
 	// Verify that MaxDuration is greater than DesiredDuration.
 	defaultDuration, err := rctx.obj.GetAccessConfig().GetDefaultDuration()
@@ -26,7 +29,52 @@ func (r *TemplateReconciler) verifyDuration(rctx *RequestContext) error {
 	}
 	if defaultDuration > maxDuration {
 		return status.SetTemplateDurationsNotValid(rctx.Context, r, rctx.obj,
-			"Error: spec.defaultDuration can not be greater than spec.maxDuration")
+			fmt.Sprintf("Error: spec.defaultDuration can not be greater than spec.maxDuration (%s > %s)",
+				defaultDuration, maxDuration))
+	}
+	if grace, err := rctx.obj.GetAccessConfig().GetExpirationGracePeriod(); err != nil {
+		return status.SetTemplateDurationsNotValid(rctx.Context, r, rctx.obj,
+			fmt.Sprintf("Error on spec.expirationGracePeriod: %s", err),
+		)
+	} else if grace < 0 {
+		return status.SetTemplateDurationsNotValid(rctx.Context, r, rctx.obj,
+			"Error: spec.expirationGracePeriod can not be negative")
+	}
+	if idle, err := rctx.obj.GetAccessConfig().GetIdleTimeout(); err != nil {
+		return status.SetTemplateDurationsNotValid(rctx.Context, r, rctx.obj,
+			fmt.Sprintf("Error on spec.idleTimeout: %s", err),
+		)
+	} else if idle < 0 {
+		return status.SetTemplateDurationsNotValid(rctx.Context, r, rctx.obj,
+			"Error: spec.idleTimeout can not be negative")
+	}
+	if eod := rctx.obj.GetAccessConfig().GetExpireAtEndOfDay(); eod != nil {
+		if _, err := eod.GetLocation(); err != nil {
+			return status.SetTemplateDurationsNotValid(rctx.Context, r, rctx.obj,
+				fmt.Sprintf("Error on spec.expireAtEndOfDay.timeZone: %s", err),
+			)
+		}
+	}
+	if profile := rctx.obj.GetAccessConfig().GetIncidentDurations(); profile != nil {
+		incident := rctx.obj.GetAccessConfig().DeepCopy()
+		profile.ApplyTo(incident)
+		incidentDefault, err := incident.GetDefaultDuration()
+		if err != nil {
+			return status.SetTemplateDurationsNotValid(rctx.Context, r, rctx.obj,
+				fmt.Sprintf("Error on spec.incidentDurations.defaultDuration: %s", err),
+			)
+		}
+		incidentMax, err := incident.GetMaxDuration()
+		if err != nil {
+			return status.SetTemplateDurationsNotValid(rctx.Context, r, rctx.obj,
+				fmt.Sprintf("Error on spec.incidentDurations.maxDuration: %s", err),
+			)
+		}
+		if incidentDefault > incidentMax {
+			return status.SetTemplateDurationsNotValid(rctx.Context, r, rctx.obj,
+				fmt.Sprintf("Error: spec.incidentDurations.defaultDuration can not be greater than "+
+					"spec.incidentDurations.maxDuration (%s > %s)", incidentDefault, incidentMax))
+		}
 	}
 	return status.SetTemplateDurationsValid(rctx.Context, r, rctx.obj,
 		"spec.defaultDuration and spec.maxDuration valid",
@@ -280,5 +280,120 @@ var _ = Describe("TemplateReconciler", Ordered, func() {
 			Expect(cond.Reason).To(Equal(string(metav1.StatusReasonNotAcceptable)))
 			Expect(cond.Message).To(MatchRegexp("can not be greater than"))
 		})
+
+		It("verifyDuration() should return error if expirationGracePeriod is negative", func() {
+			By("Should have an ExecAccessTemplate built to test against")
+			template := &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:         []string{"foo"},
+						DefaultDuration:       "1h",
+						MaxDuration:           "2h",
+						ExpirationGracePeriod: "-5m",
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "junk",
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+			err = k8sClient.Get(ctx, types.NamespacedName{
+				Name:      template.GetName(),
+				Namespace: template.GetNamespace(),
+			}, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Populating the RequestContext")
+			rctx := newRequestContext(
+				ctx,
+				reconciler.TemplateType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      template.GetName(),
+						Namespace: template.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// Run the verifyDuration
+			By("Executing the test")
+			err = reconciler.verifyDuration(rctx)
+
+			// VERIFY: No error returned, but the status of the template should be updated
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: ConditionAccessResourcesCreated = False
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				string(v1alpha1.ConditionTemplateDurationsValid.String()),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(string(metav1.StatusReasonNotAcceptable)))
+			Expect(cond.Message).To(MatchRegexp("expirationGracePeriod can not be negative"))
+		})
+
+		It("verifyDuration() should return error if incidentDurations defaultDuration > maxDuration", func() {
+			By("Should have an ExecAccessTemplate built to test against")
+			template := &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     "2h",
+						IncidentDurations: &v1alpha1.DurationProfile{
+							DefaultDuration: "4h",
+						},
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "junk",
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Populating the RequestContext")
+			rctx := newRequestContext(
+				ctx,
+				reconciler.TemplateType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      template.GetName(),
+						Namespace: template.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Executing the test")
+			err = reconciler.verifyDuration(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: The incident defaultDuration is checked against the template maxDuration
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				string(v1alpha1.ConditionTemplateDurationsValid.String()),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Message).To(ContainSubstring("spec.incidentDurations.defaultDuration can not be greater"))
+		})
 	})
 })
@@ -0,0 +1,30 @@
+package templatecontroller
+
+import (
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// verifyMaxDurationCeiling warns (through the advisory
+// ConditionMaxDurationWithinCeiling condition) when the spec.maxDuration of
+// an ITemplateResource is longer than the AbsoluteMaxDuration that the
+// controller clamps every Access Request to. Nothing is checked when there is
+// no ceiling, or when the maxDuration cannot be parsed - verifyDuration has
+// already flagged that.
+//
+// Returns:
+//   - An "error" only if the UpdateCondition function fails
+func (r *TemplateReconciler) verifyMaxDurationCeiling(rctx *RequestContext) error {
+	if r.AbsoluteMaxDuration <= 0 {
+		return nil
+	}
+	maxDuration, err := rctx.obj.GetAccessConfig().GetMaxDuration()
+	if err != nil {
+		return nil
+	}
+	if maxDuration > r.AbsoluteMaxDuration {
+		return status.SetMaxDurationExceedsCeiling(
+			rctx.Context, r, rctx.obj, maxDuration, r.AbsoluteMaxDuration,
+		)
+	}
+	return status.SetMaxDurationWithinCeiling(rctx.Context, r, rctx.obj, r.AbsoluteMaxDuration)
+}
@@ -0,0 +1,150 @@
+package templatecontroller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("TemplateReconciler", Ordered, func() {
+	Context("verifyMaxDurationCeiling()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			reconciler *TemplateReconciler
+		)
+
+		newContext := func(maxDuration string) *RequestContext {
+			template := &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: "1h",
+						MaxDuration:     maxDuration,
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "junk",
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			rctx := newRequestContext(
+				ctx,
+				reconciler.TemplateType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      template.GetName(),
+						Namespace: template.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the TemplateReconciler")
+			reconciler = &TemplateReconciler{
+				Client:                 k8sClient,
+				APIReader:              k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				TemplateType:           &v1alpha1.ExecAccessTemplate{},
+				ReconciliationInterval: 0,
+				AbsoluteMaxDuration:    4 * time.Hour,
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("verifyMaxDurationCeiling() should pass a template below the ceiling", func() {
+			rctx := newContext("2h")
+
+			err := reconciler.verifyMaxDurationCeiling(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: ConditionMaxDurationWithinCeiling = True
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionMaxDurationWithinCeiling.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Reason).To(Equal(string(metav1.StatusSuccess)))
+		})
+
+		It("verifyMaxDurationCeiling() should warn about, but not invalidate, a template above the ceiling", func() {
+			rctx := newContext("24h")
+
+			err := reconciler.verifyMaxDurationCeiling(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: ConditionMaxDurationWithinCeiling = False, with a warning
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionMaxDurationWithinCeiling.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(status.ReasonMaxDurationExceedsCeiling))
+			Expect(cond.Message).To(Equal(
+				"Warning: spec.maxDuration (24h0m0s) exceeds the absolute maximum duration of 4h0m0s, " +
+					"requests will be clamped to 4h0m0s regardless",
+			))
+
+			// VERIFY: The warning alone does not make the template invalid
+			err = reconciler.verifyTemplateValid(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.IsStatusConditionTrue(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionTemplateValid.String(),
+			)).To(BeTrue())
+		})
+
+		It("verifyMaxDurationCeiling() should do nothing without a ceiling", func() {
+			reconciler.AbsoluteMaxDuration = 0
+			DeferCleanup(func() { reconciler.AbsoluteMaxDuration = 4 * time.Hour })
+			rctx := newContext("24h")
+
+			err := reconciler.verifyMaxDurationCeiling(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionMaxDurationWithinCeiling.String(),
+			)).To(BeNil())
+		})
+	})
+})
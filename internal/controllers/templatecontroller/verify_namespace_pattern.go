@@ -0,0 +1,37 @@
+package templatecontroller
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// verifyNamespacePattern ensures that the Spec.accessConfig.namespacePattern
+// field is a valid glob pattern. Any failure results in the resource
+// ConditionNamespacePatternValid condition being set to False, which in turn
+// marks the whole template invalid.
+//
+// Templates that are not shared with other namespaces do not carry the
+// condition at all - if it was left behind from an earlier revision, it is
+// removed.
+//
+// Returns:
+//   - An "error" only if the UpdateCondition function fails
+func (r *TemplateReconciler) verifyNamespacePattern(rctx *RequestContext) error {
+	cfg := rctx.obj.GetAccessConfig()
+	if cfg.GetNamespacePattern() == "" {
+		conditions := rctx.obj.GetStatus().GetConditions()
+		if meta.FindStatusCondition(*conditions, v1alpha1.ConditionNamespacePatternValid.String()) == nil {
+			return nil
+		}
+		meta.RemoveStatusCondition(conditions, v1alpha1.ConditionNamespacePatternValid.String())
+		return status.UpdateStatus(rctx.Context, r, rctx.obj)
+	}
+
+	rctx.log.Info("Beginning NamespacePattern Verification")
+	if err := cfg.ValidateNamespacePattern(); err != nil {
+		return status.SetNamespacePatternNotValid(rctx.Context, r, rctx.obj, err)
+	}
+	return status.SetNamespacePatternValid(rctx.Context, r, rctx.obj, "Success")
+}
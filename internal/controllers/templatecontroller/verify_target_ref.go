@@ -10,9 +10,19 @@ import (
 // results in the resource ConditionTargetRefExists condition being set to
 // False.
 //
+// A template that is shared with other namespaces, but not with its own, has
+// its targetRef looked up in the namespace of each Access Request instead -
+// so there is nothing to verify here.
+//
 // Returns:
 //   - An "error" only if the UpdateCondition function fails
 func (r *TemplateReconciler) verifyTargetRef(rctx *RequestContext) error {
+	cfg := rctx.obj.GetAccessConfig()
+	if cfg.GetNamespacePattern() != "" && !cfg.SharesWithNamespace(rctx.obj.GetNamespace()) {
+		return status.SetTargetRefExists(rctx.Context, r, rctx.obj,
+			"Shared template - the targetRef is looked up in the namespace of each Access Request")
+	}
+
 	rctx.log.Info("Beginning TargetRef Verification")
 
 	// https://blog.gripdev.xyz/2020/07/20/k8s-operator-with-dynamic-crds-using-controller-runtime-no-structs/
@@ -0,0 +1,33 @@
+package templatecontroller
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/controllers/internal/status"
+)
+
+// verifyTemplateValid rolls up the results of the previous verification steps
+// into the single ConditionTemplateValid condition. This condition is what
+// the Access Request validating webhooks look at to decide whether or not new
+// requests are accepted against the template.
+//
+// Returns:
+//   - An "error" only if the UpdateCondition function fails
+func (r *TemplateReconciler) verifyTemplateValid(rctx *RequestContext) error {
+	for _, cond := range *rctx.obj.GetStatus().GetConditions() {
+		if cond.Type == v1alpha1.ConditionTemplateValid.String() ||
+			cond.Type == v1alpha1.ConditionReady.String() ||
+			v1alpha1.IsAdvisoryCondition(cond.Type) {
+			continue
+		}
+		if cond.Status != metav1.ConditionTrue {
+			return status.SetTemplateNotValid(rctx.Context, r, rctx.obj,
+				fmt.Sprintf("Condition %s is %s: %s", cond.Type, cond.Status, cond.Message),
+			)
+		}
+	}
+	return status.SetTemplateValid(rctx.Context, r, rctx.obj, "All template checks passed")
+}
@@ -0,0 +1,133 @@
+package templatecontroller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+	"github.com/diranged/oz/internal/testing/utils"
+)
+
+var _ = Describe("TemplateReconciler", Ordered, func() {
+	Context("verifyTemplateValid()", func() {
+		var (
+			ctx        = context.Background()
+			ns         *v1.Namespace
+			reconciler *TemplateReconciler
+		)
+
+		BeforeAll(func() {
+			By("Should have a namespace to execute tests in")
+			ns = &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: utils.RandomString(8),
+				},
+			}
+			err := k8sClient.Create(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Creating the RequestReconciler")
+			reconciler = &TemplateReconciler{
+				Client:                 k8sClient,
+				APIReader:              k8sClient,
+				Scheme:                 k8sClient.Scheme(),
+				TemplateType:           &v1alpha1.ExecAccessTemplate{},
+				ReconciliationInterval: 0,
+			}
+		})
+
+		AfterAll(func() {
+			By("Should delete the namespace")
+			err := k8sClient.Delete(ctx, ns)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		// newTemplateContext creates an ExecAccessTemplate with the supplied
+		// durations and returns a populated RequestContext for it.
+		newTemplateContext := func(defaultDuration, maxDuration string) *RequestContext {
+			template := &v1alpha1.ExecAccessTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      utils.RandomString(8),
+					Namespace: ns.GetName(),
+				},
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						AllowedGroups:   []string{"foo"},
+						DefaultDuration: defaultDuration,
+						MaxDuration:     maxDuration,
+					},
+					ControllerTargetRef: &v1alpha1.CrossVersionObjectReference{
+						APIVersion: "apps/v1",
+						Kind:       "Deployment",
+						Name:       "junk",
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, template)
+			Expect(err).ToNot(HaveOccurred())
+
+			rctx := newRequestContext(
+				ctx,
+				reconciler.TemplateType,
+				reconcile.Request{
+					NamespacedName: types.NamespacedName{
+						Name:      template.GetName(),
+						Namespace: template.GetNamespace(),
+					},
+				},
+			)
+			err = reconciler.fetchRequestObject(rctx)
+			Expect(err).ToNot(HaveOccurred())
+			return rctx
+		}
+
+		It("verifyTemplateValid() should set True when all conditions pass", func() {
+			By("Populating the RequestContext")
+			rctx := newTemplateContext("1h", "2h")
+			err := reconciler.verifyDuration(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Executing the test")
+			err = reconciler.verifyTemplateValid(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: ConditionTemplateValid = True
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionTemplateValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+			Expect(cond.Reason).To(Equal(string(metav1.StatusSuccess)))
+		})
+
+		It("verifyTemplateValid() should set False when any condition fails", func() {
+			By("Populating the RequestContext")
+			rctx := newTemplateContext("2h", "1h")
+			err := reconciler.verifyDuration(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("Executing the test")
+			err = reconciler.verifyTemplateValid(rctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			// VERIFY: ConditionTemplateValid = False
+			cond := meta.FindStatusCondition(
+				*rctx.obj.GetStatus().GetConditions(),
+				v1alpha1.ConditionTemplateValid.String(),
+			)
+			Expect(cond).ToNot(BeNil())
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(string(metav1.StatusReasonInvalid)))
+			Expect(cond.Message).To(MatchRegexp("TemplateDurationsValid"))
+		})
+	})
+})
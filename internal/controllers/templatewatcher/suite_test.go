@@ -0,0 +1,13 @@
+package templatewatcher
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTemplateWatcher(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "TemplateWatcher Suite")
+}
@@ -0,0 +1,112 @@
+// Package templatewatcher provides a Webhook handler that limits who may write Access Templates,
+// and which Secrets they may expose
+package templatewatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// TemplateAuthorWatcher is a ValidatingWebhookEndpoint that receives calls
+// from the Kubernetes API just before an Access Template is created or
+// updated. Only the configured Authors may write templates - otherwise any
+// user allowed to create a template could write one that grants themselves
+// broad access. This separates "who writes templates" from "who requests
+// access".
+//
+// Templates may also only expose the AllowedEnvFromSecrets to the Pods they
+// launch through their spec.accessConfig.envFrom - otherwise any template
+// author could hand out the contents of any Secret in the namespace. Likewise,
+// their spec.accessConfig.clusterRoleRef may only name one of the
+// BindableClusterRoles - otherwise any template author could hand out the
+// permissions of any ClusterRole, up to cluster-admin. Their
+// spec.accessConfig.namespacePattern, if set, may not match the reserved
+// kube-* namespaces, and their spec.accessConfig.justificationPattern, if set,
+// must be a valid regular expression, or no request could ever be granted
+// through them. For the same reason, the patterns of their
+// spec.accessConfig.parameters must be valid, and their defaults must match
+// them.
+//
+// Deletes are not checked, as removing a template never grants access (and
+// the namespace controller must be able to clean them up).
+type TemplateAuthorWatcher struct {
+	// Authors lists the users and groups that may create and update Access
+	// Templates. If nil, the writes are only limited by RBAC.
+	Authors *v1alpha1.AllowedRequesters
+
+	// AllowedEnvFromSecrets lists the names of the Secrets that templates may
+	// reference in their spec.accessConfig.envFrom. If empty, no Secrets may
+	// be referenced.
+	AllowedEnvFromSecrets []string
+
+	// BindableClusterRoles lists the names of the ClusterRoles that templates
+	// may reference in their spec.accessConfig.clusterRoleRef. If empty, no
+	// ClusterRoles may be referenced.
+	BindableClusterRoles []string
+}
+
+// +kubebuilder:webhook:path=/validate-crds-wizardofoz-co-v1alpha1-accesstemplate,mutating=false,failurePolicy=fail,sideEffects=None,groups=crds.wizardofoz.co,resources=execaccesstemplates;podaccesstemplates,verbs=create;update,versions=v1alpha1,name=vaccesstemplate.kb.io,admissionReviewVersions=v1
+
+// Handle allows the write of an Access Template if it is made by one of the
+// Authors, only references AllowedEnvFromSecrets and BindableClusterRoles, and
+// has a valid namespacePattern, justificationPattern and parameters - and
+// denies it otherwise.
+func (w *TemplateAuthorWatcher) Handle(ctx context.Context, req admission.Request) admission.Response {
+	logger := log.FromContext(ctx)
+
+	if w.Authors != nil && !w.Authors.Allows(req.UserInfo.Username, req.UserInfo.Groups) {
+		msg := fmt.Sprintf("%s is not an authorized author of Access Templates, %s of %s %s/%s denied",
+			req.UserInfo.Username, req.Operation, req.Kind.Kind, req.Namespace, req.Name)
+		logger.Info(msg)
+		return admission.Denied(msg)
+	}
+
+	// Both kinds of Access Templates carry their AccessConfig in the same
+	// place, so there is no need to decode them into their own types.
+	tmpl := struct {
+		Spec struct {
+			AccessConfig v1alpha1.AccessConfig `json:"accessConfig"`
+		} `json:"spec"`
+	}{}
+	if len(req.Object.Raw) > 0 {
+		if err := json.Unmarshal(req.Object.Raw, &tmpl); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+	}
+	if disallowed := tmpl.Spec.AccessConfig.GetDisallowedEnvFromSecrets(w.AllowedEnvFromSecrets); len(disallowed) > 0 {
+		msg := fmt.Sprintf("%s %s/%s references Secrets that are not allowed in envFrom (%s), %s denied",
+			req.Kind.Kind, req.Namespace, req.Name, strings.Join(disallowed, ", "), req.Operation)
+		logger.Info(msg)
+		return admission.Denied(msg)
+	}
+	if !tmpl.Spec.AccessConfig.IsClusterRoleRefBindable(w.BindableClusterRoles) {
+		msg := fmt.Sprintf("%s %s/%s references ClusterRole %s, which is not a bindable ClusterRole, %s denied",
+			req.Kind.Kind, req.Namespace, req.Name, tmpl.Spec.AccessConfig.GetClusterRoleRef(), req.Operation)
+		logger.Info(msg)
+		return admission.Denied(msg)
+	}
+	for _, validate := range []func() error{
+		tmpl.Spec.AccessConfig.ValidateNamespacePattern,
+		tmpl.Spec.AccessConfig.ValidateJustificationPattern,
+		tmpl.Spec.AccessConfig.ValidateParameters,
+	} {
+		if err := validate(); err != nil {
+			msg := fmt.Sprintf("%s %s/%s has an invalid accessConfig: %s, %s denied",
+				req.Kind.Kind, req.Namespace, req.Name, err, req.Operation)
+			logger.Info(msg)
+			return admission.Denied(msg)
+		}
+	}
+
+	logger.Info(fmt.Sprintf("Allowing %s of %s %s/%s by %s",
+		req.Operation, req.Kind.Kind, req.Namespace, req.Name, req.UserInfo.Username))
+	return admission.Allowed("")
+}
@@ -0,0 +1,282 @@
+package templatewatcher
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("TemplateAuthorWatcher", func() {
+	var ctx = context.Background()
+
+	// newRequest returns an admission.Request for the supplied operation on
+	// an ExecAccessTemplate, made by the supplied user.
+	newRequest := func(op admissionv1.Operation, user string, groups ...string) admission.Request {
+		return admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Kind:      metav1.GroupVersionKind{Kind: "ExecAccessTemplate"},
+				Name:      "broad-access",
+				Namespace: "test",
+				Operation: op,
+				UserInfo:  authenticationv1.UserInfo{Username: user, Groups: groups},
+			},
+		}
+	}
+
+	watcher := &TemplateAuthorWatcher{
+		Authors: &v1alpha1.AllowedRequesters{
+			Users:  []string{"alice"},
+			Groups: []string{"platform-admins"},
+		},
+	}
+
+	It("Should allow authorized authors to create and update templates", func() {
+		resp := watcher.Handle(ctx, newRequest(admissionv1.Create, "alice"))
+		Expect(resp.Allowed).To(BeTrue())
+
+		resp = watcher.Handle(ctx, newRequest(admissionv1.Update, "bob", "devs", "platform-admins"))
+		Expect(resp.Allowed).To(BeTrue())
+	})
+
+	It("Should deny anybody else", func() {
+		resp := watcher.Handle(ctx, newRequest(admissionv1.Create, "mallory", "devs"))
+		Expect(resp.Allowed).To(BeFalse())
+		Expect(resp.Result.Reason).To(BeEquivalentTo(
+			"mallory is not an authorized author of Access Templates, " +
+				"CREATE of ExecAccessTemplate test/broad-access denied",
+		))
+
+		resp = watcher.Handle(ctx, newRequest(admissionv1.Update, "mallory"))
+		Expect(resp.Allowed).To(BeFalse())
+	})
+
+	It("Should leave the writes to RBAC without any authors", func() {
+		resp := (&TemplateAuthorWatcher{}).Handle(ctx, newRequest(admissionv1.Create, "mallory"))
+		Expect(resp.Allowed).To(BeTrue())
+	})
+
+	Context("envFrom", func() {
+		watcher := &TemplateAuthorWatcher{AllowedEnvFromSecrets: []string{"db-readonly"}}
+
+		// withEnvFrom returns a request for a PodAccessTemplate whose
+		// accessConfig exposes the supplied Secrets.
+		withEnvFrom := func(secrets ...string) admission.Request {
+			envFrom := []corev1.EnvFromSource{
+				{ConfigMapRef: &corev1.ConfigMapEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "db-settings"},
+				}},
+			}
+			for _, name := range secrets {
+				envFrom = append(envFrom, corev1.EnvFromSource{SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: name},
+				}})
+			}
+			tmpl := &v1alpha1.PodAccessTemplate{
+				Spec: v1alpha1.PodAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{EnvFrom: envFrom},
+				},
+			}
+			raw, err := json.Marshal(tmpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			req := newRequest(admissionv1.Create, "alice")
+			req.Kind.Kind = "PodAccessTemplate"
+			req.Object = runtime.RawExtension{Raw: raw}
+			return req
+		}
+
+		It("Should allow templates exposing only allowed Secrets and ConfigMaps", func() {
+			resp := watcher.Handle(ctx, withEnvFrom("db-readonly"))
+			Expect(resp.Allowed).To(BeTrue())
+
+			resp = watcher.Handle(ctx, withEnvFrom())
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("Should deny templates exposing any other Secret", func() {
+			resp := watcher.Handle(ctx, withEnvFrom("db-readonly", "db-admin"))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Reason).To(BeEquivalentTo(
+				"PodAccessTemplate test/broad-access references Secrets that are not allowed " +
+					"in envFrom (db-admin), CREATE denied",
+			))
+
+			resp = (&TemplateAuthorWatcher{}).Handle(ctx, withEnvFrom("db-readonly"))
+			Expect(resp.Allowed).To(BeFalse())
+		})
+	})
+
+	Context("clusterRoleRef", func() {
+		watcher := &TemplateAuthorWatcher{BindableClusterRoles: []string{"view"}}
+
+		// withClusterRoleRef returns a request for an ExecAccessTemplate whose
+		// accessConfig references the supplied ClusterRole.
+		withClusterRoleRef := func(name string) admission.Request {
+			tmpl := &v1alpha1.ExecAccessTemplate{
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{ClusterRoleRef: name},
+				},
+			}
+			raw, err := json.Marshal(tmpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			req := newRequest(admissionv1.Create, "alice")
+			req.Object = runtime.RawExtension{Raw: raw}
+			return req
+		}
+
+		It("Should allow templates referencing a bindable ClusterRole, or none", func() {
+			resp := watcher.Handle(ctx, withClusterRoleRef("view"))
+			Expect(resp.Allowed).To(BeTrue())
+
+			resp = (&TemplateAuthorWatcher{}).Handle(ctx, withClusterRoleRef(""))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("Should deny templates referencing any other ClusterRole", func() {
+			resp := watcher.Handle(ctx, withClusterRoleRef("cluster-admin"))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Reason).To(BeEquivalentTo(
+				"ExecAccessTemplate test/broad-access references ClusterRole cluster-admin, " +
+					"which is not a bindable ClusterRole, CREATE denied",
+			))
+
+			resp = (&TemplateAuthorWatcher{}).Handle(ctx, withClusterRoleRef("view"))
+			Expect(resp.Allowed).To(BeFalse())
+		})
+	})
+
+	Context("namespacePattern", func() {
+		// withPattern returns a request for an ExecAccessTemplate whose
+		// accessConfig sets the supplied namespacePattern.
+		withPattern := func(pattern string) admission.Request {
+			tmpl := &v1alpha1.ExecAccessTemplate{
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{NamespacePattern: pattern},
+				},
+			}
+			raw, err := json.Marshal(tmpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			req := newRequest(admissionv1.Create, "alice")
+			req.Object = runtime.RawExtension{Raw: raw}
+			return req
+		}
+
+		It("Should allow templates shared with a narrow pattern", func() {
+			resp := watcher.Handle(ctx, withPattern("team-*"))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("Should deny templates shared with every namespace", func() {
+			resp := watcher.Handle(ctx, withPattern("*"))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Reason).To(BeEquivalentTo(
+				"ExecAccessTemplate test/broad-access has an invalid accessConfig: " +
+					`namespacePattern "*" matches the reserved namespace kube-system, CREATE denied`,
+			))
+
+			resp = watcher.Handle(ctx, withPattern("kube-*"))
+			Expect(resp.Allowed).To(BeFalse())
+		})
+
+		It("Should deny templates with an invalid pattern", func() {
+			resp := watcher.Handle(ctx, withPattern("team-["))
+			Expect(resp.Allowed).To(BeFalse())
+		})
+	})
+
+	Context("justificationPattern", func() {
+		// withPattern returns a request for an ExecAccessTemplate whose
+		// accessConfig sets the supplied justificationPattern.
+		withPattern := func(pattern string) admission.Request {
+			tmpl := &v1alpha1.ExecAccessTemplate{
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{JustificationPattern: pattern},
+				},
+			}
+			raw, err := json.Marshal(tmpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			req := newRequest(admissionv1.Update, "alice")
+			req.Object = runtime.RawExtension{Raw: raw}
+			return req
+		}
+
+		It("Should allow templates with a valid pattern", func() {
+			resp := watcher.Handle(ctx, withPattern(`JIRA-\d+`))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("Should deny templates with an invalid pattern", func() {
+			resp := watcher.Handle(ctx, withPattern(`JIRA-(\d+`))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(HavePrefix(
+				"ExecAccessTemplate test/broad-access has an invalid accessConfig: " +
+					`justificationPattern "JIRA-(\\d+" is invalid`,
+			))
+		})
+	})
+
+	Context("parameters", func() {
+		// withParameter returns a request for an ExecAccessTemplate whose
+		// accessConfig declares the supplied parameter.
+		withParameter := func(param v1alpha1.TemplateParameter) admission.Request {
+			tmpl := &v1alpha1.ExecAccessTemplate{
+				Spec: v1alpha1.ExecAccessTemplateSpec{
+					AccessConfig: v1alpha1.AccessConfig{
+						Parameters: []v1alpha1.TemplateParameter{param},
+					},
+				},
+			}
+			raw, err := json.Marshal(tmpl)
+			Expect(err).ToNot(HaveOccurred())
+
+			req := newRequest(admissionv1.Create, "alice")
+			req.Object = runtime.RawExtension{Raw: raw}
+			return req
+		}
+
+		It("Should allow parameters whose default matches their pattern", func() {
+			resp := watcher.Handle(ctx, withParameter(v1alpha1.TemplateParameter{
+				Name: "app", Pattern: "[a-z]+", Default: "web",
+			}))
+			Expect(resp.Allowed).To(BeTrue())
+
+			resp = watcher.Handle(ctx, withParameter(v1alpha1.TemplateParameter{
+				Name: "app", Pattern: "[a-z]+", Required: true,
+			}))
+			Expect(resp.Allowed).To(BeTrue())
+		})
+
+		It("Should deny parameters whose default does not match their pattern", func() {
+			resp := watcher.Handle(ctx, withParameter(v1alpha1.TemplateParameter{
+				Name: "app", Pattern: "[a-z]+", Default: "web-1",
+			}))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(resp.Result.Reason).To(BeEquivalentTo(
+				"ExecAccessTemplate test/broad-access has an invalid accessConfig: " +
+					`parameter "app" default "web-1" does not match pattern "[a-z]+", CREATE denied`,
+			))
+		})
+
+		It("Should deny parameters with an invalid pattern", func() {
+			resp := watcher.Handle(ctx, withParameter(v1alpha1.TemplateParameter{
+				Name: "app", Pattern: "[a-z",
+			}))
+			Expect(resp.Allowed).To(BeFalse())
+			Expect(string(resp.Result.Reason)).To(ContainSubstring(`parameter "app" has an invalid pattern`))
+		})
+	})
+})
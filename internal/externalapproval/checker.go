@@ -0,0 +1,123 @@
+package externalapproval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// State is the approval state of an Access Request in the external system.
+type State string
+
+const (
+	// StatePending means that the external system has not decided yet.
+	StatePending State = "pending"
+
+	// StateApproved means that the external system approved the request.
+	StateApproved State = "approved"
+
+	// StateRejected means that the external system rejected the request.
+	StateRejected State = "rejected"
+)
+
+// Status is the JSON document returned by the approval-status endpoint.
+type Status struct {
+	// State is the approval state of the Access Request.
+	State State `json:"state"`
+
+	// Message optionally explains the state (eg, "CHG0012345 approved by
+	// bob"). It is surfaced in the ConditionAccessApproved condition.
+	Message string `json:"message,omitempty"`
+}
+
+// DefaultTimeout is how long a Checker waits for an approval-status endpoint
+// to answer, unless it is configured otherwise.
+const DefaultTimeout = 10 * time.Second
+
+// Checker fetches the Status of Access Requests from approval-status
+// endpoints.
+type Checker struct {
+	// Client is used to make the requests. Defaults to an http.Client with
+	// the Timeout. A Client that is set should have a timeout of its own.
+	Client *http.Client
+
+	// Timeout is how long to wait for the endpoint to answer, when using the
+	// default Client. Defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Check fetches the Status of the supplied Access Request from the endpoint.
+// The request is identified by the "kind", "namespace", "name", "uid" and
+// "requester" query parameters, added to any the endpoint already has.
+//
+// Returns:
+//   - An "error" if the endpoint could not be reached, did not answer with a
+//     2xx status code, or answered with an unknown State
+func (c *Checker) Check(
+	ctx context.Context,
+	endpoint string,
+	req v1alpha1.IRequestResource,
+) (*Status, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	query.Set("kind", reflect.TypeOf(req).Elem().Name())
+	query.Set("namespace", req.GetNamespace())
+	query.Set("name", req.GetName())
+	query.Set("uid", string(req.GetUID()))
+	query.Set("requester", v1alpha1.GetRequester(req))
+	u.RawQuery = query.Encode()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+
+	resp, err := c.getClient().Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("approval status from %s failed with %s: %s", u.Host, resp.Status, body)
+	}
+
+	status := &Status{}
+	if err := json.Unmarshal(body, status); err != nil {
+		return nil, fmt.Errorf("invalid approval status from %s: %w", u.Host, err)
+	}
+	switch status.State {
+	case StatePending, StateApproved, StateRejected:
+		return status, nil
+	}
+	return nil, fmt.Errorf("unknown approval state %q from %s", status.State, u.Host)
+}
+
+// getClient returns the Client, or an http.Client with the Timeout if none is
+// set. An endpoint that never answers must not hold up the reconcile of the
+// request forever.
+func (c *Checker) getClient() *http.Client {
+	if c.Client != nil {
+		return c.Client
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
@@ -0,0 +1,109 @@
+package externalapproval
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+var _ = Describe("Checker", func() {
+	var (
+		ctx      = context.Background()
+		server   *httptest.Server
+		requests []*http.Request
+		status   int
+		response string
+		checker  *Checker
+	)
+
+	request := &v1alpha1.ExecAccessRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "my-request",
+			Namespace:   "default",
+			UID:         "1234",
+			Annotations: map[string]string{v1alpha1.RequestedByAnnotation: "alice"},
+		},
+	}
+
+	BeforeEach(func() {
+		requests, status, response = nil, http.StatusOK, `{"state": "pending"}`
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(response))
+		}))
+		DeferCleanup(server.Close)
+		checker = &Checker{}
+	})
+
+	It("Should GET the status of the request", func() {
+		ret, err := checker.Check(ctx, server.URL+"/approvals?system=servicenow", request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ret.State).To(Equal(StatePending))
+
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Method).To(Equal(http.MethodGet))
+		Expect(requests[0].URL.Path).To(Equal("/approvals"))
+		query := requests[0].URL.Query()
+		Expect(query.Get("system")).To(Equal("servicenow"))
+		Expect(query.Get("kind")).To(Equal("ExecAccessRequest"))
+		Expect(query.Get("namespace")).To(Equal("default"))
+		Expect(query.Get("name")).To(Equal("my-request"))
+		Expect(query.Get("uid")).To(Equal("1234"))
+		Expect(query.Get("requester")).To(Equal("alice"))
+	})
+
+	It("Should return the state and message reported by the endpoint", func() {
+		response = `{"state": "approved", "message": "CHG0012345 approved by bob"}`
+		ret, err := checker.Check(ctx, server.URL, request)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(ret.State).To(Equal(StateApproved))
+		Expect(ret.Message).To(Equal("CHG0012345 approved by bob"))
+	})
+
+	It("Should return an error on a failed response", func() {
+		status = http.StatusServiceUnavailable
+		_, err := checker.Check(ctx, server.URL, request)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("503 Service Unavailable"))
+	})
+
+	It("Should give up on an endpoint that does not answer in time", func() {
+		release := make(chan struct{})
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		DeferCleanup(slow.Close)
+		DeferCleanup(func() { close(release) })
+
+		checker = &Checker{Timeout: 50 * time.Millisecond}
+		_, err := checker.Check(ctx, slow.URL, request)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Client.Timeout exceeded"))
+	})
+
+	It("Should default to a client with a timeout", func() {
+		Expect((&Checker{}).getClient().Timeout).To(Equal(DefaultTimeout))
+		client := &http.Client{}
+		Expect((&Checker{Client: client, Timeout: time.Second}).getClient()).To(BeIdenticalTo(client))
+	})
+
+	It("Should return an error on an invalid or unknown state", func() {
+		response = `not json`
+		_, err := checker.Check(ctx, server.URL, request)
+		Expect(err).To(HaveOccurred())
+
+		response = `{"state": "maybe"}`
+		_, err = checker.Check(ctx, server.URL, request)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(`unknown approval state "maybe"`))
+	})
+})
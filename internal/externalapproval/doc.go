@@ -0,0 +1,9 @@
+// Package externalapproval checks the approval status of Access Requests in an
+// external approval system (such as ServiceNow or Jira), for Access Templates
+// that defer their approvals to one.
+//
+// The external system is expected to expose an approval-status endpoint that
+// the Checker polls with a GET request. The endpoint identifies the Access
+// Request from the query parameters, and answers with a JSON Status document
+// holding one of the pending, approved or rejected States.
+package externalapproval
@@ -0,0 +1,13 @@
+package externalapproval
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestExternalApproval(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ExternalApproval Suite")
+}
@@ -0,0 +1,12 @@
+// Package granttoken issues signed JSON Web Tokens describing the access
+// granted by an Access Request - who was granted access, to what, and until
+// when - so that systems downstream of the cluster can verify a grant
+// cryptographically rather than by calling back into the Kubernetes API.
+//
+// A Signer is configured with one or more private keys. The first key signs
+// new tokens, and the public halves of all of them are published as a JSON
+// Web Key Set. Keys are rotated by adding the new key to the end of the list
+// (so that verifiers can pick it up), then moving it to the front (so that it
+// signs new tokens), and finally dropping the old key once every token it has
+// signed has expired.
+package granttoken
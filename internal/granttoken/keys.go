@@ -0,0 +1,145 @@
+package granttoken
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signingKey is a single private key, along with the algorithm it signs with
+// and its key ID.
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// parseSigningKey parses a PEM encoded RSA or ECDSA private key, in either
+// PKCS#1, SEC 1 or PKCS#8 form. RSA keys sign with RS256, and ECDSA keys with
+// the ES algorithm matching their curve.
+func parseSigningKey(data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM encoded key found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.private, key.method = k, jwt.SigningMethodRS256
+	case *ecdsa.PrivateKey:
+		key.private = k
+		switch k.Curve {
+		case elliptic.P256():
+			key.method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.method = jwt.SigningMethodES384
+		case elliptic.P521():
+			key.method = jwt.SigningMethodES512
+		default:
+			return nil, fmt.Errorf("unsupported ECDSA curve %s", k.Curve.Params().Name)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+
+	key.id, err = thumbprint(key.jwk())
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// JSONWebKey is the public half of a signing key, in the form described by
+// RFC 7517.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// ECDSA keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a set of JSONWebKeys, in the form described by RFC 7517.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// jwk returns the public half of the key as a JSONWebKey.
+func (k *signingKey) jwk() JSONWebKey {
+	ret := JSONWebKey{KeyID: k.id, Use: "sig", Algorithm: k.method.Alg()}
+	switch pub := k.private.Public().(type) {
+	case *rsa.PublicKey:
+		ret.KeyType = "RSA"
+		ret.N = encodeInt(pub.N, 0)
+		ret.E = encodeInt(big.NewInt(int64(pub.E)), 0)
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		ret.KeyType = "EC"
+		ret.Curve = pub.Curve.Params().Name
+		ret.X = encodeInt(pub.X, size)
+		ret.Y = encodeInt(pub.Y, size)
+	}
+	return ret
+}
+
+// thumbprint computes the RFC 7638 thumbprint of a JSONWebKey, which is used
+// as its key ID. The thumbprint only covers the required members of the key,
+// in lexicographic order.
+func thumbprint(k JSONWebKey) (string, error) {
+	var canonical string
+	switch k.KeyType {
+	case "RSA":
+		canonical = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`, k.E, k.N)
+	case "EC":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":"EC","x":%q,"y":%q}`, k.Curve, k.X, k.Y)
+	default:
+		return "", fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// encodeInt base64url encodes the big-endian bytes of i, left-padded with
+// zeros to size bytes.
+func encodeInt(i *big.Int, size int) string {
+	b := i.Bytes()
+	if len(b) < size {
+		b = append(make([]byte, size-len(b)), b...)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
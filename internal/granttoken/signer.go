@@ -0,0 +1,201 @@
+package granttoken
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// DefaultIssuer is the "iss" claim of the tokens, unless configured otherwise.
+const DefaultIssuer = "oz"
+
+// Claims describes the access granted by a single Access Request. The
+// registered "sub" claim is the user that was granted access, "jti" is the
+// UID of the request and "exp" is when the access expires.
+type Claims struct {
+	jwt.RegisteredClaims
+
+	// Kind, Namespace and Name identify the Access Request.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// Template is the name of the Access Template the request was made against.
+	Template string `json:"template"`
+
+	// Target is the name of the Pod that access was granted to, if the
+	// request targets one.
+	Target string `json:"target,omitempty"`
+
+	// RequestedBy is the user that created the request, which is only
+	// different from the subject if the request was delegated or transferred.
+	RequestedBy string `json:"requestedBy,omitempty"`
+}
+
+// ForRequest builds the Claims describing the access granted by req, which
+// expires at expiresAt.
+func ForRequest(req v1alpha1.IRequestResource, issuedAt time.Time, expiresAt time.Time) *Claims {
+	subject := req.GetTransferTo()
+	if subject == "" {
+		subject = req.GetRequestFor()
+	}
+	if subject == "" {
+		subject = v1alpha1.GetRequester(req)
+	}
+
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			ID:        string(req.GetUID()),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			NotBefore: jwt.NewNumericDate(issuedAt),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Kind:        reflect.TypeOf(req).Elem().Name(),
+		Namespace:   req.GetNamespace(),
+		Name:        req.GetName(),
+		Template:    req.GetTemplateName(),
+		RequestedBy: v1alpha1.GetRequester(req),
+	}
+	if podReq, ok := req.(v1alpha1.IPodRequestResource); ok {
+		claims.Target = podReq.GetPodName()
+	}
+	return claims
+}
+
+// Signer signs grant tokens with the first of its keys, and verifies them
+// against any of its keys.
+type Signer struct {
+	// Issuer is the "iss" claim of the tokens. Defaults to DefaultIssuer.
+	Issuer string
+
+	// Audience is the optional "aud" claim of the tokens.
+	Audience []string
+
+	keys []*signingKey
+}
+
+// NewSigner parses the supplied PEM encoded private keys. The first key signs
+// new tokens, the rest are only kept so that tokens they have already signed
+// can still be verified while the keys are rotated.
+func NewSigner(issuer string, audience []string, pemKeys ...[]byte) (*Signer, error) {
+	if len(pemKeys) == 0 {
+		return nil, errors.New("at least one signing key is required")
+	}
+	s := &Signer{Issuer: issuer, Audience: audience}
+	seen := map[string]bool{}
+	for i, data := range pemKeys {
+		key, err := parseSigningKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key %d: %w", i, err)
+		}
+		if seen[key.id] {
+			return nil, fmt.Errorf("invalid signing key %d: duplicate of key %s", i, key.id)
+		}
+		seen[key.id] = true
+		s.keys = append(s.keys, key)
+	}
+	return s, nil
+}
+
+// KeyID returns the ID of the key that signs new tokens.
+func (s *Signer) KeyID() string {
+	return s.keys[0].id
+}
+
+func (s *Signer) getIssuer() string {
+	if s.Issuer != "" {
+		return s.Issuer
+	}
+	return DefaultIssuer
+}
+
+// Sign fills in the issuer and audience of the claims, and signs them with
+// the current key. The ID of the key is set in the "kid" header.
+func (s *Signer) Sign(claims *Claims) (string, error) {
+	claims.Issuer = s.getIssuer()
+	claims.Audience = s.Audience
+	key := s.keys[0]
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// Verify checks the signature of the token against all of the keys of the
+// Signer, validates its time based claims and issuer, and returns its Claims
+// along with the ID of the key that signed it.
+func (s *Signer) Verify(tokenString string) (*Claims, string, error) {
+	methods := []string{}
+	for _, key := range s.keys {
+		methods = append(methods, key.method.Alg())
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		for _, key := range s.keys {
+			if key.id == kid && key.method.Alg() == token.Method.Alg() {
+				return key.private.Public(), nil
+			}
+		}
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}, jwt.WithValidMethods(methods))
+	if err != nil {
+		return nil, "", err
+	}
+	if !claims.VerifyIssuer(s.getIssuer(), true) {
+		return nil, "", fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	kid, _ := token.Header["kid"].(string)
+	return claims, kid, nil
+}
+
+// IsCurrent returns true if the token is valid, was signed by the current key,
+// and describes the same grant as the supplied claims - in which case there is
+// no need to issue a new one. The issued-at time is not compared.
+func (s *Signer) IsCurrent(tokenString string, want *Claims) bool {
+	if tokenString == "" {
+		return false
+	}
+	got, kid, err := s.Verify(tokenString)
+	if err != nil || kid != s.KeyID() {
+		return false
+	}
+	return got.Subject == want.Subject &&
+		got.ID == want.ID &&
+		got.ExpiresAt != nil && want.ExpiresAt != nil &&
+		got.ExpiresAt.Equal(want.ExpiresAt.Time) &&
+		strings.Join(got.Audience, ",") == strings.Join(s.Audience, ",") &&
+		got.Kind == want.Kind &&
+		got.Namespace == want.Namespace &&
+		got.Name == want.Name &&
+		got.Template == want.Template &&
+		got.Target == want.Target &&
+		got.RequestedBy == want.RequestedBy
+}
+
+// JWKS returns the public halves of all of the keys of the Signer, so that
+// downstream systems can verify tokens signed by any of them.
+func (s *Signer) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range s.keys {
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	return set
+}
+
+// Handler returns an http.Handler that serves the JWKS() as JSON.
+func (s *Signer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.JWKS())
+	})
+}
@@ -0,0 +1,203 @@
+package granttoken
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+func newRSAKey() []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).ToNot(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+func newECKey() []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+var _ = Describe("Signer", func() {
+	var (
+		rsaKey  = newRSAKey()
+		ecKey   = newECKey()
+		now     = time.Now()
+		request *v1alpha1.ExecAccessRequest
+	)
+
+	BeforeEach(func() {
+		request = &v1alpha1.ExecAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "my-request",
+				Namespace:   "default",
+				UID:         "1234",
+				Annotations: map[string]string{v1alpha1.RequestedByAnnotation: "alice"},
+			},
+			Spec:   v1alpha1.ExecAccessRequestSpec{TemplateName: "my-template"},
+			Status: v1alpha1.ExecAccessRequestStatus{PodName: "my-pod"},
+		}
+	})
+
+	It("ForRequest() should describe the grant", func() {
+		claims := ForRequest(request, now, now.Add(time.Hour))
+
+		Expect(claims.Subject).To(Equal("alice"))
+		Expect(claims.ID).To(Equal("1234"))
+		Expect(claims.ExpiresAt.Unix()).To(Equal(now.Add(time.Hour).Unix()))
+		Expect(claims.Kind).To(Equal("ExecAccessRequest"))
+		Expect(claims.Namespace).To(Equal("default"))
+		Expect(claims.Name).To(Equal("my-request"))
+		Expect(claims.Template).To(Equal("my-template"))
+		Expect(claims.Target).To(Equal("my-pod"))
+		Expect(claims.RequestedBy).To(Equal("alice"))
+
+		By("Naming the delegated user as the subject")
+		request.Spec.RequestFor = "bob"
+		Expect(ForRequest(request, now, now).Subject).To(Equal("bob"))
+	})
+
+	It("Sign() should produce a token with the grant claims, verifiable with the JWKS", func() {
+		signer, err := NewSigner("", []string{"downstream"}, ecKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		tokenString, err := signer.Sign(ForRequest(request, now, now.Add(time.Hour)))
+		Expect(err).ToNot(HaveOccurred())
+
+		// VERIFY: The claims, decoded by hand
+		parts := strings.Split(tokenString, ".")
+		Expect(parts).To(HaveLen(3))
+		header, payload := map[string]any{}, map[string]any{}
+		raw, _ := base64.RawURLEncoding.DecodeString(parts[0])
+		Expect(json.Unmarshal(raw, &header)).To(Succeed())
+		raw, _ = base64.RawURLEncoding.DecodeString(parts[1])
+		Expect(json.Unmarshal(raw, &payload)).To(Succeed())
+		Expect(header).To(HaveKeyWithValue("alg", "ES256"))
+		Expect(header).To(HaveKeyWithValue("kid", signer.KeyID()))
+		Expect(payload).To(HaveKeyWithValue("iss", "oz"))
+		Expect(payload).To(HaveKeyWithValue("sub", "alice"))
+		Expect(payload).To(HaveKeyWithValue("aud", []any{"downstream"}))
+		Expect(payload).To(HaveKeyWithValue("jti", "1234"))
+		Expect(payload).To(HaveKeyWithValue("exp", float64(now.Add(time.Hour).Unix())))
+		Expect(payload).To(HaveKeyWithValue("target", "my-pod"))
+		Expect(payload).To(HaveKeyWithValue("template", "my-template"))
+
+		// VERIFY: The signature, with nothing but the published public key
+		jwks := signer.JWKS()
+		Expect(jwks.Keys).To(HaveLen(1))
+		jwk := jwks.Keys[0]
+		Expect(jwk.KeyID).To(Equal(signer.KeyID()))
+		Expect(jwk.KeyType).To(Equal("EC"))
+		x, _ := base64.RawURLEncoding.DecodeString(jwk.X)
+		y, _ := base64.RawURLEncoding.DecodeString(jwk.Y)
+		pub := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		_, err = jwt.Parse(tokenString, func(*jwt.Token) (any, error) { return pub, nil })
+		Expect(err).ToNot(HaveOccurred())
+
+		By("Rejecting a tampered token")
+		_, _, err = signer.Verify(parts[0] + "." + parts[1] + "x." + parts[2])
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Verify() should reject expired tokens, and tokens from other issuers or keys", func() {
+		signer, err := NewSigner("oz", nil, rsaKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		expired, err := signer.Sign(ForRequest(request, now.Add(-2*time.Hour), now.Add(-time.Hour)))
+		Expect(err).ToNot(HaveOccurred())
+		_, _, err = signer.Verify(expired)
+		Expect(err).To(MatchError(ContainSubstring("expired")))
+
+		other, err := NewSigner("someone-else", nil, rsaKey)
+		Expect(err).ToNot(HaveOccurred())
+		foreign, err := other.Sign(ForRequest(request, now, now.Add(time.Hour)))
+		Expect(err).ToNot(HaveOccurred())
+		_, _, err = signer.Verify(foreign)
+		Expect(err).To(MatchError(ContainSubstring("unexpected issuer")))
+
+		other, err = NewSigner("oz", nil, ecKey)
+		Expect(err).ToNot(HaveOccurred())
+		foreign, err = other.Sign(ForRequest(request, now, now.Add(time.Hour)))
+		Expect(err).ToNot(HaveOccurred())
+		_, _, err = signer.Verify(foreign)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("IsCurrent() should only accept tokens for the same grant, signed by the current key", func() {
+		oldSigner, err := NewSigner("", nil, rsaKey)
+		Expect(err).ToNot(HaveOccurred())
+		claims := ForRequest(request, now, now.Add(time.Hour))
+		oldToken, err := oldSigner.Sign(claims)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(oldSigner.IsCurrent(oldToken, ForRequest(request, now.Add(time.Minute), now.Add(time.Hour)))).
+			To(BeTrue())
+		Expect(oldSigner.IsCurrent(oldToken, ForRequest(request, now, now.Add(2*time.Hour)))).To(BeFalse())
+		Expect(oldSigner.IsCurrent("", claims)).To(BeFalse())
+
+		By("Rotating to a new key, keeping the old one for verification")
+		rotated, err := NewSigner("", nil, ecKey, rsaKey)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rotated.KeyID()).ToNot(Equal(oldSigner.KeyID()))
+		Expect(rotated.JWKS().Keys).To(HaveLen(2))
+
+		// VERIFY: Old tokens still verify, but are due to be re-issued
+		_, kid, err := rotated.Verify(oldToken)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(kid).To(Equal(oldSigner.KeyID()))
+		Expect(rotated.IsCurrent(oldToken, claims)).To(BeFalse())
+
+		newToken, err := rotated.Sign(claims)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rotated.IsCurrent(newToken, claims)).To(BeTrue())
+	})
+
+	It("NewSigner() should reject missing, invalid and duplicate keys", func() {
+		_, err := NewSigner("", nil)
+		Expect(err).To(MatchError("at least one signing key is required"))
+
+		_, err = NewSigner("", nil, []byte("bogus"))
+		Expect(err).To(MatchError("invalid signing key 0: no PEM encoded key found"))
+
+		_, err = NewSigner("", nil, rsaKey, rsaKey)
+		Expect(err).To(MatchError(ContainSubstring("invalid signing key 1: duplicate of key")))
+	})
+
+	It("Handler() should serve the JWKS", func() {
+		signer, err := NewSigner("", nil, rsaKey, ecKey)
+		Expect(err).ToNot(HaveOccurred())
+
+		w := httptest.NewRecorder()
+		signer.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/jwks.json", nil))
+
+		Expect(w.Code).To(Equal(http.StatusOK))
+		ret := JSONWebKeySet{}
+		Expect(json.Unmarshal(w.Body.Bytes(), &ret)).To(Succeed())
+		Expect(ret).To(Equal(signer.JWKS()))
+		Expect(ret.Keys[0].Algorithm).To(Equal("RS256"))
+		Expect(ret.Keys[1].Algorithm).To(Equal("ES256"))
+	})
+})
@@ -0,0 +1,13 @@
+package granttoken
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGrantToken(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "GrantToken Suite")
+}
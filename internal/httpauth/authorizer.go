@@ -0,0 +1,105 @@
+package httpauth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+//+kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+//+kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// Authorizer wraps http.Handlers so that they are only reached by callers
+// that the Kubernetes API authenticates and authorizes (see the package
+// documentation).
+type Authorizer struct {
+	// Client creates the TokenReviews and SubjectAccessReviews. It must not
+	// be a cached client.
+	Client client.Client
+}
+
+// Protect returns an http.Handler that authenticates and authorizes every
+// call before passing it on to the supplied handler. Calls without a valid
+// bearer token are answered with a 401, and calls from users that are not
+// allowed on the path with a 403.
+func (a *Authorizer) Protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, httpReq *http.Request) {
+		log := logf.FromContext(httpReq.Context())
+
+		token, ok := bearerToken(httpReq)
+		if !ok {
+			http.Error(w, "a bearer token is required", http.StatusUnauthorized)
+			return
+		}
+
+		tokenReview := &authenticationv1.TokenReview{
+			Spec: authenticationv1.TokenReviewSpec{Token: token},
+		}
+		if err := a.Client.Create(httpReq.Context(), tokenReview); err != nil {
+			log.Error(err, "Failed to authenticate the caller", "path", httpReq.URL.Path)
+			http.Error(w, "unable to authenticate the caller", http.StatusInternalServerError)
+			return
+		}
+		if !tokenReview.Status.Authenticated {
+			http.Error(w, "the bearer token is not valid", http.StatusUnauthorized)
+			return
+		}
+
+		user := tokenReview.Status.User
+		verb := strings.ToLower(httpReq.Method)
+		accessReview := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:   user.Username,
+				UID:    user.UID,
+				Groups: user.Groups,
+				Extra:  extra(user.Extra),
+				NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+					Path: httpReq.URL.Path,
+					Verb: verb,
+				},
+			},
+		}
+		if err := a.Client.Create(httpReq.Context(), accessReview); err != nil {
+			log.Error(err, "Failed to authorize the caller", "path", httpReq.URL.Path)
+			http.Error(w, "unable to authorize the caller", http.StatusInternalServerError)
+			return
+		}
+		if !accessReview.Status.Allowed {
+			http.Error(w, fmt.Sprintf("%s may not %s %s",
+				user.Username, verb, httpReq.URL.Path), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, httpReq)
+	})
+}
+
+// bearerToken returns the token from the Authorization header of the supplied
+// request, if it carries one.
+func bearerToken(httpReq *http.Request) (string, bool) {
+	const prefix = "bearer "
+	header := httpReq.Header.Get("Authorization")
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	token := strings.TrimSpace(header[len(prefix):])
+	return token, token != ""
+}
+
+// extra converts the extra attributes of an authenticated user into those of
+// a SubjectAccessReview.
+func extra(in map[string]authenticationv1.ExtraValue) map[string]authorizationv1.ExtraValue {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]authorizationv1.ExtraValue, len(in))
+	for k, v := range in {
+		out[k] = authorizationv1.ExtraValue(v)
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package httpauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// reviewingClient answers TokenReviews and SubjectAccessReviews the way the
+// Kubernetes API would, from its tokens and allowed maps.
+type reviewingClient struct {
+	client.Client
+
+	// tokens maps each valid token to the user it belongs to.
+	tokens map[string]string
+
+	// allowed lists the "user verb path" combinations that are authorized.
+	allowed map[string]bool
+
+	// reviews records the SubjectAccessReviews that were made.
+	reviews []authorizationv1.SubjectAccessReviewSpec
+}
+
+func (c *reviewingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	switch review := obj.(type) {
+	case *authenticationv1.TokenReview:
+		if user, ok := c.tokens[review.Spec.Token]; ok {
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: user, Groups: []string{"ops"}}
+		}
+		return nil
+	case *authorizationv1.SubjectAccessReview:
+		c.reviews = append(c.reviews, review.Spec)
+		attrs := review.Spec.NonResourceAttributes
+		review.Status.Allowed = c.allowed[review.Spec.User+" "+attrs.Verb+" "+attrs.Path]
+		return nil
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+var _ = Describe("Authorizer", func() {
+	var (
+		cl      *reviewingClient
+		handler http.Handler
+	)
+
+	// call POSTs to the protected handler with the supplied Authorization
+	// header (if any), and returns the response.
+	call := func(authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/whatif/execaccessrequest", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	BeforeEach(func() {
+		cl = &reviewingClient{
+			Client:  fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+			tokens:  map[string]string{"alice-token": "alice", "bob-token": "bob"},
+			allowed: map[string]bool{"alice post /whatif/execaccessrequest": true},
+		}
+		authorizer := &Authorizer{Client: cl}
+		handler = authorizer.Protect(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		}))
+	})
+
+	It("Should pass authorized callers on to the handler", func() {
+		rec := call("Bearer alice-token")
+		Expect(rec.Code).To(Equal(http.StatusOK))
+		Expect(rec.Body.String()).To(Equal("ok"))
+		Expect(cl.reviews).To(HaveLen(1))
+		Expect(cl.reviews[0].User).To(Equal("alice"))
+		Expect(cl.reviews[0].Groups).To(Equal([]string{"ops"}))
+	})
+
+	It("Should reject callers without a bearer token", func() {
+		Expect(call("").Code).To(Equal(http.StatusUnauthorized))
+		Expect(call("Basic YWxpY2U6cGFzcw==").Code).To(Equal(http.StatusUnauthorized))
+		Expect(call("Bearer ").Code).To(Equal(http.StatusUnauthorized))
+		Expect(cl.reviews).To(BeEmpty())
+	})
+
+	It("Should reject callers with an invalid bearer token", func() {
+		rec := call("Bearer forged")
+		Expect(rec.Code).To(Equal(http.StatusUnauthorized))
+		Expect(cl.reviews).To(BeEmpty())
+	})
+
+	It("Should reject callers that are not authorized on the path", func() {
+		rec := call("Bearer bob-token")
+		Expect(rec.Code).To(Equal(http.StatusForbidden))
+		Expect(rec.Body.String()).To(Equal("bob may not post /whatif/execaccessrequest\n"))
+	})
+})
@@ -0,0 +1,16 @@
+// Package httpauth protects the HTTP endpoints that the controller serves
+// alongside its metrics with the authentication and authorization of the
+// Kubernetes API, the same way that the API server protects its own
+// non-resource URLs (eg, /healthz).
+//
+// Callers present a bearer token, which is authenticated with a TokenReview.
+// The user it belongs to must then be allowed the lower-cased HTTP method of
+// the call on the path of the endpoint, which is checked with a
+// SubjectAccessReview. For example, a ClusterRole granting
+//
+//	rules:
+//	- nonResourceURLs: ["/whatif/*"]
+//	  verbs: ["post"]
+//
+// allows its subjects to use the what-if endpoints.
+package httpauth
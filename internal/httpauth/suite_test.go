@@ -0,0 +1,13 @@
+package httpauth
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestHTTPAuth(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "HTTPAuth Suite")
+}
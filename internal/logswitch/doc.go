@@ -0,0 +1,11 @@
+// Package logswitch lets the level and format of the controller logs be
+// changed while the controller is running, so that a stuck reconcile can be
+// debugged in production without a restart.
+//
+// A Switch holds the current level and format, and builds the zapcore.Core
+// that the root logger writes through. Every logger derived from it - with
+// any names and values attached - follows the Switch, and both settings are
+// stored atomically so that they can be flipped while other goroutines log.
+// The Switch is driven over HTTP by the handler returned from its Handler
+// method.
+package logswitch
@@ -0,0 +1,13 @@
+package logswitch
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLogSwitch(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "LogSwitch Suite")
+}
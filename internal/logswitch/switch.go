@@ -0,0 +1,237 @@
+package logswitch
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	ctrlzap "sigs.k8s.io/controller-runtime/pkg/log/zap"
+)
+
+// The formats that the logs can be written in.
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Switch holds the level and format of the controller logs.
+type Switch struct {
+	level zap.AtomicLevel
+	json  atomic.Bool
+}
+
+// New returns a Switch that starts out at the supplied level, writing in the
+// supplied format.
+//
+// Returns:
+//   - An "error" if the format is not one of FormatJSON or FormatConsole
+func New(level zap.AtomicLevel, format string) (*Switch, error) {
+	s := &Switch{level: level}
+	if err := s.SetFormat(format); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Level returns the zap.AtomicLevel that the Switch controls, which may be
+// shared with other loggers.
+func (s *Switch) Level() zap.AtomicLevel {
+	return s.level
+}
+
+// LevelString returns the current level, in the form accepted by SetLevel.
+func (s *Switch) LevelString() string {
+	level := s.level.Level()
+	if level < zapcore.DebugLevel {
+		return strconv.Itoa(-int(level))
+	}
+	return level.String()
+}
+
+// SetLevel changes the level to one of "debug", "info" or "error", or to an
+// integer verbosity greater than zero (eg "5" logs everything up to V(5)) -
+// the same values that the --zap-log-level flag accepts.
+func (s *Switch) SetLevel(value string) error {
+	level, err := parseLevel(value)
+	if err != nil {
+		return err
+	}
+	s.level.SetLevel(level)
+	return nil
+}
+
+// Format returns the current format.
+func (s *Switch) Format() string {
+	if s.json.Load() {
+		return FormatJSON
+	}
+	return FormatConsole
+}
+
+// SetFormat changes the format to FormatJSON or FormatConsole.
+func (s *Switch) SetFormat(format string) error {
+	useJSON, err := parseFormat(format)
+	if err != nil {
+		return err
+	}
+	s.json.Store(useJSON)
+	return nil
+}
+
+// Core returns a zapcore.Core that writes the entries enabled by the current
+// level to the sink, in the current format. The timeEncoder (if nil, RFC3339)
+// formats the timestamp of each entry, and verbose is passed on to the
+// controller-runtime ctrlzap.KubeAwareEncoder.
+func (s *Switch) Core(
+	sink zapcore.WriteSyncer,
+	timeEncoder zapcore.TimeEncoder,
+	verbose bool,
+) zapcore.Core {
+	if timeEncoder == nil {
+		timeEncoder = zapcore.RFC3339TimeEncoder
+	}
+	jsonCfg := zap.NewProductionEncoderConfig()
+	jsonCfg.EncodeTime = timeEncoder
+	consoleCfg := zap.NewDevelopmentEncoderConfig()
+	consoleCfg.EncodeTime = timeEncoder
+
+	return &switchingCore{
+		s: s,
+		json: zapcore.NewCore(
+			&ctrlzap.KubeAwareEncoder{Encoder: zapcore.NewJSONEncoder(jsonCfg), Verbose: verbose},
+			sink, s.level,
+		),
+		console: zapcore.NewCore(
+			&ctrlzap.KubeAwareEncoder{Encoder: zapcore.NewConsoleEncoder(consoleCfg), Verbose: verbose},
+			sink, s.level,
+		),
+	}
+}
+
+// Settings is the body of the requests to, and responses from, the Handler.
+type Settings struct {
+	Level  string `json:"level,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// Handler returns an http.Handler that responds to GET requests with the
+// current Settings, and to PUT requests by applying the supplied Settings
+// (either of which may be left out) and responding with the result. Nothing
+// is changed unless all of the supplied Settings are valid.
+func (s *Switch) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, httpReq *http.Request) {
+		switch httpReq.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var settings Settings
+			if err := json.NewDecoder(httpReq.Body).Decode(&settings); err != nil {
+				http.Error(w, fmt.Sprintf("invalid body: %s", err), http.StatusBadRequest)
+				return
+			}
+			if err := s.apply(settings); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "only GET and PUT are supported", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Settings{Level: s.LevelString(), Format: s.Format()})
+	})
+}
+
+// apply validates all of the supplied Settings, then applies them.
+func (s *Switch) apply(settings Settings) error {
+	var level zapcore.Level
+	var useJSON bool
+	var err error
+	if settings.Level != "" {
+		if level, err = parseLevel(settings.Level); err != nil {
+			return err
+		}
+	}
+	if settings.Format != "" {
+		if useJSON, err = parseFormat(settings.Format); err != nil {
+			return err
+		}
+	}
+
+	if settings.Level != "" {
+		s.level.SetLevel(level)
+	}
+	if settings.Format != "" {
+		s.json.Store(useJSON)
+	}
+	return nil
+}
+
+// parseLevel parses a level in the form accepted by SetLevel.
+func parseLevel(value string) (zapcore.Level, error) {
+	switch strings.ToLower(value) {
+	case "debug":
+		return zapcore.DebugLevel, nil
+	case "info":
+		return zapcore.InfoLevel, nil
+	case "error":
+		return zapcore.ErrorLevel, nil
+	}
+	verbosity, err := strconv.Atoi(value)
+	if err != nil || verbosity <= 0 || verbosity > 127 {
+		return 0, fmt.Errorf("invalid log level %q", value)
+	}
+	return zapcore.Level(-verbosity), nil
+}
+
+// parseFormat parses a format, returning true for FormatJSON.
+func parseFormat(format string) (bool, error) {
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		return true, nil
+	case FormatConsole:
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid log format %q", format)
+}
+
+// switchingCore is a zapcore.Core that writes through the core for the
+// current format of its Switch. Fields are attached to the cores of both
+// formats, so that they survive a change of format.
+type switchingCore struct {
+	s       *Switch
+	json    zapcore.Core
+	console zapcore.Core
+}
+
+func (c *switchingCore) active() zapcore.Core {
+	if c.s.json.Load() {
+		return c.json
+	}
+	return c.console
+}
+
+func (c *switchingCore) Enabled(level zapcore.Level) bool {
+	return c.s.level.Enabled(level)
+}
+
+func (c *switchingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &switchingCore{s: c.s, json: c.json.With(fields), console: c.console.With(fields)}
+}
+
+func (c *switchingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return c.active().Check(ent, ce)
+}
+
+func (c *switchingCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.active().Write(ent, fields)
+}
+
+func (c *switchingCore) Sync() error {
+	return c.active().Sync()
+}
@@ -0,0 +1,128 @@
+package logswitch
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var _ = Describe("Switch", func() {
+	var (
+		buf    *bytes.Buffer
+		sw     *Switch
+		logger *zap.Logger
+	)
+
+	BeforeEach(func() {
+		var err error
+		buf = &bytes.Buffer{}
+		sw, err = New(zap.NewAtomicLevelAt(zapcore.InfoLevel), FormatConsole)
+		Expect(err).ToNot(HaveOccurred())
+		logger = zap.New(sw.Core(zapcore.AddSync(buf), nil, false)).With(zap.String("controller", "test"))
+	})
+
+	It("Should change the verbosity of the output with the level", func() {
+		logger.Debug("hidden")
+		Expect(buf.String()).To(BeEmpty())
+
+		Expect(sw.SetLevel("debug")).To(Succeed())
+		logger.Debug("shown")
+		Expect(buf.String()).To(ContainSubstring("shown"))
+
+		// Verbosity levels beyond debug, as used by logr's V()
+		buf.Reset()
+		logger.Log(zapcore.Level(-3), "very verbose")
+		Expect(buf.String()).To(BeEmpty())
+		Expect(sw.SetLevel("3")).To(Succeed())
+		Expect(sw.LevelString()).To(Equal("3"))
+		logger.Log(zapcore.Level(-3), "very verbose")
+		Expect(buf.String()).To(ContainSubstring("very verbose"))
+
+		buf.Reset()
+		Expect(sw.SetLevel("error")).To(Succeed())
+		logger.Info("hidden")
+		Expect(buf.String()).To(BeEmpty())
+
+		Expect(sw.SetLevel("loud")).ToNot(Succeed())
+		Expect(sw.LevelString()).To(Equal("error"))
+	})
+
+	It("Should change the format of the output, keeping attached fields", func() {
+		logger.Info("console")
+		Expect(buf.String()).ToNot(HavePrefix("{"))
+		Expect(buf.String()).To(ContainSubstring(`{"controller": "test"}`))
+
+		buf.Reset()
+		Expect(sw.SetFormat(FormatJSON)).To(Succeed())
+		logger.Info("json")
+		Expect(buf.String()).To(HavePrefix("{"))
+		Expect(buf.String()).To(ContainSubstring(`"controller":"test"`))
+
+		Expect(sw.SetFormat("xml")).ToNot(Succeed())
+		Expect(sw.Format()).To(Equal(FormatJSON))
+	})
+
+	It("Should be safe to switch while logging", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					logger.Debug("racing")
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					_ = sw.SetLevel([]string{"debug", "info"}[j%2])
+					_ = sw.SetFormat([]string{FormatJSON, FormatConsole}[j%2])
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	Context("Handler()", func() {
+		serve := func(method string, body string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(method, "/log", strings.NewReader(body))
+			sw.Handler().ServeHTTP(rec, req)
+			return rec
+		}
+
+		It("Should return the current settings", func() {
+			rec := serve(http.MethodGet, "")
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(MatchJSON(`{"level": "info", "format": "console"}`))
+		})
+
+		It("Should apply the supplied settings", func() {
+			rec := serve(http.MethodPut, `{"level": "debug"}`)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(MatchJSON(`{"level": "debug", "format": "console"}`))
+
+			logger.Debug("shown")
+			Expect(buf.String()).To(ContainSubstring("shown"))
+		})
+
+		It("Should change nothing unless all of the settings are valid", func() {
+			rec := serve(http.MethodPut, `{"level": "debug", "format": "xml"}`)
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(sw.LevelString()).To(Equal("info"))
+			Expect(sw.Format()).To(Equal(FormatConsole))
+		})
+
+		It("Should reject other methods", func() {
+			Expect(serve(http.MethodPost, `{}`).Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
@@ -0,0 +1,10 @@
+// Package notify delivers notifications about Access Requests to the users
+// involved with them - for example, telling a requester that their access has
+// been granted, or is about to expire.
+//
+// A Notifier delivers a single Notification to an external system (such as a
+// chat bot relay listening on a webhook). The RequesterNotifier builds on top
+// of a Notifier to target the requester of an Access Request directly, using a
+// per-user destination (an email address, a Slack member ID, etc) resolved
+// from an identity mapping.
+package notify
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"time"
+)
+
+// Event describes what happened to an Access Request that a user is being
+// notified about.
+type Event string
+
+const (
+	// EventGranted is sent once the access resources of a request are ready.
+	EventGranted Event = "granted"
+
+	// EventDenied is sent when a request is rejected, and no access will be
+	// granted for it.
+	EventDenied Event = "denied"
+
+	// EventExpiring is sent when a granted request is about to expire.
+	EventExpiring Event = "expiring"
+
+	// EventRevoked is sent when a user revokes a request before it expired.
+	EventRevoked Event = "revoked"
+)
+
+// Notification is a single message about an Access Request, addressed to a
+// single destination.
+type Notification struct {
+	// Event is what happened to the Access Request.
+	Event Event `json:"event"`
+
+	// Destination is where the notification should be delivered, eg an email
+	// address or a Slack member ID. Its format is up to the receiving system.
+	Destination string `json:"destination"`
+
+	// User is the Kubernetes identity of the user being notified.
+	User string `json:"user"`
+
+	// Kind, Name and Namespace identify the Access Request.
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// Message is a human readable description of the event.
+	Message string `json:"message"`
+
+	// Time is when the notification was generated.
+	Time time.Time `json:"time"`
+}
+
+// Notifier is implemented by anything that can deliver a Notification.
+type Notifier interface {
+	// Name returns a short description of the notifier for logging.
+	Name() string
+
+	// Notify delivers the notification, returning an error if it could not
+	// be delivered.
+	Notify(ctx context.Context, n Notification) error
+}
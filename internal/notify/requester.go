@@ -0,0 +1,113 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// DefaultExpiringWithin is how long before a request expires that its
+// requester is warned about it, unless configured otherwise.
+const DefaultExpiringWithin = 15 * time.Minute
+
+// RequesterNotifier sends notifications about an Access Request directly to
+// the user that created it.
+//
+// The destination for a user is looked up in Destinations first, which is
+// managed by the operator of the controller and maps Kubernetes usernames to
+// destinations. Failing that, the requester may supply their own destination
+// with the v1alpha1.NotifyDestinationAnnotation on the request. Requesters
+// without a destination are simply not notified.
+type RequesterNotifier struct {
+	// Notifier delivers the notifications.
+	Notifier Notifier
+
+	// Destinations maps Kubernetes usernames to their notification
+	// destinations.
+	Destinations map[string]string
+
+	// ExpiringWithin is how long before a request expires that the
+	// EventExpiring notification is sent. Defaults to DefaultExpiringWithin.
+	ExpiringWithin time.Duration
+
+	// now is swapped out in tests
+	now func() time.Time
+}
+
+// GetExpiringWithin returns the ExpiringWithin setting, or its default.
+func (n *RequesterNotifier) GetExpiringWithin() time.Duration {
+	if n.ExpiringWithin > 0 {
+		return n.ExpiringWithin
+	}
+	return DefaultExpiringWithin
+}
+
+// Resolve returns the notification destination of the requester of req, and
+// whether one was found at all.
+func (n *RequesterNotifier) Resolve(req v1alpha1.IRequestResource) (string, bool) {
+	user := v1alpha1.GetRequester(req)
+	if dest := strings.TrimSpace(n.Destinations[user]); user != "" && dest != "" {
+		return dest, true
+	}
+	if dest := strings.TrimSpace(req.GetAnnotations()[v1alpha1.NotifyDestinationAnnotation]); dest != "" {
+		return dest, true
+	}
+	return "", false
+}
+
+// NotifyRequester builds the Notification for the event and delivers it to the
+// requester of req.
+//
+// Returns:
+//   - sent: true if the notification was delivered, false if it failed or the
+//     requester has no destination to deliver it to
+//   - err: any error encountered while delivering the notification
+func (n *RequesterNotifier) NotifyRequester(
+	ctx context.Context,
+	req v1alpha1.IRequestResource,
+	event Event,
+	message string,
+) (sent bool, err error) {
+	dest, ok := n.Resolve(req)
+	if !ok {
+		return false, nil
+	}
+
+	now := time.Now
+	if n.now != nil {
+		now = n.now
+	}
+	notification := Notification{
+		Event:       event,
+		Destination: dest,
+		User:        v1alpha1.GetRequester(req),
+		Kind:        reflect.TypeOf(req).Elem().Name(),
+		Name:        req.GetName(),
+		Namespace:   req.GetNamespace(),
+		Message:     message,
+		Time:        now().UTC(),
+	}
+	if err := n.Notifier.Notify(ctx, notification); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LoadDestinations parses a YAML (or JSON) document mapping Kubernetes
+// usernames to their notification destinations, eg:
+//
+//	alice@example.com: alice@example.com
+//	bob: U024BE7LH
+func LoadDestinations(data []byte) (map[string]string, error) {
+	destinations := map[string]string{}
+	if err := yaml.Unmarshal(data, &destinations); err != nil {
+		return nil, fmt.Errorf("invalid notification destinations: %w", err)
+	}
+	return destinations, nil
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// fakeNotifier records every notification delivered to it, and can be told to
+// fail.
+type fakeNotifier struct {
+	notifications []Notification
+	err           error
+}
+
+func (n *fakeNotifier) Name() string { return "fake" }
+
+func (n *fakeNotifier) Notify(_ context.Context, notification Notification) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+var _ = Describe("RequesterNotifier", func() {
+	var (
+		ctx      = context.Background()
+		fake     *fakeNotifier
+		notifier *RequesterNotifier
+	)
+
+	newRequest := func(annotations map[string]string) *v1alpha1.PodAccessRequest {
+		return &v1alpha1.PodAccessRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "my-request",
+				Namespace:   "default",
+				Annotations: annotations,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		fake = &fakeNotifier{}
+		notifier = &RequesterNotifier{
+			Notifier:     fake,
+			Destinations: map[string]string{"alice": "U024BE7LH"},
+			now:          func() time.Time { return time.Date(2023, 5, 1, 12, 0, 0, 0, time.FixedZone("PDT", -7*3600)) },
+		}
+	})
+
+	Context("NotifyRequester()", func() {
+		It("Should address the notification to the destination of the requester", func() {
+			req := newRequest(map[string]string{v1alpha1.RequestedByAnnotation: "alice"})
+
+			sent, err := notifier.NotifyRequester(ctx, req, EventGranted, "Access granted")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sent).To(BeTrue())
+			Expect(fake.notifications).To(Equal([]Notification{{
+				Event:       EventGranted,
+				Destination: "U024BE7LH",
+				User:        "alice",
+				Kind:        "PodAccessRequest",
+				Name:        "my-request",
+				Namespace:   "default",
+				Message:     "Access granted",
+				Time:        time.Date(2023, 5, 1, 19, 0, 0, 0, time.UTC),
+			}}))
+		})
+
+		It("Should fall back to the destination annotation on the request", func() {
+			req := newRequest(map[string]string{
+				v1alpha1.RequestedByAnnotation:       "bob",
+				v1alpha1.NotifyDestinationAnnotation: " bob@example.com ",
+			})
+
+			sent, err := notifier.NotifyRequester(ctx, req, EventRevoked, "Access revoked by alice")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sent).To(BeTrue())
+			Expect(fake.notifications).To(HaveLen(1))
+			Expect(fake.notifications[0].Destination).To(Equal("bob@example.com"))
+			Expect(fake.notifications[0].User).To(Equal("bob"))
+			Expect(fake.notifications[0].Event).To(Equal(EventRevoked))
+		})
+
+		It("Should prefer the configured destination over the annotation", func() {
+			req := newRequest(map[string]string{
+				v1alpha1.RequestedByAnnotation:       "alice",
+				v1alpha1.NotifyDestinationAnnotation: "mallory@example.com",
+			})
+
+			_, err := notifier.NotifyRequester(ctx, req, EventExpiring, "Access expires soon")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fake.notifications[0].Destination).To(Equal("U024BE7LH"))
+		})
+
+		It("Should skip requesters without a destination", func() {
+			req := newRequest(map[string]string{v1alpha1.RequestedByAnnotation: "carol"})
+
+			sent, err := notifier.NotifyRequester(ctx, req, EventDenied, "Access denied")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sent).To(BeFalse())
+			Expect(fake.notifications).To(BeEmpty())
+		})
+
+		It("Should return delivery errors", func() {
+			fake.err = errors.New("unavailable")
+			req := newRequest(map[string]string{v1alpha1.RequestedByAnnotation: "alice"})
+
+			sent, err := notifier.NotifyRequester(ctx, req, EventGranted, "Access granted")
+			Expect(err).To(MatchError("unavailable"))
+			Expect(sent).To(BeFalse())
+		})
+	})
+
+	Context("GetExpiringWithin()", func() {
+		It("Should default to DefaultExpiringWithin", func() {
+			Expect(notifier.GetExpiringWithin()).To(Equal(DefaultExpiringWithin))
+			notifier.ExpiringWithin = time.Minute
+			Expect(notifier.GetExpiringWithin()).To(Equal(time.Minute))
+		})
+	})
+
+	Context("LoadDestinations()", func() {
+		It("Should parse a YAML mapping of users to destinations", func() {
+			destinations, err := LoadDestinations([]byte("alice: U024BE7LH\nbob@example.com: bob@example.com\n"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(destinations).To(Equal(map[string]string{
+				"alice":           "U024BE7LH",
+				"bob@example.com": "bob@example.com",
+			}))
+		})
+
+		It("Should reject anything else", func() {
+			_, err := LoadDestinations([]byte("- alice\n- bob\n"))
+			Expect(err).To(MatchError(ContainSubstring("invalid notification destinations")))
+		})
+	})
+})
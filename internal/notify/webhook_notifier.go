@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// WebhookNotifier delivers each Notification as a JSON document POSTed to a
+// URL. The receiving service is responsible for routing the notification to
+// its Destination, for example by sending a Slack direct message or an email.
+type WebhookNotifier struct {
+	// URL that the notifications are POSTed to.
+	URL string
+
+	// Client is used to make the requests. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+var _ Notifier = &WebhookNotifier{}
+
+// Name conforms to the Notifier interface. Only the host of the URL is
+// returned, in case the rest of it carries a secret token.
+func (n *WebhookNotifier) Name() string {
+	host := n.URL
+	if u, err := url.Parse(n.URL); err == nil {
+		host = u.Host
+	}
+	return fmt.Sprintf("webhook://%s", host)
+}
+
+// Notify conforms to the Notifier interface.
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return fmt.Errorf("notification to %s failed with %s: %s", n.Name(), resp.Status, respBody)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("WebhookNotifier", func() {
+	var (
+		ctx      = context.Background()
+		server   *httptest.Server
+		requests []*http.Request
+		bodies   []string
+		status   int
+		notifier *WebhookNotifier
+	)
+
+	notification := Notification{
+		Event:       EventGranted,
+		Destination: "U024BE7LH",
+		User:        "alice",
+		Kind:        "ExecAccessRequest",
+		Name:        "my-request",
+		Namespace:   "default",
+		Message:     "Access granted",
+		Time:        time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	BeforeEach(func() {
+		requests, bodies, status = nil, nil, http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r)
+			bodies = append(bodies, string(body))
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("nope"))
+		}))
+		DeferCleanup(server.Close)
+		notifier = &WebhookNotifier{URL: server.URL + "/hooks/secret-token"}
+	})
+
+	It("Should POST the notification as JSON", func() {
+		Expect(notifier.Notify(ctx, notification)).To(Succeed())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Method).To(Equal(http.MethodPost))
+		Expect(requests[0].URL.Path).To(Equal("/hooks/secret-token"))
+		Expect(requests[0].Header.Get("Content-Type")).To(Equal("application/json"))
+		Expect(bodies[0]).To(MatchJSON(`{
+			"event": "granted",
+			"destination": "U024BE7LH",
+			"user": "alice",
+			"kind": "ExecAccessRequest",
+			"name": "my-request",
+			"namespace": "default",
+			"message": "Access granted",
+			"time": "2023-05-01T12:00:00Z"
+		}`))
+	})
+
+	It("Should return an error on a failed delivery", func() {
+		status = http.StatusBadGateway
+		err := notifier.Notify(ctx, notification)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("502 Bad Gateway: nope"))
+	})
+
+	It("Should not include the path of the URL in its name", func() {
+		Expect(notifier.Name()).To(Equal("webhook://" + strings.TrimPrefix(server.URL, "http://")))
+	})
+})
@@ -0,0 +1,10 @@
+// Package opstate keeps the operational state of the controller - state that
+// must outlive a single reconcile, and survive a restart or a leader change of
+// the controller (eg, which Pods the leastRecentlyUsed Pod selection strategy
+// handed out last) - in ConfigMaps in a single namespace.
+//
+// The namespace defaults to the one the controller runs in (see
+// DefaultNamespace), where the leader election Role already allows the
+// controller to manage ConfigMaps. Any other namespace must grant the
+// controller get, create and update on ConfigMaps.
+package opstate
@@ -0,0 +1,106 @@
+package opstate
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConfigMapPrefix prefixes the names of the ConfigMaps that hold the state.
+const ConfigMapPrefix = "oz-state-"
+
+// ComponentLabel is set on the ConfigMaps that hold the state, so that they
+// can be told apart from the other ConfigMaps of the namespace.
+const ComponentLabel = "app.kubernetes.io/component"
+
+// componentLabelValue is the value of the ComponentLabel.
+const componentLabelValue = "oz-state"
+
+// FallbackNamespace is the namespace returned by DefaultNamespace() when the
+// namespace of the controller can not be determined.
+const FallbackNamespace = "oz-system"
+
+// serviceAccountNamespaceFile holds the namespace of the Pod that the
+// controller runs in.
+var serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// DefaultNamespace returns the namespace that the controller runs in: the
+// POD_NAMESPACE environment variable (eg, set through the downward API), or
+// the namespace of its ServiceAccount. If neither is available (eg, when run
+// outside of the cluster), FallbackNamespace is returned.
+func DefaultNamespace() string {
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		return ns
+	}
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		if ns := strings.TrimSpace(string(data)); ns != "" {
+			return ns
+		}
+	}
+	return FallbackNamespace
+}
+
+// Store reads and writes the operational state of the controller. Each kind
+// of state lives in its own ConfigMap (named by ConfigMapPrefix and the name
+// of the state), which is created on the first write.
+//
+// The Client should not be backed by the cache of the manager, which would
+// otherwise watch every ConfigMap in the cluster.
+type Store struct {
+	Client    client.Client
+	Namespace string
+}
+
+// Get returns the value stored under the key of the named state, and whether
+// there is one at all.
+func (s *Store) Get(ctx context.Context, name string, key string) (string, bool, error) {
+	cm := &corev1.ConfigMap{}
+	if err := s.Client.Get(ctx, s.key(name), cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	value, ok := cm.Data[key]
+	return value, ok, nil
+}
+
+// Set stores the value under the key of the named state, creating the
+// ConfigMap that holds the state if it does not exist yet.
+func (s *Store) Set(ctx context.Context, name string, key string, value string) error {
+	cm := &corev1.ConfigMap{}
+	err := s.Client.Get(ctx, s.key(name), cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.key(name).Name,
+				Namespace: s.Namespace,
+				Labels:    map[string]string{ComponentLabel: componentLabelValue},
+			},
+			Data: map[string]string{key: value},
+		}
+		return s.Client.Create(ctx, cm)
+	}
+	if err != nil {
+		return err
+	}
+	if current, ok := cm.Data[key]; ok && current == value {
+		return nil
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[key] = value
+	return s.Client.Update(ctx, cm)
+}
+
+// key returns the name of the ConfigMap that holds the named state.
+func (s *Store) key(name string) types.NamespacedName {
+	return types.NamespacedName{Name: ConfigMapPrefix + name, Namespace: s.Namespace}
+}
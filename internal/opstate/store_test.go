@@ -0,0 +1,92 @@
+package opstate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("Store", func() {
+	var (
+		ctx   = context.Background()
+		store *Store
+	)
+
+	BeforeEach(func() {
+		store = &Store{
+			Client:    fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+			Namespace: "oz-state",
+		}
+	})
+
+	It("Should not find a value before the state exists", func() {
+		_, ok, err := store.Get(ctx, "test", "key")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Should create the state in its namespace on the first write", func() {
+		Expect(store.Set(ctx, "test", "key", "value")).To(Succeed())
+
+		cm := &corev1.ConfigMap{}
+		Expect(store.Client.Get(ctx, types.NamespacedName{
+			Name:      "oz-state-test",
+			Namespace: "oz-state",
+		}, cm)).To(Succeed())
+		Expect(cm.GetLabels()).To(HaveKeyWithValue(ComponentLabel, "oz-state"))
+		Expect(cm.Data).To(Equal(map[string]string{"key": "value"}))
+
+		value, ok, err := store.Get(ctx, "test", "key")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeTrue())
+		Expect(value).To(Equal("value"))
+	})
+
+	It("Should update existing state, keeping the other keys", func() {
+		Expect(store.Set(ctx, "test", "key", "value")).To(Succeed())
+		Expect(store.Set(ctx, "test", "other", "value")).To(Succeed())
+		Expect(store.Set(ctx, "test", "key", "updated")).To(Succeed())
+
+		value, _, err := store.Get(ctx, "test", "key")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("updated"))
+		value, _, err = store.Get(ctx, "test", "other")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("value"))
+	})
+})
+
+var _ = Describe("DefaultNamespace", func() {
+	var original string
+
+	BeforeEach(func() {
+		original = serviceAccountNamespaceFile
+		serviceAccountNamespaceFile = filepath.Join(GinkgoT().TempDir(), "namespace")
+		DeferCleanup(func() { serviceAccountNamespaceFile = original })
+	})
+
+	It("Should prefer the POD_NAMESPACE environment variable", func() {
+		GinkgoT().Setenv("POD_NAMESPACE", "from-env")
+		Expect(os.WriteFile(serviceAccountNamespaceFile, []byte("from-file\n"), 0o600)).To(Succeed())
+		Expect(DefaultNamespace()).To(Equal("from-env"))
+	})
+
+	It("Should fall back to the namespace of the ServiceAccount", func() {
+		GinkgoT().Setenv("POD_NAMESPACE", "")
+		Expect(os.WriteFile(serviceAccountNamespaceFile, []byte("from-file\n"), 0o600)).To(Succeed())
+		Expect(DefaultNamespace()).To(Equal("from-file"))
+	})
+
+	It("Should fall back to the FallbackNamespace", func() {
+		GinkgoT().Setenv("POD_NAMESPACE", "")
+		Expect(DefaultNamespace()).To(Equal(FallbackNamespace))
+	})
+})
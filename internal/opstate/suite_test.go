@@ -0,0 +1,13 @@
+package opstate
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestOpState(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "OpState Suite")
+}
@@ -0,0 +1,7 @@
+// Package version holds the build information of the oz binaries - the
+// controller and ozctl - and the versions of the CRD API that they speak.
+//
+// The controller serves its build information over HTTP (see Handler), so
+// that ozctl can compare it with its own and warn about a version skew
+// between the two (see Skew).
+package version
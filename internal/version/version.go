@@ -0,0 +1,104 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/diranged/oz/internal/api/v1alpha1"
+)
+
+// Build information, filled in at release time through -ldflags, for example:
+//
+//	-X github.com/diranged/oz/internal/version.version=1.2.3
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// devVersion is the version of builds that were not made by a release.
+const devVersion = "dev"
+
+// APIVersions are the versions of the CRD API that this build serves (in the
+// controller) or understands (in ozctl).
+var APIVersions = []string{v1alpha1.GroupVersion.String()}
+
+// Info is the build information of an oz binary.
+type Info struct {
+	Version     string   `json:"version"`
+	Commit      string   `json:"commit"`
+	Date        string   `json:"date"`
+	GoVersion   string   `json:"goVersion"`
+	Platform    string   `json:"platform"`
+	APIVersions []string `json:"apiVersions"`
+}
+
+// Get returns the build information of this binary.
+func Get() Info {
+	return Info{
+		Version:     version,
+		Commit:      commit,
+		Date:        date,
+		GoVersion:   runtime.Version(),
+		Platform:    fmt.Sprintf("%s/%s", runtime.GOOS, runtime.GOARCH),
+		APIVersions: APIVersions,
+	}
+}
+
+// Handler returns an http.Handler that serves the build information of this
+// binary as JSON.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, httpReq *http.Request) {
+		if httpReq.Method != http.MethodGet {
+			http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Get())
+	})
+}
+
+// Skew compares the build information of ozctl (the client) with that of the
+// controller, and describes every way in which they are incompatible: a
+// different major or minor version, or a CRD API version that the client
+// speaks but the controller does not serve. Development builds are never
+// considered skewed by version.
+func Skew(client Info, controller Info) []string {
+	var skew []string
+	clientMinor, clientOK := minorVersion(client.Version)
+	controllerMinor, controllerOK := minorVersion(controller.Version)
+	if clientOK && controllerOK && clientMinor != controllerMinor {
+		skew = append(skew, fmt.Sprintf(
+			"ozctl %s and the controller %s are different releases, some features may not work",
+			client.Version, controller.Version,
+		))
+	}
+
+	served := map[string]bool{}
+	for _, v := range controller.APIVersions {
+		served[v] = true
+	}
+	for _, v := range client.APIVersions {
+		if !served[v] {
+			skew = append(skew, fmt.Sprintf("the controller does not serve %s, which ozctl uses", v))
+		}
+	}
+	return skew
+}
+
+// minorVersion returns the "major.minor" part of the supplied version (with
+// or without a leading "v"), or false if it is a development build or not a
+// semantic version at all.
+func minorVersion(v string) (string, bool) {
+	if v == "" || v == devVersion {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) < 2 {
+		return "", false
+	}
+	return parts[0] + "." + parts[1], true
+}
@@ -0,0 +1,69 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version", func() {
+	Context("Handler()", func() {
+		It("Should serve the build information as JSON", func() {
+			rec := httptest.NewRecorder()
+			Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+
+			info := Info{}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &info)).To(Succeed())
+			Expect(info).To(Equal(Get()))
+			Expect(info.APIVersions).To(Equal([]string{"crds.wizardofoz.co/v1alpha1"}))
+		})
+
+		It("Should reject anything but GET", func() {
+			rec := httptest.NewRecorder()
+			Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/version", nil))
+			Expect(rec.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+
+	Context("Skew()", func() {
+		info := func(version string, apiVersions ...string) Info {
+			return Info{Version: version, APIVersions: apiVersions}
+		}
+
+		It("Should find no skew between patch releases", func() {
+			Expect(Skew(
+				info("v1.2.3", "crds.wizardofoz.co/v1alpha1"),
+				info("1.2.0", "crds.wizardofoz.co/v1alpha1"),
+			)).To(BeEmpty())
+		})
+
+		It("Should warn about different minor or major releases", func() {
+			Expect(Skew(
+				info("v1.3.0", "crds.wizardofoz.co/v1alpha1"),
+				info("1.2.0", "crds.wizardofoz.co/v1alpha1"),
+			)).To(Equal([]string{
+				"ozctl v1.3.0 and the controller 1.2.0 are different releases, some features may not work",
+			}))
+			Expect(Skew(info("v2.0.0"), info("v1.0.0"))).To(HaveLen(1))
+		})
+
+		It("Should never consider development builds skewed by version", func() {
+			Expect(Skew(info("dev"), info("v1.2.0"))).To(BeEmpty())
+			Expect(Skew(info("v1.2.0"), info("dev"))).To(BeEmpty())
+		})
+
+		It("Should warn about API versions that the controller does not serve", func() {
+			Expect(Skew(
+				info("v1.2.0", "crds.wizardofoz.co/v1alpha1", "crds.wizardofoz.co/v1beta1"),
+				info("v1.2.0", "crds.wizardofoz.co/v1alpha1"),
+			)).To(Equal([]string{
+				"the controller does not serve crds.wizardofoz.co/v1beta1, which ozctl uses",
+			}))
+		})
+	})
+})